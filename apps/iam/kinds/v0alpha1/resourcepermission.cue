@@ -16,6 +16,20 @@ ResourcePermission: {
 		name: string
 		// action set granted to the user (e.g. "admin" or "edit", "view")
 		verb: string
+		// restricts the permission with attributes of the request, only supported for folders
+		condition?: #Condition
+	}
+	#Condition: {
+		// labels the requested resource must have
+		matchLabels?: [string]: string
+		// labels the requested resource must not have
+		excludeLabels?: [string]: string
+		// start of the time window the permission applies in (RFC 3339)
+		notBefore?: string
+		// end of the time window the permission applies in (RFC 3339)
+		notAfter?: string
+		// networks the client IP must belong to
+		sourceCIDRs?: [...string]
 	}
 	
 	resource: #Resource
@@ -25,6 +25,8 @@ type ResourcePermissionspecPermission struct {
 	Name string `json:"name"`
 	// action set granted to the user (e.g. "admin" or "edit", "view")
 	Verb string `json:"verb"`
+	// restricts the permission with attributes of the request, only supported for folders
+	Condition *ResourcePermissionspecCondition `json:"condition,omitempty"`
 }
 
 // NewResourcePermissionspecPermission creates a new ResourcePermissionspecPermission object.
@@ -32,6 +34,25 @@ func NewResourcePermissionspecPermission() *ResourcePermissionspecPermission {
 	return &ResourcePermissionspecPermission{}
 }
 
+// +k8s:openapi-gen=true
+type ResourcePermissionspecCondition struct {
+	// labels the requested resource must have
+	MatchLabels map[string]string `json:"matchLabels,omitempty"`
+	// labels the requested resource must not have
+	ExcludeLabels map[string]string `json:"excludeLabels,omitempty"`
+	// start of the time window the permission applies in (RFC 3339)
+	NotBefore *string `json:"notBefore,omitempty"`
+	// end of the time window the permission applies in (RFC 3339)
+	NotAfter *string `json:"notAfter,omitempty"`
+	// networks the client IP must belong to
+	SourceCIDRs []string `json:"sourceCIDRs,omitempty"`
+}
+
+// NewResourcePermissionspecCondition creates a new ResourcePermissionspecCondition object.
+func NewResourcePermissionspecCondition() *ResourcePermissionspecCondition {
+	return &ResourcePermissionspecCondition{}
+}
+
 // +k8s:openapi-gen=true
 type ResourcePermissionSpec struct {
 	Resource    ResourcePermissionspecResource     `json:"resource"`
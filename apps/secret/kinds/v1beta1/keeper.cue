@@ -49,7 +49,35 @@ KeeperSpec: {
 
 #HashiCorpConfig: {
 	address: string
-	token:   #CredentialValue
+
+	// The Vault Enterprise namespace the secrets engine lives in.
+	// +optional
+	namespace?: string
+
+	// The mount path of the KV version 2 secrets engine. Defaults to `secret`.
+	// +optional
+	mountPath?: string
+
+	// The path under which secure values are stored in the secrets engine. Defaults to `grafana`.
+	// +optional
+	pathPrefix?: string
+
+	// Authenticate with a Vault token. Either `token` or `appRole` must be present.
+	// +optional
+	token?: #CredentialValue
+
+	// Authenticate with the AppRole auth method. Either `token` or `appRole` must be present.
+	// +optional
+	appRole?: #HashiCorpAppRoleConfig
+}
+
+#HashiCorpAppRoleConfig: {
+	roleID:   string
+	secretID: #CredentialValue
+
+	// The mount path of the AppRole auth method. Defaults to `approle`.
+	// +optional
+	mountPath?: string
 }
 
 #CredentialValue: {
@@ -64,14 +64,42 @@ func NewKeeperGCPConfig() *KeeperGCPConfig {
 
 // +k8s:openapi-gen=true
 type KeeperHashiCorpConfig struct {
-	Address string                `json:"address"`
-	Token   KeeperCredentialValue `json:"token"`
+	Address string `json:"address"`
+	// The Vault Enterprise namespace the secrets engine lives in.
+	// +optional
+	Namespace *string `json:"namespace,omitempty"`
+	// The mount path of the KV version 2 secrets engine. Defaults to `secret`.
+	// +optional
+	MountPath *string `json:"mountPath,omitempty"`
+	// The path under which secure values are stored in the secrets engine. Defaults to `grafana`.
+	// +optional
+	PathPrefix *string `json:"pathPrefix,omitempty"`
+	// Authenticate with a Vault token. Either `token` or `appRole` must be present.
+	// +optional
+	Token *KeeperCredentialValue `json:"token,omitempty"`
+	// Authenticate with the AppRole auth method. Either `token` or `appRole` must be present.
+	// +optional
+	AppRole *KeeperHashiCorpAppRoleConfig `json:"appRole,omitempty"`
 }
 
 // NewKeeperHashiCorpConfig creates a new KeeperHashiCorpConfig object.
 func NewKeeperHashiCorpConfig() *KeeperHashiCorpConfig {
-	return &KeeperHashiCorpConfig{
-		Token: *NewKeeperCredentialValue(),
+	return &KeeperHashiCorpConfig{}
+}
+
+// +k8s:openapi-gen=true
+type KeeperHashiCorpAppRoleConfig struct {
+	RoleID   string                `json:"roleID"`
+	SecretID KeeperCredentialValue `json:"secretID"`
+	// The mount path of the AppRole auth method. Defaults to `approle`.
+	// +optional
+	MountPath *string `json:"mountPath,omitempty"`
+}
+
+// NewKeeperHashiCorpAppRoleConfig creates a new KeeperHashiCorpAppRoleConfig object.
+func NewKeeperHashiCorpAppRoleConfig() *KeeperHashiCorpAppRoleConfig {
+	return &KeeperHashiCorpAppRoleConfig{
+		SecretID: *NewKeeperCredentialValue(),
 	}
 }
 
@@ -46,4 +46,8 @@ export interface Prometheus extends common.DataQuery {
   scopes?: Array<ScopeSpec & Pick<Scope['metadata'], 'name'>>;
   adhocFilters?: ScopeSpecFilter[];
   groupByKeys?: string[];
+  /**
+   * Returns native histograms as a time series of this quantile (between 0 and 1) instead of heatmap cells
+   */
+  histogramQuantile?: number;
 }
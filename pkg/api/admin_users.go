@@ -163,6 +163,18 @@ func (hs *HTTPServer) AdminUpdateUserPermissions(c *contextmodel.ReqContext) res
 		}
 	}
 
+	wasGrafanaAdmin := false
+	if !form.IsGrafanaAdmin {
+		usr, err := hs.userService.GetByID(c.Req.Context(), &user.GetUserByIDQuery{ID: userID})
+		if err != nil {
+			if errors.Is(err, user.ErrUserNotFound) {
+				return response.Error(http.StatusNotFound, user.ErrUserNotFound.Error(), nil)
+			}
+			return response.Error(http.StatusInternalServerError, "Could not read user from database", err)
+		}
+		wasGrafanaAdmin = usr.IsAdmin
+	}
+
 	err = hs.userService.Update(c.Req.Context(), &user.UpdateUserCommand{
 		UserID:         userID,
 		IsGrafanaAdmin: &form.IsGrafanaAdmin,
@@ -175,6 +187,14 @@ func (hs *HTTPServer) AdminUpdateUserPermissions(c *contextmodel.ReqContext) res
 		return response.Error(http.StatusInternalServerError, "Failed to update user permissions", err)
 	}
 
+	// Sign the user out everywhere when revoking Grafana Admin, so no session outlives the access it was opened with
+	if wasGrafanaAdmin {
+		if err := hs.AuthTokenService.RevokeAllUserTokens(c.Req.Context(), userID); err != nil {
+			return response.Error(http.StatusExpectationFailed,
+				"User permissions updated but unable to revoke user sessions", err)
+		}
+	}
+
 	return response.Success("User permissions updated")
 }
 
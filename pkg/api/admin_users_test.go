@@ -1,6 +1,7 @@
 package api
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"testing"
@@ -42,7 +43,12 @@ func TestAdminAPIEndpoint(t *testing.T) {
 		updateCmd := dtos.AdminUpdateUserPermissionsForm{
 			IsGrafanaAdmin: false,
 		}
-		userService := usertest.FakeUserService{ExpectedError: user.ErrLastGrafanaAdmin}
+		userService := usertest.FakeUserService{
+			ExpectedUser: &user.User{ID: 1, IsAdmin: true},
+			UpdateFn: func(ctx context.Context, cmd *user.UpdateUserCommand) error {
+				return user.ErrLastGrafanaAdmin
+			},
+		}
 		putAdminScenario(t, "When calling PUT on", "/api/admin/users/1/permissions",
 			"/api/admin/users/:id/permissions", role, updateCmd, func(sc *scenarioContext) {
 				sc.fakeReqWithParams("PUT", sc.url, map[string]string{}).exec()
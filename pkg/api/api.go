@@ -223,6 +223,16 @@ func (hs *HTTPServer) registerRoutes() {
 		r.Post("/api/login/passwordless/authenticate", requestmeta.SetOwner(requestmeta.TeamAuth), quota(string(auth.QuotaTargetSrv)), routing.Wrap(hs.LoginPasswordless))
 	}
 
+	if hs.Cfg.TwoFactorAuth.Enabled {
+		r.Post("/api/login/two-factor", requestmeta.SetOwner(requestmeta.TeamAuth), quota(string(auth.QuotaTargetSrv)), routing.Wrap(hs.LoginTwoFactor))
+		r.Post("/api/login/two-factor/enroll", requestmeta.SetOwner(requestmeta.TeamAuth), routing.Wrap(hs.StartTwoFactorEnrollment))
+	}
+
+	if hs.Cfg.WebAuthn.Enabled {
+		r.Post("/api/login/webauthn/begin", requestmeta.SetOwner(requestmeta.TeamAuth), routing.Wrap(hs.StartWebAuthnLogin))
+		r.Post("/api/login/webauthn", requestmeta.SetOwner(requestmeta.TeamAuth), quota(string(auth.QuotaTargetSrv)), routing.Wrap(hs.LoginWebAuthn))
+	}
+
 	// invited
 	r.Get("/api/user/invite/:code", routing.Wrap(hs.GetInviteInfoByCode))
 	r.Post("/api/user/invite/complete", routing.Wrap(hs.CompleteInvite))
@@ -291,6 +301,7 @@ func (hs *HTTPServer) registerRoutes() {
 
 			userRoute.Get("/auth-tokens", requestmeta.SetOwner(requestmeta.TeamAuth), routing.Wrap(hs.GetUserAuthTokens))
 			userRoute.Post("/revoke-auth-token", requestmeta.SetOwner(requestmeta.TeamAuth), routing.Wrap(hs.RevokeUserAuthToken))
+			userRoute.Post("/revoke-other-auth-tokens", requestmeta.SetOwner(requestmeta.TeamAuth), routing.Wrap(hs.RevokeOtherUserAuthTokens))
 		}, reqSignedInNoAnonymous)
 
 		apiRoute.Group("/users", func(usersRoute routing.RouteRegister) {
@@ -545,6 +556,9 @@ func (hs *HTTPServer) registerRoutes() {
 		}, requestmeta.SetSLOGroup(requestmeta.SLOGroupNone))
 	}, reqSignedIn)
 
+	// POST influx line protocol with a Live publish token, the token is checked by the handler.
+	r.Post("/api/live/token/push/:streamId", requestmeta.SetSLOGroup(requestmeta.SLOGroupNone), hs.LivePushGateway.HandleTokenPush)
+
 	// admin api
 	r.Group("/api/admin", func(adminRoute routing.RouteRegister) {
 		// There is additional filter which will ensure that user sees only settings that they are allowed to see, so we don't need provide additional scope here for ActionSettingsRead.
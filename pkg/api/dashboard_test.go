@@ -128,7 +128,7 @@ func newTestLive(t *testing.T, store db.DB) *live.GrafanaLive {
 		nil,
 		features, acimpl.ProvideAccessControl(features),
 		&dashboards.FakeDashboardService{},
-		nil, nil)
+		nil, nil, nil)
 	require.NoError(t, err)
 	return gLive
 }
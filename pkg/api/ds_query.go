@@ -17,6 +17,7 @@ import (
 	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
 	"github.com/grafana/grafana/pkg/services/datasources"
 	"github.com/grafana/grafana/pkg/services/featuremgmt"
+	"github.com/grafana/grafana/pkg/services/query"
 	"github.com/grafana/grafana/pkg/util/errhttp"
 	"github.com/grafana/grafana/pkg/web"
 )
@@ -28,6 +29,14 @@ func (hs *HTTPServer) handleQueryMetricsError(err error) *response.NormalRespons
 	if errors.Is(err, datasources.ErrDataSourceNotFound) {
 		return response.Error(http.StatusNotFound, "Data source not found", err)
 	}
+	var rateLimitErr *query.RateLimitError
+	if errors.As(err, &rateLimitErr) {
+		resp := response.Err(err)
+		if rateLimitErr.RetryAfter > 0 {
+			resp.SetHeader("Retry-After", rateLimitErr.RetryAfterSeconds())
+		}
+		return resp
+	}
 
 	return response.ErrOrFallback(http.StatusInternalServerError, "Query data error", err)
 }
@@ -82,6 +82,7 @@ func TestAPIEndpoint_Metrics_QueryMetricsV2(t *testing.T) {
 			pluginconfig.NewFakePluginRequestConfigProvider(),
 		),
 		dsquerierclient.NewNullQSDatasourceClientBuilder(),
+		nil,
 	)
 	server := SetupAPITestServer(t, func(hs *HTTPServer) {
 		hs.queryDataService = qds
@@ -265,6 +266,7 @@ func TestDataSourceQueryError(t *testing.T) {
 						pluginSettings.ProvideService(dbtest.NewFakeDB(),
 							secretstest.NewFakeSecretsService()), pluginconfig.NewFakePluginRequestConfigProvider()),
 					dsquerierclient.NewNullQSDatasourceClientBuilder(),
+					nil,
 				)
 				hs.QuotaService = quotatest.New(false, nil)
 			})
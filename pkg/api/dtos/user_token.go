@@ -6,6 +6,7 @@ type UserToken struct {
 	Id                     int64     `json:"id"`
 	IsActive               bool      `json:"isActive"`
 	ClientIp               string    `json:"clientIp"`
+	LocationHint           string    `json:"locationHint"`
 	Device                 string    `json:"device"`
 	OperatingSystem        string    `json:"os"`
 	OperatingSystemVersion string    `json:"osVersion"`
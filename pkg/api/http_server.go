@@ -922,6 +922,17 @@ func (hs *HTTPServer) configureTLS() error {
 		CipherSuites: tlsCiphers,
 	}
 
+	if hs.Cfg.ClientCertAuth.Enabled && hs.Cfg.ClientCertAuth.ClientCAFile != "" {
+		clientCAs, err := readClientCAs(hs.Cfg.ClientCertAuth.ClientCAFile)
+		if err != nil {
+			return err
+		}
+		// Client certificates are optional, requests without one can still use
+		// any other authentication method.
+		tlsCfg.ClientCAs = clientCAs
+		tlsCfg.ClientAuth = tls.VerifyClientCertIfGiven
+	}
+
 	hs.httpSrv.TLSConfig = tlsCfg
 
 	if hs.Cfg.Protocol == setting.HTTP2Scheme {
@@ -935,6 +946,18 @@ func (hs *HTTPServer) configureTLS() error {
 	return nil
 }
 
+func readClientCAs(file string) (*x509.CertPool, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read client_ca_file: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("no certificates found in client_ca_file %q", file)
+	}
+	return pool, nil
+}
+
 func (hs *HTTPServer) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
 	hs.tlsCerts.certLock.RLock()
 	defer hs.tlsCerts.certLock.RUnlock()
@@ -3,6 +3,7 @@ package api
 import (
 	"context"
 	"encoding/hex"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
@@ -281,6 +282,47 @@ func (hs *HTTPServer) StartPasswordless(c *contextmodel.ReqContext) {
 	c.JSON(http.StatusOK, redirect)
 }
 
+func (hs *HTTPServer) LoginTwoFactor(c *contextmodel.ReqContext) response.Response {
+	identity, err := hs.authnService.Login(c.Req.Context(), authn.ClientTwoFactor, &authn.Request{HTTPRequest: c.Req})
+	if err != nil {
+		tokenErr := &auth.CreateTokenErr{}
+		if errors.As(err, &tokenErr) {
+			return response.Error(tokenErr.StatusCode, tokenErr.ExternalErr, tokenErr.InternalErr)
+		}
+		return response.Err(err)
+	}
+	return authn.HandleLoginResponse(c.Req, c.Resp, hs.Cfg, identity, hs.ValidateRedirectTo, hs.Features)
+}
+
+func (hs *HTTPServer) StartTwoFactorEnrollment(c *contextmodel.ReqContext) response.Response {
+	redirect, err := hs.authnService.RedirectURL(c.Req.Context(), authn.ClientTwoFactor, &authn.Request{HTTPRequest: c.Req})
+	if err != nil {
+		return response.Err(err)
+	}
+	return response.JSON(http.StatusOK, redirect)
+}
+
+func (hs *HTTPServer) LoginWebAuthn(c *contextmodel.ReqContext) response.Response {
+	identity, err := hs.authnService.Login(c.Req.Context(), authn.ClientWebAuthn, &authn.Request{HTTPRequest: c.Req})
+	if err != nil {
+		tokenErr := &auth.CreateTokenErr{}
+		if errors.As(err, &tokenErr) {
+			return response.Error(tokenErr.StatusCode, tokenErr.ExternalErr, tokenErr.InternalErr)
+		}
+		return response.Err(err)
+	}
+	return authn.HandleLoginResponse(c.Req, c.Resp, hs.Cfg, identity, hs.ValidateRedirectTo, hs.Features)
+}
+
+// StartWebAuthnLogin returns the options for navigator.credentials.get.
+func (hs *HTTPServer) StartWebAuthnLogin(c *contextmodel.ReqContext) response.Response {
+	redirect, err := hs.authnService.RedirectURL(c.Req.Context(), authn.ClientWebAuthn, &authn.Request{HTTPRequest: c.Req})
+	if err != nil {
+		return response.Err(err)
+	}
+	return response.JSON(http.StatusOK, json.RawMessage(redirect.Extra["options"]))
+}
+
 func (hs *HTTPServer) loginUserWithUser(user *user.User, c *contextmodel.ReqContext) error {
 	if user == nil {
 		return errors.New("could not login user")
@@ -10,6 +10,7 @@ import (
 	"github.com/grafana/grafana/pkg/api/dtos"
 	"github.com/grafana/grafana/pkg/api/response"
 	"github.com/grafana/grafana/pkg/services/accesscontrol"
+	"github.com/grafana/grafana/pkg/services/auth"
 	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
 	"github.com/grafana/grafana/pkg/services/login"
 	"github.com/grafana/grafana/pkg/services/org"
@@ -432,7 +433,9 @@ func (hs *HTTPServer) updateOrgUserHelper(c *contextmodel.ReqContext, cmd org.Up
 		}
 	}
 
-	if err := hs.orgService.UpdateOrgUser(c.Req.Context(), &cmd); err != nil {
+	// Downgrading the role signs the user out everywhere
+	err = auth.UpdateOrgUser(c.Req.Context(), hs.orgService, hs.AuthTokenService, &cmd)
+	if err != nil && !errors.Is(err, auth.ErrRevokeSessions) {
 		if errors.Is(err, org.ErrLastOrgAdmin) {
 			return response.Error(http.StatusBadRequest, "Cannot change role so that there is no organization admin left", nil)
 		}
@@ -444,6 +447,10 @@ func (hs *HTTPServer) updateOrgUserHelper(c *contextmodel.ReqContext, cmd org.Up
 		OrgID:  cmd.OrgID,
 	})
 
+	if err != nil {
+		return response.Error(http.StatusExpectationFailed, "Organization user updated but unable to revoke user sessions", err)
+	}
+
 	return response.Success("Organization user updated")
 }
 
@@ -502,7 +509,9 @@ func (hs *HTTPServer) RemoveOrgUser(c *contextmodel.ReqContext) response.Respons
 }
 
 func (hs *HTTPServer) removeOrgUserHelper(ctx context.Context, cmd *org.RemoveOrgUserCommand) response.Response {
-	if err := hs.orgService.RemoveOrgUser(ctx, cmd); err != nil {
+	// Removing the user signs the user out everywhere
+	err := auth.RemoveOrgUser(ctx, hs.orgService, hs.AuthTokenService, cmd)
+	if err != nil && !errors.Is(err, auth.ErrRevokeSessions) {
 		if errors.Is(err, org.ErrLastOrgAdmin) {
 			return response.Error(http.StatusBadRequest, "Cannot remove last organization admin", nil)
 		}
@@ -514,6 +523,9 @@ func (hs *HTTPServer) removeOrgUserHelper(ctx context.Context, cmd *org.RemoveOr
 		if err := hs.accesscontrolService.DeleteUserPermissions(ctx, accesscontrol.GlobalOrgID, cmd.UserID); err != nil {
 			hs.log.Warn("failed to delete permissions for user", "userID", cmd.UserID, "orgID", accesscontrol.GlobalOrgID, "err", err)
 		}
+		if err != nil {
+			return response.Error(http.StatusExpectationFailed, "User deleted but unable to revoke user sessions", err)
+		}
 		return response.Success("User deleted")
 	}
 
@@ -522,6 +534,10 @@ func (hs *HTTPServer) removeOrgUserHelper(ctx context.Context, cmd *org.RemoveOr
 		hs.log.Warn("failed to delete permissions for user", "userID", cmd.UserID, "orgID", cmd.OrgID, "err", err)
 	}
 
+	if err != nil {
+		return response.Error(http.StatusExpectationFailed, "User removed from organization but unable to revoke user sessions", err)
+	}
+
 	return response.Success("User removed from organization")
 }
 
@@ -10,6 +10,7 @@ import (
 	"testing"
 
 	"github.com/grafana/grafana/pkg/configprovider"
+	"github.com/grafana/grafana/pkg/services/auth/authtest"
 	"github.com/grafana/grafana/pkg/services/authn"
 	"github.com/grafana/grafana/pkg/services/authn/authntest"
 	"github.com/stretchr/testify/assert"
@@ -695,12 +696,77 @@ func TestPatchOrgUsersAPIEndpoint_AccessControl(t *testing.T) {
 	}
 }
 
+func TestPatchOrgUsersAPIEndpoint_RoleDowngrade(t *testing.T) {
+	type testCase struct {
+		name          string
+		currentRole   org.RoleType
+		input         string
+		expectRevoked bool
+	}
+
+	tests := []testCase{
+		{
+			name:          "should sign the user out when downgrading their role",
+			currentRole:   org.RoleEditor,
+			input:         `{"role": "Viewer"}`,
+			expectRevoked: true,
+		},
+		{
+			name:        "should not sign the user out when upgrading their role",
+			currentRole: org.RoleViewer,
+			input:       `{"role": "Editor"}`,
+		},
+		{
+			name:        "should not sign the user out when keeping their role",
+			currentRole: org.RoleEditor,
+			input:       `{"role": "Editor"}`,
+		},
+	}
+	permissions := []accesscontrol.Permission{{Action: accesscontrol.ActionOrgUsersWrite, Scope: "users:*"}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var revokedUserID int64
+			server := SetupAPITestServer(t, func(hs *HTTPServer) {
+				hs.Cfg = setting.NewCfg()
+				hs.orgService = &orgtest.FakeOrgService{
+					ExpectedUserOrgDTO: []*org.UserOrgDTO{{OrgID: 1, Role: tt.currentRole}},
+				}
+				hs.authInfoService = &authinfotest.FakeService{ExpectedUserAuth: &login.UserAuth{}}
+				hs.accesscontrolService = &actest.FakeService{}
+				hs.userService = &usertest.FakeUserService{
+					ExpectedUser:         &user.User{},
+					ExpectedSignedInUser: userWithPermissions(1, permissions),
+				}
+				hs.AuthTokenService = &authtest.FakeUserAuthTokenService{
+					RevokeAllUserTokensProvider: func(ctx context.Context, userID int64) error {
+						revokedUserID = userID
+						return nil
+					},
+				}
+			})
+
+			u := userWithPermissions(1, permissions)
+			u.OrgRole = org.RoleAdmin
+			res, err := server.SendJSON(webtest.RequestWithSignedInUser(server.NewRequest(http.MethodPatch, "/api/orgs/1/users/2", strings.NewReader(tt.input)), u))
+			require.NoError(t, err)
+			assert.Equal(t, http.StatusOK, res.StatusCode)
+			if tt.expectRevoked {
+				assert.Equal(t, int64(2), revokedUserID)
+			} else {
+				assert.Zero(t, revokedUserID)
+			}
+			require.NoError(t, res.Body.Close())
+		})
+	}
+}
+
 func TestDeleteOrgUsersAPIEndpoint_AccessControl(t *testing.T) {
 	type testCase struct {
 		name           string
 		permissions    []accesscontrol.Permission
 		isGrafanaAdmin bool
 		expectedCode   int
+		expectRevoked  bool
 	}
 
 	tests := []testCase{
@@ -709,7 +775,8 @@ func TestDeleteOrgUsersAPIEndpoint_AccessControl(t *testing.T) {
 			permissions: []accesscontrol.Permission{
 				{Action: accesscontrol.ActionOrgUsersRemove, Scope: "users:*"},
 			},
-			expectedCode: http.StatusOK,
+			expectedCode:  http.StatusOK,
+			expectRevoked: true,
 		},
 		{
 			name:         "user without permissions cannot remove user from org",
@@ -718,6 +785,7 @@ func TestDeleteOrgUsersAPIEndpoint_AccessControl(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
+			var revokedUserID int64
 			server := SetupAPITestServer(t, func(hs *HTTPServer) {
 				hs.Cfg = setting.NewCfg()
 				hs.accesscontrolService = actest.FakeService{}
@@ -732,6 +800,12 @@ func TestDeleteOrgUsersAPIEndpoint_AccessControl(t *testing.T) {
 					ExpectedUser:         &user.User{},
 					ExpectedSignedInUser: userWithPermissions(1, tt.permissions),
 				}
+				hs.AuthTokenService = &authtest.FakeUserAuthTokenService{
+					RevokeAllUserTokensProvider: func(ctx context.Context, userID int64) error {
+						revokedUserID = userID
+						return nil
+					},
+				}
 			})
 
 			u := userWithPermissions(1, tt.permissions)
@@ -739,6 +813,11 @@ func TestDeleteOrgUsersAPIEndpoint_AccessControl(t *testing.T) {
 			res, err := server.SendJSON(webtest.RequestWithSignedInUser(server.NewRequest(http.MethodDelete, "/api/orgs/1/users/1", nil), u))
 			require.NoError(t, err)
 			assert.Equal(t, tt.expectedCode, res.StatusCode)
+			if tt.expectRevoked {
+				assert.Equal(t, int64(1), revokedUserID)
+			} else {
+				assert.Zero(t, revokedUserID)
+			}
 			require.NoError(t, res.Body.Close())
 		})
 	}
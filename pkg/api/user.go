@@ -563,6 +563,18 @@ func (hs *HTTPServer) ChangeUserPassword(c *contextmodel.ReqContext) response.Re
 		return response.ErrOrFallback(http.StatusInternalServerError, "Failed to change user password", err)
 	}
 
+	// Sign out every other session, keeping the one the password was changed from
+	var err error
+	if c.UserToken != nil {
+		err = hs.AuthTokenService.RevokeOtherUserTokens(c.Req.Context(), userID, c.UserToken.Id)
+	} else {
+		err = hs.AuthTokenService.RevokeAllUserTokens(c.Req.Context(), userID)
+	}
+	if err != nil {
+		return response.Error(http.StatusExpectationFailed,
+			"User password changed but unable to revoke user sessions", err)
+	}
+
 	return response.Success("User password changed")
 }
 
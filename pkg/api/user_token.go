@@ -75,6 +75,41 @@ func (hs *HTTPServer) RevokeUserAuthToken(c *contextmodel.ReqContext) response.R
 	return hs.revokeUserAuthTokenInternal(c, userID, cmd)
 }
 
+// swagger:route POST /user/revoke-other-auth-tokens signed_in_user revokeOtherUserAuthTokens
+//
+// Revoke the other auth tokens of the actual User.
+//
+// Revokes all auth tokens (devices) of the actual user except the one used for this request. The user will no longer be logged in on the other devices and will be required to authenticate again upon next activity.
+//
+// Responses:
+// 200: okResponse
+// 400: badRequestError
+// 401: unauthorisedError
+// 403: forbiddenError
+// 500: internalServerError
+func (hs *HTTPServer) RevokeOtherUserAuthTokens(c *contextmodel.ReqContext) response.Response {
+	if !c.IsIdentityType(claims.TypeUser) {
+		return response.Error(http.StatusForbidden, "entity not allowed to revoke tokens", nil)
+	}
+
+	if c.UserToken == nil {
+		return response.Error(http.StatusBadRequest, "Not signed in with a session", nil)
+	}
+
+	userID, err := c.GetInternalID()
+	if err != nil {
+		return response.Error(http.StatusInternalServerError, "failed to parse user id", err)
+	}
+
+	if err := hs.AuthTokenService.RevokeOtherUserTokens(c.Req.Context(), userID, c.UserToken.Id); err != nil {
+		return response.Error(http.StatusInternalServerError, "Failed to revoke user auth tokens", err)
+	}
+
+	return response.JSON(http.StatusOK, util.DynMap{
+		"message": "Other user auth tokens revoked",
+	})
+}
+
 func (hs *HTTPServer) RotateUserAuthTokenRedirect(c *contextmodel.ReqContext) response.Response {
 	if err := hs.rotateToken(c); err != nil {
 		hs.log.FromContext(c.Req.Context()).Debug("Failed to rotate token", "error", err)
@@ -235,6 +270,7 @@ func (hs *HTTPServer) getUserAuthTokensInternal(c *contextmodel.ReqContext, user
 			Id:                     token.Id,
 			IsActive:               isActive,
 			ClientIp:               token.ClientIp,
+			LocationHint:           network.LocationHint(token.ClientIp),
 			Device:                 client.Device.ToString(),
 			OperatingSystem:        client.Os.Family,
 			OperatingSystemVersion: osVersion,
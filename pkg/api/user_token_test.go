@@ -127,6 +127,7 @@ func TestUserTokenAPIEndpoint(t *testing.T) {
 			assert.Equal(t, tokens[0].Id, resultOne.Get("id").MustInt64())
 			assert.True(t, resultOne.Get("isActive").MustBool())
 			assert.Equal(t, "127.0.0.1", resultOne.Get("clientIp").MustString())
+			assert.Equal(t, "Local machine", resultOne.Get("locationHint").MustString())
 			assert.Equal(t, time.Unix(tokens[0].CreatedAt, 0).Format(time.RFC3339), resultOne.Get("createdAt").MustString())
 			assert.Equal(t, time.Unix(tokens[0].SeenAt, 0).Format(time.RFC3339), resultOne.Get("seenAt").MustString())
 
@@ -152,6 +153,59 @@ func TestUserTokenAPIEndpoint(t *testing.T) {
 	})
 }
 
+func TestHTTPServer_RevokeOtherUserAuthTokens(t *testing.T) {
+	type testCase struct {
+		desc           string
+		userToken      *auth.UserToken
+		expectedStatus int
+		expectRevoked  bool
+	}
+
+	tests := []testCase{
+		{
+			desc:           "Should revoke all tokens except the current one",
+			userToken:      &auth.UserToken{Id: 3, UserId: 1},
+			expectedStatus: http.StatusOK,
+			expectRevoked:  true,
+		},
+		{
+			desc:           "Should return 400 when not signed in with a session",
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			var revokedUserID, keptTokenID int64
+			server := SetupAPITestServer(t, func(hs *HTTPServer) {
+				hs.AuthTokenService = &authtest.FakeUserAuthTokenService{
+					RevokeOtherUserTokensProvider: func(ctx context.Context, userID, currentTokenID int64) error {
+						revokedUserID, keptTokenID = userID, currentTokenID
+						return nil
+					},
+				}
+			})
+
+			req := webtest.RequestWithWebContext(server.NewPostRequest("/api/user/revoke-other-auth-tokens", nil), &contextmodel.ReqContext{
+				SignedInUser: &user.SignedInUser{UserID: 1, OrgID: 1},
+				IsSignedIn:   true,
+				UserToken:    tt.userToken,
+			})
+			res, err := server.Send(req)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedStatus, res.StatusCode)
+
+			if tt.expectRevoked {
+				assert.Equal(t, int64(1), revokedUserID)
+				assert.Equal(t, tt.userToken.Id, keptTokenID)
+			} else {
+				assert.Zero(t, revokedUserID)
+			}
+			require.NoError(t, res.Body.Close())
+		})
+	}
+}
+
 func TestHTTPServer_RotateUserAuthTokenRedirect(t *testing.T) {
 	redirectTestCases := []struct {
 		name        string
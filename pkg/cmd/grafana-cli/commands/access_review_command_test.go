@@ -0,0 +1,101 @@
+package commands
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/services/accesscontrol/accessreview"
+)
+
+func TestWriteGrants(t *testing.T) {
+	grants := []accessreview.Grant{
+		{
+			Subject:    accessreview.Subject{UserID: 2, Login: "alice"},
+			Action:     "dashboards:write",
+			Scope:      "folders:uid:parent",
+			Derivation: accessreview.Derivation{Source: accessreview.SourceTeam, Role: "managed:teams:1:permissions", TeamID: 1, TeamName: "editors", InheritedFrom: "folders:uid:parent"},
+		},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, writeGrants(&buf, grants, false))
+	assert.Equal(t, "USER   ACTION            SCOPE               GRANTED THROUGH\n"+
+		"alice  dashboards:write  folders:uid:parent  team:editors → managed:teams:1:permissions → folders:uid:parent\n", buf.String())
+
+	buf.Reset()
+	require.NoError(t, writeGrants(&buf, grants, true))
+	var decoded []accessreview.Grant
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &decoded))
+	assert.Equal(t, grants, decoded)
+}
+
+func TestAccessReviewClient(t *testing.T) {
+	grants := []accessreview.Grant{
+		{
+			Subject:    accessreview.Subject{UserID: 2, Login: "alice"},
+			Action:     "dashboards:write",
+			Scope:      "folders:uid:parent",
+			Derivation: accessreview.Derivation{Source: accessreview.SourceBasicRole, Role: "basic:editor", BasicRole: "Editor"},
+		},
+	}
+
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.URL.String())
+		if r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/api/users/lookup":
+			if r.URL.Query().Get("loginOrEmail") != "alice" {
+				w.WriteHeader(http.StatusNotFound)
+				_, _ = w.Write([]byte(`{"message": "user not found"}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"id": 2}`))
+		default:
+			require.NoError(t, json.NewEncoder(w).Encode(grants))
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	client := &accessReviewClient{url: server.URL, token: "token", httpClient: server.Client()}
+	ctx := context.Background()
+
+	t.Run("should look up the user before reviewing the user's access", func(t *testing.T) {
+		requests = nil
+		result, err := client.userAccess(ctx, "alice", "dashboards:write")
+		require.NoError(t, err)
+		assert.Equal(t, grants, result)
+		assert.Equal(t, []string{
+			"/api/users/lookup?loginOrEmail=alice",
+			"/api/access-control/review/users/2?action=dashboards%3Awrite",
+		}, requests)
+	})
+
+	t.Run("should return the error message of the server", func(t *testing.T) {
+		_, err := client.userAccess(ctx, "bob", "")
+		require.ErrorContains(t, err, "404 Not Found: user not found")
+	})
+
+	t.Run("should review the access to a resource", func(t *testing.T) {
+		requests = nil
+		result, err := client.resourceAccess(ctx, "folders:uid:parent", "")
+		require.NoError(t, err)
+		assert.Equal(t, grants, result)
+		assert.Equal(t, []string{"/api/access-control/review/resources?scope=folders%3Auid%3Aparent"}, requests)
+	})
+
+	t.Run("should fail without valid credentials", func(t *testing.T) {
+		_, err := (&accessReviewClient{url: server.URL, token: "invalid", httpClient: server.Client()}).adminGrants(ctx)
+		require.ErrorContains(t, err, "401 Unauthorized")
+	})
+}
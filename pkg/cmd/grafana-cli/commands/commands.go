@@ -74,6 +74,13 @@ func initializeRunner(ctx context.Context, cmd *utils.ContextCommandLine) (serve
 	return runner, nil
 }
 
+func runCommand(command func(commandLine utils.CommandLine) error) func(context *cli.Context) error {
+	return func(context *cli.Context) error {
+		cmd := &utils.ContextCommandLine{Context: context}
+		return command(cmd)
+	}
+}
+
 func runPluginCommand(command func(commandLine utils.CommandLine) error) func(context *cli.Context) error {
 	return func(context *cli.Context) error {
 		cmd := &utils.ContextCommandLine{Context: context}
@@ -119,6 +126,28 @@ var pluginCommands = []*cli.Command{
 	},
 }
 
+var accessReviewFlags = []cli.Flag{
+	&cli.StringFlag{
+		Name:  "url",
+		Usage: "The URL of the Grafana server",
+		Value: "http://localhost:3000",
+	},
+	&cli.StringFlag{
+		Name:    "token",
+		Usage:   "A service account token with permission to read the permissions of users, the organization of the service account is reviewed",
+		EnvVars: []string{"GF_ACCESS_REVIEW_TOKEN"},
+	},
+	&cli.BoolFlag{
+		Name:  "json",
+		Usage: "Print the result as JSON",
+	},
+}
+
+var accessReviewActionFlag = &cli.StringFlag{
+	Name:  "action",
+	Usage: "Only list the permissions with this action",
+}
+
 var adminCommands = []*cli.Command{
 	{
 		Name:   "reset-admin-password",
@@ -186,6 +215,40 @@ var adminCommands = []*cli.Command{
 			},
 		},
 	},
+	{
+		Name:  "access-review",
+		Usage: "Reviews effective permissions and how they were granted",
+		Subcommands: []*cli.Command{
+			{
+				Name:   "user",
+				Usage:  "user <login or email>. Lists the permissions of a user with the role, team or basic role granting them.",
+				Action: runCommand(accessReviewUserCommand),
+				Flags:  append(accessReviewFlags, accessReviewActionFlag),
+			},
+			{
+				Name:   "resource",
+				Usage:  "resource <scope>, e.g. folders:uid:abc. Lists who has access to a resource, including access inherited from its folders.",
+				Action: runCommand(accessReviewResourceCommand),
+				Flags:  append(accessReviewFlags, accessReviewActionFlag),
+			},
+			{
+				Name:   "admins",
+				Usage:  "Lists the permissions letting users change what others have access to.",
+				Action: runCommand(accessReviewAdminsCommand),
+				Flags:  accessReviewFlags,
+			},
+			{
+				Name:   "stale",
+				Usage:  "Lists the roles of users who haven't logged in for a number of days.",
+				Action: runCommand(accessReviewStaleCommand),
+				Flags: append(accessReviewFlags, &cli.IntFlag{
+					Name:  "days",
+					Usage: "Number of days without logging in",
+					Value: 90,
+				}),
+			},
+		},
+	},
 	{
 		Name:  "secrets-consolidation",
 		Usage: "Runs an operation that re-encrypts all encrypted values in your database with new data keys",
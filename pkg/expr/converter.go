@@ -70,6 +70,11 @@ func (c *ResultConverter) Convert(ctx context.Context,
 	filtered := make([]*data.Frame, 0, len(frames))
 	totalLen := 0
 	for _, frame := range frames {
+		// Heatmap cells look like wide series, but every row is a bucket rather than a sample
+		if frame.Meta != nil && frame.Meta.Type == data.FrameType("heatmap-cells") {
+			return "", mathexp.Results{}, fmt.Errorf("%w (input refid)", ErrHeatmapCellsNotSupported)
+		}
+
 		schema := frame.TimeSeriesSchema()
 		// Check for TimeSeriesTypeNot in InfluxDB queries. A data frame of this type will cause
 		// the WideToMany() function to error out, which results in unhealthy alerts.
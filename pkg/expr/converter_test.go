@@ -119,4 +119,18 @@ func TestConvertDataFramesToResults(t *testing.T) {
 			}
 		})
 	})
+
+	t.Run("should reject heatmap cells frames", func(t *testing.T) {
+		frame := data.NewFrame("",
+			data.NewField("xMax", nil, []time.Time{time.Unix(1, 0), time.Unix(1, 0)}),
+			data.NewField("yMin", nil, []float64{1, 2}),
+			data.NewField("yMax", nil, []float64{2, 4}),
+			data.NewField("count", nil, []float64{10, 5}),
+			data.NewField("yLayout", nil, []int8{0, 0}),
+		)
+		frame.Meta = &data.FrameMeta{Type: "heatmap-cells"}
+
+		_, _, err := converter.Convert(context.Background(), datasources.DS_PROMETHEUS, data.Frames{frame})
+		require.ErrorIs(t, err, ErrHeatmapCellsNotSupported)
+	})
 }
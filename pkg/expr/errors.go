@@ -9,6 +9,8 @@ import (
 
 var ErrSeriesMustBeWide = errors.New("input data must be a wide series")
 
+var ErrHeatmapCellsNotSupported = errors.New("heatmap cells (such as Prometheus native histograms) can not be used as input, convert them to a time series first (for Prometheus set a histogram quantile on the query)")
+
 var ConversionError = errutil.BadRequest("sse.readDataError").MustTemplate(
 	"[{{ .Public.refId }}] got error: {{ .Error }}",
 	errutil.WithPublic(
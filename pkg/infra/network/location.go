@@ -0,0 +1,31 @@
+package network
+
+import (
+	"net"
+)
+
+// LocationHint returns a rough description of where a client connects from, without a geolocation lookup:
+// the kind of network for loopback, private and link-local addresses, or the network the address belongs to,
+// e.g. 203.0.113.0/24, for public addresses. It returns an empty string if the address isn't a valid IP address.
+func LocationHint(address string) string {
+	ip, err := GetIPFromAddress(address)
+	if err != nil {
+		return ""
+	}
+
+	switch {
+	case ip.IsLoopback():
+		return "Local machine"
+	case ip.IsPrivate():
+		return "Private network"
+	case ip.IsLinkLocalUnicast():
+		return "Link-local network"
+	}
+
+	if ip4 := ip.To4(); ip4 != nil {
+		network := net.IPNet{IP: ip4.Mask(net.CIDRMask(24, 32)), Mask: net.CIDRMask(24, 32)}
+		return network.String()
+	}
+	network := net.IPNet{IP: ip.Mask(net.CIDRMask(48, 128)), Mask: net.CIDRMask(48, 128)}
+	return network.String()
+}
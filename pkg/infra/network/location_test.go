@@ -0,0 +1,31 @@
+package network
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLocationHint(t *testing.T) {
+	testCases := []struct {
+		desc  string
+		input string
+		exp   string
+	}{
+		{desc: "IPv4 loopback", input: "127.0.0.1", exp: "Local machine"},
+		{desc: "IPv6 loopback", input: "[::1]", exp: "Local machine"},
+		{desc: "IPv4 private", input: "192.168.2.1", exp: "Private network"},
+		{desc: "IPv6 private", input: "fd12:3456:789a::1", exp: "Private network"},
+		{desc: "IPv4 link-local", input: "169.254.10.1", exp: "Link-local network"},
+		{desc: "IPv4 public", input: "203.0.113.42", exp: "203.0.113.0/24"},
+		{desc: "IPv4 public with port", input: "203.0.113.42:3000", exp: "203.0.113.0/24"},
+		{desc: "IPv6 public", input: "2001:0db8:0123:4567::8329", exp: "2001:db8:123::/48"},
+		{desc: "Empty", input: "", exp: ""},
+		{desc: "Invalid", input: "not an ip", exp: ""},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			assert.Equal(t, tc.exp, LocationHint(tc.input))
+		})
+	}
+}
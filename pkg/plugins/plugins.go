@@ -124,6 +124,7 @@ type JSONData struct {
 	Streaming                 bool            `json:"streaming"`
 	SDK                       bool            `json:"sdk,omitempty"`
 	MultiValueFilterOperators bool            `json:"multiValueFilterOperators,omitempty"`
+	QueryCostEstimation       bool            `json:"queryCostEstimation,omitempty"`
 
 	// Backend (Datasource + Renderer)
 	Executable string `json:"executable,omitempty"`
@@ -0,0 +1,180 @@
+package converter
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+// NativeHistogramFrameType is the frame type used for native histograms.
+const NativeHistogramFrameType data.FrameType = "heatmap-cells"
+
+// NativeHistogramBucket is a single bucket of a Prometheus native histogram.
+// Boundaries follows the Prometheus API: 0 is open left, 1 is open right,
+// 2 is open on both sides and 3 is closed on both sides.
+type NativeHistogramBucket struct {
+	Boundaries int8
+	Lower      float64
+	Upper      float64
+	Count      float64
+}
+
+// NativeHistogram is a single native histogram sample with its buckets
+// ordered by their boundaries.
+type NativeHistogram struct {
+	Time    time.Time
+	Buckets []NativeHistogramBucket
+}
+
+// Count returns the total number of observations in the histogram.
+func (h NativeHistogram) Count() float64 {
+	count := 0.0
+	for _, b := range h.Buckets {
+		count += b.Count
+	}
+	return count
+}
+
+// IsNativeHistogramFrame returns true when the frame holds native histograms as written
+// by ReadPrometheusStyleResult.
+func IsNativeHistogramFrame(frame *data.Frame) bool {
+	return frame != nil && frame.Meta != nil && frame.Meta.Type == NativeHistogramFrameType
+}
+
+// NativeHistogramsFromFrame reads the samples back from a heatmap-cells frame
+// returned for native histograms.
+func NativeHistogramsFromFrame(frame *data.Frame) ([]NativeHistogram, error) {
+	if !IsNativeHistogramFrame(frame) {
+		return nil, fmt.Errorf("frame is not a native histogram frame")
+	}
+
+	timeField, _ := frame.FieldByName("xMax")
+	yMin, _ := frame.FieldByName("yMin")
+	yMax, _ := frame.FieldByName("yMax")
+	count, _ := frame.FieldByName("count")
+	yLayout, _ := frame.FieldByName("yLayout")
+	if timeField == nil || yMin == nil || yMax == nil || count == nil || yLayout == nil {
+		return nil, fmt.Errorf("native histogram frame is missing fields")
+	}
+	if timeField.Type() != data.FieldTypeTime || yLayout.Type() != data.FieldTypeInt8 {
+		return nil, fmt.Errorf("native histogram frame has unexpected field types")
+	}
+
+	var histograms []NativeHistogram
+	for i := 0; i < timeField.Len(); i++ {
+		t, _ := timeField.At(i).(time.Time)
+		if len(histograms) == 0 || !histograms[len(histograms)-1].Time.Equal(t) {
+			histograms = append(histograms, NativeHistogram{Time: t})
+		}
+
+		lower, _ := yMin.FloatAt(i)
+		upper, _ := yMax.FloatAt(i)
+		c, _ := count.FloatAt(i)
+		h := &histograms[len(histograms)-1]
+		h.Buckets = append(h.Buckets, NativeHistogramBucket{
+			Boundaries: yLayout.At(i).(int8),
+			Lower:      lower,
+			Upper:      upper,
+			Count:      c,
+		})
+	}
+
+	return histograms, nil
+}
+
+// NativeHistogramQuantile calculates the q-quantile (0 <= q <= 1) of the histogram
+// the same way the PromQL histogram_quantile function does for native histograms,
+// interpolating linearly within the bucket holding the quantile.
+func NativeHistogramQuantile(q float64, h NativeHistogram) float64 {
+	if math.IsNaN(q) {
+		return math.NaN()
+	}
+	if q < 0 {
+		return math.Inf(-1)
+	}
+	if q > 1 {
+		return math.Inf(+1)
+	}
+
+	total := h.Count()
+	if len(h.Buckets) == 0 || total == 0 {
+		return math.NaN()
+	}
+
+	var (
+		bucket NativeHistogramBucket
+		count  float64
+		rank   = q * total
+	)
+	for _, b := range h.Buckets {
+		if b.Count == 0 {
+			continue
+		}
+		bucket = b
+		count += b.Count
+		if count >= rank {
+			break
+		}
+	}
+
+	// The zero bucket spans both sides of zero, so only use the side that has observations.
+	if bucket.Lower < 0 && bucket.Upper > 0 {
+		hasNegative, hasPositive := false, false
+		for _, b := range h.Buckets {
+			if b.Count == 0 {
+				continue
+			}
+			hasNegative = hasNegative || b.Upper <= 0
+			hasPositive = hasPositive || b.Lower >= 0
+		}
+		switch {
+		case hasPositive && !hasNegative:
+			bucket.Lower = 0
+		case hasNegative && !hasPositive:
+			bucket.Upper = 0
+		}
+	}
+
+	switch {
+	case math.IsInf(bucket.Upper, +1):
+		return bucket.Lower
+	case math.IsInf(bucket.Lower, -1):
+		return bucket.Upper
+	}
+
+	rank -= count - bucket.Count
+	return bucket.Lower + (bucket.Upper-bucket.Lower)*(rank/bucket.Count)
+}
+
+// NativeHistogramQuantileFrame converts a native histogram frame into a time series
+// of the q-quantile, so it can be used by alert rules and server side expressions.
+func NativeHistogramQuantileFrame(frame *data.Frame, q float64) (*data.Frame, error) {
+	histograms, err := NativeHistogramsFromFrame(frame)
+	if err != nil {
+		return nil, err
+	}
+
+	times := make([]time.Time, 0, len(histograms))
+	values := make([]float64, 0, len(histograms))
+	for _, h := range histograms {
+		times = append(times, h.Time)
+		values = append(values, NativeHistogramQuantile(q, h))
+	}
+
+	var labels data.Labels
+	if yMin, _ := frame.FieldByName("yMin"); yMin != nil {
+		labels = yMin.Labels
+	}
+
+	result := data.NewFrame(frame.Name,
+		data.NewField(data.TimeSeriesTimeFieldName, nil, times),
+		data.NewField(data.TimeSeriesValueFieldName, labels, values),
+	)
+	result.Meta = &data.FrameMeta{
+		Type:        data.FrameTypeTimeSeriesMulti,
+		TypeVersion: data.FrameTypeVersion{0, 1},
+	}
+	return result, nil
+}
@@ -0,0 +1,105 @@
+package converter
+
+import (
+	"math"
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	sdkjsoniter "github.com/grafana/grafana-plugin-sdk-go/data/utils/jsoniter"
+	jsoniter "github.com/json-iterator/go"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNativeHistogramQuantile(t *testing.T) {
+	h := NativeHistogram{
+		Buckets: []NativeHistogramBucket{
+			{Boundaries: 0, Lower: 1, Upper: 2, Count: 10},
+			{Boundaries: 0, Lower: 2, Upper: 4, Count: 30},
+			{Boundaries: 0, Lower: 4, Upper: 8, Count: 0},
+			{Boundaries: 0, Lower: 8, Upper: 16, Count: 10},
+		},
+	}
+
+	require.Equal(t, 50.0, h.Count())
+	require.Equal(t, 1.0, NativeHistogramQuantile(0, h))
+	require.Equal(t, 1.5, NativeHistogramQuantile(0.1, h))
+	require.Equal(t, 3.0, NativeHistogramQuantile(0.5, h))
+	require.Equal(t, 12.0, NativeHistogramQuantile(0.9, h))
+	require.Equal(t, 16.0, NativeHistogramQuantile(1, h))
+	require.True(t, math.IsInf(NativeHistogramQuantile(-1, h), -1))
+	require.True(t, math.IsInf(NativeHistogramQuantile(2, h), +1))
+	require.True(t, math.IsNaN(NativeHistogramQuantile(0.5, NativeHistogram{})))
+
+	t.Run("zero bucket only uses the populated side", func(t *testing.T) {
+		h := NativeHistogram{
+			Buckets: []NativeHistogramBucket{
+				{Boundaries: 3, Lower: -0.5, Upper: 0.5, Count: 10},
+				{Boundaries: 0, Lower: 0.5, Upper: 1, Count: 10},
+			},
+		}
+		require.Equal(t, 0.25, NativeHistogramQuantile(0.25, h))
+	})
+
+	t.Run("infinite bounds return the finite bound", func(t *testing.T) {
+		h := NativeHistogram{
+			Buckets: []NativeHistogramBucket{
+				{Boundaries: 0, Lower: 0, Upper: 1, Count: 1},
+				{Boundaries: 0, Lower: 1, Upper: math.Inf(+1), Count: 1},
+			},
+		}
+		require.Equal(t, 1.0, NativeHistogramQuantile(0.99, h))
+	})
+}
+
+func TestNativeHistogramQuantileFrame(t *testing.T) {
+	// nolint:gosec
+	f, err := os.Open(path.Join("testdata", "prom-matrix-histogram-no-labels.json"))
+	require.NoError(t, err)
+	defer func() { _ = f.Close() }()
+
+	iter := jsoniter.Parse(sdkjsoniter.ConfigDefault, f, 1024)
+	rsp := ReadPrometheusStyleResult(iter, Options{})
+	require.NoError(t, rsp.Error)
+	require.NotEmpty(t, rsp.Frames)
+
+	for _, frame := range rsp.Frames {
+		require.True(t, IsNativeHistogramFrame(frame))
+
+		histograms, err := NativeHistogramsFromFrame(frame)
+		require.NoError(t, err)
+		require.NotEmpty(t, histograms)
+
+		buckets := 0
+		for _, h := range histograms {
+			buckets += len(h.Buckets)
+		}
+		require.Equal(t, frame.Rows(), buckets)
+
+		qFrame, err := NativeHistogramQuantileFrame(frame, 0.95)
+		require.NoError(t, err)
+		require.Equal(t, data.FrameTypeTimeSeriesMulti, qFrame.Meta.Type)
+		require.Equal(t, len(histograms), qFrame.Rows())
+		require.Equal(t, frame.Fields[1].Labels, qFrame.Fields[1].Labels)
+		require.Equal(t, histograms[0].Time, qFrame.Fields[0].At(0).(time.Time))
+	}
+
+	_, err = NativeHistogramsFromFrame(data.NewFrame(""))
+	require.Error(t, err)
+}
+
+func TestReadMixedFloatAndHistogramSeries(t *testing.T) {
+	body := `{"status":"success","data":{"resultType":"matrix","result":[{"metric":{"__name__":"latency"},` +
+		`"values":[[1729529670,"1"]],` +
+		`"histograms":[[1729529685,{"count":"2","sum":"3","buckets":[[0,"1","2","2"]]}]]}]}}`
+
+	iter := jsoniter.ParseString(sdkjsoniter.ConfigDefault, body)
+	rsp := ReadPrometheusStyleResult(iter, Options{})
+	require.NoError(t, rsp.Error)
+	require.Len(t, rsp.Frames, 2)
+	require.True(t, IsNativeHistogramFrame(rsp.Frames[0]))
+	require.Equal(t, data.FrameTypeTimeSeriesMulti, rsp.Frames[1].Meta.Type)
+	require.Equal(t, 1.0, rsp.Frames[1].Fields[1].At(0))
+}
@@ -676,10 +676,13 @@ func readMatrixOrVectorMulti(iter *sdkjsoniter.Iterator, resultType string, opt
 			histogram.time.Labels = labels
 			frame := data.NewFrame("", histogram.time, histogram.yMin, histogram.yMax, histogram.count, histogram.yLayout)
 			frame.Meta = &data.FrameMeta{
-				Type: "heatmap-cells",
+				Type: NativeHistogramFrameType,
 			}
 			rsp.Frames = append(rsp.Frames, frame)
-		} else {
+		}
+
+		// A series can hold both float and histogram samples when its type changed over time
+		if histogram == nil || len(tempTimes) > 0 {
 			frame := data.NewFrame("", data.NewField(data.TimeSeriesTimeFieldName, nil, tempTimes), data.NewField(data.TimeSeriesValueFieldName, labels, tempValues))
 			frame.Meta = &data.FrameMeta{
 				Type:        data.FrameTypeTimeSeriesMulti,
@@ -120,6 +120,12 @@ func (s *Service) CallResource(ctx context.Context, req *backend.CallResourceReq
 			return err
 		}
 		return sender.Send(resp)
+	case strings.EqualFold(req.Path, "query-cost"):
+		resp, err := i.resource.EstimateCost(ctx, req)
+		if err != nil {
+			return err
+		}
+		return sender.Send(resp)
 	}
 
 	resp, err := i.resource.Execute(ctx, req)
@@ -73,6 +73,9 @@ type PrometheusQueryProperties struct {
 
 	// Group By parameters to apply to aggregate expressions in the query
 	GroupByKeys []string `json:"groupByKeys,omitempty"`
+
+	// Returns native histograms as a time series of this quantile (between 0 and 1) instead of heatmap cells
+	HistogramQuantile *float64 `json:"histogramQuantile,omitempty"`
 }
 
 // ScopeSpec is a hand copy of the ScopeSpec struct from pkg/apis/scope/v0alpha1/types.go
@@ -175,6 +178,9 @@ type Query struct {
 	ExemplarQuery bool
 	UtcOffsetSec  int64
 
+	// HistogramQuantile converts native histogram results into a quantile series when set
+	HistogramQuantile *float64
+
 	Scopes []ScopeSpec
 }
 
@@ -250,6 +256,10 @@ func Parse(span trace.Span, query backend.DataQuery, dsScrapeInterval string, in
 		}
 	}
 
+	if q := model.HistogramQuantile; q != nil && (math.IsNaN(*q) || *q < 0 || *q > 1) {
+		return nil, fmt.Errorf("histogram quantile must be between 0 and 1, got %v", *q)
+	}
+
 	if !model.Instant && !model.Range {
 		// In older dashboards, we were not setting range query param and !range && !instant was run as range query
 		model.Range = true
@@ -277,6 +287,8 @@ func Parse(span trace.Span, query backend.DataQuery, dsScrapeInterval string, in
 		RangeQuery:    model.Range,
 		ExemplarQuery: model.Exemplar,
 		UtcOffsetSec:  model.UtcOffsetSec,
+
+		HistogramQuantile: model.HistogramQuantile,
 	}, nil
 }
 
@@ -49,6 +49,33 @@ func TestParse(t *testing.T) {
 		require.Equal(t, false, res.ExemplarQuery)
 	})
 
+	t.Run("parsing query model with histogram quantile", func(t *testing.T) {
+		timeRange := backend.TimeRange{
+			From: now,
+			To:   now.Add(12 * time.Hour),
+		}
+
+		q := queryContext(`{
+			"expr": "rpc_durations_native_histogram_seconds",
+			"histogramQuantile": 0.95,
+			"refId": "A"
+		}`, timeRange, time.Duration(1)*time.Minute)
+
+		res, err := models.Parse(span, q, "15s", intervalCalculator, true, false)
+		require.NoError(t, err)
+		require.NotNil(t, res.HistogramQuantile)
+		require.Equal(t, 0.95, *res.HistogramQuantile)
+
+		q = queryContext(`{
+			"expr": "rpc_durations_native_histogram_seconds",
+			"histogramQuantile": 95,
+			"refId": "A"
+		}`, timeRange, time.Duration(1)*time.Minute)
+
+		_, err = models.Parse(span, q, "15s", intervalCalculator, true, false)
+		require.ErrorContains(t, err, "histogram quantile must be between 0 and 1")
+	})
+
 	t.Run("parsing query model with step", func(t *testing.T) {
 		timeRange := backend.TimeRange{
 			From: now,
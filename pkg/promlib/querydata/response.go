@@ -44,6 +44,12 @@ func (s *QueryData) parseResponse(ctx context.Context, q *models.Query, res *htt
 		r := converter.ReadPrometheusStyleResult(iter, converter.Options{})
 		r.Status = backend.Status(res.StatusCode)
 
+		if q.HistogramQuantile != nil {
+			if err := nativeHistogramsToQuantile(r.Frames, *q.HistogramQuantile); err != nil {
+				return backend.ErrorResponseWithErrorSource(err)
+			}
+		}
+
 		// Add frame to attach metadata
 		if len(r.Frames) == 0 && !q.ExemplarQuery {
 			r.Frames = append(r.Frames, data.NewFrame(""))
@@ -144,6 +150,21 @@ func (s *QueryData) processExemplars(ctx context.Context, q *models.Query, dr ba
 	}
 }
 
+// nativeHistogramsToQuantile replaces native histogram frames with a time series of the quantile
+func nativeHistogramsToQuantile(frames data.Frames, q float64) error {
+	for i, frame := range frames {
+		if !converter.IsNativeHistogramFrame(frame) {
+			continue
+		}
+		qFrame, err := converter.NativeHistogramQuantileFrame(frame, q)
+		if err != nil {
+			return err
+		}
+		frames[i] = qFrame
+	}
+	return nil
+}
+
 func addMetadataToMultiFrame(q *models.Query, frame *data.Frame) {
 	if frame.Meta == nil {
 		frame.Meta = &data.FrameMeta{}
@@ -69,6 +69,20 @@ func TestAddMetadataToMultiFrame(t *testing.T) {
 		assert.Len(t, result.Frames, 1)
 		assert.Equal(t, "yMin", result.Frames[0].Fields[1].Name)
 	})
+
+	t.Run("when you have native histogram result with a histogram quantile", func(t *testing.T) {
+		qd := QueryData{exemplarSampler: exemplar.NewStandardDeviationSampler}
+		resBody := `{"status":"success","data":{"resultType":"matrix","result":[{"metric":{"__name__":"rpc_durations_native_histogram_seconds","instance":"nativehisto:8080","job":"prometheus"},"histograms":[[1729529685,{"count":"78","sum":"160","buckets":[[0,"1.8340080864093422","2","10"],[0,"2","2.1810154653305154","68"]]}],[1729529700,{"count":"20","sum":"40","buckets":[[0,"2","3","20"]]}]]}]}}`
+		res := &http.Response{Body: io.NopCloser(bytes.NewBufferString(resBody)), StatusCode: 200}
+		quantile := 0.5
+		result := qd.parseResponse(context.Background(), &models.Query{HistogramQuantile: &quantile}, res)
+		assert.Nil(t, result.Error)
+		require.Len(t, result.Frames, 1)
+		require.Len(t, result.Frames[0].Fields, 2)
+		assert.Equal(t, "rpc_durations_native_histogram_seconds", result.Frames[0].Fields[1].Name)
+		assert.Equal(t, 2, result.Frames[0].Rows())
+		assert.Equal(t, 2.5, result.Frames[0].Fields[1].At(1))
+	})
 }
 
 // Helper function to create mock HTTP response.
@@ -8,6 +8,7 @@ import (
 	"net/http"
 	"net/url"
 	"slices"
+	"strconv"
 	"time"
 
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
@@ -205,3 +206,130 @@ func (r *Resource) GetSuggestions(ctx context.Context, req *backend.CallResource
 
 	return r.Execute(ctx, newReq)
 }
+
+// CostEstimateRequest is the request body for the query-cost resource.
+// It mirrors the query cost estimate contract of Grafana's query service.
+type CostEstimateRequest struct {
+	Queries []CostEstimateQuery `json:"queries"`
+	// SeriesLimit is the highest series count Grafana acts on. Counting
+	// stops just above it.
+	SeriesLimit int64 `json:"seriesLimit,omitempty"`
+}
+
+type CostEstimateQuery struct {
+	RefID      string          `json:"refId"`
+	From       time.Time       `json:"from"`
+	To         time.Time       `json:"to"`
+	IntervalMs int64           `json:"intervalMs"`
+	Model      json.RawMessage `json:"model"`
+}
+
+// CostEstimateResponse is the response body for the query-cost resource.
+type CostEstimateResponse struct {
+	Estimates []CostEstimate `json:"estimates"`
+}
+
+type CostEstimate struct {
+	RefID  string `json:"refId"`
+	Series int64  `json:"series,omitempty"`
+}
+
+// EstimateCost takes a CostEstimateRequest in the body of the resource request
+// and estimates the number of series each query selects, using the series
+// matching the query's selectors in the query's time range.
+// Queries that cannot be parsed are left out of the response.
+func (r *Resource) EstimateCost(ctx context.Context, req *backend.CallResourceRequest) (*backend.CallResourceResponse, error) {
+	costReq := CostEstimateRequest{}
+	if err := json.Unmarshal(req.Body, &costReq); err != nil {
+		return nil, fmt.Errorf("error unmarshalling query cost request: %v", err)
+	}
+
+	costResp := CostEstimateResponse{Estimates: []CostEstimate{}}
+	for _, q := range costReq.Queries {
+		model := struct {
+			Expr string `json:"expr"`
+		}{}
+		if err := json.Unmarshal(q.Model, &model); err != nil || model.Expr == "" {
+			continue
+		}
+		interval := time.Duration(q.IntervalMs) * time.Millisecond
+		interpolatedQuery := models.InterpolateVariables(
+			model.Expr,
+			interval,
+			interval,
+			"",
+			"15s",
+			q.To.Sub(q.From),
+		)
+		selectors, err := getVectorSelectors(interpolatedQuery)
+		if err != nil {
+			r.log.Debug("error parsing selectors", "error", err, "query", interpolatedQuery)
+			continue
+		}
+		if len(selectors) == 0 {
+			continue
+		}
+
+		values := url.Values{}
+		for _, s := range selectors {
+			values.Add("match[]", s)
+		}
+		values.Add("start", strconv.FormatInt(q.From.Unix(), 10))
+		values.Add("end", strconv.FormatInt(q.To.Unix(), 10))
+		if costReq.SeriesLimit > 0 {
+			values.Add("limit", strconv.FormatInt(costReq.SeriesLimit+1, 10))
+		}
+
+		seriesResp, err := r.Execute(ctx, &backend.CallResourceRequest{
+			PluginContext: req.PluginContext,
+			Method:        http.MethodGet,
+			Path:          "/api/v1/series",
+			URL:           "/api/v1/series?" + values.Encode(),
+		})
+		if err != nil {
+			return nil, err
+		}
+		if seriesResp.Status != http.StatusOK {
+			r.log.FromContext(ctx).Debug("Failed to count series for query cost", "status", seriesResp.Status, "refId", q.RefID)
+			continue
+		}
+		series := struct {
+			Data []json.RawMessage `json:"data"`
+		}{}
+		if err := json.Unmarshal(seriesResp.Body, &series); err != nil {
+			return nil, fmt.Errorf("error unmarshalling series response: %v", err)
+		}
+		costResp.Estimates = append(costResp.Estimates, CostEstimate{RefID: q.RefID, Series: int64(len(series.Data))})
+	}
+
+	body, err := json.Marshal(costResp)
+	if err != nil {
+		return nil, err
+	}
+	return &backend.CallResourceResponse{
+		Status:  http.StatusOK,
+		Headers: map[string][]string{"Content-Type": {"application/json"}},
+		Body:    body,
+	}, nil
+}
+
+// getVectorSelectors returns the distinct vector selectors of the expression,
+// without offsets or range durations.
+func getVectorSelectors(expr string) ([]string, error) {
+	parsed, err := parser.ParseExpr(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	selectors := make([]string, 0)
+	parser.Inspect(parsed, func(node parser.Node, nodes []parser.Node) error {
+		if v, ok := node.(*parser.VectorSelector); ok {
+			vs := parser.VectorSelector{LabelMatchers: v.LabelMatchers}
+			selectors = append(selectors, vs.String())
+		}
+		return nil
+	})
+
+	slices.Sort(selectors)
+	return slices.Compact(selectors), nil
+}
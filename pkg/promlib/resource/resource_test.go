@@ -8,6 +8,7 @@ import (
 	"net/http"
 	"net/url"
 	"testing"
+	"time"
 
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
 	"github.com/grafana/grafana-plugin-sdk-go/backend/log"
@@ -187,3 +188,53 @@ func TestResource_GetSuggestionsWithEmptyQueriesButFilters(t *testing.T) {
 	assert.Contains(t, decodedMatch, `job="testjob"`)
 	assert.Contains(t, decodedMatch, `instance="localhost:9090"`)
 }
+
+func TestResource_EstimateCost(t *testing.T) {
+	var capturedURLs []*url.URL
+	mockClient := &http.Client{
+		Transport: &mockRoundTripper{
+			customRoundTrip: func(req *http.Request) (*http.Response, error) {
+				capturedURLs = append(capturedURLs, req.URL)
+				return &http.Response{
+					StatusCode: 200,
+					Body:       io.NopCloser(bytes.NewReader([]byte(`{"status":"success","data":[{"__name__":"up","job":"a"},{"__name__":"up","job":"b"}]}`))),
+					Header:     make(http.Header),
+				}, nil
+			},
+		},
+	}
+	settings := backend.DataSourceInstanceSettings{
+		ID:       1,
+		URL:      "http://localhost:9090",
+		JSONData: []byte(`{}`),
+	}
+	res, err := resource.New(mockClient, settings, log.DefaultLogger)
+	require.NoError(t, err)
+
+	from := time.Unix(1609459200, 0)
+	costReq := resource.CostEstimateRequest{
+		SeriesLimit: 100,
+		Queries: []resource.CostEstimateQuery{
+			{RefID: "A", From: from, To: from.Add(time.Hour), IntervalMs: 15000, Model: json.RawMessage(`{"expr":"sum(rate(up{job=\"a\"}[$__rate_interval])) / sum(up offset 1h)"}`)},
+			{RefID: "B", From: from, To: from.Add(time.Hour), Model: json.RawMessage(`{"expr":"sum("}`)},
+		},
+	}
+	body, err := json.Marshal(costReq)
+	require.NoError(t, err)
+
+	resp, err := res.EstimateCost(context.Background(), &backend.CallResourceRequest{Body: body})
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.Status)
+
+	costResp := resource.CostEstimateResponse{}
+	require.NoError(t, json.Unmarshal(resp.Body, &costResp))
+	require.Equal(t, []resource.CostEstimate{{RefID: "A", Series: 2}}, costResp.Estimates)
+
+	require.Len(t, capturedURLs, 1)
+	require.Equal(t, "/api/v1/series", capturedURLs[0].Path)
+	query := capturedURLs[0].Query()
+	assert.Equal(t, []string{`{__name__="up",job="a"}`, `{__name__="up"}`}, query["match[]"])
+	assert.Equal(t, "1609459200", query.Get("start"))
+	assert.Equal(t, "1609462800", query.Get("end"))
+	assert.Equal(t, "101", query.Get("limit"))
+}
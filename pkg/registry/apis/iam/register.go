@@ -35,6 +35,7 @@ import (
 	"github.com/grafana/grafana/pkg/services/accesscontrol"
 	gfauthorizer "github.com/grafana/grafana/pkg/services/apiserver/auth/authorizer"
 	"github.com/grafana/grafana/pkg/services/apiserver/builder"
+	"github.com/grafana/grafana/pkg/services/authz/zanzana"
 	"github.com/grafana/grafana/pkg/services/featuremgmt"
 	"github.com/grafana/grafana/pkg/services/ssosettings"
 	"github.com/grafana/grafana/pkg/storage/legacysql"
@@ -52,17 +53,24 @@ func RegisterAPIService(
 	reg prometheus.Registerer,
 	coreRolesStorage CoreRoleStorageBackend,
 	rolesStorage RoleStorageBackend,
+	zanzanaClient zanzana.Client,
 ) (*IdentityAccessManagementAPIBuilder, error) {
 	dbProvider := legacysql.NewDatabaseProvider(sql)
 	store := legacy.NewLegacySQLStores(dbProvider)
 	legacyAccessClient := newLegacyAccessClient(ac, store)
 	authorizer := newIAMAuthorizer(accessClient, legacyAccessClient)
 
+	// Permissions restricted by a condition are only stored in zanzana, the noop client would drop them
+	var conditionClient zanzana.Client
+	if features.IsEnabledGlobally(featuremgmt.FlagZanzana) {
+		conditionClient = zanzanaClient
+	}
+
 	builder := &IdentityAccessManagementAPIBuilder{
 		store:                        store,
 		coreRolesStorage:             coreRolesStorage,
 		rolesStorage:                 rolesStorage,
-		resourcePermissionsStorage:   resourcepermission.ProvideStorageBackend(dbProvider),
+		resourcePermissionsStorage:   resourcepermission.ProvideStorageBackend(dbProvider, conditionClient),
 		sso:                          ssoService,
 		authorizer:                   authorizer,
 		legacyAccessClient:           legacyAccessClient,
@@ -85,7 +93,7 @@ func NewAPIService(
 	enabledApis map[string]bool,
 ) *IdentityAccessManagementAPIBuilder {
 	store := legacy.NewLegacySQLStores(dbProvider)
-	resourcePermissionsStorage := resourcepermission.ProvideStorageBackend(dbProvider)
+	resourcePermissionsStorage := resourcepermission.ProvideStorageBackend(dbProvider, nil)
 	resourceAuthorizer := gfauthorizer.NewResourceAuthorizer(accessClient)
 	return &IdentityAccessManagementAPIBuilder{
 		store:                        store,
@@ -0,0 +1,306 @@
+package resourcepermission
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/grafana/authlib/types"
+	"github.com/grafana/grafana/apps/iam/pkg/apis/iam/v0alpha1"
+	authzextv1 "github.com/grafana/grafana/pkg/services/authz/proto/v1"
+	"github.com/grafana/grafana/pkg/services/authz/zanzana"
+)
+
+// Permissions restricted by a condition can't be stored as rbac assignments. They are stored in zanzana
+// as tuples with the attribute_filter condition, so they are only granted when zanzana evaluates access.
+
+var (
+	// conditionalGroupResource is the only resource supporting conditions, the schema doesn't allow
+	// a condition on the tuples of other resources.
+	conditionalGroupResource = schema.GroupResource{Group: "folder.grafana.app", Resource: "folders"}
+
+	// conditionRelations maps the verb of a permission to the relation of its tuple.
+	conditionRelations = map[string]string{
+		"view":  zanzana.RelationSetView,
+		"edit":  zanzana.RelationSetEdit,
+		"admin": zanzana.RelationSetAdmin,
+	}
+)
+
+// splitConditionalPermissions splits the permissions stored as rbac assignments from the ones restricted by a condition.
+func splitConditionalPermissions(perms []v0alpha1.ResourcePermissionspecPermission) (assigned, conditional []v0alpha1.ResourcePermissionspecPermission) {
+	for _, perm := range perms {
+		if perm.Condition != nil {
+			conditional = append(conditional, perm)
+		} else {
+			assigned = append(assigned, perm)
+		}
+	}
+	return assigned, conditional
+}
+
+// toAttributeCondition converts the condition of a permission to the condition of its tuple.
+func toAttributeCondition(c *v0alpha1.ResourcePermissionspecCondition) (zanzana.AttributeCondition, error) {
+	cond := zanzana.AttributeCondition{
+		MatchLabels:   c.MatchLabels,
+		ExcludeLabels: c.ExcludeLabels,
+		SourceCIDRs:   c.SourceCIDRs,
+	}
+
+	bounds := []struct {
+		field string
+		value *string
+		dst   *time.Time
+	}{
+		{"notBefore", c.NotBefore, &cond.NotBefore},
+		{"notAfter", c.NotAfter, &cond.NotAfter},
+	}
+	for _, b := range bounds {
+		if b.value == nil {
+			continue
+		}
+		parsed, err := time.Parse(time.RFC3339, *b.value)
+		if err != nil {
+			return cond, fmt.Errorf("condition %s must be a RFC 3339 timestamp: %w", b.field, errInvalidSpec)
+		}
+		*b.dst = parsed
+	}
+
+	return cond, nil
+}
+
+// fromAttributeCondition converts the condition of a tuple to the condition of its permission.
+func fromAttributeCondition(c zanzana.AttributeCondition) *v0alpha1.ResourcePermissionspecCondition {
+	cond := &v0alpha1.ResourcePermissionspecCondition{
+		MatchLabels:   c.MatchLabels,
+		ExcludeLabels: c.ExcludeLabels,
+		SourceCIDRs:   c.SourceCIDRs,
+	}
+	if !c.NotBefore.IsZero() {
+		notBefore := c.NotBefore.UTC().Format(time.RFC3339)
+		cond.NotBefore = &notBefore
+	}
+	if !c.NotAfter.IsZero() {
+		notAfter := c.NotAfter.UTC().Format(time.RFC3339)
+		cond.NotAfter = &notAfter
+	}
+	return cond
+}
+
+// conditionSubject returns the subject of the tuple granting a permission.
+func conditionSubject(perm v0alpha1.ResourcePermissionspecPermission) (string, error) {
+	switch perm.Kind {
+	case v0alpha1.ResourcePermissionSpecPermissionKindUser:
+		return zanzana.NewTupleEntry(zanzana.TypeUser, perm.Name, ""), nil
+	case v0alpha1.ResourcePermissionSpecPermissionKindServiceAccount:
+		return zanzana.NewTupleEntry(zanzana.TypeServiceAccount, perm.Name, ""), nil
+	case v0alpha1.ResourcePermissionSpecPermissionKindTeam:
+		return zanzana.NewTupleEntry(zanzana.TypeTeam, perm.Name, zanzana.RelationTeamMember), nil
+	case v0alpha1.ResourcePermissionSpecPermissionKindBasicRole:
+		if !allowedBasicRoles[perm.Name] {
+			return "", fmt.Errorf("invalid basic role %q: %w", perm.Name, errInvalidSpec)
+		}
+		return zanzana.NewTupleEntry(zanzana.TypeRole, zanzana.TranslateBasicRole(perm.Name), zanzana.RelationAssignee), nil
+	default:
+		return "", fmt.Errorf("unknown permission kind: %q: %w", perm.Kind, errInvalidSpec)
+	}
+}
+
+// permissionFromTuple returns the permission granted by a tuple with the attribute_filter condition.
+func permissionFromTuple(tuple *openfgav1.TupleKey) (v0alpha1.ResourcePermissionspecPermission, error) {
+	perm := v0alpha1.ResourcePermissionspecPermission{}
+
+	typ, subject, _ := strings.Cut(tuple.GetUser(), ":")
+	name, _, _ := strings.Cut(subject, "#")
+	switch typ {
+	case zanzana.TypeUser:
+		perm.Kind, perm.Name = v0alpha1.ResourcePermissionSpecPermissionKindUser, name
+	case zanzana.TypeServiceAccount:
+		perm.Kind, perm.Name = v0alpha1.ResourcePermissionSpecPermissionKindServiceAccount, name
+	case zanzana.TypeTeam:
+		perm.Kind, perm.Name = v0alpha1.ResourcePermissionSpecPermissionKindTeam, name
+	case zanzana.TypeRole:
+		perm.Kind = v0alpha1.ResourcePermissionSpecPermissionKindBasicRole
+		for role := range allowedBasicRoles {
+			if zanzana.TranslateBasicRole(role) == name {
+				perm.Name = role
+			}
+		}
+	}
+	if perm.Name == "" {
+		return perm, fmt.Errorf("unsupported subject %q", tuple.GetUser())
+	}
+
+	for verb, relation := range conditionRelations {
+		if relation == tuple.GetRelation() {
+			perm.Verb = verb
+		}
+	}
+	if perm.Verb == "" {
+		return perm, fmt.Errorf("unsupported relation %q", tuple.GetRelation())
+	}
+
+	cond, err := zanzana.ParseAttributeCondition(tuple.GetCondition())
+	if err != nil {
+		return perm, err
+	}
+	perm.Condition = fromAttributeCondition(cond)
+
+	return perm, nil
+}
+
+// buildConditionTuples builds the tuples of the permissions restricted by a condition on a resource.
+// It resolves the identities the same way as rbac assignments, so they must exist.
+func (s *ResourcePermSqlBackend) buildConditionTuples(ctx context.Context, ns types.NamespaceInfo, mapper Mapper, grn *groupResourceName, perms []v0alpha1.ResourcePermissionspecPermission) ([]*openfgav1.TupleKey, error) {
+	if len(perms) == 0 {
+		return nil, nil
+	}
+	if s.zClient == nil {
+		return nil, fmt.Errorf("permission conditions require zanzana: %w", errInvalidSpec)
+	}
+	if grn.Group != conditionalGroupResource.Group || grn.Resource != conditionalGroupResource.Resource {
+		return nil, fmt.Errorf("permission conditions are only supported for %s: %w", conditionalGroupResource.String(), errInvalidSpec)
+	}
+	if _, err := s.buildRbacAssignments(ctx, ns, mapper, perms, mapper.Scope(grn.Name)); err != nil {
+		return nil, err
+	}
+
+	tuples := make([]*openfgav1.TupleKey, 0, len(perms))
+	for _, perm := range perms {
+		subject, err := conditionSubject(perm)
+		if err != nil {
+			return nil, err
+		}
+		attrs, err := toAttributeCondition(perm.Condition)
+		if err != nil {
+			return nil, err
+		}
+		condition, err := zanzana.NewAttributeCondition(attrs)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", err.Error(), errInvalidSpec)
+		}
+		tuples = append(tuples, &openfgav1.TupleKey{
+			User:      subject,
+			Relation:  conditionRelations[strings.ToLower(perm.Verb)],
+			Object:    zanzana.NewTupleEntry(zanzana.TypeFolder, grn.Name, ""),
+			Condition: condition,
+		})
+	}
+	return tuples, nil
+}
+
+// readConditionTuples returns the tuples of the permissions restricted by a condition on a resource.
+func (s *ResourcePermSqlBackend) readConditionTuples(ctx context.Context, ns types.NamespaceInfo, grn *groupResourceName) ([]*openfgav1.Tuple, error) {
+	if s.zClient == nil || grn.Group != conditionalGroupResource.Group || grn.Resource != conditionalGroupResource.Resource {
+		return nil, nil
+	}
+
+	var (
+		tuples []*openfgav1.Tuple
+		token  string
+	)
+	for {
+		res, err := s.zClient.Read(ctx, &authzextv1.ReadRequest{
+			Namespace:         ns.Value,
+			TupleKey:          &authzextv1.ReadRequestTupleKey{Object: zanzana.NewTupleEntry(zanzana.TypeFolder, grn.Name, "")},
+			ContinuationToken: token,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("reading permission conditions: %w", err)
+		}
+		for _, t := range zanzana.ToOpenFGATuples(res.GetTuples()) {
+			if zanzana.IsAttributeConditionTuple(t.GetKey()) {
+				tuples = append(tuples, t)
+			}
+		}
+		token = res.GetContinuationToken()
+		if token == "" {
+			return tuples, nil
+		}
+	}
+}
+
+// writeConditionTuples replaces the current tuples of the permissions restricted by a condition on a resource.
+func (s *ResourcePermSqlBackend) writeConditionTuples(ctx context.Context, ns types.NamespaceInfo, current []*openfgav1.Tuple, desired []*openfgav1.TupleKey) error {
+	stored := make(map[string]bool, len(current))
+	for _, t := range current {
+		stored[t.GetKey().String()] = true
+	}
+	kept := make(map[string]bool, len(desired))
+	writes := make([]*openfgav1.TupleKey, 0, len(desired))
+	for _, t := range desired {
+		kept[t.String()] = true
+		if !stored[t.String()] {
+			writes = append(writes, t)
+		}
+	}
+	deletes := make([]*openfgav1.TupleKeyWithoutCondition, 0, len(current))
+	for _, t := range current {
+		if !kept[t.GetKey().String()] {
+			deletes = append(deletes, &openfgav1.TupleKeyWithoutCondition{User: t.GetKey().GetUser(), Relation: t.GetKey().GetRelation(), Object: t.GetKey().GetObject()})
+		}
+	}
+
+	// A tuple whose condition changes is deleted and written again, which can't happen in a single request.
+	if len(deletes) > 0 {
+		if err := s.zClient.Write(ctx, &authzextv1.WriteRequest{
+			Namespace: ns.Value,
+			Deletes:   &authzextv1.WriteRequestDeletes{TupleKeys: zanzana.ToAuthzExtTupleKeysWithoutCondition(deletes)},
+		}); err != nil {
+			return fmt.Errorf("deleting permission conditions: %w", err)
+		}
+	}
+	if len(writes) > 0 {
+		if err := s.zClient.Write(ctx, &authzextv1.WriteRequest{
+			Namespace: ns.Value,
+			Writes:    &authzextv1.WriteRequestWrites{TupleKeys: zanzana.ToAuthzExtTupleKeys(writes)},
+		}); err != nil {
+			return fmt.Errorf("writing permission conditions: %w", err)
+		}
+	}
+	return nil
+}
+
+// withConditionalPermissions adds the permissions restricted by a condition to the resource permission of grn.
+// It returns nil if the resource has neither rbac assignments nor permissions restricted by a condition.
+func (s *ResourcePermSqlBackend) withConditionalPermissions(ctx context.Context, ns types.NamespaceInfo, grn *groupResourceName, rp *v0alpha1.ResourcePermission) (*v0alpha1.ResourcePermission, error) {
+	tuples, err := s.readConditionTuples(ctx, ns, grn)
+	if err != nil || len(tuples) == 0 {
+		return rp, err
+	}
+
+	var (
+		specs   []v0alpha1.ResourcePermissionspecPermission
+		created = tuples[0].GetTimestamp().AsTime()
+		updated = created
+	)
+	if rp != nil {
+		specs = rp.Spec.Permissions
+		created = rp.CreationTimestamp.Time
+		updated = rp.GetUpdateTimestamp()
+	}
+
+	for _, t := range tuples {
+		perm, err := permissionFromTuple(t.GetKey())
+		if err != nil {
+			s.logger.FromContext(ctx).Warn("Ignoring unsupported permission condition", "tuple", t.GetKey().String(), "error", err)
+			continue
+		}
+		specs = append(specs, perm)
+
+		ts := t.GetTimestamp().AsTime()
+		if ts.Before(created) {
+			created = ts
+		}
+		if ts.After(updated) {
+			updated = ts
+		}
+	}
+
+	merged := newV0ResourcePermission(grn, specs, created, updated, ns.Value)
+	return &merged, nil
+}
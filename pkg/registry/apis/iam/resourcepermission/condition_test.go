@@ -0,0 +1,237 @@
+package resourcepermission
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/grafana/grafana/apps/iam/pkg/apis/iam/v0alpha1"
+	"github.com/grafana/grafana/pkg/apimachinery/utils"
+	authzextv1 "github.com/grafana/grafana/pkg/services/authz/proto/v1"
+	"github.com/grafana/grafana/pkg/services/authz/zanzana"
+	"github.com/grafana/grafana/pkg/storage/unified/resource"
+	"github.com/grafana/grafana/pkg/storage/unified/resourcepb"
+	"github.com/grafana/grafana/pkg/util/testutil"
+)
+
+// fakeZanzanaClient stores tuples in memory, only Read and Write are implemented.
+type fakeZanzanaClient struct {
+	zanzana.Client
+	tuples map[string]*openfgav1.Tuple
+}
+
+func newFakeZanzanaClient() *fakeZanzanaClient {
+	return &fakeZanzanaClient{tuples: map[string]*openfgav1.Tuple{}}
+}
+
+func (c *fakeZanzanaClient) Read(_ context.Context, req *authzextv1.ReadRequest) (*authzextv1.ReadResponse, error) {
+	res := &authzextv1.ReadResponse{}
+	for _, t := range c.tuples {
+		if t.GetKey().GetObject() == req.GetTupleKey().GetObject() {
+			res.Tuples = append(res.Tuples, &authzextv1.Tuple{Key: zanzana.ToAuthzExtTupleKey(t.GetKey()), Timestamp: t.GetTimestamp()})
+		}
+	}
+	return res, nil
+}
+
+func (c *fakeZanzanaClient) Write(_ context.Context, req *authzextv1.WriteRequest) error {
+	for _, t := range req.GetDeletes().GetTupleKeys() {
+		delete(c.tuples, t.GetUser()+"#"+t.GetRelation()+"@"+t.GetObject())
+	}
+	for _, t := range req.GetWrites().GetTupleKeys() {
+		c.tuples[t.GetUser()+"#"+t.GetRelation()+"@"+t.GetObject()] = &openfgav1.Tuple{
+			Key:       zanzana.ToOpenFGATupleKey(t),
+			Timestamp: timestamppb.New(timeNow()),
+		}
+	}
+	return nil
+}
+
+func TestConditionConversion(t *testing.T) {
+	notBefore := "2025-09-01T08:00:00Z"
+	cond := &v0alpha1.ResourcePermissionspecCondition{
+		ExcludeLabels: map[string]string{"env": "prod"},
+		NotBefore:     &notBefore,
+		SourceCIDRs:   []string{"10.0.0.0/8"},
+	}
+
+	attrs, err := toAttributeCondition(cond)
+	require.NoError(t, err)
+	require.Equal(t, time.Date(2025, 9, 1, 8, 0, 0, 0, time.UTC), attrs.NotBefore)
+	require.True(t, attrs.NotAfter.IsZero())
+	require.Equal(t, cond, fromAttributeCondition(attrs))
+
+	invalid := "tomorrow"
+	_, err = toAttributeCondition(&v0alpha1.ResourcePermissionspecCondition{NotAfter: &invalid})
+	require.ErrorIs(t, err, errInvalidSpec)
+
+	condition, err := zanzana.NewAttributeCondition(attrs)
+	require.NoError(t, err)
+
+	perms := []v0alpha1.ResourcePermissionspecPermission{
+		{Kind: v0alpha1.ResourcePermissionSpecPermissionKindUser, Name: "user-1", Verb: "view"},
+		{Kind: v0alpha1.ResourcePermissionSpecPermissionKindServiceAccount, Name: "sa-1", Verb: "edit"},
+		{Kind: v0alpha1.ResourcePermissionSpecPermissionKindTeam, Name: "team-1", Verb: "admin"},
+		{Kind: v0alpha1.ResourcePermissionSpecPermissionKindBasicRole, Name: "Viewer", Verb: "view"},
+	}
+	for _, perm := range perms {
+		subject, err := conditionSubject(perm)
+		require.NoError(t, err)
+
+		parsed, err := permissionFromTuple(&openfgav1.TupleKey{
+			User:      subject,
+			Relation:  conditionRelations[perm.Verb],
+			Object:    "folder:fold1",
+			Condition: condition,
+		})
+		require.NoError(t, err)
+
+		perm.Condition = cond
+		require.Equal(t, perm, parsed)
+	}
+
+	_, err = conditionSubject(v0alpha1.ResourcePermissionspecPermission{Kind: v0alpha1.ResourcePermissionSpecPermissionKindBasicRole, Name: "Grafana Admin"})
+	require.ErrorIs(t, err, errInvalidSpec)
+}
+
+func TestIntegration_ResourcePermSqlBackend_Conditions(t *testing.T) {
+	testutil.SkipIntegrationTestInShortMode(t)
+
+	timeNow = func() time.Time {
+		return time.Date(2025, 9, 4, 0, 0, 0, 0, time.UTC)
+	}
+
+	backend := setupBackend(t)
+	sql, err := backend.dbProvider(context.Background())
+	require.NoError(t, err)
+	setupTestRoles(t, sql.DB)
+
+	backend.identityStore = NewFakeIdentityStore(t)
+	zClient := newFakeZanzanaClient()
+	backend.zClient = zClient
+
+	gr := v0alpha1.ResourcePermissionInfo.GroupResource()
+	write := func(eventType resourcepb.WatchEvent_Type, name string, spec v0alpha1.ResourcePermissionSpec) error {
+		obj, err := utils.MetaAccessor(&v0alpha1.ResourcePermission{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+			Spec:       spec,
+		})
+		require.NoError(t, err)
+		_, err = backend.WriteEvent(context.Background(), resource.WriteEvent{
+			Type:   eventType,
+			Key:    &resourcepb.ResourceKey{Group: gr.Group, Resource: gr.Resource, Name: name, Namespace: "default"},
+			Object: obj,
+		})
+		return err
+	}
+	read := func(name string) *v0alpha1.ResourcePermission {
+		rsp := backend.ReadResource(context.Background(), &resourcepb.ReadRequest{
+			Key: &resourcepb.ResourceKey{Group: gr.Group, Resource: gr.Resource, Name: name, Namespace: "default"},
+		})
+		require.Nil(t, rsp.Error)
+		var permission v0alpha1.ResourcePermission
+		require.NoError(t, json.Unmarshal(rsp.Value, &permission))
+		return &permission
+	}
+
+	folder := v0alpha1.ResourcePermissionspecResource{ApiGroup: "folder.grafana.app", Resource: "folders", Name: "fold2"}
+	notProd := &v0alpha1.ResourcePermissionspecCondition{ExcludeLabels: map[string]string{"env": "prod"}}
+	office := &v0alpha1.ResourcePermissionspecCondition{SourceCIDRs: []string{"10.0.0.0/8"}}
+
+	t.Run("stores permissions with a condition in zanzana", func(t *testing.T) {
+		err := write(resourcepb.WatchEvent_ADDED, "folder.grafana.app-folders-fold2", v0alpha1.ResourcePermissionSpec{
+			Resource: folder,
+			Permissions: []v0alpha1.ResourcePermissionspecPermission{
+				{Kind: v0alpha1.ResourcePermissionSpecPermissionKindUser, Name: "user-1", Verb: "view", Condition: notProd},
+			},
+		})
+		require.NoError(t, err)
+
+		require.Len(t, zClient.tuples, 1)
+		for _, tuple := range zClient.tuples {
+			require.Equal(t, "user:user-1", tuple.GetKey().GetUser())
+			require.Equal(t, zanzana.RelationSetView, tuple.GetKey().GetRelation())
+			require.Equal(t, "folder:fold2", tuple.GetKey().GetObject())
+			require.True(t, zanzana.IsAttributeConditionTuple(tuple.GetKey()))
+		}
+
+		permission := read("folder.grafana.app-folders-fold2")
+		require.Equal(t, []v0alpha1.ResourcePermissionspecPermission{
+			{Kind: v0alpha1.ResourcePermissionSpecPermissionKindUser, Name: "user-1", Verb: "view", Condition: notProd},
+		}, permission.Spec.Permissions)
+	})
+
+	t.Run("rejects creating the same resource permission again", func(t *testing.T) {
+		err := write(resourcepb.WatchEvent_ADDED, "folder.grafana.app-folders-fold2", v0alpha1.ResourcePermissionSpec{
+			Resource: folder,
+			Permissions: []v0alpha1.ResourcePermissionspecPermission{
+				{Kind: v0alpha1.ResourcePermissionSpecPermissionKindUser, Name: "user-2", Verb: "view"},
+			},
+		})
+		require.Error(t, err)
+		require.Contains(t, err.Error(), errConflict.Error())
+	})
+
+	t.Run("updates permissions with and without a condition", func(t *testing.T) {
+		err := write(resourcepb.WatchEvent_MODIFIED, "folder.grafana.app-folders-fold2", v0alpha1.ResourcePermissionSpec{
+			Resource: folder,
+			Permissions: []v0alpha1.ResourcePermissionspecPermission{
+				{Kind: v0alpha1.ResourcePermissionSpecPermissionKindUser, Name: "user-1", Verb: "view", Condition: office},
+				{Kind: v0alpha1.ResourcePermissionSpecPermissionKindBasicRole, Name: "Viewer", Verb: "view"},
+			},
+		})
+		require.NoError(t, err)
+
+		require.Len(t, zClient.tuples, 1)
+		permission := read("folder.grafana.app-folders-fold2")
+		require.Equal(t, []v0alpha1.ResourcePermissionspecPermission{
+			{Kind: v0alpha1.ResourcePermissionSpecPermissionKindBasicRole, Name: "Viewer", Verb: "view"},
+			{Kind: v0alpha1.ResourcePermissionSpecPermissionKindUser, Name: "user-1", Verb: "view", Condition: office},
+		}, permission.Spec.Permissions)
+	})
+
+	t.Run("deletes permissions with a condition", func(t *testing.T) {
+		rsp := backend.ReadResource(context.Background(), &resourcepb.ReadRequest{
+			Key: &resourcepb.ResourceKey{Group: gr.Group, Resource: gr.Resource, Name: "folder.grafana.app-folders-fold2", Namespace: "default"},
+		})
+		require.Nil(t, rsp.Error)
+
+		_, err := backend.WriteEvent(context.Background(), resource.WriteEvent{
+			Type: resourcepb.WatchEvent_DELETED,
+			Key:  &resourcepb.ResourceKey{Group: gr.Group, Resource: gr.Resource, Name: "folder.grafana.app-folders-fold2", Namespace: "default"},
+		})
+		require.NoError(t, err)
+		require.Empty(t, zClient.tuples)
+	})
+
+	t.Run("rejects conditions on dashboards", func(t *testing.T) {
+		err := write(resourcepb.WatchEvent_ADDED, "dashboard.grafana.app-dashboards-dash2", v0alpha1.ResourcePermissionSpec{
+			Resource: v0alpha1.ResourcePermissionspecResource{ApiGroup: "dashboard.grafana.app", Resource: "dashboards", Name: "dash2"},
+			Permissions: []v0alpha1.ResourcePermissionspecPermission{
+				{Kind: v0alpha1.ResourcePermissionSpecPermissionKindUser, Name: "user-1", Verb: "view", Condition: notProd},
+			},
+		})
+		require.Error(t, err)
+		require.Contains(t, err.Error(), errInvalidSpec.Error())
+	})
+
+	t.Run("rejects conditions without zanzana", func(t *testing.T) {
+		backend.zClient = nil
+		t.Cleanup(func() { backend.zClient = zClient })
+
+		err := write(resourcepb.WatchEvent_ADDED, "folder.grafana.app-folders-fold3", v0alpha1.ResourcePermissionSpec{
+			Resource: v0alpha1.ResourcePermissionspecResource{ApiGroup: "folder.grafana.app", Resource: "folders", Name: "fold3"},
+			Permissions: []v0alpha1.ResourcePermissionspecPermission{
+				{Kind: v0alpha1.ResourcePermissionSpecPermissionKindUser, Name: "user-1", Verb: "view", Condition: notProd},
+			},
+		})
+		require.Error(t, err)
+		require.Contains(t, err.Error(), errInvalidSpec.Error())
+	})
+}
@@ -94,6 +94,19 @@ func (s *ResourcePermSqlBackend) newRoleIterator(ctx context.Context, dbHelper *
 		return nil, err
 	}
 
+	for i := range v0ResourcePermissions {
+		grn := &groupResourceName{
+			Group:    v0ResourcePermissions[i].Spec.Resource.ApiGroup,
+			Resource: v0ResourcePermissions[i].Spec.Resource.Resource,
+			Name:     v0ResourcePermissions[i].Spec.Resource.Name,
+		}
+		merged, err := s.withConditionalPermissions(ctx, ns, grn, &v0ResourcePermissions[i])
+		if err != nil {
+			return nil, err
+		}
+		v0ResourcePermissions[i] = *merged
+	}
+
 	return &listIterator{
 		resourcePermissions: v0ResourcePermissions,
 		initOffset:          pagination.Continue,
@@ -375,11 +388,24 @@ func (s *ResourcePermSqlBackend) createResourcePermission(
 		return 0, err
 	}
 
-	assignments, err := s.buildRbacAssignments(ctx, ns, mapper, v0ResourcePerm.Spec.Permissions, mapper.Scope(grn.Name))
+	assigned, conditional := splitConditionalPermissions(v0ResourcePerm.Spec.Permissions)
+	assignments, err := s.buildRbacAssignments(ctx, ns, mapper, assigned, mapper.Scope(grn.Name))
+	if err != nil {
+		return 0, err
+	}
+	conditionTuples, err := s.buildConditionTuples(ctx, ns, mapper, grn, conditional)
 	if err != nil {
 		return 0, err
 	}
 
+	currentTuples, err := s.readConditionTuples(ctx, ns, grn)
+	if err != nil {
+		return 0, err
+	}
+	if len(currentTuples) > 0 {
+		return 0, errConflict
+	}
+
 	err = dbHelper.DB.GetSqlxSession().WithTransaction(ctx, func(tx *session.SessionTx) error {
 		// Check if a resource permission for the same resource already exists
 		if err = s.existsResourcePermission(ctx, tx, dbHelper, ns.OrgID, mapper.Scope(grn.Name)); err != nil {
@@ -399,6 +425,10 @@ func (s *ResourcePermSqlBackend) createResourcePermission(
 		return 0, err
 	}
 
+	if err := s.writeConditionTuples(ctx, ns, nil, conditionTuples); err != nil {
+		return 0, err
+	}
+
 	// Return a timestamp as resource version
 	return timeNow().UnixMilli(), nil
 }
@@ -408,17 +438,32 @@ func (s *ResourcePermSqlBackend) updateResourcePermission(ctx context.Context, d
 		return 0, err
 	}
 
-	err := dbHelper.DB.GetSqlxSession().WithTransaction(ctx, func(tx *session.SessionTx) error {
+	assigned, conditional := splitConditionalPermissions(v0ResourcePerm.Spec.Permissions)
+	conditionTuples, err := s.buildConditionTuples(ctx, ns, mapper, grn, conditional)
+	if err != nil {
+		return 0, err
+	}
+	currentTuples, err := s.readConditionTuples(ctx, ns, grn)
+	if err != nil {
+		return 0, err
+	}
+
+	err = dbHelper.DB.GetSqlxSession().WithTransaction(ctx, func(tx *session.SessionTx) error {
+		var currentAssigned []v0alpha1.ResourcePermissionspecPermission
 		currentPerms, err := s.getResourcePermission(ctx, dbHelper, tx, ns, grn.string())
-		if err != nil {
-			if apierrors.IsNotFound(err) {
-				return apierrors.NewNotFound(v0alpha1.ResourcePermissionInfo.GroupResource(), grn.string())
-			}
+		switch {
+		case err == nil:
+			currentAssigned = currentPerms.Spec.Permissions
+		case apierrors.IsNotFound(err) && len(currentTuples) > 0:
+			// Only permissions restricted by a condition exist
+		case apierrors.IsNotFound(err):
+			return apierrors.NewNotFound(v0alpha1.ResourcePermissionInfo.GroupResource(), grn.string())
+		default:
 			s.logger.Error("could not get resource permissions", "orgID", ns.OrgID, "scope", grn.Name, "error", err.Error())
 			return fmt.Errorf("could not get the existing resource permissions for resource %s", grn.Name)
 		}
 
-		permissionsToAdd, permissionsToRemove := diffPermissions(currentPerms.Spec.Permissions, v0ResourcePerm.Spec.Permissions)
+		permissionsToAdd, permissionsToRemove := diffPermissions(currentAssigned, assigned)
 
 		if len(permissionsToRemove) > 0 {
 			permsToRemove, err := s.buildRbacAssignments(ctx, ns, mapper, permissionsToRemove, mapper.Scope(grn.Name))
@@ -465,6 +510,12 @@ func (s *ResourcePermSqlBackend) updateResourcePermission(ctx context.Context, d
 		return 0, err
 	}
 
+	if s.zClient != nil {
+		if err := s.writeConditionTuples(ctx, ns, currentTuples, conditionTuples); err != nil {
+			return 0, err
+		}
+	}
+
 	// Return a timestamp as resource version
 	return timeNow().UnixMilli(), nil
 }
@@ -553,5 +604,13 @@ func (s *ResourcePermSqlBackend) deleteResourcePermission(ctx context.Context, s
 		return fmt.Errorf("could not delete resource permission")
 	}
 
+	currentTuples, err := s.readConditionTuples(ctx, ns, grn)
+	if err != nil {
+		return err
+	}
+	if len(currentTuples) > 0 {
+		return s.writeConditionTuples(ctx, ns, currentTuples, nil)
+	}
+
 	return nil
 }
@@ -17,6 +17,7 @@ import (
 	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/registry/apis/iam/common"
 	idStore "github.com/grafana/grafana/pkg/registry/apis/iam/legacy"
+	"github.com/grafana/grafana/pkg/services/authz/zanzana"
 	"github.com/grafana/grafana/pkg/services/sqlstore/session"
 	"github.com/grafana/grafana/pkg/storage/legacysql"
 	"github.com/grafana/grafana/pkg/storage/unified/resource"
@@ -30,6 +31,7 @@ var (
 type ResourcePermSqlBackend struct {
 	dbProvider    legacysql.LegacyDatabaseProvider
 	identityStore IdentityStore
+	zClient       zanzana.Client
 	logger        log.Logger
 
 	mappers        map[schema.GroupResource]Mapper // group/resource -> rbac mapper
@@ -39,10 +41,13 @@ type ResourcePermSqlBackend struct {
 	mutex       sync.Mutex
 }
 
-func ProvideStorageBackend(dbProvider legacysql.LegacyDatabaseProvider) *ResourcePermSqlBackend {
+// ProvideStorageBackend returns the storage of resource permissions. Permissions restricted by a condition
+// are stored with zClient, they are rejected if it is nil.
+func ProvideStorageBackend(dbProvider legacysql.LegacyDatabaseProvider, zClient zanzana.Client) *ResourcePermSqlBackend {
 	return &ResourcePermSqlBackend{
 		dbProvider:    dbProvider,
 		identityStore: idStore.NewLegacySQLStores(dbProvider),
+		zClient:       zClient,
 		logger:        log.New("resourceperm_storage_backend"),
 
 		mappers: map[schema.GroupResource]Mapper{
@@ -164,10 +169,27 @@ func (s *ResourcePermSqlBackend) ReadResource(ctx context.Context, req *resource
 		return err
 	})
 
-	if err != nil {
+	// The resource may only have permissions restricted by a condition
+	if err != nil && !apierrors.IsNotFound(err) {
 		rsp.Error = resource.AsErrorResult(err)
 		return rsp
 	}
+	notFound := err
+
+	_, grn, err := s.splitResourceName(req.Key.Name)
+	if err != nil {
+		rsp.Error = resource.AsErrorResult(apierrors.NewInternalError(err))
+		return rsp
+	}
+	resourcePermission, err = s.withConditionalPermissions(ctx, ns, grn, resourcePermission)
+	if err != nil {
+		rsp.Error = resource.AsErrorResult(apierrors.NewInternalError(err))
+		return rsp
+	}
+	if resourcePermission == nil {
+		rsp.Error = resource.AsErrorResult(notFound)
+		return rsp
+	}
 
 	rsp.ResourceVersion = resourcePermission.GetUpdateTimestamp().UnixMilli()
 	resourcePermission.Namespace = ns.Value // ensure namespace is set, this is required when existing and new resources are compared for updates
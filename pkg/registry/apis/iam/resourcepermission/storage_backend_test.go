@@ -131,7 +131,7 @@ func TestWriteEvent_Add(t *testing.T) {
 	}
 
 	t.Run("should error with invalid namespace", func(t *testing.T) {
-		backend := ProvideStorageBackend(dbProvider)
+		backend := ProvideStorageBackend(dbProvider, nil)
 
 		rv, err := backend.WriteEvent(context.Background(), resource.WriteEvent{
 			Type: resourcepb.WatchEvent_ADDED,
@@ -144,7 +144,7 @@ func TestWriteEvent_Add(t *testing.T) {
 	})
 
 	t.Run("should error if there is no permission", func(t *testing.T) {
-		backend := ProvideStorageBackend(dbProvider)
+		backend := ProvideStorageBackend(dbProvider, nil)
 
 		resourcePerm, err := utils.MetaAccessor(&v0alpha1.ResourcePermission{
 			ObjectMeta: metav1.ObjectMeta{
@@ -173,7 +173,7 @@ func TestWriteEvent_Add(t *testing.T) {
 	})
 
 	t.Run("should error if name and spec do not match", func(t *testing.T) {
-		backend := ProvideStorageBackend(dbProvider)
+		backend := ProvideStorageBackend(dbProvider, nil)
 
 		resourcePerm, err := utils.MetaAccessor(&v0alpha1.ResourcePermission{
 			ObjectMeta: metav1.ObjectMeta{
@@ -209,7 +209,7 @@ func TestWriteEvent_Add(t *testing.T) {
 	})
 
 	t.Run("should error if resource name is empty", func(t *testing.T) {
-		backend := ProvideStorageBackend(dbProvider)
+		backend := ProvideStorageBackend(dbProvider, nil)
 
 		resourcePerm, err := utils.MetaAccessor(&v0alpha1.ResourcePermission{
 			ObjectMeta: metav1.ObjectMeta{
@@ -245,7 +245,7 @@ func TestWriteEvent_Add(t *testing.T) {
 	})
 
 	t.Run("should error if the resource is unknown", func(t *testing.T) {
-		backend := ProvideStorageBackend(dbProvider)
+		backend := ProvideStorageBackend(dbProvider, nil)
 
 		resourcePerm, err := utils.MetaAccessor(&v0alpha1.ResourcePermission{
 			ObjectMeta: metav1.ObjectMeta{
@@ -281,7 +281,7 @@ func TestWriteEvent_Add(t *testing.T) {
 	})
 
 	t.Run("should work with valid resource permission", func(t *testing.T) {
-		backend := ProvideStorageBackend(dbProvider)
+		backend := ProvideStorageBackend(dbProvider, nil)
 		backend.identityStore = NewFakeIdentityStore(t)
 
 		resourcePerm, err := utils.MetaAccessor(&v0alpha1.ResourcePermission{
@@ -735,7 +735,7 @@ func TestWriteEvent_Modify(t *testing.T) {
 	}
 
 	t.Run("should error with invalid namespace", func(t *testing.T) {
-		backend := ProvideStorageBackend(dbProvider)
+		backend := ProvideStorageBackend(dbProvider, nil)
 
 		rv, err := backend.WriteEvent(context.Background(), resource.WriteEvent{
 			Type: resourcepb.WatchEvent_MODIFIED,
@@ -748,7 +748,7 @@ func TestWriteEvent_Modify(t *testing.T) {
 	})
 
 	t.Run("should error if there are no permission specified in the body", func(t *testing.T) {
-		backend := ProvideStorageBackend(dbProvider)
+		backend := ProvideStorageBackend(dbProvider, nil)
 
 		resourcePerm, err := utils.MetaAccessor(&v0alpha1.ResourcePermission{
 			ObjectMeta: metav1.ObjectMeta{
@@ -777,7 +777,7 @@ func TestWriteEvent_Modify(t *testing.T) {
 	})
 
 	t.Run("should error if name and spec do not match", func(t *testing.T) {
-		backend := ProvideStorageBackend(dbProvider)
+		backend := ProvideStorageBackend(dbProvider, nil)
 
 		resourcePerm, err := utils.MetaAccessor(&v0alpha1.ResourcePermission{
 			ObjectMeta: metav1.ObjectMeta{
@@ -813,7 +813,7 @@ func TestWriteEvent_Modify(t *testing.T) {
 	})
 
 	t.Run("should error if resource name is empty", func(t *testing.T) {
-		backend := ProvideStorageBackend(dbProvider)
+		backend := ProvideStorageBackend(dbProvider, nil)
 
 		resourcePerm, err := utils.MetaAccessor(&v0alpha1.ResourcePermission{
 			ObjectMeta: metav1.ObjectMeta{
@@ -849,7 +849,7 @@ func TestWriteEvent_Modify(t *testing.T) {
 	})
 
 	t.Run("should error if the resource is unknown", func(t *testing.T) {
-		backend := ProvideStorageBackend(dbProvider)
+		backend := ProvideStorageBackend(dbProvider, nil)
 
 		resourcePerm, err := utils.MetaAccessor(&v0alpha1.ResourcePermission{
 			ObjectMeta: metav1.ObjectMeta{
@@ -885,7 +885,7 @@ func TestWriteEvent_Modify(t *testing.T) {
 	})
 
 	t.Run("should work with valid resource permission", func(t *testing.T) {
-		backend := ProvideStorageBackend(dbProvider)
+		backend := ProvideStorageBackend(dbProvider, nil)
 		backend.identityStore = NewFakeIdentityStore(t)
 
 		resourcePerm, err := utils.MetaAccessor(&v0alpha1.ResourcePermission{
@@ -5,31 +5,45 @@ import (
 
 	secretv1beta1 "github.com/grafana/grafana/apps/secret/pkg/apis/secret/v1beta1"
 	"github.com/grafana/grafana/pkg/registry/apis/secret/contracts"
+	"github.com/grafana/grafana/pkg/registry/apis/secret/secretkeeper/metrics"
 	"github.com/grafana/grafana/pkg/registry/apis/secret/secretkeeper/sqlkeeper"
+	"github.com/grafana/grafana/pkg/registry/apis/secret/secretkeeper/vaultkeeper"
+	"github.com/grafana/grafana/pkg/setting"
 	"github.com/prometheus/client_golang/prometheus"
 )
 
 // OSSKeeperService is the OSS implementation of the Service interface.
 type OSSKeeperService struct {
 	systemKeeper *sqlkeeper.SQLKeeper
+	vaultKeeper  *vaultkeeper.VaultKeeper
 }
 
 var _ contracts.KeeperService = (*OSSKeeperService)(nil)
 
 func ProvideService(
 	tracer trace.Tracer,
+	cfg *setting.Cfg,
 	store contracts.EncryptedValueStorage,
 	encryptionManager contracts.EncryptionManager,
+	secureValueMetadataStorage contracts.SecureValueMetadataStorage,
 	reg prometheus.Registerer,
 ) (*OSSKeeperService, error) {
+	keeperMetrics := metrics.NewKeeperMetrics(reg)
+	// TODO: rename to system keeper or something like that
+	systemKeeper := sqlkeeper.NewSQLKeeper(tracer, encryptionManager, store, keeperMetrics)
+
 	return &OSSKeeperService{
-		// TODO: rename to system keeper or something like that
-		systemKeeper: sqlkeeper.NewSQLKeeper(tracer, encryptionManager, store, reg),
+		systemKeeper: systemKeeper,
+		vaultKeeper:  vaultkeeper.NewVaultKeeper(tracer, cfg, secureValueMetadataStorage, systemKeeper, keeperMetrics),
 	}, nil
 }
 
-// Ignore the config, but we could use it to get the keeper type and then return the correct keeper.
+// KeeperForConfig returns the keeper for the keeper type of the config.
 // Instantiation only happens on ProvideService ONCE.
-func (k *OSSKeeperService) KeeperForConfig(secretv1beta1.KeeperConfig) (contracts.Keeper, error) {
+func (k *OSSKeeperService) KeeperForConfig(cfg secretv1beta1.KeeperConfig) (contracts.Keeper, error) {
+	if _, ok := cfg.(*secretv1beta1.KeeperHashiCorpConfig); ok {
+		return k.vaultKeeper, nil
+	}
+
 	return k.systemKeeper, nil
 }
@@ -7,15 +7,19 @@ import (
 	"github.com/stretchr/testify/require"
 	"go.opentelemetry.io/otel/trace/noop"
 
+	secretv1beta1 "github.com/grafana/grafana/apps/secret/pkg/apis/secret/v1beta1"
 	"github.com/grafana/grafana/pkg/infra/usagestats"
+	"github.com/grafana/grafana/pkg/registry/apis/secret/clock"
 	"github.com/grafana/grafana/pkg/registry/apis/secret/encryption/cipher/service"
 	osskmsproviders "github.com/grafana/grafana/pkg/registry/apis/secret/encryption/kmsproviders"
 	"github.com/grafana/grafana/pkg/registry/apis/secret/encryption/manager"
 	"github.com/grafana/grafana/pkg/registry/apis/secret/secretkeeper/sqlkeeper"
+	"github.com/grafana/grafana/pkg/registry/apis/secret/secretkeeper/vaultkeeper"
 	"github.com/grafana/grafana/pkg/services/sqlstore"
 	"github.com/grafana/grafana/pkg/setting"
 	"github.com/grafana/grafana/pkg/storage/secret/database"
 	encryptionstorage "github.com/grafana/grafana/pkg/storage/secret/encryption"
+	"github.com/grafana/grafana/pkg/storage/secret/metadata"
 	"github.com/grafana/grafana/pkg/storage/secret/migrator"
 	"github.com/grafana/grafana/pkg/tests/testsuite"
 )
@@ -41,6 +45,13 @@ func Test_OSSKeeperService(t *testing.T) {
 		assert.NotNil(t, keeper)
 		assert.IsType(t, &sqlkeeper.SQLKeeper{}, keeper)
 	})
+
+	t.Run("KeeperForConfig should return the vault keeper for hashicorp configs", func(t *testing.T) {
+		keeper, err := keeperService.KeeperForConfig(&secretv1beta1.KeeperHashiCorpConfig{Address: "http://localhost:8200"})
+		require.NoError(t, err)
+
+		assert.IsType(t, &vaultkeeper.VaultKeeper{}, keeper)
+	})
 }
 
 func setupTestService(t *testing.T, cfg *setting.Cfg) (*OSSKeeperService, error) {
@@ -55,6 +66,9 @@ func setupTestService(t *testing.T, cfg *setting.Cfg) (*OSSKeeperService, error)
 	encValueStore, err := encryptionstorage.ProvideEncryptedValueStorage(database, tracer)
 	require.NoError(t, err)
 
+	secureValueStore, err := metadata.ProvideSecureValueMetadataStorage(clock.ProvideClock(), database, tracer, nil)
+	require.NoError(t, err)
+
 	usageStats := &usagestats.UsageStatsMock{T: t}
 	enc, err := service.ProvideAESGCMCipherService(tracer, usageStats)
 	require.NoError(t, err)
@@ -65,7 +79,7 @@ func setupTestService(t *testing.T, cfg *setting.Cfg) (*OSSKeeperService, error)
 	require.NoError(t, err)
 
 	// Initialize the keeper service
-	keeperService, err := ProvideService(tracer, encValueStore, encryptionManager, nil)
+	keeperService, err := ProvideService(tracer, cfg, encValueStore, encryptionManager, secureValueStore, nil)
 
 	return keeperService, err
 }
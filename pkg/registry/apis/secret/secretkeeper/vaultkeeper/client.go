@@ -0,0 +1,176 @@
+package vaultkeeper
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// client is a minimal client for the parts of the Vault HTTP API used by the keeper:
+// the KV version 2 secrets engine and the AppRole auth method.
+type client struct {
+	httpClient *http.Client
+	address    string
+	namespace  string
+	token      string
+	// cacheKey is the key of the cached AppRole token, empty for static tokens.
+	cacheKey string
+}
+
+// vaultError is returned when Vault responds with a non-successful status code.
+type vaultError struct {
+	StatusCode int      `json:"-"`
+	Errors     []string `json:"errors"`
+}
+
+func (e *vaultError) Error() string {
+	if len(e.Errors) == 0 {
+		return fmt.Sprintf("vault responded with status %d", e.StatusCode)
+	}
+	return fmt.Sprintf("vault responded with status %d: %s", e.StatusCode, strings.Join(e.Errors, "; "))
+}
+
+func isStatus(err error, statusCode int) bool {
+	var vErr *vaultError
+	return errors.As(err, &vErr) && vErr.StatusCode == statusCode
+}
+
+type kvMetadata struct {
+	CustomMetadata map[string]string `json:"custom_metadata"`
+	CurrentVersion int64             `json:"current_version"`
+}
+
+type kvVersion struct {
+	Version int64 `json:"version"`
+}
+
+type kvData struct {
+	Data     map[string]string `json:"data"`
+	Metadata kvVersion         `json:"metadata"`
+}
+
+type authResponse struct {
+	ClientToken   string `json:"client_token"`
+	LeaseDuration int64  `json:"lease_duration"`
+}
+
+// writeData writes a new version of the secret at the path and returns the created version.
+func (c *client) writeData(ctx context.Context, mount, path string, data map[string]string) (int64, error) {
+	var resp struct {
+		Data kvVersion `json:"data"`
+	}
+	if err := c.do(ctx, http.MethodPost, mount+"/data/"+path, "", map[string]any{"data": data}, &resp); err != nil {
+		return 0, err
+	}
+	return resp.Data.Version, nil
+}
+
+// readData reads the given version of the secret at the path.
+func (c *client) readData(ctx context.Context, mount, path string, version int64) (*kvData, error) {
+	var resp struct {
+		Data kvData `json:"data"`
+	}
+	query := url.Values{"version": []string{strconv.FormatInt(version, 10)}}
+	if err := c.do(ctx, http.MethodGet, mount+"/data/"+path+"?"+query.Encode(), "", nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp.Data, nil
+}
+
+func (c *client) readMetadata(ctx context.Context, mount, path string) (*kvMetadata, error) {
+	var resp struct {
+		Data kvMetadata `json:"data"`
+	}
+	if err := c.do(ctx, http.MethodGet, mount+"/metadata/"+path, "", nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp.Data, nil
+}
+
+// patchCustomMetadata merges the values into the custom metadata of the secret at the path.
+// A nil value removes the key.
+func (c *client) patchCustomMetadata(ctx context.Context, mount, path string, values map[string]*string) error {
+	return c.do(ctx, http.MethodPatch, mount+"/metadata/"+path, "application/merge-patch+json", map[string]any{"custom_metadata": values}, nil)
+}
+
+// destroyVersions permanently removes the data of the versions of the secret at the path.
+func (c *client) destroyVersions(ctx context.Context, mount, path string, versions ...int64) error {
+	return c.do(ctx, http.MethodPost, mount+"/destroy/"+path, "", map[string]any{"versions": versions}, nil)
+}
+
+// deleteMetadata permanently removes the secret at the path with all its versions.
+func (c *client) deleteMetadata(ctx context.Context, mount, path string) error {
+	return c.do(ctx, http.MethodDelete, mount+"/metadata/"+path, "", nil, nil)
+}
+
+func (c *client) loginAppRole(ctx context.Context, mount, roleID, secretID string) (*authResponse, error) {
+	var resp struct {
+		Auth *authResponse `json:"auth"`
+	}
+	body := map[string]string{"role_id": roleID, "secret_id": secretID}
+	if err := c.do(ctx, http.MethodPost, "auth/"+mount+"/login", "", body, &resp); err != nil {
+		return nil, err
+	}
+	if resp.Auth == nil || resp.Auth.ClientToken == "" {
+		return nil, errors.New("vault did not return a client token")
+	}
+	return resp.Auth, nil
+}
+
+func (c *client) do(ctx context.Context, method, path, contentType string, body any, out any) error {
+	var reader io.Reader
+	if body != nil {
+		payload, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(payload)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, strings.TrimSuffix(c.address, "/")+"/v1/"+path, reader)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		if contentType == "" {
+			contentType = "application/json"
+		}
+		req.Header.Set("Content-Type", contentType)
+	}
+	req.Header.Set("X-Vault-Request", "true")
+	if c.token != "" {
+		req.Header.Set("X-Vault-Token", c.token)
+	}
+	if c.namespace != "" {
+		req.Header.Set("X-Vault-Namespace", c.namespace)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		vErr := &vaultError{StatusCode: resp.StatusCode}
+		_ = json.Unmarshal(respBody, vErr)
+		return vErr
+	}
+
+	if out == nil || len(respBody) == 0 {
+		return nil
+	}
+	return json.Unmarshal(respBody, out)
+}
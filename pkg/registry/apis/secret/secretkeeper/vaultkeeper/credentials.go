@@ -0,0 +1,90 @@
+package vaultkeeper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	secretv1beta1 "github.com/grafana/grafana/apps/secret/pkg/apis/secret/v1beta1"
+	"github.com/grafana/grafana/pkg/registry/apis/secret/contracts"
+	"github.com/grafana/grafana/pkg/registry/apis/secret/xkube"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+// credentialResolver resolves the credential values referenced by a keeper configuration.
+type credentialResolver struct {
+	cfg          *setting.Cfg
+	secureValues contracts.SecureValueMetadataStorage
+	systemKeeper contracts.Keeper
+}
+
+func (r *credentialResolver) resolve(ctx context.Context, namespace string, credential secretv1beta1.KeeperCredentialValue) (string, error) {
+	switch {
+	case credential.SecureValueName != "":
+		return r.fromSecureValue(ctx, namespace, credential.SecureValueName)
+
+	case credential.ValueFromEnv != "":
+		// Keepers are defined by tenants, so they can only read the variables the operator allowed.
+		if r.cfg == nil || !slices.Contains(r.cfg.SecretsManagement.KeeperCredentialEnvAllowlist, credential.ValueFromEnv) {
+			return "", fmt.Errorf("environment variable %q is not allowed for keeper credentials", credential.ValueFromEnv)
+		}
+		value, ok := os.LookupEnv(credential.ValueFromEnv)
+		if !ok || value == "" {
+			return "", fmt.Errorf("environment variable %q is not set", credential.ValueFromEnv)
+		}
+		return value, nil
+
+	case credential.ValueFromConfig != "":
+		return r.fromConfig(credential.ValueFromConfig)
+	}
+
+	return "", errors.New("credential value is not configured")
+}
+
+// fromSecureValue exposes a secure value of the namespace. Keepers can only reference
+// secure values stored by the system keeper.
+func (r *credentialResolver) fromSecureValue(ctx context.Context, namespace, name string) (string, error) {
+	if r.secureValues == nil || r.systemKeeper == nil {
+		return "", fmt.Errorf("secure value %q cannot be resolved", name)
+	}
+
+	sv, err := r.secureValues.Read(ctx, xkube.Namespace(namespace), name, contracts.ReadOpts{})
+	if err != nil {
+		return "", fmt.Errorf("reading secure value %q: %w", name, err)
+	}
+	if sv.Spec.Keeper != nil {
+		return "", fmt.Errorf("secure value %q is not stored in the system keeper", name)
+	}
+
+	exposed, err := r.systemKeeper.Expose(ctx, &secretv1beta1.SystemKeeperConfig{}, namespace, name, sv.Status.Version)
+	if err != nil {
+		return "", fmt.Errorf("exposing secure value %q: %w", name, err)
+	}
+	return exposed.DangerouslyExposeAndConsumeValue(), nil
+}
+
+// fromConfig reads a value from the Grafana configuration. The path is the section name
+// followed by the key name, for example `secrets_manager.vault_token`, and must be in
+// the allowlist configured by the operator.
+func (r *credentialResolver) fromConfig(path string) (string, error) {
+	idx := strings.LastIndex(path, ".")
+	if idx <= 0 || idx == len(path)-1 {
+		return "", fmt.Errorf("invalid configuration path %q, expected <section>.<key>", path)
+	}
+	if r.cfg == nil || r.cfg.Raw == nil {
+		return "", fmt.Errorf("configuration path %q cannot be resolved", path)
+	}
+	if !slices.Contains(r.cfg.SecretsManagement.KeeperCredentialConfigAllowlist, path) {
+		return "", fmt.Errorf("configuration path %q is not allowed for keeper credentials", path)
+	}
+
+	section, key := path[:idx], path[idx+1:]
+	value := r.cfg.Raw.Section(section).Key(key).String()
+	if value == "" {
+		return "", fmt.Errorf("configuration path %q is not set", path)
+	}
+	return value, nil
+}
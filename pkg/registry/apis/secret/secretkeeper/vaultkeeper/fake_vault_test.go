@@ -0,0 +1,205 @@
+package vaultkeeper
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeVault is a stand-in for the parts of the Vault HTTP API used by the keeper.
+type fakeVault struct {
+	t      *testing.T
+	server *httptest.Server
+
+	mu        sync.Mutex
+	namespace string
+	tokens    map[string]bool
+	roleID    string
+	secretID  string
+	logins    int
+	secrets   map[string]*fakeSecret
+}
+
+type fakeSecret struct {
+	current        int64
+	versions       map[int64]map[string]string
+	customMetadata map[string]string
+}
+
+func newFakeVault(t *testing.T) *fakeVault {
+	v := &fakeVault{
+		t:       t,
+		tokens:  map[string]bool{"root-token": true},
+		secrets: make(map[string]*fakeSecret),
+	}
+	v.server = httptest.NewServer(http.HandlerFunc(v.handle))
+	t.Cleanup(v.server.Close)
+	return v
+}
+
+func (v *fakeVault) revokeTokens() {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.tokens = make(map[string]bool)
+}
+
+func (v *fakeVault) secret(path string) *fakeSecret {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	return v.secrets[path]
+}
+
+func (v *fakeVault) handle(w http.ResponseWriter, r *http.Request) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if r.Header.Get("X-Vault-Namespace") != v.namespace {
+		writeErrors(w, http.StatusNotFound, "namespace not found")
+		return
+	}
+
+	path := strings.TrimPrefix(r.URL.Path, "/v1/")
+	if path == "auth/approle/login" && r.Method == http.MethodPost {
+		v.login(w, r)
+		return
+	}
+
+	if !v.tokens[r.Header.Get("X-Vault-Token")] {
+		writeErrors(w, http.StatusForbidden, "permission denied")
+		return
+	}
+
+	parts := strings.SplitN(path, "/", 3)
+	if len(parts) != 3 || parts[0] != "secret" {
+		writeErrors(w, http.StatusNotFound, "no handler for route")
+		return
+	}
+	kind, secretPath := parts[1], parts[2]
+
+	switch {
+	case kind == "data" && r.Method == http.MethodPost:
+		var body struct {
+			Data map[string]string `json:"data"`
+		}
+		v.decode(r, &body)
+
+		secret, ok := v.secrets[secretPath]
+		if !ok {
+			secret = &fakeSecret{versions: make(map[int64]map[string]string), customMetadata: make(map[string]string)}
+			v.secrets[secretPath] = secret
+		}
+		secret.current++
+		secret.versions[secret.current] = body.Data
+		writeJSON(w, map[string]any{"data": map[string]any{"version": secret.current}})
+
+	case kind == "data" && r.Method == http.MethodGet:
+		secret, ok := v.secrets[secretPath]
+		if !ok {
+			writeErrors(w, http.StatusNotFound)
+			return
+		}
+		version, err := strconv.ParseInt(r.URL.Query().Get("version"), 10, 64)
+		if err != nil {
+			version = secret.current
+		}
+		data, ok := secret.versions[version]
+		if !ok || data == nil {
+			writeErrors(w, http.StatusNotFound)
+			return
+		}
+		writeJSON(w, map[string]any{"data": map[string]any{"data": data, "metadata": map[string]any{"version": version}}})
+
+	case kind == "metadata" && r.Method == http.MethodGet:
+		secret, ok := v.secrets[secretPath]
+		if !ok {
+			writeErrors(w, http.StatusNotFound)
+			return
+		}
+		writeJSON(w, map[string]any{"data": map[string]any{"current_version": secret.current, "custom_metadata": secret.customMetadata}})
+
+	case kind == "metadata" && r.Method == http.MethodPatch:
+		if r.Header.Get("Content-Type") != "application/merge-patch+json" {
+			writeErrors(w, http.StatusUnsupportedMediaType, "unsupported content type")
+			return
+		}
+		secret, ok := v.secrets[secretPath]
+		if !ok {
+			writeErrors(w, http.StatusNotFound)
+			return
+		}
+		var body struct {
+			CustomMetadata map[string]*string `json:"custom_metadata"`
+		}
+		v.decode(r, &body)
+		for key, value := range body.CustomMetadata {
+			if value == nil {
+				delete(secret.customMetadata, key)
+				continue
+			}
+			secret.customMetadata[key] = *value
+		}
+		w.WriteHeader(http.StatusNoContent)
+
+	case kind == "metadata" && r.Method == http.MethodDelete:
+		delete(v.secrets, secretPath)
+		w.WriteHeader(http.StatusNoContent)
+
+	case kind == "destroy" && r.Method == http.MethodPost:
+		var body struct {
+			Versions []int64 `json:"versions"`
+		}
+		v.decode(r, &body)
+		if secret, ok := v.secrets[secretPath]; ok {
+			for _, version := range body.Versions {
+				secret.versions[version] = nil
+			}
+		}
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		writeErrors(w, http.StatusMethodNotAllowed, "unsupported operation")
+	}
+}
+
+func (v *fakeVault) login(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		RoleID   string `json:"role_id"`
+		SecretID string `json:"secret_id"`
+	}
+	v.decode(r, &body)
+
+	if body.RoleID != v.roleID || body.SecretID != v.secretID {
+		writeErrors(w, http.StatusBadRequest, "invalid role or secret ID")
+		return
+	}
+
+	v.logins++
+	token := fmt.Sprintf("approle-token-%d", v.logins)
+	v.tokens[token] = true
+	writeJSON(w, map[string]any{"auth": map[string]any{"client_token": token, "lease_duration": 3600}})
+}
+
+func (v *fakeVault) decode(r *http.Request, out any) {
+	if err := json.NewDecoder(r.Body).Decode(out); err != nil {
+		v.t.Errorf("decoding request body: %v", err)
+	}
+}
+
+func writeJSON(w http.ResponseWriter, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+func writeErrors(w http.ResponseWriter, status int, errs ...string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if errs == nil {
+		errs = []string{}
+	}
+	_ = json.NewEncoder(w).Encode(map[string]any{"errors": errs})
+}
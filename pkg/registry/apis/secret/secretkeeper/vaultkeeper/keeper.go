@@ -0,0 +1,432 @@
+package vaultkeeper
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	secretv1beta1 "github.com/grafana/grafana/apps/secret/pkg/apis/secret/v1beta1"
+	"github.com/grafana/grafana/pkg/registry/apis/secret/contracts"
+	"github.com/grafana/grafana/pkg/registry/apis/secret/secretkeeper/metrics"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+const (
+	defaultMountPath        = "secret"
+	defaultPathPrefix       = "grafana"
+	defaultAppRoleMountPath = "approle"
+
+	// valueKey is the key of the secure value in the data of a secret.
+	valueKey = "value"
+	// versionKeyPrefix is the prefix of the custom metadata keys mapping a secure value
+	// version to the version of the secret storing it.
+	versionKeyPrefix = "grafana_version_"
+
+	requestTimeout = 30 * time.Second
+)
+
+// VaultKeeper stores secure values in a HashiCorp Vault KV version 2 secrets engine.
+//
+// Every secure value is stored in a single secret at <pathPrefix>/<namespace>/<name>.
+// The secure value versions are mapped onto the versions of the secret through its
+// custom metadata, so every version can be exposed and deleted independently.
+type VaultKeeper struct {
+	tracer      trace.Tracer
+	metrics     *metrics.KeeperMetrics
+	httpClient  *http.Client
+	credentials *credentialResolver
+
+	// tokens caches the tokens obtained with the AppRole auth method.
+	tokens   map[string]cachedToken
+	tokensMu sync.Mutex
+	now      func() time.Time
+}
+
+type cachedToken struct {
+	token string
+	// expires is zero for tokens that do not expire.
+	expires time.Time
+}
+
+var _ contracts.Keeper = (*VaultKeeper)(nil)
+
+func NewVaultKeeper(
+	tracer trace.Tracer,
+	cfg *setting.Cfg,
+	secureValues contracts.SecureValueMetadataStorage,
+	systemKeeper contracts.Keeper,
+	keeperMetrics *metrics.KeeperMetrics,
+) *VaultKeeper {
+	return &VaultKeeper{
+		tracer:     tracer,
+		metrics:    keeperMetrics,
+		httpClient: &http.Client{Timeout: requestTimeout},
+		credentials: &credentialResolver{
+			cfg:          cfg,
+			secureValues: secureValues,
+			systemKeeper: systemKeeper,
+		},
+		tokens: make(map[string]cachedToken),
+		now:    time.Now,
+	}
+}
+
+func (k *VaultKeeper) Store(ctx context.Context, cfg secretv1beta1.KeeperConfig, namespace, name string, version int64, exposedValueOrRef string) (contracts.ExternalID, error) {
+	ctx, span := k.tracer.Start(ctx, "VaultKeeper.Store", trace.WithAttributes(
+		attribute.String("namespace", namespace),
+		attribute.String("name", name),
+		attribute.Int64("version", version),
+	))
+	defer span.End()
+
+	start := time.Now()
+	vaultCfg, err := asVaultConfig(cfg)
+	if err != nil {
+		return "", err
+	}
+
+	mount, secretPath := secretLocation(vaultCfg, namespace, name)
+	err = k.withClient(ctx, vaultCfg, namespace, func(c *client) error {
+		secretVersion, err := c.writeData(ctx, mount, secretPath, map[string]string{valueKey: exposedValueOrRef})
+		if err != nil {
+			return fmt.Errorf("writing secret: %w", err)
+		}
+
+		if err := c.patchCustomMetadata(ctx, mount, secretPath, map[string]*string{versionKey(version): formatVersion(secretVersion)}); err != nil {
+			return fmt.Errorf("mapping secure value version: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("unable to store value in vault: %w", err)
+	}
+
+	k.metrics.StoreDuration.WithLabelValues(string(cfg.Type())).Observe(time.Since(start).Seconds())
+
+	return contracts.ExternalID(secretPath), nil
+}
+
+func (k *VaultKeeper) Update(ctx context.Context, cfg secretv1beta1.KeeperConfig, namespace, name string, version int64, exposedValueOrRef string) error {
+	ctx, span := k.tracer.Start(ctx, "VaultKeeper.Update", trace.WithAttributes(
+		attribute.String("namespace", namespace),
+		attribute.String("name", name),
+		attribute.Int64("version", version),
+	))
+	defer span.End()
+
+	start := time.Now()
+	vaultCfg, err := asVaultConfig(cfg)
+	if err != nil {
+		return err
+	}
+
+	mount, secretPath := secretLocation(vaultCfg, namespace, name)
+	err = k.withClient(ctx, vaultCfg, namespace, func(c *client) error {
+		previousVersion, err := mappedVersion(ctx, c, mount, secretPath, version)
+		if err != nil {
+			return err
+		}
+
+		secretVersion, err := c.writeData(ctx, mount, secretPath, map[string]string{valueKey: exposedValueOrRef})
+		if err != nil {
+			return fmt.Errorf("writing secret: %w", err)
+		}
+
+		if err := c.patchCustomMetadata(ctx, mount, secretPath, map[string]*string{versionKey(version): formatVersion(secretVersion)}); err != nil {
+			return fmt.Errorf("mapping secure value version: %w", err)
+		}
+
+		// The previous value must not be readable anymore.
+		if err := c.destroyVersions(ctx, mount, secretPath, previousVersion); err != nil {
+			return fmt.Errorf("destroying previous secret version: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update value in vault: %w", err)
+	}
+
+	k.metrics.UpdateDuration.WithLabelValues(string(cfg.Type())).Observe(time.Since(start).Seconds())
+
+	return nil
+}
+
+func (k *VaultKeeper) Expose(ctx context.Context, cfg secretv1beta1.KeeperConfig, namespace, name string, version int64) (secretv1beta1.ExposedSecureValue, error) {
+	ctx, span := k.tracer.Start(ctx, "VaultKeeper.Expose", trace.WithAttributes(
+		attribute.String("namespace", namespace),
+		attribute.String("name", name),
+		attribute.Int64("version", version),
+	))
+	defer span.End()
+
+	start := time.Now()
+	vaultCfg, err := asVaultConfig(cfg)
+	if err != nil {
+		return "", err
+	}
+
+	var value string
+	mount, secretPath := secretLocation(vaultCfg, namespace, name)
+	err = k.withClient(ctx, vaultCfg, namespace, func(c *client) error {
+		secretVersion, err := mappedVersion(ctx, c, mount, secretPath, version)
+		if err != nil {
+			return err
+		}
+
+		data, err := c.readData(ctx, mount, secretPath, secretVersion)
+		if err != nil {
+			if isStatus(err, http.StatusNotFound) {
+				return fmt.Errorf("secret version %d was deleted: %w", secretVersion, contracts.ErrSecureValueNotFound)
+			}
+			return fmt.Errorf("reading secret: %w", err)
+		}
+
+		v, ok := data.Data[valueKey]
+		if !ok {
+			return fmt.Errorf("secret version %d has no %q key: %w", secretVersion, valueKey, contracts.ErrSecureValueNotFound)
+		}
+		value = v
+		return nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("unable to get value from vault: %w", err)
+	}
+
+	exposedValue := secretv1beta1.NewExposedSecureValue(value)
+	k.metrics.ExposeDuration.WithLabelValues(string(cfg.Type())).Observe(time.Since(start).Seconds())
+
+	return exposedValue, nil
+}
+
+// Delete destroys the secret version storing the secure value version. The secret is
+// removed altogether once none of its versions are mapped to a secure value version.
+func (k *VaultKeeper) Delete(ctx context.Context, cfg secretv1beta1.KeeperConfig, namespace, name string, version int64) error {
+	ctx, span := k.tracer.Start(ctx, "VaultKeeper.Delete", trace.WithAttributes(
+		attribute.String("namespace", namespace),
+		attribute.String("name", name),
+		attribute.Int64("version", version),
+	))
+	defer span.End()
+
+	start := time.Now()
+	vaultCfg, err := asVaultConfig(cfg)
+	if err != nil {
+		return err
+	}
+
+	mount, secretPath := secretLocation(vaultCfg, namespace, name)
+	err = k.withClient(ctx, vaultCfg, namespace, func(c *client) error {
+		metadata, err := c.readMetadata(ctx, mount, secretPath)
+		if err != nil {
+			// Nothing to delete.
+			if isStatus(err, http.StatusNotFound) {
+				return nil
+			}
+			return fmt.Errorf("reading secret metadata: %w", err)
+		}
+
+		secretVersion, ok := metadata.CustomMetadata[versionKey(version)]
+		if !ok {
+			return nil
+		}
+
+		remaining := 0
+		for key := range metadata.CustomMetadata {
+			if strings.HasPrefix(key, versionKeyPrefix) && key != versionKey(version) {
+				remaining++
+			}
+		}
+		if remaining == 0 {
+			return c.deleteMetadata(ctx, mount, secretPath)
+		}
+
+		v, err := strconv.ParseInt(secretVersion, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid secret version %q: %w", secretVersion, err)
+		}
+		if err := c.destroyVersions(ctx, mount, secretPath, v); err != nil {
+			return fmt.Errorf("destroying secret version: %w", err)
+		}
+		return c.patchCustomMetadata(ctx, mount, secretPath, map[string]*string{versionKey(version): nil})
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete value from vault: %w", err)
+	}
+
+	k.metrics.DeleteDuration.WithLabelValues(string(cfg.Type())).Observe(time.Since(start).Seconds())
+
+	return nil
+}
+
+// withClient calls fn with a client authenticated for the keeper configuration.
+// Tokens obtained with AppRole are cached, and obtained again once when Vault rejects them.
+func (k *VaultKeeper) withClient(ctx context.Context, cfg *secretv1beta1.KeeperHashiCorpConfig, namespace string, fn func(c *client) error) error {
+	c, err := k.client(ctx, cfg, namespace)
+	if err != nil {
+		return err
+	}
+
+	err = fn(c)
+	if err == nil || c.cacheKey == "" || !isStatus(err, http.StatusForbidden) {
+		return err
+	}
+
+	k.tokensMu.Lock()
+	delete(k.tokens, c.cacheKey)
+	k.tokensMu.Unlock()
+
+	if c, err = k.client(ctx, cfg, namespace); err != nil {
+		return err
+	}
+	return fn(c)
+}
+
+func (k *VaultKeeper) client(ctx context.Context, cfg *secretv1beta1.KeeperHashiCorpConfig, namespace string) (*client, error) {
+	c := &client{
+		httpClient: k.httpClient,
+		address:    cfg.Address,
+	}
+	if cfg.Namespace != nil {
+		c.namespace = *cfg.Namespace
+	}
+
+	switch {
+	case cfg.Token != nil:
+		token, err := k.credentials.resolve(ctx, namespace, *cfg.Token)
+		if err != nil {
+			return nil, fmt.Errorf("resolving vault token: %w", err)
+		}
+		c.token = token
+
+	case cfg.AppRole != nil:
+		token, err := k.appRoleToken(ctx, c, cfg, namespace)
+		if err != nil {
+			return nil, err
+		}
+		c.token = token
+
+	default:
+		return nil, fmt.Errorf("vault keeper has no authentication configured")
+	}
+
+	return c, nil
+}
+
+func (k *VaultKeeper) appRoleToken(ctx context.Context, c *client, cfg *secretv1beta1.KeeperHashiCorpConfig, namespace string) (string, error) {
+	appRole := cfg.AppRole
+	secretID, err := k.credentials.resolve(ctx, namespace, appRole.SecretID)
+	if err != nil {
+		return "", fmt.Errorf("resolving approle secret id: %w", err)
+	}
+
+	c.cacheKey = appRoleCacheKey(cfg, namespace, secretID)
+	k.tokensMu.Lock()
+	cached, ok := k.tokens[c.cacheKey]
+	k.tokensMu.Unlock()
+	if ok && (cached.expires.IsZero() || k.now().Before(cached.expires)) {
+		return cached.token, nil
+	}
+
+	mount := defaultAppRoleMountPath
+	if appRole.MountPath != nil && *appRole.MountPath != "" {
+		mount = strings.Trim(*appRole.MountPath, "/")
+	}
+
+	auth, err := c.loginAppRole(ctx, mount, appRole.RoleID, secretID)
+	if err != nil {
+		return "", fmt.Errorf("logging in with approle: %w", err)
+	}
+
+	// Log in again before the token expires. Tokens without a lease duration do not expire.
+	var expires time.Time
+	if auth.LeaseDuration > 0 {
+		expires = k.now().Add(time.Duration(auth.LeaseDuration) * time.Second * 9 / 10)
+	}
+
+	k.tokensMu.Lock()
+	k.tokens[c.cacheKey] = cachedToken{token: auth.ClientToken, expires: expires}
+	k.tokensMu.Unlock()
+
+	return auth.ClientToken, nil
+}
+
+// appRoleCacheKey identifies the token of an AppRole login. Tokens are only shared by
+// keepers of the same namespace logging in with the same credentials, which are hashed
+// so the secret id is not kept in memory.
+func appRoleCacheKey(cfg *secretv1beta1.KeeperHashiCorpConfig, namespace, secretID string) string {
+	vaultNamespace, mount := "", ""
+	if cfg.Namespace != nil {
+		vaultNamespace = *cfg.Namespace
+	}
+	if cfg.AppRole.MountPath != nil {
+		mount = *cfg.AppRole.MountPath
+	}
+
+	credentials := sha256.Sum256([]byte(cfg.AppRole.RoleID + "\x00" + secretID))
+	return strings.Join([]string{namespace, cfg.Address, vaultNamespace, mount, hex.EncodeToString(credentials[:])}, "|")
+}
+
+// mappedVersion returns the version of the secret storing the secure value version.
+func mappedVersion(ctx context.Context, c *client, mount, secretPath string, version int64) (int64, error) {
+	metadata, err := c.readMetadata(ctx, mount, secretPath)
+	if err != nil {
+		if isStatus(err, http.StatusNotFound) {
+			return 0, fmt.Errorf("secret %q not found: %w", secretPath, contracts.ErrSecureValueNotFound)
+		}
+		return 0, fmt.Errorf("reading secret metadata: %w", err)
+	}
+
+	secretVersion, ok := metadata.CustomMetadata[versionKey(version)]
+	if !ok {
+		return 0, fmt.Errorf("version %d of secret %q not found: %w", version, secretPath, contracts.ErrSecureValueNotFound)
+	}
+
+	v, err := strconv.ParseInt(secretVersion, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid secret version %q: %w", secretVersion, err)
+	}
+	return v, nil
+}
+
+func asVaultConfig(cfg secretv1beta1.KeeperConfig) (*secretv1beta1.KeeperHashiCorpConfig, error) {
+	vaultCfg, ok := cfg.(*secretv1beta1.KeeperHashiCorpConfig)
+	if !ok || vaultCfg == nil {
+		return nil, fmt.Errorf("unexpected keeper config type %T", cfg)
+	}
+	return vaultCfg, nil
+}
+
+// secretLocation returns the mount path of the secrets engine and the path of the secret
+// storing the secure value.
+func secretLocation(cfg *secretv1beta1.KeeperHashiCorpConfig, namespace, name string) (string, string) {
+	mount := defaultMountPath
+	if cfg.MountPath != nil && *cfg.MountPath != "" {
+		mount = strings.Trim(*cfg.MountPath, "/")
+	}
+
+	prefix := defaultPathPrefix
+	if cfg.PathPrefix != nil {
+		prefix = strings.Trim(*cfg.PathPrefix, "/")
+	}
+
+	return mount, path.Join(prefix, namespace, name)
+}
+
+func versionKey(version int64) string {
+	return versionKeyPrefix + strconv.FormatInt(version, 10)
+}
+
+func formatVersion(version int64) *string {
+	v := strconv.FormatInt(version, 10)
+	return &v
+}
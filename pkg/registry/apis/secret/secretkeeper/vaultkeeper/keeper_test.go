@@ -0,0 +1,264 @@
+package vaultkeeper
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace/noop"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+
+	secretv1beta1 "github.com/grafana/grafana/apps/secret/pkg/apis/secret/v1beta1"
+	"github.com/grafana/grafana/pkg/registry/apis/secret/contracts"
+	"github.com/grafana/grafana/pkg/registry/apis/secret/secretkeeper/metrics"
+	"github.com/grafana/grafana/pkg/registry/apis/secret/xkube"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+func setupKeeper(t *testing.T, cfg *setting.Cfg, secureValues contracts.SecureValueMetadataStorage, systemKeeper contracts.Keeper) *VaultKeeper {
+	t.Helper()
+
+	if cfg == nil {
+		cfg = setting.NewCfg()
+		cfg.SecretsManagement.KeeperCredentialEnvAllowlist = []string{"TEST_VAULT_TOKEN", "TEST_VAULT_TOKEN_NOT_SET", "TEST_VAULT_SECRET_ID"}
+	}
+	return NewVaultKeeper(noop.NewTracerProvider().Tracer("test"), cfg, secureValues, systemKeeper, metrics.NewTestMetrics())
+}
+
+func tokenConfig(vault *fakeVault) *secretv1beta1.KeeperHashiCorpConfig {
+	return &secretv1beta1.KeeperHashiCorpConfig{
+		Address: vault.server.URL,
+		Token:   &secretv1beta1.KeeperCredentialValue{ValueFromEnv: "TEST_VAULT_TOKEN"},
+	}
+}
+
+func expose(t *testing.T, keeper *VaultKeeper, cfg secretv1beta1.KeeperConfig, namespace, name string, version int64) string {
+	t.Helper()
+
+	exposed, err := keeper.Expose(t.Context(), cfg, namespace, name, version)
+	require.NoError(t, err)
+	return exposed.DangerouslyExposeAndConsumeValue()
+}
+
+func TestVaultKeeper(t *testing.T) {
+	t.Setenv("TEST_VAULT_TOKEN", "root-token")
+
+	t.Run("versions are mapped onto the secret versions", func(t *testing.T) {
+		vault := newFakeVault(t)
+		keeper := setupKeeper(t, nil, nil, nil)
+		cfg := tokenConfig(vault)
+
+		externalID, err := keeper.Store(t.Context(), cfg, "ns1", "sv1", 1, "value1")
+		require.NoError(t, err)
+		assert.Equal(t, "grafana/ns1/sv1", externalID.String())
+
+		_, err = keeper.Store(t.Context(), cfg, "ns1", "sv1", 3, "value3")
+		require.NoError(t, err)
+
+		assert.Equal(t, "value1", expose(t, keeper, cfg, "ns1", "sv1", 1))
+		assert.Equal(t, "value3", expose(t, keeper, cfg, "ns1", "sv1", 3))
+		assert.Equal(t, map[string]string{"grafana_version_1": "1", "grafana_version_3": "2"}, vault.secret("grafana/ns1/sv1").customMetadata)
+
+		_, err = keeper.Expose(t.Context(), cfg, "ns1", "sv1", 2)
+		require.ErrorIs(t, err, contracts.ErrSecureValueNotFound)
+
+		_, err = keeper.Expose(t.Context(), cfg, "ns2", "sv1", 1)
+		require.ErrorIs(t, err, contracts.ErrSecureValueNotFound)
+	})
+
+	t.Run("updating a version destroys the previous value", func(t *testing.T) {
+		vault := newFakeVault(t)
+		keeper := setupKeeper(t, nil, nil, nil)
+		cfg := tokenConfig(vault)
+
+		_, err := keeper.Store(t.Context(), cfg, "ns1", "sv1", 1, "value1")
+		require.NoError(t, err)
+
+		require.NoError(t, keeper.Update(t.Context(), cfg, "ns1", "sv1", 1, "updated"))
+		assert.Equal(t, "updated", expose(t, keeper, cfg, "ns1", "sv1", 1))
+
+		secret := vault.secret("grafana/ns1/sv1")
+		assert.Nil(t, secret.versions[1])
+		assert.Equal(t, "2", secret.customMetadata["grafana_version_1"])
+
+		err = keeper.Update(t.Context(), cfg, "ns1", "sv1", 2, "value2")
+		require.ErrorIs(t, err, contracts.ErrSecureValueNotFound)
+	})
+
+	t.Run("deleting versions", func(t *testing.T) {
+		vault := newFakeVault(t)
+		keeper := setupKeeper(t, nil, nil, nil)
+		cfg := tokenConfig(vault)
+
+		_, err := keeper.Store(t.Context(), cfg, "ns1", "sv1", 1, "value1")
+		require.NoError(t, err)
+		_, err = keeper.Store(t.Context(), cfg, "ns1", "sv1", 2, "value2")
+		require.NoError(t, err)
+
+		require.NoError(t, keeper.Delete(t.Context(), cfg, "ns1", "sv1", 1))
+
+		_, err = keeper.Expose(t.Context(), cfg, "ns1", "sv1", 1)
+		require.ErrorIs(t, err, contracts.ErrSecureValueNotFound)
+		assert.Nil(t, vault.secret("grafana/ns1/sv1").versions[1])
+		assert.Equal(t, "value2", expose(t, keeper, cfg, "ns1", "sv1", 2))
+
+		// The secret is removed once its last version is deleted.
+		require.NoError(t, keeper.Delete(t.Context(), cfg, "ns1", "sv1", 2))
+		assert.Nil(t, vault.secret("grafana/ns1/sv1"))
+
+		// Deleting is idempotent.
+		require.NoError(t, keeper.Delete(t.Context(), cfg, "ns1", "sv1", 2))
+	})
+
+	t.Run("namespace, mount path and path prefix are used", func(t *testing.T) {
+		vault := newFakeVault(t)
+		vault.namespace = "team-a"
+		keeper := setupKeeper(t, nil, nil, nil)
+
+		cfg := tokenConfig(vault)
+		cfg.PathPrefix = ptr.To("/stacks/")
+		cfg.MountPath = ptr.To("secret/")
+
+		_, err := keeper.Store(t.Context(), cfg, "ns1", "sv1", 1, "value1")
+		require.Error(t, err, "the vault namespace is required")
+
+		cfg.Namespace = ptr.To("team-a")
+		_, err = keeper.Store(t.Context(), cfg, "ns1", "sv1", 1, "value1")
+		require.NoError(t, err)
+		require.NotNil(t, vault.secret("stacks/ns1/sv1"))
+	})
+
+	t.Run("authenticates with approle", func(t *testing.T) {
+		t.Setenv("TEST_VAULT_SECRET_ID", "secret-id")
+
+		vault := newFakeVault(t)
+		vault.roleID, vault.secretID = "role-id", "secret-id"
+		keeper := setupKeeper(t, nil, nil, nil)
+
+		cfg := &secretv1beta1.KeeperHashiCorpConfig{
+			Address: vault.server.URL,
+			AppRole: &secretv1beta1.KeeperHashiCorpAppRoleConfig{
+				RoleID:   "role-id",
+				SecretID: secretv1beta1.KeeperCredentialValue{ValueFromEnv: "TEST_VAULT_SECRET_ID"},
+			},
+		}
+
+		_, err := keeper.Store(t.Context(), cfg, "ns1", "sv1", 1, "value1")
+		require.NoError(t, err)
+		assert.Equal(t, "value1", expose(t, keeper, cfg, "ns1", "sv1", 1))
+		assert.Equal(t, 1, vault.logins, "the token should be cached")
+
+		// Log in again when the cached token is rejected.
+		vault.revokeTokens()
+		assert.Equal(t, "value1", expose(t, keeper, cfg, "ns1", "sv1", 1))
+		assert.Equal(t, 2, vault.logins)
+
+		// Tokens are not shared with other namespaces.
+		_, err = keeper.Store(t.Context(), cfg, "ns2", "sv1", 1, "value1")
+		require.NoError(t, err)
+		assert.Equal(t, 3, vault.logins)
+
+		cfg.AppRole.RoleID = "unknown"
+		_, err = keeper.Expose(t.Context(), cfg, "ns1", "sv1", 1)
+		require.Error(t, err)
+	})
+}
+
+func TestVaultKeeper_Credentials(t *testing.T) {
+	vault := newFakeVault(t)
+
+	t.Run("token from the configuration", func(t *testing.T) {
+		cfg := setting.NewCfg()
+		cfg.Raw.Section("secrets_manager.vault").Key("token").SetValue("root-token")
+		cfg.Raw.Section("security").Key("secret_key").SetValue("root-token")
+		cfg.SecretsManagement.KeeperCredentialConfigAllowlist = []string{"secrets_manager.vault.token", "secrets_manager.vault.missing"}
+		keeper := setupKeeper(t, cfg, nil, nil)
+
+		keeperCfg := &secretv1beta1.KeeperHashiCorpConfig{
+			Address: vault.server.URL,
+			Token:   &secretv1beta1.KeeperCredentialValue{ValueFromConfig: "secrets_manager.vault.token"},
+		}
+		_, err := keeper.Store(t.Context(), keeperCfg, "ns1", "sv1", 1, "value1")
+		require.NoError(t, err)
+
+		keeperCfg.Token.ValueFromConfig = "secrets_manager.vault.missing"
+		_, err = keeper.Store(t.Context(), keeperCfg, "ns1", "sv1", 1, "value1")
+		require.ErrorContains(t, err, "is not set")
+
+		keeperCfg.Token.ValueFromConfig = "security.secret_key"
+		_, err = keeper.Store(t.Context(), keeperCfg, "ns1", "sv1", 1, "value1")
+		require.ErrorContains(t, err, "is not allowed")
+	})
+
+	t.Run("token from a secure value", func(t *testing.T) {
+		secureValues := &fakeSecureValueStorage{secureValues: map[string]*secretv1beta1.SecureValue{
+			"vault-token": {ObjectMeta: metav1.ObjectMeta{Name: "vault-token"}, Status: secretv1beta1.SecureValueStatus{Version: 2}},
+			"in-vault":    {ObjectMeta: metav1.ObjectMeta{Name: "in-vault"}, Spec: secretv1beta1.SecureValueSpec{Keeper: ptr.To("vault")}},
+		}}
+		systemKeeper := &fakeSystemKeeper{values: map[string]string{"ns1/vault-token/2": "root-token"}}
+		keeper := setupKeeper(t, nil, secureValues, systemKeeper)
+
+		keeperCfg := &secretv1beta1.KeeperHashiCorpConfig{
+			Address: vault.server.URL,
+			Token:   &secretv1beta1.KeeperCredentialValue{SecureValueName: "vault-token"},
+		}
+		_, err := keeper.Store(t.Context(), keeperCfg, "ns1", "sv1", 1, "value1")
+		require.NoError(t, err)
+
+		keeperCfg.Token.SecureValueName = "in-vault"
+		_, err = keeper.Store(t.Context(), keeperCfg, "ns1", "sv1", 1, "value1")
+		require.ErrorContains(t, err, "not stored in the system keeper")
+	})
+
+	t.Run("missing environment variable", func(t *testing.T) {
+		keeper := setupKeeper(t, nil, nil, nil)
+
+		keeperCfg := &secretv1beta1.KeeperHashiCorpConfig{
+			Address: vault.server.URL,
+			Token:   &secretv1beta1.KeeperCredentialValue{ValueFromEnv: "TEST_VAULT_TOKEN_NOT_SET"},
+		}
+		_, err := keeper.Store(t.Context(), keeperCfg, "ns1", "sv1", 1, "value1")
+		require.ErrorContains(t, err, "is not set")
+	})
+
+	t.Run("environment variable not in the allowlist", func(t *testing.T) {
+		t.Setenv("TEST_VAULT_OTHER_TOKEN", "root-token")
+		keeper := setupKeeper(t, nil, nil, nil)
+
+		keeperCfg := &secretv1beta1.KeeperHashiCorpConfig{
+			Address: vault.server.URL,
+			Token:   &secretv1beta1.KeeperCredentialValue{ValueFromEnv: "TEST_VAULT_OTHER_TOKEN"},
+		}
+		_, err := keeper.Store(t.Context(), keeperCfg, "ns1", "sv1", 1, "value1")
+		require.ErrorContains(t, err, "is not allowed")
+	})
+}
+
+type fakeSecureValueStorage struct {
+	contracts.SecureValueMetadataStorage
+	secureValues map[string]*secretv1beta1.SecureValue
+}
+
+func (s *fakeSecureValueStorage) Read(_ context.Context, _ xkube.Namespace, name string, _ contracts.ReadOpts) (*secretv1beta1.SecureValue, error) {
+	sv, ok := s.secureValues[name]
+	if !ok {
+		return nil, contracts.ErrSecureValueNotFound
+	}
+	return sv, nil
+}
+
+type fakeSystemKeeper struct {
+	contracts.Keeper
+	values map[string]string
+}
+
+func (k *fakeSystemKeeper) Expose(_ context.Context, _ secretv1beta1.KeeperConfig, namespace, name string, version int64) (secretv1beta1.ExposedSecureValue, error) {
+	value, ok := k.values[namespace+"/"+name+"/"+strconv.FormatInt(version, 10)]
+	if !ok {
+		return "", contracts.ErrSecureValueNotFound
+	}
+	return secretv1beta1.NewExposedSecureValue(value), nil
+}
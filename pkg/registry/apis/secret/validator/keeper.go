@@ -95,9 +95,7 @@ func (v *keeperValidator) Validate(keeper *secretv1beta1.Keeper, oldKeeper *secr
 			errs = append(errs, field.Required(field.NewPath("spec", "hashiCorpVault", "address"), "an `address` is required"))
 		}
 
-		if err := validateCredentialValue(field.NewPath("spec", "hashiCorpVault", "token"), keeper.Spec.HashiCorpVault.Token); err != nil {
-			errs = append(errs, err)
-		}
+		errs = append(errs, validateHashiCorpAuth(keeper.Spec.HashiCorpVault)...)
 	}
 
 	return errs
@@ -134,6 +132,35 @@ func validateKeepers(keeper *secretv1beta1.Keeper) *field.Error {
 	return nil
 }
 
+func validateHashiCorpAuth(cfg *secretv1beta1.KeeperHashiCorpConfig) field.ErrorList {
+	errs := make(field.ErrorList, 0)
+	path := field.NewPath("spec", "hashiCorpVault")
+
+	switch {
+	case cfg.Token != nil && cfg.AppRole != nil:
+		errs = append(errs, field.Invalid(path, "token & appRole", "only one of `token` or `appRole` can be present at a time but found both"))
+
+	case cfg.AppRole != nil:
+		if cfg.AppRole.RoleID == "" {
+			errs = append(errs, field.Required(path.Child("appRole", "roleID"), "a `roleID` is required"))
+		}
+
+		if err := validateCredentialValue(path.Child("appRole", "secretID"), cfg.AppRole.SecretID); err != nil {
+			errs = append(errs, err)
+		}
+
+	case cfg.Token != nil:
+		if err := validateCredentialValue(path.Child("token"), *cfg.Token); err != nil {
+			errs = append(errs, err)
+		}
+
+	default:
+		errs = append(errs, field.Required(path.Child("token"), "one of `token` or `appRole` must be present"))
+	}
+
+	return errs
+}
+
 func validateCredentialValue(path *field.Path, credentials secretv1beta1.KeeperCredentialValue) *field.Error {
 	availableOptions := map[string]bool{
 		"secureValueName": credentials.SecureValueName != "",
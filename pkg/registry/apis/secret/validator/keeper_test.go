@@ -237,7 +237,7 @@ func TestValidateKeeper(t *testing.T) {
 				Description: "description",
 				HashiCorpVault: &secretv1beta1.KeeperHashiCorpConfig{
 					Address: "http://address",
-					Token: secretv1beta1.KeeperCredentialValue{
+					Token: &secretv1beta1.KeeperCredentialValue{
 						ValueFromConfig: "config.path.value",
 					},
 				},
@@ -256,7 +256,7 @@ func TestValidateKeeper(t *testing.T) {
 		t.Run("`token` must be present", func(t *testing.T) {
 			t.Run("at least one of the credential value must be present", func(t *testing.T) {
 				keeper := validKeeperHashiCorp.DeepCopy()
-				keeper.Spec.HashiCorpVault.Token = secretv1beta1.KeeperCredentialValue{}
+				keeper.Spec.HashiCorpVault.Token = &secretv1beta1.KeeperCredentialValue{}
 
 				errs := validator.Validate(keeper, nil, admission.Create)
 				require.Len(t, errs, 1)
@@ -265,7 +265,7 @@ func TestValidateKeeper(t *testing.T) {
 
 			t.Run("at most one of the credential value must be present", func(t *testing.T) {
 				keeper := validKeeperHashiCorp.DeepCopy()
-				keeper.Spec.HashiCorpVault.Token = secretv1beta1.KeeperCredentialValue{
+				keeper.Spec.HashiCorpVault.Token = &secretv1beta1.KeeperCredentialValue{
 					SecureValueName: "a",
 					ValueFromEnv:    "b",
 					ValueFromConfig: "c",
@@ -275,6 +275,48 @@ func TestValidateKeeper(t *testing.T) {
 				require.Len(t, errs, 1)
 				require.Equal(t, "spec.hashiCorpVault.token", errs[0].Field)
 			})
+
+			t.Run("unless `appRole` is present", func(t *testing.T) {
+				keeper := validKeeperHashiCorp.DeepCopy()
+				keeper.Spec.HashiCorpVault.Token = nil
+
+				errs := validator.Validate(keeper, nil, admission.Create)
+				require.Len(t, errs, 1)
+				require.Equal(t, "spec.hashiCorpVault.token", errs[0].Field)
+
+				keeper.Spec.HashiCorpVault.AppRole = &secretv1beta1.KeeperHashiCorpAppRoleConfig{
+					RoleID:   "role-id",
+					SecretID: secretv1beta1.KeeperCredentialValue{ValueFromEnv: "VAULT_SECRET_ID"},
+				}
+
+				errs = validator.Validate(keeper, nil, admission.Create)
+				require.Empty(t, errs)
+			})
+		})
+
+		t.Run("`appRole` validation", func(t *testing.T) {
+			t.Run("`token` and `appRole` cannot be present at the same time", func(t *testing.T) {
+				keeper := validKeeperHashiCorp.DeepCopy()
+				keeper.Spec.HashiCorpVault.AppRole = &secretv1beta1.KeeperHashiCorpAppRoleConfig{
+					RoleID:   "role-id",
+					SecretID: secretv1beta1.KeeperCredentialValue{ValueFromEnv: "VAULT_SECRET_ID"},
+				}
+
+				errs := validator.Validate(keeper, nil, admission.Create)
+				require.Len(t, errs, 1)
+				require.Equal(t, "spec.hashiCorpVault", errs[0].Field)
+			})
+
+			t.Run("`roleID` and `secretID` must be present", func(t *testing.T) {
+				keeper := validKeeperHashiCorp.DeepCopy()
+				keeper.Spec.HashiCorpVault.Token = nil
+				keeper.Spec.HashiCorpVault.AppRole = &secretv1beta1.KeeperHashiCorpAppRoleConfig{}
+
+				errs := validator.Validate(keeper, nil, admission.Create)
+				require.Len(t, errs, 2)
+				require.Equal(t, "spec.hashiCorpVault.appRole.roleID", errs[0].Field)
+				require.Equal(t, "spec.hashiCorpVault.appRole.secretID", errs[1].Field)
+			})
 		})
 	})
 
@@ -287,7 +329,7 @@ func TestValidateKeeper(t *testing.T) {
 				Description: "description",
 				HashiCorpVault: &secretv1beta1.KeeperHashiCorpConfig{
 					Address: "http://address",
-					Token: secretv1beta1.KeeperCredentialValue{
+					Token: &secretv1beta1.KeeperCredentialValue{
 						ValueFromConfig: "config.path.value",
 					},
 				},
@@ -319,7 +361,7 @@ func TestValidateKeeper(t *testing.T) {
 				Description: "description",
 				HashiCorpVault: &secretv1beta1.KeeperHashiCorpConfig{
 					Address: "http://address",
-					Token: secretv1beta1.KeeperCredentialValue{
+					Token: &secretv1beta1.KeeperCredentialValue{
 						ValueFromConfig: "config.path.value",
 					},
 				},
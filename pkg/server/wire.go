@@ -54,6 +54,7 @@ import (
 	secretvalidator "github.com/grafana/grafana/pkg/registry/apis/secret/validator"
 	appregistry "github.com/grafana/grafana/pkg/registry/apps"
 	"github.com/grafana/grafana/pkg/services/accesscontrol"
+	"github.com/grafana/grafana/pkg/services/accesscontrol/accessreview"
 	"github.com/grafana/grafana/pkg/services/accesscontrol/acimpl"
 	"github.com/grafana/grafana/pkg/services/accesscontrol/dualwrite"
 	"github.com/grafana/grafana/pkg/services/accesscontrol/ossaccesscontrol"
@@ -132,6 +133,8 @@ import (
 	publicdashboardsmetric "github.com/grafana/grafana/pkg/services/publicdashboards/metric"
 	publicdashboardsService "github.com/grafana/grafana/pkg/services/publicdashboards/service"
 	"github.com/grafana/grafana/pkg/services/query"
+	"github.com/grafana/grafana/pkg/services/queryaudit"
+	"github.com/grafana/grafana/pkg/services/querycost"
 	"github.com/grafana/grafana/pkg/services/queryhistory"
 	"github.com/grafana/grafana/pkg/services/quota/quotaimpl"
 	"github.com/grafana/grafana/pkg/services/rendering"
@@ -170,9 +173,13 @@ import (
 	"github.com/grafana/grafana/pkg/services/team/teamimpl"
 	tempuser "github.com/grafana/grafana/pkg/services/temp_user"
 	"github.com/grafana/grafana/pkg/services/temp_user/tempuserimpl"
+	"github.com/grafana/grafana/pkg/services/twofactor"
+	"github.com/grafana/grafana/pkg/services/twofactor/twofactorimpl"
 	"github.com/grafana/grafana/pkg/services/updatemanager"
 	"github.com/grafana/grafana/pkg/services/user"
 	"github.com/grafana/grafana/pkg/services/user/userimpl"
+	"github.com/grafana/grafana/pkg/services/webauthn"
+	"github.com/grafana/grafana/pkg/services/webauthn/webauthnimpl"
 	"github.com/grafana/grafana/pkg/setting"
 	legacydualwrite "github.com/grafana/grafana/pkg/storage/legacysql/dualwrite"
 	secretdatabase "github.com/grafana/grafana/pkg/storage/secret/database"
@@ -218,6 +225,8 @@ var wireBasicSet = wire.NewSet(
 	New,
 	api.ProvideHTTPServer,
 	query.ProvideService,
+	queryaudit.ProvideService,
+	querycost.ProvideService,
 	wire.Bind(new(query.Service), new(*query.ServiceImpl)),
 	bus.ProvideBus,
 	wire.Bind(new(bus.Bus), new(*bus.InProcBus)),
@@ -295,6 +304,7 @@ var wireBasicSet = wire.NewSet(
 	withOTelSet,
 	testdatasource.ProvideService,
 	ldapapi.ProvideService,
+	accessreview.ProvideService,
 	opentsdb.ProvideService,
 	socialimpl.ProvideService,
 	influxdb.ProvideService,
@@ -389,6 +399,10 @@ var wireBasicSet = wire.NewSet(
 	tempuserimpl.ProvideService,
 	loginattemptimpl.ProvideService,
 	wire.Bind(new(loginattempt.Service), new(*loginattemptimpl.Service)),
+	twofactorimpl.ProvideService,
+	wire.Bind(new(twofactor.Service), new(*twofactorimpl.Service)),
+	webauthnimpl.ProvideService,
+	wire.Bind(new(webauthn.Service), new(*webauthnimpl.Service)),
 	secretsMigrations.ProvideDataSourceMigrationService,
 	secretsMigrations.ProvideSecretMigrationProvider,
 	wire.Bind(new(secretsMigrations.SecretMigrationProvider), new(*secretsMigrations.SecretMigrationProviderImpl)),
@@ -0,0 +1,393 @@
+package accessreview
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel"
+
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/infra/db"
+	ac "github.com/grafana/grafana/pkg/services/accesscontrol"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	"github.com/grafana/grafana/pkg/services/folder"
+)
+
+var tracer = otel.Tracer("github.com/grafana/grafana/pkg/services/accesscontrol/accessreview")
+
+var ErrInvalidScope = errors.New("scope must identify a single resource")
+
+// Source is how a role was assigned to a user.
+type Source string
+
+const (
+	// SourceUser is a role assigned to the user directly, including the user's managed permissions.
+	SourceUser Source = "user"
+	// SourceTeam is a role assigned to a team the user is a member of.
+	SourceTeam Source = "team"
+	// SourceBasicRole is a role assigned to the basic role the user has in the organization.
+	SourceBasicRole Source = "basic_role"
+	// SourceServerAdmin is a role assigned to Grafana server administrators.
+	SourceServerAdmin Source = "server_admin"
+)
+
+// adminActions are the actions letting a user change what others have access to,
+// in addition to the actions managing the permissions of resources.
+var adminActions = []string{
+	ac.ActionOrgUsersAdd,
+	ac.ActionOrgUsersWrite,
+	ac.ActionOrgUsersRemove,
+	ac.ActionUsersWrite,
+	ac.ActionTeamsWrite,
+}
+
+// permissionsWriteSuffix is the suffix of the actions managing the permissions of resources, e.g. folders.permissions:write.
+const permissionsWriteSuffix = ".permissions:write"
+
+// IsAdminAction returns true if the action lets a user change what others have access to.
+func IsAdminAction(action string) bool {
+	if strings.HasSuffix(action, permissionsWriteSuffix) {
+		return true
+	}
+	for _, a := range adminActions {
+		if a == action {
+			return true
+		}
+	}
+	return false
+}
+
+// Subject is the user a permission is granted to.
+type Subject struct {
+	UserID           int64     `json:"userId"`
+	Login            string    `json:"login"`
+	Email            string    `json:"email"`
+	IsServiceAccount bool      `json:"isServiceAccount"`
+	LastSeenAt       time.Time `json:"lastSeenAt"`
+}
+
+// Derivation explains how a user got a permission.
+type Derivation struct {
+	Source Source `json:"source"`
+	// Role is the name of the role holding the permission.
+	Role string `json:"role"`
+	// TeamID and TeamName are set for roles assigned to a team.
+	TeamID   int64  `json:"teamId,omitempty"`
+	TeamName string `json:"teamName,omitempty"`
+	// BasicRole is set for roles assigned to a basic role.
+	BasicRole string `json:"basicRole,omitempty"`
+	// InheritedFrom is the scope of the folder a resource inherits the permission from.
+	InheritedFrom string `json:"inheritedFrom,omitempty"`
+}
+
+// Chain returns the derivation as a human readable chain, e.g. team:Editors → managed:teams:1:permissions → folders:uid:abc.
+func (d Derivation) Chain() string {
+	parts := make([]string, 0, 3)
+	switch d.Source {
+	case SourceTeam:
+		parts = append(parts, fmt.Sprintf("team:%s", d.TeamName))
+	case SourceBasicRole:
+		parts = append(parts, fmt.Sprintf("basic:%s", d.BasicRole))
+	case SourceServerAdmin:
+		parts = append(parts, ac.RoleGrafanaAdmin)
+	default:
+		parts = append(parts, string(d.Source))
+	}
+	parts = append(parts, d.Role)
+	if d.InheritedFrom != "" {
+		parts = append(parts, d.InheritedFrom)
+	}
+	return strings.Join(parts, " → ")
+}
+
+// Grant is a permission a user has together with how the user got it.
+type Grant struct {
+	Subject
+	Action     string     `json:"action"`
+	Scope      string     `json:"scope"`
+	Derivation Derivation `json:"derivation"`
+}
+
+// Assignment is a role a user has together with how the role was assigned.
+type Assignment struct {
+	Subject
+	Derivation Derivation `json:"derivation"`
+}
+
+// FolderStore returns the ancestors of a folder, satisfied by folder.Service and the legacy folder store.
+type FolderStore interface {
+	GetParents(ctx context.Context, q folder.GetParentsQuery) ([]*folder.Folder, error)
+}
+
+// DashboardStore returns a dashboard, satisfied by dashboards.DashboardService and the legacy dashboard store.
+type DashboardStore interface {
+	GetDashboard(ctx context.Context, query *dashboards.GetDashboardQuery) (*dashboards.Dashboard, error)
+}
+
+// Service reviews the effective permissions of users and resources, and how they were granted.
+type Service struct {
+	store *store
+	// acService holds the permissions of the basic roles and of the Grafana Admin role, which aren't stored in the database.
+	acService      ac.Service
+	actionResolver ac.ActionResolver
+	folders        FolderStore
+	dashboards     DashboardStore
+	now            func() time.Time
+}
+
+func ProvideService(sql db.DB, router routing.RouteRegister, accessControl ac.AccessControl, acService ac.Service, actionResolver ac.ActionResolver,
+	folderSvc folder.Service, dashboardSvc dashboards.DashboardService) *Service {
+	s := NewService(sql, acService, actionResolver, folderSvc, dashboardSvc)
+	s.registerAPIEndpoints(router, accessControl)
+	return s
+}
+
+// NewService returns a service without API endpoints.
+func NewService(sql db.DB, acService ac.Service, actionResolver ac.ActionResolver, folders FolderStore, dashboards DashboardStore) *Service {
+	return &Service{
+		store:          &store{sql: sql},
+		acService:      acService,
+		actionResolver: actionResolver,
+		folders:        folders,
+		dashboards:     dashboards,
+		now:            time.Now,
+	}
+}
+
+// UserAccess returns the permissions of a user in the organization. The action is optional.
+func (s *Service) UserAccess(ctx context.Context, orgID, userID int64, action string) ([]Grant, error) {
+	ctx, span := tracer.Start(ctx, "accessreview.UserAccess")
+	defer span.End()
+
+	return s.searchGrants(ctx, grantsQuery{OrgID: orgID, UserID: userID, Action: action})
+}
+
+// ResourceAccess returns the permissions users have on the resource identified by the scope, including the permissions
+// granted on the folders the resource is in and the wildcards matching it. The action is optional.
+func (s *Service) ResourceAccess(ctx context.Context, orgID int64, scope, action string) ([]Grant, error) {
+	ctx, span := tracer.Start(ctx, "accessreview.ResourceAccess")
+	defer span.End()
+
+	inherited, err := s.inheritedScopes(ctx, orgID, scope)
+	if err != nil {
+		return nil, err
+	}
+
+	scopes := append([]string{scope}, inherited...)
+	for _, prefix := range uniquePrefixes(scopes) {
+		scopes = append(scopes, ac.WildcardsFromPrefix(prefix)...)
+	}
+
+	grants, err := s.searchGrants(ctx, grantsQuery{OrgID: orgID, Scopes: unique(scopes), Action: action})
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range grants {
+		for _, folderScope := range inherited {
+			if grants[i].Scope == folderScope {
+				grants[i].Derivation.InheritedFrom = folderScope
+			}
+		}
+	}
+	return grants, nil
+}
+
+// AdminGrants returns the permissions letting users change what others have access to in the organization.
+func (s *Service) AdminGrants(ctx context.Context, orgID int64) ([]Grant, error) {
+	ctx, span := tracer.Start(ctx, "accessreview.AdminGrants")
+	defer span.End()
+
+	return s.searchGrants(ctx, grantsQuery{OrgID: orgID, AdminOnly: true})
+}
+
+// StaleAssignments returns the roles of the users of the organization who haven't been seen for the given duration.
+// Service accounts aren't included, as they don't log in.
+func (s *Service) StaleAssignments(ctx context.Context, orgID int64, inactiveFor time.Duration) ([]Assignment, error) {
+	ctx, span := tracer.Start(ctx, "accessreview.StaleAssignments")
+	defer span.End()
+
+	query := assignmentsQuery{OrgID: orgID, LastSeenBefore: s.now().Add(-inactiveFor), ExcludeServiceAccounts: true}
+	rows, err := s.store.searchAssignments(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	static, err := s.staticAssignments(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	assignments := make([]Assignment, 0, len(rows)+len(static))
+	for _, row := range rows {
+		assignments = append(assignments, Assignment{Subject: row.subject(), Derivation: row.derivation()})
+	}
+	for _, a := range static {
+		assignments = append(assignments, a.Assignment)
+	}
+
+	slices.SortFunc(assignments, func(a, b Assignment) int {
+		return cmp.Or(
+			a.LastSeenAt.Compare(b.LastSeenAt),
+			cmp.Compare(a.Login, b.Login),
+			cmp.Compare(a.Derivation.Source, b.Derivation.Source),
+			cmp.Compare(a.Derivation.Role, b.Derivation.Role),
+		)
+	})
+	return assignments, nil
+}
+
+// searchGrants returns the permissions of the users of the organization matching the query. Like the permissions
+// used to evaluate access, they include the permissions of the basic roles and have their action sets expanded.
+func (s *Service) searchGrants(ctx context.Context, query grantsQuery) ([]Grant, error) {
+	ctx, span := tracer.Start(ctx, "accessreview.searchGrants")
+	defer span.End()
+
+	assignmentsQuery := assignmentsQuery{OrgID: query.OrgID, UserID: query.UserID}
+	rows, err := s.store.searchAssignments(ctx, assignmentsQuery)
+	if err != nil {
+		return nil, err
+	}
+
+	permissionsQuery := permissionsQuery{OrgID: query.OrgID, UserID: query.UserID, Scopes: query.Scopes}
+	if query.Action != "" {
+		// action sets holding the action, e.g. folders:edit for dashboards:write, are stored as such
+		permissionsQuery.Actions = append([]string{query.Action}, s.actionResolver.ResolveAction(query.Action)...)
+	}
+	permissions, err := s.store.rolePermissions(ctx, permissionsQuery)
+	if err != nil {
+		return nil, err
+	}
+
+	grants := make([]Grant, 0)
+	for _, row := range rows {
+		for _, p := range s.actionResolver.ExpandActionSets(permissions[row.RoleID]) {
+			if query.matches(p) {
+				grants = append(grants, Grant{Subject: row.subject(), Action: p.Action, Scope: p.Scope, Derivation: row.derivation()})
+			}
+		}
+	}
+
+	static, err := s.staticAssignments(ctx, assignmentsQuery)
+	if err != nil {
+		return nil, err
+	}
+	for _, a := range static {
+		for _, p := range a.role.Permissions {
+			if query.matches(p) {
+				grants = append(grants, Grant{Subject: a.Subject, Action: p.Action, Scope: p.Scope, Derivation: a.Derivation})
+			}
+		}
+	}
+
+	slices.SortFunc(grants, func(a, b Grant) int {
+		return cmp.Or(
+			cmp.Compare(a.Login, b.Login),
+			cmp.Compare(a.Derivation.Source, b.Derivation.Source),
+			cmp.Compare(a.Derivation.Role, b.Derivation.Role),
+			cmp.Compare(a.Action, b.Action),
+			cmp.Compare(a.Scope, b.Scope),
+		)
+	})
+	return grants, nil
+}
+
+type staticAssignment struct {
+	Assignment
+	role *ac.RoleDTO
+}
+
+// staticAssignments returns the basic roles of the users of the organization and the Grafana Admin role of server
+// admins, with the permissions declared for them when Grafana started.
+func (s *Service) staticAssignments(ctx context.Context, query assignmentsQuery) ([]staticAssignment, error) {
+	rows, err := s.store.searchBasicRoles(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	roles := s.acService.GetStaticRoles(ctx)
+	assignments := make([]staticAssignment, 0, len(rows))
+	for _, row := range rows {
+		if role, ok := roles[row.Role]; ok {
+			assignments = append(assignments, staticAssignment{
+				Assignment: Assignment{Subject: row.subject(), Derivation: Derivation{Source: SourceBasicRole, Role: role.Name, BasicRole: row.Role}},
+				role:       role,
+			})
+		}
+		if role, ok := roles[ac.RoleGrafanaAdmin]; ok && row.IsAdmin {
+			assignments = append(assignments, staticAssignment{
+				Assignment: Assignment{Subject: row.subject(), Derivation: Derivation{Source: SourceServerAdmin, Role: role.Name}},
+				role:       role,
+			})
+		}
+	}
+	return assignments, nil
+}
+
+// inheritedScopes returns the scopes of the folders, from the closest one, the resource inherits permissions from.
+func (s *Service) inheritedScopes(ctx context.Context, orgID int64, scope string) ([]string, error) {
+	kind, attribute, identifier := ac.SplitScope(scope)
+	if identifier == "" || identifier == "*" || attribute == "*" || kind == identifier {
+		return nil, ErrInvalidScope
+	}
+
+	var folderUID string
+	switch ac.ScopePrefix(scope) {
+	case dashboards.ScopeDashboardsPrefix:
+		dashboard, err := s.dashboards.GetDashboard(ctx, &dashboards.GetDashboardQuery{UID: identifier, OrgID: orgID})
+		if err != nil {
+			return nil, err
+		}
+		if dashboard.FolderUID == "" {
+			return nil, nil
+		}
+		folderUID = dashboard.FolderUID
+	case dashboards.ScopeFoldersPrefix:
+		parents, err := s.folders.GetParents(ctx, folder.GetParentsQuery{UID: identifier, OrgID: orgID})
+		if err != nil {
+			return nil, err
+		}
+		return folderScopes(parents), nil
+	default:
+		return nil, nil
+	}
+
+	parents, err := s.folders.GetParents(ctx, folder.GetParentsQuery{UID: folderUID, OrgID: orgID})
+	if err != nil {
+		return nil, err
+	}
+	return append([]string{dashboards.ScopeFoldersProvider.GetResourceScopeUID(folderUID)}, folderScopes(parents)...), nil
+}
+
+// folderScopes returns the scopes of the ancestors of a folder, from the closest one.
+func folderScopes(parents []*folder.Folder) []string {
+	scopes := make([]string, 0, len(parents))
+	for i := len(parents) - 1; i >= 0; i-- {
+		scopes = append(scopes, dashboards.ScopeFoldersProvider.GetResourceScopeUID(parents[i].UID))
+	}
+	return scopes
+}
+
+func uniquePrefixes(scopes []string) []string {
+	prefixes := make([]string, 0, len(scopes))
+	for _, scope := range scopes {
+		prefixes = append(prefixes, ac.ScopePrefix(scope))
+	}
+	return unique(prefixes)
+}
+
+func unique(values []string) []string {
+	seen := make(map[string]bool, len(values))
+	result := make([]string, 0, len(values))
+	for _, v := range values {
+		if !seen[v] {
+			seen[v] = true
+			result = append(result, v)
+		}
+	}
+	return result
+}
@@ -0,0 +1,285 @@
+package accessreview
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/infra/localcache"
+	"github.com/grafana/grafana/pkg/infra/tracing"
+	ac "github.com/grafana/grafana/pkg/services/accesscontrol"
+	"github.com/grafana/grafana/pkg/services/accesscontrol/acimpl"
+	acdb "github.com/grafana/grafana/pkg/services/accesscontrol/database"
+	"github.com/grafana/grafana/pkg/services/accesscontrol/permreg"
+	rs "github.com/grafana/grafana/pkg/services/accesscontrol/resourcepermissions"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	"github.com/grafana/grafana/pkg/services/featuremgmt"
+	"github.com/grafana/grafana/pkg/services/folder"
+	"github.com/grafana/grafana/pkg/services/org"
+	"github.com/grafana/grafana/pkg/services/org/orgimpl"
+	"github.com/grafana/grafana/pkg/services/quota/quotatest"
+	"github.com/grafana/grafana/pkg/services/supportbundles/supportbundlestest"
+	"github.com/grafana/grafana/pkg/services/team"
+	"github.com/grafana/grafana/pkg/services/team/teamimpl"
+	"github.com/grafana/grafana/pkg/services/user"
+	"github.com/grafana/grafana/pkg/services/user/userimpl"
+	"github.com/grafana/grafana/pkg/tests/testsuite"
+	"github.com/grafana/grafana/pkg/util/testutil"
+)
+
+func TestMain(m *testing.M) {
+	testsuite.Run(m)
+}
+
+// fakeFolders holds the parent of each folder.
+type fakeFolders map[string]string
+
+func (f fakeFolders) GetParents(_ context.Context, q folder.GetParentsQuery) ([]*folder.Folder, error) {
+	if _, ok := f[q.UID]; !ok {
+		return nil, folder.ErrFolderNotFound
+	}
+	parents := []*folder.Folder{}
+	for uid := f[q.UID]; uid != ""; uid = f[uid] {
+		parents = append([]*folder.Folder{{UID: uid}}, parents...)
+	}
+	return parents, nil
+}
+
+// fakeDashboards holds the folder of each dashboard.
+type fakeDashboards map[string]string
+
+func (f fakeDashboards) GetDashboard(_ context.Context, q *dashboards.GetDashboardQuery) (*dashboards.Dashboard, error) {
+	folderUID, ok := f[q.UID]
+	if !ok {
+		return nil, dashboards.ErrDashboardNotFound
+	}
+	return &dashboards.Dashboard{UID: q.UID, OrgID: q.OrgID, FolderUID: folderUID}, nil
+}
+
+type testEnv struct {
+	svc    *Service
+	users  map[string]int64
+	teamID int64
+}
+
+// setupTestEnv creates the following users in org 1, along with a folder tree parent > child > dashboard:
+//   - carol, an Admin and a Grafana server admin, seen 10 days ago
+//   - alice, an Editor member of the editors team, seen today
+//   - bob, a Viewer, seen 100 days ago
+//   - a service account without basic role, seen 100 days ago
+//
+// Viewers are granted a fixed role reading the dashboards of the parent folder, server admins a fixed role writing
+// teams, and bob the folders:admin action set on a team folder.
+func setupTestEnv(t *testing.T) testEnv {
+	t.Helper()
+	ctx := context.Background()
+
+	sql, cfg := db.InitTestDBWithCfg(t)
+	cfg.AutoAssignOrg = true
+	cfg.AutoAssignOrgRole = "Viewer"
+	cfg.AutoAssignOrgId = 1
+
+	teamSvc, err := teamimpl.ProvideService(sql, cfg, tracing.InitializeTracerForTest())
+	require.NoError(t, err)
+	orgSvc, err := orgimpl.ProvideService(sql, cfg, quotatest.New(false, nil))
+	require.NoError(t, err)
+	_, err = orgSvc.GetOrCreate(ctx, "test")
+	require.NoError(t, err)
+	userSvc, err := userimpl.ProvideService(
+		sql, orgSvc, cfg, teamSvc, localcache.ProvideService(), tracing.InitializeTracerForTest(),
+		quotatest.New(false, nil), supportbundlestest.NewFakeBundleService(),
+	)
+	require.NoError(t, err)
+
+	now := time.Now()
+	env := testEnv{users: map[string]int64{}}
+	for _, u := range []struct {
+		login            string
+		role             org.RoleType
+		isAdmin          bool
+		isServiceAccount bool
+		lastSeenAt       time.Time
+	}{
+		{login: "carol", role: org.RoleAdmin, isAdmin: true, lastSeenAt: now.AddDate(0, 0, -10)},
+		{login: "alice", role: org.RoleEditor, lastSeenAt: now},
+		{login: "bob", role: org.RoleViewer, lastSeenAt: now.AddDate(0, 0, -100)},
+		{login: "sa-backup", role: org.RoleNone, isServiceAccount: true, lastSeenAt: now.AddDate(0, 0, -100)},
+	} {
+		created, err := userSvc.Create(ctx, &user.CreateUserCommand{Login: u.login, OrgID: 1, IsAdmin: u.isAdmin, IsServiceAccount: u.isServiceAccount})
+		require.NoError(t, err)
+		require.NoError(t, orgSvc.UpdateOrgUser(ctx, &org.UpdateOrgUserCommand{Role: u.role, OrgID: 1, UserID: created.ID}))
+		require.NoError(t, sql.WithDbSession(ctx, func(sess *db.Session) error {
+			_, err := sess.Exec("UPDATE "+sql.GetDialect().Quote("user")+" SET last_seen_at = ? WHERE id = ?", u.lastSeenAt, created.ID)
+			return err
+		}))
+		env.users[u.login] = created.ID
+	}
+
+	editors, err := teamSvc.CreateTeam(ctx, &team.CreateTeamCommand{Name: "editors", OrgID: 1})
+	require.NoError(t, err)
+	require.NoError(t, sql.WithDbSession(ctx, func(sess *db.Session) error {
+		return teamimpl.AddOrUpdateTeamMemberHook(sess, env.users["alice"], 1, editors.ID, false, team.PermissionTypeMember)
+	}))
+	env.teamID = editors.ID
+
+	permission := func(actions []string, resource, id string) rs.SetResourcePermissionCommand {
+		return rs.SetResourcePermissionCommand{Actions: actions, Resource: resource, ResourceAttribute: "uid", ResourceID: id}
+	}
+	_, err = rs.NewStore(cfg, sql, featuremgmt.WithFeatures()).SetResourcePermissions(ctx, 1, []rs.SetResourcePermissionsCommand{
+		{TeamID: editors.ID, SetResourcePermissionCommand: permission([]string{dashboards.ActionDashboardsWrite}, "folders", "parent")},
+		{User: ac.User{ID: env.users["alice"]}, SetResourcePermissionCommand: permission([]string{dashboards.ActionFoldersPermissionsWrite}, "folders", "child")},
+		{User: ac.User{ID: env.users["bob"]}, SetResourcePermissionCommand: permission([]string{dashboards.ActionDashboardsRead}, "dashboards", "dash")},
+		{User: ac.User{ID: env.users["bob"]}, SetResourcePermissionCommand: permission([]string{"folders:admin"}, "folders", "team")},
+		{User: ac.User{ID: env.users["sa-backup"]}, SetResourcePermissionCommand: permission([]string{dashboards.ActionDashboardsRead}, "dashboards", "other")},
+		{BuiltinRole: string(org.RoleViewer), SetResourcePermissionCommand: permission([]string{dashboards.ActionDashboardsRead}, "folders", "child")},
+		{BuiltinRole: ac.RoleGrafanaAdmin, SetResourcePermissionCommand: permission([]string{dashboards.ActionFoldersPermissionsWrite}, "folders", "*")},
+	}, rs.ResourceHooks{})
+	require.NoError(t, err)
+
+	actionSets := rs.NewActionSetService()
+	actionSets.StoreActionSet("folders:admin", []string{dashboards.ActionFoldersRead, dashboards.ActionDashboardsRead, dashboards.ActionFoldersPermissionsWrite})
+
+	acService := acimpl.ProvideOSSService(cfg, acdb.ProvideService(sql), actionSets, localcache.New(0, 0), featuremgmt.WithFeatures(),
+		tracing.InitializeTracerForTest(), sql, permreg.ProvidePermissionRegistry(), nil)
+	require.NoError(t, acService.DeclareFixedRoles(
+		ac.RoleRegistration{
+			Role:   ac.RoleDTO{Name: "fixed:test:reader", Permissions: []ac.Permission{{Action: dashboards.ActionDashboardsRead, Scope: "folders:uid:parent"}}},
+			Grants: []string{string(org.RoleViewer)},
+		},
+		ac.RoleRegistration{
+			Role:   ac.RoleDTO{Name: "fixed:test:teams:writer", Permissions: []ac.Permission{{Action: ac.ActionTeamsWrite, Scope: ac.ScopeTeamsAll}}},
+			Grants: []string{ac.RoleGrafanaAdmin},
+		},
+	))
+	require.NoError(t, acService.RegisterFixedRoles(ctx))
+
+	env.svc = NewService(sql, acService, actionSets, fakeFolders{"parent": "", "child": "parent", "team": ""}, fakeDashboards{"dash": "child", "other": ""})
+	env.svc.now = func() time.Time { return now }
+	return env
+}
+
+type grantSummary struct {
+	login      string
+	action     string
+	scope      string
+	derivation string
+}
+
+func summarize(grants []Grant) []grantSummary {
+	result := make([]grantSummary, 0, len(grants))
+	for _, g := range grants {
+		result = append(result, grantSummary{login: g.Login, action: g.Action, scope: g.Scope, derivation: g.Derivation.Chain()})
+	}
+	return result
+}
+
+func TestIntegrationService(t *testing.T) {
+	testutil.SkipIntegrationTestInShortMode(t)
+
+	env := setupTestEnv(t)
+	ctx := context.Background()
+
+	t.Run("resource access includes the permissions inherited from folders and wildcards", func(t *testing.T) {
+		grants, err := env.svc.ResourceAccess(ctx, 1, "dashboards:uid:dash", "")
+		require.NoError(t, err)
+
+		assert.ElementsMatch(t, []grantSummary{
+			{"alice", dashboards.ActionDashboardsWrite, "folders:uid:parent", "team:editors → managed:teams:1:permissions → folders:uid:parent"},
+			{"alice", dashboards.ActionFoldersPermissionsWrite, "folders:uid:child", "user → managed:users:2:permissions → folders:uid:child"},
+			{"bob", dashboards.ActionDashboardsRead, "dashboards:uid:dash", "user → managed:users:3:permissions"},
+			{"bob", dashboards.ActionDashboardsRead, "folders:uid:child", "basic:Viewer → managed:builtins:viewer:permissions → folders:uid:child"},
+			{"carol", dashboards.ActionFoldersPermissionsWrite, "folders:uid:*", "Grafana Admin → managed:builtins:grafana admin:permissions"},
+			{"alice", dashboards.ActionDashboardsRead, "folders:uid:parent", "basic:Editor → basic:editor → folders:uid:parent"},
+			{"bob", dashboards.ActionDashboardsRead, "folders:uid:parent", "basic:Viewer → basic:viewer → folders:uid:parent"},
+			{"carol", dashboards.ActionDashboardsRead, "folders:uid:parent", "basic:Admin → basic:admin → folders:uid:parent"},
+		}, summarize(grants))
+	})
+
+	t.Run("resource access expands action sets", func(t *testing.T) {
+		grants, err := env.svc.ResourceAccess(ctx, 1, "folders:uid:team", dashboards.ActionDashboardsRead)
+		require.NoError(t, err)
+
+		assert.Equal(t, []grantSummary{
+			{"bob", dashboards.ActionDashboardsRead, "folders:uid:team", "user → managed:users:3:permissions"},
+		}, summarize(grants))
+	})
+
+	t.Run("resource access filtered by action", func(t *testing.T) {
+		grants, err := env.svc.ResourceAccess(ctx, 1, "folders:uid:child", dashboards.ActionDashboardsWrite)
+		require.NoError(t, err)
+
+		require.Len(t, grants, 1)
+		assert.Equal(t, env.users["alice"], grants[0].UserID)
+		assert.Equal(t, SourceTeam, grants[0].Derivation.Source)
+		assert.Equal(t, env.teamID, grants[0].Derivation.TeamID)
+		assert.Equal(t, "folders:uid:parent", grants[0].Derivation.InheritedFrom)
+	})
+
+	t.Run("resource access requires a single resource", func(t *testing.T) {
+		for _, scope := range []string{"", "*", "dashboards:*", "dashboards:uid:*"} {
+			_, err := env.svc.ResourceAccess(ctx, 1, scope, "")
+			require.ErrorIs(t, err, ErrInvalidScope, scope)
+		}
+
+		_, err := env.svc.ResourceAccess(ctx, 1, "dashboards:uid:missing", "")
+		require.ErrorIs(t, err, dashboards.ErrDashboardNotFound)
+	})
+
+	t.Run("user access lists all the permissions of the user", func(t *testing.T) {
+		grants, err := env.svc.UserAccess(ctx, 1, env.users["alice"], "")
+		require.NoError(t, err)
+
+		assert.ElementsMatch(t, []grantSummary{
+			{"alice", dashboards.ActionDashboardsWrite, "folders:uid:parent", "team:editors → managed:teams:1:permissions"},
+			{"alice", dashboards.ActionFoldersPermissionsWrite, "folders:uid:child", "user → managed:users:2:permissions"},
+			{"alice", dashboards.ActionDashboardsRead, "folders:uid:parent", "basic:Editor → basic:editor"},
+		}, summarize(grants))
+	})
+
+	t.Run("admin grants report", func(t *testing.T) {
+		grants, err := env.svc.AdminGrants(ctx, 1)
+		require.NoError(t, err)
+
+		assert.ElementsMatch(t, []grantSummary{
+			{"alice", dashboards.ActionFoldersPermissionsWrite, "folders:uid:child", "user → managed:users:2:permissions"},
+			{"bob", dashboards.ActionFoldersPermissionsWrite, "folders:uid:team", "user → managed:users:3:permissions"},
+			{"carol", dashboards.ActionFoldersPermissionsWrite, "folders:uid:*", "Grafana Admin → managed:builtins:grafana admin:permissions"},
+			{"carol", ac.ActionTeamsWrite, ac.ScopeTeamsAll, "Grafana Admin → basic:grafana_admin"},
+		}, summarize(grants))
+	})
+
+	t.Run("stale permissions report excludes active users and service accounts", func(t *testing.T) {
+		assignments, err := env.svc.StaleAssignments(ctx, 1, 30*24*time.Hour)
+		require.NoError(t, err)
+
+		chains := make([]string, 0, len(assignments))
+		for _, a := range assignments {
+			assert.Equal(t, "bob", a.Login)
+			chains = append(chains, a.Derivation.Chain())
+		}
+		assert.ElementsMatch(t, []string{
+			"user → managed:users:3:permissions",
+			"basic:Viewer → managed:builtins:viewer:permissions",
+			"basic:Viewer → basic:viewer",
+		}, chains)
+
+		assignments, err = env.svc.StaleAssignments(ctx, 1, 7*24*time.Hour)
+		require.NoError(t, err)
+		logins := map[string]bool{}
+		for _, a := range assignments {
+			logins[a.Login] = true
+		}
+		assert.Equal(t, map[string]bool{"bob": true, "carol": true}, logins)
+	})
+}
+
+func TestIsAdminAction(t *testing.T) {
+	assert.True(t, IsAdminAction(dashboards.ActionFoldersPermissionsWrite))
+	assert.True(t, IsAdminAction(ac.ActionOrgUsersWrite))
+	assert.False(t, IsAdminAction(dashboards.ActionFoldersPermissionsRead))
+	assert.False(t, IsAdminAction(dashboards.ActionDashboardsWrite))
+}
@@ -0,0 +1,99 @@
+package accessreview
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/middleware/requestmeta"
+	ac "github.com/grafana/grafana/pkg/services/accesscontrol"
+	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	"github.com/grafana/grafana/pkg/services/folder"
+	"github.com/grafana/grafana/pkg/web"
+)
+
+// defaultStaleDays is the number of days without logging in after which the permissions of a user are reported as stale.
+const defaultStaleDays = 90
+
+func (s *Service) registerAPIEndpoints(router routing.RouteRegister, accessControl ac.AccessControl) {
+	authorize := ac.Middleware(accessControl)
+	userIDScope := ac.Scope("users", "id", ac.Parameter(":userId"))
+
+	router.Group("/api/access-control/review", func(rr routing.RouteRegister) {
+		rr.Get("/users/:userId", authorize(ac.EvalPermission(ac.ActionUsersPermissionsRead, userIDScope)), routing.Wrap(s.getUserAccess))
+		rr.Get("/resources", authorize(ac.EvalPermission(ac.ActionUsersPermissionsRead, ac.ScopeUsersAll)), routing.Wrap(s.getResourceAccess))
+		rr.Get("/reports/admins", authorize(ac.EvalPermission(ac.ActionUsersPermissionsRead, ac.ScopeUsersAll)), routing.Wrap(s.getAdminGrantsReport))
+		rr.Get("/reports/stale", authorize(ac.EvalPermission(ac.ActionUsersPermissionsRead, ac.ScopeUsersAll)), routing.Wrap(s.getStaleAssignmentsReport))
+	}, requestmeta.SetOwner(requestmeta.TeamAuth))
+}
+
+// GET /api/access-control/review/users/:userId
+func (s *Service) getUserAccess(c *contextmodel.ReqContext) response.Response {
+	ctx, span := tracer.Start(c.Req.Context(), "accessreview.api.getUserAccess")
+	defer span.End()
+
+	userID, err := strconv.ParseInt(web.Params(c.Req)[":userId"], 10, 64)
+	if err != nil {
+		return response.Error(http.StatusBadRequest, "userId is invalid", err)
+	}
+
+	grants, err := s.UserAccess(ctx, c.SignedInUser.GetOrgID(), userID, c.Query("action"))
+	if err != nil {
+		return response.Error(http.StatusInternalServerError, "Failed to review user access", err)
+	}
+	return response.JSON(http.StatusOK, grants)
+}
+
+// GET /api/access-control/review/resources?scope=dashboards:uid:abc
+func (s *Service) getResourceAccess(c *contextmodel.ReqContext) response.Response {
+	ctx, span := tracer.Start(c.Req.Context(), "accessreview.api.getResourceAccess")
+	defer span.End()
+
+	grants, err := s.ResourceAccess(ctx, c.SignedInUser.GetOrgID(), c.Query("scope"), c.Query("action"))
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrInvalidScope):
+			return response.Error(http.StatusBadRequest, err.Error(), err)
+		case errors.Is(err, dashboards.ErrDashboardNotFound), errors.Is(err, folder.ErrFolderNotFound):
+			return response.Error(http.StatusNotFound, "Resource not found", err)
+		}
+		return response.Error(http.StatusInternalServerError, "Failed to review resource access", err)
+	}
+	return response.JSON(http.StatusOK, grants)
+}
+
+// GET /api/access-control/review/reports/admins
+func (s *Service) getAdminGrantsReport(c *contextmodel.ReqContext) response.Response {
+	ctx, span := tracer.Start(c.Req.Context(), "accessreview.api.getAdminGrantsReport")
+	defer span.End()
+
+	grants, err := s.AdminGrants(ctx, c.SignedInUser.GetOrgID())
+	if err != nil {
+		return response.Error(http.StatusInternalServerError, "Failed to report admin grants", err)
+	}
+	return response.JSON(http.StatusOK, grants)
+}
+
+// GET /api/access-control/review/reports/stale?days=90
+func (s *Service) getStaleAssignmentsReport(c *contextmodel.ReqContext) response.Response {
+	ctx, span := tracer.Start(c.Req.Context(), "accessreview.api.getStaleAssignmentsReport")
+	defer span.End()
+
+	days := c.QueryInt("days")
+	if days == 0 {
+		days = defaultStaleDays
+	}
+	if days < 0 {
+		return response.Error(http.StatusBadRequest, "days must be positive", nil)
+	}
+
+	assignments, err := s.StaleAssignments(ctx, c.SignedInUser.GetOrgID(), time.Duration(days)*24*time.Hour)
+	if err != nil {
+		return response.Error(http.StatusInternalServerError, "Failed to report stale permissions", err)
+	}
+	return response.JSON(http.StatusOK, assignments)
+}
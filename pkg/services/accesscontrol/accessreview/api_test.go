@@ -0,0 +1,114 @@
+package accessreview
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/api/routing"
+	ac "github.com/grafana/grafana/pkg/services/accesscontrol"
+	"github.com/grafana/grafana/pkg/services/accesscontrol/acimpl"
+	"github.com/grafana/grafana/pkg/services/featuremgmt"
+	"github.com/grafana/grafana/pkg/services/user"
+	"github.com/grafana/grafana/pkg/util/testutil"
+	"github.com/grafana/grafana/pkg/web/webtest"
+)
+
+func TestIntegrationAPI(t *testing.T) {
+	testutil.SkipIntegrationTestInShortMode(t)
+
+	env := setupTestEnv(t)
+	router := routing.NewRouteRegister()
+	env.svc.registerAPIEndpoints(router, acimpl.ProvideAccessControl(featuremgmt.WithFeatures()))
+	server := webtest.NewServer(t, router)
+
+	bobScope := ac.Scope("users", "id", fmt.Sprint(env.users["bob"]))
+	tests := []struct {
+		desc         string
+		url          string
+		permissions  []ac.Permission
+		expectedCode int
+		expectedLen  int
+	}{
+		{
+			desc:         "should review the access of a user with permission on the user",
+			url:          fmt.Sprintf("/api/access-control/review/users/%d", env.users["bob"]),
+			permissions:  []ac.Permission{{Action: ac.ActionUsersPermissionsRead, Scope: bobScope}},
+			expectedCode: http.StatusOK,
+			expectedLen:  7,
+		},
+		{
+			desc:         "should not review the access of another user",
+			url:          fmt.Sprintf("/api/access-control/review/users/%d", env.users["alice"]),
+			permissions:  []ac.Permission{{Action: ac.ActionUsersPermissionsRead, Scope: bobScope}},
+			expectedCode: http.StatusForbidden,
+		},
+		{
+			desc:         "should not review the access of a resource without permission on all users",
+			url:          "/api/access-control/review/resources?scope=dashboards:uid:dash",
+			permissions:  []ac.Permission{{Action: ac.ActionUsersPermissionsRead, Scope: bobScope}},
+			expectedCode: http.StatusForbidden,
+		},
+		{
+			desc:         "should review the access of a resource",
+			url:          "/api/access-control/review/resources?scope=dashboards:uid:dash&action=dashboards:read",
+			permissions:  []ac.Permission{{Action: ac.ActionUsersPermissionsRead, Scope: ac.ScopeUsersAll}},
+			expectedCode: http.StatusOK,
+			expectedLen:  5,
+		},
+		{
+			desc:         "should reject a scope matching several resources",
+			url:          "/api/access-control/review/resources?scope=dashboards:*",
+			permissions:  []ac.Permission{{Action: ac.ActionUsersPermissionsRead, Scope: ac.ScopeUsersAll}},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			desc:         "should return not found for a missing resource",
+			url:          "/api/access-control/review/resources?scope=folders:uid:missing",
+			permissions:  []ac.Permission{{Action: ac.ActionUsersPermissionsRead, Scope: ac.ScopeUsersAll}},
+			expectedCode: http.StatusNotFound,
+		},
+		{
+			desc:         "should report admin grants",
+			url:          "/api/access-control/review/reports/admins",
+			permissions:  []ac.Permission{{Action: ac.ActionUsersPermissionsRead, Scope: ac.ScopeUsersAll}},
+			expectedCode: http.StatusOK,
+			expectedLen:  4,
+		},
+		{
+			desc:         "should report stale permissions",
+			url:          "/api/access-control/review/reports/stale?days=30",
+			permissions:  []ac.Permission{{Action: ac.ActionUsersPermissionsRead, Scope: ac.ScopeUsersAll}},
+			expectedCode: http.StatusOK,
+			expectedLen:  3,
+		},
+		{
+			desc:         "should reject a negative number of days",
+			url:          "/api/access-control/review/reports/stale?days=-1",
+			permissions:  []ac.Permission{{Action: ac.ActionUsersPermissionsRead, Scope: ac.ScopeUsersAll}},
+			expectedCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			req := server.NewGetRequest(tt.url)
+			webtest.RequestWithSignedInUser(req, &user.SignedInUser{OrgID: 1, Permissions: map[int64]map[string][]string{1: ac.GroupScopesByActionContext(context.Background(), tt.permissions)}})
+			res, err := server.Send(req)
+			require.NoError(t, err)
+			defer func() { require.NoError(t, res.Body.Close()) }()
+
+			require.Equal(t, tt.expectedCode, res.StatusCode)
+			if tt.expectedCode == http.StatusOK {
+				var result []json.RawMessage
+				require.NoError(t, json.NewDecoder(res.Body).Decode(&result))
+				assert.Len(t, result, tt.expectedLen)
+			}
+		})
+	}
+}
@@ -0,0 +1,267 @@
+package accessreview
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana/pkg/infra/db"
+	ac "github.com/grafana/grafana/pkg/services/accesscontrol"
+)
+
+const (
+	// assignmentsSQL is a query to select the role assignments of all users, along with how each role was assigned.
+	// It has to be formatted with the quoted user table and takes the global org ID and the Grafana Admin role as parameters.
+	assignmentsSQL = `SELECT ur.user_id, ur.org_id, ur.role_id, 'user' AS source_type, 0 AS team_id, '' AS basic_role
+	FROM user_role AS ur
+	UNION ALL
+	SELECT tm.user_id, tr.org_id, tr.role_id, 'team' AS source_type, tr.team_id, '' AS basic_role
+	FROM team_role AS tr
+	INNER JOIN team_member AS tm ON tm.team_id = tr.team_id
+	UNION ALL
+	SELECT ou.user_id, ou.org_id, br.role_id, 'basic_role' AS source_type, 0 AS team_id, ou.role AS basic_role
+	FROM builtin_role AS br
+	INNER JOIN org_user AS ou ON ou.role = br.role AND (br.org_id = ou.org_id OR br.org_id = ?)
+	UNION ALL
+	SELECT sa.user_id, br.org_id, br.role_id, 'server_admin' AS source_type, 0 AS team_id, '' AS basic_role
+	FROM builtin_role AS br
+	INNER JOIN (
+		SELECT u.id AS user_id
+		FROM %s AS u WHERE u.is_admin
+	) AS sa ON 1 = 1
+	WHERE br.role = ?`
+)
+
+type grantsQuery struct {
+	OrgID     int64
+	UserID    int64
+	Scopes    []string
+	Action    string
+	AdminOnly bool
+}
+
+// matches returns true if the permission, with its action sets expanded, is one the query is looking for.
+func (q grantsQuery) matches(p ac.Permission) bool {
+	if q.Action != "" && p.Action != q.Action {
+		return false
+	}
+	if len(q.Scopes) > 0 && !slices.Contains(q.Scopes, p.Scope) {
+		return false
+	}
+	return !q.AdminOnly || IsAdminAction(p.Action)
+}
+
+type assignmentsQuery struct {
+	OrgID                  int64
+	UserID                 int64
+	LastSeenBefore         time.Time
+	ExcludeServiceAccounts bool
+}
+
+type permissionsQuery struct {
+	OrgID   int64
+	UserID  int64
+	Actions []string
+	Scopes  []string
+}
+
+type assignmentRow struct {
+	UserID           int64     `xorm:"user_id"`
+	Login            string    `xorm:"login"`
+	Email            string    `xorm:"email"`
+	IsServiceAccount bool      `xorm:"is_service_account"`
+	LastSeenAt       time.Time `xorm:"last_seen_at"`
+	Source           string    `xorm:"source_type"`
+	TeamID           int64     `xorm:"team_id"`
+	TeamName         string    `xorm:"team_name"`
+	BasicRole        string    `xorm:"basic_role"`
+	RoleID           int64     `xorm:"role_id"`
+	RoleName         string    `xorm:"role_name"`
+}
+
+func (r assignmentRow) subject() Subject {
+	return Subject{
+		UserID:           r.UserID,
+		Login:            r.Login,
+		Email:            r.Email,
+		IsServiceAccount: r.IsServiceAccount,
+		LastSeenAt:       r.LastSeenAt,
+	}
+}
+
+func (r assignmentRow) derivation() Derivation {
+	return Derivation{
+		Source:    Source(r.Source),
+		Role:      r.RoleName,
+		TeamID:    r.TeamID,
+		TeamName:  r.TeamName,
+		BasicRole: r.BasicRole,
+	}
+}
+
+// basicRoleRow is a user of the organization or a server admin, with the user's basic role in the organization if any.
+type basicRoleRow struct {
+	UserID           int64     `xorm:"user_id"`
+	Login            string    `xorm:"login"`
+	Email            string    `xorm:"email"`
+	IsServiceAccount bool      `xorm:"is_service_account"`
+	LastSeenAt       time.Time `xorm:"last_seen_at"`
+	Role             string    `xorm:"role"`
+	IsAdmin          bool      `xorm:"is_admin"`
+}
+
+func (r basicRoleRow) subject() Subject {
+	return Subject{
+		UserID:           r.UserID,
+		Login:            r.Login,
+		Email:            r.Email,
+		IsServiceAccount: r.IsServiceAccount,
+		LastSeenAt:       r.LastSeenAt,
+	}
+}
+
+type permissionRow struct {
+	RoleID int64  `xorm:"role_id"`
+	Action string `xorm:"action"`
+	Scope  string `xorm:"scope"`
+}
+
+type store struct {
+	sql db.DB
+}
+
+// assignmentsTable returns the derived table, aliased as a, of the role assignments of all users.
+func (s *store) assignmentsTable() (string, []any) {
+	userTable := s.sql.GetDialect().Quote("user")
+	return `(
+			` + fmt.Sprintf(assignmentsSQL, userTable) + `
+		) AS a`, []any{ac.GlobalOrgID, ac.RoleGrafanaAdmin}
+}
+
+// searchAssignments returns the role assignments stored in the database with their users, roles and teams.
+func (s *store) searchAssignments(ctx context.Context, query assignmentsQuery) ([]assignmentRow, error) {
+	ctx, span := tracer.Start(ctx, "accessreview.store.searchAssignments")
+	defer span.End()
+
+	rows := make([]assignmentRow, 0)
+	err := s.sql.WithDbSession(ctx, func(sess *db.Session) error {
+		assignments, params := s.assignmentsTable()
+		q := `
+		SELECT
+			a.user_id,
+			u.login,
+			u.email,
+			u.is_service_account,
+			u.last_seen_at,
+			a.source_type,
+			a.team_id,
+			COALESCE(t.name, '') AS team_name,
+			a.basic_role,
+			a.role_id,
+			r.name AS role_name
+		FROM ` + assignments + `
+		INNER JOIN ` + s.sql.GetDialect().Quote("user") + ` AS u ON u.id = a.user_id
+		INNER JOIN role AS r ON r.id = a.role_id
+		LEFT JOIN team AS t ON t.id = a.team_id
+		WHERE (a.org_id = ? OR a.org_id = ?)`
+		params = append(params, query.OrgID, ac.GlobalOrgID)
+
+		q, params = s.filterUsers(q, params, query)
+		q += " ORDER BY u.last_seen_at, u.login, a.source_type, r.name"
+
+		return sess.SQL(q, params...).Find(&rows)
+	})
+	return rows, err
+}
+
+// searchBasicRoles returns the users of the organization with their basic role, and the server admins.
+// The permissions of basic roles and of the Grafana Admin role are declared in memory, not stored in the database.
+func (s *store) searchBasicRoles(ctx context.Context, query assignmentsQuery) ([]basicRoleRow, error) {
+	ctx, span := tracer.Start(ctx, "accessreview.store.searchBasicRoles")
+	defer span.End()
+
+	dialect := s.sql.GetDialect()
+	rows := make([]basicRoleRow, 0)
+	err := s.sql.WithDbSession(ctx, func(sess *db.Session) error {
+		q := `
+		SELECT
+			u.id AS user_id,
+			u.login,
+			u.email,
+			u.is_service_account,
+			u.last_seen_at,
+			COALESCE(ou.role, '') AS role,
+			u.is_admin
+		FROM ` + dialect.Quote("user") + ` AS u
+		LEFT JOIN org_user AS ou ON ou.user_id = u.id AND ou.org_id = ?
+		WHERE (ou.user_id IS NOT NULL OR u.is_admin = ` + dialect.BooleanStr(true) + `)`
+		params := []any{query.OrgID}
+
+		q, params = s.filterUsers(q, params, query)
+		q += " ORDER BY u.last_seen_at, u.login"
+
+		return sess.SQL(q, params...).Find(&rows)
+	})
+	return rows, err
+}
+
+// filterUsers adds the user conditions of the query to a statement joining the user table as u.
+func (s *store) filterUsers(q string, params []any, query assignmentsQuery) (string, []any) {
+	if query.UserID > 0 {
+		q += " AND u.id = ?"
+		params = append(params, query.UserID)
+	}
+	if query.ExcludeServiceAccounts {
+		q += " AND u.is_service_account = " + s.sql.GetDialect().BooleanStr(false)
+	}
+	if !query.LastSeenBefore.IsZero() {
+		q += " AND u.last_seen_at < ?"
+		params = append(params, query.LastSeenBefore)
+	}
+	return q, params
+}
+
+// rolePermissions returns the permissions, by role ID, of the roles assigned in the organization.
+// Action sets are returned as stored, so the actions to look for have to include the action sets holding them.
+func (s *store) rolePermissions(ctx context.Context, query permissionsQuery) (map[int64][]ac.Permission, error) {
+	ctx, span := tracer.Start(ctx, "accessreview.store.rolePermissions")
+	defer span.End()
+
+	rows := make([]permissionRow, 0)
+	err := s.sql.WithDbSession(ctx, func(sess *db.Session) error {
+		assignments, params := s.assignmentsTable()
+		subquery := "SELECT a.role_id FROM " + assignments + " WHERE (a.org_id = ? OR a.org_id = ?)"
+		params = append(params, query.OrgID, ac.GlobalOrgID)
+		if query.UserID > 0 {
+			subquery += " AND a.user_id = ?"
+			params = append(params, query.UserID)
+		}
+
+		q := "SELECT p.role_id, p.action, p.scope FROM permission AS p WHERE p.role_id IN (" + subquery + ")"
+		if len(query.Actions) > 0 {
+			q += " AND p.action IN (?" + strings.Repeat(", ?", len(query.Actions)-1) + ")"
+			for _, action := range query.Actions {
+				params = append(params, action)
+			}
+		}
+		if len(query.Scopes) > 0 {
+			q += " AND p.scope IN (?" + strings.Repeat(", ?", len(query.Scopes)-1) + ")"
+			for _, scope := range query.Scopes {
+				params = append(params, scope)
+			}
+		}
+
+		return sess.SQL(q, params...).Find(&rows)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	permissions := make(map[int64][]ac.Permission)
+	for _, row := range rows {
+		permissions[row.RoleID] = append(permissions[row.RoleID], ac.Permission{Action: row.Action, Scope: row.Scope})
+	}
+	return permissions, nil
+}
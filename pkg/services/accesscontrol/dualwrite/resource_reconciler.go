@@ -75,9 +75,10 @@ func (r resourceReconciler) reconcile(ctx context.Context, namespace string) err
 		}
 
 		// 5. Check if tuple from zanzana don't exists in grafana db, if not add them to deletes.
+		// Tuples restricted by request attributes are only stored in zanzana, so we keep them.
 		for key, tuple := range zanzanaTuples {
 			_, ok := tuples[key]
-			if !ok {
+			if !ok && !zanzana.IsAttributeConditionTuple(tuple) {
 				deletes = append(deletes, &openfgav1.TupleKeyWithoutCondition{
 					User:     tuple.User,
 					Relation: tuple.Relation,
@@ -23,6 +23,7 @@ var (
 	ErrUserTokenNotFound       = errors.New("user token not found")
 	ErrInvalidSessionToken     = usertoken.ErrInvalidSessionToken
 	ErrExternalSessionNotFound = errors.New("external session not found")
+	ErrSessionLimitReached     = errors.New("maximum number of concurrent sessions reached")
 )
 
 type (
@@ -88,6 +89,8 @@ type UserTokenService interface {
 	RotateToken(ctx context.Context, cmd RotateCommand) (*UserToken, error)
 	RevokeToken(ctx context.Context, token *UserToken, soft bool) error
 	RevokeAllUserTokens(ctx context.Context, userID int64) error
+	// RevokeOtherUserTokens revokes all tokens of the user except the one with currentTokenID
+	RevokeOtherUserTokens(ctx context.Context, userID, currentTokenID int64) error
 	GetUserToken(ctx context.Context, userID, userTokenID int64) (*UserToken, error)
 	GetUserTokens(ctx context.Context, userID int64) ([]*UserToken, error)
 	ActiveTokenCount(ctx context.Context, userID *int64) (int64, error)
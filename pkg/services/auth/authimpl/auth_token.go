@@ -6,6 +6,7 @@ import (
 	"encoding/hex"
 	"errors"
 	"net"
+	"net/http"
 	"strings"
 	"time"
 
@@ -109,6 +110,10 @@ func (s *UserAuthTokenService) CreateToken(ctx context.Context, cmd *auth.Create
 	}
 
 	err = s.sqlStore.InTransaction(ctx, func(ctx context.Context) error {
+		if err := s.enforceSessionLimit(ctx, cmd.User.ID); err != nil {
+			return err
+		}
+
 		if cmd.ExternalSession != nil {
 			inErr := s.externalSessionStore.Create(ctx, cmd.ExternalSession)
 			if inErr != nil {
@@ -138,6 +143,62 @@ func (s *UserAuthTokenService) CreateToken(ctx context.Context, cmd *auth.Create
 	return &userToken, err
 }
 
+// enforceSessionLimit makes room for a new session of the user when login_maximum_concurrent_sessions is set,
+// either by revoking the oldest sessions or by denying the new one, depending on concurrent_sessions_policy.
+func (s *UserAuthTokenService) enforceSessionLimit(ctx context.Context, userID int64) error {
+	if s.cfg.LoginMaxConcurrentSessions <= 0 {
+		return nil
+	}
+
+	var tokens []*userAuthToken
+	err := s.sqlStore.WithDbSession(ctx, func(dbSession *db.Session) error {
+		// Lock the user until the transaction ends, so concurrent logins of the user are counted one after the
+		// other. Locking the tokens of the user doesn't stop a concurrent login from adding a token.
+		var id int64
+		if _, err := dbSession.Table("user").Cols("id").Where("id = ?", userID).ForUpdate().Get(&id); err != nil {
+			return err
+		}
+
+		return dbSession.Where("user_id = ? AND created_at > ? AND rotated_at > ? AND revoked_at = 0",
+			userID,
+			s.createdAfterParam(),
+			s.rotatedAfterParam()).
+			Asc("created_at", "id").
+			Find(&tokens)
+	})
+	if err != nil {
+		return err
+	}
+
+	excess := len(tokens) - s.cfg.LoginMaxConcurrentSessions + 1
+	if excess <= 0 {
+		return nil
+	}
+
+	ctxLogger := s.log.FromContext(ctx)
+	if s.cfg.ConcurrentSessionsPolicy == setting.ConcurrentSessionsDenyNew {
+		ctxLogger.Debug("Denying new session, maximum number of concurrent sessions reached", "userID", userID, "count", len(tokens))
+		return &auth.CreateTokenErr{
+			StatusCode:  http.StatusForbidden,
+			InternalErr: auth.ErrSessionLimitReached,
+			ExternalErr: "Maximum number of concurrent sessions reached. Sign out from another device and try again.",
+		}
+	}
+
+	for _, token := range tokens[:excess] {
+		var userToken auth.UserToken
+		if err := token.toUserToken(&userToken); err != nil {
+			return err
+		}
+		if err := s.RevokeToken(ctx, &userToken, true); err != nil && !errors.Is(err, auth.ErrUserTokenNotFound) {
+			return err
+		}
+		ctxLogger.Debug("Revoked oldest session, maximum number of concurrent sessions reached", "tokenID", token.Id, "userID", userID)
+	}
+
+	return nil
+}
+
 func (s *UserAuthTokenService) LookupToken(ctx context.Context, unhashedToken string) (*auth.UserToken, error) {
 	ctx, span := s.tracer.Start(ctx, "authtoken.LookupToken")
 	defer span.End()
@@ -492,6 +553,45 @@ func (s *UserAuthTokenService) RevokeAllUserTokens(ctx context.Context, userId i
 	})
 }
 
+func (s *UserAuthTokenService) RevokeOtherUserTokens(ctx context.Context, userID, currentTokenID int64) error {
+	ctx, span := s.tracer.Start(ctx, "authtoken.RevokeOtherUserTokens")
+	defer span.End()
+
+	return s.sqlStore.InTransaction(ctx, func(ctx context.Context) error {
+		ctxLogger := s.log.FromContext(ctx)
+
+		var tokens []*userAuthToken
+		err := s.sqlStore.WithDbSession(ctx, func(dbSession *db.Session) error {
+			if err := dbSession.Where("user_id = ? AND id <> ?", userID, currentTokenID).Find(&tokens); err != nil {
+				return err
+			}
+			if len(tokens) == 0 {
+				return nil
+			}
+
+			_, err := dbSession.Exec("DELETE FROM user_auth_token WHERE user_id = ? AND id <> ?", userID, currentTokenID)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, token := range tokens {
+			if token.ExternalSessionId == 0 {
+				continue
+			}
+			if err := s.externalSessionStore.Delete(ctx, token.ExternalSessionId); err != nil {
+				// Intentionally not returning error here, as the token has been revoked -> the backround job will clean up orphaned external sessions
+				ctxLogger.Warn("Failed to delete external session", "externalSessionID", token.ExternalSessionId, "err", err)
+			}
+		}
+
+		ctxLogger.Debug("Other user tokens for user revoked", "userID", userID, "currentTokenID", currentTokenID, "count", len(tokens))
+
+		return nil
+	})
+}
+
 func (s *UserAuthTokenService) BatchRevokeAllUserTokens(ctx context.Context, userIds []int64) error {
 	ctx, span := s.tracer.Start(ctx, "authtoken.BatchRevokeAllUserTokens")
 	defer span.End()
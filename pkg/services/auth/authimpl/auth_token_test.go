@@ -5,7 +5,9 @@ import (
 	"encoding/json"
 	"errors"
 	"net"
+	"net/http"
 	"reflect"
+	"sync"
 	"testing"
 	"time"
 
@@ -938,3 +940,159 @@ func TestIntegrationBatchRevokeAllUserTokens(t *testing.T) {
 		}
 	})
 }
+
+func TestIntegrationRevokeOtherUserTokens(t *testing.T) {
+	testutil.SkipIntegrationTestInShortMode(t)
+
+	ctx := createTestContext(t)
+	usr := &user.User{ID: int64(10)}
+	otherUsr := &user.User{ID: int64(11)}
+
+	createToken := func(u *user.User, extSession *auth.ExternalSession) *auth.UserToken {
+		userToken, err := ctx.tokenService.CreateToken(context.Background(), &auth.CreateTokenCommand{
+			User:            u,
+			ClientIP:        net.ParseIP("192.168.10.11"),
+			UserAgent:       "some user agent",
+			ExternalSession: extSession,
+		})
+		require.Nil(t, err)
+		require.NotNil(t, userToken)
+		return userToken
+	}
+
+	current := createToken(usr, nil)
+	other := createToken(usr, &auth.ExternalSession{UserID: usr.ID, AuthModule: "test", UserAuthID: 1})
+	otherUsrToken := createToken(otherUsr, nil)
+
+	err := ctx.tokenService.RevokeOtherUserTokens(context.Background(), usr.ID, current.Id)
+	require.Nil(t, err)
+
+	model, err := ctx.getAuthTokenByID(current.Id)
+	require.Nil(t, err)
+	require.NotNil(t, model)
+
+	model, err = ctx.getAuthTokenByID(other.Id)
+	require.Nil(t, err)
+	require.Nil(t, model)
+
+	extSession, err := ctx.getExternalSessionByID(other.ExternalSessionId)
+	require.Nil(t, err)
+	require.Nil(t, extSession)
+
+	model, err = ctx.getAuthTokenByID(otherUsrToken.Id)
+	require.Nil(t, err)
+	require.NotNil(t, model)
+}
+
+func TestIntegrationConcurrentSessionLimit(t *testing.T) {
+	testutil.SkipIntegrationTestInShortMode(t)
+
+	usr := &user.User{ID: int64(10)}
+	createToken := func(ctx *testContext) (*auth.UserToken, error) {
+		return ctx.tokenService.CreateToken(context.Background(), &auth.CreateTokenCommand{
+			User:      usr,
+			ClientIP:  net.ParseIP("192.168.10.11"),
+			UserAgent: "some user agent",
+		})
+	}
+
+	t.Run("should revoke the oldest sessions when the limit is reached", func(t *testing.T) {
+		ctx := createTestContext(t)
+		ctx.tokenService.cfg.LoginMaxConcurrentSessions = 2
+		ctx.tokenService.cfg.ConcurrentSessionsPolicy = setting.ConcurrentSessionsRevokeOldest
+
+		first, err := createToken(ctx)
+		require.Nil(t, err)
+		second, err := createToken(ctx)
+		require.Nil(t, err)
+		third, err := createToken(ctx)
+		require.Nil(t, err)
+
+		_, err = ctx.tokenService.LookupToken(context.Background(), first.UnhashedToken)
+		var revokedErr *auth.TokenRevokedError
+		require.ErrorAs(t, err, &revokedErr)
+
+		tokens, err := ctx.tokenService.GetUserTokens(context.Background(), usr.ID)
+		require.Nil(t, err)
+		require.Len(t, tokens, 2)
+		require.Equal(t, second.Id, tokens[0].Id)
+		require.Equal(t, third.Id, tokens[1].Id)
+	})
+
+	t.Run("should deny new sessions when the limit is reached", func(t *testing.T) {
+		ctx := createTestContext(t)
+		ctx.tokenService.cfg.LoginMaxConcurrentSessions = 1
+		ctx.tokenService.cfg.ConcurrentSessionsPolicy = setting.ConcurrentSessionsDenyNew
+
+		first, err := createToken(ctx)
+		require.Nil(t, err)
+
+		_, err = createToken(ctx)
+		var tokenErr *auth.CreateTokenErr
+		require.ErrorAs(t, err, &tokenErr)
+		require.Equal(t, http.StatusForbidden, tokenErr.StatusCode)
+		require.ErrorIs(t, tokenErr.InternalErr, auth.ErrSessionLimitReached)
+
+		tokens, err := ctx.tokenService.GetUserTokens(context.Background(), usr.ID)
+		require.Nil(t, err)
+		require.Len(t, tokens, 1)
+		require.Equal(t, first.Id, tokens[0].Id)
+	})
+
+	t.Run("should not exceed the limit with concurrent logins", func(t *testing.T) {
+		for _, policy := range []string{setting.ConcurrentSessionsRevokeOldest, setting.ConcurrentSessionsDenyNew} {
+			ctx := createTestContext(t)
+			ctx.tokenService.cfg.LoginMaxConcurrentSessions = 2
+			ctx.tokenService.cfg.ConcurrentSessionsPolicy = policy
+			err := ctx.sqlstore.WithDbSession(context.Background(), func(sess *db.Session) error {
+				_, err := sess.Table("user").Insert(&user.User{ID: usr.ID, UID: "concurrent", Login: "concurrent", Email: "concurrent@example.org", OrgID: 1, Created: time.Now(), Updated: time.Now()})
+				return err
+			})
+			require.Nil(t, err)
+
+			var wg sync.WaitGroup
+			errs := make([]error, 10)
+			for i := range errs {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					_, errs[i] = createToken(ctx)
+				}()
+			}
+			wg.Wait()
+
+			created := 0
+			for _, err := range errs {
+				var tokenErr *auth.CreateTokenErr
+				if errors.As(err, &tokenErr) {
+					require.ErrorIs(t, tokenErr.InternalErr, auth.ErrSessionLimitReached)
+					continue
+				}
+				require.Nil(t, err)
+				created++
+			}
+
+			tokens, err := ctx.tokenService.GetUserTokens(context.Background(), usr.ID)
+			require.Nil(t, err)
+			require.Len(t, tokens, 2, policy)
+			if policy == setting.ConcurrentSessionsDenyNew {
+				require.Equal(t, 2, created)
+			} else {
+				require.Equal(t, len(errs), created)
+			}
+		}
+	})
+
+	t.Run("should not count revoked sessions", func(t *testing.T) {
+		ctx := createTestContext(t)
+		ctx.tokenService.cfg.LoginMaxConcurrentSessions = 1
+		ctx.tokenService.cfg.ConcurrentSessionsPolicy = setting.ConcurrentSessionsDenyNew
+
+		first, err := createToken(ctx)
+		require.Nil(t, err)
+		require.Nil(t, ctx.tokenService.RevokeToken(context.Background(), first, true))
+
+		_, err = createToken(ctx)
+		require.Nil(t, err)
+	})
+}
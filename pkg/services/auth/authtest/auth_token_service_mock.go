@@ -303,6 +303,24 @@ func (_m *MockUserAuthTokenService) RevokeAllUserTokens(ctx context.Context, use
 	return r0
 }
 
+// RevokeOtherUserTokens provides a mock function with given fields: ctx, userID, currentTokenID
+func (_m *MockUserAuthTokenService) RevokeOtherUserTokens(ctx context.Context, userID int64, currentTokenID int64) error {
+	ret := _m.Called(ctx, userID, currentTokenID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RevokeOtherUserTokens")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64) error); ok {
+		r0 = rf(ctx, userID, currentTokenID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // RevokeToken provides a mock function with given fields: ctx, token, soft
 func (_m *MockUserAuthTokenService) RevokeToken(ctx context.Context, token *usertoken.UserToken, soft bool) error {
 	ret := _m.Called(ctx, token, soft)
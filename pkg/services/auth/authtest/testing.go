@@ -27,6 +27,7 @@ type FakeUserAuthTokenService struct {
 	LookupTokenProvider                 func(ctx context.Context, unhashedToken string) (*auth.UserToken, error)
 	RevokeTokenProvider                 func(ctx context.Context, token *auth.UserToken, soft bool) error
 	RevokeAllUserTokensProvider         func(ctx context.Context, userID int64) error
+	RevokeOtherUserTokensProvider       func(ctx context.Context, userID, currentTokenID int64) error
 	ActiveTokenCountProvider            func(ctx context.Context, userID *int64) (int64, error)
 	GetUserTokenProvider                func(ctx context.Context, userID, userTokenID int64) (*auth.UserToken, error)
 	GetUserTokensProvider               func(ctx context.Context, userID int64) ([]*auth.UserToken, error)
@@ -57,6 +58,9 @@ func NewFakeUserAuthTokenService() *FakeUserAuthTokenService {
 		RevokeAllUserTokensProvider: func(ctx context.Context, userId int64) error {
 			return nil
 		},
+		RevokeOtherUserTokensProvider: func(ctx context.Context, userId, currentTokenId int64) error {
+			return nil
+		},
 		BatchRevokedTokenProvider: func(ctx context.Context, userIds []int64) error {
 			return nil
 		},
@@ -117,6 +121,10 @@ func (s *FakeUserAuthTokenService) RevokeAllUserTokens(ctx context.Context, user
 	return s.RevokeAllUserTokensProvider(context.Background(), userId)
 }
 
+func (s *FakeUserAuthTokenService) RevokeOtherUserTokens(ctx context.Context, userId, currentTokenId int64) error {
+	return s.RevokeOtherUserTokensProvider(context.Background(), userId, currentTokenId)
+}
+
 func (s *FakeUserAuthTokenService) ActiveTokenCount(ctx context.Context, userID *int64) (int64, error) {
 	return s.ActiveTokenCountProvider(context.Background(), userID)
 }
@@ -0,0 +1,60 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/grafana/grafana/pkg/services/org"
+)
+
+// ErrRevokeSessions is returned when the organization membership of a user was changed, but the sessions of the
+// user couldn't be revoked.
+var ErrRevokeSessions = errors.New("unable to revoke user sessions")
+
+// UpdateOrgUser changes the role of a user in an organization. The user is signed out everywhere when the role is
+// downgraded, so no session outlives the access it was opened with.
+func UpdateOrgUser(ctx context.Context, orgService org.Service, tokenService UserTokenService, cmd *org.UpdateOrgUserCommand) error {
+	prevRole, err := orgUserRole(ctx, orgService, cmd.UserID, cmd.OrgID)
+	if err != nil {
+		return err
+	}
+
+	if err := orgService.UpdateOrgUser(ctx, cmd); err != nil {
+		return err
+	}
+
+	if prevRole == "" || cmd.Role.Includes(prevRole) {
+		return nil
+	}
+	if err := tokenService.RevokeAllUserTokens(ctx, cmd.UserID); err != nil {
+		return fmt.Errorf("%w: %w", ErrRevokeSessions, err)
+	}
+	return nil
+}
+
+// RemoveOrgUser removes a user from an organization, and signs the user out everywhere.
+func RemoveOrgUser(ctx context.Context, orgService org.Service, tokenService UserTokenService, cmd *org.RemoveOrgUserCommand) error {
+	if err := orgService.RemoveOrgUser(ctx, cmd); err != nil {
+		return err
+	}
+
+	if err := tokenService.RevokeAllUserTokens(ctx, cmd.UserID); err != nil {
+		return fmt.Errorf("%w: %w", ErrRevokeSessions, err)
+	}
+	return nil
+}
+
+// orgUserRole returns the role of the user in the organization, or an empty role if the user isn't a member of it.
+func orgUserRole(ctx context.Context, orgService org.Service, userID, orgID int64) (org.RoleType, error) {
+	orgs, err := orgService.GetUserOrgList(ctx, &org.GetUserOrgListQuery{UserID: userID})
+	if err != nil {
+		return "", err
+	}
+	for _, o := range orgs {
+		if o.OrgID == orgID {
+			return o.Role, nil
+		}
+	}
+	return "", nil
+}
@@ -28,8 +28,11 @@ const (
 	ClientSession      = "auth.client.session"
 	ClientForm         = "auth.client.form"
 	ClientProxy        = "auth.client.proxy"
+	ClientCert         = "auth.client.cert"
 	ClientSAML         = "auth.client.saml"
 	ClientPasswordless = "auth.client.passwordless"
+	ClientTwoFactor    = "auth.client.two-factor"
+	ClientWebAuthn     = "auth.client.webauthn"
 	ClientLDAP         = "ldap"
 	ClientProvisioning = "auth.client.apiserver.provisioning"
 )
@@ -26,7 +26,9 @@ import (
 	"github.com/grafana/grafana/pkg/services/quota"
 	"github.com/grafana/grafana/pkg/services/rendering"
 	tempuser "github.com/grafana/grafana/pkg/services/temp_user"
+	"github.com/grafana/grafana/pkg/services/twofactor"
 	"github.com/grafana/grafana/pkg/services/user"
+	"github.com/grafana/grafana/pkg/services/webauthn"
 	"github.com/grafana/grafana/pkg/setting"
 )
 
@@ -44,6 +46,7 @@ func ProvideRegistration(
 	socialService social.Service, cache *remotecache.RemoteCache,
 	ldapService service.LDAP, settingsProviderService setting.Provider,
 	tracer tracing.Tracer, tempUserService tempuser.Service, notificationService notifications.Service,
+	twoFactorService twofactor.Service, webAuthnService webauthn.Service,
 ) Registration {
 	logger := log.New("authn.registration")
 
@@ -73,12 +76,29 @@ func ProvideRegistration(
 	// if we have password clients configure check if basic auth or form auth is enabled
 	if len(passwordClients) > 0 {
 		passwordClient := clients.ProvidePassword(loginAttempts, tracer, passwordClients...)
+
+		var twoFactor *clients.TwoFactor
+		if cfg.TwoFactorAuth.Enabled {
+			var passkeys webauthn.Service
+			if cfg.WebAuthn.Enabled {
+				passkeys = webAuthnService
+			}
+			twoFactor = clients.ProvideTwoFactor(cfg, twoFactorService, passkeys, loginAttempts, cache)
+		}
+
 		if cfg.BasicAuthEnabled {
-			authnSvc.RegisterClient(clients.ProvideBasic(passwordClient))
+			authnSvc.RegisterClient(clients.ProvideBasic(passwordClient, twoFactor))
 		}
 
 		if !cfg.DisableLoginForm {
-			authnSvc.RegisterClient(clients.ProvideForm(passwordClient))
+			if twoFactor != nil {
+				authnSvc.RegisterClient(twoFactor)
+			}
+			authnSvc.RegisterClient(clients.ProvideForm(passwordClient, twoFactor))
+
+			if cfg.WebAuthn.Enabled {
+				authnSvc.RegisterClient(clients.ProvideWebAuthn(cfg, webAuthnService, loginAttempts, twoFactor))
+			}
 		}
 	}
 
@@ -102,6 +122,15 @@ func ProvideRegistration(
 		}
 	}
 
+	if cfg.ClientCertAuth.Enabled {
+		clientCert, err := clients.ProvideClientCert(cfg, userService, tracer)
+		if err != nil {
+			logger.Error("Failed to configure client certificate auth", "err", err)
+		} else {
+			authnSvc.RegisterClient(clientCert)
+		}
+	}
+
 	if cfg.AuthProxy.Enabled && len(proxyClients) > 0 {
 		proxy, err := clients.ProvideProxy(cfg, cache, tracer, proxyClients...)
 		if err != nil {
@@ -132,7 +161,7 @@ func ProvideRegistration(
 	// FIXME (jguer): move to User package
 	// Pass nil for k8sClient - it will be handled gracefully in the SCIMSettingsUtil
 	userSync := sync.ProvideUserSync(userService, userProtectionService, authInfoService, quotaService, tracer, features, cfg, nil)
-	orgSync := sync.ProvideOrgSync(userService, orgService, accessControlService, cfg, tracer)
+	orgSync := sync.ProvideOrgSync(userService, orgService, sessionService, accessControlService, cfg, tracer)
 	authnSvc.RegisterPostAuthHook(userSync.SyncUserHook, 10)
 	authnSvc.RegisterPostAuthHook(userSync.EnableUserHook, 20)
 	authnSvc.RegisterPostAuthHook(orgSync.SyncOrgRolesHook, 40)
@@ -10,23 +10,25 @@ import (
 	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/infra/tracing"
 	"github.com/grafana/grafana/pkg/services/accesscontrol"
+	"github.com/grafana/grafana/pkg/services/auth"
 	"github.com/grafana/grafana/pkg/services/authn"
 	"github.com/grafana/grafana/pkg/services/org"
 	"github.com/grafana/grafana/pkg/services/user"
 	"github.com/grafana/grafana/pkg/setting"
 )
 
-func ProvideOrgSync(userService user.Service, orgService org.Service, accessControl accesscontrol.Service, cfg *setting.Cfg, tracer tracing.Tracer) *OrgSync {
-	return &OrgSync{userService, orgService, accessControl, cfg, log.New("org.sync"), tracer}
+func ProvideOrgSync(userService user.Service, orgService org.Service, sessionService auth.UserTokenService, accessControl accesscontrol.Service, cfg *setting.Cfg, tracer tracing.Tracer) *OrgSync {
+	return &OrgSync{userService, orgService, sessionService, accessControl, cfg, log.New("org.sync"), tracer}
 }
 
 type OrgSync struct {
-	userService   user.Service
-	orgService    org.Service
-	accessControl accesscontrol.Service
-	cfg           *setting.Cfg
-	log           log.Logger
-	tracer        tracing.Tracer
+	userService    user.Service
+	orgService     org.Service
+	sessionService auth.UserTokenService
+	accessControl  accesscontrol.Service
+	cfg            *setting.Cfg
+	log            log.Logger
+	tracer         tracing.Tracer
 }
 
 func (s *OrgSync) SyncOrgRolesHook(ctx context.Context, id *authn.Identity, _ *authn.Request) error {
@@ -75,11 +77,14 @@ func (s *OrgSync) SyncOrgRolesHook(ctx context.Context, id *authn.Identity, _ *a
 		if extRole == "" {
 			deleteOrgIds = append(deleteOrgIds, orga.OrgID)
 		} else if extRole != orga.Role {
-			// update role
+			// update role, downgrading it signs the user out everywhere
 			cmd := &org.UpdateOrgUserCommand{OrgID: orga.OrgID, UserID: userID, Role: extRole}
-			if err := s.orgService.UpdateOrgUser(ctx, cmd); err != nil {
-				ctxLogger.Error("Failed to update active org user", "error", err)
-				return err
+			if err := auth.UpdateOrgUser(ctx, s.orgService, s.sessionService, cmd); err != nil {
+				if !errors.Is(err, auth.ErrRevokeSessions) {
+					ctxLogger.Error("Failed to update active org user", "error", err)
+					return err
+				}
+				ctxLogger.Error("Failed to revoke sessions of user after role downgrade", "orgId", orga.OrgID, "error", err)
 			}
 		}
 	}
@@ -111,14 +116,19 @@ func (s *OrgSync) SyncOrgRolesHook(ctx context.Context, id *authn.Identity, _ *a
 	// delete any removed org roles
 	for _, orgID := range deleteOrgIds {
 		ctxLogger.Debug("Removing user's organization membership as part of syncing with OAuth login", "orgId", orgID)
+		// removing the user signs the user out everywhere
 		cmd := &org.RemoveOrgUserCommand{OrgID: orgID, UserID: userID}
-		if err := s.orgService.RemoveOrgUser(ctx, cmd); err != nil {
-			ctxLogger.Error("Failed to remove user from org", "orgId", orgID, "error", err)
-			if errors.Is(err, org.ErrLastOrgAdmin) {
-				continue
-			}
+		if err := auth.RemoveOrgUser(ctx, s.orgService, s.sessionService, cmd); err != nil {
+			if errors.Is(err, auth.ErrRevokeSessions) {
+				ctxLogger.Error("Failed to revoke sessions of user removed from org", "orgId", orgID, "error", err)
+			} else {
+				ctxLogger.Error("Failed to remove user from org", "orgId", orgID, "error", err)
+				if errors.Is(err, org.ErrLastOrgAdmin) {
+					continue
+				}
 
-			return err
+				return err
+			}
 		}
 
 		if err := s.accessControl.DeleteUserPermissions(ctx, orgID, cmd.UserID); err != nil {
@@ -15,6 +15,7 @@ import (
 	"github.com/grafana/grafana/pkg/infra/tracing"
 	"github.com/grafana/grafana/pkg/services/accesscontrol"
 	"github.com/grafana/grafana/pkg/services/accesscontrol/actest"
+	"github.com/grafana/grafana/pkg/services/auth/authtest"
 	"github.com/grafana/grafana/pkg/services/authn"
 	"github.com/grafana/grafana/pkg/services/login"
 	"github.com/grafana/grafana/pkg/services/org"
@@ -120,11 +121,12 @@ func TestOrgSync_SyncOrgRolesHook(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			s := &OrgSync{
-				userService:   tt.fields.userService,
-				orgService:    tt.fields.orgService,
-				accessControl: tt.fields.accessControl,
-				log:           tt.fields.log,
-				tracer:        tracing.InitializeTracerForTest(),
+				userService:    tt.fields.userService,
+				orgService:     tt.fields.orgService,
+				sessionService: authtest.NewFakeUserAuthTokenService(),
+				accessControl:  tt.fields.accessControl,
+				log:            tt.fields.log,
+				tracer:         tracing.InitializeTracerForTest(),
 			}
 			if err := s.SyncOrgRolesHook(tt.args.ctx, tt.args.id, nil); (err != nil) != tt.wantErr {
 				t.Errorf("OrgSync.SyncOrgRolesHook() error = %v, wantErr %v", err, tt.wantErr)
@@ -135,6 +137,68 @@ func TestOrgSync_SyncOrgRolesHook(t *testing.T) {
 	}
 }
 
+func TestOrgSync_SyncOrgRolesHook_RevokeSessions(t *testing.T) {
+	tests := []struct {
+		name          string
+		orgRoles      map[int64]identity.RoleType
+		expectRevoked bool
+	}{
+		{
+			name:          "should sign the user out when downgrading the role",
+			orgRoles:      map[int64]identity.RoleType{1: org.RoleViewer, 2: org.RoleEditor},
+			expectRevoked: true,
+		},
+		{
+			name:     "should not sign the user out when upgrading the role",
+			orgRoles: map[int64]identity.RoleType{1: org.RoleAdmin, 2: org.RoleEditor},
+		},
+		{
+			name:     "should not sign the user out when keeping the role",
+			orgRoles: map[int64]identity.RoleType{1: org.RoleEditor, 2: org.RoleEditor},
+		},
+		{
+			name:          "should sign the user out when removing the user from an org",
+			orgRoles:      map[int64]identity.RoleType{2: org.RoleEditor},
+			expectRevoked: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var revokedUserID int64
+			s := &OrgSync{
+				userService: &usertest.FakeUserService{},
+				orgService: &orgtest.FakeOrgService{
+					ExpectedUserOrgDTO:      []*org.UserOrgDTO{{OrgID: 1, Role: org.RoleEditor}, {OrgID: 2, Role: org.RoleEditor}},
+					ExpectedOrgListResponse: orgtest.OrgListResponse{{OrgID: 1}},
+				},
+				sessionService: &authtest.FakeUserAuthTokenService{
+					RevokeAllUserTokensProvider: func(ctx context.Context, userID int64) error {
+						revokedUserID = userID
+						return nil
+					},
+				},
+				accessControl: actest.FakeService{},
+				log:           log.NewNopLogger(),
+				tracer:        tracing.InitializeTracerForTest(),
+			}
+
+			err := s.SyncOrgRolesHook(context.Background(), &authn.Identity{
+				ID:           "1",
+				Type:         claims.TypeUser,
+				OrgID:        2,
+				OrgRoles:     tt.orgRoles,
+				ClientParams: authn.ClientParams{SyncOrgRoles: true},
+			}, nil)
+			assert.NoError(t, err)
+			if tt.expectRevoked {
+				assert.Equal(t, int64(1), revokedUserID)
+			} else {
+				assert.Zero(t, revokedUserID)
+			}
+		})
+	}
+}
+
 func TestOrgSync_SetDefaultOrgHook(t *testing.T) {
 	testCases := []struct {
 		name              string
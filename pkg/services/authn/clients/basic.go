@@ -9,14 +9,21 @@ import (
 
 var errDecodingBasicAuthHeader = errutil.BadRequest("basic-auth.invalid-header", errutil.WithPublicMessage("Invalid Basic Auth Header"))
 
-var _ authn.ContextAwareClient = new(Basic)
+var (
+	_ authn.ContextAwareClient = new(Basic)
+	_ authn.HookClient         = new(Basic)
+)
 
-func ProvideBasic(client authn.PasswordClient) *Basic {
-	return &Basic{client}
+// ProvideBasic returns a basic auth client. twoFactor is optional, when set
+// users enrolled in or required to use two-factor authentication are rejected
+// since basic auth can not carry a second factor.
+func ProvideBasic(client authn.PasswordClient, twoFactor *TwoFactor) *Basic {
+	return &Basic{client, twoFactor}
 }
 
 type Basic struct {
-	client authn.PasswordClient
+	client    authn.PasswordClient
+	twoFactor *TwoFactor
 }
 
 func (c *Basic) String() string {
@@ -40,6 +47,13 @@ func (c *Basic) IsEnabled() bool {
 	return true
 }
 
+func (c *Basic) Hook(ctx context.Context, identity *authn.Identity, r *authn.Request) error {
+	if c.twoFactor == nil {
+		return nil
+	}
+	return c.twoFactor.RejectPasswordOnly(ctx, identity)
+}
+
 func (c *Basic) Test(ctx context.Context, r *authn.Request) bool {
 	if r.HTTPRequest == nil {
 		return false
@@ -4,13 +4,20 @@ import (
 	"context"
 	"net/http"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 
 	claims "github.com/grafana/authlib/types"
 
+	"github.com/grafana/grafana/pkg/infra/remotecache"
 	"github.com/grafana/grafana/pkg/services/authn"
 	"github.com/grafana/grafana/pkg/services/authn/authntest"
+	"github.com/grafana/grafana/pkg/services/login"
+	"github.com/grafana/grafana/pkg/services/loginattempt/loginattempttest"
+	"github.com/grafana/grafana/pkg/services/twofactor"
+	"github.com/grafana/grafana/pkg/services/twofactor/twofactortest"
+	"github.com/grafana/grafana/pkg/setting"
 )
 
 func TestBasic_Authenticate(t *testing.T) {
@@ -38,7 +45,7 @@ func TestBasic_Authenticate(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.desc, func(t *testing.T) {
-			c := ProvideBasic(tt.client)
+			c := ProvideBasic(tt.client, nil)
 
 			identity, err := c.Authenticate(context.Background(), tt.req)
 			if tt.expectedErr != nil {
@@ -52,6 +59,54 @@ func TestBasic_Authenticate(t *testing.T) {
 	}
 }
 
+func TestBasic_Hook(t *testing.T) {
+	tests := []struct {
+		desc        string
+		identity    *authn.Identity
+		status      *twofactor.Status
+		expectedErr error
+	}{
+		{
+			desc:     "should allow users without two-factor",
+			identity: &authn.Identity{ID: "1", Type: claims.TypeUser, AuthenticatedBy: login.PasswordAuthModule},
+			status:   &twofactor.Status{},
+		},
+		{
+			desc:        "should reject enrolled users",
+			identity:    &authn.Identity{ID: "1", Type: claims.TypeUser, AuthenticatedBy: login.PasswordAuthModule},
+			status:      &twofactor.Status{Enabled: true},
+			expectedErr: errTwoFactorPasswordOnly,
+		},
+		{
+			desc:        "should reject users required to enroll",
+			identity:    &authn.Identity{ID: "1", Type: claims.TypeUser, AuthenticatedBy: login.PasswordAuthModule},
+			status:      &twofactor.Status{Required: true},
+			expectedErr: errTwoFactorPasswordOnly,
+		},
+		{
+			desc:     "should allow users authenticated by ldap",
+			identity: &authn.Identity{ID: "1", Type: claims.TypeUser, AuthenticatedBy: login.LDAPAuthModule},
+			status:   &twofactor.Status{Enabled: true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			cfg := setting.NewCfg()
+			cfg.TwoFactorAuth = setting.AuthTwoFactorSettings{Enabled: true, LoginTimeout: time.Minute}
+			twoFactor := ProvideTwoFactor(cfg, &twofactortest.FakeService{ExpectedStatus: tt.status}, nil, loginattempttest.FakeLoginAttemptService{ExpectedValid: true}, remotecache.NewFakeCacheStorage())
+			c := ProvideBasic(authntest.FakePasswordClient{}, twoFactor)
+
+			err := c.Hook(context.Background(), tt.identity, &authn.Request{})
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
 func TestBasic_Test(t *testing.T) {
 	type TestCase struct {
 		desc     string
@@ -91,7 +146,7 @@ func TestBasic_Test(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.desc, func(t *testing.T) {
-			c := ProvideBasic(authntest.FakePasswordClient{})
+			c := ProvideBasic(authntest.FakePasswordClient{}, nil)
 			assert.Equal(t, tt.expected, c.Test(context.Background(), tt.req))
 		})
 	}
@@ -0,0 +1,387 @@
+package clients
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	claims "github.com/grafana/authlib/types"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/grafana/grafana/pkg/apimachinery/errutil"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/authn"
+	"github.com/grafana/grafana/pkg/services/login"
+	"github.com/grafana/grafana/pkg/services/user"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+const (
+	clientCertSourceCN      = "cn"
+	clientCertSourceSubject = "subject"
+	clientCertSourceDNS     = "dns"
+	clientCertSourceEmail   = "email"
+	clientCertSourceURI     = "uri"
+
+	clientCertTypeUser           = "user"
+	clientCertTypeServiceAccount = "service_account"
+
+	// clientCertLoginGroup is the name of the capture group used as login, the
+	// whole value is used when a rule doesn't have it.
+	clientCertLoginGroup = "login"
+
+	denyListCheckInterval = 30 * time.Second
+)
+
+var (
+	errClientCertRevoked     = errutil.Unauthorized("client-cert.revoked", errutil.WithPublicMessage("Client certificate has been revoked"))
+	errClientCertNoMatch     = errutil.Unauthorized("client-cert.no-match", errutil.WithPublicMessage("Client certificate is not mapped to an identity"))
+	errClientCertOrgMismatch = errutil.Unauthorized("client-cert.organization-mismatch", errutil.WithPublicMessage("Client certificate does not belong to the requested organization"))
+)
+
+var _ authn.ContextAwareClient = new(ClientCert)
+
+func ProvideClientCert(cfg *setting.Cfg, userService user.Service, tracer trace.Tracer) (*ClientCert, error) {
+	if cfg.ClientCertAuth.ClientCAFile == "" {
+		return nil, errors.New("client_ca_file is required to verify client certificates")
+	}
+
+	rules, err := parseClientCertRules(cfg.ClientCertAuth.Rules)
+	if err != nil {
+		return nil, err
+	}
+
+	denyList, err := newCertDenyList(cfg.ClientCertAuth.DenyList, cfg.ClientCertAuth.DenyListFile)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ClientCert{
+		cfg:         cfg,
+		log:         log.New(authn.ClientCert),
+		userService: userService,
+		tracer:      tracer,
+		rules:       rules,
+		denyList:    denyList,
+	}, nil
+}
+
+// ClientCert authenticates requests with a client certificate that was
+// verified by the HTTP server during the TLS handshake.
+type ClientCert struct {
+	cfg         *setting.Cfg
+	log         log.Logger
+	userService user.Service
+	tracer      trace.Tracer
+	rules       []clientCertRule
+	denyList    *certDenyList
+}
+
+type clientCertRule struct {
+	source       string
+	pattern      *regexp.Regexp
+	identityType string
+}
+
+func (c *ClientCert) Name() string {
+	return authn.ClientCert
+}
+
+func (c *ClientCert) IsEnabled() bool {
+	return c.cfg.ClientCertAuth.Enabled
+}
+
+func (c *ClientCert) Test(ctx context.Context, r *authn.Request) bool {
+	if r.HTTPRequest == nil || r.HTTPRequest.TLS == nil {
+		return false
+	}
+	chains := r.HTTPRequest.TLS.VerifiedChains
+	return len(chains) > 0 && len(chains[0]) > 0
+}
+
+func (c *ClientCert) Priority() uint {
+	return 55
+}
+
+func (c *ClientCert) Authenticate(ctx context.Context, r *authn.Request) (*authn.Identity, error) {
+	ctx, span := c.tracer.Start(ctx, "authn.clientcert.Authenticate")
+	defer span.End()
+
+	chain := r.HTTPRequest.TLS.VerifiedChains[0]
+	for _, cert := range chain {
+		if c.denyList.isDenied(ctx, c.log, cert) {
+			return nil, errClientCertRevoked.Errorf("certificate with serial %s in chain is on the deny list", cert.SerialNumber.Text(16))
+		}
+	}
+
+	leaf := chain[0]
+	rule, value, username, ok := c.match(leaf)
+	if !ok {
+		return nil, errClientCertNoMatch.Errorf("no rule matches certificate %q", leaf.Subject.String())
+	}
+
+	if rule.identityType == clientCertTypeServiceAccount {
+		return c.authenticateServiceAccount(ctx, r, username)
+	}
+
+	identity := &authn.Identity{
+		Login:           username,
+		AuthID:          value,
+		AuthenticatedBy: login.ClientCertAuthModule,
+		ClientParams: authn.ClientParams{
+			SyncUser:        true,
+			FetchSyncedUser: true,
+			SyncPermissions: true,
+			AllowSignUp:     c.cfg.ClientCertAuth.AutoSignUp,
+		},
+	}
+
+	// The user is only looked up by the value the rule matched. Other values of
+	// the certificate are not verified by the rule and could point to another user.
+	if rule.source == clientCertSourceEmail {
+		identity.Email = value
+		identity.ClientParams.LookUpParams.Email = &identity.Email
+	} else {
+		identity.ClientParams.LookUpParams.Login = &identity.Login
+	}
+
+	return identity, nil
+}
+
+func (c *ClientCert) authenticateServiceAccount(ctx context.Context, r *authn.Request, username string) (*authn.Identity, error) {
+	usr, err := c.userService.GetByLogin(ctx, &user.GetUserByLoginQuery{LoginOrEmail: username})
+	if err != nil {
+		if errors.Is(err, user.ErrUserNotFound) {
+			return nil, errClientCertNoMatch.Errorf("no service account with login %q", username)
+		}
+		return nil, err
+	}
+
+	if !usr.IsServiceAccount {
+		return nil, errClientCertNoMatch.Errorf("%q is not a service account", username)
+	}
+
+	if r.OrgID == 0 {
+		r.OrgID = usr.OrgID
+	} else if r.OrgID != usr.OrgID {
+		return nil, errClientCertOrgMismatch.Errorf("service account belongs to org %d but %d was requested", usr.OrgID, r.OrgID)
+	}
+
+	return &authn.Identity{
+		ID:              strconv.FormatInt(usr.ID, 10),
+		Type:            claims.TypeServiceAccount,
+		OrgID:           usr.OrgID,
+		AuthenticatedBy: login.ClientCertAuthModule,
+		ClientParams:    authn.ClientParams{FetchSyncedUser: true, SyncPermissions: true},
+	}, nil
+}
+
+// match returns the first rule matching a value of the certificate, the value
+// and the login extracted from it.
+func (c *ClientCert) match(cert *x509.Certificate) (clientCertRule, string, string, bool) {
+	for _, rule := range c.rules {
+		for _, value := range clientCertValues(cert, rule.source) {
+			matches := rule.pattern.FindStringSubmatch(value)
+			if matches == nil {
+				continue
+			}
+
+			username := value
+			if i := rule.pattern.SubexpIndex(clientCertLoginGroup); i > 0 {
+				username = matches[i]
+			}
+			if username == "" {
+				continue
+			}
+			return rule, value, username, true
+		}
+	}
+	return clientCertRule{}, "", "", false
+}
+
+func clientCertValues(cert *x509.Certificate, source string) []string {
+	switch source {
+	case clientCertSourceCN:
+		if cert.Subject.CommonName == "" {
+			return nil
+		}
+		return []string{cert.Subject.CommonName}
+	case clientCertSourceSubject:
+		return []string{cert.Subject.String()}
+	case clientCertSourceDNS:
+		return cert.DNSNames
+	case clientCertSourceEmail:
+		return cert.EmailAddresses
+	case clientCertSourceURI:
+		values := make([]string, 0, len(cert.URIs))
+		for _, uri := range cert.URIs {
+			values = append(values, uri.String())
+		}
+		return values
+	}
+	return nil
+}
+
+// parseClientCertRules parses rules in the format <source>:<regexp>:<type>.
+// The regular expression has to match the whole value and may contain colons.
+func parseClientCertRules(rules []string) ([]clientCertRule, error) {
+	parsed := make([]clientCertRule, 0, len(rules))
+	for _, rule := range rules {
+		first, last := strings.Index(rule, ":"), strings.LastIndex(rule, ":")
+		if first == -1 || first == last {
+			return nil, fmt.Errorf("invalid client certificate rule %q, expected <source>:<regexp>:<type>", rule)
+		}
+
+		source, expr, identityType := rule[:first], rule[first+1:last], rule[last+1:]
+		switch source {
+		case clientCertSourceCN, clientCertSourceSubject, clientCertSourceDNS, clientCertSourceEmail, clientCertSourceURI:
+		default:
+			return nil, fmt.Errorf("invalid source %q in client certificate rule %q", source, rule)
+		}
+
+		switch identityType {
+		case clientCertTypeUser, clientCertTypeServiceAccount:
+		default:
+			return nil, fmt.Errorf("invalid type %q in client certificate rule %q", identityType, rule)
+		}
+
+		pattern, err := regexp.Compile("^(?:" + expr + ")$")
+		if err != nil {
+			return nil, fmt.Errorf("invalid regular expression in client certificate rule %q: %w", rule, err)
+		}
+
+		parsed = append(parsed, clientCertRule{source: source, pattern: pattern, identityType: identityType})
+	}
+	return parsed, nil
+}
+
+// certDenyList contains revoked certificates. Entries from the deny list file
+// are reloaded when the file changes, so certificates can be revoked without a
+// restart.
+type certDenyList struct {
+	entries map[string]struct{}
+	file    string
+
+	mu          sync.RWMutex
+	fileEntries map[string]struct{}
+	modTime     time.Time
+	lastCheck   time.Time
+}
+
+func newCertDenyList(entries []string, file string) (*certDenyList, error) {
+	list := &certDenyList{entries: make(map[string]struct{}, len(entries)), file: file}
+	for _, entry := range entries {
+		key, err := parseDenyListEntry(entry)
+		if err != nil {
+			return nil, err
+		}
+		list.entries[key] = struct{}{}
+	}
+
+	if file != "" {
+		if err := list.reload(time.Now()); err != nil {
+			return nil, err
+		}
+	}
+	return list, nil
+}
+
+func (l *certDenyList) isDenied(ctx context.Context, logger log.Logger, cert *x509.Certificate) bool {
+	if l.file != "" {
+		l.mu.RLock()
+		check := time.Since(l.lastCheck) > denyListCheckInterval
+		l.mu.RUnlock()
+		if check {
+			// Keep the previous entries if the file can't be read.
+			if err := l.reload(time.Now()); err != nil {
+				logger.FromContext(ctx).Error("Failed to reload client certificate deny list", "file", l.file, "error", err)
+			}
+		}
+	}
+
+	fingerprint := sha256.Sum256(cert.Raw)
+	keys := []string{"serial:" + cert.SerialNumber.Text(16), "sha256:" + hex.EncodeToString(fingerprint[:])}
+
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	for _, key := range keys {
+		if _, ok := l.entries[key]; ok {
+			return true
+		}
+		if _, ok := l.fileEntries[key]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+func (l *certDenyList) reload(now time.Time) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.lastCheck = now
+
+	info, err := os.Stat(l.file)
+	if err != nil {
+		return err
+	}
+	if info.ModTime().Equal(l.modTime) && l.fileEntries != nil {
+		return nil
+	}
+
+	f, err := os.Open(l.file)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = f.Close() }()
+
+	entries := map[string]struct{}{}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, err := parseDenyListEntry(line)
+		if err != nil {
+			return err
+		}
+		entries[key] = struct{}{}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	l.fileEntries = entries
+	l.modTime = info.ModTime()
+	return nil
+}
+
+// parseDenyListEntry normalizes a serial number in hex, or a sha256 fingerprint
+// prefixed with sha256:. Bytes may be separated by colons.
+func parseDenyListEntry(entry string) (string, error) {
+	entry = strings.ToLower(strings.TrimSpace(entry))
+	if fingerprint, ok := strings.CutPrefix(entry, "sha256:"); ok {
+		fingerprint = strings.ReplaceAll(fingerprint, ":", "")
+		if b, err := hex.DecodeString(fingerprint); err != nil || len(b) != sha256.Size {
+			return "", fmt.Errorf("invalid sha256 fingerprint %q in client certificate deny list", entry)
+		}
+		return "sha256:" + fingerprint, nil
+	}
+
+	serial, ok := new(big.Int).SetString(strings.ReplaceAll(entry, ":", ""), 16)
+	if !ok {
+		return "", fmt.Errorf("invalid serial number %q in client certificate deny list", entry)
+	}
+	return "serial:" + serial.Text(16), nil
+}
@@ -0,0 +1,238 @@
+package clients
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"math/big"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	claims "github.com/grafana/authlib/types"
+	"github.com/grafana/grafana/pkg/infra/tracing"
+	"github.com/grafana/grafana/pkg/services/authn"
+	"github.com/grafana/grafana/pkg/services/login"
+	"github.com/grafana/grafana/pkg/services/user"
+	"github.com/grafana/grafana/pkg/services/user/usertest"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+func newTestCert(t *testing.T, serial int64, template *x509.Certificate) *x509.Certificate {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template.SerialNumber = big.NewInt(serial)
+	template.NotBefore = time.Now().Add(-time.Hour)
+	template.NotAfter = time.Now().Add(time.Hour)
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return cert
+}
+
+func newClientCertRequest(orgID int64, chain ...*x509.Certificate) *authn.Request {
+	return &authn.Request{OrgID: orgID, HTTPRequest: &http.Request{
+		TLS: &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{chain}},
+	}}
+}
+
+func TestClientCert_Authenticate(t *testing.T) {
+	spiffe, err := url.Parse("spiffe://example.org/grafana/sa-1-deploy")
+	require.NoError(t, err)
+
+	ca := newTestCert(t, 1, &x509.Certificate{Subject: pkix.Name{CommonName: "ca"}, IsCA: true, BasicConstraintsValid: true})
+	userCert := newTestCert(t, 0xabcd, &x509.Certificate{
+		Subject:        pkix.Name{CommonName: "jane.users.example.org"},
+		EmailAddresses: []string{"jane@example.org"},
+	})
+	serviceCert := newTestCert(t, 3, &x509.Certificate{Subject: pkix.Name{CommonName: "deploy"}, URIs: []*url.URL{spiffe}})
+	unmatchedCert := newTestCert(t, 4, &x509.Certificate{Subject: pkix.Name{CommonName: "Not A Login"}})
+	partnerCert := newTestCert(t, 5, &x509.Certificate{
+		Subject:        pkix.Name{CommonName: "Partner"},
+		EmailAddresses: []string{"bob@partners.example.org"},
+	})
+
+	caFingerprint := sha256.Sum256(ca.Raw)
+
+	rules := []string{
+		`uri:spiffe://example\.org/grafana/(?P<login>sa-[^/]+):service_account`,
+		`cn:(?P<login>[a-z.]+)\.users\.example\.org:user`,
+		`email:(?P<login>[a-z]+)@partners\.example\.org:user`,
+	}
+
+	type testCase struct {
+		desc        string
+		req         *authn.Request
+		denyList    []string
+		user        *user.User
+		expectedErr error
+		expected    *authn.Identity
+	}
+
+	tests := []testCase{
+		{
+			desc: "should map certificate to user",
+			req:  newClientCertRequest(1, userCert, ca),
+			expected: &authn.Identity{
+				Login:           "jane",
+				AuthID:          "jane.users.example.org",
+				AuthenticatedBy: login.ClientCertAuthModule,
+			},
+		},
+		{
+			desc: "should map certificate to user by email",
+			req:  newClientCertRequest(1, partnerCert, ca),
+			expected: &authn.Identity{
+				Login:           "bob",
+				Email:           "bob@partners.example.org",
+				AuthID:          "bob@partners.example.org",
+				AuthenticatedBy: login.ClientCertAuthModule,
+			},
+		},
+		{
+			desc: "should map certificate to service account",
+			req:  newClientCertRequest(1, serviceCert, ca),
+			user: &user.User{ID: 10, OrgID: 1, Login: "sa-1-deploy", IsServiceAccount: true},
+			expected: &authn.Identity{
+				ID:              "10",
+				Type:            claims.TypeServiceAccount,
+				OrgID:           1,
+				AuthenticatedBy: login.ClientCertAuthModule,
+			},
+		},
+		{
+			desc:        "should fail for service account in another org",
+			req:         newClientCertRequest(2, serviceCert, ca),
+			user:        &user.User{ID: 10, OrgID: 1, Login: "sa-1-deploy", IsServiceAccount: true},
+			expectedErr: errClientCertOrgMismatch,
+		},
+		{
+			desc:        "should fail when service account rule matches a user",
+			req:         newClientCertRequest(1, serviceCert, ca),
+			user:        &user.User{ID: 10, OrgID: 1, Login: "sa-1-deploy"},
+			expectedErr: errClientCertNoMatch,
+		},
+		{
+			desc:        "should fail when no rule matches",
+			req:         newClientCertRequest(1, unmatchedCert, ca),
+			expectedErr: errClientCertNoMatch,
+		},
+		{
+			desc:        "should fail for denied serial number",
+			req:         newClientCertRequest(1, userCert, ca),
+			denyList:    []string{"ab:cd"},
+			expectedErr: errClientCertRevoked,
+		},
+		{
+			desc:        "should fail for denied issuer fingerprint",
+			req:         newClientCertRequest(1, userCert, ca),
+			denyList:    []string{"sha256:" + hex.EncodeToString(caFingerprint[:])},
+			expectedErr: errClientCertRevoked,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			cfg := setting.NewCfg()
+			cfg.ClientCertAuth = setting.AuthClientCertSettings{
+				Enabled:      true,
+				ClientCAFile: "ca.pem",
+				Rules:        rules,
+				DenyList:     tt.denyList,
+			}
+			userService := &usertest.FakeUserService{ExpectedUser: tt.user}
+			if tt.user == nil {
+				userService.ExpectedError = user.ErrUserNotFound
+			}
+
+			c, err := ProvideClientCert(cfg, userService, tracing.InitializeTracerForTest())
+			require.NoError(t, err)
+			require.True(t, c.Test(context.Background(), tt.req))
+
+			identity, err := c.Authenticate(context.Background(), tt.req)
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected.ID, identity.ID)
+			assert.Equal(t, tt.expected.Type, identity.Type)
+			assert.Equal(t, tt.expected.OrgID, identity.OrgID)
+			assert.Equal(t, tt.expected.Login, identity.Login)
+			assert.Equal(t, tt.expected.Email, identity.Email)
+			assert.Equal(t, tt.expected.AuthID, identity.AuthID)
+			assert.Equal(t, tt.expected.AuthenticatedBy, identity.AuthenticatedBy)
+			if identity.Type != claims.TypeServiceAccount {
+				// Users are only looked up by the value matched by the rule.
+				if tt.expected.Email != "" {
+					assert.Nil(t, identity.ClientParams.LookUpParams.Login)
+					assert.Equal(t, tt.expected.Email, *identity.ClientParams.LookUpParams.Email)
+				} else {
+					assert.Nil(t, identity.ClientParams.LookUpParams.Email)
+					assert.Equal(t, tt.expected.Login, *identity.ClientParams.LookUpParams.Login)
+				}
+			}
+		})
+	}
+}
+
+func TestClientCert_Test(t *testing.T) {
+	cfg := setting.NewCfg()
+	cfg.ClientCertAuth = setting.AuthClientCertSettings{Enabled: true, ClientCAFile: "ca.pem", Rules: []string{"cn:.+:user"}}
+	c, err := ProvideClientCert(cfg, &usertest.FakeUserService{}, tracing.InitializeTracerForTest())
+	require.NoError(t, err)
+
+	assert.False(t, c.Test(context.Background(), &authn.Request{HTTPRequest: &http.Request{}}))
+	assert.False(t, c.Test(context.Background(), &authn.Request{HTTPRequest: &http.Request{TLS: &tls.ConnectionState{}}}))
+}
+
+func TestProvideClientCert_InvalidConfig(t *testing.T) {
+	tests := map[string]setting.AuthClientCertSettings{
+		"missing ca file": {Rules: []string{"cn:.+:user"}},
+		"invalid rule":    {ClientCAFile: "ca.pem", Rules: []string{"cn:user"}},
+		"invalid source":  {ClientCAFile: "ca.pem", Rules: []string{"ou:.+:user"}},
+		"invalid type":    {ClientCAFile: "ca.pem", Rules: []string{"cn:.+:admin"}},
+		"invalid regexp":  {ClientCAFile: "ca.pem", Rules: []string{"cn:(:user"}},
+		"invalid serial":  {ClientCAFile: "ca.pem", DenyList: []string{"xyz"}},
+	}
+
+	for desc, settings := range tests {
+		t.Run(desc, func(t *testing.T) {
+			cfg := setting.NewCfg()
+			cfg.ClientCertAuth = settings
+			_, err := ProvideClientCert(cfg, &usertest.FakeUserService{}, tracing.InitializeTracerForTest())
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestCertDenyList_ReloadFile(t *testing.T) {
+	cert := newTestCert(t, 0x1f, &x509.Certificate{Subject: pkix.Name{CommonName: "user"}})
+	file := filepath.Join(t.TempDir(), "deny.txt")
+	require.NoError(t, os.WriteFile(file, []byte("# revoked certificates\n"), 0o600))
+
+	list, err := newCertDenyList(nil, file)
+	require.NoError(t, err)
+	assert.False(t, list.isDenied(context.Background(), nil, cert))
+
+	require.NoError(t, os.WriteFile(file, []byte("# revoked certificates\n1F\n"), 0o600))
+	require.NoError(t, os.Chtimes(file, time.Now(), time.Now().Add(time.Minute)))
+	require.NoError(t, list.reload(time.Now()))
+	assert.True(t, list.isDenied(context.Background(), nil, cert))
+}
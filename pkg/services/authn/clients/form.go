@@ -10,14 +10,17 @@ import (
 
 var errBadForm = errutil.BadRequest("form-auth.invalid", errutil.WithPublicMessage("bad login data"))
 
-var _ authn.Client = new(Form)
+var _ authn.HookClient = new(Form)
 
-func ProvideForm(client authn.PasswordClient) *Form {
-	return &Form{client}
+// ProvideForm returns a form client. twoFactor is optional and challenges
+// users for a second factor after a successful password login.
+func ProvideForm(client authn.PasswordClient, twoFactor *TwoFactor) *Form {
+	return &Form{client, twoFactor}
 }
 
 type Form struct {
-	client authn.PasswordClient
+	client    authn.PasswordClient
+	twoFactor *TwoFactor
 }
 
 type loginForm struct {
@@ -40,3 +43,10 @@ func (c *Form) Authenticate(ctx context.Context, r *authn.Request) (*authn.Ident
 func (c *Form) IsEnabled() bool {
 	return true
 }
+
+func (c *Form) Hook(ctx context.Context, identity *authn.Identity, r *authn.Request) error {
+	if c.twoFactor == nil {
+		return nil
+	}
+	return c.twoFactor.Challenge(ctx, identity)
+}
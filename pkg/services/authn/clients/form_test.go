@@ -40,7 +40,7 @@ func TestForm_Authenticate(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.desc, func(t *testing.T) {
-			c := ProvideForm(&authntest.FakePasswordClient{})
+			c := ProvideForm(&authntest.FakePasswordClient{}, nil)
 			_, err := c.Authenticate(context.Background(), tt.req)
 			assert.ErrorIs(t, err, tt.expectedErr)
 		})
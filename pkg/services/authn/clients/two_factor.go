@@ -0,0 +1,298 @@
+package clients
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	claims "github.com/grafana/authlib/types"
+	"github.com/grafana/grafana/pkg/apimachinery/errutil"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/infra/remotecache"
+	"github.com/grafana/grafana/pkg/services/authn"
+	"github.com/grafana/grafana/pkg/services/login"
+	"github.com/grafana/grafana/pkg/services/loginattempt"
+	"github.com/grafana/grafana/pkg/services/twofactor"
+	"github.com/grafana/grafana/pkg/services/webauthn"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/util"
+	"github.com/grafana/grafana/pkg/web"
+)
+
+var (
+	errTwoFactorRequired        = errutil.Unauthorized("two-factor.required").MustTemplate("two-factor authentication required", errutil.WithPublic("Two-factor authentication required"))
+	errTwoFactorInvalidToken    = errutil.Unauthorized("two-factor.invalid-token", errutil.WithPublicMessage("Login expired, please log in again"))
+	errTwoFactorTooManyAttempts = errutil.Unauthorized("two-factor.invalid.login-attempt", errutil.WithPublicMessage("Login temporarily blocked"))
+	errTwoFactorAlreadyEnrolled = errutil.BadRequest("two-factor.already-enrolled", errutil.WithPublicMessage("Two-factor authentication is already enabled"))
+	errTwoFactorBadForm         = errutil.BadRequest("two-factor.invalid-form", errutil.WithPublicMessage("Bad login data"))
+	errTwoFactorPasswordOnly    = errutil.Unauthorized("two-factor.password-only", errutil.WithPublicMessage("Two-factor authentication is required, log in with the login form or use a service account token"))
+
+	errTwoFactorInternal = errutil.Internal("two-factor.failed", errutil.WithPublicMessage("An internal error occurred in the two-factor client"))
+)
+
+const twoFactorKeyPrefix = "two-factor-pending-%s"
+
+var _ authn.RedirectClient = new(TwoFactor)
+
+// ProvideTwoFactor returns a two-factor client. passkeys is optional, when set
+// users with a passkey are challenged and can use it as their second factor.
+func ProvideTwoFactor(cfg *setting.Cfg, service twofactor.Service, passkeys webauthn.Service, loginAttempts loginattempt.Service, cache remotecache.CacheStorage) *TwoFactor {
+	return &TwoFactor{cfg, service, passkeys, loginAttempts, cache, log.New("authn.two-factor")}
+}
+
+// TwoFactor completes a login that was challenged for a second factor after
+// the password was verified. The challenge is identified by a short lived
+// token so that the password does not have to be sent again.
+type TwoFactor struct {
+	cfg           *setting.Cfg
+	service       twofactor.Service
+	passkeys      webauthn.Service
+	loginAttempts loginattempt.Service
+	cache         remotecache.CacheStorage
+	log           log.Logger
+}
+
+type TwoFactorPendingEntry struct {
+	UserID int64  `json:"user_id"`
+	Login  string `json:"login"`
+	// Enroll is true when the user has to set up two-factor authentication
+	// before the login can complete.
+	Enroll bool `json:"enroll"`
+}
+
+type TwoFactorForm struct {
+	Token string `json:"token" binding:"Required"`
+	Code  string `json:"code" binding:"Required"`
+}
+
+type TwoFactorEnrollForm struct {
+	Token string `json:"token" binding:"Required"`
+}
+
+func (c *TwoFactor) Name() string {
+	return authn.ClientTwoFactor
+}
+
+func (c *TwoFactor) IsEnabled() bool {
+	return c.service.IsEnabled()
+}
+
+// secondFactors returns the second factors a user authenticated with a password
+// is enrolled in, and whether a second factor is required for the user.
+func (c *TwoFactor) secondFactors(ctx context.Context, id *authn.Identity) (methods []string, required bool, err error) {
+	// Only users with a Grafana password are challenged, external providers are
+	// responsible for their own second factor.
+	if !id.IsIdentityType(claims.TypeUser) || id.GetAuthenticatedBy() != login.PasswordAuthModule {
+		return nil, false, nil
+	}
+
+	userID, err := id.GetInternalID()
+	if err != nil {
+		return nil, false, err
+	}
+
+	status, err := c.service.GetStatus(ctx, userID)
+	if err != nil {
+		return nil, false, errTwoFactorInternal.Errorf("failed to get two-factor status: %w", err)
+	}
+
+	methods = []string{}
+	if status.Enabled {
+		methods = append(methods, "totp")
+	}
+	if c.passkeys != nil {
+		hasPasskeys, err := c.passkeys.HasCredentials(ctx, userID)
+		if err != nil {
+			return nil, false, errTwoFactorInternal.Errorf("failed to get passkeys: %w", err)
+		}
+		if hasPasskeys {
+			methods = append(methods, "webauthn")
+		}
+	}
+
+	required = status.Required || (c.cfg.TwoFactorAuth.RequireForGrafanaAdmins && id.GetIsGrafanaAdmin())
+	return methods, required, nil
+}
+
+// RejectPasswordOnly is called once a password was verified by a client that
+// can not challenge for a second factor, such as basic auth. It returns an
+// error if the user is enrolled in or required to use a second factor.
+func (c *TwoFactor) RejectPasswordOnly(ctx context.Context, id *authn.Identity) error {
+	methods, required, err := c.secondFactors(ctx, id)
+	if err != nil {
+		return err
+	}
+	if len(methods) > 0 || required {
+		return errTwoFactorPasswordOnly.Errorf("user %s has to use a second factor", id.GetLogin())
+	}
+	return nil
+}
+
+// Challenge is called once a password login succeeded. It returns an error
+// carrying a pending login token if the user needs to provide a second factor.
+func (c *TwoFactor) Challenge(ctx context.Context, id *authn.Identity) error {
+	methods, required, err := c.secondFactors(ctx, id)
+	if err != nil {
+		return err
+	}
+	enrolled := len(methods) > 0
+	if !enrolled && !required {
+		return nil
+	}
+
+	userID, err := id.GetInternalID()
+	if err != nil {
+		return err
+	}
+
+	token, err := util.GetRandomString(32)
+	if err != nil {
+		return errTwoFactorInternal.Errorf("failed to generate token: %w", err)
+	}
+
+	entry, err := json.Marshal(&TwoFactorPendingEntry{UserID: userID, Login: id.GetLogin(), Enroll: !enrolled})
+	if err != nil {
+		return err
+	}
+
+	if err := c.cache.Set(ctx, fmt.Sprintf(twoFactorKeyPrefix, token), entry, c.cfg.TwoFactorAuth.LoginTimeout); err != nil {
+		return errTwoFactorInternal.Errorf("cache error: %w", err)
+	}
+
+	return errTwoFactorRequired.Build(errutil.TemplateData{
+		Public: map[string]any{
+			"token":              token,
+			"enrollmentRequired": !enrolled,
+			"methods":            methods,
+		},
+	})
+}
+
+// Authenticate implements authn.Client. It verifies the code for a pending
+// login and completes a pending enrollment.
+func (c *TwoFactor) Authenticate(ctx context.Context, r *authn.Request) (*authn.Identity, error) {
+	form := TwoFactorForm{}
+	if err := web.Bind(r.HTTPRequest, &form); err != nil {
+		return nil, errTwoFactorBadForm.Errorf("failed to parse request: %w", err)
+	}
+
+	entry, err := c.getPending(ctx, form.Token)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.validateAttempts(ctx, r, entry.Login); err != nil {
+		return nil, err
+	}
+
+	if entry.Enroll {
+		err = c.service.ConfirmEnrollment(ctx, entry.UserID, strings.TrimSpace(form.Code))
+	} else {
+		err = c.service.Verify(ctx, entry.UserID, strings.TrimSpace(form.Code))
+	}
+	if err != nil {
+		if addErr := c.loginAttempts.Add(ctx, entry.Login, web.RemoteAddr(r.HTTPRequest)); addErr != nil {
+			c.log.FromContext(ctx).Error("Failed to add login attempt", "user", entry.Login, "err", addErr)
+		}
+		return nil, err
+	}
+
+	if err := c.completePending(ctx, form.Token, entry); err != nil {
+		return nil, err
+	}
+
+	r.SetMeta(authn.MetaKeyAuthModule, login.PasswordAuthModule)
+
+	return &authn.Identity{
+		ID:              strconv.FormatInt(entry.UserID, 10),
+		Type:            claims.TypeUser,
+		OrgID:           r.OrgID,
+		ClientParams:    authn.ClientParams{FetchSyncedUser: true, SyncPermissions: true},
+		AuthenticatedBy: login.PasswordAuthModule,
+	}, nil
+}
+
+// RedirectURL implements authn.RedirectClient. It starts the enrollment of a
+// user that has to set up two-factor authentication to complete a login.
+func (c *TwoFactor) RedirectURL(ctx context.Context, r *authn.Request) (*authn.Redirect, error) {
+	form := TwoFactorEnrollForm{}
+	if err := web.Bind(r.HTTPRequest, &form); err != nil {
+		return nil, errTwoFactorBadForm.Errorf("failed to parse request: %w", err)
+	}
+
+	entry, err := c.getPending(ctx, form.Token)
+	if err != nil {
+		return nil, err
+	}
+	if !entry.Enroll {
+		return nil, errTwoFactorAlreadyEnrolled.Errorf("user %d is already enrolled", entry.UserID)
+	}
+
+	if err := c.validateAttempts(ctx, r, entry.Login); err != nil {
+		return nil, err
+	}
+
+	enrollment, err := c.service.StartEnrollment(ctx, entry.UserID, entry.Login)
+	if err != nil {
+		return nil, err
+	}
+
+	return &authn.Redirect{
+		URL: c.cfg.AppSubURL + "/login",
+		Extra: map[string]string{
+			"secret":        enrollment.Secret,
+			"url":           enrollment.URL,
+			"recoveryCodes": strings.Join(enrollment.RecoveryCodes, ","),
+		},
+	}, nil
+}
+
+func (c *TwoFactor) getPending(ctx context.Context, token string) (*TwoFactorPendingEntry, error) {
+	data, err := c.cache.Get(ctx, fmt.Sprintf(twoFactorKeyPrefix, token))
+	if err != nil {
+		if errors.Is(err, remotecache.ErrCacheItemNotFound) {
+			return nil, errTwoFactorInvalidToken.Errorf("no pending two-factor login for token")
+		}
+		return nil, errTwoFactorInternal.Errorf("cache error: %w", err)
+	}
+
+	entry := &TwoFactorPendingEntry{}
+	if err := json.Unmarshal(data, entry); err != nil {
+		return nil, errTwoFactorInternal.Errorf("failed to parse entry from two-factor cache: %w", err)
+	}
+	return entry, nil
+}
+
+// completePending removes a pending login once the second factor was verified.
+func (c *TwoFactor) completePending(ctx context.Context, token string, entry *TwoFactorPendingEntry) error {
+	if err := c.cache.Delete(ctx, fmt.Sprintf(twoFactorKeyPrefix, token)); err != nil {
+		return errTwoFactorInternal.Errorf("failed to delete entry from two-factor cache: %w", err)
+	}
+
+	if err := c.loginAttempts.Reset(ctx, entry.Login); err != nil {
+		c.log.FromContext(ctx).Warn("Could not reset login attempts", "err", err, "username", entry.Login)
+	}
+	return nil
+}
+
+func (c *TwoFactor) validateAttempts(ctx context.Context, r *authn.Request, username string) error {
+	ok, err := c.loginAttempts.Validate(ctx, username)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errTwoFactorTooManyAttempts.Errorf("too many consecutive incorrect login attempts for user - login for user temporarily blocked")
+	}
+
+	ok, err = c.loginAttempts.ValidateIPAddress(ctx, web.RemoteAddr(r.HTTPRequest))
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errTwoFactorTooManyAttempts.Errorf("too many consecutive incorrect login attempts for IP address - login for IP address temporarily blocked")
+	}
+	return nil
+}
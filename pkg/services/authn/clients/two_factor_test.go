@@ -0,0 +1,224 @@
+package clients
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	claims "github.com/grafana/authlib/types"
+	"github.com/grafana/grafana/pkg/apimachinery/errutil"
+	"github.com/grafana/grafana/pkg/infra/remotecache"
+	"github.com/grafana/grafana/pkg/services/authn"
+	"github.com/grafana/grafana/pkg/services/login"
+	"github.com/grafana/grafana/pkg/services/loginattempt/loginattempttest"
+	"github.com/grafana/grafana/pkg/services/twofactor"
+	"github.com/grafana/grafana/pkg/services/twofactor/twofactortest"
+	"github.com/grafana/grafana/pkg/services/webauthn"
+	"github.com/grafana/grafana/pkg/services/webauthn/webauthntest"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+func TestTwoFactor_Challenge(t *testing.T) {
+	isAdmin := true
+
+	type testCase struct {
+		desc          string
+		identity      *authn.Identity
+		status        *twofactor.Status
+		passkeys      []*webauthn.Credential
+		requireAdmins bool
+
+		expectChallenge bool
+		expectEnroll    bool
+	}
+
+	tests := []testCase{
+		{
+			desc:     "should not challenge users without two-factor",
+			identity: &authn.Identity{ID: "1", Type: claims.TypeUser, AuthenticatedBy: login.PasswordAuthModule},
+			status:   &twofactor.Status{},
+		},
+		{
+			desc:            "should challenge enrolled users",
+			identity:        &authn.Identity{ID: "1", Type: claims.TypeUser, AuthenticatedBy: login.PasswordAuthModule},
+			status:          &twofactor.Status{Enabled: true},
+			expectChallenge: true,
+		},
+		{
+			desc:            "should challenge users with passkeys",
+			identity:        &authn.Identity{ID: "1", Type: claims.TypeUser, AuthenticatedBy: login.PasswordAuthModule},
+			status:          &twofactor.Status{Required: true},
+			passkeys:        []*webauthn.Credential{{ID: 1}},
+			expectChallenge: true,
+		},
+		{
+			desc:     "should not challenge users authenticated by ldap",
+			identity: &authn.Identity{ID: "1", Type: claims.TypeUser, AuthenticatedBy: login.LDAPAuthModule},
+			status:   &twofactor.Status{Enabled: true},
+		},
+		{
+			desc:            "should require enrollment when required by org",
+			identity:        &authn.Identity{ID: "1", Type: claims.TypeUser, AuthenticatedBy: login.PasswordAuthModule},
+			status:          &twofactor.Status{Required: true},
+			expectChallenge: true,
+			expectEnroll:    true,
+		},
+		{
+			desc:            "should require enrollment for grafana admins when configured",
+			identity:        &authn.Identity{ID: "1", Type: claims.TypeUser, AuthenticatedBy: login.PasswordAuthModule, IsGrafanaAdmin: &isAdmin},
+			status:          &twofactor.Status{},
+			requireAdmins:   true,
+			expectChallenge: true,
+			expectEnroll:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			cfg := setting.NewCfg()
+			cfg.TwoFactorAuth = setting.AuthTwoFactorSettings{Enabled: true, LoginTimeout: time.Minute, RequireForGrafanaAdmins: tt.requireAdmins}
+			cache := remotecache.NewFakeCacheStorage()
+			c := ProvideTwoFactor(cfg, &twofactortest.FakeService{ExpectedStatus: tt.status}, &webauthntest.FakeService{ExpectedCredentials: tt.passkeys}, loginattempttest.FakeLoginAttemptService{ExpectedValid: true}, cache)
+
+			err := c.Challenge(context.Background(), tt.identity)
+			if !tt.expectChallenge {
+				require.NoError(t, err)
+				return
+			}
+
+			var gfErr errutil.Error
+			require.ErrorAs(t, err, &gfErr)
+			assert.Equal(t, "two-factor.required", gfErr.MessageID)
+			assert.Equal(t, tt.expectEnroll, gfErr.PublicPayload["enrollmentRequired"])
+
+			token, ok := gfErr.PublicPayload["token"].(string)
+			require.True(t, ok)
+			entry, err := c.getPending(context.Background(), token)
+			require.NoError(t, err)
+			assert.Equal(t, int64(1), entry.UserID)
+			assert.Equal(t, tt.expectEnroll, entry.Enroll)
+		})
+	}
+}
+
+func TestTwoFactor_Authenticate(t *testing.T) {
+	type testCase struct {
+		desc        string
+		entry       *TwoFactorPendingEntry
+		verifyErr   error
+		blockLogin  bool
+		expectedErr error
+		expectAdd   bool
+	}
+
+	tests := []testCase{
+		{
+			desc:  "should authenticate with valid code",
+			entry: &TwoFactorPendingEntry{UserID: 1, Login: "user"},
+		},
+		{
+			desc:  "should confirm enrollment for pending enrollment",
+			entry: &TwoFactorPendingEntry{UserID: 1, Login: "user", Enroll: true},
+		},
+		{
+			desc:        "should fail for unknown token",
+			expectedErr: errTwoFactorInvalidToken,
+		},
+		{
+			desc:        "should fail and record attempt for invalid code",
+			entry:       &TwoFactorPendingEntry{UserID: 1, Login: "user"},
+			verifyErr:   twofactor.ErrInvalidCode.Errorf("invalid code"),
+			expectedErr: twofactor.ErrInvalidCode,
+			expectAdd:   true,
+		},
+		{
+			desc:        "should fail when login is blocked",
+			entry:       &TwoFactorPendingEntry{UserID: 1, Login: "user"},
+			blockLogin:  true,
+			expectedErr: errTwoFactorTooManyAttempts,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			cfg := setting.NewCfg()
+			cfg.TwoFactorAuth = setting.AuthTwoFactorSettings{Enabled: true, LoginTimeout: time.Minute}
+			cache := remotecache.NewFakeCacheStorage()
+			if tt.entry != nil {
+				data, err := json.Marshal(tt.entry)
+				require.NoError(t, err)
+				require.NoError(t, cache.Set(context.Background(), fmt.Sprintf(twoFactorKeyPrefix, "token"), data, time.Minute))
+			}
+
+			service := &twofactortest.FakeService{ExpectedErr: tt.verifyErr}
+			attempts := &loginattempttest.MockLoginAttemptService{ExpectedValid: !tt.blockLogin}
+			c := ProvideTwoFactor(cfg, service, nil, attempts, cache)
+
+			req := &authn.Request{OrgID: 1, HTTPRequest: &http.Request{
+				Header: map[string][]string{"Content-Type": {"application/json"}},
+				Body:   io.NopCloser(strings.NewReader(`{"token": "token", "code": " 123456 "}`)),
+			}}
+
+			identity, err := c.Authenticate(context.Background(), req)
+			assert.Equal(t, tt.expectAdd, attempts.AddCalled)
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, "1", identity.ID)
+			assert.Equal(t, int64(1), identity.OrgID)
+			assert.Equal(t, login.PasswordAuthModule, identity.AuthenticatedBy)
+			assert.Equal(t, []string{"123456"}, service.VerifiedCodes)
+			assert.Equal(t, tt.entry.Enroll, service.Confirmed)
+			assert.True(t, attempts.ResetCalled)
+
+			_, err = c.getPending(context.Background(), "token")
+			assert.ErrorIs(t, err, errTwoFactorInvalidToken, "token should only be usable once")
+		})
+	}
+}
+
+func TestTwoFactor_RedirectURL(t *testing.T) {
+	cfg := setting.NewCfg()
+	cfg.TwoFactorAuth = setting.AuthTwoFactorSettings{Enabled: true, LoginTimeout: time.Minute}
+	cache := remotecache.NewFakeCacheStorage()
+	service := &twofactortest.FakeService{ExpectedEnrollment: &twofactor.Enrollment{
+		Secret:        "SECRET",
+		URL:           "otpauth://totp/Grafana:user?secret=SECRET",
+		RecoveryCodes: []string{"AAAAA-BBBBB", "CCCCC-DDDDD"},
+	}}
+	c := ProvideTwoFactor(cfg, service, nil, loginattempttest.FakeLoginAttemptService{ExpectedValid: true}, cache)
+
+	newRequest := func() *authn.Request {
+		return &authn.Request{HTTPRequest: &http.Request{
+			Header: map[string][]string{"Content-Type": {"application/json"}},
+			Body:   io.NopCloser(strings.NewReader(`{"token": "token"}`)),
+		}}
+	}
+
+	data, err := json.Marshal(&TwoFactorPendingEntry{UserID: 1, Login: "user"})
+	require.NoError(t, err)
+	require.NoError(t, cache.Set(context.Background(), fmt.Sprintf(twoFactorKeyPrefix, "token"), data, time.Minute))
+
+	_, err = c.RedirectURL(context.Background(), newRequest())
+	require.ErrorIs(t, err, errTwoFactorAlreadyEnrolled)
+
+	data, err = json.Marshal(&TwoFactorPendingEntry{UserID: 1, Login: "user", Enroll: true})
+	require.NoError(t, err)
+	require.NoError(t, cache.Set(context.Background(), fmt.Sprintf(twoFactorKeyPrefix, "token"), data, time.Minute))
+
+	redirect, err := c.RedirectURL(context.Background(), newRequest())
+	require.NoError(t, err)
+	assert.Equal(t, "SECRET", redirect.Extra["secret"])
+	assert.Equal(t, "AAAAA-BBBBB,CCCCC-DDDDD", redirect.Extra["recoveryCodes"])
+}
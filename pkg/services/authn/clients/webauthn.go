@@ -0,0 +1,182 @@
+package clients
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+
+	claims "github.com/grafana/authlib/types"
+	"github.com/grafana/grafana/pkg/apimachinery/errutil"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/authn"
+	"github.com/grafana/grafana/pkg/services/login"
+	"github.com/grafana/grafana/pkg/services/loginattempt"
+	"github.com/grafana/grafana/pkg/services/webauthn"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/web"
+)
+
+var (
+	errWebAuthnBadForm            = errutil.BadRequest("webauthn.invalid-form", errutil.WithPublicMessage("Bad login data"))
+	errWebAuthnTooManyAttempts    = errutil.Unauthorized("webauthn.invalid.login-attempt", errutil.WithPublicMessage("Login temporarily blocked"))
+	errWebAuthnUserNotVerified    = errutil.Unauthorized("webauthn.user-not-verified", errutil.WithPublicMessage("Passkey did not verify the user"))
+	errWebAuthnTwoFactorDisabled  = errutil.BadRequest("webauthn.two-factor-disabled", errutil.WithPublicMessage("Two-factor authentication is not enabled"))
+	errWebAuthnInternal           = errutil.Internal("webauthn.failed", errutil.WithPublicMessage("An internal error occurred in the passkey client"))
+	errWebAuthnCredentialMismatch = errutil.Unauthorized("webauthn.credential-mismatch", errutil.WithPublicMessage("Invalid passkey"))
+)
+
+var _ authn.RedirectClient = new(WebAuthn)
+
+// ProvideWebAuthn returns a passkey client. twoFactor is optional, when set a
+// passkey can complete a login that was challenged for a second factor.
+func ProvideWebAuthn(cfg *setting.Cfg, service webauthn.Service, loginAttempts loginattempt.Service, twoFactor *TwoFactor) *WebAuthn {
+	return &WebAuthn{cfg, service, loginAttempts, twoFactor, log.New("authn.webauthn")}
+}
+
+// WebAuthn logs in users with a passkey. Without a pending two-factor token
+// the passkey is used on its own and has to verify the user, with a token it
+// is the second factor of a password login.
+type WebAuthn struct {
+	cfg           *setting.Cfg
+	service       webauthn.Service
+	loginAttempts loginattempt.Service
+	twoFactor     *TwoFactor
+	log           log.Logger
+}
+
+type WebAuthnBeginForm struct {
+	// Token is the pending two-factor login token, if any.
+	Token string `json:"token"`
+}
+
+type WebAuthnForm struct {
+	Token      string                     `json:"token"`
+	Credential webauthn.AssertionResponse `json:"credential"`
+}
+
+func (c *WebAuthn) Name() string {
+	return authn.ClientWebAuthn
+}
+
+func (c *WebAuthn) IsEnabled() bool {
+	return c.service.IsEnabled()
+}
+
+// Authenticate implements authn.Client. It verifies an assertion created with
+// the options returned by RedirectURL.
+func (c *WebAuthn) Authenticate(ctx context.Context, r *authn.Request) (*authn.Identity, error) {
+	form := WebAuthnForm{}
+	if err := web.Bind(r.HTTPRequest, &form); err != nil {
+		return nil, errWebAuthnBadForm.Errorf("failed to parse request: %w", err)
+	}
+
+	var entry *TwoFactorPendingEntry
+	if form.Token != "" {
+		var err error
+		if entry, err = c.getPending(ctx, form.Token); err != nil {
+			return nil, err
+		}
+		if err := c.twoFactor.validateAttempts(ctx, r, entry.Login); err != nil {
+			return nil, err
+		}
+	} else if err := c.validateIPAddress(ctx, r); err != nil {
+		return nil, err
+	}
+
+	result, err := c.service.FinishLogin(ctx, &form.Credential)
+	if err != nil {
+		c.addAttempt(ctx, r, entry)
+		return nil, err
+	}
+
+	if entry != nil {
+		if result.UserID != entry.UserID {
+			c.addAttempt(ctx, r, entry)
+			return nil, errWebAuthnCredentialMismatch.Errorf("passkey of user %d used for pending login of user %d", result.UserID, entry.UserID)
+		}
+		if err := c.twoFactor.completePending(ctx, form.Token, entry); err != nil {
+			return nil, err
+		}
+	} else if !result.UserVerified {
+		// A passkey used as a second factor might not verify the user, it
+		// must not be enough to log in without a password.
+		return nil, errWebAuthnUserNotVerified.Errorf("passkey %d did not verify the user", result.CredentialID)
+	}
+
+	r.SetMeta(authn.MetaKeyAuthModule, login.WebAuthnAuthModule)
+
+	return &authn.Identity{
+		ID:              strconv.FormatInt(result.UserID, 10),
+		Type:            claims.TypeUser,
+		OrgID:           r.OrgID,
+		ClientParams:    authn.ClientParams{FetchSyncedUser: true, SyncPermissions: true},
+		AuthenticatedBy: login.WebAuthnAuthModule,
+	}, nil
+}
+
+// RedirectURL implements authn.RedirectClient. It returns the options for the
+// browser to get an assertion, in the "options" extra.
+func (c *WebAuthn) RedirectURL(ctx context.Context, r *authn.Request) (*authn.Redirect, error) {
+	form := WebAuthnBeginForm{}
+	if err := web.Bind(r.HTTPRequest, &form); err != nil {
+		return nil, errWebAuthnBadForm.Errorf("failed to parse request: %w", err)
+	}
+
+	var userID int64
+	if form.Token != "" {
+		entry, err := c.getPending(ctx, form.Token)
+		if err != nil {
+			return nil, err
+		}
+		if err := c.twoFactor.validateAttempts(ctx, r, entry.Login); err != nil {
+			return nil, err
+		}
+		userID = entry.UserID
+	} else if err := c.validateIPAddress(ctx, r); err != nil {
+		return nil, err
+	}
+
+	options, err := c.service.BeginLogin(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := json.Marshal(options)
+	if err != nil {
+		return nil, errWebAuthnInternal.Errorf("failed to encode options: %w", err)
+	}
+
+	return &authn.Redirect{
+		URL:   c.cfg.AppSubURL + "/login",
+		Extra: map[string]string{"options": string(data)},
+	}, nil
+}
+
+func (c *WebAuthn) getPending(ctx context.Context, token string) (*TwoFactorPendingEntry, error) {
+	if c.twoFactor == nil {
+		return nil, errWebAuthnTwoFactorDisabled.Errorf("two-factor token used while two-factor authentication is disabled")
+	}
+	return c.twoFactor.getPending(ctx, token)
+}
+
+func (c *WebAuthn) validateIPAddress(ctx context.Context, r *authn.Request) error {
+	ok, err := c.loginAttempts.ValidateIPAddress(ctx, web.RemoteAddr(r.HTTPRequest))
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errWebAuthnTooManyAttempts.Errorf("too many consecutive incorrect login attempts for IP address - login for IP address temporarily blocked")
+	}
+	return nil
+}
+
+// addAttempt records a failed login, for the pending user if there is one.
+func (c *WebAuthn) addAttempt(ctx context.Context, r *authn.Request, entry *TwoFactorPendingEntry) {
+	username := ""
+	if entry != nil {
+		username = entry.Login
+	}
+	if err := c.loginAttempts.Add(ctx, username, web.RemoteAddr(r.HTTPRequest)); err != nil {
+		c.log.FromContext(ctx).Error("Failed to add login attempt", "user", username, "err", err)
+	}
+}
@@ -0,0 +1,169 @@
+package clients
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/remotecache"
+	"github.com/grafana/grafana/pkg/services/authn"
+	"github.com/grafana/grafana/pkg/services/login"
+	"github.com/grafana/grafana/pkg/services/loginattempt/loginattempttest"
+	"github.com/grafana/grafana/pkg/services/twofactor/twofactortest"
+	"github.com/grafana/grafana/pkg/services/webauthn"
+	"github.com/grafana/grafana/pkg/services/webauthn/webauthntest"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+func TestWebAuthn_Authenticate(t *testing.T) {
+	type testCase struct {
+		desc        string
+		body        string
+		entry       *TwoFactorPendingEntry
+		noTwoFactor bool
+		result      *webauthn.LoginResult
+		finishErr   error
+		blockLogin  bool
+
+		expectedErr error
+		expectAdd   bool
+		expectReset bool
+	}
+
+	tests := []testCase{
+		{
+			desc:   "should authenticate with verified passkey",
+			body:   `{"credential": {}}`,
+			result: &webauthn.LoginResult{UserID: 1, CredentialID: 2, UserVerified: true},
+		},
+		{
+			desc:        "should not authenticate with unverified passkey without token",
+			body:        `{"credential": {}}`,
+			result:      &webauthn.LoginResult{UserID: 1, CredentialID: 2},
+			expectedErr: errWebAuthnUserNotVerified,
+		},
+		{
+			desc:        "should complete pending two-factor login",
+			body:        `{"token": "token", "credential": {}}`,
+			entry:       &TwoFactorPendingEntry{UserID: 1, Login: "user"},
+			result:      &webauthn.LoginResult{UserID: 1, CredentialID: 2},
+			expectReset: true,
+		},
+		{
+			desc:        "should fail for passkey of another user",
+			body:        `{"token": "token", "credential": {}}`,
+			entry:       &TwoFactorPendingEntry{UserID: 1, Login: "user"},
+			result:      &webauthn.LoginResult{UserID: 2, CredentialID: 2, UserVerified: true},
+			expectedErr: errWebAuthnCredentialMismatch,
+			expectAdd:   true,
+		},
+		{
+			desc:        "should fail and record attempt for invalid passkey",
+			body:        `{"credential": {}}`,
+			finishErr:   webauthn.ErrInvalidCredential.Errorf("invalid signature"),
+			expectedErr: webauthn.ErrInvalidCredential,
+			expectAdd:   true,
+		},
+		{
+			desc:        "should fail for unknown token",
+			body:        `{"token": "token", "credential": {}}`,
+			expectedErr: errTwoFactorInvalidToken,
+		},
+		{
+			desc:        "should fail for token when two-factor is disabled",
+			body:        `{"token": "token", "credential": {}}`,
+			noTwoFactor: true,
+			expectedErr: errWebAuthnTwoFactorDisabled,
+		},
+		{
+			desc:        "should fail when login is blocked",
+			body:        `{"credential": {}}`,
+			blockLogin:  true,
+			expectedErr: errWebAuthnTooManyAttempts,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			cfg := setting.NewCfg()
+			cache := remotecache.NewFakeCacheStorage()
+			if tt.entry != nil {
+				data, err := json.Marshal(tt.entry)
+				require.NoError(t, err)
+				require.NoError(t, cache.Set(context.Background(), fmt.Sprintf(twoFactorKeyPrefix, "token"), data, time.Minute))
+			}
+
+			attempts := &loginattempttest.MockLoginAttemptService{ExpectedValid: !tt.blockLogin}
+			service := &webauthntest.FakeService{ExpectedResult: tt.result, ExpectedErr: tt.finishErr}
+			var twoFactor *TwoFactor
+			if !tt.noTwoFactor {
+				twoFactor = ProvideTwoFactor(cfg, &twofactortest.FakeService{}, service, attempts, cache)
+			}
+			c := ProvideWebAuthn(cfg, service, attempts, twoFactor)
+
+			req := &authn.Request{OrgID: 1, HTTPRequest: &http.Request{
+				Header: map[string][]string{"Content-Type": {"application/json"}},
+				Body:   io.NopCloser(strings.NewReader(tt.body)),
+			}}
+
+			identity, err := c.Authenticate(context.Background(), req)
+			assert.Equal(t, tt.expectAdd, attempts.AddCalled)
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, "1", identity.ID)
+			assert.Equal(t, int64(1), identity.OrgID)
+			assert.Equal(t, login.WebAuthnAuthModule, identity.AuthenticatedBy)
+			assert.Equal(t, tt.expectReset, attempts.ResetCalled)
+
+			if tt.entry != nil {
+				_, err = twoFactor.getPending(context.Background(), "token")
+				assert.ErrorIs(t, err, errTwoFactorInvalidToken, "token should only be usable once")
+			}
+		})
+	}
+}
+
+func TestWebAuthn_RedirectURL(t *testing.T) {
+	cfg := setting.NewCfg()
+	cache := remotecache.NewFakeCacheStorage()
+	service := &webauthntest.FakeService{ExpectedAssertion: &webauthn.CredentialAssertion{
+		PublicKey: webauthn.RequestOptions{Challenge: []byte("challenge"), UserVerification: "required"},
+	}}
+	attempts := loginattempttest.FakeLoginAttemptService{ExpectedValid: true}
+	c := ProvideWebAuthn(cfg, service, attempts, ProvideTwoFactor(cfg, &twofactortest.FakeService{}, service, attempts, cache))
+
+	newRequest := func(body string) *authn.Request {
+		return &authn.Request{HTTPRequest: &http.Request{
+			Header: map[string][]string{"Content-Type": {"application/json"}},
+			Body:   io.NopCloser(strings.NewReader(body)),
+		}}
+	}
+
+	redirect, err := c.RedirectURL(context.Background(), newRequest(`{}`))
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), service.BeginLoginUserID)
+
+	options := &webauthn.CredentialAssertion{}
+	require.NoError(t, json.Unmarshal([]byte(redirect.Extra["options"]), options))
+	assert.Equal(t, []byte("challenge"), []byte(options.PublicKey.Challenge))
+
+	data, err := json.Marshal(&TwoFactorPendingEntry{UserID: 1, Login: "user"})
+	require.NoError(t, err)
+	require.NoError(t, cache.Set(context.Background(), fmt.Sprintf(twoFactorKeyPrefix, "token"), data, time.Minute))
+
+	_, err = c.RedirectURL(context.Background(), newRequest(`{"token": "token"}`))
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), service.BeginLoginUserID)
+}
@@ -49,6 +49,7 @@ func ProvideZanzana(cfg *setting.Cfg, db db.DB, tracer tracing.Tracer, features
 				Token:            cfg.ZanzanaClient.Token,
 				TokenExchangeURL: cfg.ZanzanaClient.TokenExchangeURL,
 				ServerCertFile:   cfg.ZanzanaClient.ServerCertFile,
+				TrustedProxies:   cfg.ZanzanaClient.TrustedProxies,
 			})
 	case setting.ZanzanaModeEmbedded:
 		store, err := zanzana.NewEmbeddedStore(cfg, db, logger)
@@ -81,7 +82,7 @@ func ProvideZanzana(cfg *setting.Cfg, db db.DB, tracer tracing.Tracer, features
 		authzv1.RegisterAuthzServiceServer(channel, srv)
 		authzextv1.RegisterAuthzExtentionServiceServer(channel, srv)
 
-		client, err = zanzana.NewClient(channel)
+		client, err = zanzana.NewClient(channel, cfg.ZanzanaClient.TrustedProxies)
 		if err != nil {
 			return nil, fmt.Errorf("failed to initialize zanzana client: %w", err)
 		}
@@ -98,6 +99,7 @@ type ZanzanaClientConfig struct {
 	Token            string
 	TokenExchangeURL string
 	ServerCertFile   string
+	TrustedProxies   string
 }
 
 func NewZanzanaClient(namespace string, cfg ZanzanaClientConfig) (zanzana.Client, error) {
@@ -133,7 +135,7 @@ func NewZanzanaClient(namespace string, cfg ZanzanaClientConfig) (zanzana.Client
 		return nil, fmt.Errorf("failed to create zanzana client to remote server: %w", err)
 	}
 
-	client, err := zanzana.NewClient(conn)
+	client, err := zanzana.NewClient(conn, cfg.TrustedProxies)
 	if err != nil {
 		return nil, fmt.Errorf("failed to initialize zanzana client: %w", err)
 	}
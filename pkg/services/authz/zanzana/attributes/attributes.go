@@ -0,0 +1,39 @@
+// Package attributes holds the attributes of a request that grants restricted by the attribute_filter
+// condition are evaluated against. It has no dependencies, so services checking access can provide
+// the attributes without depending on zanzana.
+package attributes
+
+import "context"
+
+// Request are the attributes of a request the attribute_filter condition is evaluated against.
+type Request struct {
+	// ClientIP is the IP address of the client the request originates from.
+	ClientIP string
+	// ResourceLabels are the labels of the requested resource. Grants restricted by labels only apply
+	// when the labels are known, i.e. not nil.
+	ResourceLabels map[string]string
+}
+
+type requestKey struct{}
+
+// WithRequest returns a context holding the attributes of the request.
+func WithRequest(ctx context.Context, attrs Request) context.Context {
+	return context.WithValue(ctx, requestKey{}, attrs)
+}
+
+// FromContext returns the attributes of the request stored in the context, if any.
+func FromContext(ctx context.Context) (Request, bool) {
+	attrs, ok := ctx.Value(requestKey{}).(Request)
+	return attrs, ok
+}
+
+// WithResourceLabels returns a context holding the labels of the requested resource, in addition to
+// the attributes already stored in ctx. Nil labels are stored as empty labels, so they are known.
+func WithResourceLabels(ctx context.Context, labels map[string]string) context.Context {
+	attrs, _ := FromContext(ctx)
+	attrs.ResourceLabels = labels
+	if attrs.ResourceLabels == nil {
+		attrs.ResourceLabels = map[string]string{}
+	}
+	return WithRequest(ctx, attrs)
+}
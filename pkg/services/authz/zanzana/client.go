@@ -20,8 +20,8 @@ type Client interface {
 	BatchCheck(ctx context.Context, req *authzextv1.BatchCheckRequest) (*authzextv1.BatchCheckResponse, error)
 }
 
-func NewClient(cc grpc.ClientConnInterface) (*client.Client, error) {
-	return client.New(cc)
+func NewClient(cc grpc.ClientConnInterface, trustedProxies string) (*client.Client, error) {
+	return client.New(cc, trustedProxies)
 }
 
 func WithShadowClient(accessClient authlib.AccessClient, zanzanaClient authlib.AccessClient, reg prometheus.Registerer) (authlib.AccessClient, error) {
@@ -2,6 +2,10 @@ package client
 
 import (
 	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
 
 	authzlib "github.com/grafana/authlib/authz"
 	authzv1 "github.com/grafana/authlib/authz/proto/v1"
@@ -11,6 +15,8 @@ import (
 
 	"github.com/grafana/grafana/pkg/infra/log"
 	authzextv1 "github.com/grafana/grafana/pkg/services/authz/proto/v1"
+	"github.com/grafana/grafana/pkg/services/authz/zanzana/common"
+	"github.com/grafana/grafana/pkg/web"
 )
 
 var _ authlib.AccessClient = (*Client)(nil)
@@ -22,15 +28,26 @@ type Client struct {
 	authz          authzv1.AuthzServiceClient
 	authzext       authzextv1.AuthzExtentionServiceClient
 	authzlibclient *authzlib.ClientImpl
+	// trustedProxies are the networks of the proxies the client address is taken from
+	// the X-Real-IP and X-Forwarded-For headers for.
+	trustedProxies []*net.IPNet
 }
 
-func New(cc grpc.ClientConnInterface) (*Client, error) {
+// New returns a client using cc. trustedProxies is a comma separated list of the IP
+// addresses or CIDR networks of the proxies allowed to forward the client address.
+func New(cc grpc.ClientConnInterface, trustedProxies string) (*Client, error) {
+	proxies, err := parseTrustedProxies(trustedProxies)
+	if err != nil {
+		return nil, err
+	}
+
 	authzlibclient := authzlib.NewClient(cc, authzlib.WithTracerClientOption(tracer))
 	c := &Client{
 		authzlibclient: authzlibclient,
 		authz:          authzv1.NewAuthzServiceClient(cc),
 		authzext:       authzextv1.NewAuthzExtentionServiceClient(cc),
 		logger:         log.New("zanzana.client"),
+		trustedProxies: proxies,
 	}
 
 	return c, nil
@@ -40,14 +57,14 @@ func (c *Client) Check(ctx context.Context, id authlib.AuthInfo, req authlib.Che
 	ctx, span := tracer.Start(ctx, "authlib.zanzana.client.Check")
 	defer span.End()
 
-	return c.authzlibclient.Check(ctx, id, req)
+	return c.authzlibclient.Check(c.withRequestAttributes(ctx), id, req)
 }
 
 func (c *Client) Compile(ctx context.Context, id authlib.AuthInfo, req authlib.ListRequest) (authlib.ItemChecker, authlib.Zookie, error) {
 	ctx, span := tracer.Start(ctx, "authlib.zanzana.client.Compile")
 	defer span.End()
 
-	return c.authzlibclient.Compile(ctx, id, req)
+	return c.authzlibclient.Compile(c.withRequestAttributes(ctx), id, req)
 }
 
 func (c *Client) Read(ctx context.Context, req *authzextv1.ReadRequest) (*authzextv1.ReadResponse, error) {
@@ -69,5 +86,67 @@ func (c *Client) BatchCheck(ctx context.Context, req *authzextv1.BatchCheckReque
 	ctx, span := tracer.Start(ctx, "authlib.zanzana.client.Check")
 	defer span.End()
 
-	return c.authzext.BatchCheck(ctx, req)
+	return c.authzext.BatchCheck(c.withRequestAttributes(ctx), req)
+}
+
+// withRequestAttributes forwards the attributes of the request to the server, so grants restricted
+// by the attribute_filter condition can be evaluated. The client IP defaults to the address of the
+// http request being served, if any.
+func (c *Client) withRequestAttributes(ctx context.Context) context.Context {
+	attrs, _ := common.RequestAttributesFromContext(ctx)
+	if attrs.ClientIP == "" {
+		if reqCtx := web.FromContext(ctx); reqCtx != nil && reqCtx.Req != nil {
+			attrs.ClientIP = c.clientIP(reqCtx.Req)
+		}
+	}
+	return common.AppendRequestAttributesToOutgoingContext(ctx, attrs)
+}
+
+// clientIP returns the address of the peer of the request. The address forwarded in the
+// request headers is only used when the peer is a trusted proxy, since the headers are
+// otherwise set by the client itself.
+func (c *Client) clientIP(req *http.Request) string {
+	host, _, err := net.SplitHostPort(req.RemoteAddr)
+	if err != nil {
+		host = req.RemoteAddr
+	}
+
+	peer := net.ParseIP(host)
+	if peer == nil {
+		return ""
+	}
+	for _, network := range c.trustedProxies {
+		if !network.Contains(peer) {
+			continue
+		}
+		if forwarded := web.RemoteAddr(req); net.ParseIP(forwarded) != nil {
+			return forwarded
+		}
+		break
+	}
+	return peer.String()
+}
+
+func parseTrustedProxies(s string) ([]*net.IPNet, error) {
+	proxies := make([]*net.IPNet, 0)
+	for _, addr := range strings.Split(s, ",") {
+		addr = strings.TrimSpace(addr)
+		if addr == "" {
+			continue
+		}
+		if !strings.Contains(addr, "/") {
+			if ip := net.ParseIP(addr); ip != nil && ip.To4() == nil {
+				addr += "/128"
+			} else {
+				addr += "/32"
+			}
+		}
+
+		_, network, err := net.ParseCIDR(addr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q: %w", addr, err)
+		}
+		proxies = append(proxies, network)
+	}
+	return proxies, nil
 }
@@ -0,0 +1,65 @@
+package client
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_clientIP(t *testing.T) {
+	tests := []struct {
+		desc           string
+		trustedProxies string
+		remoteAddr     string
+		headers        map[string]string
+		expected       string
+	}{
+		{
+			desc:       "should use the peer address",
+			remoteAddr: "10.0.0.1:1234",
+			expected:   "10.0.0.1",
+		},
+		{
+			desc:       "should ignore forwarded headers from untrusted peers",
+			remoteAddr: "192.168.1.1:1234",
+			headers:    map[string]string{"X-Real-IP": "10.0.0.1", "X-Forwarded-For": "10.0.0.2"},
+			expected:   "192.168.1.1",
+		},
+		{
+			desc:           "should use forwarded headers from trusted proxies",
+			trustedProxies: "127.0.0.1, 172.16.0.0/12",
+			remoteAddr:     "172.20.0.5:1234",
+			headers:        map[string]string{"X-Forwarded-For": "10.0.0.2, 172.20.0.4"},
+			expected:       "10.0.0.2",
+		},
+		{
+			desc:           "should use the peer address of trusted proxies without forwarded headers",
+			trustedProxies: "::1",
+			remoteAddr:     "[::1]:1234",
+			expected:       "::1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			c, err := New(nil, tt.trustedProxies)
+			require.NoError(t, err)
+
+			req, err := http.NewRequest(http.MethodGet, "/", nil)
+			require.NoError(t, err)
+			req.RemoteAddr = tt.remoteAddr
+			for key, value := range tt.headers {
+				req.Header.Set(key, value)
+			}
+
+			assert.Equal(t, tt.expected, c.clientIP(req))
+		})
+	}
+}
+
+func TestNew_InvalidTrustedProxies(t *testing.T) {
+	_, err := New(nil, "10.0.0.0/33")
+	require.Error(t, err)
+}
@@ -0,0 +1,314 @@
+package common
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"github.com/grafana/grafana/pkg/services/authz/zanzana/attributes"
+)
+
+// ConditionAttributeFilter is the condition restricting a grant with attributes of the request.
+const ConditionAttributeFilter = "attribute_filter"
+
+// Parameters of the attribute_filter condition provided by the server with every request.
+const (
+	attrResourceLabels = "resource_labels"
+	attrLabelsKnown    = "labels_known"
+	attrCurrentTime    = "current_time"
+	attrClientIP       = "client_ip"
+)
+
+// Parameters of the attribute_filter condition stored with the tuple.
+const (
+	attrMatchLabels   = "match_labels"
+	attrExcludeLabels = "exclude_labels"
+	attrNotBefore     = "not_before"
+	attrNotAfter      = "not_after"
+	attrSourceCIDRs   = "source_cidrs"
+)
+
+const (
+	metadataClientIP       = "x-zanzana-client-ip"
+	metadataResourceLabels = "x-zanzana-resource-labels"
+)
+
+var (
+	// Bounds of the time window used when a condition doesn't restrict it, as supported by CEL timestamps.
+	minConditionTime = time.Date(1, time.January, 1, 0, 0, 0, 0, time.UTC)
+	maxConditionTime = time.Date(9999, time.December, 31, 23, 59, 59, 0, time.UTC)
+
+	// The current time is truncated, so requests evaluated in the same minute can be served from cache.
+	conditionTimeGranularity = time.Minute
+
+	ErrInvalidCondition = errors.New("invalid condition")
+)
+
+// AttributeCondition restricts a grant with attributes of the request. Empty fields don't restrict the grant.
+type AttributeCondition struct {
+	// MatchLabels the resource must have.
+	MatchLabels map[string]string
+	// ExcludeLabels the resource must not have.
+	ExcludeLabels map[string]string
+	// NotBefore is the start of the time window the grant applies in.
+	NotBefore time.Time
+	// NotAfter is the end of the time window the grant applies in.
+	NotAfter time.Time
+	// SourceCIDRs the client IP must be in.
+	SourceCIDRs []string
+}
+
+// NewAttributeCondition returns the attribute_filter condition for a tuple.
+func NewAttributeCondition(c AttributeCondition) (*openfgav1.RelationshipCondition, error) {
+	notBefore, notAfter := minConditionTime, maxConditionTime
+	if !c.NotBefore.IsZero() {
+		notBefore = c.NotBefore
+	}
+	if !c.NotAfter.IsZero() {
+		notAfter = c.NotAfter
+	}
+
+	cidrs := make([]any, 0, len(c.SourceCIDRs))
+	for _, cidr := range c.SourceCIDRs {
+		cidrs = append(cidrs, cidr)
+	}
+
+	conditionCtx, err := structpb.NewStruct(map[string]any{
+		attrMatchLabels:   labelsToAny(c.MatchLabels),
+		attrExcludeLabels: labelsToAny(c.ExcludeLabels),
+		attrNotBefore:     notBefore.UTC().Format(time.RFC3339),
+		attrNotAfter:      notAfter.UTC().Format(time.RFC3339),
+		attrSourceCIDRs:   cidrs,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	condition := &openfgav1.RelationshipCondition{Name: ConditionAttributeFilter, Context: conditionCtx}
+	if err := ValidateAttributeCondition(condition); err != nil {
+		return nil, err
+	}
+	return condition, nil
+}
+
+// ValidateAttributeCondition validates the context of an attribute_filter condition and fills in
+// the parameters it doesn't restrict, so the condition can always be evaluated.
+func ValidateAttributeCondition(condition *openfgav1.RelationshipCondition) error {
+	if condition.GetName() != ConditionAttributeFilter {
+		return nil
+	}
+
+	if condition.Context == nil {
+		condition.Context = &structpb.Struct{}
+	}
+	if condition.Context.Fields == nil {
+		condition.Context.Fields = map[string]*structpb.Value{}
+	}
+	fields := condition.Context.Fields
+
+	for key := range fields {
+		switch key {
+		case attrMatchLabels, attrExcludeLabels, attrNotBefore, attrNotAfter, attrSourceCIDRs:
+		default:
+			return fmt.Errorf("%w: unsupported parameter %q", ErrInvalidCondition, key)
+		}
+	}
+
+	for _, key := range []string{attrMatchLabels, attrExcludeLabels} {
+		if _, ok := fields[key]; !ok {
+			fields[key] = structpb.NewStructValue(&structpb.Struct{Fields: map[string]*structpb.Value{}})
+			continue
+		}
+		labels := fields[key].GetStructValue()
+		if labels == nil {
+			return fmt.Errorf("%w: %s must be a map of strings", ErrInvalidCondition, key)
+		}
+		for name, value := range labels.GetFields() {
+			if _, ok := value.GetKind().(*structpb.Value_StringValue); !ok {
+				return fmt.Errorf("%w: %s value of label %q must be a string", ErrInvalidCondition, key, name)
+			}
+		}
+	}
+
+	window := map[string]time.Time{attrNotBefore: minConditionTime, attrNotAfter: maxConditionTime}
+	for key, bound := range window {
+		if _, ok := fields[key]; !ok {
+			fields[key] = structpb.NewStringValue(bound.Format(time.RFC3339))
+			continue
+		}
+		parsed, err := time.Parse(time.RFC3339, fields[key].GetStringValue())
+		if err != nil {
+			return fmt.Errorf("%w: %s must be a RFC 3339 timestamp", ErrInvalidCondition, key)
+		}
+		window[key] = parsed
+	}
+	if !window[attrNotBefore].Before(window[attrNotAfter]) {
+		return fmt.Errorf("%w: %s must be before %s", ErrInvalidCondition, attrNotBefore, attrNotAfter)
+	}
+
+	if _, ok := fields[attrSourceCIDRs]; !ok {
+		fields[attrSourceCIDRs] = structpb.NewListValue(&structpb.ListValue{})
+	}
+	cidrs := fields[attrSourceCIDRs].GetListValue()
+	if cidrs == nil {
+		return fmt.Errorf("%w: %s must be a list of strings", ErrInvalidCondition, attrSourceCIDRs)
+	}
+	for _, cidr := range cidrs.GetValues() {
+		if _, _, err := net.ParseCIDR(cidr.GetStringValue()); err != nil {
+			return fmt.Errorf("%w: %s contains an invalid CIDR %q", ErrInvalidCondition, attrSourceCIDRs, cidr.GetStringValue())
+		}
+	}
+
+	return nil
+}
+
+// ParseAttributeCondition returns the restrictions of an attribute_filter condition. Restrictions
+// filled in because the condition doesn't restrict them are returned empty.
+func ParseAttributeCondition(condition *openfgav1.RelationshipCondition) (AttributeCondition, error) {
+	var c AttributeCondition
+	if condition.GetName() != ConditionAttributeFilter {
+		return c, fmt.Errorf("%w: expected %s condition, got %q", ErrInvalidCondition, ConditionAttributeFilter, condition.GetName())
+	}
+
+	fields := condition.GetContext().GetFields()
+	c.MatchLabels = labelsFromValue(fields[attrMatchLabels])
+	c.ExcludeLabels = labelsFromValue(fields[attrExcludeLabels])
+
+	for key, dst := range map[string]*time.Time{attrNotBefore: &c.NotBefore, attrNotAfter: &c.NotAfter} {
+		value, ok := fields[key]
+		if !ok {
+			continue
+		}
+		parsed, err := time.Parse(time.RFC3339, value.GetStringValue())
+		if err != nil {
+			return c, fmt.Errorf("%w: %s must be a RFC 3339 timestamp", ErrInvalidCondition, key)
+		}
+		if !parsed.Equal(minConditionTime) && !parsed.Equal(maxConditionTime) {
+			*dst = parsed
+		}
+	}
+
+	for _, cidr := range fields[attrSourceCIDRs].GetListValue().GetValues() {
+		c.SourceCIDRs = append(c.SourceCIDRs, cidr.GetStringValue())
+	}
+
+	return c, nil
+}
+
+// IsAttributeConditionTuple returns true if the tuple is restricted by request attributes.
+func IsAttributeConditionTuple(t *openfgav1.TupleKey) bool {
+	return t.GetCondition().GetName() == ConditionAttributeFilter
+}
+
+// RequestAttributes are the attributes of a request the attribute_filter condition is evaluated against.
+type RequestAttributes = attributes.Request
+
+// WithRequestAttributes returns a context holding the attributes of the request.
+func WithRequestAttributes(ctx context.Context, attrs RequestAttributes) context.Context {
+	return attributes.WithRequest(ctx, attrs)
+}
+
+// RequestAttributesFromContext returns the attributes of the request stored in the context, if any.
+func RequestAttributesFromContext(ctx context.Context) (RequestAttributes, bool) {
+	return attributes.FromContext(ctx)
+}
+
+// AppendRequestAttributesToOutgoingContext adds the attributes of the request to the outgoing grpc metadata.
+func AppendRequestAttributesToOutgoingContext(ctx context.Context, attrs RequestAttributes) context.Context {
+	kv := make([]string, 0, 4)
+	if attrs.ClientIP != "" {
+		kv = append(kv, metadataClientIP, attrs.ClientIP)
+	}
+	if attrs.ResourceLabels != nil {
+		labels, err := json.Marshal(attrs.ResourceLabels)
+		if err == nil {
+			kv = append(kv, metadataResourceLabels, string(labels))
+		}
+	}
+
+	if len(kv) == 0 {
+		return ctx
+	}
+	return metadata.AppendToOutgoingContext(ctx, kv...)
+}
+
+// RequestAttributesFromIncomingContext returns the attributes of the request from the incoming grpc metadata.
+func RequestAttributesFromIncomingContext(ctx context.Context) RequestAttributes {
+	var attrs RequestAttributes
+
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return attrs
+	}
+
+	if values := md.Get(metadataClientIP); len(values) > 0 {
+		attrs.ClientIP = values[0]
+	}
+	if values := md.Get(metadataResourceLabels); len(values) > 0 {
+		labels := map[string]string{}
+		if err := json.Unmarshal([]byte(values[0]), &labels); err == nil {
+			attrs.ResourceLabels = labels
+		}
+	}
+
+	return attrs
+}
+
+// ConditionContext returns the request context of an openfga request. It holds the attributes
+// of the request in addition to the fields of resourceCtx.
+func ConditionContext(resourceCtx *structpb.Struct, attrs RequestAttributes, now time.Time) *structpb.Struct {
+	fields := make(map[string]*structpb.Value, len(resourceCtx.GetFields())+4)
+	for key, value := range resourceCtx.GetFields() {
+		fields[key] = value
+	}
+
+	labels := make(map[string]*structpb.Value, len(attrs.ResourceLabels))
+	for key, value := range attrs.ResourceLabels {
+		labels[key] = structpb.NewStringValue(value)
+	}
+
+	fields[attrResourceLabels] = structpb.NewStructValue(&structpb.Struct{Fields: labels})
+	fields[attrLabelsKnown] = structpb.NewBoolValue(attrs.ResourceLabels != nil)
+	fields[attrCurrentTime] = structpb.NewStringValue(now.UTC().Truncate(conditionTimeGranularity).Format(time.RFC3339))
+	fields[attrClientIP] = structpb.NewStringValue(normalizeClientIP(attrs.ClientIP))
+
+	return &structpb.Struct{Fields: fields}
+}
+
+// normalizeClientIP returns the IP address or an empty string if it isn't valid,
+// so it can safely be parsed by the condition.
+func normalizeClientIP(ip string) string {
+	parsed := net.ParseIP(strings.Trim(ip, "[]"))
+	if parsed == nil {
+		return ""
+	}
+	return parsed.String()
+}
+
+func labelsFromValue(value *structpb.Value) map[string]string {
+	fields := value.GetStructValue().GetFields()
+	if len(fields) == 0 {
+		return nil
+	}
+	labels := make(map[string]string, len(fields))
+	for key, value := range fields {
+		labels[key] = value.GetStringValue()
+	}
+	return labels
+}
+
+func labelsToAny(labels map[string]string) map[string]any {
+	result := make(map[string]any, len(labels))
+	for key, value := range labels {
+		result[key] = value
+	}
+	return result
+}
@@ -0,0 +1,106 @@
+package common
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func TestValidateAttributeCondition(t *testing.T) {
+	t.Run("fills in the parameters not restricting the grant", func(t *testing.T) {
+		condition := &openfgav1.RelationshipCondition{Name: ConditionAttributeFilter}
+		require.NoError(t, ValidateAttributeCondition(condition))
+
+		fields := condition.GetContext().GetFields()
+		assert.Empty(t, fields[attrMatchLabels].GetStructValue().GetFields())
+		assert.Empty(t, fields[attrExcludeLabels].GetStructValue().GetFields())
+		assert.Empty(t, fields[attrSourceCIDRs].GetListValue().GetValues())
+		assert.Equal(t, "0001-01-01T00:00:00Z", fields[attrNotBefore].GetStringValue())
+		assert.Equal(t, "9999-12-31T23:59:59Z", fields[attrNotAfter].GetStringValue())
+	})
+
+	t.Run("ignores other conditions", func(t *testing.T) {
+		condition := &openfgav1.RelationshipCondition{Name: "group_filter"}
+		require.NoError(t, ValidateAttributeCondition(condition))
+		assert.Nil(t, condition.GetContext())
+	})
+
+	testCases := map[string]map[string]*structpb.Value{
+		"request attribute":   {attrClientIP: structpb.NewStringValue("10.0.0.1")},
+		"invalid labels":      {attrMatchLabels: structpb.NewStringValue("env=prod")},
+		"invalid label value": {attrExcludeLabels: structpb.NewStructValue(&structpb.Struct{Fields: map[string]*structpb.Value{"env": structpb.NewBoolValue(true)}})},
+		"invalid timestamp":   {attrNotBefore: structpb.NewStringValue("yesterday")},
+		"empty time window":   {attrNotBefore: structpb.NewStringValue("2025-01-02T00:00:00Z"), attrNotAfter: structpb.NewStringValue("2025-01-01T00:00:00Z")},
+		"invalid cidr":        {attrSourceCIDRs: structpb.NewListValue(&structpb.ListValue{Values: []*structpb.Value{structpb.NewStringValue("10.0.0.1")}})},
+	}
+
+	for name, fields := range testCases {
+		t.Run(name, func(t *testing.T) {
+			condition := &openfgav1.RelationshipCondition{Name: ConditionAttributeFilter, Context: &structpb.Struct{Fields: fields}}
+			require.ErrorIs(t, ValidateAttributeCondition(condition), ErrInvalidCondition)
+		})
+	}
+}
+
+func TestNewAttributeCondition(t *testing.T) {
+	condition, err := NewAttributeCondition(AttributeCondition{
+		MatchLabels: map[string]string{"team": "a"},
+		NotAfter:    time.Date(2025, time.March, 1, 12, 0, 0, 0, time.FixedZone("CET", 3600)),
+		SourceCIDRs: []string{"10.0.0.0/8"},
+	})
+	require.NoError(t, err)
+
+	fields := condition.GetContext().GetFields()
+	assert.Equal(t, "a", fields[attrMatchLabels].GetStructValue().GetFields()["team"].GetStringValue())
+	assert.Equal(t, "2025-03-01T11:00:00Z", fields[attrNotAfter].GetStringValue())
+	assert.Equal(t, "10.0.0.0/8", fields[attrSourceCIDRs].GetListValue().GetValues()[0].GetStringValue())
+
+	_, err = NewAttributeCondition(AttributeCondition{SourceCIDRs: []string{"invalid"}})
+	require.ErrorIs(t, err, ErrInvalidCondition)
+}
+
+func TestParseAttributeCondition(t *testing.T) {
+	expected := AttributeCondition{
+		ExcludeLabels: map[string]string{"env": "prod"},
+		NotBefore:     time.Date(2025, time.March, 1, 8, 0, 0, 0, time.UTC),
+		SourceCIDRs:   []string{"10.0.0.0/8"},
+	}
+	condition, err := NewAttributeCondition(expected)
+	require.NoError(t, err)
+
+	parsed, err := ParseAttributeCondition(condition)
+	require.NoError(t, err)
+	assert.Equal(t, expected, parsed)
+
+	_, err = ParseAttributeCondition(&openfgav1.RelationshipCondition{Name: "group_filter"})
+	require.ErrorIs(t, err, ErrInvalidCondition)
+}
+
+func TestConditionContext(t *testing.T) {
+	ctx := AppendRequestAttributesToOutgoingContext(context.Background(), RequestAttributes{
+		ClientIP:       "[2001:db8::1]",
+		ResourceLabels: map[string]string{"env": "prod"},
+	})
+	md, _ := metadata.FromOutgoingContext(ctx)
+	attrs := RequestAttributesFromIncomingContext(metadata.NewIncomingContext(context.Background(), md))
+
+	resourceCtx := &structpb.Struct{Fields: map[string]*structpb.Value{"subresource": structpb.NewStringValue("dashboard.grafana.app/dashboards")}}
+	fields := ConditionContext(resourceCtx, attrs, time.Date(2025, time.March, 1, 12, 30, 45, 0, time.UTC)).GetFields()
+
+	assert.Equal(t, "dashboard.grafana.app/dashboards", fields["subresource"].GetStringValue())
+	assert.Equal(t, "2001:db8::1", fields[attrClientIP].GetStringValue())
+	assert.Equal(t, "prod", fields[attrResourceLabels].GetStructValue().GetFields()["env"].GetStringValue())
+	assert.True(t, fields[attrLabelsKnown].GetBoolValue())
+	assert.Equal(t, "2025-03-01T12:30:00Z", fields[attrCurrentTime].GetStringValue())
+
+	fields = ConditionContext(nil, RequestAttributes{ClientIP: "not-an-ip"}, time.Now()).GetFields()
+	assert.Equal(t, "", fields[attrClientIP].GetStringValue())
+	assert.False(t, fields[attrLabelsKnown].GetBoolValue())
+	assert.Empty(t, fields[attrResourceLabels].GetStructValue().GetFields())
+}
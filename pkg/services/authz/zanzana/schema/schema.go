@@ -15,6 +15,8 @@ var (
 	resourceDSL string
 	//go:embed schema_subresource.fga
 	subresourceDSL string
+	//go:embed schema_attribute.fga
+	attributeDSL string
 )
 
 var SchemaModules = []transformer.ModuleFile{
@@ -34,4 +36,8 @@ var SchemaModules = []transformer.ModuleFile{
 		Name:     "schema_subresource.fga",
 		Contents: subresourceDSL,
 	},
+	{
+		Name:     "schema_attribute.fga",
+		Contents: attributeDSL,
+	},
 }
@@ -30,3 +30,14 @@ func authorize(ctx context.Context, namespace string, ss setting.ZanzanaServerSe
 	}
 	return nil
 }
+
+// isInternalCaller returns true if the request was made by a service authenticated with an
+// access token, e.g. Grafana, rather than on behalf of a user. Only those callers can
+// forward the address of the client the request originates from.
+func isInternalCaller(ctx context.Context, ss setting.ZanzanaServerSettings) bool {
+	if ss.AllowInsecure {
+		return true
+	}
+	c, ok := claims.AuthInfoFrom(ctx)
+	return ok && claims.IsIdentityType(c.GetIdentityType(), claims.TypeAccessPolicy)
+}
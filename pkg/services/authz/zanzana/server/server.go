@@ -10,6 +10,7 @@ import (
 	authzv1 "github.com/grafana/authlib/authz/proto/v1"
 	openfgav1 "github.com/openfga/api/proto/openfga/v1"
 	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/protobuf/types/known/structpb"
 	"google.golang.org/protobuf/types/known/wrapperspb"
 
 	dashboardV2alpha1 "github.com/grafana/grafana/apps/dashboard/pkg/apis/dashboard/v2alpha1"
@@ -118,3 +119,14 @@ func (s *Server) getContextuals(subject string) (*openfgav1.ContextualTupleKeys,
 
 	return nil, nil
 }
+
+// conditionContext returns the context of an openfga request. In addition to resourceCtx it holds
+// the attributes of the request, used to evaluate grants restricted by the attribute_filter condition.
+func (s *Server) conditionContext(ctx context.Context, resourceCtx *structpb.Struct) *structpb.Struct {
+	attrs := common.RequestAttributesFromIncomingContext(ctx)
+	if attrs.ClientIP != "" && !isInternalCaller(ctx, s.cfg) {
+		s.logger.Debug("Ignoring client ip forwarded by an external caller")
+		attrs.ClientIP = ""
+	}
+	return common.ConditionContext(resourceCtx, attrs, time.Now())
+}
@@ -0,0 +1,126 @@
+package server
+
+import (
+	"context"
+	"testing"
+
+	openfgav1 "github.com/openfga/api/proto/openfga/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	authnlib "github.com/grafana/authlib/authn"
+	authzv1 "github.com/grafana/authlib/authz/proto/v1"
+	claims "github.com/grafana/authlib/types"
+
+	"github.com/grafana/grafana/pkg/apimachinery/utils"
+	authzextv1 "github.com/grafana/grafana/pkg/services/authz/proto/v1"
+	"github.com/grafana/grafana/pkg/services/authz/zanzana/common"
+)
+
+func newContextWithAttributes(attrs common.RequestAttributes) context.Context {
+	ctx := common.AppendRequestAttributesToOutgoingContext(newContextWithNamespace(), attrs)
+	md, _ := metadata.FromOutgoingContext(ctx)
+	return metadata.NewIncomingContext(ctx, md)
+}
+
+func withCondition(t *testing.T, tuple *openfgav1.TupleKey, c common.AttributeCondition) *openfgav1.TupleKey {
+	t.Helper()
+
+	condition, err := common.NewAttributeCondition(c)
+	require.NoError(t, err)
+	tuple.Condition = condition
+	return tuple
+}
+
+func testAttributeConditions(t *testing.T, server *Server) {
+	newReq := func(subject, group, resource, folder, name string) *authzv1.CheckRequest {
+		return &authzv1.CheckRequest{
+			Namespace: namespace,
+			Subject:   subject,
+			Verb:      utils.VerbGet,
+			Group:     group,
+			Resource:  resource,
+			Name:      name,
+			Folder:    folder,
+		}
+	}
+
+	check := func(t *testing.T, ctx context.Context, req *authzv1.CheckRequest) bool {
+		t.Helper()
+
+		res, err := server.Check(ctx, req)
+		require.NoError(t, err)
+		return res.GetAllowed()
+	}
+
+	t.Run("user:17 should only read dashboards in folder 1 not labeled env=prod", func(t *testing.T) {
+		req := newReq("user:17", dashboardGroup, dashboardResource, "1", "1")
+
+		assert.True(t, check(t, newContextWithAttributes(common.RequestAttributes{ResourceLabels: map[string]string{"env": "dev"}}), req))
+		assert.True(t, check(t, newContextWithAttributes(common.RequestAttributes{ResourceLabels: map[string]string{}}), req))
+		assert.False(t, check(t, newContextWithAttributes(common.RequestAttributes{ResourceLabels: map[string]string{"env": "prod"}}), req))
+
+		// grants restricted by labels don't apply when the labels of the resource are unknown
+		assert.False(t, check(t, newContextWithNamespace(), req))
+
+		res, err := server.List(newContextWithNamespace(), &authzv1.ListRequest{
+			Namespace: namespace,
+			Verb:      utils.VerbList,
+			Subject:   "user:17",
+			Group:     dashboardGroup,
+			Resource:  dashboardResource,
+		})
+		require.NoError(t, err)
+		assert.Empty(t, res.GetFolders())
+	})
+
+	t.Run("user:18 and user:19 should only read dashboards during their time window", func(t *testing.T) {
+		assert.True(t, check(t, newContextWithNamespace(), newReq("user:18", dashboardGroup, dashboardResource, "", "1")))
+		assert.False(t, check(t, newContextWithNamespace(), newReq("user:19", dashboardGroup, dashboardResource, "", "1")))
+	})
+
+	t.Run("user:20 should only read folder 1 from the allowed networks", func(t *testing.T) {
+		req := newReq("user:20", folderGroup, folderResource, "", "1")
+
+		assert.True(t, check(t, newContextWithAttributes(common.RequestAttributes{ClientIP: "10.1.2.3"}), req))
+		assert.True(t, check(t, newContextWithAttributes(common.RequestAttributes{ClientIP: "[2001:db8::1]"}), req))
+		assert.False(t, check(t, newContextWithAttributes(common.RequestAttributes{ClientIP: "192.168.1.1"}), req))
+		assert.False(t, check(t, newContextWithAttributes(common.RequestAttributes{ClientIP: "not-an-ip"}), req))
+		assert.False(t, check(t, newContextWithNamespace(), req))
+
+		// only internal callers can forward the client ip
+		userCtx := claims.WithAuthInfo(newContextWithAttributes(common.RequestAttributes{ClientIP: "10.1.2.3"}), authnlib.NewIDTokenAuthInfo(
+			authnlib.Claims[authnlib.AccessTokenClaims]{Rest: authnlib.AccessTokenClaims{Namespace: "*"}},
+			&authnlib.Claims[authnlib.IDTokenClaims]{Rest: authnlib.IDTokenClaims{Type: claims.TypeUser, Namespace: "*"}},
+		))
+		assert.False(t, check(t, userCtx, req))
+
+		res, err := server.List(newContextWithAttributes(common.RequestAttributes{ClientIP: "10.1.2.3"}), &authzv1.ListRequest{
+			Namespace: namespace,
+			Verb:      utils.VerbList,
+			Subject:   "user:20",
+			Group:     folderGroup,
+			Resource:  folderResource,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"1"}, res.GetItems())
+	})
+
+	t.Run("should reject invalid conditions", func(t *testing.T) {
+		tuple := common.NewFolderTuple("user:21", common.RelationSetView, "1")
+		tuple.Condition = &openfgav1.RelationshipCondition{
+			Name: common.ConditionAttributeFilter,
+			Context: &structpb.Struct{Fields: map[string]*structpb.Value{
+				"client_ip": structpb.NewStringValue("10.1.2.3"),
+			}},
+		}
+
+		_, err := server.Write(newContextWithNamespace(), &authzextv1.WriteRequest{
+			Namespace: namespace,
+			Writes:    &authzextv1.WriteRequestWrites{TupleKeys: common.ToAuthzExtTupleKeys([]*openfgav1.TupleKey{tuple})},
+		})
+		require.Error(t, err)
+	})
+}
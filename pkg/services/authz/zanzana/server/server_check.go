@@ -180,7 +180,7 @@ func (s *Server) openfgaCheck(ctx context.Context, store *storeInfo, subject, re
 			Relation: relation,
 			Object:   object,
 		},
-		Context:          resourceCtx,
+		Context:          s.conditionContext(ctx, resourceCtx),
 		ContextualTuples: contextuals,
 	})
 
@@ -91,7 +91,7 @@ func (s *Server) listTyped(ctx context.Context, subject, relation string, resour
 			Type:                 resource.Type(),
 			Relation:             subresourceRelation,
 			User:                 subject,
-			Context:              resourceCtx,
+			Context:              s.conditionContext(ctx, resourceCtx),
 			ContextualTuples:     contextuals,
 		})
 
@@ -109,6 +109,7 @@ func (s *Server) listTyped(ctx context.Context, subject, relation string, resour
 		Type:                 resource.Type(),
 		Relation:             relation,
 		User:                 subject,
+		Context:              s.conditionContext(ctx, nil),
 		ContextualTuples:     contextuals,
 	})
 	if err != nil {
@@ -139,7 +140,7 @@ func (s *Server) listGeneric(ctx context.Context, subject, relation string, reso
 			Type:                 common.TypeFolder,
 			Relation:             folderRelation,
 			User:                 subject,
-			Context:              resourceCtx,
+			Context:              s.conditionContext(ctx, resourceCtx),
 			ContextualTuples:     contextuals,
 		})
 
@@ -159,7 +160,7 @@ func (s *Server) listGeneric(ctx context.Context, subject, relation string, reso
 			Type:                 common.TypeResource,
 			Relation:             relation,
 			User:                 subject,
-			Context:              resourceCtx,
+			Context:              s.conditionContext(ctx, resourceCtx),
 			ContextualTuples:     contextuals,
 		})
 		if err != nil {
@@ -3,6 +3,7 @@ package server
 import (
 	"context"
 	"testing"
+	"time"
 
 	openfgav1 "github.com/openfga/api/proto/openfga/v1"
 	"github.com/prometheus/client_golang/prometheus"
@@ -82,6 +83,10 @@ func TestIntegrationServer(t *testing.T) {
 	t.Run("test batch check", func(t *testing.T) {
 		testBatchCheck(t, srv)
 	})
+
+	t.Run("test attribute conditions", func(t *testing.T) {
+		testAttributeConditions(t, srv)
+	})
 }
 
 func setup(t *testing.T, testDB db.DB, cfg *setting.Cfg) *Server {
@@ -123,6 +128,20 @@ func setup(t *testing.T, testDB db.DB, cfg *setting.Cfg) *Server {
 			common.NewTypedResourceTuple("user:14", common.RelationGet, common.TypeTeam, teamGroup, teamResource, statusSubresource, "1"),
 			common.NewTypedResourceTuple("user:15", common.RelationGet, common.TypeUser, userGroup, userResource, statusSubresource, "1"),
 			common.NewTypedResourceTuple("user:16", common.RelationGet, common.TypeServiceAccount, serviceAccountGroup, serviceAccountResource, statusSubresource, "1"),
+			withCondition(t, common.NewFolderResourceTuple("user:17", common.RelationSetView, dashboardGroup, dashboardResource, "", "1"), common.AttributeCondition{
+				ExcludeLabels: map[string]string{"env": "prod"},
+			}),
+			withCondition(t, common.NewGroupResourceTuple("user:18", common.RelationSetView, dashboardGroup, dashboardResource, ""), common.AttributeCondition{
+				NotBefore: time.Now().Add(-time.Hour),
+				NotAfter:  time.Now().Add(time.Hour),
+			}),
+			withCondition(t, common.NewGroupResourceTuple("user:19", common.RelationSetView, dashboardGroup, dashboardResource, ""), common.AttributeCondition{
+				NotBefore: time.Now().Add(-2 * time.Hour),
+				NotAfter:  time.Now().Add(-time.Hour),
+			}),
+			withCondition(t, common.NewFolderTuple("user:20", common.RelationSetView, "1"), common.AttributeCondition{
+				SourceCIDRs: []string{"10.0.0.0/8", "2001:db8::/32"},
+			}),
 		},
 	}
 	for _, w := range writes.TupleKeys {
@@ -41,7 +41,11 @@ func (s *Server) write(ctx context.Context, req *authzextv1.WriteRequest) (*auth
 
 	writeTuples := make([]*openfgav1.TupleKey, 0)
 	for _, t := range req.GetWrites().GetTupleKeys() {
-		writeTuples = append(writeTuples, common.ToOpenFGATupleKey(t))
+		tuple := common.ToOpenFGATupleKey(t)
+		if err := common.ValidateAttributeCondition(tuple.GetCondition()); err != nil {
+			return nil, fmt.Errorf("invalid tuple %s: %w", tuple.String(), err)
+		}
+		writeTuples = append(writeTuples, tuple)
 	}
 
 	deleteTuples := make([]*openfgav1.TupleKeyWithoutCondition, 0)
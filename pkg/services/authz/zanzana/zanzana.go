@@ -71,6 +71,18 @@ var (
 	ToOpenFGATupleKeyWithoutCondition = common.ToOpenFGATupleKeyWithoutCondition
 )
 
+type (
+	AttributeCondition = common.AttributeCondition
+	RequestAttributes  = common.RequestAttributes
+)
+
+var (
+	NewAttributeCondition     = common.NewAttributeCondition
+	ParseAttributeCondition   = common.ParseAttributeCondition
+	IsAttributeConditionTuple = common.IsAttributeConditionTuple
+	WithRequestAttributes     = common.WithRequestAttributes
+)
+
 // NewTupleEntry constructs new openfga entry type:name[#relation].
 // Relation allows to specify group of users (subjects) related to type:name
 // (for example, team:devs#member refers to users which are members of team devs)
@@ -0,0 +1,140 @@
+package keyfileprovider
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/grafana/grafana/pkg/services/encryption"
+	"github.com/grafana/grafana/pkg/services/secrets"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+// keyIdDelimiter delimits the identifier of the key a blob was encrypted with.
+const keyIdDelimiter = '#'
+
+var keyIdPattern = regexp.MustCompile(`^[a-zA-Z0-9_.-]+$`)
+
+// keyFileProvider encrypts data keys with keys read from a local file.
+//
+// Every line of the file holds a key in the format <key-id>=<key>, and lines
+// starting with # are comments. Data keys are encrypted with the active key, and
+// the encrypted blobs reference the key they were encrypted with. Rotating the key
+// encryption key consists of adding a new key to the file, making it the active key
+// and re-encrypting the data keys. Secrets do not need to be re-encrypted, and the
+// previous key can be removed once the data keys were re-encrypted.
+type keyFileProvider struct {
+	enc       encryption.Internal
+	keys      map[string]string
+	activeKey string
+}
+
+// New returns a provider configured from the section:
+//
+//	[security.encryption.keyfile.<keyName>]
+//	path = /etc/grafana/encryption.keys
+//	active_key = <key-id>
+//
+// The active key defaults to the last key of the file.
+func New(section *setting.DynamicSection, enc encryption.Internal) (secrets.Provider, error) {
+	path := section.Key("path").MustString("")
+	if path == "" {
+		return nil, errors.New("missing key file path")
+	}
+
+	keys, lastKey, err := readKeyFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	activeKey := section.Key("active_key").MustString(lastKey)
+	if _, ok := keys[activeKey]; !ok {
+		return nil, fmt.Errorf("active key %q not found in key file %s", activeKey, path)
+	}
+
+	return &keyFileProvider{
+		enc:       enc,
+		keys:      keys,
+		activeKey: activeKey,
+	}, nil
+}
+
+func readKeyFile(path string) (map[string]string, string, error) {
+	// We can ignore the gosec G304 warning on this one because `path` comes
+	// from the Grafana configuration file.
+	// nolint:gosec
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to open key file: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+
+	keys := make(map[string]string)
+	var lastKey string
+
+	scanner := bufio.NewScanner(f)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		id, key, ok := strings.Cut(line, "=")
+		id, key = strings.TrimSpace(id), strings.TrimSpace(key)
+		if !ok || !keyIdPattern.MatchString(id) || key == "" {
+			return nil, "", fmt.Errorf("invalid key on line %d of key file %s: expected <key-id>=<key>", lineNumber, path)
+		}
+		if _, exists := keys[id]; exists {
+			return nil, "", fmt.Errorf("duplicated key %q in key file %s", id, path)
+		}
+
+		keys[id] = key
+		lastKey = id
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, "", fmt.Errorf("failed to read key file: %w", err)
+	}
+
+	if len(keys) == 0 {
+		return nil, "", fmt.Errorf("key file %s contains no keys", path)
+	}
+
+	return keys, lastKey, nil
+}
+
+func (p *keyFileProvider) Encrypt(ctx context.Context, blob []byte) ([]byte, error) {
+	encrypted, err := p.enc.Encrypt(ctx, blob, p.keys[p.activeKey])
+	if err != nil {
+		return nil, err
+	}
+
+	prefix := []byte{keyIdDelimiter}
+	prefix = append(prefix, p.activeKey...)
+	prefix = append(prefix, keyIdDelimiter)
+
+	return append(prefix, encrypted...), nil
+}
+
+func (p *keyFileProvider) Decrypt(ctx context.Context, blob []byte) ([]byte, error) {
+	if len(blob) == 0 || blob[0] != keyIdDelimiter {
+		return nil, errors.New("malformed blob: missing key identifier")
+	}
+
+	end := bytes.IndexByte(blob[1:], keyIdDelimiter)
+	if end == -1 {
+		return nil, errors.New("malformed blob: missing key identifier")
+	}
+
+	id := string(blob[1 : end+1])
+	key, ok := p.keys[id]
+	if !ok {
+		return nil, fmt.Errorf("key %q not found in key file", id)
+	}
+
+	return p.enc.Decrypt(ctx, blob[end+2:], key)
+}
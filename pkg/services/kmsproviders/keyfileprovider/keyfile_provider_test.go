@@ -0,0 +1,134 @@
+package keyfileprovider
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/ini.v1"
+
+	encryptionservice "github.com/grafana/grafana/pkg/services/encryption/service"
+	"github.com/grafana/grafana/pkg/services/secrets"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+func writeKeyFile(t *testing.T, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "encryption.keys")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0600))
+	return path
+}
+
+func setupProvider(t *testing.T, config string) (secrets.Provider, error) {
+	t.Helper()
+
+	raw, err := ini.Load([]byte("[security.encryption.keyfile.v1]\n" + config))
+	require.NoError(t, err)
+
+	cfg := setting.NewCfg()
+	cfg.Raw = raw
+
+	return New(cfg.SectionWithEnvOverrides("security.encryption.keyfile.v1"), encryptionservice.SetupTestService(t))
+}
+
+func TestKeyFileProvider(t *testing.T) {
+	t.Run("encrypts with the last key by default", func(t *testing.T) {
+		path := writeKeyFile(t, "# comment\nkey-1 = first secret\n\nkey-2 = second secret\n")
+		provider, err := setupProvider(t, "path = "+path)
+		require.NoError(t, err)
+
+		encrypted, err := provider.Encrypt(t.Context(), []byte("data key"))
+		require.NoError(t, err)
+		assert.Contains(t, string(encrypted), "#key-2#")
+
+		decrypted, err := provider.Decrypt(t.Context(), encrypted)
+		require.NoError(t, err)
+		assert.Equal(t, []byte("data key"), decrypted)
+	})
+
+	t.Run("rotating the active key keeps previous blobs readable", func(t *testing.T) {
+		path := writeKeyFile(t, "key-1=first secret\n")
+		provider, err := setupProvider(t, "path = "+path)
+		require.NoError(t, err)
+
+		encrypted, err := provider.Encrypt(t.Context(), []byte("data key"))
+		require.NoError(t, err)
+
+		require.NoError(t, os.WriteFile(path, []byte("key-1=first secret\nkey-2=second secret\n"), 0600))
+		rotated, err := setupProvider(t, "path = "+path)
+		require.NoError(t, err)
+
+		decrypted, err := rotated.Decrypt(t.Context(), encrypted)
+		require.NoError(t, err)
+		assert.Equal(t, []byte("data key"), decrypted)
+
+		reencrypted, err := rotated.Encrypt(t.Context(), decrypted)
+		require.NoError(t, err)
+		assert.Contains(t, string(reencrypted), "#key-2#")
+
+		// Once data keys were re-encrypted, the previous key can be removed.
+		require.NoError(t, os.WriteFile(path, []byte("key-2=second secret\n"), 0600))
+		pruned, err := setupProvider(t, "path = "+path)
+		require.NoError(t, err)
+
+		_, err = pruned.Decrypt(t.Context(), encrypted)
+		require.ErrorContains(t, err, `key "key-1" not found`)
+
+		decrypted, err = pruned.Decrypt(t.Context(), reencrypted)
+		require.NoError(t, err)
+		assert.Equal(t, []byte("data key"), decrypted)
+	})
+
+	t.Run("explicit active key", func(t *testing.T) {
+		path := writeKeyFile(t, "key-1=first secret\nkey-2=second secret\n")
+		provider, err := setupProvider(t, "path = "+path+"\nactive_key = key-1")
+		require.NoError(t, err)
+
+		encrypted, err := provider.Encrypt(t.Context(), []byte("data key"))
+		require.NoError(t, err)
+		assert.Contains(t, string(encrypted), "#key-1#")
+
+		_, err = setupProvider(t, "path = "+path+"\nactive_key = key-3")
+		require.ErrorContains(t, err, `active key "key-3" not found`)
+	})
+
+	t.Run("malformed blobs", func(t *testing.T) {
+		path := writeKeyFile(t, "key-1=first secret\n")
+		provider, err := setupProvider(t, "path = "+path)
+		require.NoError(t, err)
+
+		for _, blob := range []string{"", "key-1#data", "#key-1"} {
+			_, err = provider.Decrypt(t.Context(), []byte(blob))
+			require.ErrorContains(t, err, "malformed blob", blob)
+		}
+	})
+
+	t.Run("invalid configuration", func(t *testing.T) {
+		testCases := map[string]struct {
+			keyFile string
+			err     string
+		}{
+			"missing separator": {keyFile: "key-1\n", err: "invalid key on line 1"},
+			"empty key":         {keyFile: "# keys\nkey-1=\n", err: "invalid key on line 2"},
+			"invalid key id":    {keyFile: "key#1=secret\n", err: "invalid key on line 1"},
+			"duplicated key":    {keyFile: "key-1=a\nkey-1=b\n", err: `duplicated key "key-1"`},
+			"no keys":           {keyFile: "# no keys yet\n", err: "contains no keys"},
+		}
+
+		for name, tc := range testCases {
+			t.Run(name, func(t *testing.T) {
+				_, err := setupProvider(t, "path = "+writeKeyFile(t, tc.keyFile))
+				require.ErrorContains(t, err, tc.err)
+			})
+		}
+
+		_, err := setupProvider(t, "")
+		require.ErrorContains(t, err, "missing key file path")
+
+		_, err = setupProvider(t, "path = "+filepath.Join(t.TempDir(), "missing.keys"))
+		require.ErrorContains(t, err, "failed to open key file")
+	})
+}
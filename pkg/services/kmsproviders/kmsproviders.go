@@ -12,6 +12,21 @@ const (
 	// which fallbacks to Grafana's secret key. See the
 	// defaultprovider package for further information.
 	Default = "secretKey.v1"
+
+	// VaultTransit is the kind of the providers using a key
+	// from the HashiCorp Vault Transit secrets engine. See the
+	// vaultprovider package for further information.
+	VaultTransit = "hashicorpvault"
+
+	// KeyFile is the kind of the providers using keys read
+	// from a local file. See the keyfileprovider package for
+	// further information.
+	KeyFile = "keyfile"
+
+	// SectionPrefix is the prefix of the configuration
+	// sections of the kms providers, followed by the
+	// provider identifier: <provider>.<keyName>.
+	SectionPrefix = "security.encryption."
 )
 
 type Service interface {
@@ -1,10 +1,16 @@
 package osskmsproviders
 
 import (
+	"fmt"
+	"strings"
+
+	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/services/encryption"
 	"github.com/grafana/grafana/pkg/services/featuremgmt"
 	"github.com/grafana/grafana/pkg/services/kmsproviders"
 	grafana "github.com/grafana/grafana/pkg/services/kmsproviders/defaultprovider"
+	"github.com/grafana/grafana/pkg/services/kmsproviders/keyfileprovider"
+	"github.com/grafana/grafana/pkg/services/kmsproviders/vaultprovider"
 	"github.com/grafana/grafana/pkg/services/secrets"
 	"github.com/grafana/grafana/pkg/setting"
 )
@@ -13,6 +19,7 @@ type Service struct {
 	enc      encryption.Internal
 	cfg      *setting.Cfg
 	features featuremgmt.FeatureToggles
+	log      log.Logger
 }
 
 func ProvideService(enc encryption.Internal, cfg *setting.Cfg, features featuremgmt.FeatureToggles) Service {
@@ -20,11 +27,48 @@ func ProvideService(enc encryption.Internal, cfg *setting.Cfg, features featurem
 		enc:      enc,
 		cfg:      cfg,
 		features: features,
+		log:      log.New("kmsproviders"),
 	}
 }
 
+// Provide returns the default provider, and the providers listed in the
+// `available_encryption_providers` setting of the [security] section.
 func (s Service) Provide() (map[secrets.ProviderID]secrets.Provider, error) {
-	return map[secrets.ProviderID]secrets.Provider{
+	providers := map[secrets.ProviderID]secrets.Provider{
 		kmsproviders.Default: grafana.New(s.cfg, s.enc),
-	}, nil
+	}
+
+	available := s.cfg.SectionWithEnvOverrides("security").Key("available_encryption_providers").MustString("")
+	for _, id := range strings.Fields(available) {
+		providerID := kmsproviders.NormalizeProviderID(secrets.ProviderID(id))
+		if _, ok := providers[providerID]; ok {
+			continue
+		}
+
+		kind, err := providerID.Kind()
+		if err != nil {
+			return nil, err
+		}
+
+		section := s.cfg.SectionWithEnvOverrides(kmsproviders.SectionPrefix + string(providerID))
+
+		var provider secrets.Provider
+		switch kind {
+		case kmsproviders.VaultTransit:
+			provider, err = vaultprovider.New(section)
+		case kmsproviders.KeyFile:
+			provider, err = keyfileprovider.New(section, s.enc)
+		default:
+			s.log.Warn("Encryption provider is not supported", "provider", providerID)
+			continue
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("failed to initialize encryption provider %s: %w", providerID, err)
+		}
+
+		providers[providerID] = provider
+	}
+
+	return providers, nil
 }
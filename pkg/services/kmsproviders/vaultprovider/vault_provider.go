@@ -0,0 +1,174 @@
+package vaultprovider
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/secrets"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+const (
+	defaultTransitEnginePath    = "transit"
+	defaultTokenRenewalInterval = 5 * time.Minute
+	requestTimeout              = 30 * time.Second
+)
+
+// vaultProvider encrypts data keys with a named key of the HashiCorp Vault
+// Transit secrets engine. The key material never leaves Vault.
+//
+// Vault includes the key version in the ciphertext, so the key can be rotated
+// in Vault without re-encrypting anything: older versions keep decrypting the
+// existing data keys, and re-encrypting the data keys moves them to the latest
+// version.
+type vaultProvider struct {
+	client               *http.Client
+	url                  string
+	token                string
+	namespace            string
+	transitEnginePath    string
+	keyRing              string
+	tokenRenewalInterval time.Duration
+	log                  log.Logger
+}
+
+var (
+	_ secrets.Provider           = (*vaultProvider)(nil)
+	_ secrets.BackgroundProvider = (*vaultProvider)(nil)
+)
+
+// New returns a provider configured from the section:
+//
+//	[security.encryption.hashicorpvault.<keyName>]
+//	url = http://localhost:8200
+//	token = <periodic service token>
+//	namespace = <vault enterprise namespace>
+//	transit_engine_path = transit
+//	key_ring = grafana-encryption-key
+//	token_renewal_interval = 5m
+func New(section *setting.DynamicSection) (secrets.Provider, error) {
+	p := &vaultProvider{
+		client:               &http.Client{Timeout: requestTimeout},
+		url:                  strings.TrimSuffix(section.Key("url").MustString(""), "/"),
+		token:                section.Key("token").MustString(""),
+		namespace:            section.Key("namespace").MustString(""),
+		transitEnginePath:    strings.Trim(section.Key("transit_engine_path").MustString(defaultTransitEnginePath), "/"),
+		keyRing:              section.Key("key_ring").MustString(""),
+		tokenRenewalInterval: section.Key("token_renewal_interval").MustDuration(defaultTokenRenewalInterval),
+		log:                  log.New("kmsproviders.vault"),
+	}
+
+	if p.url == "" {
+		return nil, errors.New("missing vault url")
+	}
+	if p.token == "" {
+		return nil, errors.New("missing vault token")
+	}
+	if p.keyRing == "" {
+		return nil, errors.New("missing vault key ring")
+	}
+
+	return p, nil
+}
+
+func (p *vaultProvider) Encrypt(ctx context.Context, blob []byte) ([]byte, error) {
+	var resp struct {
+		Data struct {
+			Ciphertext string `json:"ciphertext"`
+		} `json:"data"`
+	}
+
+	body := map[string]string{"plaintext": base64.StdEncoding.EncodeToString(blob)}
+	if err := p.do(ctx, p.transitEnginePath+"/encrypt/"+p.keyRing, body, &resp); err != nil {
+		return nil, fmt.Errorf("failed to encrypt with vault: %w", err)
+	}
+
+	return []byte(resp.Data.Ciphertext), nil
+}
+
+func (p *vaultProvider) Decrypt(ctx context.Context, blob []byte) ([]byte, error) {
+	var resp struct {
+		Data struct {
+			Plaintext string `json:"plaintext"`
+		} `json:"data"`
+	}
+
+	body := map[string]string{"ciphertext": string(blob)}
+	if err := p.do(ctx, p.transitEnginePath+"/decrypt/"+p.keyRing, body, &resp); err != nil {
+		return nil, fmt.Errorf("failed to decrypt with vault: %w", err)
+	}
+
+	return base64.StdEncoding.DecodeString(resp.Data.Plaintext)
+}
+
+// Run renews the token periodically, so periodic service tokens do not expire.
+func (p *vaultProvider) Run(ctx context.Context) error {
+	if p.tokenRenewalInterval <= 0 {
+		return nil
+	}
+
+	ticker := time.NewTicker(p.tokenRenewalInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := p.do(ctx, "auth/token/renew-self", map[string]string{}, nil); err != nil {
+				p.log.Error("Failed to renew vault token", "error", err)
+			}
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+func (p *vaultProvider) do(ctx context.Context, path string, body any, out any) error {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.url+"/v1/"+path, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Vault-Request", "true")
+	req.Header.Set("X-Vault-Token", p.token)
+	if p.namespace != "" {
+		req.Header.Set("X-Vault-Namespace", p.namespace)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		var vErr struct {
+			Errors []string `json:"errors"`
+		}
+		_ = json.Unmarshal(respBody, &vErr)
+		return fmt.Errorf("vault responded with status %d: %s", resp.StatusCode, strings.Join(vErr.Errors, "; "))
+	}
+
+	if out == nil {
+		return nil
+	}
+	return json.Unmarshal(respBody, out)
+}
@@ -0,0 +1,145 @@
+package vaultprovider
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/ini.v1"
+
+	"github.com/grafana/grafana/pkg/services/secrets"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+// fakeTransit is a stand-in for the Vault Transit secrets engine. Ciphertexts
+// embed the plaintext and the key version, like Vault does.
+type fakeTransit struct {
+	mu       sync.Mutex
+	renewals int
+	version  int
+}
+
+func (f *fakeTransit) handle(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if r.Header.Get("X-Vault-Token") != "vault-token" || r.Header.Get("X-Vault-Namespace") != "team-a" {
+		w.WriteHeader(http.StatusForbidden)
+		_, _ = w.Write([]byte(`{"errors":["permission denied"]}`))
+		return
+	}
+
+	var body map[string]string
+	_ = json.NewDecoder(r.Body).Decode(&body)
+
+	var data map[string]string
+	switch r.URL.Path {
+	case "/v1/transit-engine/encrypt/grafana":
+		data = map[string]string{"ciphertext": "vault:v" + strconv.Itoa(f.version) + ":" + body["plaintext"]}
+	case "/v1/transit-engine/decrypt/grafana":
+		plaintext, ok := strings.CutPrefix(body["ciphertext"], "vault:v1:")
+		if !ok {
+			plaintext, ok = strings.CutPrefix(body["ciphertext"], "vault:v2:")
+		}
+		if !ok {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"errors":["invalid ciphertext"]}`))
+			return
+		}
+		data = map[string]string{"plaintext": plaintext}
+	case "/v1/auth/token/renew-self":
+		f.renewals++
+	default:
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	_ = json.NewEncoder(w).Encode(map[string]any{"data": data})
+}
+
+func (f *fakeTransit) renewalCount() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.renewals
+}
+
+func setupProvider(t *testing.T, config string) (*fakeTransit, secrets.Provider, error) {
+	t.Helper()
+
+	transit := &fakeTransit{version: 1}
+	server := httptest.NewServer(http.HandlerFunc(transit.handle))
+	t.Cleanup(server.Close)
+
+	raw, err := ini.Load([]byte("[security.encryption.hashicorpvault.v1]\nurl = " + server.URL + "\n" + config))
+	require.NoError(t, err)
+
+	cfg := setting.NewCfg()
+	cfg.Raw = raw
+
+	provider, err := New(cfg.SectionWithEnvOverrides("security.encryption.hashicorpvault.v1"))
+	return transit, provider, err
+}
+
+func TestVaultProvider(t *testing.T) {
+	config := "token = vault-token\nnamespace = team-a\ntransit_engine_path = /transit-engine/\nkey_ring = grafana\n"
+
+	t.Run("encrypt and decrypt", func(t *testing.T) {
+		transit, provider, err := setupProvider(t, config)
+		require.NoError(t, err)
+
+		encrypted, err := provider.Encrypt(t.Context(), []byte("data key"))
+		require.NoError(t, err)
+		assert.True(t, strings.HasPrefix(string(encrypted), "vault:v1:"))
+
+		// Blobs encrypted with previous key versions remain readable after rotating the key in Vault.
+		transit.mu.Lock()
+		transit.version = 2
+		transit.mu.Unlock()
+		decrypted, err := provider.Decrypt(t.Context(), encrypted)
+		require.NoError(t, err)
+		assert.Equal(t, []byte("data key"), decrypted)
+
+		_, err = provider.Decrypt(t.Context(), []byte("not a vault ciphertext"))
+		require.ErrorContains(t, err, "invalid ciphertext")
+	})
+
+	t.Run("vault errors are returned", func(t *testing.T) {
+		_, provider, err := setupProvider(t, "token = wrong-token\nnamespace = team-a\ntransit_engine_path = transit-engine\nkey_ring = grafana\n")
+		require.NoError(t, err)
+
+		_, err = provider.Encrypt(t.Context(), []byte("data key"))
+		require.ErrorContains(t, err, "status 403: permission denied")
+	})
+
+	t.Run("renews the token periodically", func(t *testing.T) {
+		transit, provider, err := setupProvider(t, config+"token_renewal_interval = 10ms\n")
+		require.NoError(t, err)
+
+		bp, ok := provider.(secrets.BackgroundProvider)
+		require.True(t, ok)
+
+		ctx, cancel := context.WithCancel(t.Context())
+		done := make(chan error)
+		go func() { done <- bp.Run(ctx) }()
+
+		require.Eventually(t, func() bool { return transit.renewalCount() >= 2 }, time.Second, 10*time.Millisecond)
+		cancel()
+		require.NoError(t, <-done)
+	})
+
+	t.Run("invalid configuration", func(t *testing.T) {
+		_, _, err := setupProvider(t, "key_ring = grafana\n")
+		require.ErrorContains(t, err, "missing vault token")
+
+		_, _, err = setupProvider(t, "token = vault-token\n")
+		require.ErrorContains(t, err, "missing vault key ring")
+	})
+}
@@ -39,6 +39,7 @@ type IServer interface {
 	Bind() error
 	UserBind(string, string) error
 	Dial() error
+	HealthCheck() error
 	Close()
 }
 
@@ -168,6 +169,22 @@ func dialTLSWithTimeout(network, addr string, config *tls.Config, timeout time.D
 	return conn, nil
 }
 
+// HealthCheck reads the root DSE to verify that the connection can still be used.
+// Dial() sets the connection with the server for this Struct. Therefore, we require a
+// call to Dial() before being able to execute this function.
+func (server *Server) HealthCheck() error {
+	_, err := server.Connection.Search(&ldap.SearchRequest{
+		BaseDN:       "",
+		Scope:        ldap.ScopeBaseObject,
+		DerefAliases: ldap.NeverDerefAliases,
+		SizeLimit:    1,
+		TimeLimit:    server.Config.Timeout,
+		Filter:       "(objectClass=*)",
+		Attributes:   []string{"1.1"},
+	})
+	return err
+}
+
 // Close closes the LDAP connection
 // Dial() sets the connection with the server for this Struct. Therefore, we require a
 // call to Dial() before being able to execute this function.
@@ -585,15 +602,8 @@ func (server *Server) userBind(path, password string) error {
 func (server *Server) requestMemberOf(entry *ldap.Entry) ([]string, error) {
 	var memberOf []string
 	var config = server.Config
-	var searchBaseDNs []string
 
-	if len(config.GroupSearchBaseDNs) > 0 {
-		searchBaseDNs = config.GroupSearchBaseDNs
-	} else {
-		searchBaseDNs = config.SearchBaseDNs
-	}
-
-	for _, groupSearchBase := range searchBaseDNs {
+	for _, groupSearchBase := range server.groupSearchBaseDNs() {
 		var filterReplace string
 		if config.GroupSearchFilterUserAttribute == "" {
 			filterReplace = getAttribute(config.Attr.Username, entry)
@@ -676,16 +686,24 @@ func (server *Server) serializeUsers(
 func (server *Server) getMemberOf(result *ldap.Entry) (
 	[]string, error,
 ) {
+	var memberOf []string
 	if server.Config.GroupSearchFilter == "" {
-		memberOf := getArrayAttribute(server.Config.Attr.MemberOf, result)
-
-		return memberOf, nil
+		memberOf = getArrayAttribute(server.Config.Attr.MemberOf, result)
+	} else {
+		var err error
+		memberOf, err = server.requestMemberOf(result)
+		if err != nil {
+			return nil, err
+		}
 	}
 
-	memberOf, err := server.requestMemberOf(result)
-	if err != nil {
-		return nil, err
-	}
+	return server.resolveNestedGroups(result, memberOf)
+}
 
-	return memberOf, nil
+// groupSearchBaseDNs returns the base DNs to search groups in
+func (server *Server) groupSearchBaseDNs() []string {
+	if len(server.Config.GroupSearchBaseDNs) > 0 {
+		return server.Config.GroupSearchBaseDNs
+	}
+	return server.Config.SearchBaseDNs
 }
@@ -48,24 +48,41 @@ type IMultiLDAP interface {
 	User(login string) (
 		*login.ExternalUserInfo, ldap.ServerConfig, error,
 	)
+
+	// Close closes the pooled connections
+	Close()
 }
 
 // MultiLDAP is basic struct of LDAP authorization
 type MultiLDAP struct {
 	configs []*ldap.ServerConfig
+	pools   []*connectionPool
 	cfg     *ldap.Config
 	log     log.Logger
 }
 
 // New creates the new LDAP auth
 func New(configs []*ldap.ServerConfig, cfg *ldap.Config) IMultiLDAP {
+	pools := make([]*connectionPool, 0, len(configs))
+	for _, config := range configs {
+		pools = append(pools, newConnectionPool(config, cfg))
+	}
+
 	return &MultiLDAP{
 		configs: configs,
+		pools:   pools,
 		cfg:     cfg,
 		log:     log.New("ldap"),
 	}
 }
 
+// Close closes the pooled connections of all servers
+func (multiples *MultiLDAP) Close() {
+	for _, pool := range multiples.pools {
+		pool.close()
+	}
+}
+
 // Ping dials each of the LDAP servers and returns their status. If the server is unavailable, it also returns the error.
 func (multiples *MultiLDAP) Ping() ([]*ServerStatus, error) {
 	if len(multiples.configs) == 0 {
@@ -107,9 +124,9 @@ func (multiples *MultiLDAP) Login(query *login.LoginUserQuery) (
 	ldapSilentErrors := []error{}
 
 	for index, config := range multiples.configs {
-		server := newLDAP(config, multiples.cfg)
-
-		if err := server.Dial(); err != nil {
+		pool := multiples.pools[index]
+		server, err := pool.get()
+		if err != nil {
 			logDialFailure(err, config)
 
 			// Only return an error if it is the last server so we can try next server
@@ -119,9 +136,8 @@ func (multiples *MultiLDAP) Login(query *login.LoginUserQuery) (
 			continue
 		}
 
-		defer server.Close()
-
 		user, err := server.Login(query)
+		pool.put(server, err)
 		if err != nil {
 			if isSilentError(err) {
 				ldapSilentErrors = append(ldapSilentErrors, err)
@@ -165,9 +181,9 @@ func (multiples *MultiLDAP) User(login string) (
 
 	search := []string{login}
 	for index, config := range multiples.configs {
-		server := newLDAP(config, multiples.cfg)
-
-		if err := server.Dial(); err != nil {
+		pool := multiples.pools[index]
+		server, err := pool.get()
+		if err != nil {
 			logDialFailure(err, config)
 
 			// Only return an error if it is the last server so we can try next server
@@ -177,13 +193,8 @@ func (multiples *MultiLDAP) User(login string) (
 			continue
 		}
 
-		defer server.Close()
-
-		if err := server.Bind(); err != nil {
-			return nil, *config, err
-		}
-
-		users, err := server.Users(search)
+		users, err := bindAndSearch(server, search)
+		pool.put(server, err)
 		if err != nil {
 			return nil, *config, err
 		}
@@ -208,9 +219,9 @@ func (multiples *MultiLDAP) Users(logins []string) (
 	}
 
 	for index, config := range multiples.configs {
-		server := newLDAP(config, multiples.cfg)
-
-		if err := server.Dial(); err != nil {
+		pool := multiples.pools[index]
+		server, err := pool.get()
+		if err != nil {
 			logDialFailure(err, config)
 
 			// Only return an error if it is the last server so we can try next server
@@ -220,13 +231,8 @@ func (multiples *MultiLDAP) Users(logins []string) (
 			continue
 		}
 
-		defer server.Close()
-
-		if err := server.Bind(); err != nil {
-			return nil, err
-		}
-
-		users, err := server.Users(logins)
+		users, err := bindAndSearch(server, logins)
+		pool.put(server, err)
 		if err != nil {
 			return nil, err
 		}
@@ -236,6 +242,14 @@ func (multiples *MultiLDAP) Users(logins []string) (
 	return result, nil
 }
 
+// bindAndSearch binds the search user and searches the users by login
+func bindAndSearch(server ldap.IServer, logins []string) ([]*login.ExternalUserInfo, error) {
+	if err := server.Bind(); err != nil {
+		return nil, err
+	}
+	return server.Users(logins)
+}
+
 // isSilentError evaluates an error and tells whenever we should fail the LDAP request
 // immediately or if we should continue into other LDAP servers
 func isSilentError(err error) bool {
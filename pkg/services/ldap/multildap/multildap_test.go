@@ -458,6 +458,9 @@ type mockLDAP struct {
 	usersCalledTimes int
 	bindCalledTimes  int
 
+	healthCheckCalledTimes int
+	healthCheckErrReturn   error
+
 	dialErrReturn error
 
 	loginErrReturn error
@@ -498,6 +501,12 @@ func (mock *mockLDAP) Dial() error {
 	return mock.dialErrReturn
 }
 
+// HealthCheck test fn
+func (mock *mockLDAP) HealthCheck() error {
+	mock.healthCheckCalledTimes++
+	return mock.healthCheckErrReturn
+}
+
 // Close test fn
 func (mock *mockLDAP) Close() {
 	mock.closeCalledTimes++
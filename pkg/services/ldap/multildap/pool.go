@@ -0,0 +1,120 @@
+package multildap
+
+import (
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/ldap"
+)
+
+// healthCheckAfter is the time a connection can be idle before it is
+// health-checked when it is taken from the pool.
+const healthCheckAfter = 30 * time.Second
+
+// connectionPool keeps connections to a single LDAP server open for reuse.
+// Every operation binds again before searching, so a connection can be reused
+// regardless of which user was bound last.
+type connectionPool struct {
+	config *ldap.ServerConfig
+	cfg    *ldap.Config
+	log    log.Logger
+
+	mu     sync.Mutex
+	idle   []*idleConnection
+	closed bool
+}
+
+type idleConnection struct {
+	server ldap.IServer
+	since  time.Time
+}
+
+func newConnectionPool(config *ldap.ServerConfig, cfg *ldap.Config) *connectionPool {
+	return &connectionPool{config: config, cfg: cfg, log: log.New("ldap")}
+}
+
+// get returns an idle connection that is still usable, or dials a new one
+func (pool *connectionPool) get() (ldap.IServer, error) {
+	for {
+		conn := pool.pop()
+		if conn == nil {
+			break
+		}
+
+		idleFor := time.Since(conn.since)
+		if idleFor > pool.idleTimeout() {
+			conn.server.Close()
+			continue
+		}
+
+		if idleFor > healthCheckAfter {
+			if err := conn.server.HealthCheck(); err != nil {
+				pool.log.Debug("Closing unhealthy LDAP connection", "host", pool.config.Host, "port", pool.config.Port, "error", err)
+				conn.server.Close()
+				continue
+			}
+		}
+
+		return conn.server, nil
+	}
+
+	server := newLDAP(pool.config, pool.cfg)
+	if err := server.Dial(); err != nil {
+		return nil, err
+	}
+	return server, nil
+}
+
+// put returns a connection to the pool after it was used. Connections are
+// closed when the operation failed with an error that may have left them
+// unusable, or when the pool is full.
+func (pool *connectionPool) put(server ldap.IServer, err error) {
+	if err != nil && !isSilentError(err) {
+		server.Close()
+		return
+	}
+
+	pool.mu.Lock()
+	if pool.closed || len(pool.idle) >= pool.config.MaxIdleConnections {
+		pool.mu.Unlock()
+		server.Close()
+		return
+	}
+	pool.idle = append(pool.idle, &idleConnection{server: server, since: time.Now()})
+	pool.mu.Unlock()
+}
+
+func (pool *connectionPool) pop() *idleConnection {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	if len(pool.idle) == 0 {
+		return nil
+	}
+	// use the most recently returned connection, so the others can time out
+	conn := pool.idle[len(pool.idle)-1]
+	pool.idle = pool.idle[:len(pool.idle)-1]
+	return conn
+}
+
+func (pool *connectionPool) idleTimeout() time.Duration {
+	if pool.config.IdleTimeout > 0 {
+		return time.Duration(pool.config.IdleTimeout) * time.Second
+	}
+	return ldap.DefaultIdleTimeout * time.Second
+}
+
+// close closes the idle connections, connections in use are closed when they
+// are returned.
+func (pool *connectionPool) close() {
+	pool.mu.Lock()
+	idle := pool.idle
+	pool.idle = nil
+	pool.closed = true
+	pool.mu.Unlock()
+
+	for _, conn := range idle {
+		conn.server.Close()
+	}
+}
@@ -0,0 +1,114 @@
+package multildap
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/services/ldap"
+)
+
+func TestConnectionPool(t *testing.T) {
+	t.Run("Should reuse returned connections", func(t *testing.T) {
+		mock := setup()
+		defer teardown()
+
+		pool := newConnectionPool(&ldap.ServerConfig{MaxIdleConnections: 1}, &ldap.Config{})
+		server, err := pool.get()
+		require.NoError(t, err)
+		pool.put(server, nil)
+
+		_, err = pool.get()
+		require.NoError(t, err)
+		assert.Equal(t, 1, mock.dialCalledTimes)
+		assert.Equal(t, 0, mock.closeCalledTimes)
+	})
+
+	t.Run("Should close connections when pooling is disabled", func(t *testing.T) {
+		mock := setup()
+		defer teardown()
+
+		pool := newConnectionPool(&ldap.ServerConfig{}, &ldap.Config{})
+		server, err := pool.get()
+		require.NoError(t, err)
+		pool.put(server, nil)
+
+		assert.Equal(t, 1, mock.closeCalledTimes)
+	})
+
+	t.Run("Should close connections after an error", func(t *testing.T) {
+		mock := setup()
+		defer teardown()
+
+		pool := newConnectionPool(&ldap.ServerConfig{MaxIdleConnections: 1}, &ldap.Config{})
+		server, err := pool.get()
+		require.NoError(t, err)
+		pool.put(server, errors.New("connection reset"))
+
+		assert.Equal(t, 1, mock.closeCalledTimes)
+		assert.Empty(t, pool.idle)
+	})
+
+	t.Run("Should keep connections after invalid credentials", func(t *testing.T) {
+		mock := setup()
+		defer teardown()
+
+		pool := newConnectionPool(&ldap.ServerConfig{MaxIdleConnections: 1}, &ldap.Config{})
+		server, err := pool.get()
+		require.NoError(t, err)
+		pool.put(server, ldap.ErrInvalidCredentials)
+
+		assert.Equal(t, 0, mock.closeCalledTimes)
+		assert.Len(t, pool.idle, 1)
+	})
+
+	t.Run("Should health check connections idle for a while", func(t *testing.T) {
+		mock := setup()
+		defer teardown()
+
+		pool := newConnectionPool(&ldap.ServerConfig{MaxIdleConnections: 1}, &ldap.Config{})
+		pool.idle = []*idleConnection{{server: mock, since: time.Now().Add(-time.Minute)}}
+		mock.healthCheckErrReturn = errors.New("connection closed")
+
+		_, err := pool.get()
+		require.NoError(t, err)
+		assert.Equal(t, 1, mock.healthCheckCalledTimes)
+		assert.Equal(t, 1, mock.closeCalledTimes)
+		assert.Equal(t, 1, mock.dialCalledTimes)
+	})
+
+	t.Run("Should close connections past the idle timeout", func(t *testing.T) {
+		mock := setup()
+		defer teardown()
+
+		pool := newConnectionPool(&ldap.ServerConfig{MaxIdleConnections: 1, IdleTimeout: 60}, &ldap.Config{})
+		pool.idle = []*idleConnection{{server: mock, since: time.Now().Add(-2 * time.Minute)}}
+
+		_, err := pool.get()
+		require.NoError(t, err)
+		assert.Equal(t, 0, mock.healthCheckCalledTimes)
+		assert.Equal(t, 1, mock.closeCalledTimes)
+		assert.Equal(t, 1, mock.dialCalledTimes)
+	})
+
+	t.Run("Should close idle connections on close", func(t *testing.T) {
+		mock := setup()
+		defer teardown()
+
+		pool := newConnectionPool(&ldap.ServerConfig{MaxIdleConnections: 2}, &ldap.Config{})
+		first, err := pool.get()
+		require.NoError(t, err)
+		second, err := pool.get()
+		require.NoError(t, err)
+		pool.put(first, nil)
+
+		pool.close()
+		assert.Equal(t, 1, mock.closeCalledTimes)
+
+		pool.put(second, nil)
+		assert.Equal(t, 2, mock.closeCalledTimes)
+	})
+}
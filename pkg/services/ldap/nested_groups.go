@@ -0,0 +1,113 @@
+package ldap
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/go-ldap/ldap/v3"
+)
+
+// matchingRuleInChain is the LDAP_MATCHING_RULE_IN_CHAIN OID of Active Directory.
+// It walks the chain of ancestry of an attribute, so a filter like
+// (member:1.2.840.113556.1.4.1941:=<dn>) matches all groups <dn> is a member of,
+// directly or through other groups.
+const matchingRuleInChain = "1.2.840.113556.1.4.1941"
+
+// resolveNestedGroups adds the groups the user is a member of through other
+// groups to the groups the user is a direct member of
+func (server *Server) resolveNestedGroups(entry *ldap.Entry, memberOf []string) ([]string, error) {
+	switch server.Config.NestedGroups {
+	case NestedGroupsInChain:
+		filter := fmt.Sprintf("(member:%s:=%s)", matchingRuleInChain, ldap.EscapeFilter(entry.DN))
+		groups, err := server.searchGroups(filter)
+		if err != nil {
+			return nil, err
+		}
+		return appendGroups(append([]string{}, memberOf...), newGroupSet(memberOf), groups), nil
+	case NestedGroupsRecursive:
+		return server.recursiveGroups(memberOf)
+	default:
+		return memberOf, nil
+	}
+}
+
+// recursiveGroups searches the parent groups of each group, level by level,
+// until no new groups are found or the max depth is reached. Groups that were
+// already found are not searched again, which protects against cycles.
+func (server *Server) recursiveGroups(memberOf []string) ([]string, error) {
+	filter := server.Config.NestedGroupSearchFilter
+	if filter == "" {
+		filter = DefaultNestedGroupSearchFilter
+	}
+	maxDepth := server.Config.NestedGroupsMaxDepth
+	if maxDepth == 0 {
+		maxDepth = DefaultNestedGroupsMaxDepth
+	}
+
+	seen := newGroupSet(memberOf)
+	result := append([]string{}, memberOf...)
+	current := memberOf
+	for depth := 0; depth < maxDepth && len(current) > 0; depth++ {
+		var next []string
+		for _, group := range current {
+			parents, err := server.searchGroups(strings.ReplaceAll(filter, "%s", ldap.EscapeFilter(group)))
+			if err != nil {
+				return nil, err
+			}
+			next = appendGroups(next, seen, parents)
+		}
+		result = append(result, next...)
+		current = next
+	}
+
+	if len(current) > 0 {
+		server.log.Warn("Stopped resolving nested LDAP groups at max depth", "maxDepth", maxDepth, "groups", current)
+	}
+
+	return result, nil
+}
+
+// searchGroups returns the DNs of the groups matching the filter
+func (server *Server) searchGroups(filter string) ([]string, error) {
+	var groups []string
+	for _, base := range server.groupSearchBaseDNs() {
+		result, err := server.Connection.Search(&ldap.SearchRequest{
+			BaseDN:       base,
+			Scope:        ldap.ScopeWholeSubtree,
+			DerefAliases: ldap.NeverDerefAliases,
+			Attributes:   []string{"dn"},
+			Filter:       filter,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		for _, entry := range result.Entries {
+			groups = append(groups, entry.DN)
+		}
+	}
+	return groups, nil
+}
+
+type groupSet map[string]struct{}
+
+func newGroupSet(groups []string) groupSet {
+	set := make(groupSet, len(groups))
+	for _, group := range groups {
+		set[strings.ToLower(group)] = struct{}{}
+	}
+	return set
+}
+
+// appendGroups appends the groups that are not in seen yet, and adds them to seen
+func appendGroups(groups []string, seen groupSet, found []string) []string {
+	for _, group := range found {
+		key := strings.ToLower(group)
+		if _, ok := seen[key]; ok {
+			continue
+		}
+		seen[key] = struct{}{}
+		groups = append(groups, group)
+	}
+	return groups
+}
@@ -0,0 +1,106 @@
+package ldap
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/go-ldap/ldap/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+)
+
+func newNestedGroupsServer(config *ServerConfig, fn searchFunc) (*Server, *MockConnection) {
+	connection := &MockConnection{}
+	connection.setSearchFunc(fn)
+	config.GroupSearchBaseDNs = []string{"ou=groups,dc=grafana,dc=org"}
+	return &Server{Config: config, Connection: connection, log: log.New("test-logger")}, connection
+}
+
+func groupsResult(dns ...string) *ldap.SearchResult {
+	result := &ldap.SearchResult{}
+	for _, dn := range dns {
+		result.Entries = append(result.Entries, &ldap.Entry{DN: dn})
+	}
+	return result
+}
+
+func TestServer_resolveNestedGroups(t *testing.T) {
+	entry := &ldap.Entry{DN: "cn=jane,ou=users,dc=grafana,dc=org"}
+
+	t.Run("disabled", func(t *testing.T) {
+		server, connection := newNestedGroupsServer(&ServerConfig{}, nil)
+
+		groups, err := server.resolveNestedGroups(entry, []string{"cn=admins"})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"cn=admins"}, groups)
+		assert.False(t, connection.SearchCalled)
+	})
+
+	t.Run("in chain", func(t *testing.T) {
+		var filter string
+		server, _ := newNestedGroupsServer(&ServerConfig{NestedGroups: NestedGroupsInChain}, func(request *ldap.SearchRequest) (*ldap.SearchResult, error) {
+			filter = request.Filter
+			return groupsResult("CN=Admins", "cn=editors", "cn=viewers"), nil
+		})
+
+		groups, err := server.resolveNestedGroups(entry, []string{"cn=admins"})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"cn=admins", "cn=editors", "cn=viewers"}, groups)
+		assert.Equal(t, "(member:1.2.840.113556.1.4.1941:=cn=jane,ou=users,dc=grafana,dc=org)", filter)
+	})
+
+	// admins -> editors -> viewers -> admins
+	parents := map[string][]string{
+		"cn=admins":  {"cn=editors"},
+		"cn=editors": {"cn=viewers"},
+		"cn=viewers": {"cn=admins"},
+	}
+	recursive := func(searches *int) searchFunc {
+		return func(request *ldap.SearchRequest) (*ldap.SearchResult, error) {
+			*searches++
+			for group, parents := range parents {
+				if request.Filter == fmt.Sprintf("(member=%s)", group) {
+					return groupsResult(parents...), nil
+				}
+			}
+			return groupsResult(), nil
+		}
+	}
+
+	t.Run("recursive with cycle", func(t *testing.T) {
+		searches := 0
+		server, _ := newNestedGroupsServer(&ServerConfig{NestedGroups: NestedGroupsRecursive}, recursive(&searches))
+
+		groups, err := server.resolveNestedGroups(entry, []string{"cn=admins"})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"cn=admins", "cn=editors", "cn=viewers"}, groups)
+		assert.Equal(t, 3, searches)
+	})
+
+	t.Run("recursive stops at max depth", func(t *testing.T) {
+		searches := 0
+		server, _ := newNestedGroupsServer(&ServerConfig{NestedGroups: NestedGroupsRecursive, NestedGroupsMaxDepth: 1}, recursive(&searches))
+
+		groups, err := server.resolveNestedGroups(entry, []string{"cn=admins"})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"cn=admins", "cn=editors"}, groups)
+		assert.Equal(t, 1, searches)
+	})
+
+	t.Run("recursive with custom filter", func(t *testing.T) {
+		var filter string
+		server, _ := newNestedGroupsServer(&ServerConfig{
+			NestedGroups:            NestedGroupsRecursive,
+			NestedGroupSearchFilter: "(&(objectClass=groupOfNames)(member=%s))",
+		}, func(request *ldap.SearchRequest) (*ldap.SearchResult, error) {
+			filter = request.Filter
+			return groupsResult(), nil
+		})
+
+		_, err := server.resolveNestedGroups(entry, []string{"cn=a(b)"})
+		require.NoError(t, err)
+		assert.Equal(t, `(&(objectClass=groupOfNames)(member=cn=a\28b\29))`, filter)
+	})
+}
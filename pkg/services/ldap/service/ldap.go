@@ -132,7 +132,7 @@ func (s *LDAPImpl) Reload(ctx context.Context, settings models.SSOSettings) erro
 
 	s.cfg = cfg
 	s.ldapCfg = ldapCfg
-	s.client = multildap.New(s.ldapCfg.Servers, s.cfg)
+	s.setClient(multildap.New(s.ldapCfg.Servers, s.cfg))
 
 	return nil
 }
@@ -180,6 +180,10 @@ func (s *LDAPImpl) Validate(ctx context.Context, settings models.SSOSettings, ol
 			}
 		}
 
+		if err := ldap.ValidateNestedGroups(server); err != nil {
+			return fmt.Errorf("invalid nested groups configured for server with index %d: %w", i, err)
+		}
+
 		for _, groupMap := range server.Groups {
 			if groupMap.OrgRole == "" && groupMap.IsGrafanaAdmin == nil {
 				return fmt.Errorf("organization role or Grafana admin status is required in group mappings for server with index %d", i)
@@ -209,11 +213,19 @@ func (s *LDAPImpl) ReloadConfig() error {
 	}
 
 	s.ldapCfg = config
-	s.client = client
+	s.setClient(client)
 
 	return nil
 }
 
+// setClient replaces the client and closes the pooled connections of the previous one.
+func (s *LDAPImpl) setClient(client multildap.IMultiLDAP) {
+	if s.client != nil {
+		s.client.Close()
+	}
+	s.client = client
+}
+
 func (s *LDAPImpl) Client() multildap.IMultiLDAP {
 	return s.client
 }
@@ -16,6 +16,21 @@ import (
 
 const DefaultTimeout = 10
 
+const (
+	// NestedGroupsInChain resolves nested groups with the LDAP_MATCHING_RULE_IN_CHAIN
+	// matching rule of Active Directory, in a single search.
+	NestedGroupsInChain = "in_chain"
+	// NestedGroupsRecursive resolves nested groups by searching the parent
+	// groups of each group, for servers without a transitive matching rule.
+	NestedGroupsRecursive = "recursive"
+
+	DefaultNestedGroupSearchFilter = "(member=%s)"
+	DefaultNestedGroupsMaxDepth    = 10
+
+	// DefaultIdleTimeout is the time in seconds a pooled connection can stay idle.
+	DefaultIdleTimeout = 300
+)
+
 // Config holds parameters from the .ini config file
 type Config struct {
 	Enabled           bool
@@ -62,6 +77,14 @@ type ServerConfig struct {
 	GroupSearchFilterUserAttribute string   `toml:"group_search_filter_user_attribute" json:"group_search_filter_user_attribute"`
 	GroupSearchBaseDNs             []string `toml:"group_search_base_dns" json:"group_search_base_dns"`
 
+	NestedGroups            string `toml:"nested_groups" json:"nested_groups"`
+	NestedGroupSearchFilter string `toml:"nested_group_search_filter" json:"nested_group_search_filter"`
+	NestedGroupsMaxDepth    int    `toml:"nested_groups_max_depth" json:"nested_groups_max_depth"`
+
+	// MaxIdleConnections is the number of connections kept open for reuse, 0 disables pooling
+	MaxIdleConnections int `toml:"max_idle_connections" json:"max_idle_connections"`
+	IdleTimeout        int `toml:"idle_timeout" json:"idle_timeout"`
+
 	Groups []*GroupToOrgRole `toml:"group_mappings" json:"group_mappings"`
 }
 
@@ -193,6 +216,10 @@ func readConfig(configFile string) (*ServersConfig, error) {
 			}
 		}
 
+		if err := ValidateNestedGroups(server); err != nil {
+			return nil, err
+		}
+
 		for _, groupMap := range server.Groups {
 			if groupMap.OrgRole == "" && groupMap.IsGrafanaAdmin == nil {
 				return nil, fmt.Errorf("LDAP group mapping: organization role or grafana admin status is required")
@@ -212,6 +239,20 @@ func readConfig(configFile string) (*ServersConfig, error) {
 	return result, nil
 }
 
+// ValidateNestedGroups checks the nested group resolution of a server.
+func ValidateNestedGroups(server *ServerConfig) error {
+	switch server.NestedGroups {
+	case "", NestedGroupsInChain, NestedGroupsRecursive:
+	default:
+		return fmt.Errorf("LDAP nested_groups must be %q or %q, got %q", NestedGroupsInChain, NestedGroupsRecursive, server.NestedGroups)
+	}
+
+	if server.NestedGroupsMaxDepth < 0 {
+		return fmt.Errorf("LDAP nested_groups_max_depth must not be negative")
+	}
+	return nil
+}
+
 func assertNotEmptyCfg(val any, propName string) error {
 	switch v := val.(type) {
 	case string:
@@ -51,6 +51,7 @@ import (
 	"github.com/grafana/grafana/pkg/services/live/model"
 	"github.com/grafana/grafana/pkg/services/live/orgchannel"
 	"github.com/grafana/grafana/pkg/services/live/pipeline"
+	"github.com/grafana/grafana/pkg/services/live/pushtoken"
 	"github.com/grafana/grafana/pkg/services/live/pushws"
 	"github.com/grafana/grafana/pkg/services/live/runstream"
 	"github.com/grafana/grafana/pkg/services/live/survey"
@@ -60,6 +61,7 @@ import (
 	"github.com/grafana/grafana/pkg/services/query"
 	"github.com/grafana/grafana/pkg/services/secrets"
 	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/tsdb/grafanads"
 	"github.com/grafana/grafana/pkg/util"
 	"github.com/grafana/grafana/pkg/web"
 )
@@ -83,7 +85,7 @@ func ProvideService(plugCtxProvider *plugincontext.Provider, cfg *setting.Cfg, r
 	dataSourceCache datasources.CacheService, sqlStore db.DB, secretsService secrets.Service,
 	usageStatsService usagestats.Service, queryDataService query.Service, toggles featuremgmt.FeatureToggles,
 	accessControl accesscontrol.AccessControl, dashboardService dashboards.DashboardService,
-	orgService org.Service, configProvider apiserver.RestConfigProvider) (*GrafanaLive, error) {
+	orgService org.Service, configProvider apiserver.RestConfigProvider, grafanaDS *grafanads.Service) (*GrafanaLive, error) {
 	g := &GrafanaLive{
 		Cfg:                   cfg,
 		Features:              toggles,
@@ -161,21 +163,28 @@ func ProvideService(plugCtxProvider *plugincontext.Provider, cfg *setting.Cfg, r
 		}
 	}
 
+	history := managedstream.HistoryConfig{
+		MaxRows: cfg.LiveHistoryMaxRows,
+		MaxAge:  cfg.LiveHistoryMaxAge,
+	}
 	if redisClient != nil {
 		managedStreamRunner = managedstream.NewRunner(
 			g.Publish,
 			channelLocalPublisher,
-			managedstream.NewRedisFrameCache(redisClient, g.keyPrefix),
+			managedstream.NewRedisFrameCache(redisClient, g.keyPrefix, history),
 		)
 	} else {
 		managedStreamRunner = managedstream.NewRunner(
 			g.Publish,
 			channelLocalPublisher,
-			managedstream.NewMemoryFrameCache(),
+			managedstream.NewMemoryFrameCache(history),
 		)
 	}
 
 	g.ManagedStreamRunner = managedStreamRunner
+	if grafanaDS != nil {
+		grafanaDS.SetLiveHistory(managedStreamRunner)
+	}
 
 	g.contextGetter = liveplugin.NewContextGetter(g.PluginContextProvider, g.DataSourceCache)
 	pipelinedChannelLocalPublisher := liveplugin.NewChannelLocalPublisher(node, g.Pipeline)
@@ -191,6 +200,7 @@ func ProvideService(plugCtxProvider *plugincontext.Provider, cfg *setting.Cfg, r
 		AccessControl:    accessControl,
 	}
 	g.storage = database.NewStorage(g.SQLStore, g.CacheService)
+	g.PublishTokens = pushtoken.NewService(g.SQLStore)
 	g.GrafanaScope.Dashboards = dash
 	g.GrafanaScope.Features["dashboard"] = dash
 	g.GrafanaScope.Features["broadcast"] = features.NewBroadcastRunner(g.storage)
@@ -412,16 +422,61 @@ func ProvideService(plugCtxProvider *plugincontext.Provider, cfg *setting.Cfg, r
 		group.Get("/ws", g.websocketHandler)
 	}, middleware.ReqSignedIn, requestmeta.SetSLOGroup(requestmeta.SLOGroupNone))
 
+	g.tokenPushWebsocketHandler = func(ctx *contextmodel.ReqContext) {
+		newCtx, ok := g.authenticatePublishToken(ctx)
+		if !ok {
+			return
+		}
+		newCtx = livecontext.SetContextStreamID(newCtx, web.Params(ctx.Req)[":streamId"])
+		r := ctx.Req.WithContext(newCtx)
+		pushWSHandler.ServeHTTP(ctx.Resp, r)
+	}
+
+	g.tokenPushPipelineWebsocketHandler = func(ctx *contextmodel.ReqContext) {
+		newCtx, ok := g.authenticatePublishToken(ctx)
+		if !ok {
+			return
+		}
+		newCtx = livecontext.SetContextChannelID(newCtx, web.Params(ctx.Req)["*"])
+		r := ctx.Req.WithContext(newCtx)
+		pushPipelineWSHandler.ServeHTTP(ctx.Resp, r)
+	}
+
 	g.RouteRegister.Group("/api/live", func(group routing.RouteRegister) {
 		group.Get("/push/:streamId", g.pushWebsocketHandler)
 		group.Get("/pipeline/push/*", g.pushPipelineWebsocketHandler)
 	}, middleware.ReqOrgAdmin, requestmeta.SetSLOGroup(requestmeta.SLOGroupNone))
 
+	// Pushing with a publish token does not require a signed in user,
+	// the token is checked by the handlers.
+	g.RouteRegister.Group("/api/live/token", func(group routing.RouteRegister) {
+		group.Get("/push/:streamId", g.tokenPushWebsocketHandler)
+		group.Get("/pipeline/push/*", g.tokenPushPipelineWebsocketHandler)
+	}, requestmeta.SetSLOGroup(requestmeta.SLOGroupNone))
+
+	g.PublishTokens.RegisterAPIEndpoints(g.RouteRegister)
+
 	g.registerUsageMetrics()
 
 	return g, nil
 }
 
+// authenticatePublishToken checks the publish token of a push request and writes
+// the error status when it is not valid.
+func (g *GrafanaLive) authenticatePublishToken(ctx *contextmodel.ReqContext) (context.Context, bool) {
+	publisher, err := g.PublishTokens.Authenticate(ctx.Req.Context(), ctx.Req.Header.Get(pushtoken.HeaderName))
+	if err != nil {
+		if errors.Is(err, pushtoken.ErrInvalidToken) {
+			ctx.Resp.WriteHeader(http.StatusUnauthorized)
+			return nil, false
+		}
+		logger.Error("Error authenticating publish token", "error", err)
+		ctx.Resp.WriteHeader(http.StatusInternalServerError)
+		return nil, false
+	}
+	return livecontext.SetContextPublisher(ctx.Req.Context(), publisher), true
+}
+
 func setupRedisLiveEngine(g *GrafanaLive, node *centrifuge.Node) error {
 	redisAddress := g.Cfg.LiveHAEngineAddress
 	redisPassword := g.Cfg.LiveHAEnginePassword
@@ -491,6 +546,9 @@ type GrafanaLive struct {
 	pushWebsocketHandler         interface{}
 	pushPipelineWebsocketHandler interface{}
 
+	tokenPushWebsocketHandler         interface{}
+	tokenPushPipelineWebsocketHandler interface{}
+
 	// Full channel handler
 	channels   map[string]model.ChannelHandler
 	channelsMu sync.RWMutex
@@ -500,6 +558,7 @@ type GrafanaLive struct {
 
 	ManagedStreamRunner *managedstream.Runner
 	Pipeline            *pipeline.Pipeline
+	PublishTokens       *pushtoken.Service
 	pipelineStorage     pipeline.Storage
 
 	contextGetter    *liveplugin.ContextGetter
@@ -561,7 +620,11 @@ func (g *GrafanaLive) Run(ctx context.Context) error {
 		})
 	}
 
-	return eGroup.Wait()
+	err := eGroup.Wait()
+	if g.Pipeline != nil {
+		g.Pipeline.Close()
+	}
+	return err
 }
 
 func getCheckOriginFunc(appURL *url.URL, originPatterns []string, originGlobs []glob.Glob) func(r *http.Request) bool {
@@ -1270,6 +1333,7 @@ func (g *GrafanaLive) HandlePipelineConvertTestHTTP(c *contextmodel.ReqContext)
 		Storage:              storage,
 		ChannelHandlerGetter: g,
 	}
+	defer builder.Close()
 	channelRuleGetter := pipeline.NewCacheSegmentedTree(builder)
 	pipe, err := pipeline.New(channelRuleGetter)
 	if err != nil {
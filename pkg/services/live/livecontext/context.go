@@ -4,6 +4,7 @@ import (
 	"context"
 
 	"github.com/grafana/grafana/pkg/apimachinery/identity"
+	"github.com/grafana/grafana/pkg/services/live/pushtoken"
 )
 
 type signedUserContextKeyType int
@@ -52,3 +53,19 @@ func GetContextChannelID(ctx context.Context) (string, bool) {
 	}
 	return "", false
 }
+
+type publisherContextKey struct{}
+
+// SetContextPublisher stores the publish token a push request was authenticated with.
+func SetContextPublisher(ctx context.Context, publisher *pushtoken.Publisher) context.Context {
+	ctx = context.WithValue(ctx, publisherContextKey{}, publisher)
+	return ctx
+}
+
+func GetContextPublisher(ctx context.Context) (*pushtoken.Publisher, bool) {
+	if val := ctx.Value(publisherContextKey{}); val != nil {
+		publisher, ok := val.(*pushtoken.Publisher)
+		return publisher, ok
+	}
+	return nil, false
+}
@@ -15,4 +15,6 @@ type FrameCache interface {
 	GetFrame(ctx context.Context, orgID int64, channel string) (json.RawMessage, bool, error)
 	// Update updates frame cache and returns true if schema changed.
 	Update(ctx context.Context, orgID int64, channel string, frameJson data.FrameJSONCache) (bool, error)
+	// GetHistory returns the rows kept for a channel in org when history is enabled.
+	GetHistory(ctx context.Context, orgID int64, channel string) (*data.Frame, bool, error)
 }
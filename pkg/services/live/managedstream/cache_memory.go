@@ -12,16 +12,20 @@ import (
 
 // MemoryFrameCache ...
 type MemoryFrameCache struct {
-	mu     sync.RWMutex
-	frames map[int64]map[string]data.FrameJSONCache
-	log    log.Logger
+	mu      sync.RWMutex
+	frames  map[int64]map[string]data.FrameJSONCache
+	history map[int64]map[string]*historyRing
+	config  HistoryConfig
+	log     log.Logger
 }
 
 // NewMemoryFrameCache ...
-func NewMemoryFrameCache() *MemoryFrameCache {
+func NewMemoryFrameCache(history HistoryConfig) *MemoryFrameCache {
 	return &MemoryFrameCache{
-		frames: map[int64]map[string]data.FrameJSONCache{},
-		log:    log.New("live.memoryframecache"),
+		frames:  map[int64]map[string]data.FrameJSONCache{},
+		history: map[int64]map[string]*historyRing{},
+		config:  history,
+		log:     log.New("live.memoryframecache"),
 	}
 }
 
@@ -53,14 +57,32 @@ func (c *MemoryFrameCache) GetFrame(ctx context.Context, orgID int64, channel st
 }
 
 func (c *MemoryFrameCache) Update(ctx context.Context, orgID int64, channel string, jsonFrame data.FrameJSONCache) (bool, error) {
+	// Decode outside the lock, history is only touched under the channel lock.
+	var historyFrame *data.Frame
+	if c.config.Enabled() {
+		frame, err := decodeHistoryFrame(jsonFrame.Bytes(data.IncludeAll))
+		if err != nil {
+			return false, err
+		}
+		historyFrame = frame
+	}
+
 	c.mu.Lock()
-	defer c.mu.Unlock()
 	if _, ok := c.frames[orgID]; !ok {
 		c.frames[orgID] = map[string]data.FrameJSONCache{}
 	}
 	cachedJsonFrame, exists := c.frames[orgID][channel]
 	schemaUpdated := !exists || !cachedJsonFrame.SameSchema(&jsonFrame)
 	c.frames[orgID][channel] = jsonFrame
+	var ring *historyRing
+	if historyFrame != nil {
+		ring = c.historyRing(orgID, channel)
+	}
+	c.mu.Unlock()
+
+	if ring != nil {
+		ring.push(historyFrame)
+	}
 	c.log.Debug("Cache update",
 		"orgId", orgID,
 		"channel", channel,
@@ -68,3 +90,27 @@ func (c *MemoryFrameCache) Update(ctx context.Context, orgID int64, channel stri
 	)
 	return schemaUpdated, nil
 }
+
+// historyRing returns the history of a channel, creating it if needed. Must be called with c.mu held.
+func (c *MemoryFrameCache) historyRing(orgID int64, channel string) *historyRing {
+	if _, ok := c.history[orgID]; !ok {
+		c.history[orgID] = map[string]*historyRing{}
+	}
+	ring, ok := c.history[orgID][channel]
+	if !ok {
+		ring = newHistoryRing(c.config)
+		c.history[orgID][channel] = ring
+	}
+	return ring
+}
+
+func (c *MemoryFrameCache) GetHistory(_ context.Context, orgID int64, channel string) (*data.Frame, bool, error) {
+	c.mu.RLock()
+	ring, ok := c.history[orgID][channel]
+	c.mu.RUnlock()
+	if !ok {
+		return nil, false, nil
+	}
+	frame, ok := ring.frame()
+	return frame, ok, nil
+}
@@ -3,7 +3,10 @@ package managedstream
 import (
 	"context"
 	"encoding/json"
+	"fmt"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/grafana/grafana-plugin-sdk-go/data"
 	"github.com/stretchr/testify/require"
@@ -62,8 +65,91 @@ func testFrameCache(t *testing.T, c FrameCache) {
 	require.NotEqual(t, string(channels["test"]), string(schema))
 }
 
+// testFrameCacheHistory expects the cache to keep 3 rows of history.
+func testFrameCacheHistory(t *testing.T, c FrameCache) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	push := func(values ...float64) {
+		t.Helper()
+		times := make([]time.Time, len(values))
+		for i, v := range values {
+			times[i] = start.Add(time.Duration(v) * time.Second)
+		}
+		frame := data.NewFrame("history",
+			data.NewField("time", nil, times),
+			data.NewField("value", nil, values),
+		)
+		frameJsonCache, err := data.FrameToJSONCache(frame)
+		require.NoError(t, err)
+		_, err = c.Update(context.Background(), 1, "history", frameJsonCache)
+		require.NoError(t, err)
+	}
+
+	_, ok, err := c.GetHistory(context.Background(), 1, "history")
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	push(1)
+	push(2, 3)
+	push(4)
+
+	history, ok, err := c.GetHistory(context.Background(), 1, "history")
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, 3, history.Rows())
+	require.Equal(t, 2.0, history.Fields[1].At(0))
+	require.Equal(t, 4.0, history.Fields[1].At(2))
+
+	// A new schema starts a new history.
+	frame := data.NewFrame("history", data.NewField("time", nil, []time.Time{start}))
+	frameJsonCache, err := data.FrameToJSONCache(frame)
+	require.NoError(t, err)
+	_, err = c.Update(context.Background(), 1, "history", frameJsonCache)
+	require.NoError(t, err)
+
+	history, ok, err = c.GetHistory(context.Background(), 1, "history")
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, 1, history.Rows())
+	require.Len(t, history.Fields, 1)
+}
+
 func TestMemoryFrameCache(t *testing.T) {
-	c := NewMemoryFrameCache()
+	c := NewMemoryFrameCache(HistoryConfig{})
 	require.NotNil(t, c)
 	testFrameCache(t, c)
+
+	_, ok, err := c.GetHistory(context.Background(), 1, "test")
+	require.NoError(t, err)
+	require.False(t, ok)
+}
+
+func TestMemoryFrameCacheHistory(t *testing.T) {
+	c := NewMemoryFrameCache(HistoryConfig{MaxRows: 3})
+	testFrameCacheHistory(t, c)
+}
+
+func TestMemoryFrameCacheHistory_Concurrent(t *testing.T) {
+	c := NewMemoryFrameCache(HistoryConfig{MaxRows: 10})
+	frameJsonCache, err := data.FrameToJSONCache(data.NewFrame("test", data.NewField("value", nil, []int64{1})))
+	require.NoError(t, err)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		channel := fmt.Sprintf("test-%d", i)
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			_, err := c.Update(context.Background(), 1, channel, frameJsonCache)
+			require.NoError(t, err)
+		}()
+		go func() {
+			defer wg.Done()
+			history, ok, err := c.GetHistory(context.Background(), 1, channel)
+			require.NoError(t, err)
+			if ok {
+				require.Equal(t, 1, history.Rows())
+			}
+		}()
+	}
+	wg.Wait()
 }
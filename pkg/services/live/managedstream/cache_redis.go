@@ -19,14 +19,16 @@ type RedisFrameCache struct {
 	redisClient *redis.Client
 	frames      map[int64]map[string]data.FrameJSONCache
 	keyPrefix   string
+	history     HistoryConfig
 }
 
 // NewRedisFrameCache ...
-func NewRedisFrameCache(redisClient *redis.Client, keyPrefix string) *RedisFrameCache {
+func NewRedisFrameCache(redisClient *redis.Client, keyPrefix string, history HistoryConfig) *RedisFrameCache {
 	return &RedisFrameCache{
 		keyPrefix:   keyPrefix,
 		frames:      map[int64]map[string]data.FrameJSONCache{},
 		redisClient: redisClient,
+		history:     history,
 	}
 }
 
@@ -59,6 +61,8 @@ func (c *RedisFrameCache) GetFrame(ctx context.Context, orgID int64, channel str
 
 const (
 	frameCacheTTL = 7 * 24 * time.Hour
+	// maxHistoryEntries limits the pushed frames kept in Redis when history is only limited by age.
+	maxHistoryEntries = 10000
 )
 
 func (c *RedisFrameCache) Update(ctx context.Context, orgID int64, channel string, jsonFrame data.FrameJSONCache) (bool, error) {
@@ -96,17 +100,69 @@ func (c *RedisFrameCache) Update(ctx context.Context, orgID int64, channel strin
 		return false, err
 	}
 
+	schemaUpdated := true
 	if mapReply, ok := reply.(*redis.StringStringMapCmd); ok {
 		result, err := mapReply.Result()
 		if err != nil {
 			return false, err
 		}
-		if len(result) == 0 {
-			return true, nil
+		schemaUpdated = len(result) == 0 || result["schema"] != stringSchema
+	}
+
+	if c.history.Enabled() {
+		if err := c.updateHistory(ctx, key, jsonFrame, schemaUpdated); err != nil {
+			return false, err
+		}
+	}
+	return schemaUpdated, nil
+}
+
+// updateHistory keeps every pushed frame in a list next to the channel key.
+// The rows are merged and trimmed to the history limits when read.
+func (c *RedisFrameCache) updateHistory(ctx context.Context, key string, jsonFrame data.FrameJSONCache, schemaUpdated bool) error {
+	historyKey := key + ".history"
+	maxEntries := int64(maxHistoryEntries)
+	if c.history.MaxRows > 0 {
+		// Every frame has at least one row, so this is enough to hold the last MaxRows rows.
+		maxEntries = min(maxEntries, int64(c.history.MaxRows))
+	}
+
+	pipe := c.redisClient.TxPipeline()
+	defer func() { _ = pipe.Close() }()
+
+	if schemaUpdated {
+		pipe.Del(ctx, historyKey)
+	}
+	pipe.RPush(ctx, historyKey, string(jsonFrame.Bytes(data.IncludeAll)))
+	pipe.LTrim(ctx, historyKey, -maxEntries, -1)
+	pipe.Expire(ctx, historyKey, frameCacheTTL)
+
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+func (c *RedisFrameCache) GetHistory(ctx context.Context, orgID int64, channel string) (*data.Frame, bool, error) {
+	if !c.history.Enabled() {
+		return nil, false, nil
+	}
+	key := c.getCacheKey(orgchannel.PrependOrgID(orgID, channel))
+	entries, err := c.redisClient.LRange(ctx, key+".history", 0, -1).Result()
+	if err != nil {
+		return nil, false, err
+	}
+	if len(entries) == 0 {
+		return nil, false, nil
+	}
+
+	var history *data.Frame
+	for _, entry := range entries {
+		frame, err := decodeHistoryFrame([]byte(entry))
+		if err != nil {
+			return nil, false, err
 		}
-		return result["schema"] != stringSchema, nil
+		history = appendHistory(history, frame, HistoryConfig{})
 	}
-	return true, nil
+	return trimHistory(history, c.history), true, nil
 }
 
 func (c *RedisFrameCache) getCacheKey(channelID string) string {
@@ -35,9 +35,10 @@ func TestIntegrationRedisCacheStorage(t *testing.T) {
 
 	t.Cleanup(redisCleanup(t, redisClient, prefix))
 
-	c := NewRedisFrameCache(redisClient, prefix)
+	c := NewRedisFrameCache(redisClient, prefix, HistoryConfig{MaxRows: 3})
 	require.NotNil(t, c)
 	testFrameCache(t, c)
+	testFrameCacheHistory(t, c)
 
 	keys, err := redisClient.Keys(redisClient.Context(), "*").Result()
 	if err != nil {
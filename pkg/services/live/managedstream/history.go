@@ -0,0 +1,143 @@
+package managedstream
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+// HistoryConfig limits the history kept for each managed stream channel.
+// History is disabled when both limits are zero.
+type HistoryConfig struct {
+	// MaxRows is the maximum number of rows kept per channel.
+	MaxRows int
+	// MaxAge drops rows older than the latest row by more than this duration.
+	MaxAge time.Duration
+}
+
+// maxHistoryRows limits the rows kept per channel when history is only limited by age,
+// as rows without a time field or value are never dropped for their age.
+const maxHistoryRows = 10000
+
+// Enabled returns true when history should be kept.
+func (c HistoryConfig) Enabled() bool {
+	return c.MaxRows > 0 || c.MaxAge > 0
+}
+
+// rowLimit returns the maximum number of rows kept per channel.
+func (c HistoryConfig) rowLimit() int {
+	if c.MaxRows > 0 {
+		return min(c.MaxRows, maxHistoryRows)
+	}
+	return maxHistoryRows
+}
+
+// appendHistory appends the rows of frame to history and trims the result to the
+// configured limits. History starts again from frame when the schema changes.
+func appendHistory(history *data.Frame, frame *data.Frame, cfg HistoryConfig) *data.Frame {
+	if history == nil || !sameFields(history, frame) {
+		history = frame.EmptyCopy()
+	}
+	for i := 0; i < frame.Rows(); i++ {
+		history.AppendRow(frame.RowCopy(i)...)
+	}
+	return trimHistory(history, cfg)
+}
+
+// trimHistory removes the oldest rows above the configured limits.
+func trimHistory(history *data.Frame, cfg HistoryConfig) *data.Frame {
+	start := 0
+	if history.Rows() > cfg.rowLimit() {
+		start = history.Rows() - cfg.rowLimit()
+	}
+
+	if timeIdx := historyTimeIndex(history); cfg.MaxAge > 0 && timeIdx >= 0 {
+		var latest time.Time
+		for i := 0; i < history.Rows(); i++ {
+			if t, ok := historyTimeAt(history.Fields[timeIdx], i); ok && t.After(latest) {
+				latest = t
+			}
+		}
+		cutoff := latest.Add(-cfg.MaxAge)
+		for start < history.Rows() {
+			t, ok := historyTimeAt(history.Fields[timeIdx], start)
+			if ok && !t.Before(cutoff) {
+				break
+			}
+			start++
+		}
+	}
+
+	if start == 0 {
+		return history
+	}
+	return sliceFrame(history, start, history.Rows())
+}
+
+// filterHistory returns the rows of history within the time range.
+func filterHistory(history *data.Frame, tr backend.TimeRange) (*data.Frame, error) {
+	timeIdx := historyTimeIndex(history)
+	if timeIdx < 0 {
+		return history, nil
+	}
+	return history.FilterRowsByField(timeIdx, func(v any) (bool, error) {
+		t, ok := historyTime(v)
+		return ok && !t.Before(tr.From) && !t.After(tr.To), nil
+	})
+}
+
+// decodeHistoryFrame reads a frame stored with data.IncludeAll.
+func decodeHistoryFrame(raw []byte) (*data.Frame, error) {
+	frame := &data.Frame{}
+	if err := json.Unmarshal(raw, frame); err != nil {
+		return nil, err
+	}
+	return frame, nil
+}
+
+func sliceFrame(frame *data.Frame, start, end int) *data.Frame {
+	out := frame.EmptyCopy()
+	for i := start; i < end; i++ {
+		out.AppendRow(frame.RowCopy(i)...)
+	}
+	return out
+}
+
+func sameFields(a, b *data.Frame) bool {
+	if len(a.Fields) != len(b.Fields) {
+		return false
+	}
+	for i := range a.Fields {
+		if a.Fields[i].Name != b.Fields[i].Name || a.Fields[i].Type() != b.Fields[i].Type() {
+			return false
+		}
+	}
+	return true
+}
+
+func historyTimeIndex(frame *data.Frame) int {
+	for i, f := range frame.Fields {
+		if f.Type() == data.FieldTypeTime || f.Type() == data.FieldTypeNullableTime {
+			return i
+		}
+	}
+	return -1
+}
+
+func historyTimeAt(f *data.Field, idx int) (time.Time, bool) {
+	return historyTime(f.At(idx))
+}
+
+func historyTime(v any) (time.Time, bool) {
+	switch t := v.(type) {
+	case time.Time:
+		return t, true
+	case *time.Time:
+		if t != nil {
+			return *t, true
+		}
+	}
+	return time.Time{}, false
+}
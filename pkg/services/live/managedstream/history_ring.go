@@ -0,0 +1,109 @@
+package managedstream
+
+import (
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+// historyRing keeps the history of a single channel. Rows are stored in a ring
+// buffer so a push only costs the rows it adds and drops, and each channel has
+// its own lock so pushes to different channels do not wait on each other.
+type historyRing struct {
+	mu      sync.Mutex
+	cfg     HistoryConfig
+	schema  *data.Frame
+	timeIdx int
+	latest  time.Time
+	rows    [][]any
+	head    int
+	size    int
+}
+
+func newHistoryRing(cfg HistoryConfig) *historyRing {
+	return &historyRing{cfg: cfg}
+}
+
+// push appends the rows of frame. History starts again from frame when the
+// schema changes.
+func (r *historyRing) push(frame *data.Frame) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.schema == nil || !sameFields(r.schema, frame) {
+		r.schema = frame.EmptyCopy()
+		r.timeIdx = historyTimeIndex(frame)
+		r.latest = time.Time{}
+		r.rows = nil
+		r.head, r.size = 0, 0
+	}
+	for i := 0; i < frame.Rows(); i++ {
+		row := frame.RowCopy(i)
+		if r.timeIdx >= 0 {
+			if t, ok := historyTime(row[r.timeIdx]); ok && t.After(r.latest) {
+				r.latest = t
+			}
+		}
+		r.append(row)
+	}
+	r.trimAge()
+}
+
+func (r *historyRing) append(row []any) {
+	if r.size == r.cfg.rowLimit() {
+		// Full, overwrite the oldest row.
+		r.rows[r.head] = row
+		r.head = (r.head + 1) % len(r.rows)
+		return
+	}
+	if r.size == len(r.rows) {
+		r.grow()
+	}
+	r.rows[(r.head+r.size)%len(r.rows)] = row
+	r.size++
+}
+
+// grow doubles the capacity up to the row limit, moving the rows to the start.
+func (r *historyRing) grow() {
+	capacity := min(max(2*len(r.rows), 16), r.cfg.rowLimit())
+	rows := make([][]any, capacity)
+	for i := 0; i < r.size; i++ {
+		rows[i] = r.rows[(r.head+i)%len(r.rows)]
+	}
+	r.rows = rows
+	r.head = 0
+}
+
+// trimAge drops the oldest rows that are older than the latest row by more than MaxAge.
+func (r *historyRing) trimAge() {
+	if r.cfg.MaxAge <= 0 || r.timeIdx < 0 {
+		return
+	}
+	cutoff := r.latest.Add(-r.cfg.MaxAge)
+	for r.size > 0 {
+		t, ok := historyTime(r.rows[r.head][r.timeIdx])
+		if ok && !t.Before(cutoff) {
+			break
+		}
+		r.rows[r.head] = nil
+		r.head = (r.head + 1) % len(r.rows)
+		r.size--
+	}
+}
+
+// frame returns a copy of the history as a single frame, or false if no frame
+// was pushed yet.
+func (r *historyRing) frame() (*data.Frame, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.schema == nil {
+		return nil, false
+	}
+	out := r.schema.EmptyCopy()
+	for i := 0; i < r.size; i++ {
+		out.AppendRow(r.rows[(r.head+i)%len(r.rows)]...)
+	}
+	return out, true
+}
@@ -0,0 +1,81 @@
+package managedstream
+
+import (
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAppendHistory(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	frame := func(offsets ...int) *data.Frame {
+		times := make([]time.Time, len(offsets))
+		values := make([]int64, len(offsets))
+		for i, o := range offsets {
+			times[i] = start.Add(time.Duration(o) * time.Minute)
+			values[i] = int64(o)
+		}
+		return data.NewFrame("test", data.NewField("time", nil, times), data.NewField("value", nil, values))
+	}
+
+	t.Run("trims by age relative to the latest row", func(t *testing.T) {
+		cfg := HistoryConfig{MaxAge: 10 * time.Minute}
+		history := appendHistory(nil, frame(0, 5), cfg)
+		history = appendHistory(history, frame(12, 15), cfg)
+		require.Equal(t, 3, history.Rows())
+		require.Equal(t, int64(5), history.Fields[1].At(0))
+	})
+
+	t.Run("applies both limits", func(t *testing.T) {
+		cfg := HistoryConfig{MaxRows: 2, MaxAge: time.Hour}
+		history := appendHistory(nil, frame(0, 1, 2, 3), cfg)
+		require.Equal(t, 2, history.Rows())
+		require.Equal(t, int64(2), history.Fields[1].At(0))
+	})
+
+	t.Run("filters by time range", func(t *testing.T) {
+		history := appendHistory(nil, frame(0, 5, 10, 15), HistoryConfig{MaxRows: 10})
+		filtered, err := filterHistory(history, backend.TimeRange{From: start.Add(5 * time.Minute), To: start.Add(10 * time.Minute)})
+		require.NoError(t, err)
+		require.Equal(t, 2, filtered.Rows())
+		require.Equal(t, int64(10), filtered.Fields[1].At(1))
+	})
+
+	t.Run("ring buffer keeps the latest rows", func(t *testing.T) {
+		ring := newHistoryRing(HistoryConfig{MaxRows: 3, MaxAge: 10 * time.Minute})
+		ring.push(frame(0, 1))
+		ring.push(frame(2, 3, 4))
+		ring.push(frame(5))
+		history, ok := ring.frame()
+		require.True(t, ok)
+		require.Equal(t, 3, history.Rows())
+		require.Equal(t, int64(3), history.Fields[1].At(0))
+		require.Equal(t, int64(5), history.Fields[1].At(2))
+
+		ring.push(frame(15))
+		history, _ = ring.frame()
+		require.Equal(t, 2, history.Rows())
+		require.Equal(t, int64(5), history.Fields[1].At(0))
+	})
+
+	t.Run("ring buffer caps history limited by age for frames without time", func(t *testing.T) {
+		ring := newHistoryRing(HistoryConfig{MaxAge: time.Minute})
+		values := make([]int64, maxHistoryRows+5)
+		for i := range values {
+			values[i] = int64(i)
+		}
+		ring.push(data.NewFrame("test", data.NewField("value", nil, values)))
+		history, ok := ring.frame()
+		require.True(t, ok)
+		require.Equal(t, maxHistoryRows, history.Rows())
+		require.Equal(t, int64(5), history.Fields[0].At(0))
+	})
+
+	t.Run("ring buffer without frames has no history", func(t *testing.T) {
+		_, ok := newHistoryRing(HistoryConfig{MaxRows: 3}).frame()
+		require.False(t, ok)
+	})
+}
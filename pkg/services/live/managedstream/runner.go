@@ -113,6 +113,19 @@ func (r *Runner) GetManagedChannels(orgID int64) ([]*ManagedChannel, error) {
 	return channels, nil
 }
 
+// GetHistory returns the rows kept for a managed stream channel within the time range.
+func (r *Runner) GetHistory(ctx context.Context, orgID int64, channel string, tr backend.TimeRange) (*data.Frame, bool, error) {
+	history, ok, err := r.frameCache.GetHistory(ctx, orgID, channel)
+	if err != nil || !ok {
+		return nil, false, err
+	}
+	frame, err := filterHistory(history, tr)
+	if err != nil {
+		return nil, false, err
+	}
+	return frame, true, nil
+}
+
 // GetOrCreateStream -- for now this will create new manager for each key.
 // Eventually, the stream behavior will need to be configured explicitly
 func (r *Runner) GetOrCreateStream(orgID int64, scope string, namespace string) (*NamespaceStream, error) {
@@ -239,8 +252,23 @@ func (s *NamespaceStream) GetHandlerForPath(_ string) (model.ChannelHandler, err
 	return s, nil
 }
 
+// OnSubscribe returns the channel history as initial data so new subscribers
+// do not start from an empty frame, or the last pushed frame when history is disabled.
 func (s *NamespaceStream) OnSubscribe(ctx context.Context, u identity.Requester, e model.SubscribeEvent) (model.SubscribeReply, backend.SubscribeStreamStatus, error) {
 	reply := model.SubscribeReply{}
+	history, ok, err := s.frameCache.GetHistory(ctx, u.GetOrgID(), e.Channel)
+	if err != nil {
+		return reply, 0, err
+	}
+	if ok {
+		frameJSON, err := data.FrameToJSON(history, data.IncludeAll)
+		if err != nil {
+			return reply, 0, err
+		}
+		reply.Data = frameJSON
+		return reply, backend.SubscribeStreamStatusOK, nil
+	}
+
 	frameJSON, ok, err := s.frameCache.GetFrame(ctx, u.GetOrgID(), e.Channel)
 	if err != nil {
 		return reply, 0, err
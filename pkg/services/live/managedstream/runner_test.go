@@ -2,11 +2,16 @@ package managedstream
 
 import (
 	"context"
+	"encoding/json"
 	"testing"
 	"time"
 
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
 	"github.com/grafana/grafana-plugin-sdk-go/data"
 	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/services/live/model"
+	"github.com/grafana/grafana/pkg/services/user"
 )
 
 type testPublisher struct {
@@ -19,13 +24,13 @@ func (p *testPublisher) publish(_ int64, _ string, _ []byte) error {
 
 func TestNewManagedStream(t *testing.T) {
 	publisher := &testPublisher{t: t}
-	c := NewNamespaceStream(1, "stream", "a", publisher.publish, nil, NewMemoryFrameCache())
+	c := NewNamespaceStream(1, "stream", "a", publisher.publish, nil, NewMemoryFrameCache(HistoryConfig{}))
 	require.NotNil(t, c)
 }
 
 func TestManagedStreamMinuteRate(t *testing.T) {
 	publisher := &testPublisher{t: t}
-	c := NewNamespaceStream(1, "stream", "a", publisher.publish, nil, NewMemoryFrameCache())
+	c := NewNamespaceStream(1, "stream", "a", publisher.publish, nil, NewMemoryFrameCache(HistoryConfig{}))
 	require.NotNil(t, c)
 
 	c.incRate("test1", time.Now().Unix())
@@ -47,7 +52,7 @@ func TestManagedStreamMinuteRate(t *testing.T) {
 
 func TestGetManagedStreams(t *testing.T) {
 	publisher := &testPublisher{t: t}
-	frameCache := NewMemoryFrameCache()
+	frameCache := NewMemoryFrameCache(HistoryConfig{})
 	runner := NewRunner(publisher.publish, nil, frameCache)
 	s1, err := runner.GetOrCreateStream(1, "stream", "test1")
 	require.NoError(t, err)
@@ -83,3 +88,31 @@ func TestGetManagedStreams(t *testing.T) {
 	require.NoError(t, err)
 	require.Len(t, managedChannels, 7) // Not affected by other org.
 }
+
+func TestManagedStreamHistory(t *testing.T) {
+	publisher := &testPublisher{t: t}
+	runner := NewRunner(publisher.publish, nil, NewMemoryFrameCache(HistoryConfig{MaxRows: 10}))
+	s, err := runner.GetOrCreateStream(1, "stream", "test")
+	require.NoError(t, err)
+
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 3; i++ {
+		frame := data.NewFrame("cpu",
+			data.NewField("time", nil, []time.Time{start.Add(time.Duration(i) * time.Minute)}),
+			data.NewField("value", nil, []float64{float64(i)}),
+		)
+		require.NoError(t, s.Push(context.Background(), "cpu", frame))
+	}
+
+	reply, status, err := s.OnSubscribe(context.Background(), &user.SignedInUser{OrgID: 1}, model.SubscribeEvent{Channel: "stream/test/cpu"})
+	require.NoError(t, err)
+	require.Equal(t, backend.SubscribeStreamStatusOK, status)
+	var initial data.Frame
+	require.NoError(t, json.Unmarshal(reply.Data, &initial))
+	require.Equal(t, 3, initial.Rows())
+
+	history, ok, err := runner.GetHistory(context.Background(), 1, "stream/test/cpu", backend.TimeRange{From: start.Add(time.Minute), To: start.Add(time.Hour)})
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, 2, history.Rows())
+}
@@ -40,6 +40,9 @@ type ConverterConfig struct {
 	ExactJsonConverterConfig  *ExactJsonConverterConfig  `json:"jsonExact,omitempty"`
 	AutoInfluxConverterConfig *AutoInfluxConverterConfig `json:"influxAuto,omitempty"`
 	JsonFrameConverterConfig  *JsonFrameConverterConfig  `json:"jsonFrame,omitempty"`
+
+	PrometheusTextConverterConfig *PrometheusTextConverterConfig `json:"prometheusText,omitempty"`
+	OtlpMetricsConverterConfig    *OtlpMetricsConverterConfig    `json:"otlpMetrics,omitempty"`
 }
 
 type DropFieldsFrameProcessorConfig struct {
@@ -55,6 +58,29 @@ type FrameProcessorConfig struct {
 	DropFieldsProcessorConfig *DropFieldsFrameProcessorConfig `json:"dropFields,omitempty"`
 	KeepFieldsProcessorConfig *KeepFieldsFrameProcessorConfig `json:"keepFields,omitempty"`
 	MultipleProcessorConfig   *MultipleFrameProcessorConfig   `json:"multiple,omitempty"`
+	AggregateProcessorConfig  *AggregateFrameProcessorConfig  `json:"aggregate,omitempty"`
+
+	ComputeFieldProcessorConfig *ComputeFieldFrameProcessorConfig `json:"computeField,omitempty"`
+}
+
+type ComputeFieldFrameProcessorConfig struct {
+	// FieldName of the computed field.
+	FieldName string `json:"fieldName"`
+	// Expression is a math expression where fields are referenced as $name or ${name}.
+	Expression string `json:"expression"`
+	// Config is set on the computed field.
+	Config *data.FieldConfig `json:"config,omitempty" ts_type:"FieldConfig"`
+}
+
+type AggregateFrameProcessorConfig struct {
+	// WindowMilliseconds is the size of each window.
+	WindowMilliseconds int64 `json:"windowMilliseconds"`
+	// SlideMilliseconds is the interval between the start of two sliding windows.
+	// Windows are tumbling when not set.
+	SlideMilliseconds int64 `json:"slideMilliseconds,omitempty"`
+	// Reducers applied to each numeric field and label set: mean, min, max, last
+	// or count. Defaults to mean.
+	Reducers []string `json:"reducers,omitempty"`
 }
 
 type MultipleFrameProcessorConfig struct {
@@ -79,6 +105,22 @@ type LokiOutputConfig struct {
 	UID string `json:"uid"`
 }
 
+type ArchiveOutputConfig struct {
+	// Directory on the local disk to write files to.
+	Directory string `json:"directory,omitempty"`
+	// BucketURL of a gocloud.dev blob bucket, for example s3://bucket?region=us-east-1.
+	// Used when Directory is not set.
+	BucketURL string `json:"bucketUrl,omitempty"`
+	// Format of the files, arrow or parquet. Defaults to arrow.
+	Format string `json:"format,omitempty"`
+	// MaxFileBytes is the estimated size after which a file is written. Defaults to 64MB.
+	MaxFileBytes int64 `json:"maxFileBytes,omitempty"`
+	// RotateMilliseconds is the time after which a file is written. Defaults to an hour.
+	RotateMilliseconds int64 `json:"rotateMilliseconds,omitempty"`
+	// RetentionDays removes files older than this many days. Files are kept when not set.
+	RetentionDays int `json:"retentionDays,omitempty"`
+}
+
 type MultipleSubscriberConfig struct {
 	Subscribers []SubscriberConfig `json:"subscribers"`
 }
@@ -109,6 +151,7 @@ type FrameOutputterConfig struct {
 	RemoteWriteOutputConfig *RemoteWriteOutputConfig   `json:"remoteWrite,omitempty"`
 	LokiOutputConfig        *LokiOutputConfig          `json:"loki,omitempty"`
 	ChangeLogOutputConfig   *ChangeLogOutputConfig     `json:"changeLog,omitempty"`
+	ArchiveOutputConfig     *ArchiveOutputConfig       `json:"archive,omitempty"`
 }
 
 type MultipleFrameConditionCheckerConfig struct {
@@ -126,6 +169,11 @@ type FrameConditionCheckerConfig struct {
 	Type                           string                               `json:"type" ts_type:"Omit<keyof FrameConditionCheckerConfig, 'type'>"`
 	MultipleConditionCheckerConfig *MultipleFrameConditionCheckerConfig `json:"multiple,omitempty"`
 	NumberCompareConditionConfig   *NumberCompareFrameConditionConfig   `json:"numberCompare,omitempty"`
+	ExpressionConditionConfig      *ExpressionFrameConditionConfig      `json:"expression,omitempty"`
+}
+
+type ExpressionFrameConditionConfig struct {
+	Expression string `json:"expression"`
 }
 
 type AutoJsonConverterConfig struct {
@@ -152,4 +200,15 @@ type AutoInfluxConverterConfig struct {
 
 type JsonFrameConverterConfig struct{}
 
+type PrometheusTextConverterConfig struct {
+	// Format is prometheus or openmetrics. When empty OpenMetrics is detected
+	// from the # EOF marker at the end of the input.
+	Format string `json:"format,omitempty"`
+}
+
+type OtlpMetricsConverterConfig struct {
+	// ResourceAttributes are copied to series labels, service.name by default.
+	ResourceAttributes []string `json:"resourceAttributes,omitempty"`
+}
+
 type ManagedStreamOutputConfig struct{}
@@ -0,0 +1,194 @@
+package pipeline
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+)
+
+// defaultOtlpResourceAttributes are copied to the labels of every series when
+// the converter has no resource attributes configured.
+var defaultOtlpResourceAttributes = []string{"service.name"}
+
+// maxOtlpBodySize limits the size of a gzip compressed body after decompression.
+const maxOtlpBodySize = 64 * 1024 * 1024
+
+// OtlpMetricsConverter decodes OTLP/HTTP metrics protobuf input and transforms it
+// to several ChannelFrame objects where Channel is constructed from original
+// channel + / + <metric_name>. Histograms and summaries are split into series
+// following the Prometheus naming conventions (_bucket, _sum and _count).
+type OtlpMetricsConverter struct {
+	config      OtlpMetricsConverterConfig
+	nowTimeFunc func() time.Time
+}
+
+// NewOtlpMetricsConverter creates new OtlpMetricsConverter.
+func NewOtlpMetricsConverter(config OtlpMetricsConverterConfig) *OtlpMetricsConverter {
+	return &OtlpMetricsConverter{config: config, nowTimeFunc: time.Now}
+}
+
+const ConverterTypeOtlpMetrics = "otlpMetrics"
+
+func (c *OtlpMetricsConverter) Type() string {
+	return ConverterTypeOtlpMetrics
+}
+
+func (c *OtlpMetricsConverter) Convert(_ context.Context, vars Vars, body []byte) ([]*ChannelFrame, error) {
+	// OTLP exporters usually compress the request body.
+	if len(body) > 2 && body[0] == 0x1f && body[1] == 0x8b {
+		r, err := gzip.NewReader(bytes.NewReader(body))
+		if err != nil {
+			return nil, fmt.Errorf("error reading gzip body: %w", err)
+		}
+		body, err = io.ReadAll(io.LimitReader(r, maxOtlpBodySize))
+		if err != nil {
+			return nil, fmt.Errorf("error reading gzip body: %w", err)
+		}
+	}
+
+	unmarshaler := &pmetric.ProtoUnmarshaler{}
+	metrics, err := unmarshaler.UnmarshalMetrics(body)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing metrics: %w", err)
+	}
+
+	resourceAttributes := c.config.ResourceAttributes
+	if len(resourceAttributes) == 0 {
+		resourceAttributes = defaultOtlpResourceAttributes
+	}
+
+	now := c.nowTimeFunc()
+	var samples []metricSample
+	rms := metrics.ResourceMetrics()
+	for i := 0; i < rms.Len(); i++ {
+		rm := rms.At(i)
+		resourceLabels := data.Labels{}
+		for _, name := range resourceAttributes {
+			if v, ok := rm.Resource().Attributes().Get(name); ok {
+				resourceLabels[name] = v.AsString()
+			}
+		}
+
+		sms := rm.ScopeMetrics()
+		for j := 0; j < sms.Len(); j++ {
+			ms := sms.At(j).Metrics()
+			for k := 0; k < ms.Len(); k++ {
+				samples = append(samples, otlpMetricSamples(ms.At(k), resourceLabels, now)...)
+			}
+		}
+	}
+
+	return metricSamplesToChannelFrames(vars.Channel, samples), nil
+}
+
+func otlpMetricSamples(m pmetric.Metric, resourceLabels data.Labels, now time.Time) []metricSample {
+	var samples []metricSample
+	add := func(name string, attrs pcommon.Map, ts pcommon.Timestamp, value float64, extra ...string) {
+		labels := otlpLabels(resourceLabels, attrs)
+		for i := 0; i+1 < len(extra); i += 2 {
+			labels[extra[i]] = extra[i+1]
+		}
+		t := now
+		if ts != 0 {
+			t = ts.AsTime()
+		}
+		samples = append(samples, metricSample{
+			family: m.Name(),
+			name:   name,
+			labels: labels,
+			time:   t,
+			value:  value,
+		})
+	}
+
+	switch m.Type() {
+	case pmetric.MetricTypeGauge:
+		otlpNumberSamples(m.Name(), m.Gauge().DataPoints(), add)
+	case pmetric.MetricTypeSum:
+		otlpNumberSamples(m.Name(), m.Sum().DataPoints(), add)
+	case pmetric.MetricTypeHistogram:
+		dps := m.Histogram().DataPoints()
+		for i := 0; i < dps.Len(); i++ {
+			dp := dps.At(i)
+			bounds := dp.ExplicitBounds()
+			counts := dp.BucketCounts()
+			var cumulative uint64
+			for b := 0; b < counts.Len(); b++ {
+				cumulative += counts.At(b)
+				le := math.Inf(+1)
+				if b < bounds.Len() {
+					le = bounds.At(b)
+				}
+				add(m.Name()+"_bucket", dp.Attributes(), dp.Timestamp(), float64(cumulative), "le", formatBucketBound(le))
+			}
+			if dp.HasSum() {
+				add(m.Name()+"_sum", dp.Attributes(), dp.Timestamp(), dp.Sum())
+			}
+			add(m.Name()+"_count", dp.Attributes(), dp.Timestamp(), float64(dp.Count()))
+		}
+	case pmetric.MetricTypeExponentialHistogram:
+		// Exponential buckets have no fixed boundaries, so only the totals are kept.
+		dps := m.ExponentialHistogram().DataPoints()
+		for i := 0; i < dps.Len(); i++ {
+			dp := dps.At(i)
+			if dp.HasSum() {
+				add(m.Name()+"_sum", dp.Attributes(), dp.Timestamp(), dp.Sum())
+			}
+			add(m.Name()+"_count", dp.Attributes(), dp.Timestamp(), float64(dp.Count()))
+		}
+	case pmetric.MetricTypeSummary:
+		dps := m.Summary().DataPoints()
+		for i := 0; i < dps.Len(); i++ {
+			dp := dps.At(i)
+			qs := dp.QuantileValues()
+			for q := 0; q < qs.Len(); q++ {
+				add(m.Name(), dp.Attributes(), dp.Timestamp(), qs.At(q).Value(), "quantile", formatBucketBound(qs.At(q).Quantile()))
+			}
+			add(m.Name()+"_sum", dp.Attributes(), dp.Timestamp(), dp.Sum())
+			add(m.Name()+"_count", dp.Attributes(), dp.Timestamp(), float64(dp.Count()))
+		}
+	}
+	return samples
+}
+
+func otlpNumberSamples(name string, dps pmetric.NumberDataPointSlice, add func(string, pcommon.Map, pcommon.Timestamp, float64, ...string)) {
+	for i := 0; i < dps.Len(); i++ {
+		dp := dps.At(i)
+		var value float64
+		switch dp.ValueType() {
+		case pmetric.NumberDataPointValueTypeInt:
+			value = float64(dp.IntValue())
+		case pmetric.NumberDataPointValueTypeDouble:
+			value = dp.DoubleValue()
+		default:
+			continue
+		}
+		add(name, dp.Attributes(), dp.Timestamp(), value)
+	}
+}
+
+func otlpLabels(resourceLabels data.Labels, attrs pcommon.Map) data.Labels {
+	labels := resourceLabels.Copy()
+	attrs.Range(func(k string, v pcommon.Value) bool {
+		labels[k] = v.AsString()
+		return true
+	})
+	return labels
+}
+
+// formatBucketBound formats le and quantile label values the way Prometheus does.
+func formatBucketBound(v float64) string {
+	if math.IsInf(v, +1) {
+		return "+Inf"
+	}
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
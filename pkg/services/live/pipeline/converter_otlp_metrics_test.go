@@ -0,0 +1,91 @@
+package pipeline
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+)
+
+func TestOtlpMetricsConverter_Convert(t *testing.T) {
+	ts := time.Date(2021, 01, 01, 12, 12, 12, 0, time.UTC)
+
+	metrics := pmetric.NewMetrics()
+	rm := metrics.ResourceMetrics().AppendEmpty()
+	rm.Resource().Attributes().PutStr("service.name", "checkout")
+	rm.Resource().Attributes().PutStr("host.name", "node-1")
+	sm := rm.ScopeMetrics().AppendEmpty()
+
+	gauge := sm.Metrics().AppendEmpty()
+	gauge.SetName("queue.size")
+	dp := gauge.SetEmptyGauge().DataPoints().AppendEmpty()
+	dp.SetTimestamp(pcommon.NewTimestampFromTime(ts))
+	dp.SetIntValue(4)
+	dp.Attributes().PutStr("queue", "orders")
+
+	histogram := sm.Metrics().AppendEmpty()
+	histogram.SetName("http.server.duration")
+	hdp := histogram.SetEmptyHistogram().DataPoints().AppendEmpty()
+	hdp.SetTimestamp(pcommon.NewTimestampFromTime(ts))
+	hdp.ExplicitBounds().FromRaw([]float64{0.1, 1})
+	hdp.BucketCounts().FromRaw([]uint64{2, 3, 1})
+	hdp.SetSum(2.5)
+	hdp.SetCount(6)
+
+	marshaler := &pmetric.ProtoMarshaler{}
+	body, err := marshaler.MarshalMetrics(metrics)
+	require.NoError(t, err)
+
+	check := func(t *testing.T, channelFrames []*ChannelFrame) {
+		t.Helper()
+		require.Len(t, channelFrames, 2)
+
+		queue := channelFrames[0]
+		require.Equal(t, "stream/otel/queue.size", queue.Channel)
+		require.Equal(t, ts, queue.Frame.Fields[0].At(0))
+		require.Equal(t, data.Labels{"service.name": "checkout", "queue": "orders"}, queue.Frame.Fields[1].Labels)
+		require.Equal(t, 4.0, queue.Frame.Fields[1].At(0))
+
+		duration := channelFrames[1]
+		require.Equal(t, "stream/otel/http.server.duration", duration.Channel)
+		require.Len(t, duration.Frame.Fields, 6)
+		require.Equal(t, "http.server.duration_bucket", duration.Frame.Fields[3].Name)
+		require.Equal(t, "+Inf", duration.Frame.Fields[3].Labels["le"])
+		require.Equal(t, 6.0, duration.Frame.Fields[3].At(0))
+		require.Equal(t, 5.0, duration.Frame.Fields[2].At(0))
+		require.Equal(t, "http.server.duration_count", duration.Frame.Fields[5].Name)
+	}
+
+	t.Run("protobuf", func(t *testing.T) {
+		converter := NewOtlpMetricsConverter(OtlpMetricsConverterConfig{})
+		channelFrames, err := converter.Convert(context.Background(), Vars{Channel: "stream/otel"}, body)
+		require.NoError(t, err)
+		check(t, channelFrames)
+	})
+
+	t.Run("gzip protobuf", func(t *testing.T) {
+		var buf bytes.Buffer
+		w := gzip.NewWriter(&buf)
+		_, err := w.Write(body)
+		require.NoError(t, err)
+		require.NoError(t, w.Close())
+
+		converter := NewOtlpMetricsConverter(OtlpMetricsConverterConfig{})
+		channelFrames, err := converter.Convert(context.Background(), Vars{Channel: "stream/otel"}, buf.Bytes())
+		require.NoError(t, err)
+		check(t, channelFrames)
+	})
+
+	t.Run("resource attributes", func(t *testing.T) {
+		converter := NewOtlpMetricsConverter(OtlpMetricsConverterConfig{ResourceAttributes: []string{"host.name"}})
+		channelFrames, err := converter.Convert(context.Background(), Vars{Channel: "stream/otel"}, body)
+		require.NoError(t, err)
+		require.Equal(t, data.Labels{"host.name": "node-1", "queue": "orders"}, channelFrames[0].Frame.Fields[1].Labels)
+	})
+}
@@ -0,0 +1,134 @@
+package pipeline
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/model/textparse"
+)
+
+const (
+	PrometheusTextFormatPrometheus  = "prometheus"
+	PrometheusTextFormatOpenMetrics = "openmetrics"
+)
+
+// PrometheusTextConverter decodes Prometheus text exposition or OpenMetrics input
+// and transforms it to several ChannelFrame objects where Channel is constructed
+// from original channel + / + <metric_family>.
+type PrometheusTextConverter struct {
+	config      PrometheusTextConverterConfig
+	nowTimeFunc func() time.Time
+}
+
+// NewPrometheusTextConverter creates new PrometheusTextConverter.
+func NewPrometheusTextConverter(config PrometheusTextConverterConfig) *PrometheusTextConverter {
+	return &PrometheusTextConverter{config: config, nowTimeFunc: time.Now}
+}
+
+const ConverterTypePrometheusText = "prometheusText"
+
+func (c *PrometheusTextConverter) Type() string {
+	return ConverterTypePrometheusText
+}
+
+// openMetricsEOF terminates every OpenMetrics exposition.
+var openMetricsEOF = []byte("# EOF")
+
+// familySuffixes are the suffixes of the series that belong to a metric family.
+var familySuffixes = []string{"_total", "_bucket", "_sum", "_count", "_created", "_info", "_gcount", "_gsum"}
+
+func (c *PrometheusTextConverter) Convert(_ context.Context, vars Vars, body []byte) ([]*ChannelFrame, error) {
+	format := c.config.Format
+	if format == "" {
+		format = PrometheusTextFormatPrometheus
+		if bytes.HasSuffix(bytes.TrimSpace(body), openMetricsEOF) {
+			format = PrometheusTextFormatOpenMetrics
+		}
+	}
+
+	var parser textparse.Parser
+	switch format {
+	case PrometheusTextFormatPrometheus:
+		parser = textparse.NewPromParser(body, labels.NewSymbolTable())
+	case PrometheusTextFormatOpenMetrics:
+		parser = textparse.NewOpenMetricsParser(body, labels.NewSymbolTable(), textparse.WithOMParserCTSeriesSkipped())
+	default:
+		return nil, fmt.Errorf("unsupported format: %s", format)
+	}
+
+	now := c.nowTimeFunc()
+	var (
+		samples []metricSample
+		family  string
+		lset    labels.Labels
+	)
+	for {
+		entry, err := parser.Next()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, fmt.Errorf("error parsing metrics: %w", err)
+		}
+
+		switch entry {
+		case textparse.EntryType:
+			name, _ := parser.Type()
+			family = string(name)
+		case textparse.EntryHelp:
+			name, _ := parser.Help()
+			family = string(name)
+		case textparse.EntryUnit:
+			name, _ := parser.Unit()
+			family = string(name)
+		case textparse.EntrySeries:
+			_, ts, value := parser.Series()
+			parser.Labels(&lset)
+
+			sample := metricSample{
+				name:   lset.Get(labels.MetricName),
+				labels: data.Labels{},
+				time:   now,
+				value:  value,
+			}
+			sample.family = metricFamilyName(family, sample.name)
+			lset.Range(func(l labels.Label) {
+				if l.Name != labels.MetricName {
+					sample.labels[l.Name] = l.Value
+				}
+			})
+			if ts != nil {
+				sample.time = time.UnixMilli(*ts)
+			}
+			samples = append(samples, sample)
+		case textparse.EntryHistogram:
+			// Native histograms only exist in the protobuf exposition format.
+			continue
+		}
+	}
+
+	return metricSamplesToChannelFrames(vars.Channel, samples), nil
+}
+
+// metricFamilyName returns the family of a series given the family declared by the last
+// metadata line, or the series name when it does not belong to that family.
+func metricFamilyName(family, name string) string {
+	if family == "" || name == family {
+		return name
+	}
+	if suffix, ok := strings.CutPrefix(name, family); ok {
+		for _, s := range familySuffixes {
+			if suffix == s {
+				return family
+			}
+		}
+	}
+	return name
+}
@@ -0,0 +1,76 @@
+package pipeline
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrometheusTextConverter_Convert(t *testing.T) {
+	now := time.Date(2021, 01, 01, 12, 12, 12, 0, time.UTC)
+
+	t.Run("prometheus", func(t *testing.T) {
+		converter := NewPrometheusTextConverter(PrometheusTextConverterConfig{})
+		converter.nowTimeFunc = func() time.Time { return now }
+
+		body := []byte(`# HELP http_requests_total Total requests.
+# TYPE http_requests_total counter
+http_requests_total{code="200"} 10
+http_requests_total{code="500"} 2
+# TYPE request_duration_seconds histogram
+request_duration_seconds_bucket{le="0.5"} 3
+request_duration_seconds_bucket{le="+Inf"} 5
+request_duration_seconds_sum 1.5
+request_duration_seconds_count 5
+node:cpu:rate 0.25 1609503132000
+`)
+		channelFrames, err := converter.Convert(context.Background(), Vars{Channel: "stream/agent"}, body)
+		require.NoError(t, err)
+		require.Len(t, channelFrames, 3)
+
+		requests := channelFrames[0]
+		require.Equal(t, "stream/agent/http_requests_total", requests.Channel)
+		require.Len(t, requests.Frame.Fields, 3)
+		require.Equal(t, now, requests.Frame.Fields[0].At(0))
+		require.Equal(t, data.Labels{"code": "500"}, requests.Frame.Fields[2].Labels)
+		require.Equal(t, 2.0, requests.Frame.Fields[2].At(0))
+
+		histogram := channelFrames[1]
+		require.Equal(t, "stream/agent/request_duration_seconds", histogram.Channel)
+		require.Len(t, histogram.Frame.Fields, 5)
+		require.Equal(t, "request_duration_seconds_bucket", histogram.Frame.Fields[2].Name)
+		require.Equal(t, data.Labels{"le": "+Inf"}, histogram.Frame.Fields[2].Labels)
+		require.Equal(t, "request_duration_seconds_count", histogram.Frame.Fields[4].Name)
+
+		rule := channelFrames[2]
+		require.Equal(t, "stream/agent/node_cpu_rate", rule.Channel)
+		require.Equal(t, time.UnixMilli(1609503132000), rule.Frame.Fields[0].At(0))
+	})
+
+	t.Run("openmetrics", func(t *testing.T) {
+		converter := NewPrometheusTextConverter(PrometheusTextConverterConfig{})
+		converter.nowTimeFunc = func() time.Time { return now }
+
+		body := []byte(`# TYPE jobs counter
+jobs_total{queue="default"} 7 # {trace_id="abc"} 1.0
+jobs_created{queue="default"} 1609459200
+# EOF
+`)
+		channelFrames, err := converter.Convert(context.Background(), Vars{Channel: "stream/agent"}, body)
+		require.NoError(t, err)
+		require.Len(t, channelFrames, 1)
+		require.Equal(t, "stream/agent/jobs", channelFrames[0].Channel)
+		require.Len(t, channelFrames[0].Frame.Fields, 2)
+		require.Equal(t, "jobs_total", channelFrames[0].Frame.Fields[1].Name)
+		require.Equal(t, 7.0, channelFrames[0].Frame.Fields[1].At(0))
+	})
+
+	t.Run("invalid input", func(t *testing.T) {
+		converter := NewPrometheusTextConverter(PrometheusTextConverterConfig{Format: PrometheusTextFormatOpenMetrics})
+		_, err := converter.Convert(context.Background(), Vars{Channel: "stream/agent"}, []byte("up 1\n"))
+		require.Error(t, err)
+	})
+}
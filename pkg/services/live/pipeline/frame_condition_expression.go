@@ -0,0 +1,48 @@
+package pipeline
+
+import (
+	"context"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+// FrameExpressionCondition evaluates a math expression over the fields of a frame,
+// for example "$temperature > 30 && $humidity < 20". The condition is met when the
+// expression returns a non-zero value for any row.
+type FrameExpressionCondition struct {
+	Expression string
+	expr       *frameExpression
+}
+
+const FrameConditionCheckerTypeExpression = "expression"
+
+func (c *FrameExpressionCondition) Type() string {
+	return FrameConditionCheckerTypeExpression
+}
+
+func (c *FrameExpressionCondition) CheckFrameCondition(_ context.Context, frame *data.Frame) (bool, error) {
+	for row := 0; row < frame.Rows(); row++ {
+		values, err := c.expr.executeRow(frame, row)
+		if err != nil {
+			return false, err
+		}
+		for _, v := range values {
+			value, err := expressionValue(v)
+			if err != nil {
+				return false, err
+			}
+			if value != nil && *value != 0 {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+func NewFrameExpressionCondition(expression string) (*FrameExpressionCondition, error) {
+	expr, err := newFrameExpression(expression)
+	if err != nil {
+		return nil, err
+	}
+	return &FrameExpressionCondition{Expression: expression, expr: expr}, nil
+}
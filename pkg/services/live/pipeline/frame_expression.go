@@ -0,0 +1,72 @@
+package pipeline
+
+import (
+	"fmt"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+
+	"github.com/grafana/grafana/pkg/expr/mathexp"
+)
+
+// frameExpression is a math expression evaluated for each row of a frame. Numeric
+// fields are available as variables named after the field, for example $temp_c or
+// ${temp c}. Fields that share a name but have different labels are joined by
+// labels like in server side expressions.
+type frameExpression struct {
+	expr *mathexp.Expr
+}
+
+func newFrameExpression(expression string) (*frameExpression, error) {
+	expr, err := mathexp.New(expression)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing expression: %w", err)
+	}
+	return &frameExpression{expr: expr}, nil
+}
+
+// executeRow returns the expression results for a frame row. The results are
+// numbers or a scalar when no field is referenced.
+func (e *frameExpression) executeRow(frame *data.Frame, row int) (mathexp.Values, error) {
+	vars := mathexp.Vars{}
+	for _, name := range e.expr.VarNames {
+		if _, ok := vars[name]; ok {
+			continue
+		}
+		for _, f := range frame.Fields {
+			if f.Name != name || !f.Type().Numeric() {
+				continue
+			}
+			v, err := f.NullableFloatAt(row)
+			if err != nil {
+				return nil, err
+			}
+			n := mathexp.NewNumber(name, f.Labels)
+			n.SetValue(v)
+			results := vars[name]
+			results.Values = append(results.Values, n)
+			vars[name] = results
+		}
+		if _, ok := vars[name]; !ok {
+			return nil, fmt.Errorf("no numeric field %q in frame", name)
+		}
+	}
+
+	// The tracer is not used when executing math expressions.
+	results, err := e.expr.Execute("", vars, nil)
+	if err != nil {
+		return nil, err
+	}
+	return results.Values, nil
+}
+
+// expressionValue returns the value held by a number or scalar result.
+func expressionValue(v mathexp.Value) (*float64, error) {
+	switch r := v.(type) {
+	case mathexp.Number:
+		return r.GetFloat64Value(), nil
+	case mathexp.Scalar:
+		return r.GetFloat64Value(), nil
+	default:
+		return nil, fmt.Errorf("unsupported expression result type: %s", v.Type())
+	}
+}
@@ -0,0 +1,88 @@
+package pipeline
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/require"
+)
+
+func TestComputeFieldFrameProcessor(t *testing.T) {
+	now := time.Now()
+
+	t.Run("adds converted field", func(t *testing.T) {
+		p, err := NewComputeFieldFrameProcessor(ComputeFieldFrameProcessorConfig{
+			FieldName:  "temp_f",
+			Expression: "$temp_c * 9/5 + 32",
+			Config:     &data.FieldConfig{Unit: "fahrenheit"},
+		})
+		require.NoError(t, err)
+
+		frame := data.NewFrame("sensor",
+			data.NewField("time", nil, []time.Time{now, now}),
+			data.NewField("temp_c", nil, []float64{100, -40}),
+		)
+		frame, err = p.ProcessFrame(context.Background(), Vars{}, frame)
+		require.NoError(t, err)
+		require.Len(t, frame.Fields, 3)
+		require.Equal(t, "temp_f", frame.Fields[2].Name)
+		require.Equal(t, "fahrenheit", frame.Fields[2].Config.Unit)
+		require.Equal(t, 212.0, *frame.Fields[2].At(0).(*float64))
+		require.Equal(t, -40.0, *frame.Fields[2].At(1).(*float64))
+	})
+
+	t.Run("joins fields by labels", func(t *testing.T) {
+		p, err := NewComputeFieldFrameProcessor(ComputeFieldFrameProcessorConfig{
+			FieldName:  "ratio",
+			Expression: "$errors / $requests",
+		})
+		require.NoError(t, err)
+
+		frame := data.NewFrame("http",
+			data.NewField("time", nil, []time.Time{now}),
+			data.NewField("errors", data.Labels{"host": "a"}, []float64{1}),
+			data.NewField("errors", data.Labels{"host": "b"}, []float64{2}),
+			data.NewField("requests", data.Labels{"host": "a"}, []float64{10}),
+			data.NewField("requests", data.Labels{"host": "b"}, []float64{10}),
+		)
+		frame, err = p.ProcessFrame(context.Background(), Vars{}, frame)
+		require.NoError(t, err)
+		require.Len(t, frame.Fields, 7)
+		require.Equal(t, data.Labels{"host": "a"}, frame.Fields[5].Labels)
+		require.Equal(t, 0.1, *frame.Fields[5].At(0).(*float64))
+		require.Equal(t, 0.2, *frame.Fields[6].At(0).(*float64))
+	})
+
+	t.Run("missing field", func(t *testing.T) {
+		p, err := NewComputeFieldFrameProcessor(ComputeFieldFrameProcessorConfig{FieldName: "x", Expression: "$unknown + 1"})
+		require.NoError(t, err)
+		_, err = p.ProcessFrame(context.Background(), Vars{}, data.NewFrame("", data.NewField("value", nil, []float64{1})))
+		require.ErrorContains(t, err, "unknown")
+	})
+
+	t.Run("invalid expression", func(t *testing.T) {
+		_, err := NewComputeFieldFrameProcessor(ComputeFieldFrameProcessorConfig{FieldName: "x", Expression: "$a +"})
+		require.Error(t, err)
+	})
+}
+
+func TestFrameExpressionCondition(t *testing.T) {
+	c, err := NewFrameExpressionCondition("$temperature > 30 && $humidity < 20")
+	require.NoError(t, err)
+
+	check := func(temperature, humidity float64) bool {
+		t.Helper()
+		ok, err := c.CheckFrameCondition(context.Background(), data.NewFrame("",
+			data.NewField("temperature", nil, []float64{temperature}),
+			data.NewField("humidity", nil, []*float64{&humidity}),
+		))
+		require.NoError(t, err)
+		return ok
+	}
+
+	require.True(t, check(35, 10))
+	require.False(t, check(35, 50))
+	require.False(t, check(20, 10))
+}
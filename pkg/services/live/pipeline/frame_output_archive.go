@@ -0,0 +1,342 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/apache/arrow-go/v18/parquet"
+	"github.com/apache/arrow-go/v18/parquet/compress"
+	"github.com/apache/arrow-go/v18/parquet/pqarrow"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"gocloud.dev/blob"
+	_ "gocloud.dev/blob/azureblob"
+	"gocloud.dev/blob/fileblob"
+	_ "gocloud.dev/blob/gcsblob"
+	_ "gocloud.dev/blob/s3blob"
+
+	"github.com/grafana/grafana/pkg/services/live/orgchannel"
+)
+
+const (
+	ArchiveFormatArrow   = "arrow"
+	ArchiveFormatParquet = "parquet"
+
+	defaultArchiveMaxFileBytes = 64 * 1024 * 1024
+	defaultArchiveRotate       = time.Hour
+	// archiveCheckInterval is how often batches are checked for time based rotation.
+	archiveCheckInterval = 10 * time.Second
+	// archiveRetentionInterval is how often expired files are removed.
+	archiveRetentionInterval = time.Hour
+	// archiveKeyPrefix is the prefix of every archive file, retention only
+	// removes files below it so other data in a shared bucket is kept.
+	archiveKeyPrefix = "live-archive/"
+)
+
+// ArchiveFrameOutput batches frames of each channel and writes them to Arrow or
+// Parquet files in a local directory or a blob bucket. A file is written when
+// its estimated size or age reaches the configured limit, or when the frame
+// schema changes. Files are stored as
+// live-archive/<orgId>/<channel>/<yyyy>/<mm>/<dd>/<time>.<format>.
+// Close must be called to write the pending batches and release the bucket.
+type ArchiveFrameOutput struct {
+	mu sync.Mutex
+
+	config  ArchiveOutputConfig
+	bucket  *blob.Bucket
+	rotate  time.Duration
+	batches map[string]*archiveBatch
+	closed  bool
+	// writing tracks writes started by OutputFrame so Close waits for them.
+	writing sync.WaitGroup
+
+	done      chan struct{}
+	stopped   chan struct{}
+	closeOnce sync.Once
+	closeErr  error
+
+	nowTimeFunc func() time.Time
+}
+
+type archiveBatch struct {
+	orgID   int64
+	channel string
+	frame   *data.Frame
+	size    int64
+	started time.Time
+}
+
+func NewArchiveFrameOutput(config ArchiveOutputConfig) (*ArchiveFrameOutput, error) {
+	switch config.Format {
+	case "":
+		config.Format = ArchiveFormatArrow
+	case ArchiveFormatArrow, ArchiveFormatParquet:
+	default:
+		return nil, fmt.Errorf("unsupported archive format: %s", config.Format)
+	}
+	if config.MaxFileBytes <= 0 {
+		config.MaxFileBytes = defaultArchiveMaxFileBytes
+	}
+
+	var (
+		bucket *blob.Bucket
+		err    error
+	)
+	switch {
+	case config.Directory != "":
+		bucket, err = fileblob.OpenBucket(config.Directory, &fileblob.Options{
+			CreateDir: true,
+			NoTempDir: true,
+			Metadata:  fileblob.MetadataDontWrite,
+		})
+	case config.BucketURL != "":
+		bucket, err = blob.OpenBucket(context.Background(), config.BucketURL)
+	default:
+		return nil, errors.New("directory or bucketUrl is required")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error opening archive bucket: %w", err)
+	}
+
+	out := &ArchiveFrameOutput{
+		config:      config,
+		bucket:      bucket,
+		rotate:      time.Duration(config.RotateMilliseconds) * time.Millisecond,
+		batches:     map[string]*archiveBatch{},
+		done:        make(chan struct{}),
+		stopped:     make(chan struct{}),
+		nowTimeFunc: time.Now,
+	}
+	if out.rotate <= 0 {
+		out.rotate = defaultArchiveRotate
+	}
+	go out.run()
+	return out, nil
+}
+
+const FrameOutputTypeArchive = "archive"
+
+func (out *ArchiveFrameOutput) Type() string {
+	return FrameOutputTypeArchive
+}
+
+func (out *ArchiveFrameOutput) OutputFrame(ctx context.Context, vars Vars, frame *data.Frame) ([]*ChannelFrame, error) {
+	key := orgchannel.PrependOrgID(vars.OrgID, vars.Channel)
+
+	var toWrite []*archiveBatch
+	out.mu.Lock()
+	if out.closed {
+		out.mu.Unlock()
+		return nil, errors.New("archive output is closed")
+	}
+	batch, ok := out.batches[key]
+	if ok && !archiveSameSchema(batch.frame, frame) {
+		toWrite = append(toWrite, batch)
+		ok = false
+	}
+	if !ok {
+		batch = &archiveBatch{
+			orgID:   vars.OrgID,
+			channel: vars.Channel,
+			frame:   frame.EmptyCopy(),
+			started: out.nowTimeFunc(),
+		}
+		out.batches[key] = batch
+	}
+	for i := 0; i < frame.Rows(); i++ {
+		batch.frame.AppendRow(frame.RowCopy(i)...)
+	}
+	batch.size += estimateFrameSize(frame)
+	if batch.size >= out.config.MaxFileBytes {
+		toWrite = append(toWrite, batch)
+		delete(out.batches, key)
+	}
+	out.writing.Add(1)
+	defer out.writing.Done()
+	out.mu.Unlock()
+
+	for _, b := range toWrite {
+		if err := out.write(ctx, b); err != nil {
+			logger.Error("Error writing archive file", "error", err, "channel", b.channel)
+		}
+	}
+	return nil, nil
+}
+
+func (out *ArchiveFrameOutput) run() {
+	defer close(out.stopped)
+	checkTicker := time.NewTicker(archiveCheckInterval)
+	defer checkTicker.Stop()
+	retentionTicker := time.NewTicker(archiveRetentionInterval)
+	defer retentionTicker.Stop()
+
+	for {
+		select {
+		case <-out.done:
+			return
+		case <-checkTicker.C:
+			out.rotateExpired(context.Background())
+		case <-retentionTicker.C:
+			if err := out.removeExpired(context.Background()); err != nil {
+				logger.Error("Error removing expired archive files", "error", err)
+			}
+		}
+	}
+}
+
+// Close stops the background rotation, writes all pending batches and closes
+// the bucket. Frames output after Close are rejected.
+func (out *ArchiveFrameOutput) Close() error {
+	out.closeOnce.Do(func() {
+		close(out.done)
+		<-out.stopped
+
+		out.mu.Lock()
+		out.closed = true
+		toWrite := make([]*archiveBatch, 0, len(out.batches))
+		for key, b := range out.batches {
+			toWrite = append(toWrite, b)
+			delete(out.batches, key)
+		}
+		out.mu.Unlock()
+		out.writing.Wait()
+
+		var errs []error
+		for _, b := range toWrite {
+			if err := out.write(context.Background(), b); err != nil {
+				errs = append(errs, fmt.Errorf("error writing archive file for %s: %w", b.channel, err))
+			}
+		}
+		if err := out.bucket.Close(); err != nil {
+			errs = append(errs, err)
+		}
+		out.closeErr = errors.Join(errs...)
+	})
+	return out.closeErr
+}
+
+// rotateExpired writes the batches started more than the rotation interval ago.
+func (out *ArchiveFrameOutput) rotateExpired(ctx context.Context) {
+	now := out.nowTimeFunc()
+	var toWrite []*archiveBatch
+	out.mu.Lock()
+	for key, b := range out.batches {
+		if now.Sub(b.started) >= out.rotate {
+			toWrite = append(toWrite, b)
+			delete(out.batches, key)
+		}
+	}
+	out.mu.Unlock()
+
+	for _, b := range toWrite {
+		if err := out.write(ctx, b); err != nil {
+			logger.Error("Error writing archive file", "error", err, "channel", b.channel)
+		}
+	}
+}
+
+// removeExpired deletes archive files older than the configured retention.
+func (out *ArchiveFrameOutput) removeExpired(ctx context.Context) error {
+	if out.config.RetentionDays <= 0 {
+		return nil
+	}
+	cutoff := out.nowTimeFunc().AddDate(0, 0, -out.config.RetentionDays)
+
+	iter := out.bucket.List(&blob.ListOptions{Prefix: archiveKeyPrefix})
+	for {
+		obj, err := iter.Next(ctx)
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if obj.IsDir || !isArchiveFile(obj.Key) || !obj.ModTime.Before(cutoff) {
+			continue
+		}
+		if err := out.bucket.Delete(ctx, obj.Key); err != nil {
+			return err
+		}
+	}
+}
+
+func (out *ArchiveFrameOutput) write(ctx context.Context, b *archiveBatch) error {
+	if b.frame.Rows() == 0 {
+		return nil
+	}
+	key := fmt.Sprintf("%s%d/%s/%s.%s", archiveKeyPrefix, b.orgID, b.channel, b.started.UTC().Format("2006/01/02/150405.000000000"), out.config.Format)
+
+	w, err := out.bucket.NewWriter(ctx, key, nil)
+	if err != nil {
+		return err
+	}
+	switch out.config.Format {
+	case ArchiveFormatParquet:
+		err = writeParquetFrame(w, b.frame)
+	default:
+		var raw []byte
+		raw, err = b.frame.MarshalArrow()
+		if err == nil {
+			_, err = w.Write(raw)
+		}
+	}
+	if err != nil {
+		_ = w.Close()
+		return err
+	}
+	logger.Debug("Archive file written", "key", key, "rows", b.frame.Rows())
+	return w.Close()
+}
+
+func isArchiveFile(key string) bool {
+	return strings.HasSuffix(key, "."+ArchiveFormatArrow) || strings.HasSuffix(key, "."+ArchiveFormatParquet)
+}
+
+func writeParquetFrame(w io.Writer, frame *data.Frame) error {
+	table, err := data.FrameToArrowTable(frame)
+	if err != nil {
+		return err
+	}
+	defer table.Release()
+
+	props := parquet.NewWriterProperties(parquet.WithCompression(compress.Codecs.Snappy))
+	// Storing the Arrow schema keeps field labels and config in the file.
+	arrowProps := pqarrow.NewArrowWriterProperties(pqarrow.WithStoreSchema())
+	return pqarrow.WriteTable(table, w, 64*1024, props, arrowProps)
+}
+
+func archiveSameSchema(a, b *data.Frame) bool {
+	if len(a.Fields) != len(b.Fields) {
+		return false
+	}
+	for i := range a.Fields {
+		if a.Fields[i].Name != b.Fields[i].Name || a.Fields[i].Type() != b.Fields[i].Type() || !a.Fields[i].Labels.Equals(b.Fields[i].Labels) {
+			return false
+		}
+	}
+	return true
+}
+
+// estimateFrameSize returns the approximate size of the frame values in bytes.
+func estimateFrameSize(frame *data.Frame) int64 {
+	var size int64
+	for _, f := range frame.Fields {
+		for i := 0; i < f.Len(); i++ {
+			switch v := f.At(i).(type) {
+			case string:
+				size += int64(len(v))
+			case *string:
+				if v != nil {
+					size += int64(len(*v))
+				}
+			default:
+				size += 8
+			}
+		}
+	}
+	return size
+}
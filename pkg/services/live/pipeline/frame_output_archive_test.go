@@ -0,0 +1,198 @@
+package pipeline
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/require"
+)
+
+func archiveFiles(t *testing.T, dir string) []string {
+	t.Helper()
+	var files []string
+	err := filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			files = append(files, path)
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	return files
+}
+
+func TestArchiveFrameOutput(t *testing.T) {
+	started := time.Date(2021, 1, 1, 12, 0, 0, 0, time.UTC)
+	vars := Vars{OrgID: 1, Channel: "stream/sensors/a"}
+	frame := func(v float64) *data.Frame {
+		return data.NewFrame("sensor",
+			data.NewField("time", nil, []time.Time{started}),
+			data.NewField("value", data.Labels{"sensor": "a"}, []float64{v}),
+		)
+	}
+
+	t.Run("rotates arrow files by size", func(t *testing.T) {
+		dir := t.TempDir()
+		out, err := NewArchiveFrameOutput(ArchiveOutputConfig{Directory: dir, MaxFileBytes: 32})
+		require.NoError(t, err)
+		out.nowTimeFunc = func() time.Time { return started }
+
+		for i := 0; i < 3; i++ {
+			_, err := out.OutputFrame(context.Background(), vars, frame(float64(i)))
+			require.NoError(t, err)
+		}
+
+		files := archiveFiles(t, dir)
+		require.Len(t, files, 1)
+		require.Equal(t, filepath.Join(dir, "live-archive/1/stream/sensors/a/2021/01/01/120000.000000000.arrow"), files[0])
+
+		raw, err := os.ReadFile(files[0])
+		require.NoError(t, err)
+		archived, err := data.UnmarshalArrowFrame(raw)
+		require.NoError(t, err)
+		require.Equal(t, 2, archived.Rows())
+		require.Equal(t, data.Labels{"sensor": "a"}, archived.Fields[1].Labels)
+	})
+
+	t.Run("rotates parquet files by time", func(t *testing.T) {
+		dir := t.TempDir()
+		out, err := NewArchiveFrameOutput(ArchiveOutputConfig{Directory: dir, Format: ArchiveFormatParquet, RotateMilliseconds: 60000})
+		require.NoError(t, err)
+		now := started
+		out.nowTimeFunc = func() time.Time { return now }
+
+		_, err = out.OutputFrame(context.Background(), vars, frame(1))
+		require.NoError(t, err)
+		out.rotateExpired(context.Background())
+		require.Empty(t, archiveFiles(t, dir))
+
+		now = started.Add(time.Minute)
+		out.rotateExpired(context.Background())
+		files := archiveFiles(t, dir)
+		require.Len(t, files, 1)
+		raw, err := os.ReadFile(files[0])
+		require.NoError(t, err)
+		require.True(t, bytes.HasPrefix(raw, []byte("PAR1")))
+	})
+
+	t.Run("removes files after retention", func(t *testing.T) {
+		dir := t.TempDir()
+		out, err := NewArchiveFrameOutput(ArchiveOutputConfig{Directory: dir, RetentionDays: 30})
+		require.NoError(t, err)
+
+		oldFile := filepath.Join(dir, "live-archive", "1", "old.arrow")
+		newFile := filepath.Join(dir, "live-archive", "1", "new.arrow")
+		oldOther := filepath.Join(dir, "live-archive", "1", "old.txt")
+		outside := filepath.Join(dir, "other", "old.arrow")
+		require.NoError(t, os.MkdirAll(filepath.Dir(oldFile), 0750))
+		require.NoError(t, os.MkdirAll(filepath.Dir(outside), 0750))
+		for _, f := range []string{oldFile, newFile, oldOther, outside} {
+			require.NoError(t, os.WriteFile(f, []byte("data"), 0600))
+			if f != newFile {
+				require.NoError(t, os.Chtimes(f, time.Now().AddDate(0, 0, -31), time.Now().AddDate(0, 0, -31)))
+			}
+		}
+
+		require.NoError(t, out.removeExpired(context.Background()))
+		require.ElementsMatch(t, []string{newFile, oldOther, outside}, archiveFiles(t, dir))
+		require.NoError(t, out.Close())
+	})
+
+	t.Run("writes pending batches on close", func(t *testing.T) {
+		dir := t.TempDir()
+		out, err := NewArchiveFrameOutput(ArchiveOutputConfig{Directory: dir})
+		require.NoError(t, err)
+
+		_, err = out.OutputFrame(context.Background(), vars, frame(1))
+		require.NoError(t, err)
+		require.Empty(t, archiveFiles(t, dir))
+
+		require.NoError(t, out.Close())
+		require.Len(t, archiveFiles(t, dir), 1)
+		require.NoError(t, out.Close())
+
+		_, err = out.OutputFrame(context.Background(), vars, frame(2))
+		require.Error(t, err)
+	})
+
+	t.Run("requires a destination", func(t *testing.T) {
+		_, err := NewArchiveFrameOutput(ArchiveOutputConfig{})
+		require.Error(t, err)
+	})
+}
+
+func TestArchiveFrameOutput_KeptOnRebuild(t *testing.T) {
+	builder := &StorageRuleBuilder{}
+	config := &FrameOutputterConfig{
+		Type:                FrameOutputTypeArchive,
+		ArchiveOutputConfig: &ArchiveOutputConfig{Directory: t.TempDir()},
+	}
+	out1, err := builder.extractFrameOutputter(config, nil)
+	require.NoError(t, err)
+	out2, err := builder.extractFrameOutputter(config, nil)
+	require.NoError(t, err)
+	require.Same(t, out1, out2)
+}
+
+type archiveRuleStorage struct {
+	Storage
+	rules []ChannelRule
+}
+
+func (s *archiveRuleStorage) ListChannelRules(_ context.Context, _ int64) ([]ChannelRule, error) {
+	return s.rules, nil
+}
+
+func (s *archiveRuleStorage) ListWriteConfigs(_ context.Context, _ int64) ([]WriteConfig, error) {
+	return nil, nil
+}
+
+func TestArchiveFrameOutput_ClosedWhenReplaced(t *testing.T) {
+	archiveRule := func(dir string) ChannelRule {
+		return ChannelRule{
+			Pattern: "stream/sensors/a",
+			Settings: ChannelRuleSettings{
+				FrameOutputters: []*FrameOutputterConfig{{
+					Type:                FrameOutputTypeArchive,
+					ArchiveOutputConfig: &ArchiveOutputConfig{Directory: dir},
+				}},
+			},
+		}
+	}
+	dir := t.TempDir()
+	storage := &archiveRuleStorage{rules: []ChannelRule{archiveRule(dir)}}
+	builder := &StorageRuleBuilder{Storage: storage}
+
+	rules, err := builder.BuildRules(context.Background(), 1)
+	require.NoError(t, err)
+	out := rules[0].FrameOutputters[0].(*ArchiveFrameOutput)
+	_, err = out.OutputFrame(context.Background(), Vars{OrgID: 1, Channel: "stream/sensors/a"}, data.NewFrame("sensor",
+		data.NewField("time", nil, []time.Time{time.Now()}),
+	))
+	require.NoError(t, err)
+
+	// Rebuilding with the same configuration keeps the output.
+	rules, err = builder.BuildRules(context.Background(), 1)
+	require.NoError(t, err)
+	require.Same(t, out, rules[0].FrameOutputters[0])
+	require.Empty(t, archiveFiles(t, dir))
+
+	// A new configuration replaces the output, which writes its pending batch.
+	storage.rules = []ChannelRule{archiveRule(t.TempDir())}
+	rules, err = builder.BuildRules(context.Background(), 1)
+	require.NoError(t, err)
+	require.NotSame(t, out, rules[0].FrameOutputters[0])
+	require.Len(t, archiveFiles(t, dir), 1)
+
+	builder.Close()
+	rules, err = builder.BuildRules(context.Background(), 1)
+	require.NoError(t, err)
+	require.Empty(t, rules)
+}
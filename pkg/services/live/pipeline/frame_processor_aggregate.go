@@ -0,0 +1,306 @@
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+
+	"github.com/grafana/grafana/pkg/services/live/orgchannel"
+)
+
+const (
+	AggregateReducerMean  = "mean"
+	AggregateReducerMin   = "min"
+	AggregateReducerMax   = "max"
+	AggregateReducerLast  = "last"
+	AggregateReducerCount = "count"
+)
+
+// AggregateFrameProcessor aggregates numeric fields of incoming frames over tumbling
+// or sliding windows. Frames are consumed until a window closes, then a frame with
+// a row for each closed window is passed further. Windows are aligned to the
+// frame time and each row is stamped with the window end. Rows older than the
+// current window are dropped.
+type AggregateFrameProcessor struct {
+	config AggregateFrameProcessorConfig
+	window time.Duration
+	slide  time.Duration
+
+	mu     sync.Mutex
+	states map[string]*aggregateState
+	// nowTimeFunc is used for frames without a time field.
+	nowTimeFunc func() time.Time
+}
+
+func NewAggregateFrameProcessor(config AggregateFrameProcessorConfig) *AggregateFrameProcessor {
+	p := &AggregateFrameProcessor{
+		config:      config,
+		window:      time.Duration(config.WindowMilliseconds) * time.Millisecond,
+		slide:       time.Duration(config.SlideMilliseconds) * time.Millisecond,
+		states:      map[string]*aggregateState{},
+		nowTimeFunc: time.Now,
+	}
+	if p.slide <= 0 {
+		p.slide = p.window
+	}
+	if len(p.config.Reducers) == 0 {
+		p.config.Reducers = []string{AggregateReducerMean}
+	}
+	return p
+}
+
+const FrameProcessorTypeAggregate = "aggregate"
+
+func (p *AggregateFrameProcessor) Type() string {
+	return FrameProcessorTypeAggregate
+}
+
+func validateAggregateFrameProcessorConfig(config AggregateFrameProcessorConfig) error {
+	if config.WindowMilliseconds <= 0 {
+		return fmt.Errorf("windowMilliseconds must be positive")
+	}
+	if config.SlideMilliseconds < 0 || config.SlideMilliseconds > config.WindowMilliseconds {
+		return fmt.Errorf("slideMilliseconds must be between 0 and windowMilliseconds")
+	}
+	for _, r := range config.Reducers {
+		switch r {
+		case AggregateReducerMean, AggregateReducerMin, AggregateReducerMax, AggregateReducerLast, AggregateReducerCount:
+		default:
+			return fmt.Errorf("unknown reducer: %s", r)
+		}
+	}
+	return nil
+}
+
+type aggregatePoint struct {
+	time  time.Time
+	value float64
+}
+
+type aggregateSeries struct {
+	name   string
+	labels data.Labels
+	points []aggregatePoint
+}
+
+type aggregateWindow struct {
+	end time.Time
+	// values holds the reduced values of each series, indexed by series then reducer.
+	values map[int][]float64
+}
+
+// aggregateState keeps the points of a channel that are still part of an open window.
+type aggregateState struct {
+	mu     sync.Mutex
+	series []*aggregateSeries
+	index  map[string]int
+	// next is the end of the next window to close.
+	next time.Time
+}
+
+func (p *AggregateFrameProcessor) getState(orgID int64, channel string) *aggregateState {
+	key := orgchannel.PrependOrgID(orgID, channel)
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	s, ok := p.states[key]
+	if !ok {
+		s = &aggregateState{index: map[string]int{}}
+		p.states[key] = s
+	}
+	return s
+}
+
+func (p *AggregateFrameProcessor) ProcessFrame(_ context.Context, vars Vars, frame *data.Frame) (*data.Frame, error) {
+	timeIdx := -1
+	for i, f := range frame.Fields {
+		if f.Type() == data.FieldTypeTime || f.Type() == data.FieldTypeNullableTime {
+			timeIdx = i
+			break
+		}
+	}
+
+	state := p.getState(vars.OrgID, vars.Channel)
+	state.mu.Lock()
+	defer state.mu.Unlock()
+
+	var windows []aggregateWindow
+	now := p.nowTimeFunc()
+	for row := 0; row < frame.Rows(); row++ {
+		t := now
+		if timeIdx >= 0 {
+			switch v := frame.Fields[timeIdx].At(row).(type) {
+			case time.Time:
+				t = v
+			case *time.Time:
+				if v == nil {
+					continue
+				}
+				t = *v
+			}
+		}
+
+		if state.next.IsZero() {
+			state.next = t.Truncate(p.slide).Add(p.slide)
+		}
+		windows = append(windows, p.closeWindows(state, t)...)
+		if t.Before(state.next.Add(-p.window)) {
+			// The windows holding this row were already sent.
+			continue
+		}
+
+		for i, f := range frame.Fields {
+			if i == timeIdx || !f.Type().Numeric() {
+				continue
+			}
+			v, err := f.NullableFloatAt(row)
+			if err != nil {
+				return nil, err
+			}
+			if v == nil || math.IsNaN(*v) {
+				continue
+			}
+			s := state.getSeries(f.Name, f.Labels)
+			s.points = append(s.points, aggregatePoint{time: t, value: *v})
+		}
+	}
+
+	if len(windows) == 0 {
+		return nil, nil
+	}
+	return p.windowsToFrame(frame.Name, state, windows), nil
+}
+
+func (s *aggregateState) getSeries(name string, labels data.Labels) *aggregateSeries {
+	key := name + labels.String()
+	if idx, ok := s.index[key]; ok {
+		return s.series[idx]
+	}
+	series := &aggregateSeries{name: name, labels: labels.Copy()}
+	s.index[key] = len(s.series)
+	s.series = append(s.series, series)
+	return series
+}
+
+// closeWindows reduces all windows ending at or before t and removes points that
+// are not part of any open window.
+func (p *AggregateFrameProcessor) closeWindows(state *aggregateState, t time.Time) []aggregateWindow {
+	var windows []aggregateWindow
+	for !t.Before(state.next) {
+		start := state.next.Add(-p.window)
+		w := aggregateWindow{end: state.next, values: map[int][]float64{}}
+		for idx, s := range state.series {
+			var points []aggregatePoint
+			for _, pt := range s.points {
+				if !pt.time.Before(start) && pt.time.Before(state.next) {
+					points = append(points, pt)
+				}
+			}
+			if len(points) > 0 {
+				w.values[idx] = p.reduce(points)
+			}
+		}
+		if len(w.values) > 0 {
+			windows = append(windows, w)
+		}
+
+		state.next = state.next.Add(p.slide)
+		remaining := 0
+		for _, s := range state.series {
+			s.points = dropPointsBefore(s.points, state.next.Add(-p.window))
+			remaining += len(s.points)
+		}
+		if remaining == 0 && !t.Before(state.next) {
+			// Skip the empty windows of a gap in the data.
+			state.next = t.Truncate(p.slide).Add(p.slide)
+		}
+	}
+	return windows
+}
+
+// dropPointsBefore filters in place, points are not sorted as rows may arrive out of order.
+func dropPointsBefore(points []aggregatePoint, t time.Time) []aggregatePoint {
+	kept := points[:0]
+	for _, pt := range points {
+		if !pt.time.Before(t) {
+			kept = append(kept, pt)
+		}
+	}
+	return kept
+}
+
+func (p *AggregateFrameProcessor) reduce(points []aggregatePoint) []float64 {
+	values := make([]float64, len(p.config.Reducers))
+	for i, r := range p.config.Reducers {
+		switch r {
+		case AggregateReducerMean:
+			sum := 0.0
+			for _, pt := range points {
+				sum += pt.value
+			}
+			values[i] = sum / float64(len(points))
+		case AggregateReducerMin:
+			values[i] = points[0].value
+			for _, pt := range points[1:] {
+				values[i] = math.Min(values[i], pt.value)
+			}
+		case AggregateReducerMax:
+			values[i] = points[0].value
+			for _, pt := range points[1:] {
+				values[i] = math.Max(values[i], pt.value)
+			}
+		case AggregateReducerLast:
+			last := points[0]
+			for _, pt := range points[1:] {
+				if !pt.time.Before(last.time) {
+					last = pt
+				}
+			}
+			values[i] = last.value
+		case AggregateReducerCount:
+			values[i] = float64(len(points))
+		}
+	}
+	return values
+}
+
+// windowsToFrame builds a frame with a row for each window. Fields keep the name of
+// the input field when a single reducer is configured, otherwise the reducer is
+// appended to the name, for example value_max.
+func (p *AggregateFrameProcessor) windowsToFrame(name string, state *aggregateState, windows []aggregateWindow) *data.Frame {
+	times := make([]time.Time, len(windows))
+	for i, w := range windows {
+		times[i] = w.end
+	}
+	fields := []*data.Field{data.NewField("time", nil, times)}
+
+	for idx, s := range state.series {
+		found := false
+		for _, w := range windows {
+			if _, ok := w.values[idx]; ok {
+				found = true
+				break
+			}
+		}
+		if !found {
+			continue
+		}
+		for r, reducer := range p.config.Reducers {
+			values := make([]*float64, len(windows))
+			for i, w := range windows {
+				if v, ok := w.values[idx]; ok {
+					values[i] = &v[r]
+				}
+			}
+			fieldName := s.name
+			if len(p.config.Reducers) > 1 {
+				fieldName += "_" + reducer
+			}
+			fields = append(fields, data.NewField(fieldName, s.labels, values))
+		}
+	}
+	return data.NewFrame(name, fields...)
+}
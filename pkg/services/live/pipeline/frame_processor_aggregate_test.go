@@ -0,0 +1,139 @@
+package pipeline
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/require"
+)
+
+func aggregateTestFrame(t time.Time, value float64) *data.Frame {
+	return data.NewFrame("sensor",
+		data.NewField("time", nil, []time.Time{t}),
+		data.NewField("value", data.Labels{"sensor": "a"}, []float64{value}),
+		data.NewField("state", nil, []string{"ok"}),
+	)
+}
+
+func TestAggregateFrameProcessor_Tumbling(t *testing.T) {
+	p := NewAggregateFrameProcessor(AggregateFrameProcessorConfig{
+		WindowMilliseconds: 1000,
+		Reducers:           []string{AggregateReducerMean, AggregateReducerMax, AggregateReducerCount},
+	})
+	vars := Vars{OrgID: 1, Channel: "stream/sensors/a"}
+	start := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	for i, v := range []float64{1, 2, 3} {
+		frame, err := p.ProcessFrame(context.Background(), vars, aggregateTestFrame(start.Add(time.Duration(i)*300*time.Millisecond), v))
+		require.NoError(t, err)
+		require.Nil(t, frame)
+	}
+
+	frame, err := p.ProcessFrame(context.Background(), vars, aggregateTestFrame(start.Add(1100*time.Millisecond), 10))
+	require.NoError(t, err)
+	require.NotNil(t, frame)
+	require.Equal(t, 1, frame.Rows())
+	require.Len(t, frame.Fields, 4)
+	require.Equal(t, start.Add(time.Second), frame.Fields[0].At(0))
+	require.Equal(t, "value_mean", frame.Fields[1].Name)
+	require.Equal(t, data.Labels{"sensor": "a"}, frame.Fields[1].Labels)
+	require.Equal(t, 2.0, *frame.Fields[1].At(0).(*float64))
+	require.Equal(t, 3.0, *frame.Fields[2].At(0).(*float64))
+	require.Equal(t, 3.0, *frame.Fields[3].At(0).(*float64))
+
+	// A late row for the sent window is dropped and a gap skips empty windows.
+	frame, err = p.ProcessFrame(context.Background(), vars, aggregateTestFrame(start.Add(500*time.Millisecond), 100))
+	require.NoError(t, err)
+	require.Nil(t, frame)
+
+	frame, err = p.ProcessFrame(context.Background(), vars, aggregateTestFrame(start.Add(time.Hour), 1))
+	require.NoError(t, err)
+	require.Equal(t, 1, frame.Rows())
+	require.Equal(t, start.Add(2*time.Second), frame.Fields[0].At(0))
+	require.Equal(t, 10.0, *frame.Fields[1].At(0).(*float64))
+
+	// Other channels have their own windows.
+	frame, err = p.ProcessFrame(context.Background(), Vars{OrgID: 1, Channel: "stream/sensors/b"}, aggregateTestFrame(start.Add(2*time.Hour), 1))
+	require.NoError(t, err)
+	require.Nil(t, frame)
+}
+
+func TestAggregateFrameProcessor_Sliding(t *testing.T) {
+	p := NewAggregateFrameProcessor(AggregateFrameProcessorConfig{
+		WindowMilliseconds: 2000,
+		SlideMilliseconds:  1000,
+		Reducers:           []string{AggregateReducerLast},
+	})
+	vars := Vars{OrgID: 1, Channel: "stream/sensors/a"}
+	start := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	frame := data.NewFrame("sensor",
+		data.NewField("time", nil, []time.Time{start, start.Add(1500 * time.Millisecond), start.Add(2500 * time.Millisecond)}),
+		data.NewField("value", nil, []float64{1, 2, 3}),
+	)
+	out, err := p.ProcessFrame(context.Background(), vars, frame)
+	require.NoError(t, err)
+	require.Equal(t, 2, out.Rows())
+	require.Equal(t, "value", out.Fields[1].Name)
+	require.Equal(t, start.Add(time.Second), out.Fields[0].At(0))
+	require.Equal(t, 1.0, *out.Fields[1].At(0).(*float64))
+	require.Equal(t, start.Add(2*time.Second), out.Fields[0].At(1))
+	require.Equal(t, 2.0, *out.Fields[1].At(1).(*float64))
+
+	out, err = p.ProcessFrame(context.Background(), vars, aggregateTestFrame(start.Add(3*time.Second), 4))
+	require.NoError(t, err)
+	require.Equal(t, 1, out.Rows())
+	// The window from 1s to 3s holds the rows at 1.5s and 2.5s.
+	require.Equal(t, 3.0, *out.Fields[1].At(0).(*float64))
+}
+
+func TestAggregateFrameProcessor_Concurrent(t *testing.T) {
+	p := NewAggregateFrameProcessor(AggregateFrameProcessorConfig{
+		WindowMilliseconds: 1000,
+		Reducers:           []string{AggregateReducerCount},
+	})
+	start := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, err := p.ProcessFrame(context.Background(), Vars{OrgID: 1, Channel: "stream/sensors/a"}, aggregateTestFrame(start.Add(time.Duration(i)*time.Millisecond), 1))
+			require.NoError(t, err)
+		}(i)
+	}
+	wg.Wait()
+
+	frame, err := p.ProcessFrame(context.Background(), Vars{OrgID: 1, Channel: "stream/sensors/a"}, aggregateTestFrame(start.Add(time.Second), 1))
+	require.NoError(t, err)
+	require.Equal(t, 100.0, *frame.Fields[1].At(0).(*float64))
+}
+
+func TestValidateAggregateFrameProcessorConfig(t *testing.T) {
+	require.NoError(t, validateAggregateFrameProcessorConfig(AggregateFrameProcessorConfig{WindowMilliseconds: 1000}))
+	require.Error(t, validateAggregateFrameProcessorConfig(AggregateFrameProcessorConfig{}))
+	require.Error(t, validateAggregateFrameProcessorConfig(AggregateFrameProcessorConfig{WindowMilliseconds: 1000, SlideMilliseconds: 2000}))
+	require.Error(t, validateAggregateFrameProcessorConfig(AggregateFrameProcessorConfig{WindowMilliseconds: 1000, Reducers: []string{"median"}}))
+}
+
+func TestAggregateFrameProcessor_KeptOnRebuild(t *testing.T) {
+	builder := &StorageRuleBuilder{}
+	config := &FrameProcessorConfig{
+		Type:                     FrameProcessorTypeAggregate,
+		AggregateProcessorConfig: &AggregateFrameProcessorConfig{WindowMilliseconds: 1000},
+	}
+	p1, err := builder.extractFrameProcessor(config)
+	require.NoError(t, err)
+	p2, err := builder.extractFrameProcessor(config)
+	require.NoError(t, err)
+	require.Same(t, p1, p2)
+
+	config.AggregateProcessorConfig.WindowMilliseconds = 2000
+	p3, err := builder.extractFrameProcessor(config)
+	require.NoError(t, err)
+	require.NotSame(t, p1, p3)
+}
@@ -0,0 +1,62 @@
+package pipeline
+
+import (
+	"context"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+// ComputeFieldFrameProcessor adds a field calculated with a math expression
+// from other fields of a data.Frame, for example "$temp_c * 9/5 + 32".
+type ComputeFieldFrameProcessor struct {
+	config ComputeFieldFrameProcessorConfig
+	expr   *frameExpression
+}
+
+func NewComputeFieldFrameProcessor(config ComputeFieldFrameProcessorConfig) (*ComputeFieldFrameProcessor, error) {
+	expr, err := newFrameExpression(config.Expression)
+	if err != nil {
+		return nil, err
+	}
+	return &ComputeFieldFrameProcessor{config: config, expr: expr}, nil
+}
+
+const FrameProcessorTypeComputeField = "computeField"
+
+func (p *ComputeFieldFrameProcessor) Type() string {
+	return FrameProcessorTypeComputeField
+}
+
+func (p *ComputeFieldFrameProcessor) ProcessFrame(_ context.Context, _ Vars, frame *data.Frame) (*data.Frame, error) {
+	rows := frame.Rows()
+
+	// One field is added for each label set returned by the expression.
+	var fields []*data.Field
+	fieldIndex := map[string]int{}
+	for row := 0; row < rows; row++ {
+		values, err := p.expr.executeRow(frame, row)
+		if err != nil {
+			return nil, err
+		}
+		for _, v := range values {
+			value, err := expressionValue(v)
+			if err != nil {
+				return nil, err
+			}
+			labels := v.GetLabels()
+			key := labels.String()
+			idx, ok := fieldIndex[key]
+			if !ok {
+				field := data.NewField(p.config.FieldName, labels, make([]*float64, rows))
+				field.Config = p.config.Config
+				idx = len(fields)
+				fieldIndex[key] = idx
+				fields = append(fields, field)
+			}
+			fields[idx].Set(row, value)
+		}
+	}
+
+	frame.Fields = append(frame.Fields, fields...)
+	return frame, nil
+}
@@ -0,0 +1,87 @@
+package pipeline
+
+import (
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+// metricSample is a single float sample read by the Prometheus and OTLP converters.
+type metricSample struct {
+	// family is the metric family the sample belongs to, for example
+	// http_request_duration_seconds for http_request_duration_seconds_bucket.
+	family string
+	name   string
+	labels data.Labels
+	time   time.Time
+	value  float64
+}
+
+type metricFrameKey struct {
+	family string
+	time   int64
+}
+
+type metricFrameBuilder struct {
+	time   time.Time
+	fields []*data.Field
+	// fieldIndex maps series name and labels to the field index.
+	fieldIndex map[string]int
+}
+
+// metricSamplesToChannelFrames groups samples into one frame for each metric family
+// and time, with a value field for each series. Channel is constructed from
+// original channel + / + <metric_family>.
+func metricSamplesToChannelFrames(channel string, samples []metricSample) []*ChannelFrame {
+	// maintain the order of frames as they appear in input.
+	var keyOrder []metricFrameKey
+	builders := map[metricFrameKey]*metricFrameBuilder{}
+
+	for _, s := range samples {
+		key := metricFrameKey{family: s.family, time: s.time.UnixNano()}
+		b, ok := builders[key]
+		if !ok {
+			b = &metricFrameBuilder{
+				time:       s.time,
+				fieldIndex: map[string]int{},
+			}
+			builders[key] = b
+			keyOrder = append(keyOrder, key)
+		}
+
+		seriesKey := s.name + s.labels.String()
+		if idx, ok := b.fieldIndex[seriesKey]; ok {
+			// Last sample wins when a series is repeated at the same time.
+			b.fields[idx].Set(0, s.value)
+			continue
+		}
+		b.fieldIndex[seriesKey] = len(b.fields)
+		b.fields = append(b.fields, data.NewField(s.name, s.labels, []float64{s.value}))
+	}
+
+	channelFrames := make([]*ChannelFrame, 0, len(keyOrder))
+	for _, key := range keyOrder {
+		b := builders[key]
+		fields := append([]*data.Field{data.NewField("time", nil, []time.Time{b.time})}, b.fields...)
+		channelFrames = append(channelFrames, &ChannelFrame{
+			Channel: channel + "/" + metricChannelPath(key.family),
+			Frame:   data.NewFrame(key.family, fields...),
+		})
+	}
+	return channelFrames
+}
+
+// metricChannelPath replaces characters that are not allowed in a channel path,
+// such as the colons used by Prometheus recording rules.
+func metricChannelPath(name string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		case r == '_', r == '-', r == '.', r == '=':
+			return r
+		}
+		return '_'
+	}, name)
+}
@@ -184,6 +184,14 @@ type Pipeline struct {
 	tracer     trace.Tracer
 }
 
+// Close releases the resources held by the rules, for example writing the
+// pending batches of archive outputs.
+func (p *Pipeline) Close() {
+	if c, ok := p.ruleGetter.(interface{ Close() }); ok {
+		c.Close()
+	}
+}
+
 // New creates new Pipeline.
 func New(ruleGetter ChannelRuleGetter) (*Pipeline, error) {
 	p := &Pipeline{
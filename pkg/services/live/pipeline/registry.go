@@ -48,6 +48,15 @@ var FrameOutputsRegistry = []EntityInfo{
 		Type:        FrameOutputTypeLoki,
 		Description: "output frame as JSON to Loki",
 	},
+	{
+		Type:        FrameOutputTypeArchive,
+		Description: "archive frames to rotated Arrow or Parquet files",
+		Example: ArchiveOutputConfig{
+			BucketURL:     "s3://live-archive?region=us-east-1",
+			Format:        ArchiveFormatParquet,
+			RetentionDays: 365,
+		},
+	},
 }
 
 var ConvertersRegistry = []EntityInfo{
@@ -66,6 +75,18 @@ var ConvertersRegistry = []EntityInfo{
 		Type:        ConverterTypeJsonFrame,
 		Description: "JSON-encoded Grafana data frame",
 	},
+	{
+		Type:        ConverterTypePrometheusText,
+		Description: "accept Prometheus text exposition or OpenMetrics",
+		Example:     PrometheusTextConverterConfig{},
+	},
+	{
+		Type:        ConverterTypeOtlpMetrics,
+		Description: "accept OTLP/HTTP metrics protobuf",
+		Example: OtlpMetricsConverterConfig{
+			ResourceAttributes: []string{"service.name", "service.instance.id"},
+		},
+	},
 }
 
 var FrameProcessorsRegistry = []EntityInfo{
@@ -79,6 +100,23 @@ var FrameProcessorsRegistry = []EntityInfo{
 		Description: "list the fields that should be removed",
 		Example:     DropFieldsFrameProcessorConfig{},
 	},
+	{
+		Type:        FrameProcessorTypeComputeField,
+		Description: "add a field calculated with a math expression",
+		Example: ComputeFieldFrameProcessorConfig{
+			FieldName:  "temp_f",
+			Expression: "$temp_c * 9/5 + 32",
+		},
+	},
+	{
+		Type:        FrameProcessorTypeAggregate,
+		Description: "aggregate numeric fields over tumbling or sliding windows",
+		Example: AggregateFrameProcessorConfig{
+			WindowMilliseconds: 10000,
+			SlideMilliseconds:  1000,
+			Reducers:           []string{AggregateReducerMean, AggregateReducerMax},
+		},
+	},
 }
 
 var DataOutputsRegistry = []EntityInfo{
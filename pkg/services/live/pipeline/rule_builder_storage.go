@@ -2,7 +2,11 @@ package pipeline
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"sync"
 
 	"github.com/centrifugal/centrifuge"
 
@@ -17,6 +21,89 @@ type StorageRuleBuilder struct {
 	Storage              Storage
 	ChannelHandlerGetter ChannelHandlerGetter
 	SecretsService       secrets.Service
+
+	// Rules are periodically rebuilt from storage. Entities keeping state between
+	// frames are cached by configuration so that rebuilding does not reset them.
+	// Entities no longer used by any organization are closed after a rebuild.
+	buildMu    sync.Mutex
+	statefulMu sync.Mutex
+	stateful   map[string]*statefulEntry
+	building   map[string]struct{}
+	closed     bool
+}
+
+type statefulEntry struct {
+	value any
+	orgs  map[int64]struct{}
+}
+
+// getStateful returns the entity previously created for the same type and
+// configuration, or creates a new one.
+func (f *StorageRuleBuilder) getStateful(typ string, config any, create func() (any, error)) (any, error) {
+	jsonConfig, err := json.Marshal(config)
+	if err != nil {
+		return nil, err
+	}
+	key := typ + string(jsonConfig)
+
+	f.statefulMu.Lock()
+	defer f.statefulMu.Unlock()
+	if f.closed {
+		return nil, errors.New("rule builder is closed")
+	}
+	if f.building != nil {
+		f.building[key] = struct{}{}
+	}
+	if e, ok := f.stateful[key]; ok {
+		return e.value, nil
+	}
+	e, err := create()
+	if err != nil {
+		return nil, err
+	}
+	if f.stateful == nil {
+		f.stateful = map[string]*statefulEntry{}
+	}
+	f.stateful[key] = &statefulEntry{value: e, orgs: map[int64]struct{}{}}
+	return e, nil
+}
+
+// releaseStateful records the entities used by the rules just built for orgID
+// and closes the ones no organization uses anymore. Must be called with
+// f.statefulMu held.
+func (f *StorageRuleBuilder) releaseStateful(orgID int64, used map[string]struct{}) {
+	for key, e := range f.stateful {
+		if _, ok := used[key]; ok {
+			e.orgs[orgID] = struct{}{}
+			continue
+		}
+		delete(e.orgs, orgID)
+		if len(e.orgs) > 0 {
+			continue
+		}
+		delete(f.stateful, key)
+		closeStateful(e.value)
+	}
+}
+
+// Close closes all stateful entities, for example writing the pending batches
+// of archive outputs. Rules can not be built after Close.
+func (f *StorageRuleBuilder) Close() {
+	f.statefulMu.Lock()
+	defer f.statefulMu.Unlock()
+	f.closed = true
+	for key, e := range f.stateful {
+		delete(f.stateful, key)
+		closeStateful(e.value)
+	}
+}
+
+func closeStateful(e any) {
+	if c, ok := e.(io.Closer); ok {
+		if err := c.Close(); err != nil {
+			logger.Error("Error closing replaced pipeline entity", "error", err)
+		}
+	}
 }
 
 func (f *StorageRuleBuilder) extractSubscriber(config *SubscriberConfig) (Subscriber, error) {
@@ -69,6 +156,16 @@ func (f *StorageRuleBuilder) extractConverter(config *ConverterConfig) (Converte
 			return nil, missingConfiguration
 		}
 		return NewAutoInfluxConverter(*config.AutoInfluxConverterConfig), nil
+	case ConverterTypePrometheusText:
+		if config.PrometheusTextConverterConfig == nil {
+			config.PrometheusTextConverterConfig = &PrometheusTextConverterConfig{}
+		}
+		return NewPrometheusTextConverter(*config.PrometheusTextConverterConfig), nil
+	case ConverterTypeOtlpMetrics:
+		if config.OtlpMetricsConverterConfig == nil {
+			config.OtlpMetricsConverterConfig = &OtlpMetricsConverterConfig{}
+		}
+		return NewOtlpMetricsConverter(*config.OtlpMetricsConverterConfig), nil
 	default:
 		return nil, fmt.Errorf("unknown converter type: %s", config.Type)
 	}
@@ -90,6 +187,25 @@ func (f *StorageRuleBuilder) extractFrameProcessor(config *FrameProcessorConfig)
 			return nil, missingConfiguration
 		}
 		return NewKeepFieldsFrameProcessor(*config.KeepFieldsProcessorConfig), nil
+	case FrameProcessorTypeComputeField:
+		if config.ComputeFieldProcessorConfig == nil {
+			return nil, missingConfiguration
+		}
+		return NewComputeFieldFrameProcessor(*config.ComputeFieldProcessorConfig)
+	case FrameProcessorTypeAggregate:
+		if config.AggregateProcessorConfig == nil {
+			return nil, missingConfiguration
+		}
+		if err := validateAggregateFrameProcessorConfig(*config.AggregateProcessorConfig); err != nil {
+			return nil, err
+		}
+		p, err := f.getStateful(config.Type, config.AggregateProcessorConfig, func() (any, error) {
+			return NewAggregateFrameProcessor(*config.AggregateProcessorConfig), nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		return p.(*AggregateFrameProcessor), nil
 	case FrameProcessorTypeMultiple:
 		if config.MultipleProcessorConfig == nil {
 			return nil, missingConfiguration
@@ -121,6 +237,11 @@ func (f *StorageRuleBuilder) extractFrameConditionChecker(config *FrameCondition
 		}
 		c := *config.NumberCompareConditionConfig
 		return NewFrameNumberCompareCondition(c.FieldName, c.Op, c.Value), nil
+	case FrameConditionCheckerTypeExpression:
+		if config.ExpressionConditionConfig == nil {
+			return nil, missingConfiguration
+		}
+		return NewFrameExpressionCondition(config.ExpressionConditionConfig.Expression)
 	case FrameConditionCheckerTypeMultiple:
 		var conditions []FrameConditionChecker
 		if config.MultipleConditionCheckerConfig == nil {
@@ -249,6 +370,18 @@ func (f *StorageRuleBuilder) extractFrameOutputter(config *FrameOutputterConfig,
 			return nil, missingConfiguration
 		}
 		return NewChangeLogFrameOutput(f.FrameStorage, *config.ChangeLogOutputConfig), nil
+	case FrameOutputTypeArchive:
+		if config.ArchiveOutputConfig == nil {
+			return nil, missingConfiguration
+		}
+		// Reusing the output keeps batches which are not written to a file yet.
+		out, err := f.getStateful(config.Type, config.ArchiveOutputConfig, func() (any, error) {
+			return NewArchiveFrameOutput(*config.ArchiveOutputConfig)
+		})
+		if err != nil {
+			return nil, err
+		}
+		return out.(*ArchiveFrameOutput), nil
 	default:
 		return nil, fmt.Errorf("unknown output type: %s", config.Type)
 	}
@@ -300,6 +433,32 @@ func (f *StorageRuleBuilder) getWriteConfig(uid string, writeConfigs []WriteConf
 }
 
 func (f *StorageRuleBuilder) BuildRules(ctx context.Context, orgID int64) ([]*LiveChannelRule, error) {
+	f.buildMu.Lock()
+	defer f.buildMu.Unlock()
+
+	f.statefulMu.Lock()
+	if f.closed {
+		// Nothing is routed through a closed builder.
+		f.statefulMu.Unlock()
+		return nil, nil
+	}
+	f.building = map[string]struct{}{}
+	f.statefulMu.Unlock()
+
+	rules, err := f.buildRules(ctx, orgID)
+
+	f.statefulMu.Lock()
+	defer f.statefulMu.Unlock()
+	used := f.building
+	f.building = nil
+	if err != nil {
+		return nil, err
+	}
+	f.releaseStateful(orgID, used)
+	return rules, nil
+}
+
+func (f *StorageRuleBuilder) buildRules(ctx context.Context, orgID int64) ([]*LiveChannelRule, error) {
 	channelRules, err := f.Storage.ListChannelRules(ctx, orgID)
 	if err != nil {
 		return nil, err
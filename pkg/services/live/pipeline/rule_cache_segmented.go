@@ -59,6 +59,13 @@ func (s *CacheSegmentedTree) fillOrg(orgID int64) error {
 	return nil
 }
 
+// Close closes the rule builder when it holds resources.
+func (s *CacheSegmentedTree) Close() {
+	if c, ok := s.ruleBuilder.(interface{ Close() }); ok {
+		c.Close()
+	}
+}
+
 func (s *CacheSegmentedTree) Get(orgID int64, channel string) (*LiveChannelRule, bool, error) {
 	s.radixMu.RLock()
 	_, ok := s.radix[orgID]
@@ -12,6 +12,7 @@ import (
 	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
 	"github.com/grafana/grafana/pkg/services/live"
 	"github.com/grafana/grafana/pkg/services/live/convert"
+	"github.com/grafana/grafana/pkg/services/live/pushtoken"
 	"github.com/grafana/grafana/pkg/services/live/pushurl"
 	"github.com/grafana/grafana/pkg/setting"
 	"github.com/grafana/grafana/pkg/web"
@@ -46,9 +47,41 @@ func (g *Gateway) Run(ctx context.Context) error {
 }
 
 func (g *Gateway) Handle(ctx *contextmodel.ReqContext) {
+	g.handle(ctx, ctx.OrgID, nil)
+}
+
+// HandleTokenPush handles pushes authenticated with a publish token instead of
+// a signed in user. Frames are only pushed to channels allowed by the token.
+func (g *Gateway) HandleTokenPush(ctx *contextmodel.ReqContext) {
+	publisher, err := g.GrafanaLive.PublishTokens.Authenticate(ctx.Req.Context(), ctx.Req.Header.Get(pushtoken.HeaderName))
+	if err != nil {
+		if errors.Is(err, pushtoken.ErrInvalidToken) {
+			ctx.Resp.WriteHeader(http.StatusUnauthorized)
+		} else {
+			logger.Error("Error authenticating publish token", "error", err)
+			ctx.Resp.WriteHeader(http.StatusInternalServerError)
+		}
+		return
+	}
+	if err := publisher.Allow(ctx.Req.Context()); err != nil {
+		switch {
+		case errors.Is(err, pushtoken.ErrRateLimitExceeded):
+			ctx.Resp.WriteHeader(http.StatusTooManyRequests)
+		case errors.Is(err, pushtoken.ErrInvalidToken):
+			ctx.Resp.WriteHeader(http.StatusUnauthorized)
+		default:
+			logger.Error("Error authenticating publish token", "error", err)
+			ctx.Resp.WriteHeader(http.StatusInternalServerError)
+		}
+		return
+	}
+	g.handle(ctx, publisher.OrgID(), publisher)
+}
+
+func (g *Gateway) handle(ctx *contextmodel.ReqContext, orgID int64, publisher *pushtoken.Publisher) {
 	streamID := web.Params(ctx.Req)[":streamId"]
 
-	stream, err := g.GrafanaLive.ManagedStreamRunner.GetOrCreateStream(ctx.OrgID, liveDto.ScopeStream, streamID)
+	stream, err := g.GrafanaLive.ManagedStreamRunner.GetOrCreateStream(orgID, liveDto.ScopeStream, streamID)
 	if err != nil {
 		logger.Error("Error getting stream", "error", err)
 		ctx.Resp.WriteHeader(http.StatusInternalServerError)
@@ -83,6 +116,18 @@ func (g *Gateway) Handle(ctx *contextmodel.ReqContext) {
 		return
 	}
 
+	if publisher != nil {
+		// Reject the whole request so that a device does not publish partially.
+		for _, mf := range metricFrames {
+			channel := liveDto.Channel{Scope: liveDto.ScopeStream, Namespace: streamID, Path: mf.Key()}.String()
+			if err := publisher.CanPublish(channel); err != nil {
+				logger.Debug("Push rejected", "error", err, "tokenId", publisher.TokenID())
+				ctx.Resp.WriteHeader(http.StatusForbidden)
+				return
+			}
+		}
+	}
+
 	// TODO -- make sure all packets are combined together!
 	// interval = "1s" vs flush_interval = "5s"
 
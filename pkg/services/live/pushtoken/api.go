@@ -0,0 +1,66 @@
+package pushtoken
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/middleware"
+	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
+	"github.com/grafana/grafana/pkg/web"
+)
+
+// RegisterAPIEndpoints registers the endpoints to manage the publish tokens of an org.
+func (s *Service) RegisterAPIEndpoints(routeRegister routing.RouteRegister) {
+	routeRegister.Group("/api/live/publish-tokens", func(group routing.RouteRegister) {
+		group.Get("/", routing.Wrap(s.listHandler))
+		group.Post("/", routing.Wrap(s.createHandler))
+		group.Delete("/:id", routing.Wrap(s.deleteHandler))
+	}, middleware.ReqOrgAdmin)
+}
+
+func (s *Service) listHandler(c *contextmodel.ReqContext) response.Response {
+	tokens, err := s.List(c.Req.Context(), c.GetOrgID())
+	if err != nil {
+		return response.Error(http.StatusInternalServerError, "Failed to list publish tokens", err)
+	}
+	return response.JSON(http.StatusOK, tokens)
+}
+
+func (s *Service) createHandler(c *contextmodel.ReqContext) response.Response {
+	cmd := CreatePublishTokenCommand{}
+	if err := web.Bind(c.Req, &cmd); err != nil {
+		return response.Error(http.StatusBadRequest, "bad request data", err)
+	}
+	cmd.OrgID = c.GetOrgID()
+	cmd.CreatedBy, _ = c.GetInternalID()
+
+	result, err := s.Create(c.Req.Context(), cmd)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrTokenNameTaken):
+			return response.Error(http.StatusConflict, err.Error(), err)
+		case errors.Is(err, ErrNameRequired), errors.Is(err, ErrNoPatterns),
+			errors.Is(err, ErrInvalidPattern), errors.Is(err, ErrInvalidRateLimit):
+			return response.Error(http.StatusBadRequest, err.Error(), err)
+		}
+		return response.Error(http.StatusInternalServerError, "Failed to create publish token", err)
+	}
+	return response.JSON(http.StatusOK, result)
+}
+
+func (s *Service) deleteHandler(c *contextmodel.ReqContext) response.Response {
+	id, err := strconv.ParseInt(web.Params(c.Req)[":id"], 10, 64)
+	if err != nil {
+		return response.Error(http.StatusBadRequest, "id is invalid", err)
+	}
+	if err := s.Delete(c.Req.Context(), c.GetOrgID(), id); err != nil {
+		if errors.Is(err, ErrTokenNotFound) {
+			return response.Error(http.StatusNotFound, "Publish token not found", err)
+		}
+		return response.Error(http.StatusInternalServerError, "Failed to delete publish token", err)
+	}
+	return response.Success("Publish token deleted")
+}
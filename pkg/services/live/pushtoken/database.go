@@ -0,0 +1,80 @@
+package pushtoken
+
+import (
+	"context"
+	"time"
+
+	"github.com/grafana/grafana/pkg/infra/db"
+)
+
+type store interface {
+	insert(ctx context.Context, token *publishToken) error
+	list(ctx context.Context, orgID int64) ([]*publishToken, error)
+	getByHash(ctx context.Context, hash string) (*publishToken, error)
+	delete(ctx context.Context, orgID, id int64) error
+	updateLastUsed(ctx context.Context, id int64, lastUsedAt time.Time) error
+}
+
+type sqlStore struct {
+	db db.DB
+}
+
+func (s *sqlStore) insert(ctx context.Context, token *publishToken) error {
+	return s.db.WithDbSession(ctx, func(sess *db.Session) error {
+		exists, err := sess.Where("org_id = ? AND name = ?", token.OrgID, token.Name).Exist(&publishToken{})
+		if err != nil {
+			return err
+		}
+		if exists {
+			return ErrTokenNameTaken
+		}
+		_, err = sess.Insert(token)
+		return err
+	})
+}
+
+func (s *sqlStore) list(ctx context.Context, orgID int64) ([]*publishToken, error) {
+	tokens := make([]*publishToken, 0)
+	err := s.db.WithDbSession(ctx, func(sess *db.Session) error {
+		return sess.Where("org_id = ?", orgID).Asc("name").Find(&tokens)
+	})
+	return tokens, err
+}
+
+func (s *sqlStore) getByHash(ctx context.Context, hash string) (*publishToken, error) {
+	var token publishToken
+	err := s.db.WithDbSession(ctx, func(sess *db.Session) error {
+		has, err := sess.Where("token_hash = ?", hash).Get(&token)
+		if err != nil {
+			return err
+		}
+		if !has {
+			return ErrTokenNotFound
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+func (s *sqlStore) delete(ctx context.Context, orgID, id int64) error {
+	return s.db.WithDbSession(ctx, func(sess *db.Session) error {
+		affected, err := sess.Where("org_id = ? AND id = ?", orgID, id).Delete(&publishToken{})
+		if err != nil {
+			return err
+		}
+		if affected == 0 {
+			return ErrTokenNotFound
+		}
+		return nil
+	})
+}
+
+func (s *sqlStore) updateLastUsed(ctx context.Context, id int64, lastUsedAt time.Time) error {
+	return s.db.WithDbSession(ctx, func(sess *db.Session) error {
+		_, err := sess.ID(id).Cols("last_used_at").Update(&publishToken{LastUsedAt: &lastUsedAt})
+		return err
+	})
+}
@@ -0,0 +1,54 @@
+package pushtoken
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/tests/testsuite"
+	"github.com/grafana/grafana/pkg/util/testutil"
+)
+
+func TestMain(m *testing.M) {
+	testsuite.Run(m)
+}
+
+func TestIntegrationPublishTokenStore(t *testing.T) {
+	testutil.SkipIntegrationTestInShortMode(t)
+
+	s := NewService(db.InitTestDB(t))
+	ctx := context.Background()
+
+	result, err := s.Create(ctx, CreatePublishTokenCommand{
+		OrgID:     1,
+		Name:      "factory-7",
+		Patterns:  []string{"stream/factory-7/*"},
+		RateLimit: 10,
+		CreatedBy: 1,
+	})
+	require.NoError(t, err)
+	require.Equal(t, 10, result.Burst)
+
+	_, err = s.Create(ctx, CreatePublishTokenCommand{OrgID: 1, Name: "factory-7", Patterns: []string{"stream/x"}})
+	require.ErrorIs(t, err, ErrTokenNameTaken)
+
+	publisher, err := s.Authenticate(ctx, result.Key)
+	require.NoError(t, err)
+	require.Equal(t, result.ID, publisher.TokenID())
+
+	tokens, err := s.List(ctx, 1)
+	require.NoError(t, err)
+	require.Len(t, tokens, 1)
+	require.Equal(t, []string{"stream/factory-7/*path"}, tokens[0].Patterns)
+
+	tokens, err = s.List(ctx, 2)
+	require.NoError(t, err)
+	require.Empty(t, tokens)
+
+	require.ErrorIs(t, s.Delete(ctx, 2, result.ID), ErrTokenNotFound)
+	require.NoError(t, s.Delete(ctx, 1, result.ID))
+	_, err = s.Authenticate(ctx, result.Key)
+	require.ErrorIs(t, err, ErrInvalidToken)
+}
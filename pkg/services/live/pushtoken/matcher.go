@@ -0,0 +1,50 @@
+package pushtoken
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/grafana/grafana/pkg/services/live/pipeline/tree"
+)
+
+// normalizePattern converts a trailing * to a named catch-all parameter,
+// so that stream/factory-7/* can be used instead of stream/factory-7/*path.
+func normalizePattern(pattern string) string {
+	pattern = strings.Trim(strings.TrimSpace(pattern), "/")
+	if strings.HasSuffix(pattern, "/*") || pattern == "*" {
+		pattern += "path"
+	}
+	return pattern
+}
+
+// newMatcher builds a pattern tree in the same way as the channel rule cache.
+func newMatcher(patterns []string) (matcher *tree.Node, err error) {
+	if len(patterns) == 0 {
+		return nil, ErrNoPatterns
+	}
+	defer func() {
+		// The tree panics on malformed or conflicting patterns.
+		if r := recover(); r != nil {
+			matcher = nil
+			err = fmt.Errorf("%w: %v", ErrInvalidPattern, r)
+		}
+	}()
+
+	matcher = tree.New()
+	seen := map[string]struct{}{}
+	for _, p := range patterns {
+		if p == "" {
+			return nil, fmt.Errorf("%w: empty pattern", ErrInvalidPattern)
+		}
+		if _, ok := seen[p]; ok {
+			continue
+		}
+		seen[p] = struct{}{}
+		matcher.AddRoute("/"+p, p)
+	}
+	return matcher, nil
+}
+
+func matches(matcher *tree.Node, channel string) bool {
+	return matcher.GetValue("/"+channel, true).Handler != nil
+}
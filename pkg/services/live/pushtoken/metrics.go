@@ -0,0 +1,32 @@
+package pushtoken
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const (
+	resultOK             = "ok"
+	resultInvalid        = "invalid"
+	resultForbidden      = "forbidden"
+	resultRateLimited    = "rate_limited"
+	metricsNamespace     = "grafana"
+	metricsSubsystem     = "live"
+	invalidTokenMetricID = ""
+)
+
+var (
+	requestsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Subsystem: metricsSubsystem,
+		Name:      "publish_token_requests_total",
+		Help:      "Number of push messages authenticated with a publish token by result.",
+	}, []string{"token_id", "result"})
+
+	lastUsedTimestamp = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Subsystem: metricsSubsystem,
+		Name:      "publish_token_last_used_timestamp_seconds",
+		Help:      "Time a publish token was last used to push a message.",
+	}, []string{"token_id"})
+)
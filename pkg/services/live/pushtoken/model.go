@@ -0,0 +1,69 @@
+package pushtoken
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	ErrTokenNotFound     = errors.New("publish token not found")
+	ErrTokenNameTaken    = errors.New("publish token with the same name already exists")
+	ErrInvalidToken      = errors.New("invalid publish token")
+	ErrChannelNotAllowed = errors.New("publish token does not allow the channel")
+	ErrRateLimitExceeded = errors.New("publish token rate limit exceeded")
+	ErrInvalidPattern    = errors.New("invalid channel pattern")
+	ErrNoPatterns        = errors.New("at least one channel pattern is required")
+	ErrInvalidRateLimit  = errors.New("rate limit and burst must not be negative")
+	ErrNameRequired      = errors.New("publish token name is required")
+)
+
+// PublishToken is a credential that allows publishing to Live channels matching
+// its patterns without a signed in user. Patterns use the channel rule syntax,
+// for example stream/factory-7/*path or stream/:site/temperature.
+type PublishToken struct {
+	ID         int64      `json:"id"`
+	OrgID      int64      `json:"orgId"`
+	Name       string     `json:"name"`
+	Patterns   []string   `json:"patterns"`
+	RateLimit  float64    `json:"rateLimit"`
+	Burst      int        `json:"burst"`
+	CreatedBy  int64      `json:"createdBy"`
+	Created    time.Time  `json:"created"`
+	LastUsedAt *time.Time `json:"lastUsedAt,omitempty"`
+}
+
+// CreatePublishTokenCommand creates a publish token. RateLimit is the number
+// of messages per second a token may publish, zero means unlimited. Burst
+// defaults to the rate limit rounded up.
+type CreatePublishTokenCommand struct {
+	Name      string   `json:"name"`
+	Patterns  []string `json:"patterns"`
+	RateLimit float64  `json:"rateLimit"`
+	Burst     int      `json:"burst"`
+
+	OrgID     int64 `json:"-"`
+	CreatedBy int64 `json:"-"`
+}
+
+// CreatePublishTokenResult contains the token key, it is only returned once.
+type CreatePublishTokenResult struct {
+	PublishToken
+	Key string `json:"key"`
+}
+
+type publishToken struct {
+	ID         int64 `xorm:"pk autoincr 'id'"`
+	OrgID      int64 `xorm:"org_id"`
+	Name       string
+	TokenHash  string `xorm:"token_hash"`
+	Patterns   string
+	RateLimit  float64 `xorm:"rate_limit"`
+	Burst      int
+	CreatedBy  int64 `xorm:"created_by"`
+	Created    time.Time
+	LastUsedAt *time.Time `xorm:"last_used_at"`
+}
+
+func (t publishToken) TableName() string {
+	return "live_publish_token"
+}
@@ -0,0 +1,353 @@
+package pushtoken
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/time/rate"
+
+	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/live/pipeline/tree"
+)
+
+// HeaderName is the request header carrying the publish token key.
+const HeaderName = "X-Grafana-Live-Token"
+
+const (
+	keyPrefix = "glpt_"
+	// cacheTTL limits how long a deleted token keeps working on other instances.
+	cacheTTL = 30 * time.Second
+	// invalidCacheTTL limits how long a key that matched no token is rejected without reading the database.
+	invalidCacheTTL = 5 * time.Second
+	// maxInvalidCached bounds the invalid keys remembered, as anyone can send them.
+	maxInvalidCached = 1000
+	// lastUsedUpdateInterval limits database writes for frequently used tokens.
+	lastUsedUpdateInterval = time.Minute
+)
+
+var logger = log.New("live.pushtoken")
+
+// Service manages publish tokens and authenticates push requests made with them.
+type Service struct {
+	store store
+	now   func() time.Time
+
+	mu     sync.Mutex
+	tokens map[string]*cachedToken
+	// invalid holds the time the hashes of keys matching no token were looked up.
+	invalid map[string]time.Time
+}
+
+type cachedToken struct {
+	token   PublishToken
+	matcher *tree.Node
+	limiter *rate.Limiter
+	loaded  time.Time
+	// revoked is set when the token is deleted, stopping the connections using it.
+	revoked atomic.Bool
+	// lastUsedSaved is the last time the last used time was written to the database.
+	lastUsedSaved time.Time
+}
+
+func NewService(store db.DB) *Service {
+	return newService(&sqlStore{db: store})
+}
+
+func newService(s store) *Service {
+	return &Service{
+		store:   s,
+		now:     time.Now,
+		tokens:  map[string]*cachedToken{},
+		invalid: map[string]time.Time{},
+	}
+}
+
+func hashKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+func generateKey() (string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return keyPrefix + hex.EncodeToString(b), nil
+}
+
+func (s *Service) Create(ctx context.Context, cmd CreatePublishTokenCommand) (*CreatePublishTokenResult, error) {
+	if cmd.Name == "" {
+		return nil, ErrNameRequired
+	}
+	if cmd.RateLimit < 0 || cmd.Burst < 0 {
+		return nil, ErrInvalidRateLimit
+	}
+	patterns := make([]string, 0, len(cmd.Patterns))
+	for _, p := range cmd.Patterns {
+		patterns = append(patterns, normalizePattern(p))
+	}
+	if _, err := newMatcher(patterns); err != nil {
+		return nil, err
+	}
+	burst := cmd.Burst
+	if burst == 0 {
+		burst = int(math.Ceil(cmd.RateLimit))
+	}
+
+	key, err := generateKey()
+	if err != nil {
+		return nil, err
+	}
+	jsonPatterns, err := json.Marshal(patterns)
+	if err != nil {
+		return nil, err
+	}
+	t := &publishToken{
+		OrgID:     cmd.OrgID,
+		Name:      cmd.Name,
+		TokenHash: hashKey(key),
+		Patterns:  string(jsonPatterns),
+		RateLimit: cmd.RateLimit,
+		Burst:     burst,
+		CreatedBy: cmd.CreatedBy,
+		Created:   s.now(),
+	}
+	if err := s.store.insert(ctx, t); err != nil {
+		return nil, err
+	}
+	token, err := t.toPublishToken()
+	if err != nil {
+		return nil, err
+	}
+	return &CreatePublishTokenResult{PublishToken: token, Key: key}, nil
+}
+
+func (s *Service) List(ctx context.Context, orgID int64) ([]PublishToken, error) {
+	stored, err := s.store.list(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+	tokens := make([]PublishToken, 0, len(stored))
+	for _, t := range stored {
+		token, err := t.toPublishToken()
+		if err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, token)
+	}
+	return tokens, nil
+}
+
+func (s *Service) Delete(ctx context.Context, orgID, id int64) error {
+	if err := s.store.delete(ctx, orgID, id); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	for hash, t := range s.tokens {
+		if t.token.ID == id {
+			t.revoked.Store(true)
+			delete(s.tokens, hash)
+		}
+	}
+	s.mu.Unlock()
+
+	tokenID := strconv.FormatInt(id, 10)
+	lastUsedTimestamp.DeleteLabelValues(tokenID)
+	for _, result := range []string{resultOK, resultForbidden, resultRateLimited} {
+		requestsTotal.DeleteLabelValues(tokenID, result)
+	}
+	return nil
+}
+
+// Authenticate returns the publisher for a token key. The returned publisher
+// must be checked for each channel before publishing.
+func (s *Service) Authenticate(ctx context.Context, key string) (*Publisher, error) {
+	if key == "" {
+		requestsTotal.WithLabelValues(invalidTokenMetricID, resultInvalid).Inc()
+		return nil, ErrInvalidToken
+	}
+	hash := hashKey(key)
+	token, err := s.authenticate(ctx, hash)
+	if err != nil {
+		return nil, err
+	}
+	return &Publisher{service: s, hash: hash, token: token}, nil
+}
+
+// authenticate returns the token with the key hash, from the cache unless it expired.
+func (s *Service) authenticate(ctx context.Context, hash string) (*cachedToken, error) {
+	now := s.now()
+
+	s.mu.Lock()
+	cached, ok := s.tokens[hash]
+	lookedUp, invalid := s.invalid[hash]
+	s.mu.Unlock()
+	if ok && now.Sub(cached.loaded) < cacheTTL {
+		return cached, nil
+	}
+	if invalid && now.Sub(lookedUp) < invalidCacheTTL {
+		requestsTotal.WithLabelValues(invalidTokenMetricID, resultInvalid).Inc()
+		return nil, ErrInvalidToken
+	}
+
+	stored, err := s.store.getByHash(ctx, hash)
+	if err != nil {
+		if errors.Is(err, ErrTokenNotFound) {
+			s.mu.Lock()
+			delete(s.tokens, hash)
+			if len(s.invalid) >= maxInvalidCached {
+				clear(s.invalid)
+			}
+			s.invalid[hash] = now
+			s.mu.Unlock()
+			requestsTotal.WithLabelValues(invalidTokenMetricID, resultInvalid).Inc()
+			return nil, ErrInvalidToken
+		}
+		return nil, err
+	}
+	token, err := stored.toPublishToken()
+	if err != nil {
+		return nil, err
+	}
+	matcher, err := newMatcher(token.Patterns)
+	if err != nil {
+		return nil, err
+	}
+
+	reloaded := &cachedToken{
+		token:   token,
+		matcher: matcher,
+		limiter: newLimiter(token.RateLimit, token.Burst),
+		loaded:  now,
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if ok && cached.token.RateLimit == token.RateLimit && cached.token.Burst == token.Burst {
+		// Keep the limiter, otherwise reloading would reset the burst.
+		reloaded.limiter = cached.limiter
+		reloaded.lastUsedSaved = cached.lastUsedSaved
+	}
+	s.tokens[hash] = reloaded
+	return reloaded, nil
+}
+
+func newLimiter(limit float64, burst int) *rate.Limiter {
+	if limit <= 0 {
+		return rate.NewLimiter(rate.Inf, 0)
+	}
+	return rate.NewLimiter(rate.Limit(limit), burst)
+}
+
+func (s *Service) markUsed(t *cachedToken) {
+	now := s.now()
+	lastUsedTimestamp.WithLabelValues(strconv.FormatInt(t.token.ID, 10)).Set(float64(now.Unix()))
+
+	s.mu.Lock()
+	if now.Sub(t.lastUsedSaved) < lastUsedUpdateInterval {
+		s.mu.Unlock()
+		return
+	}
+	t.lastUsedSaved = now
+	s.mu.Unlock()
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := s.store.updateLastUsed(ctx, t.token.ID, now); err != nil {
+			logger.Warn("Failed to update publish token last used time", "tokenId", t.token.ID, "error", err)
+		}
+	}()
+}
+
+// Publisher is an authenticated publish token.
+type Publisher struct {
+	service *Service
+	hash    string
+	token   *cachedToken
+}
+
+// OrgID returns the organization the token publishes to.
+func (p *Publisher) OrgID() int64 {
+	return p.token.token.OrgID
+}
+
+// TokenID returns the ID of the token.
+func (p *Publisher) TokenID() int64 {
+	return p.token.token.ID
+}
+
+// Allow consumes the rate limit of the token for one message. It must be
+// called once for each pushed message. It returns ErrInvalidToken once the
+// token is deleted, so long-lived connections stop publishing with it.
+func (p *Publisher) Allow(ctx context.Context) error {
+	if err := p.refresh(ctx); err != nil {
+		return err
+	}
+	if !p.token.limiter.Allow() {
+		requestsTotal.WithLabelValues(p.tokenLabel(), resultRateLimited).Inc()
+		return ErrRateLimitExceeded
+	}
+	requestsTotal.WithLabelValues(p.tokenLabel(), resultOK).Inc()
+	p.service.markUsed(p.token)
+	return nil
+}
+
+// refresh authenticates the token again once its cache entry expired, as it may
+// have been changed or deleted on another instance.
+func (p *Publisher) refresh(ctx context.Context) error {
+	if p.token.revoked.Load() {
+		requestsTotal.WithLabelValues(invalidTokenMetricID, resultInvalid).Inc()
+		return ErrInvalidToken
+	}
+	if p.service.now().Sub(p.token.loaded) < cacheTTL {
+		return nil
+	}
+	token, err := p.service.authenticate(ctx, p.hash)
+	if err != nil {
+		return err
+	}
+	p.token = token
+	return nil
+}
+
+// CanPublish checks that the channel matches one of the token patterns.
+func (p *Publisher) CanPublish(channel string) error {
+	if !matches(p.token.matcher, channel) {
+		requestsTotal.WithLabelValues(p.tokenLabel(), resultForbidden).Inc()
+		return fmt.Errorf("%w: %s", ErrChannelNotAllowed, channel)
+	}
+	return nil
+}
+
+func (p *Publisher) tokenLabel() string {
+	return strconv.FormatInt(p.token.token.ID, 10)
+}
+
+func (t *publishToken) toPublishToken() (PublishToken, error) {
+	var patterns []string
+	if err := json.Unmarshal([]byte(t.Patterns), &patterns); err != nil {
+		return PublishToken{}, fmt.Errorf("error decoding patterns of publish token %d: %w", t.ID, err)
+	}
+	return PublishToken{
+		ID:         t.ID,
+		OrgID:      t.OrgID,
+		Name:       t.Name,
+		Patterns:   patterns,
+		RateLimit:  t.RateLimit,
+		Burst:      t.Burst,
+		CreatedBy:  t.CreatedBy,
+		Created:    t.Created,
+		LastUsedAt: t.LastUsedAt,
+	}, nil
+}
@@ -0,0 +1,209 @@
+package pushtoken
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type fakeStore struct {
+	mu      sync.Mutex
+	nextID  int64
+	tokens  []*publishToken
+	lookups int
+}
+
+func (s *fakeStore) insert(_ context.Context, token *publishToken) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, t := range s.tokens {
+		if t.OrgID == token.OrgID && t.Name == token.Name {
+			return ErrTokenNameTaken
+		}
+	}
+	s.nextID++
+	token.ID = s.nextID
+	s.tokens = append(s.tokens, token)
+	return nil
+}
+
+func (s *fakeStore) list(_ context.Context, orgID int64) ([]*publishToken, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var tokens []*publishToken
+	for _, t := range s.tokens {
+		if t.OrgID == orgID {
+			copied := *t
+			tokens = append(tokens, &copied)
+		}
+	}
+	return tokens, nil
+}
+
+func (s *fakeStore) getByHash(_ context.Context, hash string) (*publishToken, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lookups++
+	for _, t := range s.tokens {
+		if t.TokenHash == hash {
+			copied := *t
+			return &copied, nil
+		}
+	}
+	return nil, ErrTokenNotFound
+}
+
+func (s *fakeStore) delete(_ context.Context, orgID, id int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, t := range s.tokens {
+		if t.OrgID == orgID && t.ID == id {
+			s.tokens = append(s.tokens[:i], s.tokens[i+1:]...)
+			return nil
+		}
+	}
+	return ErrTokenNotFound
+}
+
+func (s *fakeStore) updateLastUsed(_ context.Context, id int64, lastUsedAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, t := range s.tokens {
+		if t.ID == id {
+			t.LastUsedAt = &lastUsedAt
+		}
+	}
+	return nil
+}
+
+func TestService_CanPublish(t *testing.T) {
+	s := newService(&fakeStore{})
+	result, err := s.Create(context.Background(), CreatePublishTokenCommand{
+		OrgID:    1,
+		Name:     "factory-7",
+		Patterns: []string{"stream/factory-7/*", "stream/:site/temperature"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, []string{"stream/factory-7/*path", "stream/:site/temperature"}, result.Patterns)
+
+	publisher, err := s.Authenticate(context.Background(), result.Key)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), publisher.OrgID())
+
+	require.NoError(t, publisher.CanPublish("stream/factory-7/line-1/cpu"))
+	require.NoError(t, publisher.CanPublish("stream/factory-1/temperature"))
+	require.ErrorIs(t, publisher.CanPublish("stream/factory-1/humidity"), ErrChannelNotAllowed)
+	require.ErrorIs(t, publisher.CanPublish("stream/factory-8/cpu"), ErrChannelNotAllowed)
+}
+
+func TestService_Authenticate(t *testing.T) {
+	s := newService(&fakeStore{})
+	result, err := s.Create(context.Background(), CreatePublishTokenCommand{
+		OrgID:    1,
+		Name:     "device",
+		Patterns: []string{"stream/device/*"},
+	})
+	require.NoError(t, err)
+
+	_, err = s.Authenticate(context.Background(), "")
+	require.ErrorIs(t, err, ErrInvalidToken)
+	_, err = s.Authenticate(context.Background(), result.Key+"x")
+	require.ErrorIs(t, err, ErrInvalidToken)
+
+	_, err = s.Authenticate(context.Background(), result.Key)
+	require.NoError(t, err)
+
+	require.NoError(t, s.Delete(context.Background(), 1, result.ID))
+	_, err = s.Authenticate(context.Background(), result.Key)
+	require.ErrorIs(t, err, ErrInvalidToken)
+}
+
+func TestService_Authenticate_InvalidKeyCache(t *testing.T) {
+	store := &fakeStore{}
+	s := newService(store)
+	now := time.Now()
+	s.now = func() time.Time { return now }
+
+	for i := 0; i < 3; i++ {
+		_, err := s.Authenticate(context.Background(), "glpt_invalid")
+		require.ErrorIs(t, err, ErrInvalidToken)
+	}
+	require.Equal(t, 1, store.lookups)
+
+	now = now.Add(invalidCacheTTL)
+	_, err := s.Authenticate(context.Background(), "glpt_invalid")
+	require.ErrorIs(t, err, ErrInvalidToken)
+	require.Equal(t, 2, store.lookups)
+}
+
+func TestService_Delete_StopsPublishers(t *testing.T) {
+	store := &fakeStore{}
+	s := newService(store)
+	result, err := s.Create(context.Background(), CreatePublishTokenCommand{OrgID: 1, Name: "device", Patterns: []string{"stream/device/*"}})
+	require.NoError(t, err)
+
+	publisher, err := s.Authenticate(context.Background(), result.Key)
+	require.NoError(t, err)
+	require.NoError(t, publisher.Allow(context.Background()))
+
+	other := newService(store)
+	now := time.Now()
+	other.now = func() time.Time { return now }
+	otherPublisher, err := other.Authenticate(context.Background(), result.Key)
+	require.NoError(t, err)
+
+	require.NoError(t, s.Delete(context.Background(), 1, result.ID))
+	require.ErrorIs(t, publisher.Allow(context.Background()), ErrInvalidToken)
+
+	// Other instances notice the deletion once their cache expired.
+	require.NoError(t, otherPublisher.Allow(context.Background()))
+	now = now.Add(cacheTTL)
+	require.ErrorIs(t, otherPublisher.Allow(context.Background()), ErrInvalidToken)
+}
+
+func TestService_RateLimit(t *testing.T) {
+	store := &fakeStore{}
+	s := newService(store)
+	result, err := s.Create(context.Background(), CreatePublishTokenCommand{
+		OrgID:     1,
+		Name:      "device",
+		Patterns:  []string{"stream/device/*"},
+		RateLimit: 0.001,
+		Burst:     2,
+	})
+	require.NoError(t, err)
+
+	publisher, err := s.Authenticate(context.Background(), result.Key)
+	require.NoError(t, err)
+	require.NoError(t, publisher.Allow(context.Background()))
+	require.NoError(t, publisher.Allow(context.Background()))
+
+	// The limiter is shared by all requests made with the same token.
+	publisher, err = s.Authenticate(context.Background(), result.Key)
+	require.NoError(t, err)
+	require.ErrorIs(t, publisher.Allow(context.Background()), ErrRateLimitExceeded)
+
+	require.Eventually(t, func() bool {
+		tokens, err := s.List(context.Background(), 1)
+		return err == nil && len(tokens) == 1 && tokens[0].LastUsedAt != nil
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestService_Create(t *testing.T) {
+	s := newService(&fakeStore{})
+	cmd := CreatePublishTokenCommand{OrgID: 1, Name: "device", Patterns: []string{"stream/device/*"}}
+	_, err := s.Create(context.Background(), cmd)
+	require.NoError(t, err)
+	_, err = s.Create(context.Background(), cmd)
+	require.ErrorIs(t, err, ErrTokenNameTaken)
+
+	_, err = s.Create(context.Background(), CreatePublishTokenCommand{OrgID: 1, Name: "empty"})
+	require.ErrorIs(t, err, ErrNoPatterns)
+	_, err = s.Create(context.Background(), CreatePublishTokenCommand{OrgID: 1, Name: "invalid", Patterns: []string{"stream/:/x"}})
+	require.ErrorIs(t, err, ErrInvalidPattern)
+	_, err = s.Create(context.Background(), CreatePublishTokenCommand{OrgID: 1, Name: "negative", Patterns: []string{"stream/x"}, RateLimit: -1})
+	require.ErrorIs(t, err, ErrInvalidRateLimit)
+}
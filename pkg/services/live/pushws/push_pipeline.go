@@ -1,6 +1,7 @@
 package pushws
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/gorilla/websocket"
@@ -8,6 +9,7 @@ import (
 	"github.com/grafana/grafana/pkg/services/live/convert"
 	"github.com/grafana/grafana/pkg/services/live/livecontext"
 	"github.com/grafana/grafana/pkg/services/live/pipeline"
+	"github.com/grafana/grafana/pkg/services/live/pushtoken"
 )
 
 // PipelinePushHandler handles WebSocket client connections that push data to Live Pipeline.
@@ -44,12 +46,18 @@ func (s *PipelinePushHandler) ServeHTTP(rw http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	user, ok := livecontext.GetContextSignedUser(r.Context())
+	orgID, publisher, ok := getPushOrgID(r.Context())
 	if !ok {
 		logger.Error("No user found in context")
 		rw.WriteHeader(http.StatusInternalServerError)
 		return
 	}
+	if publisher != nil {
+		if err := publisher.CanPublish(channelID); err != nil {
+			rw.WriteHeader(http.StatusForbidden)
+			return
+		}
+	}
 
 	conn, err := s.upgrade.Upgrade(rw, r, nil)
 	if err != nil {
@@ -71,7 +79,18 @@ func (s *PipelinePushHandler) ServeHTTP(rw http.ResponseWriter, r *http.Request)
 			"bodyLength", len(body),
 		)
 
-		ruleFound, err := s.pipeline.ProcessInput(r.Context(), user.GetOrgID(), channelID, body)
+		if publisher != nil {
+			if err := publisher.Allow(r.Context()); err != nil {
+				logger.Debug("Push message rejected", "error", err, "tokenId", publisher.TokenID())
+				if errors.Is(err, pushtoken.ErrRateLimitExceeded) {
+					continue
+				}
+				// The token was deleted or can't be checked, close the connection.
+				return
+			}
+		}
+
+		ruleFound, err := s.pipeline.ProcessInput(r.Context(), orgID, channelID, body)
 		if err != nil {
 			logger.Error("Pipeline input processing error", "error", err, "body", string(body))
 			return
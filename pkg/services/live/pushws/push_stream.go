@@ -1,6 +1,7 @@
 package pushws
 
 import (
+	"errors"
 	"net/http"
 	"time"
 
@@ -10,6 +11,7 @@ import (
 	"github.com/grafana/grafana/pkg/services/live/convert"
 	"github.com/grafana/grafana/pkg/services/live/livecontext"
 	"github.com/grafana/grafana/pkg/services/live/managedstream"
+	"github.com/grafana/grafana/pkg/services/live/pushtoken"
 	"github.com/grafana/grafana/pkg/services/live/pushurl"
 )
 
@@ -47,7 +49,7 @@ func (s *Handler) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	user, ok := livecontext.GetContextSignedUser(r.Context())
+	orgID, publisher, ok := getPushOrgID(r.Context())
 	if !ok {
 		logger.Error("No user found in context")
 		rw.WriteHeader(http.StatusInternalServerError)
@@ -70,7 +72,18 @@ func (s *Handler) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
 			break
 		}
 
-		stream, err := s.managedStreamRunner.GetOrCreateStream(user.GetOrgID(), liveDto.ScopeStream, streamID)
+		if publisher != nil {
+			if err := publisher.Allow(r.Context()); err != nil {
+				logger.Debug("Push message rejected", "error", err, "tokenId", publisher.TokenID())
+				if errors.Is(err, pushtoken.ErrRateLimitExceeded) {
+					continue
+				}
+				// The token was deleted or can't be checked, close the connection.
+				return
+			}
+		}
+
+		stream, err := s.managedStreamRunner.GetOrCreateStream(orgID, liveDto.ScopeStream, streamID)
 		if err != nil {
 			logger.Error("Error getting stream", "error", err)
 			continue
@@ -95,6 +108,13 @@ func (s *Handler) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
 		}
 
 		for _, mf := range metricFrames {
+			if publisher != nil {
+				channel := liveDto.Channel{Scope: liveDto.ScopeStream, Namespace: streamID, Path: mf.Key()}.String()
+				if err := publisher.CanPublish(channel); err != nil {
+					logger.Debug("Push frame rejected", "error", err, "tokenId", publisher.TokenID())
+					continue
+				}
+			}
 			err := stream.Push(r.Context(), mf.Key(), mf.Frame())
 			if err != nil {
 				logger.Error("Error pushing frame", "error", err, "data", string(body))
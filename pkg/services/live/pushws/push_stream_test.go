@@ -0,0 +1,74 @@
+package pushws
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/services/live/livecontext"
+	"github.com/grafana/grafana/pkg/services/live/managedstream"
+	"github.com/grafana/grafana/pkg/services/live/pushtoken"
+	"github.com/grafana/grafana/pkg/tests/testsuite"
+	"github.com/grafana/grafana/pkg/util/testutil"
+)
+
+func TestMain(m *testing.M) {
+	testsuite.Run(m)
+}
+
+func TestIntegrationHandler_DeletedToken(t *testing.T) {
+	testutil.SkipIntegrationTestInShortMode(t)
+
+	ctx := context.Background()
+	tokens := pushtoken.NewService(db.InitTestDB(t))
+	result, err := tokens.Create(ctx, pushtoken.CreatePublishTokenCommand{OrgID: 1, Name: "device", Patterns: []string{"stream/device/*"}})
+	require.NoError(t, err)
+
+	var published atomic.Int64
+	runner := managedstream.NewRunner(func(orgID int64, channel string, data []byte) error {
+		published.Add(1)
+		return nil
+	}, nil, managedstream.NewMemoryFrameCache(managedstream.HistoryConfig{}))
+	handler := NewHandler(runner, Config{})
+
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		publisher, err := tokens.Authenticate(r.Context(), r.Header.Get(pushtoken.HeaderName))
+		if err != nil {
+			rw.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		reqCtx := livecontext.SetContextStreamID(r.Context(), "device")
+		reqCtx = livecontext.SetContextPublisher(reqCtx, publisher)
+		handler.ServeHTTP(rw, r.WithContext(reqCtx))
+	}))
+	t.Cleanup(server.Close)
+
+	conn, resp, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http"), http.Header{pushtoken.HeaderName: {result.Key}})
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+	t.Cleanup(func() { _ = conn.Close() })
+
+	require.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte("cpu value=1")))
+	require.Eventually(t, func() bool { return published.Load() == 1 }, time.Second, 10*time.Millisecond)
+
+	require.NoError(t, tokens.Delete(ctx, 1, result.ID))
+	require.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte("cpu value=2")))
+
+	// The server closes the connection instead of publishing with the deleted token.
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+	_, _, err = conn.ReadMessage()
+	require.Error(t, err)
+	var netErr net.Error
+	require.False(t, errors.As(err, &netErr) && netErr.Timeout(), "connection should be closed")
+	require.Equal(t, int64(1), published.Load())
+}
@@ -11,6 +11,8 @@ import (
 	"github.com/gorilla/websocket"
 
 	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/live/livecontext"
+	"github.com/grafana/grafana/pkg/services/live/pushtoken"
 )
 
 var (
@@ -40,6 +42,19 @@ type Config struct {
 	PingInterval time.Duration
 }
 
+// getPushOrgID returns the org of the signed in user, or of the publish token when
+// the request was authenticated with one. The publisher is nil for signed in users.
+func getPushOrgID(ctx context.Context) (int64, *pushtoken.Publisher, bool) {
+	if publisher, ok := livecontext.GetContextPublisher(ctx); ok {
+		return publisher.OrgID(), publisher, true
+	}
+	user, ok := livecontext.GetContextSignedUser(ctx)
+	if !ok {
+		return 0, nil, false
+	}
+	return user.GetOrgID(), nil, true
+}
+
 func sameHostOriginCheck() func(r *http.Request) bool {
 	return func(r *http.Request) bool {
 		err := checkSameHost(r)
@@ -25,10 +25,12 @@ const (
 	// modules
 	PasswordAuthModule     = "password"
 	PasswordlessAuthModule = "passwordless"
+	WebAuthnAuthModule     = "webauthn"
 	APIKeyAuthModule       = "apikey"
 	SAMLAuthModule         = "auth.saml"
 	LDAPAuthModule         = "ldap"
 	AuthProxyAuthModule    = "authproxy"
+	ClientCertAuthModule   = "clientcert"
 	JWTModule              = "jwt"
 	ExtendedJWTModule      = "extendedjwt"
 	RenderModule           = "render"
@@ -48,6 +50,7 @@ const (
 	JWTLabel  = "JWT"
 	// OAuth provider labels
 	AuthProxyLabel    = "Auth Proxy"
+	ClientCertLabel   = "Client Certificate"
 	AzureADLabel      = "AzureAD"
 	GoogleLabel       = "Google"
 	GenericOAuthLabel = "Generic OAuth"
@@ -81,6 +84,8 @@ func GetAuthProviderLabel(authModule string) string {
 		return JWTLabel
 	case AuthProxyAuthModule:
 		return AuthProxyLabel
+	case ClientCertAuthModule:
+		return ClientCertLabel
 	case GenericOAuthModule, strings.TrimPrefix(GenericOAuthModule, "oauth_"):
 		return GenericOAuthLabel
 	default:
@@ -601,7 +601,7 @@ func TestValidate(t *testing.T) {
 				tracing.InitializeTracerForTest(),
 				dsquerierclient.NewNullQSDatasourceClientBuilder(),
 			)
-			validator := NewConditionValidator(cacheService, expressions, store)
+			validator := NewConditionValidator(cacheService, expressions, store, nil)
 			evalCtx := NewContext(context.Background(), u)
 
 			err := validator.Validate(evalCtx, condition)
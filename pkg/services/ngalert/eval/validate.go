@@ -2,25 +2,31 @@ package eval
 
 import (
 	"fmt"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
 
 	"github.com/grafana/grafana/pkg/expr"
 	"github.com/grafana/grafana/pkg/plugins"
 	"github.com/grafana/grafana/pkg/services/datasources"
 	"github.com/grafana/grafana/pkg/services/ngalert/models"
 	"github.com/grafana/grafana/pkg/services/pluginsintegration/pluginstore"
+	"github.com/grafana/grafana/pkg/services/querycost"
 )
 
 type ConditionValidator struct {
 	dataSourceCache   datasources.CacheService
 	pluginsStore      pluginstore.Store
 	expressionService expressionBuilder
+	queryCost         *querycost.Service
 }
 
-func NewConditionValidator(datasourceCache datasources.CacheService, expressionService *expr.Service, pluginsStore pluginstore.Store) *ConditionValidator {
+func NewConditionValidator(datasourceCache datasources.CacheService, expressionService *expr.Service, pluginsStore pluginstore.Store, queryCost *querycost.Service) *ConditionValidator {
 	return &ConditionValidator{
 		dataSourceCache:   datasourceCache,
 		expressionService: expressionService,
 		pluginsStore:      pluginsStore,
+		queryCost:         queryCost,
 	}
 }
 
@@ -50,6 +56,9 @@ func (e *ConditionValidator) Validate(ctx EvaluationContext, condition models.Co
 		case expr.TypeCMDNode:
 		}
 	}
+	if err := e.checkQueryCost(ctx, req); err != nil {
+		return err
+	}
 	pipeline, err := e.expressionService.BuildPipeline(ctx.Ctx, req)
 	if err != nil {
 		return err
@@ -63,3 +72,37 @@ func (e *ConditionValidator) Validate(ctx EvaluationContext, condition models.Co
 	}
 	return models.ErrConditionNotExist(condition.Condition, refIDs)
 }
+
+// checkQueryCost rejects rules with queries whose estimated cost is above the
+// limits applied to data source queries.
+func (e *ConditionValidator) checkQueryCost(ctx EvaluationContext, req *expr.Request) error {
+	if !e.queryCost.Enabled() {
+		return nil
+	}
+	now := time.Now()
+	queriesByDS := map[string][]backend.DataQuery{}
+	dataSources := map[string]*datasources.DataSource{}
+	for _, query := range req.Queries {
+		if query.DataSource == nil || expr.NodeTypeFromDatasourceUID(query.DataSource.UID) != expr.TypeDatasourceNode {
+			continue
+		}
+		dataQuery := backend.DataQuery{
+			RefID:         query.RefID,
+			QueryType:     query.QueryType,
+			Interval:      query.Interval,
+			MaxDataPoints: query.MaxDataPoints,
+			JSON:          query.JSON,
+		}
+		if query.TimeRange != nil {
+			dataQuery.TimeRange = query.TimeRange.AbsoluteTime(now)
+		}
+		queriesByDS[query.DataSource.UID] = append(queriesByDS[query.DataSource.UID], dataQuery)
+		dataSources[query.DataSource.UID] = query.DataSource
+	}
+	requests := make([]querycost.DataSourceQueries, 0, len(queriesByDS))
+	for uid, queries := range queriesByDS {
+		requests = append(requests, querycost.DataSourceQueries{DataSource: dataSources[uid], Queries: queries})
+	}
+	_, err := e.queryCost.Check(ctx.Ctx, ctx.User, requests)
+	return err
+}
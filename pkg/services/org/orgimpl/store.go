@@ -227,6 +227,7 @@ func (ss *sqlStore) Delete(ctx context.Context, cmd *org.DeleteOrgCommand) error
 			"DELETE FROM team_role WHERE org_id = ?",
 			"DELETE FROM user_role WHERE org_id = ?",
 			"DELETE FROM builtin_role WHERE org_id = ?",
+			"DELETE FROM org_two_factor WHERE org_id = ?",
 		}
 
 		// Add registered deletes
@@ -807,6 +808,8 @@ func (ss *sqlStore) userDeletions() []string {
 		"DELETE FROM user_auth WHERE user_id = ?",
 		"DELETE FROM user_auth_token WHERE user_id = ?",
 		"DELETE FROM quota WHERE user_id = ?",
+		"DELETE FROM user_two_factor WHERE user_id = ?",
+		"DELETE FROM webauthn_credential WHERE user_id = ?",
 	}
 	return deletes
 }
@@ -0,0 +1,393 @@
+package clientmiddleware
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/grafana/grafana/pkg/apimachinery/errutil"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/plugins"
+)
+
+var (
+	errConcurrencyLimitReached = errutil.TooManyRequests("plugin.concurrencyLimitReached",
+		errutil.WithPublicMessage("Too many concurrent requests to the data source. Please try again later."),
+		errutil.WithDownstream())
+
+	errCircuitOpen = errutil.BadGateway("plugin.circuitOpen",
+		errutil.WithPublicMessage("The data source is failing and requests to it are paused. Please try again later."),
+		errutil.WithDownstream())
+)
+
+// ConcurrencyLimitConfig configures the ConcurrencyLimitMiddleware. Zero values disable
+// the corresponding limit.
+type ConcurrencyLimitConfig struct {
+	// MaxConcurrentPerDatasource is the maximum number of concurrent requests to a data source.
+	MaxConcurrentPerDatasource int
+	// MaxConcurrentPerPlugin is the maximum number of concurrent requests to a plugin,
+	// across all of its data sources.
+	MaxConcurrentPerPlugin int
+	// QueueTimeout is how long a request waits for a free slot before it is rejected.
+	QueueTimeout time.Duration
+	// FailureThreshold is the number of consecutive requests failing because the data
+	// source is unavailable that open the circuit of a data source.
+	FailureThreshold int
+	// OpenDuration is how long a circuit stays open before a request is let through
+	// to check whether the data source recovered.
+	OpenDuration time.Duration
+}
+
+func (c ConcurrencyLimitConfig) enabled() bool {
+	return c.MaxConcurrentPerDatasource > 0 || c.MaxConcurrentPerPlugin > 0 || c.FailureThreshold > 0
+}
+
+// NewConcurrencyLimitMiddleware creates a new backend.HandlerMiddleware that limits
+// the number of concurrent QueryData and CallResource requests per data source and
+// per plugin, and stops sending requests to a data source after repeated failures.
+// Requests wait in a queue for a free slot for at most QueueTimeout.
+func NewConcurrencyLimitMiddleware(cfg ConcurrencyLimitConfig, promRegisterer prometheus.Registerer) backend.HandlerMiddleware {
+	limiter := newConcurrencyLimiter(cfg, promRegisterer)
+	return backend.HandlerMiddlewareFunc(func(next backend.Handler) backend.Handler {
+		return &ConcurrencyLimitMiddleware{
+			BaseHandler: backend.NewBaseHandler(next),
+			limiter:     limiter,
+		}
+	})
+}
+
+type ConcurrencyLimitMiddleware struct {
+	backend.BaseHandler
+
+	limiter *concurrencyLimiter
+}
+
+func (m *ConcurrencyLimitMiddleware) QueryData(ctx context.Context, req *backend.QueryDataRequest) (*backend.QueryDataResponse, error) {
+	if req == nil || !m.limiter.cfg.enabled() {
+		return m.BaseHandler.QueryData(ctx, req)
+	}
+
+	release, err := m.limiter.acquire(ctx, req.PluginContext)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := m.BaseHandler.QueryData(ctx, req)
+	release(ctx, isUnavailableError(err) || allResponsesUnavailable(resp))
+	return resp, err
+}
+
+func (m *ConcurrencyLimitMiddleware) CallResource(ctx context.Context, req *backend.CallResourceRequest, sender backend.CallResourceResponseSender) error {
+	if req == nil || !m.limiter.cfg.enabled() {
+		return m.BaseHandler.CallResource(ctx, req, sender)
+	}
+
+	release, err := m.limiter.acquire(ctx, req.PluginContext)
+	if err != nil {
+		return err
+	}
+
+	serverError := false
+	wrappedSender := backend.CallResourceResponseSenderFunc(func(res *backend.CallResourceResponse) error {
+		if res != nil && isUnavailableStatus(res.Status) {
+			serverError = true
+		}
+		return sender.Send(res)
+	})
+	err = m.BaseHandler.CallResource(ctx, req, wrappedSender)
+	release(ctx, isUnavailableError(err) || serverError)
+	return err
+}
+
+// allResponsesUnavailable reports whether every query of the response failed because
+// the data source is unavailable, which means the data source itself is failing
+// rather than some of the queries. Errors caused by the queries, such as invalid
+// queries or missing permissions, do not count.
+func allResponsesUnavailable(resp *backend.QueryDataResponse) bool {
+	if resp == nil || len(resp.Responses) == 0 {
+		return false
+	}
+	for _, r := range resp.Responses {
+		if r.Error == nil {
+			return false
+		}
+		if isUnavailableError(r.Error) {
+			continue
+		}
+		if r.ErrorSource != backend.ErrorSourceDownstream || !isUnavailableStatus(int(r.Status)) {
+			return false
+		}
+	}
+	return true
+}
+
+// isUnavailableError reports whether err means the data source or the plugin could
+// not be reached: timeouts, connection errors and unavailable plugins.
+func isUnavailableError(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, plugins.ErrPluginUnavailable) {
+		return true
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+	var opErr *net.OpError
+	return errors.As(err, &opErr)
+}
+
+// isUnavailableStatus reports whether the HTTP status returned by a data source is a
+// server error. Client errors are caused by the request and do not count.
+func isUnavailableStatus(status int) bool {
+	return status >= http.StatusInternalServerError
+}
+
+type concurrencyLimiterMetrics struct {
+	inFlight      *prometheus.GaugeVec
+	queueDuration *prometheus.HistogramVec
+	rejectedTotal *prometheus.CounterVec
+	circuitOpen   *prometheus.GaugeVec
+	circuitOpened *prometheus.CounterVec
+}
+
+type concurrencyLimiter struct {
+	cfg     ConcurrencyLimitConfig
+	log     log.Logger
+	metrics concurrencyLimiterMetrics
+	now     func() time.Time
+
+	mu          sync.Mutex
+	datasources map[string]chan struct{}
+	plugins     map[string]chan struct{}
+	circuits    map[string]*circuitBreaker
+}
+
+func newConcurrencyLimiter(cfg ConcurrencyLimitConfig, promRegisterer prometheus.Registerer) *concurrencyLimiter {
+	metrics := concurrencyLimiterMetrics{
+		inFlight: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: "grafana",
+			Name:      "plugin_concurrent_requests",
+			Help:      "The number of plugin requests in flight that are subject to the concurrency limits",
+		}, []string{"plugin_id"}),
+		queueDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: "grafana",
+			Name:      "plugin_request_queue_duration_seconds",
+			Help:      "Time plugin requests waited for a free concurrency slot",
+			Buckets:   []float64{.001, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10, 30},
+		}, []string{"plugin_id"}),
+		rejectedTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "grafana",
+			Name:      "plugin_request_rejected_total",
+			Help:      "The total amount of plugin requests rejected by the concurrency limits or an open circuit",
+		}, []string{"plugin_id", "reason"}),
+		circuitOpen: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: "grafana",
+			Name:      "plugin_open_circuits",
+			Help:      "The number of data sources of a plugin with an open circuit",
+		}, []string{"plugin_id"}),
+		circuitOpened: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "grafana",
+			Name:      "plugin_circuit_opened_total",
+			Help:      "The total amount of times a circuit was opened after repeated failures",
+		}, []string{"plugin_id"}),
+	}
+	promRegisterer.MustRegister(
+		metrics.inFlight,
+		metrics.queueDuration,
+		metrics.rejectedTotal,
+		metrics.circuitOpen,
+		metrics.circuitOpened,
+	)
+
+	return &concurrencyLimiter{
+		cfg:         cfg,
+		log:         log.New("plugin.concurrency_limit"),
+		metrics:     metrics,
+		now:         time.Now,
+		datasources: map[string]chan struct{}{},
+		plugins:     map[string]chan struct{}{},
+		circuits:    map[string]*circuitBreaker{},
+	}
+}
+
+// limiterKey returns the key identifying the data source of a request. Requests
+// without a data source, such as app plugin requests, are keyed by plugin.
+func limiterKey(pCtx backend.PluginContext) string {
+	if pCtx.DataSourceInstanceSettings != nil {
+		return strconv.FormatInt(pCtx.OrgID, 10) + "/" + pCtx.DataSourceInstanceSettings.UID
+	}
+	return pCtx.PluginID
+}
+
+func (l *concurrencyLimiter) slots(m map[string]chan struct{}, key string, size int) chan struct{} {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	s, ok := m[key]
+	if !ok {
+		s = make(chan struct{}, size)
+		m[key] = s
+	}
+	return s
+}
+
+func (l *concurrencyLimiter) circuit(key string) *circuitBreaker {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	c, ok := l.circuits[key]
+	if !ok {
+		c = &circuitBreaker{}
+		l.circuits[key] = c
+	}
+	return c
+}
+
+// acquire waits for a free slot of the data source and of the plugin. The returned
+// function must be called with the outcome of the request to release the slots.
+func (l *concurrencyLimiter) acquire(ctx context.Context, pCtx backend.PluginContext) (func(context.Context, bool), error) {
+	key := limiterKey(pCtx)
+	pluginID := pCtx.PluginID
+
+	var circuit *circuitBreaker
+	if l.cfg.FailureThreshold > 0 {
+		circuit = l.circuit(key)
+		if !circuit.allow(l.now(), l.cfg.OpenDuration) {
+			l.metrics.rejectedTotal.WithLabelValues(pluginID, "circuit_open").Inc()
+			return nil, l.downstreamError(ctx, errCircuitOpen.Errorf("circuit open for %s", key))
+		}
+	}
+
+	var acquired []chan struct{}
+	releaseSlots := func() {
+		for _, s := range acquired {
+			<-s
+		}
+	}
+
+	start := l.now()
+	var timeout <-chan time.Time
+	if l.cfg.QueueTimeout > 0 {
+		timer := time.NewTimer(l.cfg.QueueTimeout)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	// The data source slot is taken first, so that requests queued for a slow
+	// data source do not hold the slots of the other data sources of the plugin.
+	var toAcquire []chan struct{}
+	if l.cfg.MaxConcurrentPerDatasource > 0 && pCtx.DataSourceInstanceSettings != nil {
+		toAcquire = append(toAcquire, l.slots(l.datasources, key, l.cfg.MaxConcurrentPerDatasource))
+	}
+	if l.cfg.MaxConcurrentPerPlugin > 0 {
+		toAcquire = append(toAcquire, l.slots(l.plugins, pluginID, l.cfg.MaxConcurrentPerPlugin))
+	}
+	for _, s := range toAcquire {
+		select {
+		case s <- struct{}{}:
+			acquired = append(acquired, s)
+		case <-timeout:
+			releaseSlots()
+			circuit.abort()
+			l.metrics.rejectedTotal.WithLabelValues(pluginID, "queue_timeout").Inc()
+			return nil, l.downstreamError(ctx, errConcurrencyLimitReached.Errorf("timed out waiting for a free request slot for %s", key))
+		case <-ctx.Done():
+			releaseSlots()
+			circuit.abort()
+			return nil, ctx.Err()
+		}
+	}
+	l.metrics.queueDuration.WithLabelValues(pluginID).Observe(l.now().Sub(start).Seconds())
+	l.metrics.inFlight.WithLabelValues(pluginID).Inc()
+
+	return func(ctx context.Context, failed bool) {
+		releaseSlots()
+		l.metrics.inFlight.WithLabelValues(pluginID).Dec()
+		if circuit == nil {
+			return
+		}
+		// Requests canceled by the caller say nothing about the data source.
+		if failed && errors.Is(ctx.Err(), context.Canceled) {
+			circuit.abort()
+			return
+		}
+		wasOpen, isOpen := circuit.record(l.now(), failed, l.cfg.FailureThreshold)
+		switch {
+		case !wasOpen && isOpen:
+			l.log.Warn("Opening circuit after repeated failures", "pluginId", pluginID, "key", key, "openDuration", l.cfg.OpenDuration)
+			l.metrics.circuitOpened.WithLabelValues(pluginID).Inc()
+			l.metrics.circuitOpen.WithLabelValues(pluginID).Inc()
+		case wasOpen && !isOpen:
+			l.log.Info("Closing circuit", "pluginId", pluginID, "key", key)
+			l.metrics.circuitOpen.WithLabelValues(pluginID).Dec()
+		}
+	}, nil
+}
+
+// downstreamError marks the error and the request context with the downstream error
+// source, so that the request is not counted as a plugin failure.
+func (l *concurrencyLimiter) downstreamError(ctx context.Context, err error) error {
+	if innerErr := backend.WithDownstreamErrorSource(ctx); innerErr != nil {
+		l.log.Debug("Failed to set downstream error source", "error", innerErr)
+	}
+	return backend.DownstreamError(err)
+}
+
+// circuitBreaker opens after FailureThreshold consecutive failed requests. While open,
+// requests are rejected until OpenDuration has passed, then a single probe request is
+// let through: the circuit closes if it succeeds and opens again if it fails.
+type circuitBreaker struct {
+	mu       sync.Mutex
+	failures int
+	open     bool
+	openedAt time.Time
+	probing  bool
+}
+
+func (c *circuitBreaker) allow(now time.Time, openDuration time.Duration) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.open {
+		return true
+	}
+	if c.probing || now.Sub(c.openedAt) < openDuration {
+		return false
+	}
+	c.probing = true
+	return true
+}
+
+// abort is called when a request let through did not reach the plugin.
+func (c *circuitBreaker) abort() {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.probing = false
+}
+
+func (c *circuitBreaker) record(now time.Time, failed bool, threshold int) (wasOpen bool, isOpen bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	wasOpen = c.open
+	c.probing = false
+	if !failed {
+		c.failures = 0
+		c.open = false
+		return wasOpen, false
+	}
+	c.failures++
+	if c.open || c.failures >= threshold {
+		c.open = true
+		c.openedAt = now
+	}
+	return wasOpen, c.open
+}
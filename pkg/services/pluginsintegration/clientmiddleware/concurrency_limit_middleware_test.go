@@ -0,0 +1,213 @@
+package clientmiddleware
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/handlertest"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+)
+
+func datasourcePluginContext(uid string) backend.PluginContext {
+	return backend.PluginContext{
+		OrgID:                      1,
+		PluginID:                   "elasticsearch",
+		DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{UID: uid},
+	}
+}
+
+func TestConcurrencyLimitMiddleware(t *testing.T) {
+	t.Run("Should reject requests to a data source after queue timeout", func(t *testing.T) {
+		cdt := handlertest.NewHandlerMiddlewareTest(t, handlertest.WithMiddlewares(
+			NewConcurrencyLimitMiddleware(ConcurrencyLimitConfig{
+				MaxConcurrentPerDatasource: 1,
+				QueueTimeout:               50 * time.Millisecond,
+			}, prometheus.NewRegistry()),
+		))
+
+		started := make(chan struct{})
+		unblock := make(chan struct{})
+		var once sync.Once
+		cdt.TestHandler.QueryDataFunc = func(ctx context.Context, req *backend.QueryDataRequest) (*backend.QueryDataResponse, error) {
+			if req.PluginContext.DataSourceInstanceSettings.UID == "slow" {
+				once.Do(func() { close(started) })
+				<-unblock
+			}
+			return &backend.QueryDataResponse{}, nil
+		}
+
+		done := make(chan error)
+		go func() {
+			_, err := cdt.MiddlewareHandler.QueryData(context.Background(), &backend.QueryDataRequest{PluginContext: datasourcePluginContext("slow")})
+			done <- err
+		}()
+		<-started
+
+		_, err := cdt.MiddlewareHandler.QueryData(context.Background(), &backend.QueryDataRequest{PluginContext: datasourcePluginContext("slow")})
+		require.ErrorIs(t, err, errConcurrencyLimitReached)
+		require.True(t, backend.IsDownstreamError(err))
+
+		err = cdt.MiddlewareHandler.CallResource(context.Background(), &backend.CallResourceRequest{PluginContext: datasourcePluginContext("slow")}, nopCallResourceSender)
+		require.ErrorIs(t, err, errConcurrencyLimitReached)
+
+		_, err = cdt.MiddlewareHandler.QueryData(context.Background(), &backend.QueryDataRequest{PluginContext: datasourcePluginContext("fast")})
+		require.NoError(t, err)
+
+		close(unblock)
+		require.NoError(t, <-done)
+
+		_, err = cdt.MiddlewareHandler.QueryData(context.Background(), &backend.QueryDataRequest{PluginContext: datasourcePluginContext("slow")})
+		require.NoError(t, err)
+	})
+
+	t.Run("Should limit requests across data sources of a plugin", func(t *testing.T) {
+		cdt := handlertest.NewHandlerMiddlewareTest(t, handlertest.WithMiddlewares(
+			NewConcurrencyLimitMiddleware(ConcurrencyLimitConfig{
+				MaxConcurrentPerPlugin: 1,
+				QueueTimeout:           time.Second,
+			}, prometheus.NewRegistry()),
+		))
+
+		started := make(chan struct{})
+		unblock := make(chan struct{})
+		cdt.TestHandler.QueryDataFunc = func(ctx context.Context, req *backend.QueryDataRequest) (*backend.QueryDataResponse, error) {
+			if req.PluginContext.DataSourceInstanceSettings.UID == "a" {
+				close(started)
+				<-unblock
+			}
+			return &backend.QueryDataResponse{}, nil
+		}
+
+		done := make(chan error)
+		go func() {
+			_, err := cdt.MiddlewareHandler.QueryData(context.Background(), &backend.QueryDataRequest{PluginContext: datasourcePluginContext("a")})
+			done <- err
+		}()
+		<-started
+
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+		_, err := cdt.MiddlewareHandler.QueryData(ctx, &backend.QueryDataRequest{PluginContext: datasourcePluginContext("b")})
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+
+		// A queued request gets the slot once it is released.
+		queued := make(chan error)
+		go func() {
+			_, err := cdt.MiddlewareHandler.QueryData(context.Background(), &backend.QueryDataRequest{PluginContext: datasourcePluginContext("b")})
+			queued <- err
+		}()
+		close(unblock)
+		require.NoError(t, <-done)
+		require.NoError(t, <-queued)
+	})
+
+	t.Run("Should open circuit after repeated failures", func(t *testing.T) {
+		limiter := newConcurrencyLimiter(ConcurrencyLimitConfig{
+			FailureThreshold: 2,
+			OpenDuration:     time.Minute,
+		}, prometheus.NewRegistry())
+		now := time.Now()
+		limiter.now = func() time.Time { return now }
+		cdt := handlertest.NewHandlerMiddlewareTest(t, handlertest.WithMiddlewares(
+			backend.HandlerMiddlewareFunc(func(next backend.Handler) backend.Handler {
+				return &ConcurrencyLimitMiddleware{BaseHandler: backend.NewBaseHandler(next), limiter: limiter}
+			}),
+		))
+
+		calls := 0
+		var pluginErr error
+		cdt.TestHandler.QueryDataFunc = func(ctx context.Context, req *backend.QueryDataRequest) (*backend.QueryDataResponse, error) {
+			calls++
+			return &backend.QueryDataResponse{}, pluginErr
+		}
+		query := func() error {
+			_, err := cdt.MiddlewareHandler.QueryData(context.Background(), &backend.QueryDataRequest{PluginContext: datasourcePluginContext("es")})
+			return err
+		}
+
+		pluginErr = context.DeadlineExceeded
+		require.Error(t, query())
+		require.Error(t, query())
+		require.Equal(t, 2, calls)
+
+		err := query()
+		require.ErrorIs(t, err, errCircuitOpen)
+		require.True(t, backend.IsDownstreamError(err))
+		require.Equal(t, 2, calls)
+
+		// Other data sources are not affected.
+		pluginErr = nil
+		_, err = cdt.MiddlewareHandler.QueryData(context.Background(), &backend.QueryDataRequest{PluginContext: datasourcePluginContext("other")})
+		require.NoError(t, err)
+		require.Equal(t, 3, calls)
+
+		// A failed probe opens the circuit again.
+		now = now.Add(time.Minute)
+		pluginErr = &net.OpError{Op: "dial", Net: "tcp", Err: syscall.ECONNREFUSED}
+		require.ErrorIs(t, query(), syscall.ECONNREFUSED)
+		require.ErrorIs(t, query(), errCircuitOpen)
+		require.Equal(t, 4, calls)
+
+		// A successful probe closes the circuit.
+		now = now.Add(time.Minute)
+		pluginErr = nil
+		require.NoError(t, query())
+		require.NoError(t, query())
+		require.Equal(t, 6, calls)
+	})
+
+	t.Run("Should only count unavailable data sources as failures", func(t *testing.T) {
+		cdt := handlertest.NewHandlerMiddlewareTest(t, handlertest.WithMiddlewares(
+			NewConcurrencyLimitMiddleware(ConcurrencyLimitConfig{
+				FailureThreshold: 1,
+				OpenDuration:     time.Minute,
+			}, prometheus.NewRegistry()),
+		))
+
+		var resp backend.DataResponse
+		var pluginErr error
+		cdt.TestHandler.QueryDataFunc = func(ctx context.Context, req *backend.QueryDataRequest) (*backend.QueryDataResponse, error) {
+			return &backend.QueryDataResponse{Responses: backend.Responses{"A": resp}}, pluginErr
+		}
+		query := func() error {
+			_, err := cdt.MiddlewareHandler.QueryData(context.Background(), &backend.QueryDataRequest{PluginContext: datasourcePluginContext("es")})
+			return err
+		}
+
+		// Plugin errors and invalid queries do not open the circuit.
+		pluginErr = errors.New("invalid query")
+		require.EqualError(t, query(), "invalid query")
+		pluginErr = nil
+		resp = backend.ErrDataResponseWithSource(backend.StatusBadRequest, backend.ErrorSourceDownstream, "syntax error")
+		require.NoError(t, query())
+		resp = backend.ErrDataResponseWithSource(backend.StatusInternal, backend.ErrorSourcePlugin, "plugin bug")
+		require.NoError(t, query())
+		require.NoError(t, query())
+
+		// Server errors of the data source do.
+		resp = backend.ErrDataResponseWithSource(backend.StatusBadGateway, backend.ErrorSourceDownstream, "bad gateway")
+		require.NoError(t, query())
+		require.ErrorIs(t, query(), errCircuitOpen)
+	})
+
+	t.Run("Should count resource server errors as failures", func(t *testing.T) {
+		cdt := handlertest.NewHandlerMiddlewareTest(t,
+			handlertest.WithMiddlewares(NewConcurrencyLimitMiddleware(ConcurrencyLimitConfig{
+				FailureThreshold: 1,
+				OpenDuration:     time.Minute,
+			}, prometheus.NewRegistry())),
+			handlertest.WithResourceResponses([]*backend.CallResourceResponse{{Status: 503}}),
+		)
+
+		req := &backend.CallResourceRequest{PluginContext: datasourcePluginContext("es")}
+		require.NoError(t, cdt.MiddlewareHandler.CallResource(context.Background(), req, nopCallResourceSender))
+		require.ErrorIs(t, cdt.MiddlewareHandler.CallResource(context.Background(), req, nopCallResourceSender), errCircuitOpen)
+	})
+}
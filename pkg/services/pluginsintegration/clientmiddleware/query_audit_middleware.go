@@ -0,0 +1,192 @@
+package clientmiddleware
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+
+	"github.com/grafana/grafana/pkg/apimachinery/identity"
+	"github.com/grafana/grafana/pkg/plugins/instrumentationutils"
+	"github.com/grafana/grafana/pkg/services/contexthandler"
+	"github.com/grafana/grafana/pkg/services/query"
+	"github.com/grafana/grafana/pkg/services/queryaudit"
+)
+
+// NewQueryAuditMiddleware creates a new backend.HandlerMiddleware that records
+// data source queries and resource calls in the query audit log.
+func NewQueryAuditMiddleware(auditor *queryaudit.Service) backend.HandlerMiddleware {
+	return backend.HandlerMiddlewareFunc(func(next backend.Handler) backend.Handler {
+		return &QueryAuditMiddleware{
+			BaseHandler: backend.NewBaseHandler(next),
+			auditor:     auditor,
+		}
+	})
+}
+
+type QueryAuditMiddleware struct {
+	backend.BaseHandler
+	auditor *queryaudit.Service
+}
+
+func (m *QueryAuditMiddleware) newRecord(ctx context.Context, endpoint string, pCtx backend.PluginContext, start time.Time) queryaudit.Record {
+	rec := queryaudit.Record{
+		Timestamp:  start,
+		Endpoint:   endpoint,
+		OrgID:      pCtx.OrgID,
+		PluginID:   pCtx.PluginID,
+		DurationMs: time.Since(start).Milliseconds(),
+	}
+	if settings := pCtx.DataSourceInstanceSettings; settings != nil {
+		rec.DatasourceUID = settings.UID
+	}
+	if u := pCtx.User; u != nil {
+		rec.UserLogin = u.Login
+	}
+	if requester, err := identity.GetRequester(ctx); err == nil {
+		rec.UserUID = requester.GetUID()
+	}
+	if reqCtx := contexthandler.FromContext(ctx); reqCtx != nil && reqCtx.Req != nil {
+		rec.DashboardUID = reqCtx.Req.Header.Get(query.HeaderDashboardUID)
+		rec.PanelID = reqCtx.Req.Header.Get(query.HeaderPanelID)
+	}
+	return rec
+}
+
+func (m *QueryAuditMiddleware) QueryData(ctx context.Context, req *backend.QueryDataRequest) (*backend.QueryDataResponse, error) {
+	if req == nil {
+		return m.BaseHandler.QueryData(ctx, req)
+	}
+
+	start := time.Now()
+	resp, err := m.BaseHandler.QueryData(ctx, req)
+
+	rec := m.newRecord(ctx, queryaudit.EndpointQueryData, req.PluginContext, start)
+	rec.Status = instrumentationutils.RequestStatusFromQueryDataResponse(resp, err).String()
+
+	queries := make([]string, 0, len(req.Queries))
+	for _, q := range req.Queries {
+		queries = append(queries, string(q.JSON))
+		from, to := q.TimeRange.From, q.TimeRange.To
+		if rec.From == nil || from.Before(*rec.From) {
+			rec.From = &from
+		}
+		if rec.To == nil || to.After(*rec.To) {
+			rec.To = &to
+		}
+	}
+	rec.Query = "[" + strings.Join(queries, ",") + "]"
+
+	if err != nil {
+		rec.Error = err.Error()
+	} else if resp != nil {
+		rec.BytesReturned = estimateResponseBytes(resp)
+		errs := []string{}
+		for refID, dr := range resp.Responses {
+			if dr.Error != nil {
+				errs = append(errs, fmt.Sprintf("%s: %s", refID, dr.Error))
+			}
+		}
+		sort.Strings(errs)
+		rec.Error = strings.Join(errs, "; ")
+	}
+
+	m.auditor.Log(rec)
+	return resp, err
+}
+
+func (m *QueryAuditMiddleware) CallResource(ctx context.Context, req *backend.CallResourceRequest, sender backend.CallResourceResponseSender) error {
+	if req == nil {
+		return m.BaseHandler.CallResource(ctx, req, sender)
+	}
+
+	start := time.Now()
+	var bytesReturned int64
+	statusCode := 0
+	auditSender := backend.CallResourceResponseSenderFunc(func(res *backend.CallResourceResponse) error {
+		if res != nil {
+			if statusCode == 0 {
+				statusCode = res.Status
+			}
+			bytesReturned += int64(len(res.Body))
+		}
+		return sender.Send(res)
+	})
+	err := m.BaseHandler.CallResource(ctx, req, auditSender)
+
+	rec := m.newRecord(ctx, queryaudit.EndpointCallResource, req.PluginContext, start)
+	rec.Query = req.Method + " " + req.URL
+	if len(req.Body) > 0 {
+		rec.Query += "\n" + string(req.Body)
+	}
+	rec.BytesReturned = bytesReturned
+	status := instrumentationutils.RequestStatusFromError(err)
+	if err != nil {
+		rec.Error = err.Error()
+	} else if statusCode >= 500 {
+		status = instrumentationutils.RequestStatusError
+		rec.Error = fmt.Sprintf("resource responded with status %d", statusCode)
+	}
+	rec.Status = status.String()
+
+	m.auditor.Log(rec)
+	return err
+}
+
+// estimateResponseBytes returns the approximate size of the values returned in
+// the frames of the response. It avoids encoding the response, which would cost
+// as much as the query itself for large responses.
+func estimateResponseBytes(resp *backend.QueryDataResponse) int64 {
+	var size int64
+	for _, dr := range resp.Responses {
+		for _, frame := range dr.Frames {
+			if frame == nil {
+				continue
+			}
+			for _, field := range frame.Fields {
+				if field != nil {
+					size += estimateFieldBytes(field)
+				}
+			}
+		}
+	}
+	return size
+}
+
+func estimateFieldBytes(field *data.Field) int64 {
+	n := int64(field.Len())
+	switch field.Type().NonNullableType() {
+	case data.FieldTypeString, data.FieldTypeJSON:
+		var size int64
+		for i := 0; i < field.Len(); i++ {
+			switch v := field.At(i).(type) {
+			case string:
+				size += int64(len(v))
+			case *string:
+				if v != nil {
+					size += int64(len(*v))
+				}
+			case json.RawMessage:
+				size += int64(len(v))
+			case *json.RawMessage:
+				if v != nil {
+					size += int64(len(*v))
+				}
+			}
+		}
+		return size
+	case data.FieldTypeInt8, data.FieldTypeUint8, data.FieldTypeBool:
+		return n
+	case data.FieldTypeInt16, data.FieldTypeUint16:
+		return 2 * n
+	case data.FieldTypeInt32, data.FieldTypeUint32, data.FieldTypeFloat32:
+		return 4 * n
+	default:
+		return 8 * n
+	}
+}
@@ -0,0 +1,128 @@
+package clientmiddleware
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/handlertest"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/apimachinery/identity"
+	"github.com/grafana/grafana/pkg/services/contexthandler/ctxkey"
+	"github.com/grafana/grafana/pkg/services/query"
+	"github.com/grafana/grafana/pkg/services/queryaudit"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+func TestQueryAuditMiddleware(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "query-audit.log")
+	cfg := setting.NewCfg()
+	section := cfg.Raw.Section("query_audit")
+	section.Key("enabled").SetValue("true")
+	section.Key("file_path").SetValue(path)
+	auditor, err := queryaudit.ProvideService(cfg, nil)
+	require.NoError(t, err)
+
+	cdt := handlertest.NewHandlerMiddlewareTest(t,
+		handlertest.WithMiddlewares(NewQueryAuditMiddleware(auditor)),
+		handlertest.WithResourceResponses([]*backend.CallResourceResponse{{Status: http.StatusBadGateway, Body: []byte("upstream down")}}),
+	)
+	cdt.TestHandler.QueryDataFunc = func(ctx context.Context, req *backend.QueryDataRequest) (*backend.QueryDataResponse, error) {
+		return &backend.QueryDataResponse{Responses: backend.Responses{
+			"A": backend.DataResponse{Frames: data.Frames{data.NewFrame("", data.NewField("value", nil, []float64{1, 2}))}},
+			"B": backend.ErrDataResponse(backend.StatusBadRequest, "parse error"),
+		}}, nil
+	}
+
+	reqCtx := newReqContextWithRequest(&http.Request{Header: http.Header{
+		query.HeaderDashboardUID: []string{"dash-1"},
+		query.HeaderPanelID:      []string{"4"},
+	}})
+	ctx := ctxkey.Set(context.Background(), reqCtx)
+	ctx = identity.WithRequester(ctx, &identity.StaticRequester{Type: "user", UserUID: "u1", Login: "alice", OrgID: 1})
+
+	pCtx := backend.PluginContext{
+		OrgID:                      1,
+		PluginID:                   "prometheus",
+		User:                       &backend.User{Login: "alice"},
+		DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{UID: "prom"},
+	}
+	from := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	_, err = cdt.MiddlewareHandler.QueryData(ctx, &backend.QueryDataRequest{
+		PluginContext: pCtx,
+		Queries: []backend.DataQuery{
+			{RefID: "A", JSON: []byte(`{"expr":"up"}`), TimeRange: backend.TimeRange{From: from, To: from.Add(time.Hour)}},
+			{RefID: "B", JSON: []byte(`{"expr":"rate(x[5m])","apiKey":"abc"}`), TimeRange: backend.TimeRange{From: from.Add(-time.Hour), To: from}},
+		},
+	})
+	require.NoError(t, err)
+
+	err = cdt.MiddlewareHandler.CallResource(ctx, &backend.CallResourceRequest{
+		PluginContext: pCtx,
+		Method:        http.MethodGet,
+		URL:           "api/v1/labels?token=secret",
+	}, nopCallResourceSender)
+	require.NoError(t, err)
+
+	runCtx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- auditor.Run(runCtx) }()
+	cancel()
+	require.ErrorIs(t, <-done, context.Canceled)
+
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer func() { _ = f.Close() }()
+	records := []queryaudit.Record{}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var r queryaudit.Record
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &r))
+		records = append(records, r)
+	}
+	require.Len(t, records, 2)
+
+	queryRecord := records[0]
+	require.Equal(t, queryaudit.EndpointQueryData, queryRecord.Endpoint)
+	require.Equal(t, int64(1), queryRecord.OrgID)
+	require.Equal(t, "user:u1", queryRecord.UserUID)
+	require.Equal(t, "alice", queryRecord.UserLogin)
+	require.Equal(t, "prometheus", queryRecord.PluginID)
+	require.Equal(t, "prom", queryRecord.DatasourceUID)
+	require.Equal(t, "dash-1", queryRecord.DashboardUID)
+	require.Equal(t, "4", queryRecord.PanelID)
+	require.Equal(t, `[{"expr":"up"},{"expr":"rate(x[5m])","apiKey":"[REDACTED]"}]`, queryRecord.Query)
+	require.True(t, from.Add(-time.Hour).Equal(*queryRecord.From))
+	require.True(t, from.Add(time.Hour).Equal(*queryRecord.To))
+	require.Equal(t, "error", queryRecord.Status)
+	require.Equal(t, "B: parse error", queryRecord.Error)
+	require.Equal(t, int64(16), queryRecord.BytesReturned)
+
+	resourceRecord := records[1]
+	require.Equal(t, queryaudit.EndpointCallResource, resourceRecord.Endpoint)
+	require.Equal(t, "GET api/v1/labels?token=[REDACTED]", resourceRecord.Query)
+	require.Equal(t, int64(len("upstream down")), resourceRecord.BytesReturned)
+	require.Equal(t, "error", resourceRecord.Status)
+	require.Equal(t, "resource responded with status 502", resourceRecord.Error)
+}
+
+func TestEstimateResponseBytes(t *testing.T) {
+	resp := &backend.QueryDataResponse{Responses: backend.Responses{
+		"A": {Frames: data.Frames{data.NewFrame("",
+			data.NewField("time", nil, []time.Time{time.Now(), time.Now()}),
+			data.NewField("value", nil, []*float64{nil, nil}),
+			data.NewField("host", nil, []string{"a", "bcd"}),
+			data.NewField("enabled", nil, []bool{true, false}),
+		)}},
+		"B": backend.ErrDataResponse(backend.StatusBadRequest, "parse error"),
+	}}
+	require.Equal(t, int64(16+16+4+2), estimateResponseBytes(resp))
+}
@@ -59,6 +59,7 @@ import (
 	"github.com/grafana/grafana/pkg/services/pluginsintegration/provisionedplugins"
 	"github.com/grafana/grafana/pkg/services/pluginsintegration/renderer"
 	"github.com/grafana/grafana/pkg/services/pluginsintegration/serviceregistration"
+	"github.com/grafana/grafana/pkg/services/queryaudit"
 	"github.com/grafana/grafana/pkg/services/rendering"
 	"github.com/grafana/grafana/pkg/setting"
 )
@@ -167,22 +168,23 @@ func ProvideClientWithMiddlewares(
 	cachingService caching.CachingService,
 	features featuremgmt.FeatureToggles,
 	promRegisterer prometheus.Registerer,
+	queryAuditor *queryaudit.Service,
 ) (*backend.MiddlewareHandler, error) {
-	return NewMiddlewareHandler(cfg, pluginRegistry, oAuthTokenService, tracer, cachingService, features, promRegisterer, pluginRegistry)
+	return NewMiddlewareHandler(cfg, pluginRegistry, oAuthTokenService, tracer, cachingService, features, promRegisterer, pluginRegistry, queryAuditor)
 }
 
 func NewMiddlewareHandler(
 	cfg *setting.Cfg,
 	pluginRegistry registry.Service, oAuthTokenService oauthtoken.OAuthTokenService,
 	tracer tracing.Tracer, cachingService caching.CachingService, features featuremgmt.FeatureToggles,
-	promRegisterer prometheus.Registerer, registry registry.Service,
+	promRegisterer prometheus.Registerer, registry registry.Service, queryAuditor *queryaudit.Service,
 ) (*backend.MiddlewareHandler, error) {
 	c := client.ProvideService(pluginRegistry)
-	middlewares := CreateMiddlewares(cfg, oAuthTokenService, tracer, cachingService, features, promRegisterer, registry)
+	middlewares := CreateMiddlewares(cfg, oAuthTokenService, tracer, cachingService, features, promRegisterer, registry, queryAuditor)
 	return backend.HandlerFromMiddlewares(c, middlewares...)
 }
 
-func CreateMiddlewares(cfg *setting.Cfg, oAuthTokenService oauthtoken.OAuthTokenService, tracer tracing.Tracer, cachingService caching.CachingService, features featuremgmt.FeatureToggles, promRegisterer prometheus.Registerer, registry registry.Service) []backend.HandlerMiddleware {
+func CreateMiddlewares(cfg *setting.Cfg, oAuthTokenService oauthtoken.OAuthTokenService, tracer tracing.Tracer, cachingService caching.CachingService, features featuremgmt.FeatureToggles, promRegisterer prometheus.Registerer, registry registry.Service, queryAuditor *queryaudit.Service) []backend.HandlerMiddleware {
 	middlewares := []backend.HandlerMiddleware{
 		clientmiddleware.NewTracingMiddleware(tracer),
 		clientmiddleware.NewMetricsMiddleware(promRegisterer, registry),
@@ -193,6 +195,10 @@ func CreateMiddlewares(cfg *setting.Cfg, oAuthTokenService oauthtoken.OAuthToken
 		middlewares = append(middlewares, clientmiddleware.NewLoggerMiddleware(log.New("plugin.instrumentation"), registry))
 	}
 
+	if queryAuditor.Enabled() {
+		middlewares = append(middlewares, clientmiddleware.NewQueryAuditMiddleware(queryAuditor))
+	}
+
 	skipCookiesNames := []string{cfg.LoginCookieName}
 
 	middlewares = append(middlewares,
@@ -201,6 +207,14 @@ func CreateMiddlewares(cfg *setting.Cfg, oAuthTokenService oauthtoken.OAuthToken
 		clientmiddleware.NewOAuthTokenMiddleware(oAuthTokenService),
 		clientmiddleware.NewCookiesMiddleware(skipCookiesNames),
 		clientmiddleware.NewCachingMiddlewareWithFeatureManager(cachingService, features),
+		// Placed after caching so that cached responses do not take a concurrency slot.
+		clientmiddleware.NewConcurrencyLimitMiddleware(clientmiddleware.ConcurrencyLimitConfig{
+			MaxConcurrentPerDatasource: cfg.PluginMaxConcurrentRequestsPerDatasource,
+			MaxConcurrentPerPlugin:     cfg.PluginMaxConcurrentRequestsPerPlugin,
+			QueueTimeout:               cfg.PluginConcurrentRequestsQueueTimeout,
+			FailureThreshold:           cfg.PluginCircuitBreakerFailureThreshold,
+			OpenDuration:               cfg.PluginCircuitBreakerOpenDuration,
+		}, promRegisterer),
 		clientmiddleware.NewForwardIDMiddleware(),
 		clientmiddleware.NewUseAlertHeadersMiddleware(),
 	)
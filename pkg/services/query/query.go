@@ -11,6 +11,7 @@ import (
 
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
 	"github.com/grafana/grafana-plugin-sdk-go/backend/gtime"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
 	"golang.org/x/sync/errgroup"
 
 	"github.com/grafana/grafana/pkg/api/dtos"
@@ -25,6 +26,7 @@ import (
 	"github.com/grafana/grafana/pkg/services/dsquerierclient"
 	"github.com/grafana/grafana/pkg/services/ngalert/models"
 	"github.com/grafana/grafana/pkg/services/pluginsintegration/plugincontext"
+	"github.com/grafana/grafana/pkg/services/querycost"
 	"github.com/grafana/grafana/pkg/services/validations"
 	"github.com/grafana/grafana/pkg/setting"
 	"github.com/grafana/grafana/pkg/tsdb/grafanads"
@@ -50,6 +52,7 @@ func ProvideService(
 	pluginClient plugins.Client,
 	pCtxProvider *plugincontext.Provider,
 	qsDatasourceClientBuilder dsquerierclient.QSDatasourceClientBuilder,
+	costChecker *querycost.Service,
 ) *ServiceImpl {
 	g := &ServiceImpl{
 		cfg:                        cfg,
@@ -61,6 +64,10 @@ func ProvideService(
 		log:                        log.New("query_data"),
 		concurrentQueryLimit:       cfg.SectionWithEnvOverrides("query").Key("concurrent_query_limit").MustInt(runtime.NumCPU()),
 		qsDatasourceClientBuilder:  qsDatasourceClientBuilder,
+		costChecker:                costChecker,
+	}
+	if limits, enabled := readQueryRateLimits(cfg); enabled {
+		g.rateLimiter = newQueryRateLimiter(limits)
 	}
 	g.log.Info("Query Service initialization")
 	return g
@@ -89,6 +96,8 @@ type ServiceImpl struct {
 	concurrentQueryLimit       int
 	qsDatasourceClientBuilder  dsquerierclient.QSDatasourceClientBuilder
 	headers                    map[string]string
+	rateLimiter                *queryRateLimiter
+	costChecker                *querycost.Service
 }
 
 // Run ServiceImpl.
@@ -98,7 +107,8 @@ func (s *ServiceImpl) Run(ctx context.Context) error {
 }
 
 // QueryData processes queries and returns query responses. It handles queries to single or mixed datasources, as well as expressions.
-func (s *ServiceImpl) queryData(ctx context.Context, user identity.Requester, skipDSCache bool, reqDTO dtos.MetricRequest, supportLocaltimeRange bool) (*backend.QueryDataResponse, error) {
+// Rate limits and query costs are only checked once per request, so checkLimits is false for the per datasource requests of mixed queries.
+func (s *ServiceImpl) queryData(ctx context.Context, user identity.Requester, skipDSCache bool, reqDTO dtos.MetricRequest, supportLocaltimeRange bool, checkLimits bool) (*backend.QueryDataResponse, error) {
 	fromAlert := false
 	for header, val := range s.headers {
 		if header == models.FromAlertHeaderName && val == "true" {
@@ -111,6 +121,30 @@ func (s *ServiceImpl) queryData(ctx context.Context, user identity.Requester, sk
 		return nil, err
 	}
 
+	var costWarnings querycost.Warnings
+	// Enforce the query limits before any plugin is called. Alert rule evaluations are not limited,
+	// their query cost is checked when the rule is saved.
+	if checkLimits && !fromAlert {
+		if s.rateLimiter != nil && user != nil {
+			if err := s.rateLimiter.allow(user, parsedReq); err != nil {
+				return nil, err
+			}
+		}
+		costWarnings, err = s.checkQueryCost(ctx, user, parsedReq)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	resp, err := s.executeParsedRequest(ctx, user, skipDSCache, reqDTO, parsedReq, fromAlert)
+	if err != nil {
+		return nil, err
+	}
+	addCostWarnings(resp, costWarnings)
+	return resp, nil
+}
+
+func (s *ServiceImpl) executeParsedRequest(ctx context.Context, user identity.Requester, skipDSCache bool, reqDTO dtos.MetricRequest, parsedReq *parsedRequest, fromAlert bool) (*backend.QueryDataResponse, error) {
 	// If there are expressions, handle them and return
 	if parsedReq.hasExpression || fromAlert {
 		return s.handleExpressions(ctx, user, parsedReq)
@@ -123,12 +157,51 @@ func (s *ServiceImpl) queryData(ctx context.Context, user identity.Requester, sk
 	return s.executeConcurrentQueries(ctx, user, skipDSCache, reqDTO, parsedReq.parsedQueries)
 }
 
+// checkQueryCost rejects the request if the estimated cost of a data source query is above the configured limits.
+func (s *ServiceImpl) checkQueryCost(ctx context.Context, user identity.Requester, parsedReq *parsedRequest) (querycost.Warnings, error) {
+	if !s.costChecker.Enabled() {
+		return nil, nil
+	}
+	requests := make([]querycost.DataSourceQueries, 0, len(parsedReq.parsedQueries))
+	for uid, queries := range parsedReq.parsedQueries {
+		if len(queries) == 0 || expr.NodeTypeFromDatasourceUID(uid) != expr.TypeDatasourceNode {
+			continue
+		}
+		dataQueries := make([]backend.DataQuery, 0, len(queries))
+		for _, pq := range queries {
+			dataQueries = append(dataQueries, pq.query)
+		}
+		requests = append(requests, querycost.DataSourceQueries{DataSource: queries[0].datasource, Queries: dataQueries})
+	}
+	return s.costChecker.Check(ctx, user, requests)
+}
+
+// addCostWarnings adds a notice to the first frame of each query with a cost warning.
+func addCostWarnings(resp *backend.QueryDataResponse, warnings querycost.Warnings) {
+	if resp == nil {
+		return
+	}
+	for refID, texts := range warnings {
+		dr, ok := resp.Responses[refID]
+		if !ok || len(dr.Frames) == 0 {
+			continue
+		}
+		frame := dr.Frames[0]
+		if frame.Meta == nil {
+			frame.Meta = &data.FrameMeta{}
+		}
+		for _, text := range texts {
+			frame.Meta.Notices = append(frame.Meta.Notices, data.Notice{Severity: data.NoticeSeverityWarning, Text: text})
+		}
+	}
+}
+
 func (s *ServiceImpl) QueryData(ctx context.Context, user identity.Requester, skipDSCache bool, reqDTO dtos.MetricRequest) (*backend.QueryDataResponse, error) {
-	return s.queryData(ctx, user, skipDSCache, reqDTO, false)
+	return s.queryData(ctx, user, skipDSCache, reqDTO, false, true)
 }
 
 func (s *ServiceImpl) QueryDataNew(ctx context.Context, user identity.Requester, skipDSCache bool, reqDTO dtos.MetricRequest) (*backend.QueryDataResponse, error) {
-	return s.queryData(ctx, user, skipDSCache, reqDTO, true)
+	return s.queryData(ctx, user, skipDSCache, reqDTO, true, true)
 }
 
 // splitResponse contains the results of a concurrent data source query - the response and any headers
@@ -172,7 +245,7 @@ func (s *ServiceImpl) executeConcurrentQueries(ctx context.Context, user identit
 			defer recoveryFn(subDTO.Queries)
 
 			ctxCopy := contexthandler.CopyWithReqContext(ctx)
-			subResp, err := s.QueryData(ctxCopy, user, skipDSCache, subDTO)
+			subResp, err := s.queryData(ctxCopy, user, skipDSCache, subDTO, false, false)
 			if err == nil {
 				reqCtx, header := contexthandler.FromContext(ctxCopy), http.Header{}
 				if reqCtx != nil {
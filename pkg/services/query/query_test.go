@@ -781,6 +781,7 @@ func setup(t *testing.T, isMultiTenant bool, mockClient clientapi.QueryDataClien
 		pc,
 		pCtxProvider,
 		qsdsClientBuilder,
+		nil,
 	)
 
 	return &testContext{
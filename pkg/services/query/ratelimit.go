@@ -0,0 +1,321 @@
+package query
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"golang.org/x/time/rate"
+
+	"github.com/grafana/grafana/pkg/apimachinery/errutil"
+	"github.com/grafana/grafana/pkg/apimachinery/identity"
+	"github.com/grafana/grafana/pkg/expr"
+	"github.com/grafana/grafana/pkg/services/datasources"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+// DataSourceRateLimitsKey is the key in a data source's JSON data that
+// overrides the query rate limits for queries to that data source.
+const DataSourceRateLimitsKey = "queryRateLimits"
+
+const (
+	rateLimitScopeUser = "user"
+	rateLimitScopeTeam = "team"
+	rateLimitScopeOrg  = "org"
+)
+
+var (
+	errQueryRateLimited    = errutil.TooManyRequests("query.rateLimited", errutil.WithPublicMessage("Query rate limit exceeded, please retry later"))
+	errQueryBatchTooLarge  = errutil.TooManyRequests("query.batchTooLarge", errutil.WithPublicMessage("Request contains more queries than the query rate limit allows"))
+	queryRateLimitRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "grafana",
+		Name:      "query_rate_limit_requests_total",
+		Help:      "Number of data source query requests checked against the query rate limits.",
+	}, []string{"datasource_type", "result"})
+	queryRateLimitQueries = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "grafana",
+		Name:      "query_rate_limit_queries_total",
+		Help:      "Number of data source queries checked against the query rate limits.",
+	}, []string{"datasource_type", "result"})
+)
+
+// RateLimitError is returned when a query request exceeds a rate limit.
+// RetryAfter is zero when the request can never be allowed as sent.
+type RateLimitError struct {
+	RetryAfter time.Duration
+	err        error
+}
+
+func (e *RateLimitError) Error() string {
+	return e.err.Error()
+}
+
+func (e *RateLimitError) Unwrap() error {
+	return e.err
+}
+
+// RetryAfterSeconds returns the value for the Retry-After header, rounded up to whole seconds.
+func (e *RateLimitError) RetryAfterSeconds() string {
+	return strconv.Itoa(int(math.Ceil(e.RetryAfter.Seconds())))
+}
+
+// QueryRateLimit is a token bucket limit on requests and queries. A zero
+// rate disables that part of the limit. A zero burst defaults to the rate
+// rounded up.
+type QueryRateLimit struct {
+	RequestsPerSecond float64 `json:"requestsPerSecond,omitempty"`
+	RequestBurst      int     `json:"requestBurst,omitempty"`
+	QueriesPerSecond  float64 `json:"queriesPerSecond,omitempty"`
+	QueryBurst        int     `json:"queryBurst,omitempty"`
+}
+
+// QueryRateLimits holds the limits applied to each user, to each team a
+// user is a member of and to each organization.
+type QueryRateLimits struct {
+	User QueryRateLimit `json:"user"`
+	Team QueryRateLimit `json:"team"`
+	Org  QueryRateLimit `json:"org"`
+}
+
+func (l QueryRateLimits) forScope(scope string) QueryRateLimit {
+	switch scope {
+	case rateLimitScopeTeam:
+		return l.Team
+	case rateLimitScopeOrg:
+		return l.Org
+	default:
+		return l.User
+	}
+}
+
+func readQueryRateLimits(cfg *setting.Cfg) (QueryRateLimits, bool) {
+	section := cfg.SectionWithEnvOverrides("query_rate_limit")
+	readLimit := func(scope string) QueryRateLimit {
+		return QueryRateLimit{
+			RequestsPerSecond: section.Key(scope + "_requests_per_second").MustFloat64(0),
+			RequestBurst:      section.Key(scope + "_request_burst").MustInt(0),
+			QueriesPerSecond:  section.Key(scope + "_queries_per_second").MustFloat64(0),
+			QueryBurst:        section.Key(scope + "_query_burst").MustInt(0),
+		}
+	}
+	limits := QueryRateLimits{
+		User: readLimit(rateLimitScopeUser),
+		Team: readLimit(rateLimitScopeTeam),
+		Org:  readLimit(rateLimitScopeOrg),
+	}
+	return limits, section.Key("enabled").MustBool(false)
+}
+
+// dataSourceRateLimits returns the limits overridden in the data source's
+// settings, if any.
+func dataSourceRateLimits(ds *datasources.DataSource) (QueryRateLimits, bool, error) {
+	if ds.JsonData == nil {
+		return QueryRateLimits{}, false, nil
+	}
+	raw, ok := ds.JsonData.CheckGet(DataSourceRateLimitsKey)
+	if !ok {
+		return QueryRateLimits{}, false, nil
+	}
+	b, err := raw.MarshalJSON()
+	if err != nil {
+		return QueryRateLimits{}, false, err
+	}
+	limits := QueryRateLimits{}
+	if err := json.Unmarshal(b, &limits); err != nil {
+		return QueryRateLimits{}, false, fmt.Errorf("invalid %s for data source %s: %w", DataSourceRateLimitsKey, ds.UID, err)
+	}
+	return limits, true, nil
+}
+
+// rateLimitTarget is a group of queries sharing the same buckets. Queries
+// to data sources without overridden limits share the default buckets.
+type rateLimitTarget struct {
+	dsUID   string
+	limits  QueryRateLimits
+	queries int
+	// queriesByType counts the queries per data source type for metrics.
+	queriesByType map[string]int
+}
+
+func (t *rateLimitTarget) add(dsType string, queries int) {
+	t.queries += queries
+	t.queriesByType[dsType] += queries
+}
+
+type rateLimitBucket struct {
+	limiter  *rate.Limiter
+	lastUsed time.Time
+}
+
+// queryRateLimiter enforces token bucket limits on data source queries.
+type queryRateLimiter struct {
+	defaults QueryRateLimits
+	now      func() time.Time
+
+	mu        sync.Mutex
+	buckets   map[string]*rateLimitBucket
+	lastSweep time.Time
+}
+
+func newQueryRateLimiter(defaults QueryRateLimits) *queryRateLimiter {
+	return &queryRateLimiter{
+		defaults: defaults,
+		now:      time.Now,
+		buckets:  map[string]*rateLimitBucket{},
+	}
+}
+
+// allow takes tokens for the request from every bucket the user's queries
+// count against. Either all tokens are taken or none are.
+func (l *queryRateLimiter) allow(user identity.Requester, parsedReq *parsedRequest) error {
+	targets, err := l.targets(parsedReq)
+	if err != nil {
+		return err
+	}
+	if len(targets) == 0 {
+		return nil
+	}
+
+	subjects := rateLimitSubjects(user)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.sweep(now)
+
+	reservations := make([]*rate.Reservation, 0)
+	cancelAll := func() {
+		for _, r := range reservations {
+			r.CancelAt(now)
+		}
+	}
+	for _, target := range targets {
+		for _, subject := range subjects {
+			limit := target.limits.forScope(subject.scope)
+			checks := []struct {
+				kind  string
+				rps   float64
+				burst int
+				n     int
+			}{
+				{"requests", limit.RequestsPerSecond, limit.RequestBurst, 1},
+				{"queries", limit.QueriesPerSecond, limit.QueryBurst, target.queries},
+			}
+			for _, c := range checks {
+				if c.rps <= 0 {
+					continue
+				}
+				key := fmt.Sprintf("%s/%s/%s/%s", c.kind, subject.scope, subject.id, target.dsUID)
+				r := l.bucket(key, c.rps, c.burst, now).ReserveN(now, c.n)
+				if !r.OK() {
+					cancelAll()
+					l.observe(targets, subject.scope+"_limited")
+					return &RateLimitError{err: errQueryBatchTooLarge.Errorf("%d queries exceed the %s query burst", c.n, subject.scope)}
+				}
+				if delay := r.DelayFrom(now); delay > 0 {
+					r.CancelAt(now)
+					cancelAll()
+					l.observe(targets, subject.scope+"_limited")
+					return &RateLimitError{
+						RetryAfter: delay,
+						err:        errQueryRateLimited.Errorf("%s rate limit exceeded for %s %s", c.kind, subject.scope, subject.id),
+					}
+				}
+				reservations = append(reservations, r)
+			}
+		}
+	}
+	l.observe(targets, "allowed")
+	return nil
+}
+
+func (l *queryRateLimiter) targets(parsedReq *parsedRequest) ([]*rateLimitTarget, error) {
+	shared := &rateLimitTarget{limits: l.defaults, queriesByType: map[string]int{}}
+	targets := []*rateLimitTarget{}
+	for uid, queries := range parsedReq.parsedQueries {
+		if len(queries) == 0 || expr.NodeTypeFromDatasourceUID(uid) != expr.TypeDatasourceNode {
+			continue
+		}
+		ds := queries[0].datasource
+		limits, ok, err := dataSourceRateLimits(ds)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			shared.add(ds.Type, len(queries))
+			continue
+		}
+		target := &rateLimitTarget{dsUID: uid, limits: limits, queriesByType: map[string]int{}}
+		target.add(ds.Type, len(queries))
+		targets = append(targets, target)
+	}
+	if shared.queries > 0 {
+		targets = append(targets, shared)
+	}
+	return targets, nil
+}
+
+func (l *queryRateLimiter) bucket(key string, rps float64, burst int, now time.Time) *rate.Limiter {
+	if burst <= 0 {
+		burst = int(math.Ceil(rps))
+	}
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &rateLimitBucket{limiter: rate.NewLimiter(rate.Limit(rps), burst)}
+		l.buckets[key] = b
+	} else if b.limiter.Limit() != rate.Limit(rps) || b.limiter.Burst() != burst {
+		// The data source settings have changed since the bucket was created.
+		b.limiter.SetLimitAt(now, rate.Limit(rps))
+		b.limiter.SetBurstAt(now, burst)
+	}
+	b.lastUsed = now
+	return b.limiter
+}
+
+// sweep drops buckets that have refilled, as they are equivalent to new ones.
+func (l *queryRateLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < time.Minute {
+		return
+	}
+	l.lastSweep = now
+	for key, b := range l.buckets {
+		if now.Sub(b.lastUsed) >= time.Minute && b.limiter.TokensAt(now) >= float64(b.limiter.Burst()) {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+func (l *queryRateLimiter) observe(targets []*rateLimitTarget, result string) {
+	queriesByType := map[string]int{}
+	for _, target := range targets {
+		for dsType, queries := range target.queriesByType {
+			queriesByType[dsType] += queries
+		}
+	}
+	for dsType, queries := range queriesByType {
+		queryRateLimitRequests.WithLabelValues(dsType, result).Inc()
+		queryRateLimitQueries.WithLabelValues(dsType, result).Add(float64(queries))
+	}
+}
+
+type rateLimitSubject struct {
+	scope string
+	id    string
+}
+
+func rateLimitSubjects(user identity.Requester) []rateLimitSubject {
+	subjects := []rateLimitSubject{}
+	if uid := user.GetUID(); uid != "" {
+		subjects = append(subjects, rateLimitSubject{scope: rateLimitScopeUser, id: uid})
+	}
+	for _, teamID := range user.GetTeams() {
+		subjects = append(subjects, rateLimitSubject{scope: rateLimitScopeTeam, id: strconv.FormatInt(teamID, 10)})
+	}
+	return append(subjects, rateLimitSubject{scope: rateLimitScopeOrg, id: strconv.FormatInt(user.GetOrgID(), 10)})
+}
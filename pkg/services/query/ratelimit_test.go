@@ -0,0 +1,105 @@
+package query
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/services/datasources"
+	"github.com/grafana/grafana/pkg/services/user"
+)
+
+func rateLimitRequest(queries map[*datasources.DataSource]int) *parsedRequest {
+	req := &parsedRequest{parsedQueries: map[string][]parsedQuery{}}
+	for ds, n := range queries {
+		for i := 0; i < n; i++ {
+			req.parsedQueries[ds.UID] = append(req.parsedQueries[ds.UID], parsedQuery{datasource: ds})
+		}
+	}
+	return req
+}
+
+func TestQueryRateLimiter(t *testing.T) {
+	prom := &datasources.DataSource{UID: "prom", Type: "prometheus"}
+	loki := &datasources.DataSource{UID: "loki", Type: "loki"}
+	signedInUser := &user.SignedInUser{UserUID: "u1", OrgID: 1, Teams: []int64{7}}
+
+	newLimiter := func(limits QueryRateLimits) (*queryRateLimiter, *time.Time) {
+		l := newQueryRateLimiter(limits)
+		now := time.Now()
+		l.now = func() time.Time { return now }
+		return l, &now
+	}
+
+	t.Run("Should reject requests over the user limit with retry after", func(t *testing.T) {
+		l, now := newLimiter(QueryRateLimits{User: QueryRateLimit{RequestsPerSecond: 0.5, RequestBurst: 2}})
+		req := rateLimitRequest(map[*datasources.DataSource]int{prom: 1})
+
+		require.NoError(t, l.allow(signedInUser, req))
+		require.NoError(t, l.allow(signedInUser, req))
+		err := l.allow(signedInUser, req)
+		var rlErr *RateLimitError
+		require.ErrorAs(t, err, &rlErr)
+		require.ErrorIs(t, err, errQueryRateLimited)
+		require.Equal(t, 2*time.Second, rlErr.RetryAfter)
+		require.Equal(t, "2", rlErr.RetryAfterSeconds())
+
+		// Other users have their own bucket.
+		require.NoError(t, l.allow(&user.SignedInUser{UserUID: "u2", OrgID: 1}, req))
+
+		*now = now.Add(2 * time.Second)
+		require.NoError(t, l.allow(signedInUser, req))
+	})
+
+	t.Run("Should limit query count across data sources", func(t *testing.T) {
+		l, _ := newLimiter(QueryRateLimits{Org: QueryRateLimit{QueriesPerSecond: 1, QueryBurst: 5}})
+
+		require.NoError(t, l.allow(signedInUser, rateLimitRequest(map[*datasources.DataSource]int{prom: 2, loki: 2})))
+		require.ErrorIs(t, l.allow(signedInUser, rateLimitRequest(map[*datasources.DataSource]int{prom: 2})), errQueryRateLimited)
+		require.NoError(t, l.allow(signedInUser, rateLimitRequest(map[*datasources.DataSource]int{loki: 1})))
+
+		err := l.allow(&user.SignedInUser{OrgID: 2}, rateLimitRequest(map[*datasources.DataSource]int{prom: 6}))
+		var rlErr *RateLimitError
+		require.ErrorAs(t, err, &rlErr)
+		require.ErrorIs(t, err, errQueryBatchTooLarge)
+		require.Zero(t, rlErr.RetryAfter)
+	})
+
+	t.Run("Should not take tokens when another limit rejects the request", func(t *testing.T) {
+		l, _ := newLimiter(QueryRateLimits{
+			User: QueryRateLimit{RequestsPerSecond: 1, RequestBurst: 2},
+			Team: QueryRateLimit{RequestsPerSecond: 1, RequestBurst: 1},
+		})
+		req := rateLimitRequest(map[*datasources.DataSource]int{prom: 1})
+
+		require.NoError(t, l.allow(signedInUser, req))
+		require.ErrorIs(t, l.allow(signedInUser, req), errQueryRateLimited)
+		// The user bucket still has a token since the team limit rejected the request.
+		require.NoError(t, l.allow(&user.SignedInUser{UserUID: "u1", OrgID: 1}, req))
+	})
+
+	t.Run("Should use limits overridden in data source settings", func(t *testing.T) {
+		l, _ := newLimiter(QueryRateLimits{User: QueryRateLimit{RequestsPerSecond: 1, RequestBurst: 1}})
+		limited := &datasources.DataSource{UID: "es", Type: "elasticsearch", JsonData: simplejson.NewFromAny(map[string]any{
+			DataSourceRateLimitsKey: map[string]any{
+				"user": map[string]any{"requestsPerSecond": 1, "requestBurst": 3},
+			},
+		})}
+		req := rateLimitRequest(map[*datasources.DataSource]int{limited: 1})
+
+		require.NoError(t, l.allow(signedInUser, rateLimitRequest(map[*datasources.DataSource]int{prom: 1})))
+		for i := 0; i < 3; i++ {
+			require.NoError(t, l.allow(signedInUser, req))
+		}
+		require.ErrorIs(t, l.allow(signedInUser, req), errQueryRateLimited)
+	})
+
+	t.Run("Should not limit expression queries", func(t *testing.T) {
+		l, _ := newLimiter(QueryRateLimits{User: QueryRateLimit{QueriesPerSecond: 1, QueryBurst: 1}})
+		exprDS := &datasources.DataSource{UID: "__expr__", Type: "__expr__"}
+
+		require.NoError(t, l.allow(signedInUser, rateLimitRequest(map[*datasources.DataSource]int{prom: 1, exprDS: 3})))
+	})
+}
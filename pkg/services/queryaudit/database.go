@@ -0,0 +1,42 @@
+package queryaudit
+
+import (
+	"context"
+	"time"
+
+	"github.com/grafana/grafana/pkg/infra/db"
+)
+
+// sqlSink stores records in the query_audit table.
+type sqlSink struct {
+	db db.DB
+}
+
+func (s *sqlSink) Name() string {
+	return SinkSQL
+}
+
+func (s *sqlSink) Write(ctx context.Context, records []Record) error {
+	rows := make([]*queryAudit, 0, len(records))
+	for _, r := range records {
+		rows = append(rows, toQueryAudit(r))
+	}
+	return s.db.WithDbSession(ctx, func(sess *db.Session) error {
+		_, err := sess.InsertMulti(rows)
+		return err
+	})
+}
+
+// deleteBefore removes records older than the retention period.
+func (s *sqlSink) deleteBefore(ctx context.Context, before time.Time) (int64, error) {
+	var affected int64
+	err := s.db.WithDbSession(ctx, func(sess *db.Session) error {
+		res, err := sess.Exec("DELETE FROM query_audit WHERE timestamp < ?", before)
+		if err != nil {
+			return err
+		}
+		affected, err = res.RowsAffected()
+		return err
+	})
+	return affected, err
+}
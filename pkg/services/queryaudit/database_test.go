@@ -0,0 +1,49 @@
+package queryaudit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/tests/testsuite"
+	"github.com/grafana/grafana/pkg/util/testutil"
+)
+
+func TestMain(m *testing.M) {
+	testsuite.Run(m)
+}
+
+func TestIntegrationSQLSink(t *testing.T) {
+	testutil.SkipIntegrationTestInShortMode(t)
+
+	store := db.InitTestDB(t)
+	sink := &sqlSink{db: store}
+	ctx := context.Background()
+
+	now := time.Now().UTC().Truncate(time.Second)
+	from := now.Add(-time.Hour)
+	err := sink.Write(ctx, []Record{
+		{Timestamp: now.Add(-48 * time.Hour), OrgID: 1, Endpoint: EndpointQueryData, PluginID: "prometheus", Status: "ok"},
+		{Timestamp: now, OrgID: 1, Endpoint: EndpointQueryData, PluginID: "prometheus", DatasourceUID: "prom", Query: `[{"expr":"up"}]`, From: &from, To: &now, DurationMs: 12, BytesReturned: 512, Status: "ok"},
+	})
+	require.NoError(t, err)
+
+	deleted, err := sink.deleteBefore(ctx, now.Add(-24*time.Hour))
+	require.NoError(t, err)
+	require.Equal(t, int64(1), deleted)
+
+	rows := []*queryAudit{}
+	err = store.WithDbSession(ctx, func(sess *db.Session) error {
+		return sess.Find(&rows)
+	})
+	require.NoError(t, err)
+	require.Len(t, rows, 1)
+	require.Equal(t, "prom", rows[0].DatasourceUID)
+	require.Equal(t, `[{"expr":"up"}]`, rows[0].Query)
+	require.Equal(t, int64(512), rows[0].BytesReturned)
+	require.NotNil(t, rows[0].TimeFrom)
+	require.True(t, from.Equal(*rows[0].TimeFrom))
+}
@@ -0,0 +1,97 @@
+package queryaudit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type lokiConfig struct {
+	URL      string
+	TenantID string
+	Username string
+	Password string
+}
+
+// lokiSink pushes records to Loki, one stream per org and endpoint.
+type lokiSink struct {
+	cfg    lokiConfig
+	client *http.Client
+}
+
+func newLokiSink(cfg lokiConfig) *lokiSink {
+	return &lokiSink{
+		cfg:    cfg,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+type lokiStream struct {
+	Stream map[string]string `json:"stream"`
+	Values [][2]string       `json:"values"`
+}
+
+type lokiPushRequest struct {
+	Streams []*lokiStream `json:"streams"`
+}
+
+func (s *lokiSink) Name() string {
+	return SinkLoki
+}
+
+func (s *lokiSink) Write(ctx context.Context, records []Record) error {
+	streams := map[string]*lokiStream{}
+	push := lokiPushRequest{}
+	for _, r := range records {
+		line, err := json.Marshal(r)
+		if err != nil {
+			return err
+		}
+		key := fmt.Sprintf("%d/%s", r.OrgID, r.Endpoint)
+		stream, ok := streams[key]
+		if !ok {
+			stream = &lokiStream{Stream: map[string]string{
+				"service_name": "grafana",
+				"source":       "query_audit",
+				"org_id":       strconv.FormatInt(r.OrgID, 10),
+				"endpoint":     r.Endpoint,
+			}}
+			streams[key] = stream
+			push.Streams = append(push.Streams, stream)
+		}
+		stream.Values = append(stream.Values, [2]string{strconv.FormatInt(r.Timestamp.UnixNano(), 10), string(line)})
+	}
+
+	body, err := json.Marshal(push)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimSuffix(s.cfg.URL, "/")+"/loki/api/v1/push", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if s.cfg.TenantID != "" {
+		req.Header.Set("X-Scope-OrgID", s.cfg.TenantID)
+	}
+	if s.cfg.Username != "" {
+		req.SetBasicAuth(s.cfg.Username, s.cfg.Password)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("loki push failed with status %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
@@ -0,0 +1,21 @@
+package queryaudit
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	metricRecordsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "grafana",
+		Subsystem: "query_audit",
+		Name:      "records_total",
+		Help:      "Number of query audit records written, by sink and result.",
+	}, []string{"sink", "result"})
+	metricRecordsDropped = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: "grafana",
+		Subsystem: "query_audit",
+		Name:      "records_dropped_total",
+		Help:      "Number of query audit records dropped because the buffer was full.",
+	})
+)
@@ -0,0 +1,75 @@
+package queryaudit
+
+import (
+	"time"
+)
+
+const (
+	EndpointQueryData    = "queryData"
+	EndpointCallResource = "callResource"
+)
+
+// Record is a single audited data source query or resource call.
+type Record struct {
+	Timestamp     time.Time  `json:"timestamp"`
+	Endpoint      string     `json:"endpoint"`
+	OrgID         int64      `json:"orgId"`
+	UserUID       string     `json:"userUid,omitempty"`
+	UserLogin     string     `json:"userLogin,omitempty"`
+	PluginID      string     `json:"pluginId"`
+	DatasourceUID string     `json:"datasourceUid,omitempty"`
+	DashboardUID  string     `json:"dashboardUid,omitempty"`
+	PanelID       string     `json:"panelId,omitempty"`
+	Query         string     `json:"query,omitempty"`
+	From          *time.Time `json:"from,omitempty"`
+	To            *time.Time `json:"to,omitempty"`
+	DurationMs    int64      `json:"durationMs"`
+	BytesReturned int64      `json:"bytesReturned"`
+	Status        string     `json:"status"`
+	Error         string     `json:"error,omitempty"`
+}
+
+type queryAudit struct {
+	ID            int64      `xorm:"pk autoincr 'id'"`
+	OrgID         int64      `xorm:"org_id"`
+	Timestamp     time.Time  `xorm:"timestamp"`
+	Endpoint      string     `xorm:"endpoint"`
+	UserUID       string     `xorm:"user_uid"`
+	UserLogin     string     `xorm:"user_login"`
+	PluginID      string     `xorm:"plugin_id"`
+	DatasourceUID string     `xorm:"datasource_uid"`
+	DashboardUID  string     `xorm:"dashboard_uid"`
+	PanelID       string     `xorm:"panel_id"`
+	Query         string     `xorm:"query"`
+	TimeFrom      *time.Time `xorm:"time_from"`
+	TimeTo        *time.Time `xorm:"time_to"`
+	DurationMs    int64      `xorm:"duration_ms"`
+	BytesReturned int64      `xorm:"bytes_returned"`
+	Status        string     `xorm:"status"`
+	Error         string     `xorm:"error"`
+}
+
+func (queryAudit) TableName() string {
+	return "query_audit"
+}
+
+func toQueryAudit(r Record) *queryAudit {
+	return &queryAudit{
+		OrgID:         r.OrgID,
+		Timestamp:     r.Timestamp,
+		Endpoint:      r.Endpoint,
+		UserUID:       r.UserUID,
+		UserLogin:     r.UserLogin,
+		PluginID:      r.PluginID,
+		DatasourceUID: r.DatasourceUID,
+		DashboardUID:  r.DashboardUID,
+		PanelID:       r.PanelID,
+		Query:         r.Query,
+		TimeFrom:      r.From,
+		TimeTo:        r.To,
+		DurationMs:    r.DurationMs,
+		BytesReturned: r.BytesReturned,
+		Status:        r.Status,
+		Error:         r.Error,
+	}
+}
@@ -0,0 +1,56 @@
+package queryaudit
+
+import (
+	"fmt"
+	"regexp"
+)
+
+const redacted = "[REDACTED]"
+
+// defaultRedactRules mask common ways secrets end up in query text. The
+// replacement keeps the key so the record still shows what was sent.
+var defaultRedactRules = []redactRule{
+	{
+		re:   regexp.MustCompile(`(?i)((?:password|passwd|pwd|secret|token|api[_-]?key|access[_-]?key|authorization)["']?\s*[:=]\s*["']?(?:(?:bearer|basic)\s+)?)[^"'\s&,;}]+`),
+		repl: "${1}" + redacted,
+	},
+	{
+		re:   regexp.MustCompile(`(?i)(bearer\s+)[a-z0-9._~+/=-]+`),
+		repl: "${1}" + redacted,
+	},
+	{
+		re:   regexp.MustCompile(`([a-zA-Z][a-zA-Z0-9+.-]*://[^:/@\s]+:)[^@/\s]+@`),
+		repl: "${1}" + redacted + "@",
+	},
+}
+
+type redactRule struct {
+	re   *regexp.Regexp
+	repl string
+}
+
+// Redactor masks secrets in query text.
+type Redactor struct {
+	rules []redactRule
+}
+
+// NewRedactor returns a Redactor using the default rules and the given
+// patterns. Text matching a pattern is replaced in full.
+func NewRedactor(patterns []string) (*Redactor, error) {
+	rules := append([]redactRule{}, defaultRedactRules...)
+	for _, p := range patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid redact pattern %q: %w", p, err)
+		}
+		rules = append(rules, redactRule{re: re, repl: redacted})
+	}
+	return &Redactor{rules: rules}, nil
+}
+
+func (r *Redactor) Redact(s string) string {
+	for _, rule := range r.rules {
+		s = rule.re.ReplaceAllString(s, rule.repl)
+	}
+	return s
+}
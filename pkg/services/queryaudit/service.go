@@ -0,0 +1,193 @@
+package queryaudit
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"time"
+	"unicode/utf8"
+
+	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/util"
+)
+
+const (
+	maxBatchSize           = 500
+	retentionCheckInterval = time.Hour
+)
+
+// Service records who queried which data source and hands the records
+// to the configured sinks in batches.
+type Service struct {
+	enabled        bool
+	sinks          []Sink
+	sqlSink        *sqlSink
+	retention      time.Duration
+	redactor       *Redactor
+	maxQueryLength int
+	flushInterval  time.Duration
+	records        chan Record
+	now            func() time.Time
+	log            log.Logger
+}
+
+func ProvideService(cfg *setting.Cfg, db db.DB) (*Service, error) {
+	section := cfg.SectionWithEnvOverrides("query_audit")
+	s := &Service{
+		enabled: section.Key("enabled").MustBool(false),
+		now:     time.Now,
+		log:     log.New("query.audit"),
+	}
+	if !s.enabled {
+		return s, nil
+	}
+
+	redactor, err := NewRedactor(util.SplitString(section.Key("redact_patterns").String()))
+	if err != nil {
+		return nil, err
+	}
+	s.redactor = redactor
+	s.maxQueryLength = section.Key("max_query_length").MustInt(4096)
+	s.flushInterval = section.Key("flush_interval").MustDuration(5 * time.Second)
+	s.records = make(chan Record, section.Key("buffer_size").MustInt(10000))
+
+	for _, name := range util.SplitString(section.Key("sinks").MustString(SinkFile)) {
+		switch name {
+		case SinkFile:
+			path := section.Key("file_path").MustString(filepath.Join(cfg.LogsPath, "query-audit.log"))
+			sink, err := newFileSink(path, section.Key("file_max_size_mb").MustInt(256), section.Key("file_max_days").MustInt64(7))
+			if err != nil {
+				return nil, fmt.Errorf("failed to open query audit file: %w", err)
+			}
+			s.sinks = append(s.sinks, sink)
+		case SinkSQL:
+			s.sqlSink = &sqlSink{db: db}
+			s.retention = section.Key("sql_retention").MustDuration(30 * 24 * time.Hour)
+			s.sinks = append(s.sinks, s.sqlSink)
+		case SinkLoki:
+			lokiCfg := lokiConfig{
+				URL:      section.Key("loki_url").String(),
+				TenantID: section.Key("loki_tenant_id").String(),
+				Username: section.Key("loki_username").String(),
+				Password: section.Key("loki_password").String(),
+			}
+			if lokiCfg.URL == "" {
+				return nil, fmt.Errorf("query audit sink %q requires loki_url", SinkLoki)
+			}
+			s.sinks = append(s.sinks, newLokiSink(lokiCfg))
+		default:
+			return nil, fmt.Errorf("unknown query audit sink %q", name)
+		}
+	}
+	return s, nil
+}
+
+// Enabled returns true if records should be collected.
+func (s *Service) Enabled() bool {
+	return s != nil && s.enabled
+}
+
+func (s *Service) IsDisabled() bool {
+	return !s.Enabled()
+}
+
+// Log redacts the record and queues it for the sinks. Records are dropped
+// rather than blocking the query when the buffer is full.
+func (s *Service) Log(r Record) {
+	if !s.Enabled() {
+		return
+	}
+	r.Query = s.redactor.Redact(r.Query)
+	if s.maxQueryLength > 0 && len(r.Query) > s.maxQueryLength {
+		// Cut on a rune boundary so the stored query stays valid UTF-8.
+		cut := s.maxQueryLength
+		for cut > 0 && !utf8.RuneStart(r.Query[cut]) {
+			cut--
+		}
+		r.Query = r.Query[:cut] + "..."
+	}
+	r.Error = s.redactor.Redact(r.Error)
+
+	select {
+	case s.records <- r:
+	default:
+		metricRecordsDropped.Inc()
+	}
+}
+
+func (s *Service) Run(ctx context.Context) error {
+	flushTicker := time.NewTicker(s.flushInterval)
+	defer flushTicker.Stop()
+	retentionTicker := time.NewTicker(retentionCheckInterval)
+	defer retentionTicker.Stop()
+
+	s.deleteExpired(ctx)
+
+	batch := make([]Record, 0, maxBatchSize)
+	flush := func(ctx context.Context) {
+		if len(batch) == 0 {
+			return
+		}
+		s.write(ctx, batch)
+		batch = make([]Record, 0, maxBatchSize)
+	}
+
+	for {
+		select {
+		case r := <-s.records:
+			batch = append(batch, r)
+			if len(batch) >= maxBatchSize {
+				flush(ctx)
+			}
+		case <-flushTicker.C:
+			flush(ctx)
+		case <-retentionTicker.C:
+			s.deleteExpired(ctx)
+		case <-ctx.Done():
+			// Write what is left so records are not lost on shutdown.
+			flushCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+		drain:
+			for {
+				select {
+				case r := <-s.records:
+					batch = append(batch, r)
+					if len(batch) >= maxBatchSize {
+						flush(flushCtx)
+					}
+				default:
+					break drain
+				}
+			}
+			flush(flushCtx)
+			return ctx.Err()
+		}
+	}
+}
+
+func (s *Service) write(ctx context.Context, batch []Record) {
+	for _, sink := range s.sinks {
+		if err := sink.Write(ctx, batch); err != nil {
+			s.log.Error("Failed to write query audit records", "sink", sink.Name(), "records", len(batch), "error", err)
+			metricRecordsTotal.WithLabelValues(sink.Name(), "error").Add(float64(len(batch)))
+			continue
+		}
+		metricRecordsTotal.WithLabelValues(sink.Name(), "success").Add(float64(len(batch)))
+	}
+}
+
+func (s *Service) deleteExpired(ctx context.Context) {
+	if s.sqlSink == nil || s.retention <= 0 {
+		return
+	}
+	deleted, err := s.sqlSink.deleteBefore(ctx, s.now().Add(-s.retention))
+	if err != nil {
+		s.log.Error("Failed to delete expired query audit records", "error", err)
+		return
+	}
+	if deleted > 0 {
+		s.log.Debug("Deleted expired query audit records", "count", deleted)
+	}
+}
@@ -0,0 +1,56 @@
+package queryaudit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+)
+
+const (
+	SinkFile = "file"
+	SinkSQL  = "sql"
+	SinkLoki = "loki"
+)
+
+// Sink stores batches of audit records.
+type Sink interface {
+	Name() string
+	Write(ctx context.Context, records []Record) error
+}
+
+// fileSink writes records as JSON lines to a local file that is rotated
+// by size and by day.
+type fileSink struct {
+	w *log.FileLogWriter
+}
+
+func newFileSink(path string, maxSizeMB int, maxDays int64) (*fileSink, error) {
+	w := log.NewFileWriter()
+	w.Filename = path
+	w.Maxsize = maxSizeMB << 20
+	w.Maxdays = maxDays
+	w.Maxlines = 0
+	if err := w.Init(); err != nil {
+		return nil, err
+	}
+	return &fileSink{w: w}, nil
+}
+
+func (s *fileSink) Name() string {
+	return SinkFile
+}
+
+func (s *fileSink) Write(_ context.Context, records []Record) error {
+	for _, r := range records {
+		var buf bytes.Buffer
+		if err := json.NewEncoder(&buf).Encode(r); err != nil {
+			return err
+		}
+		if _, err := s.w.Write(buf.Bytes()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package querycost
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/grafana/grafana/pkg/apimachinery/errutil"
+)
+
+// ResourcePath is the resource data source plugins can implement to report
+// the estimated cost of queries before they run. The plugin receives an
+// EstimateRequest as the body of a POST request and responds with an
+// EstimateResponse. Only plugins setting queryCostEstimation in their
+// plugin.json are asked.
+const ResourcePath = "query-cost"
+
+var ErrQueryCostExceeded = errutil.BadRequest("query.costExceeded").MustTemplate(
+	"query {{ .Public.RefId }} estimated to return {{ .Public.Estimate }} {{ .Public.Measure }}, limit is {{ .Public.Limit }}",
+	errutil.WithPublic("Query {{ .Public.RefId }} is estimated to return {{ .Public.Estimate }} {{ .Public.Measure }}, which is more than the limit of {{ .Public.Limit }}. Reduce the time range or make the query more selective."),
+)
+
+// EstimateRequest is the body sent to the query-cost resource.
+type EstimateRequest struct {
+	Queries []EstimateQuery `json:"queries"`
+	// SeriesLimit is the highest series count Grafana acts on. Plugins may
+	// stop counting once it is reached.
+	SeriesLimit int64 `json:"seriesLimit,omitempty"`
+	// RowLimit is the highest row count Grafana acts on.
+	RowLimit int64 `json:"rowLimit,omitempty"`
+}
+
+type EstimateQuery struct {
+	RefID         string          `json:"refId"`
+	QueryType     string          `json:"queryType,omitempty"`
+	From          time.Time       `json:"from"`
+	To            time.Time       `json:"to"`
+	IntervalMs    int64           `json:"intervalMs"`
+	MaxDataPoints int64           `json:"maxDataPoints"`
+	Model         json.RawMessage `json:"model"`
+}
+
+// EstimateResponse is the body returned by the query-cost resource.
+type EstimateResponse struct {
+	Estimates []Estimate `json:"estimates"`
+}
+
+// Estimate is the expected cost of a single query. Zero values mean the
+// plugin could not estimate that measure.
+type Estimate struct {
+	RefID  string `json:"refId"`
+	Series int64  `json:"series,omitempty"`
+	Rows   int64  `json:"rows,omitempty"`
+}
+
+// Threshold is a warn and a reject limit for one measure. Zero disables
+// the limit.
+type Threshold struct {
+	Warn int64
+	Max  int64
+}
+
+type Config struct {
+	Enabled         bool
+	Series          Threshold
+	Rows            Threshold
+	Points          Threshold
+	EstimateTimeout time.Duration
+}
+
+// Warnings are messages for queries whose cost is above a warn threshold,
+// keyed by RefID.
+type Warnings map[string][]string
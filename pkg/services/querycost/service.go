@@ -0,0 +1,294 @@
+package querycost
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+
+	"github.com/grafana/grafana/pkg/apimachinery/errutil"
+	"github.com/grafana/grafana/pkg/apimachinery/identity"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/plugins"
+	"github.com/grafana/grafana/pkg/services/datasources"
+	"github.com/grafana/grafana/pkg/services/pluginsintegration/plugincontext"
+	"github.com/grafana/grafana/pkg/services/pluginsintegration/pluginstore"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+// unsupportedTTL is how long a plugin that does not implement the
+// query-cost resource is not asked again.
+const unsupportedTTL = 10 * time.Minute
+
+type pluginContextProvider interface {
+	GetWithDataSource(ctx context.Context, pluginID string, user identity.Requester, ds *datasources.DataSource) (backend.PluginContext, error)
+}
+
+type pluginStore interface {
+	Plugin(ctx context.Context, pluginID string) (pluginstore.Plugin, bool)
+}
+
+// DataSourceQueries are the queries of a request to a single data source.
+type DataSourceQueries struct {
+	DataSource *datasources.DataSource
+	Queries    []backend.DataQuery
+}
+
+// Service estimates the cost of data source queries and rejects the ones
+// above the configured limits before they run.
+type Service struct {
+	cfg          Config
+	pluginClient plugins.Client
+	pCtxProvider pluginContextProvider
+	pluginStore  pluginStore
+	now          func() time.Time
+	log          log.Logger
+
+	mu          sync.Mutex
+	unsupported map[string]time.Time
+}
+
+func ProvideService(cfg *setting.Cfg, pluginClient plugins.Client, pCtxProvider *plugincontext.Provider, pluginStore pluginstore.Store) *Service {
+	section := cfg.SectionWithEnvOverrides("query_cost")
+	return newService(Config{
+		Enabled: section.Key("enabled").MustBool(false),
+		Series: Threshold{
+			Warn: section.Key("warn_series").MustInt64(0),
+			Max:  section.Key("max_series").MustInt64(0),
+		},
+		Rows: Threshold{
+			Warn: section.Key("warn_rows").MustInt64(0),
+			Max:  section.Key("max_rows").MustInt64(0),
+		},
+		Points: Threshold{
+			Warn: section.Key("warn_points").MustInt64(0),
+			Max:  section.Key("max_points").MustInt64(0),
+		},
+		EstimateTimeout: section.Key("estimate_timeout").MustDuration(2 * time.Second),
+	}, pluginClient, pCtxProvider, pluginStore)
+}
+
+func newService(cfg Config, pluginClient plugins.Client, pCtxProvider pluginContextProvider, pluginStore pluginStore) *Service {
+	return &Service{
+		cfg:          cfg,
+		pluginClient: pluginClient,
+		pCtxProvider: pCtxProvider,
+		pluginStore:  pluginStore,
+		now:          time.Now,
+		log:          log.New("query.cost"),
+		unsupported:  map[string]time.Time{},
+	}
+}
+
+// Enabled returns true if queries should be checked.
+func (s *Service) Enabled() bool {
+	return s != nil && s.cfg.Enabled
+}
+
+// Check estimates the cost of queries to one or more data sources. It returns
+// ErrQueryCostExceeded if a query is above a limit, and warnings for
+// queries above a warn threshold. Queries the data source cannot estimate
+// are only checked against the points limit.
+func (s *Service) Check(ctx context.Context, user identity.Requester, requests []DataSourceQueries) (Warnings, error) {
+	if !s.Enabled() || len(requests) == 0 {
+		return nil, nil
+	}
+
+	estimates := s.estimateAll(ctx, user, requests)
+	warnings := Warnings{}
+	for i, r := range requests {
+		for _, q := range r.Queries {
+			est := estimates[i][q.RefID]
+			series := est.Series
+			if series <= 0 {
+				series = 1
+			}
+			checks := []struct {
+				measure   string
+				value     int64
+				threshold Threshold
+			}{
+				{"series", est.Series, s.cfg.Series},
+				{"rows", est.Rows, s.cfg.Rows},
+				{"data points", series * pointsPerSeries(q), s.cfg.Points},
+			}
+			for _, c := range checks {
+				if c.threshold.Max > 0 && c.value > c.threshold.Max {
+					s.log.FromContext(ctx).Info("Rejected query over cost limit", "datasource", r.DataSource.UID, "refId", q.RefID, "measure", c.measure, "estimate", c.value, "limit", c.threshold.Max)
+					return nil, ErrQueryCostExceeded.Build(errutil.TemplateData{
+						Public: map[string]any{
+							"RefId":    q.RefID,
+							"Measure":  c.measure,
+							"Estimate": c.value,
+							"Limit":    c.threshold.Max,
+						},
+					})
+				}
+				if c.threshold.Warn > 0 && c.value > c.threshold.Warn {
+					warnings[q.RefID] = append(warnings[q.RefID], fmt.Sprintf("Query is estimated to return %d %s, which is more than the recommended %d. Consider reducing the time range or making the query more selective.", c.value, c.measure, c.threshold.Warn))
+				}
+			}
+		}
+	}
+	return warnings, nil
+}
+
+// estimateAll asks the data sources for estimates in parallel. The estimate
+// timeout applies to all of them, the estimates still missing when it expires
+// are ignored.
+func (s *Service) estimateAll(ctx context.Context, user identity.Requester, requests []DataSourceQueries) []map[string]Estimate {
+	ctx, cancel := context.WithTimeout(ctx, s.cfg.EstimateTimeout)
+	defer cancel()
+
+	type result struct {
+		idx       int
+		estimates map[string]Estimate
+	}
+	// Buffered so estimates finishing after the timeout don't block.
+	results := make(chan result, len(requests))
+	pending := 0
+	for i, r := range requests {
+		if len(r.Queries) == 0 {
+			continue
+		}
+		pending++
+		go func() {
+			results <- result{idx: i, estimates: s.estimate(ctx, user, r.DataSource, r.Queries)}
+		}()
+	}
+
+	estimates := make([]map[string]Estimate, len(requests))
+	for ; pending > 0; pending-- {
+		select {
+		case r := <-results:
+			estimates[r.idx] = r.estimates
+		case <-ctx.Done():
+			s.log.FromContext(ctx).Warn("Timed out estimating query cost", "pending", pending)
+			return estimates
+		}
+	}
+	return estimates
+}
+
+// pointsPerSeries is the number of steps in the query's time range.
+func pointsPerSeries(q backend.DataQuery) int64 {
+	rng := q.TimeRange.Duration()
+	if rng <= 0 {
+		return 1
+	}
+	step := q.Interval
+	if step <= 0 && q.MaxDataPoints > 0 {
+		step = rng / time.Duration(q.MaxDataPoints)
+	}
+	if step <= 0 {
+		return 1
+	}
+	points := int64(rng / step)
+	if points < 1 {
+		return 1
+	}
+	return points
+}
+
+// estimate asks the data source plugin for estimates. Failures are logged
+// and the queries are checked without them, as the estimate is advisory.
+func (s *Service) estimate(ctx context.Context, user identity.Requester, ds *datasources.DataSource, queries []backend.DataQuery) map[string]Estimate {
+	estimates := map[string]Estimate{}
+	if s.pluginClient == nil || s.pCtxProvider == nil || !s.supported(ctx, ds.Type) {
+		return estimates
+	}
+	logger := s.log.FromContext(ctx)
+
+	pCtx, err := s.pCtxProvider.GetWithDataSource(ctx, ds.Type, user, ds)
+	if err != nil {
+		logger.Debug("Failed to get plugin context for query cost estimate", "datasource", ds.UID, "error", err)
+		return estimates
+	}
+
+	estReq := EstimateRequest{SeriesLimit: s.cfg.Series.Max, RowLimit: s.cfg.Rows.Max}
+	for _, q := range queries {
+		estReq.Queries = append(estReq.Queries, EstimateQuery{
+			RefID:         q.RefID,
+			QueryType:     q.QueryType,
+			From:          q.TimeRange.From,
+			To:            q.TimeRange.To,
+			IntervalMs:    q.Interval.Milliseconds(),
+			MaxDataPoints: q.MaxDataPoints,
+			Model:         q.JSON,
+		})
+	}
+	body, err := json.Marshal(estReq)
+	if err != nil {
+		return estimates
+	}
+
+	var resp *backend.CallResourceResponse
+	err = s.pluginClient.CallResource(ctx, &backend.CallResourceRequest{
+		PluginContext: pCtx,
+		Path:          ResourcePath,
+		Method:        http.MethodPost,
+		URL:           ResourcePath,
+		Headers:       map[string][]string{"Content-Type": {"application/json"}},
+		Body:          body,
+	}, backend.CallResourceResponseSenderFunc(func(res *backend.CallResourceResponse) error {
+		if resp == nil {
+			resp = res
+		}
+		return nil
+	}))
+	if err != nil {
+		logger.Warn("Failed to estimate query cost", "datasource", ds.UID, "error", err)
+		return estimates
+	}
+	if resp == nil || resp.Status == http.StatusNotFound || resp.Status == http.StatusNotImplemented {
+		s.markUnsupported(ds.Type)
+		return estimates
+	}
+	if resp.Status/100 != 2 {
+		logger.Warn("Failed to estimate query cost", "datasource", ds.UID, "status", resp.Status, "body", string(resp.Body))
+		return estimates
+	}
+
+	estResp := EstimateResponse{}
+	if err := json.Unmarshal(resp.Body, &estResp); err != nil {
+		logger.Warn("Invalid query cost estimate", "datasource", ds.UID, "error", err)
+		return estimates
+	}
+	for _, est := range estResp.Estimates {
+		estimates[est.RefID] = est
+	}
+	return estimates
+}
+
+// supported returns true if the plugin declares it estimates query costs in
+// its plugin.json, as other plugins may forward any resource call upstream.
+func (s *Service) supported(ctx context.Context, pluginID string) bool {
+	if s.pluginStore == nil {
+		return false
+	}
+	if p, ok := s.pluginStore.Plugin(ctx, pluginID); !ok || !p.QueryCostEstimation {
+		return false
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	until, ok := s.unsupported[pluginID]
+	if !ok {
+		return true
+	}
+	if s.now().After(until) {
+		delete(s.unsupported, pluginID)
+		return true
+	}
+	return false
+}
+
+func (s *Service) markUnsupported(pluginID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.unsupported[pluginID] = s.now().Add(unsupportedTTL)
+}
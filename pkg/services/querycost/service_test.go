@@ -0,0 +1,190 @@
+package querycost
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/apimachinery/identity"
+	"github.com/grafana/grafana/pkg/plugins"
+	"github.com/grafana/grafana/pkg/services/datasources"
+	"github.com/grafana/grafana/pkg/services/pluginsintegration/pluginstore"
+	"github.com/grafana/grafana/pkg/services/user"
+)
+
+type fakePluginClient struct {
+	plugins.Client
+	mu       sync.Mutex
+	calls    int
+	req      EstimateRequest
+	status   int
+	response EstimateResponse
+	// delay holds how long each plugin takes to respond.
+	delay map[string]time.Duration
+}
+
+func (c *fakePluginClient) CallResource(ctx context.Context, req *backend.CallResourceRequest, sender backend.CallResourceResponseSender) error {
+	c.mu.Lock()
+	c.calls++
+	delay := c.delay[req.PluginContext.PluginID]
+	if err := json.Unmarshal(req.Body, &c.req); err != nil {
+		c.mu.Unlock()
+		return err
+	}
+	body, err := json.Marshal(c.response)
+	c.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	select {
+	case <-time.After(delay):
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	return sender.Send(&backend.CallResourceResponse{Status: c.status, Body: body})
+}
+
+// fakePluginStore holds whether each plugin estimates query costs.
+type fakePluginStore map[string]bool
+
+func (f fakePluginStore) Plugin(_ context.Context, pluginID string) (pluginstore.Plugin, bool) {
+	supported, ok := f[pluginID]
+	if !ok {
+		return pluginstore.Plugin{}, false
+	}
+	return pluginstore.Plugin{JSONData: plugins.JSONData{ID: pluginID, QueryCostEstimation: supported}}, true
+}
+
+type fakePluginContextProvider struct{}
+
+func (fakePluginContextProvider) GetWithDataSource(ctx context.Context, pluginID string, user identity.Requester, ds *datasources.DataSource) (backend.PluginContext, error) {
+	return backend.PluginContext{PluginID: pluginID}, nil
+}
+
+func TestService_Check(t *testing.T) {
+	ds := &datasources.DataSource{UID: "prom", Type: "prometheus"}
+	signedInUser := &user.SignedInUser{UserID: 1, OrgID: 1}
+	pluginStore := fakePluginStore{"prometheus": true, "loki": true, "proxy": false}
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	query := func(refID string, rng time.Duration, interval time.Duration) backend.DataQuery {
+		return backend.DataQuery{
+			RefID:         refID,
+			Interval:      interval,
+			MaxDataPoints: 1000,
+			TimeRange:     backend.TimeRange{From: from, To: from.Add(rng)},
+			JSON:          []byte(`{"expr":"up"}`),
+		}
+	}
+
+	t.Run("Should reject queries over the series limit", func(t *testing.T) {
+		client := &fakePluginClient{status: http.StatusOK, response: EstimateResponse{Estimates: []Estimate{{RefID: "A", Series: 50}, {RefID: "B", Series: 5000}}}}
+		s := newService(Config{Enabled: true, Series: Threshold{Warn: 10, Max: 1000}, EstimateTimeout: time.Second}, client, fakePluginContextProvider{}, pluginStore)
+
+		_, err := s.Check(context.Background(), signedInUser, []DataSourceQueries{{DataSource: ds, Queries: []backend.DataQuery{query("A", time.Hour, time.Minute), query("B", time.Hour, time.Minute)}}})
+		require.ErrorIs(t, err, ErrQueryCostExceeded)
+		require.Contains(t, err.Error(), "query B estimated to return 5000 series, limit is 1000")
+		require.Equal(t, int64(1000), client.req.SeriesLimit)
+		require.Len(t, client.req.Queries, 2)
+		require.Equal(t, int64(60000), client.req.Queries[0].IntervalMs)
+		require.JSONEq(t, `{"expr":"up"}`, string(client.req.Queries[0].Model))
+	})
+
+	t.Run("Should warn for queries over the warn threshold", func(t *testing.T) {
+		client := &fakePluginClient{status: http.StatusOK, response: EstimateResponse{Estimates: []Estimate{{RefID: "A", Series: 50}, {RefID: "B", Series: 5}}}}
+		s := newService(Config{Enabled: true, Series: Threshold{Warn: 10, Max: 1000}, EstimateTimeout: time.Second}, client, fakePluginContextProvider{}, pluginStore)
+
+		warnings, err := s.Check(context.Background(), signedInUser, []DataSourceQueries{{DataSource: ds, Queries: []backend.DataQuery{query("A", time.Hour, time.Minute), query("B", time.Hour, time.Minute)}}})
+		require.NoError(t, err)
+		require.Len(t, warnings, 1)
+		require.Len(t, warnings["A"], 1)
+		require.Contains(t, warnings["A"][0], "50 series")
+	})
+
+	t.Run("Should reject queries over the points limit", func(t *testing.T) {
+		client := &fakePluginClient{status: http.StatusOK, response: EstimateResponse{Estimates: []Estimate{{RefID: "A", Series: 100}}}}
+		s := newService(Config{Enabled: true, Points: Threshold{Max: 1_000_000}, EstimateTimeout: time.Second}, client, fakePluginContextProvider{}, pluginStore)
+
+		// 100 series over 90 days at a 1m step.
+		_, err := s.Check(context.Background(), signedInUser, []DataSourceQueries{{DataSource: ds, Queries: []backend.DataQuery{query("A", 90*24*time.Hour, time.Minute)}}})
+		require.ErrorIs(t, err, ErrQueryCostExceeded)
+		require.Contains(t, err.Error(), "12960000 data points")
+
+		// Without an interval the step is the range divided by the max data points.
+		_, err = s.Check(context.Background(), signedInUser, []DataSourceQueries{{DataSource: ds, Queries: []backend.DataQuery{query("A", 90*24*time.Hour, 0)}}})
+		require.NoError(t, err)
+	})
+
+	t.Run("Should not ask plugins that do not implement estimates again", func(t *testing.T) {
+		client := &fakePluginClient{status: http.StatusNotFound}
+		s := newService(Config{Enabled: true, Series: Threshold{Max: 10}, Points: Threshold{Max: 100}, EstimateTimeout: time.Second}, client, fakePluginContextProvider{}, pluginStore)
+		now := time.Now()
+		s.now = func() time.Time { return now }
+
+		_, err := s.Check(context.Background(), signedInUser, []DataSourceQueries{{DataSource: ds, Queries: []backend.DataQuery{query("A", time.Hour, time.Minute)}}})
+		require.NoError(t, err)
+		_, err = s.Check(context.Background(), signedInUser, []DataSourceQueries{{DataSource: ds, Queries: []backend.DataQuery{query("A", time.Hour, time.Minute)}}})
+		require.NoError(t, err)
+		require.Equal(t, 1, client.calls)
+
+		// The points limit still applies without an estimate.
+		_, err = s.Check(context.Background(), signedInUser, []DataSourceQueries{{DataSource: ds, Queries: []backend.DataQuery{query("A", 4*time.Hour, time.Minute)}}})
+		require.ErrorIs(t, err, ErrQueryCostExceeded)
+
+		now = now.Add(unsupportedTTL + time.Second)
+		_, err = s.Check(context.Background(), signedInUser, []DataSourceQueries{{DataSource: ds, Queries: []backend.DataQuery{query("A", time.Hour, time.Minute)}}})
+		require.NoError(t, err)
+		require.Equal(t, 2, client.calls)
+	})
+
+	t.Run("Should only ask plugins declaring they estimate query costs", func(t *testing.T) {
+		client := &fakePluginClient{status: http.StatusOK, response: EstimateResponse{Estimates: []Estimate{{RefID: "A", Series: 5000}}}}
+		s := newService(Config{Enabled: true, Series: Threshold{Max: 1000}, EstimateTimeout: time.Second}, client, fakePluginContextProvider{}, pluginStore)
+
+		for _, pluginID := range []string{"proxy", "unknown"} {
+			_, err := s.Check(context.Background(), signedInUser, []DataSourceQueries{{
+				DataSource: &datasources.DataSource{UID: pluginID, Type: pluginID},
+				Queries:    []backend.DataQuery{query("A", time.Hour, time.Minute)},
+			}})
+			require.NoError(t, err)
+		}
+		require.Equal(t, 0, client.calls)
+	})
+
+	t.Run("Should estimate data sources in parallel under one timeout", func(t *testing.T) {
+		client := &fakePluginClient{
+			status:   http.StatusOK,
+			response: EstimateResponse{Estimates: []Estimate{{RefID: "A", Series: 5000}}},
+			delay:    map[string]time.Duration{"prometheus": 100 * time.Millisecond, "loki": 100 * time.Millisecond},
+		}
+		s := newService(Config{Enabled: true, Series: Threshold{Max: 1000}, EstimateTimeout: 150 * time.Millisecond}, client, fakePluginContextProvider{}, pluginStore)
+
+		_, err := s.Check(context.Background(), signedInUser, []DataSourceQueries{
+			{DataSource: ds, Queries: []backend.DataQuery{query("B", time.Hour, time.Minute)}},
+			{DataSource: &datasources.DataSource{UID: "loki", Type: "loki"}, Queries: []backend.DataQuery{query("A", time.Hour, time.Minute)}},
+		})
+		require.ErrorIs(t, err, ErrQueryCostExceeded)
+		require.Equal(t, 2, client.calls)
+
+		client.delay["loki"] = time.Minute
+		started := time.Now()
+		_, err = s.Check(context.Background(), signedInUser, []DataSourceQueries{
+			{DataSource: &datasources.DataSource{UID: "loki", Type: "loki"}, Queries: []backend.DataQuery{query("A", time.Hour, time.Minute)}},
+		})
+		require.NoError(t, err, "the estimate that timed out is ignored")
+		require.Less(t, time.Since(started), time.Second)
+	})
+
+	t.Run("Should not check queries when disabled", func(t *testing.T) {
+		var s *Service
+		require.False(t, s.Enabled())
+		warnings, err := s.Check(context.Background(), signedInUser, []DataSourceQueries{{DataSource: ds, Queries: []backend.DataQuery{query("A", time.Hour, time.Minute)}}})
+		require.NoError(t, err)
+		require.Nil(t, warnings)
+	})
+}
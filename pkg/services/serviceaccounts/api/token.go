@@ -147,21 +147,8 @@ func (api *ServiceAccountsAPI) CreateToken(c *contextmodel.ReqContext) response.
 	// Force affected service account to be the one referenced in the URL
 	cmd.OrgId = c.GetOrgID()
 
-	if api.cfg.ApiKeyMaxSecondsToLive != -1 {
-		if cmd.SecondsToLive == 0 {
-			return response.Error(http.StatusBadRequest, "Number of seconds before expiration should be set", nil)
-		}
-		if cmd.SecondsToLive > api.cfg.ApiKeyMaxSecondsToLive {
-			return response.Error(http.StatusBadRequest, "Number of seconds before expiration is greater than the global limit", nil)
-		}
-	}
-
-	if api.cfg.SATokenExpirationDayLimit > 0 {
-		dayExpireLimit := time.Now().Add(time.Duration(api.cfg.SATokenExpirationDayLimit) * time.Hour * 24).Truncate(24 * time.Hour)
-		expirationDate := time.Now().Add(time.Duration(cmd.SecondsToLive) * time.Second).Truncate(24 * time.Hour)
-		if expirationDate.After(dayExpireLimit) {
-			return response.Respond(http.StatusBadRequest, "The expiration date input exceeds the limit for service account access tokens expiration date")
-		}
+	if err := serviceaccounts.ValidateSecondsToLive(api.cfg, cmd.SecondsToLive); err != nil {
+		return response.Err(err)
 	}
 
 	newKeyInfo, err := satokengen.New(ServiceID)
@@ -185,6 +172,60 @@ func (api *ServiceAccountsAPI) CreateToken(c *contextmodel.ReqContext) response.
 	return response.JSON(http.StatusOK, result)
 }
 
+// swagger:route POST /serviceaccounts/{serviceAccountId}/tokens/{tokenId}/rotate service_accounts rotateToken
+//
+// # RotateToken replaces a service account token with a new one
+//
+// The rotated token remains valid for the overlap period, so clients can switch to the new token before it expires.
+//
+// Required permissions (See note in the [introduction](https://grafana.com/docs/grafana/latest/developers/http_api/serviceaccount/#service-account-api) for an explanation):
+// action: `serviceaccounts:write` scope: `serviceaccounts:id:1` (single service account)
+//
+// Responses:
+// 200: createTokenResponse
+// 400: badRequestError
+// 401: unauthorisedError
+// 403: forbiddenError
+// 404: notFoundError
+// 500: internalServerError
+func (api *ServiceAccountsAPI) RotateToken(c *contextmodel.ReqContext) response.Response {
+	saID, err := strconv.ParseInt(web.Params(c.Req)[":serviceAccountId"], 10, 64)
+	if err != nil {
+		return response.Error(http.StatusBadRequest, "Service Account ID is invalid", err)
+	}
+
+	tokenID, err := strconv.ParseInt(web.Params(c.Req)[":tokenId"], 10, 64)
+	if err != nil {
+		return response.Error(http.StatusBadRequest, "Token ID is invalid", err)
+	}
+
+	cmd := serviceaccounts.RotateServiceAccountTokenCommand{}
+	if err = web.Bind(c.Req, &cmd); err != nil {
+		return response.Error(http.StatusBadRequest, "Bad request data", err)
+	}
+
+	newKeyInfo, err := satokengen.New(ServiceID)
+	if err != nil {
+		return response.Error(http.StatusInternalServerError, "Generating service account token failed", err)
+	}
+
+	cmd.OrgId = c.GetOrgID()
+	cmd.Key = newKeyInfo.HashedKey
+
+	apiKey, err := api.service.RotateServiceAccountToken(c.Req.Context(), saID, tokenID, &cmd)
+	if err != nil {
+		return response.ErrOrFallback(http.StatusInternalServerError, "Failed to rotate service account token", err)
+	}
+
+	result := &dtos.NewApiKeyResult{
+		ID:   apiKey.ID,
+		Name: apiKey.Name,
+		Key:  newKeyInfo.ClientSecret,
+	}
+
+	return response.JSON(http.StatusOK, result)
+}
+
 // swagger:route DELETE /serviceaccounts/{serviceAccountId}/tokens/{tokenId} service_accounts deleteToken
 //
 // # DeleteToken deletes service account tokens
@@ -241,6 +282,16 @@ type CreateTokenParams struct {
 	Body serviceaccounts.AddServiceAccountTokenCommand
 }
 
+// swagger:parameters rotateToken
+type RotateTokenParams struct {
+	// in:path
+	TokenId int64 `json:"tokenId"`
+	// in:path
+	ServiceAccountId int64 `json:"serviceAccountId"`
+	// in:body
+	Body serviceaccounts.RotateServiceAccountTokenCommand
+}
+
 // swagger:parameters deleteToken
 type DeleteTokenParams struct {
 	// in:path
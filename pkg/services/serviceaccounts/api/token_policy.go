@@ -0,0 +1,73 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/grafana/grafana/pkg/api/response"
+	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
+	"github.com/grafana/grafana/pkg/services/serviceaccounts"
+	"github.com/grafana/grafana/pkg/web"
+)
+
+// swagger:route GET /serviceaccounts/token-policy service_accounts getTokenPolicy
+//
+// # Get the service account token policy of the organization
+//
+// Required permissions (See note in the [introduction](https://grafana.com/docs/grafana/latest/developers/http_api/serviceaccount/#service-account-api) for an explanation):
+// action: `serviceaccounts:read` scope: `serviceaccounts:*`
+//
+// Responses:
+// 200: getTokenPolicyResponse
+// 401: unauthorisedError
+// 403: forbiddenError
+// 500: internalServerError
+func (api *ServiceAccountsAPI) GetTokenPolicy(c *contextmodel.ReqContext) response.Response {
+	policy, err := api.service.GetTokenPolicy(c.Req.Context(), c.GetOrgID())
+	if err != nil {
+		return response.ErrOrFallback(http.StatusInternalServerError, "Failed to get token policy", err)
+	}
+
+	return response.JSON(http.StatusOK, policy)
+}
+
+// swagger:route PUT /serviceaccounts/token-policy service_accounts updateTokenPolicy
+//
+// # Update the service account token policy of the organization
+//
+// The policy applies to tokens created after the update. Idle tokens are revoked and
+// expiry warnings are sent by a background job.
+//
+// Required permissions (See note in the [introduction](https://grafana.com/docs/grafana/latest/developers/http_api/serviceaccount/#service-account-api) for an explanation):
+// action: `serviceaccounts:write` scope: `serviceaccounts:*`
+//
+// Responses:
+// 200: okResponse
+// 400: badRequestError
+// 401: unauthorisedError
+// 403: forbiddenError
+// 500: internalServerError
+func (api *ServiceAccountsAPI) UpdateTokenPolicy(c *contextmodel.ReqContext) response.Response {
+	policy := serviceaccounts.TokenPolicy{}
+	if err := web.Bind(c.Req, &policy); err != nil {
+		return response.Error(http.StatusBadRequest, "Bad request data", err)
+	}
+
+	if err := api.service.UpdateTokenPolicy(c.Req.Context(), c.GetOrgID(), &policy); err != nil {
+		return response.ErrOrFallback(http.StatusInternalServerError, "Failed to update token policy", err)
+	}
+
+	return response.Success("Token policy updated")
+}
+
+// swagger:parameters updateTokenPolicy
+type UpdateTokenPolicyParams struct {
+	// in:body
+	// required:true
+	Body serviceaccounts.TokenPolicy
+}
+
+// swagger:response getTokenPolicyResponse
+type GetTokenPolicyResponse struct {
+	// in:body
+	Body *serviceaccounts.TokenPolicy
+}
@@ -0,0 +1,80 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
+	"github.com/grafana/grafana/pkg/services/serviceaccounts"
+	satests "github.com/grafana/grafana/pkg/services/serviceaccounts/tests"
+	"github.com/grafana/grafana/pkg/services/user"
+	"github.com/grafana/grafana/pkg/web/webtest"
+)
+
+func TestServiceAccountsAPI_GetTokenPolicy(t *testing.T) {
+	policy := &serviceaccounts.TokenPolicy{MaxTTLDays: 90, RequireExpiry: true}
+	server := setupTests(t, func(a *ServiceAccountsAPI) {
+		a.service = &satests.FakeServiceAccountService{ExpectedTokenPolicy: policy}
+	})
+
+	req := server.NewGetRequest("/api/serviceaccounts/token-policy")
+	webtest.RequestWithSignedInUser(req, &user.SignedInUser{OrgID: 1, Permissions: map[int64]map[string][]string{1: accesscontrol.GroupScopesByActionContext(context.Background(), []accesscontrol.Permission{{Action: serviceaccounts.ActionRead, Scope: serviceaccounts.ScopeAll}})}})
+	res, err := server.Send(req)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, res.StatusCode)
+
+	result := &serviceaccounts.TokenPolicy{}
+	require.NoError(t, json.NewDecoder(res.Body).Decode(result))
+	assert.Equal(t, policy, result)
+	require.NoError(t, res.Body.Close())
+}
+
+func TestServiceAccountsAPI_UpdateTokenPolicy(t *testing.T) {
+	type TestCase struct {
+		desc         string
+		permissions  []accesscontrol.Permission
+		expectedErr  error
+		expectedCode int
+	}
+
+	tests := []TestCase{
+		{
+			desc:         "should be able to update token policy with correct permission",
+			permissions:  []accesscontrol.Permission{{Action: serviceaccounts.ActionWrite, Scope: serviceaccounts.ScopeAll}},
+			expectedCode: http.StatusOK,
+		},
+		{
+			desc:         "should not be able to update token policy with permission for a single service account",
+			permissions:  []accesscontrol.Permission{{Action: serviceaccounts.ActionWrite, Scope: "serviceaccounts:id:1"}},
+			expectedCode: http.StatusForbidden,
+		},
+		{
+			desc:         "should not be able to update invalid token policy",
+			permissions:  []accesscontrol.Permission{{Action: serviceaccounts.ActionWrite, Scope: serviceaccounts.ScopeAll}},
+			expectedErr:  serviceaccounts.ErrInvalidTokenPolicy.Errorf(""),
+			expectedCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			server := setupTests(t, func(a *ServiceAccountsAPI) {
+				a.service = &satests.FakeServiceAccountService{ExpectedErr: tt.expectedErr}
+			})
+
+			req := server.NewRequest(http.MethodPut, "/api/serviceaccounts/token-policy", strings.NewReader(`{"maxTtlDays": 90}`))
+			webtest.RequestWithSignedInUser(req, &user.SignedInUser{OrgID: 1, Permissions: map[int64]map[string][]string{1: accesscontrol.GroupScopesByActionContext(context.Background(), tt.permissions)}})
+			res, err := server.SendJSON(req)
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.expectedCode, res.StatusCode)
+			require.NoError(t, res.Body.Close())
+		})
+	}
+}
@@ -178,3 +178,78 @@ func TestServiceAccountsAPI_DeleteToken(t *testing.T) {
 		})
 	}
 }
+
+func TestServiceAccountsAPI_RotateToken(t *testing.T) {
+	type TestCase struct {
+		desc         string
+		saID         int64
+		body         string
+		permissions  []accesscontrol.Permission
+		tokenTTL     int64
+		policy       *serviceaccounts.TokenPolicy
+		expectedErr  error
+		expectedCode int
+	}
+
+	tests := []TestCase{
+		{
+			desc:         "should be able to rotate service account token with correct permission",
+			saID:         1,
+			body:         `{}`,
+			tokenTTL:     -1,
+			permissions:  []accesscontrol.Permission{{Action: serviceaccounts.ActionWrite, Scope: "serviceaccounts:id:1"}},
+			expectedCode: http.StatusOK,
+		},
+		{
+			desc:         "should not be able to rotate service account token with wrong permission",
+			saID:         2,
+			body:         `{}`,
+			tokenTTL:     -1,
+			permissions:  []accesscontrol.Permission{{Action: serviceaccounts.ActionWrite, Scope: "serviceaccounts:id:1"}},
+			expectedCode: http.StatusForbidden,
+		},
+		{
+			desc:         "should not be able to rotate service account token if its lifetime exceeds the global limit",
+			saID:         1,
+			body:         `{}`,
+			tokenTTL:     10 * int64(time.Hour),
+			permissions:  []accesscontrol.Permission{{Action: serviceaccounts.ActionWrite, Scope: "serviceaccounts:id:1"}},
+			expectedErr:  serviceaccounts.ErrTokenExceedsGlobalLimit.Errorf(""),
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			desc:         "should not be able to rotate revoked service account token",
+			saID:         1,
+			body:         `{}`,
+			tokenTTL:     -1,
+			permissions:  []accesscontrol.Permission{{Action: serviceaccounts.ActionWrite, Scope: "serviceaccounts:id:1"}},
+			expectedErr:  serviceaccounts.ErrTokenRevoked.Errorf(""),
+			expectedCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			policy := tt.policy
+			if policy == nil {
+				policy = &serviceaccounts.TokenPolicy{}
+			}
+			server := setupTests(t, func(a *ServiceAccountsAPI) {
+				a.cfg.ApiKeyMaxSecondsToLive = tt.tokenTTL
+				a.service = &satests.FakeServiceAccountService{
+					ExpectedErr:         tt.expectedErr,
+					ExpectedAPIKey:      &apikey.APIKey{ID: 2, Name: "rotated"},
+					ExpectedTokenPolicy: policy,
+				}
+			})
+
+			req := server.NewRequest(http.MethodPost, fmt.Sprintf("/api/serviceaccounts/%d/tokens/1/rotate", tt.saID), strings.NewReader(tt.body))
+			webtest.RequestWithSignedInUser(req, &user.SignedInUser{OrgID: 1, Permissions: map[int64]map[string][]string{1: accesscontrol.GroupScopesByActionContext(context.Background(), tt.permissions)}})
+			res, err := server.SendJSON(req)
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.expectedCode, res.StatusCode)
+			require.NoError(t, res.Body.Close())
+		})
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/grafana/grafana/pkg/infra/db"
 	"github.com/grafana/grafana/pkg/services/apikey"
@@ -41,6 +42,23 @@ func (s *ServiceAccountsStoreImpl) ListTokens(
 	return result, err
 }
 
+// ListActiveTokens returns the service account tokens of an organization that are neither revoked nor expired
+func (s *ServiceAccountsStoreImpl) ListActiveTokens(ctx context.Context, orgID int64) ([]apikey.APIKey, error) {
+	result := make([]apikey.APIKey, 0)
+	err := s.sqlStore.WithDbSession(ctx, func(sess *db.Session) error {
+		err := sess.Where("org_id=? AND service_account_id IS NOT NULL", orgID).
+			Where("(is_revoked IS NULL OR is_revoked = ?)", s.sqlStore.GetDialect().BooleanValue(false)).
+			Where("(expires IS NULL OR expires > ?)", time.Now().Unix()).
+			Asc("id").
+			Find(&result)
+		if err != nil {
+			return fmt.Errorf("%s: %w", "list active tokens error", err)
+		}
+		return nil
+	})
+	return result, err
+}
+
 func (s *ServiceAccountsStoreImpl) AddServiceAccountToken(ctx context.Context, serviceAccountId int64, cmd *serviceaccounts.AddServiceAccountTokenCommand) (*apikey.APIKey, error) {
 	var apiKey *apikey.APIKey
 
@@ -109,6 +127,24 @@ func (s *ServiceAccountsStoreImpl) RevokeServiceAccountToken(ctx context.Context
 	})
 }
 
+// UpdateServiceAccountTokenExpiry sets the expiration of a service account token
+func (s *ServiceAccountsStoreImpl) UpdateServiceAccountTokenExpiry(ctx context.Context, orgId, serviceAccountId, tokenId int64, expires int64) error {
+	rawSQL := "UPDATE api_key SET expires = ? WHERE id=? and org_id=? and service_account_id=?"
+
+	return s.sqlStore.WithDbSession(ctx, func(sess *db.Session) error {
+		result, err := sess.Exec(rawSQL, expires, tokenId, orgId, serviceAccountId)
+		if err != nil {
+			return err
+		}
+		affected, err := result.RowsAffected()
+		if affected == 0 {
+			return serviceaccounts.ErrServiceAccountTokenNotFound.Errorf("service account token with id %d not found for service account with id %d", tokenId, serviceAccountId)
+		}
+
+		return err
+	})
+}
+
 // assignApiKeyToServiceAccount sets the API key service account ID
 func (s *ServiceAccountsStoreImpl) assignApiKeyToServiceAccount(ctx context.Context, apiKeyId int64, serviceAccountId int64) error {
 	return s.sqlStore.WithDbSession(ctx, func(sess *db.Session) error {
@@ -3,6 +3,7 @@ package database
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 
@@ -185,3 +186,68 @@ func TestIntegration_Store_DeleteServiceAccountToken(t *testing.T) {
 		}
 	}
 }
+
+func TestIntegration_Store_ListActiveTokens(t *testing.T) {
+	testutil.SkipIntegrationTestInShortMode(t)
+
+	userToCreate := tests.TestUser{Login: "servicetestwithTeam@admin", IsServiceAccount: true}
+	db, store := setupTestDatabase(t)
+	sa := tests.SetupUserServiceAccount(t, db, store.cfg, userToCreate)
+
+	addToken := func(name string) int64 {
+		key, err := satokengen.New(name)
+		require.NoError(t, err)
+		newKey, err := store.AddServiceAccountToken(context.Background(), sa.ID, &serviceaccounts.AddServiceAccountTokenCommand{
+			Name:          name,
+			OrgId:         sa.OrgID,
+			Key:           key.HashedKey,
+			SecondsToLive: 3600,
+		})
+		require.NoError(t, err)
+		return newKey.ID
+	}
+
+	activeID := addToken("active")
+	revokedID := addToken("revoked")
+	expiredID := addToken("expired")
+
+	require.NoError(t, store.RevokeServiceAccountToken(context.Background(), sa.OrgID, sa.ID, revokedID))
+	require.NoError(t, store.UpdateServiceAccountTokenExpiry(context.Background(), sa.OrgID, sa.ID, expiredID, time.Now().Add(-time.Minute).Unix()))
+
+	keys, err := store.ListActiveTokens(context.Background(), sa.OrgID)
+	require.NoError(t, err)
+	require.Len(t, keys, 1)
+	require.Equal(t, activeID, keys[0].ID)
+
+	keys, err = store.ListActiveTokens(context.Background(), sa.OrgID+1)
+	require.NoError(t, err)
+	require.Empty(t, keys)
+}
+
+func TestIntegration_Store_UpdateServiceAccountTokenExpiry(t *testing.T) {
+	testutil.SkipIntegrationTestInShortMode(t)
+
+	userToCreate := tests.TestUser{Login: "servicetestwithTeam@admin", IsServiceAccount: true}
+	db, store := setupTestDatabase(t)
+	sa := tests.SetupUserServiceAccount(t, db, store.cfg, userToCreate)
+
+	key, err := satokengen.New(t.Name())
+	require.NoError(t, err)
+	newKey, err := store.AddServiceAccountToken(context.Background(), sa.ID, &serviceaccounts.AddServiceAccountTokenCommand{
+		Name:  t.Name(),
+		OrgId: sa.OrgID,
+		Key:   key.HashedKey,
+	})
+	require.NoError(t, err)
+
+	expires := time.Now().Add(time.Hour).Unix()
+	require.NoError(t, store.UpdateServiceAccountTokenExpiry(context.Background(), sa.OrgID, sa.ID, newKey.ID, expires))
+
+	keys, err := store.ListTokens(context.Background(), &serviceaccounts.GetSATokensQuery{OrgID: &sa.OrgID, ServiceAccountID: &sa.ID})
+	require.NoError(t, err)
+	require.Len(t, keys, 1)
+	require.Equal(t, expires, *keys[0].Expires)
+
+	err = store.UpdateServiceAccountTokenExpiry(context.Background(), sa.OrgID, sa.ID+1, newKey.ID, expires)
+	require.ErrorIs(t, err, serviceaccounts.ErrServiceAccountTokenNotFound)
+}
@@ -17,6 +17,7 @@ import (
 	"github.com/grafana/grafana/pkg/infra/usagestats"
 	"github.com/grafana/grafana/pkg/services/accesscontrol"
 	"github.com/grafana/grafana/pkg/services/apikey"
+	"github.com/grafana/grafana/pkg/services/notifications"
 	"github.com/grafana/grafana/pkg/services/org"
 	"github.com/grafana/grafana/pkg/services/serviceaccounts"
 	"github.com/grafana/grafana/pkg/services/serviceaccounts/database"
@@ -26,8 +27,9 @@ import (
 )
 
 const (
-	metricsCollectionInterval = time.Minute * 30
-	defaultSecretScanInterval = time.Minute * 5
+	metricsCollectionInterval  = time.Minute * 30
+	defaultSecretScanInterval  = time.Minute * 5
+	defaultTokenPolicyInterval = time.Hour
 )
 
 type ServiceAccountsService struct {
@@ -42,6 +44,8 @@ type ServiceAccountsService struct {
 	secretScanService secretscan.Checker
 	orgService        org.Service
 	serverLock        *serverlock.ServerLockService
+	kvStore           kvstore.KVStore
+	notifications     notifications.EmailSender
 
 	secretScanEnabled  bool
 	secretScanInterval time.Duration
@@ -58,6 +62,7 @@ func ProvideServiceAccountsService(
 	acService accesscontrol.Service,
 	permissions accesscontrol.ServiceAccountPermissionsService,
 	serverLockService *serverlock.ServerLockService,
+	notificationService notifications.EmailSender,
 ) (*ServiceAccountsService, error) {
 	serviceAccountsStore := database.ProvideServiceAccountsStore(
 		cfg,
@@ -77,6 +82,8 @@ func ProvideServiceAccountsService(
 		backgroundLog: log.New("serviceaccounts.background"),
 		orgService:    orgService,
 		serverLock:    serverLockService,
+		kvStore:       kvStore,
+		notifications: notificationService,
 	}
 
 	if err := RegisterRoles(acService); err != nil {
@@ -143,6 +150,18 @@ func (sa *ServiceAccountsService) Run(ctx context.Context) error {
 		defer tokenCheckTicker.Stop()
 	}
 
+	// Enforce a minimum interval of 1 minute.
+	tokenPolicyInterval := sa.cfg.SATokenPolicyCheckInterval
+	if tokenPolicyInterval < time.Minute {
+		sa.backgroundLog.Warn("Token policy check interval is too low, increasing to " +
+			defaultTokenPolicyInterval.String())
+
+		tokenPolicyInterval = defaultTokenPolicyInterval
+	}
+
+	tokenPolicyTicker := time.NewTicker(tokenPolicyInterval)
+	defer tokenPolicyTicker.Stop()
+
 	for {
 		select {
 		case <-ctx.Done():
@@ -165,6 +184,17 @@ func (sa *ServiceAccountsService) Run(ctx context.Context) error {
 			if err := sa.secretScanService.CheckTokens(ctx); err != nil {
 				sa.backgroundLog.Warn("Failed to check for leaked tokens", "error", err.Error())
 			}
+		case <-tokenPolicyTicker.C:
+			sa.backgroundLog.Debug("Enforcing token policies")
+
+			err := sa.serverLock.LockAndExecute(ctx, "enforce service account token policies", tokenPolicyInterval, func(ctx context.Context) {
+				if err := sa.enforceTokenPolicies(ctx); err != nil {
+					sa.backgroundLog.Warn("Failed to enforce token policies", "error", err.Error())
+				}
+			})
+			if err != nil {
+				sa.backgroundLog.Warn("Failed to lock and execute the token policy enforcement", "error", err)
+			}
 		}
 	}
 }
@@ -285,6 +315,15 @@ func (sa *ServiceAccountsService) AddServiceAccountToken(ctx context.Context, se
 	if err := validServiceAccountID(serviceAccountID); err != nil {
 		return nil, err
 	}
+
+	policy, err := sa.GetTokenPolicy(ctx, query.OrgId)
+	if err != nil {
+		return nil, err
+	}
+	if err := policy.ValidateSecondsToLive(query.SecondsToLive); err != nil {
+		return nil, err
+	}
+
 	return sa.store.AddServiceAccountToken(ctx, serviceAccountID, query)
 }
 
@@ -27,6 +27,10 @@ type FakeServiceAccountStore struct {
 	ExpectedAPIKey                          *apikey.APIKey
 	ExpectedBoolean                         bool
 	ExpectedError                           error
+
+	RevokedTokenIDs    []int64
+	UpdatedTokenExpiry map[int64]int64
+	AddedTokens        []*serviceaccounts.AddServiceAccountTokenCommand
 }
 
 var _ store = (*FakeServiceAccountStore)(nil)
@@ -86,13 +90,20 @@ func (f *FakeServiceAccountStore) ListTokens(ctx context.Context, query *service
 	return f.ExpectedAPIKeys, f.ExpectedError
 }
 
+// ListActiveTokens is a fake listing active tokens.
+func (f *FakeServiceAccountStore) ListActiveTokens(ctx context.Context, orgID int64) ([]apikey.APIKey, error) {
+	return f.ExpectedAPIKeys, f.ExpectedError
+}
+
 // RevokeServiceAccountToken is a fake revoking a service account token.
 func (f *FakeServiceAccountStore) RevokeServiceAccountToken(ctx context.Context, orgId, serviceAccountId, tokenId int64) error {
+	f.RevokedTokenIDs = append(f.RevokedTokenIDs, tokenId)
 	return f.ExpectedError
 }
 
 // AddServiceAccountToken is a fake adding a service account token.
 func (f *FakeServiceAccountStore) AddServiceAccountToken(ctx context.Context, serviceAccountID int64, cmd *serviceaccounts.AddServiceAccountTokenCommand) (*apikey.APIKey, error) {
+	f.AddedTokens = append(f.AddedTokens, cmd)
 	return f.ExpectedAPIKey, f.ExpectedError
 }
 
@@ -101,6 +112,15 @@ func (f *FakeServiceAccountStore) DeleteServiceAccountToken(ctx context.Context,
 	return f.ExpectedError
 }
 
+// UpdateServiceAccountTokenExpiry is a fake updating the expiration of a service account token.
+func (f *FakeServiceAccountStore) UpdateServiceAccountTokenExpiry(ctx context.Context, orgID, serviceAccountID, tokenID int64, expires int64) error {
+	if f.UpdatedTokenExpiry == nil {
+		f.UpdatedTokenExpiry = map[int64]int64{}
+	}
+	f.UpdatedTokenExpiry[tokenID] = expires
+	return f.ExpectedError
+}
+
 // GetUsageMetrics is a fake getting usage metrics.
 func (f *FakeServiceAccountStore) GetUsageMetrics(ctx context.Context) (*serviceaccounts.Stats, error) {
 	return f.ExpectedStats, f.ExpectedError
@@ -14,6 +14,7 @@ type store interface {
 	DeleteServiceAccountToken(ctx context.Context, orgID, serviceAccountID, tokenID int64) error
 	EnableServiceAccount(ctx context.Context, orgID, serviceAccountID int64, enable bool) error
 	GetUsageMetrics(ctx context.Context) (*serviceaccounts.Stats, error)
+	ListActiveTokens(ctx context.Context, orgID int64) ([]apikey.APIKey, error)
 	ListTokens(ctx context.Context, query *serviceaccounts.GetSATokensQuery) ([]apikey.APIKey, error)
 	MigrateApiKeysToServiceAccounts(ctx context.Context, orgID int64) (*serviceaccounts.MigrationResult, error)
 	RetrieveServiceAccount(ctx context.Context, query *serviceaccounts.GetServiceAccountQuery) (*serviceaccounts.ServiceAccountProfileDTO, error)
@@ -22,4 +23,5 @@ type store interface {
 	SearchOrgServiceAccounts(ctx context.Context, query *serviceaccounts.SearchOrgServiceAccountsQuery) (*serviceaccounts.SearchOrgServiceAccountsResult, error)
 	UpdateServiceAccount(ctx context.Context, orgID, serviceAccountID int64,
 		saForm *serviceaccounts.UpdateServiceAccountForm) (*serviceaccounts.ServiceAccountProfileDTO, error)
+	UpdateServiceAccountTokenExpiry(ctx context.Context, orgID, serviceAccountID, tokenID int64, expires int64) error
 }
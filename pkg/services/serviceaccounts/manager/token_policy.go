@@ -0,0 +1,318 @@
+package manager
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/grafana/grafana/pkg/apimachinery/identity"
+	"github.com/grafana/grafana/pkg/services/apikey"
+	"github.com/grafana/grafana/pkg/services/notifications"
+	"github.com/grafana/grafana/pkg/services/org"
+	"github.com/grafana/grafana/pkg/services/serviceaccounts"
+)
+
+const (
+	tokenPolicyNamespace = "serviceaccounts"
+	tokenPolicyKey       = "tokenPolicy"
+	// tokenExpiryWarningPrefix is the prefix of the keys recording the expiration
+	// a warning was sent for, so every token is only reported once
+	tokenExpiryWarningPrefix = "tokenExpiryWarning."
+
+	tmplTokenExpiry = "service_account_token_expiry"
+)
+
+// defaultTokenPolicy is the policy of organizations that did not set their own
+func (sa *ServiceAccountsService) defaultTokenPolicy() *serviceaccounts.TokenPolicy {
+	return &serviceaccounts.TokenPolicy{
+		MaxTTLDays:             sa.cfg.SATokenMaxTTLDays,
+		RequireExpiry:          sa.cfg.SATokenRequireExpiry,
+		IdleRevocationDays:     sa.cfg.SATokenIdleRevocationDays,
+		ExpiryWarningDays:      sa.cfg.SATokenExpiryWarningDays,
+		RotationOverlapSeconds: int64(sa.cfg.SATokenRotationOverlap.Seconds()),
+	}
+}
+
+func (sa *ServiceAccountsService) GetTokenPolicy(ctx context.Context, orgID int64) (*serviceaccounts.TokenPolicy, error) {
+	if err := validOrgID(orgID); err != nil {
+		return nil, err
+	}
+
+	value, ok, err := sa.kvStore.Get(ctx, orgID, tokenPolicyNamespace, tokenPolicyKey)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return sa.defaultTokenPolicy(), nil
+	}
+
+	policy := &serviceaccounts.TokenPolicy{}
+	if err := json.Unmarshal([]byte(value), policy); err != nil {
+		return nil, fmt.Errorf("failed to decode token policy of org %d: %w", orgID, err)
+	}
+	// the configuration may have become stricter since the policy was stored
+	return policy.Restrict(sa.defaultTokenPolicy()), nil
+}
+
+func (sa *ServiceAccountsService) UpdateTokenPolicy(ctx context.Context, orgID int64, policy *serviceaccounts.TokenPolicy) error {
+	if err := validOrgID(orgID); err != nil {
+		return err
+	}
+	if err := policy.Validate(); err != nil {
+		return err
+	}
+	if *policy.Restrict(sa.defaultTokenPolicy()) != *policy {
+		return serviceaccounts.ErrTokenPolicyExceedsLimits.Errorf("token policy of org %d is less strict than the configuration", orgID)
+	}
+
+	value, err := json.Marshal(policy)
+	if err != nil {
+		return err
+	}
+	return sa.kvStore.Set(ctx, orgID, tokenPolicyNamespace, tokenPolicyKey, string(value))
+}
+
+func (sa *ServiceAccountsService) RotateServiceAccountToken(ctx context.Context, serviceAccountID, tokenID int64, cmd *serviceaccounts.RotateServiceAccountTokenCommand) (*apikey.APIKey, error) {
+	if err := validOrgID(cmd.OrgId); err != nil {
+		return nil, err
+	}
+	if err := validServiceAccountID(serviceAccountID); err != nil {
+		return nil, err
+	}
+	if err := validServiceAccountTokenID(tokenID); err != nil {
+		return nil, err
+	}
+
+	tokens, err := sa.store.ListTokens(ctx, &serviceaccounts.GetSATokensQuery{OrgID: &cmd.OrgId, ServiceAccountID: &serviceAccountID})
+	if err != nil {
+		return nil, err
+	}
+
+	var rotated *apikey.APIKey
+	for i := range tokens {
+		if tokens[i].ID == tokenID {
+			rotated = &tokens[i]
+			break
+		}
+	}
+	if rotated == nil {
+		return nil, serviceaccounts.ErrServiceAccountTokenNotFound.Errorf("service account token with id %d not found for service account with id %d", tokenID, serviceAccountID)
+	}
+	if rotated.IsRevoked != nil && *rotated.IsRevoked {
+		return nil, serviceaccounts.ErrTokenRevoked.Errorf("service account token with id %d is revoked", tokenID)
+	}
+
+	policy, err := sa.GetTokenPolicy(ctx, cmd.OrgId)
+	if err != nil {
+		return nil, err
+	}
+
+	secondsToLive := cmd.SecondsToLive
+	if secondsToLive == 0 {
+		switch {
+		case policy.MaxTTLDays > 0:
+			secondsToLive = policy.MaxSecondsToLive()
+		case rotated.Expires != nil:
+			// keep the lifetime of the rotated token
+			secondsToLive = *rotated.Expires - rotated.Created.Unix()
+		}
+	}
+	if err := serviceaccounts.ValidateSecondsToLive(sa.cfg, secondsToLive); err != nil {
+		return nil, err
+	}
+
+	overlap := policy.RotationOverlapSeconds
+	if cmd.OverlapSeconds != nil {
+		overlap = *cmd.OverlapSeconds
+	}
+	if overlap < 0 {
+		return nil, serviceaccounts.ErrInvalidTokenExpiration.Errorf("invalid rotation overlap value %d", overlap)
+	}
+
+	now := time.Now()
+	name := cmd.Name
+	if name == "" {
+		name = fmt.Sprintf("%s-%s", rotated.Name, now.Format("20060102150405"))
+	}
+
+	var token *apikey.APIKey
+	err = sa.db.InTransaction(ctx, func(ctx context.Context) error {
+		token, err = sa.AddServiceAccountToken(ctx, serviceAccountID, &serviceaccounts.AddServiceAccountTokenCommand{
+			Name:          name,
+			OrgId:         cmd.OrgId,
+			Key:           cmd.Key,
+			SecondsToLive: secondsToLive,
+		})
+		if err != nil {
+			return err
+		}
+
+		expires := now.Unix() + overlap
+		if rotated.Expires != nil && *rotated.Expires < expires {
+			return nil
+		}
+		return sa.store.UpdateServiceAccountTokenExpiry(ctx, cmd.OrgId, serviceAccountID, tokenID, expires)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sa.log.Info("Rotated service account token", "orgId", cmd.OrgId, "serviceAccountId", serviceAccountID, "tokenId", tokenID, "newTokenId", token.ID, "overlap", time.Duration(overlap)*time.Second)
+	return token, nil
+}
+
+// enforceTokenPolicies revokes idle tokens, caps the expiration of tokens
+// living longer than the policy allows and warns about expiring tokens in all organizations
+func (sa *ServiceAccountsService) enforceTokenPolicies(ctx context.Context) error {
+	orgs, err := sa.orgService.Search(ctx, &org.SearchOrgsQuery{})
+	if err != nil {
+		return err
+	}
+
+	for _, o := range orgs {
+		if err := sa.enforceTokenPolicy(ctx, o); err != nil {
+			sa.backgroundLog.Warn("Failed to enforce service account token policy", "orgId", o.ID, "error", err)
+		}
+	}
+	return nil
+}
+
+func (sa *ServiceAccountsService) enforceTokenPolicy(ctx context.Context, o *org.OrgDTO) error {
+	policy, err := sa.GetTokenPolicy(ctx, o.ID)
+	if err != nil {
+		return err
+	}
+
+	warn := policy.ExpiryWarningDays > 0 && sa.cfg.Smtp.Enabled
+	if policy.IdleRevocationDays == 0 && policy.MaxTTLDays == 0 && !warn {
+		return nil
+	}
+
+	tokens, err := sa.store.ListActiveTokens(ctx, o.ID)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	idleLimit := now.AddDate(0, 0, -policy.IdleRevocationDays)
+	warnLimit := now.AddDate(0, 0, policy.ExpiryWarningDays)
+
+	expiring := make([]apikey.APIKey, 0)
+	for _, token := range tokens {
+		if token.ServiceAccountId == nil {
+			continue
+		}
+
+		if policy.IdleRevocationDays > 0 {
+			lastUsed := token.Created
+			if token.LastUsedAt != nil {
+				lastUsed = *token.LastUsedAt
+			}
+			if lastUsed.Before(idleLimit) {
+				if err := sa.store.RevokeServiceAccountToken(ctx, o.ID, *token.ServiceAccountId, token.ID); err != nil {
+					return err
+				}
+				sa.backgroundLog.Info("Revoked idle service account token", "orgId", o.ID, "serviceAccountId", *token.ServiceAccountId, "tokenId", token.ID, "lastUsed", lastUsed)
+				continue
+			}
+		}
+
+		if policy.MaxTTLDays > 0 {
+			// tokens created before the policy was set can not live longer than it allows
+			maxExpires := token.Created.Unix() + policy.MaxSecondsToLive()
+			if token.Expires == nil || *token.Expires > maxExpires {
+				if err := sa.store.UpdateServiceAccountTokenExpiry(ctx, o.ID, *token.ServiceAccountId, token.ID, maxExpires); err != nil {
+					return err
+				}
+				sa.backgroundLog.Info("Capped service account token expiration to the token policy", "orgId", o.ID, "serviceAccountId", *token.ServiceAccountId, "tokenId", token.ID, "expires", time.Unix(maxExpires, 0))
+				token.Expires = &maxExpires
+			}
+		}
+
+		if warn && token.Expires != nil && time.Unix(*token.Expires, 0).Before(warnLimit) {
+			expiring = append(expiring, token)
+		}
+	}
+
+	return sa.warnExpiringTokens(ctx, o, expiring)
+}
+
+type expiringToken struct {
+	Name             string
+	ServiceAccountID int64
+	Expires          string
+}
+
+// warnExpiringTokens sends an email to the admins of the organization listing
+// the tokens that expire soon and were not reported yet
+func (sa *ServiceAccountsService) warnExpiringTokens(ctx context.Context, o *org.OrgDTO, tokens []apikey.APIKey) error {
+	report := make([]apikey.APIKey, 0, len(tokens))
+	for _, token := range tokens {
+		warned, ok, err := sa.kvStore.Get(ctx, o.ID, tokenPolicyNamespace, tokenExpiryWarningPrefix+strconv.FormatInt(token.ID, 10))
+		if err != nil {
+			return err
+		}
+		// tokens can be warned about again after their expiration was changed
+		if ok && warned == strconv.FormatInt(*token.Expires, 10) {
+			continue
+		}
+		report = append(report, token)
+	}
+	if len(report) == 0 {
+		return nil
+	}
+
+	recipients, err := sa.orgAdminEmails(ctx, o.ID)
+	if err != nil {
+		return err
+	}
+	if len(recipients) == 0 {
+		sa.backgroundLog.Debug("No org admins to warn about expiring service account tokens", "orgId", o.ID)
+		return nil
+	}
+
+	data := make([]expiringToken, 0, len(report))
+	for _, token := range report {
+		data = append(data, expiringToken{
+			Name:             token.Name,
+			ServiceAccountID: *token.ServiceAccountId,
+			Expires:          time.Unix(*token.Expires, 0).UTC().Format(time.RFC1123),
+		})
+	}
+
+	if err := sa.notifications.SendEmailCommandHandler(ctx, &notifications.SendEmailCommand{
+		To:       recipients,
+		Template: tmplTokenExpiry,
+		Data: map[string]any{
+			"OrgName": o.Name,
+			"Tokens":  data,
+		},
+	}); err != nil {
+		return err
+	}
+
+	for _, token := range report {
+		if err := sa.kvStore.Set(ctx, o.ID, tokenPolicyNamespace, tokenExpiryWarningPrefix+strconv.FormatInt(token.ID, 10), strconv.FormatInt(*token.Expires, 10)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (sa *ServiceAccountsService) orgAdminEmails(ctx context.Context, orgID int64) ([]string, error) {
+	ctx, requester := identity.WithServiceIdentity(ctx, orgID)
+	users, err := sa.orgService.GetOrgUsers(ctx, &org.GetOrgUsersQuery{OrgID: orgID, User: requester, DontEnforceAccessControl: true})
+	if err != nil {
+		return nil, err
+	}
+
+	emails := make([]string, 0)
+	for _, u := range users {
+		if u.Role == string(org.RoleAdmin) && !u.IsDisabled && u.Email != "" {
+			emails = append(emails, u.Email)
+		}
+	}
+	return emails, nil
+}
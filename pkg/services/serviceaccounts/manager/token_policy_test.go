@@ -0,0 +1,265 @@
+package manager
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/infra/kvstore"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/apikey"
+	"github.com/grafana/grafana/pkg/services/notifications"
+	"github.com/grafana/grafana/pkg/services/org"
+	"github.com/grafana/grafana/pkg/services/org/orgtest"
+	"github.com/grafana/grafana/pkg/services/serviceaccounts"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/util/testutil"
+)
+
+func setupTokenPolicyService(storeMock *FakeServiceAccountStore) (*ServiceAccountsService, *notifications.NotificationServiceMock) {
+	cfg := setting.NewCfg()
+	cfg.ApiKeyMaxSecondsToLive = -1
+	cfg.SATokenExpirationDayLimit = -1
+	cfg.SATokenExpiryWarningDays = 7
+	cfg.SATokenRotationOverlap = time.Hour
+	cfg.Smtp.Enabled = true
+
+	notificationService := notifications.MockNotificationService()
+	return &ServiceAccountsService{
+		cfg:           cfg,
+		store:         storeMock,
+		kvStore:       kvstore.NewFakeKVStore(),
+		notifications: notificationService,
+		orgService: &orgtest.FakeOrgService{ExpectedOrgUsers: []*org.OrgUserDTO{
+			{UserID: 1, Email: "admin@example.org", Role: string(org.RoleAdmin)},
+			{UserID: 2, Email: "viewer@example.org", Role: string(org.RoleViewer)},
+		}},
+		log:           log.NewNopLogger(),
+		backgroundLog: log.NewNopLogger(),
+	}, notificationService
+}
+
+func TestServiceAccountsService_TokenPolicy(t *testing.T) {
+	svc, _ := setupTokenPolicyService(newServiceAccountStoreFake())
+	ctx := context.Background()
+
+	policy, err := svc.GetTokenPolicy(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, &serviceaccounts.TokenPolicy{ExpiryWarningDays: 7, RotationOverlapSeconds: 3600}, policy)
+
+	updated := &serviceaccounts.TokenPolicy{MaxTTLDays: 90, RequireExpiry: true, IdleRevocationDays: 30}
+	require.NoError(t, svc.UpdateTokenPolicy(ctx, 1, updated))
+
+	policy, err = svc.GetTokenPolicy(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, updated, policy)
+
+	policy, err = svc.GetTokenPolicy(ctx, 2)
+	require.NoError(t, err)
+	assert.Equal(t, 0, policy.MaxTTLDays, "policies should be per organization")
+
+	err = svc.UpdateTokenPolicy(ctx, 1, &serviceaccounts.TokenPolicy{MaxTTLDays: -1})
+	assert.ErrorIs(t, err, serviceaccounts.ErrInvalidTokenPolicy)
+}
+
+func TestServiceAccountsService_TokenPolicy_ConfigLimits(t *testing.T) {
+	svc, _ := setupTokenPolicyService(newServiceAccountStoreFake())
+	svc.cfg.SATokenMaxTTLDays = 90
+	svc.cfg.SATokenRequireExpiry = true
+	svc.cfg.SATokenIdleRevocationDays = 30
+	ctx := context.Background()
+
+	tests := []struct {
+		desc        string
+		policy      *serviceaccounts.TokenPolicy
+		expectedErr error
+	}{
+		{desc: "should reject removing the maximum lifetime", policy: &serviceaccounts.TokenPolicy{MaxTTLDays: 0, RequireExpiry: true, IdleRevocationDays: 30}, expectedErr: serviceaccounts.ErrTokenPolicyExceedsLimits},
+		{desc: "should reject a longer maximum lifetime", policy: &serviceaccounts.TokenPolicy{MaxTTLDays: 365, RequireExpiry: true, IdleRevocationDays: 30}, expectedErr: serviceaccounts.ErrTokenPolicyExceedsLimits},
+		{desc: "should reject not requiring an expiration", policy: &serviceaccounts.TokenPolicy{MaxTTLDays: 90, IdleRevocationDays: 30}, expectedErr: serviceaccounts.ErrTokenPolicyExceedsLimits},
+		{desc: "should reject disabling idle revocation", policy: &serviceaccounts.TokenPolicy{MaxTTLDays: 90, RequireExpiry: true}, expectedErr: serviceaccounts.ErrTokenPolicyExceedsLimits},
+		{desc: "should accept a stricter policy", policy: &serviceaccounts.TokenPolicy{MaxTTLDays: 30, RequireExpiry: true, IdleRevocationDays: 7, ExpiryWarningDays: 14}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			err := svc.UpdateTokenPolicy(ctx, 1, tt.policy)
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+
+	t.Run("should apply stricter configuration to stored policies", func(t *testing.T) {
+		require.NoError(t, svc.UpdateTokenPolicy(ctx, 2, &serviceaccounts.TokenPolicy{MaxTTLDays: 60, RequireExpiry: true, IdleRevocationDays: 30}))
+		svc.cfg.SATokenMaxTTLDays = 14
+		policy, err := svc.GetTokenPolicy(ctx, 2)
+		require.NoError(t, err)
+		assert.Equal(t, 14, policy.MaxTTLDays)
+	})
+}
+
+func TestServiceAccountsService_AddServiceAccountToken_Policy(t *testing.T) {
+	storeMock := newServiceAccountStoreFake()
+	storeMock.ExpectedAPIKey = &apikey.APIKey{ID: 1}
+	svc, _ := setupTokenPolicyService(storeMock)
+	ctx := context.Background()
+	require.NoError(t, svc.UpdateTokenPolicy(ctx, 1, &serviceaccounts.TokenPolicy{MaxTTLDays: 90}))
+
+	tests := []struct {
+		desc          string
+		secondsToLive int64
+		expectedErr   error
+	}{
+		{desc: "should require an expiration", secondsToLive: 0, expectedErr: serviceaccounts.ErrTokenExpiryRequired},
+		{desc: "should reject lifetime above the maximum", secondsToLive: 91 * 24 * 3600, expectedErr: serviceaccounts.ErrTokenTTLExceedsPolicy},
+		{desc: "should accept lifetime within the maximum", secondsToLive: 90 * 24 * 3600},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			_, err := svc.AddServiceAccountToken(ctx, 1, &serviceaccounts.AddServiceAccountTokenCommand{Name: "token", OrgId: 1, SecondsToLive: tt.secondsToLive})
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestServiceAccountsService_EnforceTokenPolicy(t *testing.T) {
+	now := time.Now()
+	saID := int64(10)
+	recentlyUsed := now.Add(-time.Hour)
+	expiresSoon := now.Add(2 * 24 * time.Hour).Unix()
+	expiresLater := now.Add(30 * 24 * time.Hour).Unix()
+
+	storeMock := newServiceAccountStoreFake()
+	storeMock.ExpectedAPIKeys = []apikey.APIKey{
+		{ID: 1, Name: "idle", ServiceAccountId: &saID, Created: now.AddDate(0, 0, -40)},
+		{ID: 2, Name: "expiring", ServiceAccountId: &saID, Created: now.AddDate(0, 0, -40), LastUsedAt: &recentlyUsed, Expires: &expiresSoon},
+		{ID: 3, Name: "valid", ServiceAccountId: &saID, Created: now.AddDate(0, 0, -1), Expires: &expiresLater},
+	}
+	svc, notificationService := setupTokenPolicyService(storeMock)
+	ctx := context.Background()
+	require.NoError(t, svc.UpdateTokenPolicy(ctx, 1, &serviceaccounts.TokenPolicy{IdleRevocationDays: 30, ExpiryWarningDays: 7}))
+
+	emails := 0
+	notificationService.EmailHandler = func(ctx context.Context, cmd *notifications.SendEmailCommand) error {
+		emails++
+		return nil
+	}
+
+	o := &org.OrgDTO{ID: 1, Name: "Main Org."}
+	require.NoError(t, svc.enforceTokenPolicy(ctx, o))
+
+	assert.Equal(t, []int64{1}, storeMock.RevokedTokenIDs)
+	require.Equal(t, 1, emails)
+	assert.Equal(t, []string{"admin@example.org"}, notificationService.Email.To)
+	assert.Equal(t, tmplTokenExpiry, notificationService.Email.Template)
+	tokens := notificationService.Email.Data["Tokens"].([]expiringToken)
+	require.Len(t, tokens, 1)
+	assert.Equal(t, "expiring", tokens[0].Name)
+
+	// tokens are only reported once for the same expiration
+	storeMock.ExpectedAPIKeys = storeMock.ExpectedAPIKeys[1:]
+	require.NoError(t, svc.enforceTokenPolicy(ctx, o))
+	assert.Equal(t, 1, emails)
+
+	expiresSooner := now.Add(24 * time.Hour).Unix()
+	storeMock.ExpectedAPIKeys[0].Expires = &expiresSooner
+	require.NoError(t, svc.enforceTokenPolicy(ctx, o))
+	assert.Equal(t, 2, emails)
+}
+
+func TestIntegrationServiceAccountsService_RotateServiceAccountToken(t *testing.T) {
+	testutil.SkipIntegrationTestInShortMode(t)
+
+	now := time.Now()
+	expires := now.AddDate(0, 0, 80).Unix()
+	revoked := true
+
+	storeMock := newServiceAccountStoreFake()
+	storeMock.ExpectedAPIKey = &apikey.APIKey{ID: 3, Name: "token-rotated"}
+	storeMock.ExpectedAPIKeys = []apikey.APIKey{
+		{ID: 1, Name: "token", Created: now.AddDate(0, 0, -10), Expires: &expires},
+		{ID: 2, Name: "revoked", Created: now.AddDate(0, 0, -10), IsRevoked: &revoked},
+	}
+	svc, _ := setupTokenPolicyService(storeMock)
+	svc.db = db.InitTestDB(t)
+	ctx := context.Background()
+
+	t.Run("should let the rotated token expire after the overlap", func(t *testing.T) {
+		token, err := svc.RotateServiceAccountToken(ctx, 10, 1, &serviceaccounts.RotateServiceAccountTokenCommand{OrgId: 1, Key: "key"})
+		require.NoError(t, err)
+		assert.Equal(t, int64(3), token.ID)
+		assert.InDelta(t, now.Add(time.Hour).Unix(), storeMock.UpdatedTokenExpiry[1], 5)
+	})
+
+	t.Run("should not extend the expiration of the rotated token", func(t *testing.T) {
+		overlap := int64(100 * 24 * 3600)
+		storeMock.UpdatedTokenExpiry = nil
+		_, err := svc.RotateServiceAccountToken(ctx, 10, 1, &serviceaccounts.RotateServiceAccountTokenCommand{OrgId: 1, Key: "key", OverlapSeconds: &overlap})
+		require.NoError(t, err)
+		assert.Empty(t, storeMock.UpdatedTokenExpiry)
+	})
+
+	t.Run("should keep the lifetime of the rotated token without a policy maximum", func(t *testing.T) {
+		svc.cfg.ApiKeyMaxSecondsToLive = 100 * 24 * 3600
+		t.Cleanup(func() { svc.cfg.ApiKeyMaxSecondsToLive = -1 })
+		storeMock.AddedTokens = nil
+		_, err := svc.RotateServiceAccountToken(ctx, 10, 1, &serviceaccounts.RotateServiceAccountTokenCommand{OrgId: 1, Key: "key"})
+		require.NoError(t, err)
+		require.Len(t, storeMock.AddedTokens, 1)
+		assert.Equal(t, expires-storeMock.ExpectedAPIKeys[0].Created.Unix(), storeMock.AddedTokens[0].SecondsToLive)
+	})
+
+	t.Run("should validate the resolved lifetime against the configuration", func(t *testing.T) {
+		svc.cfg.ApiKeyMaxSecondsToLive = 30 * 24 * 3600
+		t.Cleanup(func() { svc.cfg.ApiKeyMaxSecondsToLive = -1 })
+		_, err := svc.RotateServiceAccountToken(ctx, 10, 1, &serviceaccounts.RotateServiceAccountTokenCommand{OrgId: 1, Key: "key"})
+		assert.ErrorIs(t, err, serviceaccounts.ErrTokenExceedsGlobalLimit)
+	})
+
+	t.Run("should fail for revoked token", func(t *testing.T) {
+		_, err := svc.RotateServiceAccountToken(ctx, 10, 2, &serviceaccounts.RotateServiceAccountTokenCommand{OrgId: 1, Key: "key"})
+		assert.ErrorIs(t, err, serviceaccounts.ErrTokenRevoked)
+	})
+
+	t.Run("should fail for unknown token", func(t *testing.T) {
+		_, err := svc.RotateServiceAccountToken(ctx, 10, 4, &serviceaccounts.RotateServiceAccountTokenCommand{OrgId: 1, Key: "key"})
+		assert.ErrorIs(t, err, serviceaccounts.ErrServiceAccountTokenNotFound)
+	})
+}
+
+func TestServiceAccountsService_EnforceTokenPolicy_MaxTTL(t *testing.T) {
+	now := time.Now()
+	saID := int64(10)
+	created := now.AddDate(0, 0, -10)
+	maxExpires := created.Unix() + 30*24*3600
+	expiresLater := now.AddDate(1, 0, 0).Unix()
+	expiresSoon := now.Add(24 * time.Hour).Unix()
+
+	storeMock := newServiceAccountStoreFake()
+	storeMock.ExpectedAPIKeys = []apikey.APIKey{
+		{ID: 1, Name: "no-expiry", ServiceAccountId: &saID, Created: created},
+		{ID: 2, Name: "too-long", ServiceAccountId: &saID, Created: created, Expires: &expiresLater},
+		{ID: 3, Name: "within", ServiceAccountId: &saID, Created: created, Expires: &expiresSoon},
+	}
+	svc, _ := setupTokenPolicyService(storeMock)
+	svc.cfg.Smtp.Enabled = false
+	ctx := context.Background()
+	require.NoError(t, svc.UpdateTokenPolicy(ctx, 1, &serviceaccounts.TokenPolicy{MaxTTLDays: 30}))
+
+	require.NoError(t, svc.enforceTokenPolicy(ctx, &org.OrgDTO{ID: 1, Name: "Main Org."}))
+
+	assert.Equal(t, map[int64]int64{1: maxExpires, 2: maxExpires}, storeMock.UpdatedTokenExpiry)
+	assert.Empty(t, storeMock.RevokedTokenIDs)
+}
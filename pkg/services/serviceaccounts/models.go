@@ -7,6 +7,7 @@ import (
 	"github.com/grafana/grafana/pkg/apimachinery/identity"
 	"github.com/grafana/grafana/pkg/services/accesscontrol"
 	"github.com/grafana/grafana/pkg/services/org"
+	"github.com/grafana/grafana/pkg/setting"
 )
 
 var (
@@ -40,6 +41,14 @@ var (
 	ErrServiceAccountTokenNotFound       = errutil.NotFound("serviceaccounts.ErrTokenNotFound", errutil.WithPublicMessage("service account token not found"))
 	ErrInvalidTokenExpiration            = errutil.ValidationFailed("serviceaccounts.ErrInvalidInput", errutil.WithPublicMessage("invalid SecondsToLive value"))
 	ErrDuplicateToken                    = errutil.BadRequest("serviceaccounts.ErrTokenAlreadyExists", errutil.WithPublicMessage("service account token with given name already exists in the organization"))
+	ErrTokenExpiryRequired               = errutil.BadRequest("serviceaccounts.ErrTokenExpiryRequired", errutil.WithPublicMessage("the token policy of the organization requires tokens to expire"))
+	ErrTokenTTLExceedsPolicy             = errutil.BadRequest("serviceaccounts.ErrTokenTTLExceedsPolicy", errutil.WithPublicMessage("token expiration exceeds the maximum lifetime of the token policy"))
+	ErrInvalidTokenPolicy                = errutil.ValidationFailed("serviceaccounts.ErrInvalidTokenPolicy", errutil.WithPublicMessage("invalid token policy"))
+	ErrTokenRevoked                      = errutil.BadRequest("serviceaccounts.ErrTokenRevoked", errutil.WithPublicMessage("revoked service account tokens can not be rotated"))
+	ErrTokenExpirationNotSet             = errutil.BadRequest("serviceaccounts.ErrTokenExpirationNotSet", errutil.WithPublicMessage("Number of seconds before expiration should be set"))
+	ErrTokenExceedsGlobalLimit           = errutil.BadRequest("serviceaccounts.ErrTokenExceedsGlobalLimit", errutil.WithPublicMessage("Number of seconds before expiration is greater than the global limit"))
+	ErrTokenPolicyExceedsLimits          = errutil.BadRequest("serviceaccounts.ErrTokenPolicyExceedsLimits", errutil.WithPublicMessage("token policy can not be less strict than the instance configuration"))
+	ErrTokenExceedsDayLimit              = errutil.BadRequest("serviceaccounts.ErrTokenExceedsDayLimit", errutil.WithPublicMessage("The expiration date input exceeds the limit for service account access tokens expiration date"))
 )
 
 type MigrationResult struct {
@@ -111,6 +120,104 @@ type AddServiceAccountTokenCommand struct {
 	SecondsToLive int64  `json:"secondsToLive"`
 }
 
+// swagger:model
+type RotateServiceAccountTokenCommand struct {
+	// Name of the new token, defaults to the name of the rotated token with a timestamp
+	Name string `json:"name"`
+	// Lifetime of the new token, defaults to the max lifetime of the token policy
+	SecondsToLive int64 `json:"secondsToLive"`
+	// Seconds the rotated token remains valid, defaults to the rotation overlap of the token policy
+	OverlapSeconds *int64 `json:"overlapSeconds"`
+	OrgId          int64  `json:"-"`
+	Key            string `json:"-"`
+}
+
+// TokenPolicy restricts the service account tokens of an organization.
+// swagger:model
+type TokenPolicy struct {
+	// Maximum lifetime of new tokens in days, 0 means no limit
+	// example: 90
+	MaxTTLDays int `json:"maxTtlDays"`
+	// Require new tokens to have an expiration
+	// example: true
+	RequireExpiry bool `json:"requireExpiry"`
+	// Revoke tokens that were not used for this many days, 0 disables revocation
+	// example: 30
+	IdleRevocationDays int `json:"idleRevocationDays"`
+	// Warn the organization admins this many days before a token expires, 0 disables warnings
+	// example: 7
+	ExpiryWarningDays int `json:"expiryWarningDays"`
+	// Seconds a rotated token remains valid after the new token was issued
+	// example: 86400
+	RotationOverlapSeconds int64 `json:"rotationOverlapSeconds"`
+}
+
+func (p *TokenPolicy) Validate() error {
+	if p.MaxTTLDays < 0 || p.IdleRevocationDays < 0 || p.ExpiryWarningDays < 0 || p.RotationOverlapSeconds < 0 {
+		return ErrInvalidTokenPolicy.Errorf("token policy values can not be negative")
+	}
+	return nil
+}
+
+// ValidateSecondsToLive checks if a token with the given lifetime complies with the policy
+func (p *TokenPolicy) ValidateSecondsToLive(secondsToLive int64) error {
+	if secondsToLive <= 0 {
+		if p.RequireExpiry || p.MaxTTLDays > 0 {
+			return ErrTokenExpiryRequired.Errorf("token policy requires an expiration")
+		}
+		return nil
+	}
+	if p.MaxTTLDays > 0 && secondsToLive > p.MaxSecondsToLive() {
+		return ErrTokenTTLExceedsPolicy.Errorf("token lifetime of %d seconds exceeds the policy maximum of %d days", secondsToLive, p.MaxTTLDays)
+	}
+	return nil
+}
+
+func (p *TokenPolicy) MaxSecondsToLive() int64 {
+	return int64(p.MaxTTLDays) * int64((24 * time.Hour).Seconds())
+}
+
+// Restrict returns a copy of the policy that is at least as strict as limits.
+// The maximum lifetime, required expiration and idle revocation of the
+// configuration are limits organizations can only make stricter.
+func (p *TokenPolicy) Restrict(limits *TokenPolicy) *TokenPolicy {
+	restricted := *p
+	restricted.MaxTTLDays = stricterDays(p.MaxTTLDays, limits.MaxTTLDays)
+	restricted.RequireExpiry = p.RequireExpiry || limits.RequireExpiry
+	restricted.IdleRevocationDays = stricterDays(p.IdleRevocationDays, limits.IdleRevocationDays)
+	return &restricted
+}
+
+// stricterDays returns the lower of two limits in days, 0 means no limit
+func stricterDays(a, b int) int {
+	if a == 0 || (b > 0 && b < a) {
+		return b
+	}
+	return a
+}
+
+// ValidateSecondsToLive checks a token lifetime against the limits of the configuration
+func ValidateSecondsToLive(cfg *setting.Cfg, secondsToLive int64) error {
+	if cfg.ApiKeyMaxSecondsToLive != -1 {
+		if secondsToLive == 0 {
+			return ErrTokenExpirationNotSet.Errorf("token lifetime must be set when api_key_max_seconds_to_live is configured")
+		}
+		if secondsToLive > cfg.ApiKeyMaxSecondsToLive {
+			return ErrTokenExceedsGlobalLimit.Errorf("token lifetime of %d seconds exceeds the limit of %d seconds", secondsToLive, cfg.ApiKeyMaxSecondsToLive)
+		}
+	}
+
+	if cfg.SATokenExpirationDayLimit > 0 {
+		dayExpireLimit := time.Now().Add(time.Duration(cfg.SATokenExpirationDayLimit) * time.Hour * 24).Truncate(24 * time.Hour)
+		expirationDate := time.Now().Add(time.Duration(secondsToLive) * time.Second).Truncate(24 * time.Hour)
+		if expirationDate.After(dayExpireLimit) {
+			return ErrTokenExceedsDayLimit.Errorf("token expiration exceeds the limit of %d days", cfg.SATokenExpirationDayLimit)
+		}
+	}
+
+	return nil
+}
+
 type SearchOrgServiceAccountsQuery struct {
 	OrgID        int64
 	Query        string
@@ -40,3 +40,29 @@ func TestIsExternalServiceAccount(t *testing.T) {
 		})
 	}
 }
+
+func TestTokenPolicy_ValidateSecondsToLive(t *testing.T) {
+	day := int64(24 * 3600)
+	tests := []struct {
+		name          string
+		policy        TokenPolicy
+		secondsToLive int64
+		wantErr       error
+	}{
+		{name: "no policy without expiry", secondsToLive: 0},
+		{name: "required expiry without expiry", policy: TokenPolicy{RequireExpiry: true}, secondsToLive: 0, wantErr: ErrTokenExpiryRequired},
+		{name: "max ttl without expiry", policy: TokenPolicy{MaxTTLDays: 90}, secondsToLive: 0, wantErr: ErrTokenExpiryRequired},
+		{name: "max ttl within limit", policy: TokenPolicy{MaxTTLDays: 90}, secondsToLive: 90 * day},
+		{name: "max ttl above limit", policy: TokenPolicy{MaxTTLDays: 90}, secondsToLive: 90*day + 1, wantErr: ErrTokenTTLExceedsPolicy},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.policy.ValidateSecondsToLive(tt.secondsToLive)
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
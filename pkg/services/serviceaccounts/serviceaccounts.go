@@ -40,6 +40,13 @@ type Service interface {
 		cmd *AddServiceAccountTokenCommand) (*apikey.APIKey, error)
 	DeleteServiceAccountToken(ctx context.Context, orgID, serviceAccountID, tokenID int64) error
 	ListTokens(ctx context.Context, query *GetSATokensQuery) ([]apikey.APIKey, error)
+	// RotateServiceAccountToken adds a new token and lets the rotated token expire after the overlap period
+	RotateServiceAccountToken(ctx context.Context, serviceAccountID, tokenID int64,
+		cmd *RotateServiceAccountTokenCommand) (*apikey.APIKey, error)
+
+	// Token policies
+	GetTokenPolicy(ctx context.Context, orgID int64) (*TokenPolicy, error)
+	UpdateTokenPolicy(ctx context.Context, orgID int64, policy *TokenPolicy) error
 
 	MigrateApiKeysToServiceAccounts(ctx context.Context, orgID int64) (*MigrationResult, error)
 }
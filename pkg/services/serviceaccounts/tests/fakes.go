@@ -16,6 +16,7 @@ type FakeServiceAccountService struct {
 	ExpectedServiceAccountID               int64
 	ExpectedServiceAccountProfile          *serviceaccounts.ServiceAccountProfileDTO
 	ExpectedServiceAccountTokens           []apikey.APIKey
+	ExpectedTokenPolicy                    *serviceaccounts.TokenPolicy
 }
 
 var _ serviceaccounts.Service = new(FakeServiceAccountService)
@@ -65,3 +66,17 @@ func (f *FakeServiceAccountService) SearchOrgServiceAccounts(ctx context.Context
 func (f *FakeServiceAccountService) DeleteServiceAccountToken(ctx context.Context, orgID, id, tokenID int64) error {
 	return f.ExpectedErr
 }
+
+func (f *FakeServiceAccountService) RotateServiceAccountToken(ctx context.Context, id, tokenID int64, cmd *serviceaccounts.RotateServiceAccountTokenCommand) (*apikey.APIKey, error) {
+	return f.ExpectedAPIKey, f.ExpectedErr
+}
+
+// Token policies
+
+func (f *FakeServiceAccountService) GetTokenPolicy(ctx context.Context, orgID int64) (*serviceaccounts.TokenPolicy, error) {
+	return f.ExpectedTokenPolicy, f.ExpectedErr
+}
+
+func (f *FakeServiceAccountService) UpdateTokenPolicy(ctx context.Context, orgID int64, policy *serviceaccounts.TokenPolicy) error {
+	return f.ExpectedErr
+}
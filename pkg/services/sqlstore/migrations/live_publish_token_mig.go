@@ -0,0 +1,31 @@
+package migrations
+
+import (
+	. "github.com/grafana/grafana/pkg/services/sqlstore/migrator"
+)
+
+func addLivePublishTokenMigrations(mg *Migrator) {
+	livePublishTokenV1 := Table{
+		Name: "live_publish_token",
+		Columns: []*Column{
+			{Name: "id", Type: DB_BigInt, Nullable: false, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "org_id", Type: DB_BigInt, Nullable: false},
+			{Name: "name", Type: DB_NVarchar, Length: 190, Nullable: false},
+			{Name: "token_hash", Type: DB_NVarchar, Length: 64, Nullable: false},
+			{Name: "patterns", Type: DB_Text, Nullable: false},
+			{Name: "rate_limit", Type: DB_Double, Nullable: false},
+			{Name: "burst", Type: DB_Int, Nullable: false},
+			{Name: "created_by", Type: DB_BigInt, Nullable: false},
+			{Name: "created", Type: DB_DateTime, Nullable: false},
+			{Name: "last_used_at", Type: DB_DateTime, Nullable: true},
+		},
+		Indices: []*Index{
+			{Cols: []string{"org_id", "name"}, Type: UniqueIndex},
+			{Cols: []string{"token_hash"}, Type: UniqueIndex},
+		},
+	}
+
+	mg.AddMigration("create live_publish_token table v1", NewAddTableMigration(livePublishTokenV1))
+	mg.AddMigration("add unique index live_publish_token.org_id-name", NewAddIndexMigration(livePublishTokenV1, livePublishTokenV1.Indices[0]))
+	mg.AddMigration("add unique index live_publish_token.token_hash", NewAddIndexMigration(livePublishTokenV1, livePublishTokenV1.Indices[1]))
+}
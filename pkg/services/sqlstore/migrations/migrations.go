@@ -155,4 +155,12 @@ func (oss *OSSMigrations) AddMigration(mg *Migrator) {
 	ualert.DropTitleUniqueIndexMigration(mg)
 
 	ualert.AddStateFiredAtColumn(mg)
+
+	addLivePublishTokenMigrations(mg)
+
+	addQueryAuditMigrations(mg)
+
+	addTwoFactorMigrations(mg)
+
+	addWebAuthnMigrations(mg)
 }
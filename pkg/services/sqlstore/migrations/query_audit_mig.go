@@ -0,0 +1,38 @@
+package migrations
+
+import (
+	. "github.com/grafana/grafana/pkg/services/sqlstore/migrator"
+)
+
+func addQueryAuditMigrations(mg *Migrator) {
+	queryAuditV1 := Table{
+		Name: "query_audit",
+		Columns: []*Column{
+			{Name: "id", Type: DB_BigInt, Nullable: false, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "org_id", Type: DB_BigInt, Nullable: false},
+			{Name: "timestamp", Type: DB_DateTime, Nullable: false},
+			{Name: "endpoint", Type: DB_NVarchar, Length: 32, Nullable: false},
+			{Name: "user_uid", Type: DB_NVarchar, Length: 64, Nullable: true},
+			{Name: "user_login", Type: DB_NVarchar, Length: 190, Nullable: true},
+			{Name: "plugin_id", Type: DB_NVarchar, Length: 190, Nullable: false},
+			{Name: "datasource_uid", Type: DB_NVarchar, Length: 40, Nullable: true},
+			{Name: "dashboard_uid", Type: DB_NVarchar, Length: 40, Nullable: true},
+			{Name: "panel_id", Type: DB_NVarchar, Length: 40, Nullable: true},
+			{Name: "query", Type: DB_MediumText, Nullable: true},
+			{Name: "time_from", Type: DB_DateTime, Nullable: true},
+			{Name: "time_to", Type: DB_DateTime, Nullable: true},
+			{Name: "duration_ms", Type: DB_BigInt, Nullable: false},
+			{Name: "bytes_returned", Type: DB_BigInt, Nullable: false},
+			{Name: "status", Type: DB_NVarchar, Length: 32, Nullable: false},
+			{Name: "error", Type: DB_Text, Nullable: true},
+		},
+		Indices: []*Index{
+			{Cols: []string{"org_id", "timestamp"}},
+			{Cols: []string{"timestamp"}},
+		},
+	}
+
+	mg.AddMigration("create query_audit table v1", NewAddTableMigration(queryAuditV1))
+	mg.AddMigration("add index query_audit.org_id-timestamp", NewAddIndexMigration(queryAuditV1, queryAuditV1.Indices[0]))
+	mg.AddMigration("add index query_audit.timestamp", NewAddIndexMigration(queryAuditV1, queryAuditV1.Indices[1]))
+}
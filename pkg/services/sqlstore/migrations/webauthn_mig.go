@@ -0,0 +1,35 @@
+package migrations
+
+import (
+	. "github.com/grafana/grafana/pkg/services/sqlstore/migrator"
+)
+
+func addWebAuthnMigrations(mg *Migrator) {
+	webauthnCredentialV1 := Table{
+		Name: "webauthn_credential",
+		Columns: []*Column{
+			{Name: "id", Type: DB_BigInt, Nullable: false, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "user_id", Type: DB_BigInt, Nullable: false},
+			{Name: "name", Type: DB_NVarchar, Length: 190, Nullable: false},
+			// credential ids can be up to 1023 bytes, so they are looked up by hash
+			{Name: "credential_id", Type: DB_Text, Nullable: false},
+			{Name: "credential_id_hash", Type: DB_NVarchar, Length: 64, Nullable: false},
+			{Name: "user_handle", Type: DB_NVarchar, Length: 190, Nullable: false},
+			{Name: "public_key", Type: DB_Text, Nullable: false},
+			{Name: "sign_count", Type: DB_BigInt, Nullable: false},
+			{Name: "aaguid", Type: DB_NVarchar, Length: 64, Nullable: false},
+			{Name: "transports", Type: DB_NVarchar, Length: 190, Nullable: false},
+			{Name: "created", Type: DB_DateTime, Nullable: false},
+			{Name: "updated", Type: DB_DateTime, Nullable: false},
+			{Name: "last_used", Type: DB_DateTime, Nullable: true},
+		},
+		Indices: []*Index{
+			{Cols: []string{"credential_id_hash"}, Type: UniqueIndex},
+			{Cols: []string{"user_id"}},
+		},
+	}
+
+	mg.AddMigration("create webauthn_credential table v1", NewAddTableMigration(webauthnCredentialV1))
+	mg.AddMigration("add unique index webauthn_credential.credential_id_hash", NewAddIndexMigration(webauthnCredentialV1, webauthnCredentialV1.Indices[0]))
+	mg.AddMigration("add index webauthn_credential.user_id", NewAddIndexMigration(webauthnCredentialV1, webauthnCredentialV1.Indices[1]))
+}
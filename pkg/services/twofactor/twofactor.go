@@ -0,0 +1,66 @@
+package twofactor
+
+import (
+	"context"
+
+	"github.com/grafana/grafana/pkg/apimachinery/errutil"
+)
+
+var (
+	ErrInvalidCode      = errutil.Unauthorized("two-factor.invalid-code", errutil.WithPublicMessage("Invalid two-factor authentication code"))
+	ErrNotEnrolled      = errutil.BadRequest("two-factor.not-enrolled", errutil.WithPublicMessage("Two-factor authentication is not enabled"))
+	ErrAlreadyEnrolled  = errutil.BadRequest("two-factor.already-enrolled", errutil.WithPublicMessage("Two-factor authentication is already enabled"))
+	ErrNoPendingEnroll  = errutil.BadRequest("two-factor.no-pending-enrollment", errutil.WithPublicMessage("No two-factor authentication enrollment in progress"))
+	ErrRequiredByPolicy = errutil.Forbidden("two-factor.required", errutil.WithPublicMessage("Two-factor authentication is required and cannot be disabled"))
+	ErrTooManyAttempts  = errutil.TooManyRequests("two-factor.too-many-attempts", errutil.WithPublicMessage("Too many invalid two-factor authentication codes, try again later"))
+)
+
+// Service manages TOTP two-factor authentication for users with a Grafana password.
+type Service interface {
+	// IsEnabled returns true if two-factor authentication is enabled in the configuration.
+	IsEnabled() bool
+	// GetStatus returns the two-factor status of a user.
+	GetStatus(ctx context.Context, userID int64) (*Status, error)
+	// StartEnrollment generates a new secret and recovery codes for a user.
+	// The enrollment is pending until it is confirmed with a valid code.
+	StartEnrollment(ctx context.Context, userID int64, login string) (*Enrollment, error)
+	// ConfirmEnrollment enables two-factor authentication for a user with a pending enrollment.
+	ConfirmEnrollment(ctx context.Context, userID int64, code string) error
+	// Verify checks a TOTP code or recovery code for an enrolled user.
+	// Codes can only be used once.
+	Verify(ctx context.Context, userID int64, code string) error
+	// RegenerateRecoveryCodes replaces the recovery codes of an enrolled user.
+	RegenerateRecoveryCodes(ctx context.Context, userID int64, code string) ([]string, error)
+	// Disable removes two-factor authentication for a user after checking a code.
+	// It fails if two-factor authentication is required for the user.
+	Disable(ctx context.Context, userID int64, code string) error
+	// Reset removes two-factor authentication for a user without a code. Used by administrators.
+	Reset(ctx context.Context, userID int64) error
+	// GetOrgPolicy returns the two-factor policy of an org.
+	GetOrgPolicy(ctx context.Context, orgID int64) (*OrgPolicy, error)
+	// SetOrgPolicy updates the two-factor policy of an org.
+	SetOrgPolicy(ctx context.Context, orgID int64, policy OrgPolicy) error
+}
+
+type Status struct {
+	// Enabled is true once the user has confirmed an enrollment.
+	Enabled bool `json:"enabled"`
+	// Required is true if any org the user is a member of requires two-factor authentication.
+	Required bool `json:"required"`
+	// RecoveryCodesRemaining is the number of unused recovery codes.
+	RecoveryCodesRemaining int `json:"recoveryCodesRemaining"`
+}
+
+type Enrollment struct {
+	// Secret is the base32 encoded TOTP secret.
+	Secret string `json:"secret"`
+	// URL is the otpauth:// URL to show as a QR code.
+	URL string `json:"url"`
+	// RecoveryCodes can each be used once instead of a TOTP code.
+	RecoveryCodes []string `json:"recoveryCodes"`
+}
+
+type OrgPolicy struct {
+	// Required makes users of the org with a Grafana password enroll before they can log in.
+	Required bool `json:"required"`
+}
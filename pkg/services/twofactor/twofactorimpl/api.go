@@ -0,0 +1,198 @@
+package twofactorimpl
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/grafana/authlib/types"
+
+	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/middleware"
+	ac "github.com/grafana/grafana/pkg/services/accesscontrol"
+	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
+	"github.com/grafana/grafana/pkg/services/twofactor"
+	"github.com/grafana/grafana/pkg/web"
+)
+
+type codeForm struct {
+	Code string `json:"code" binding:"Required"`
+}
+
+func (s *Service) registerAPIEndpoints(routeRegister routing.RouteRegister) {
+	authorize := ac.Middleware(s.accessControl)
+
+	routeRegister.Group("/api/user/two-factor", func(userRoute routing.RouteRegister) {
+		userRoute.Get("/", routing.Wrap(s.getUserStatusHandler))
+		userRoute.Post("/enroll", routing.Wrap(s.startEnrollmentHandler))
+		userRoute.Post("/confirm", routing.Wrap(s.confirmEnrollmentHandler))
+		userRoute.Post("/recovery-codes", routing.Wrap(s.regenerateRecoveryCodesHandler))
+		userRoute.Post("/disable", routing.Wrap(s.disableHandler))
+	}, middleware.ReqSignedInNoAnonymous)
+
+	userIDScope := ac.Scope("global.users", "id", ac.Parameter(":id"))
+	routeRegister.Delete("/api/admin/users/:id/two-factor", middleware.ReqSignedIn, authorize(ac.EvalPermission(ac.ActionUsersWrite, userIDScope)), routing.Wrap(s.resetHandler))
+
+	routeRegister.Group("/api/org/two-factor", func(orgRoute routing.RouteRegister) {
+		orgRoute.Get("/", authorize(ac.EvalPermission(ac.ActionOrgsRead)), routing.Wrap(s.getOrgPolicyHandler))
+		orgRoute.Put("/", authorize(ac.EvalPermission(ac.ActionOrgsWrite)), routing.Wrap(s.setOrgPolicyHandler))
+	}, middleware.ReqSignedIn)
+}
+
+// signedInUserID returns the id of the signed in user. Two-factor
+// authentication is only available to users, not service accounts.
+func signedInUserID(c *contextmodel.ReqContext) (int64, error) {
+	if !c.SignedInUser.IsIdentityType(types.TypeUser) {
+		return 0, errors.New("two-factor authentication is only available to users")
+	}
+	return c.SignedInUser.GetInternalID()
+}
+
+// limitAttempts runs verify with the same attempt limiting as the two-factor
+// login step, so that a signed in session can't be used to guess codes.
+func (s *Service) limitAttempts(c *contextmodel.ReqContext, verify func() error) error {
+	ctx := c.Req.Context()
+	login := c.SignedInUser.GetLogin()
+	ip := web.RemoteAddr(c.Req)
+
+	ok, err := s.loginAttempts.Validate(ctx, login)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return twofactor.ErrTooManyAttempts.Errorf("too many invalid two-factor codes for user")
+	}
+	ok, err = s.loginAttempts.ValidateIPAddress(ctx, ip)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return twofactor.ErrTooManyAttempts.Errorf("too many invalid two-factor codes for IP address")
+	}
+
+	err = verify()
+	switch {
+	case errors.Is(err, twofactor.ErrInvalidCode):
+		if addErr := s.loginAttempts.Add(ctx, login, ip); addErr != nil {
+			s.log.FromContext(ctx).Error("Failed to add login attempt", "user", login, "err", addErr)
+		}
+	case err == nil:
+		if resetErr := s.loginAttempts.Reset(ctx, login); resetErr != nil {
+			s.log.FromContext(ctx).Error("Failed to reset login attempts", "user", login, "err", resetErr)
+		}
+	}
+	return err
+}
+
+func (s *Service) getUserStatusHandler(c *contextmodel.ReqContext) response.Response {
+	userID, err := signedInUserID(c)
+	if err != nil {
+		return response.Error(http.StatusForbidden, "Two-factor authentication is only available to users", err)
+	}
+	status, err := s.GetStatus(c.Req.Context(), userID)
+	if err != nil {
+		return response.Error(http.StatusInternalServerError, "Failed to get two-factor status", err)
+	}
+	return response.JSON(http.StatusOK, status)
+}
+
+func (s *Service) startEnrollmentHandler(c *contextmodel.ReqContext) response.Response {
+	userID, err := signedInUserID(c)
+	if err != nil {
+		return response.Error(http.StatusForbidden, "Two-factor authentication is only available to users", err)
+	}
+	enrollment, err := s.StartEnrollment(c.Req.Context(), userID, c.SignedInUser.GetLogin())
+	if err != nil {
+		return response.ErrOrFallback(http.StatusInternalServerError, "Failed to start two-factor enrollment", err)
+	}
+	return response.JSON(http.StatusOK, enrollment)
+}
+
+func (s *Service) confirmEnrollmentHandler(c *contextmodel.ReqContext) response.Response {
+	userID, err := signedInUserID(c)
+	if err != nil {
+		return response.Error(http.StatusForbidden, "Two-factor authentication is only available to users", err)
+	}
+	form := codeForm{}
+	if err := web.Bind(c.Req, &form); err != nil {
+		return response.Error(http.StatusBadRequest, "bad request data", err)
+	}
+	err = s.limitAttempts(c, func() error {
+		return s.ConfirmEnrollment(c.Req.Context(), userID, form.Code)
+	})
+	if err != nil {
+		return response.ErrOrFallback(http.StatusInternalServerError, "Failed to confirm two-factor enrollment", err)
+	}
+	return response.Success("Two-factor authentication enabled")
+}
+
+func (s *Service) regenerateRecoveryCodesHandler(c *contextmodel.ReqContext) response.Response {
+	userID, err := signedInUserID(c)
+	if err != nil {
+		return response.Error(http.StatusForbidden, "Two-factor authentication is only available to users", err)
+	}
+	form := codeForm{}
+	if err := web.Bind(c.Req, &form); err != nil {
+		return response.Error(http.StatusBadRequest, "bad request data", err)
+	}
+	var codes []string
+	err = s.limitAttempts(c, func() error {
+		var err error
+		codes, err = s.RegenerateRecoveryCodes(c.Req.Context(), userID, form.Code)
+		return err
+	})
+	if err != nil {
+		return response.ErrOrFallback(http.StatusInternalServerError, "Failed to regenerate recovery codes", err)
+	}
+	return response.JSON(http.StatusOK, map[string]any{"recoveryCodes": codes})
+}
+
+func (s *Service) disableHandler(c *contextmodel.ReqContext) response.Response {
+	userID, err := signedInUserID(c)
+	if err != nil {
+		return response.Error(http.StatusForbidden, "Two-factor authentication is only available to users", err)
+	}
+	form := codeForm{}
+	if err := web.Bind(c.Req, &form); err != nil {
+		return response.Error(http.StatusBadRequest, "bad request data", err)
+	}
+	err = s.limitAttempts(c, func() error {
+		return s.Disable(c.Req.Context(), userID, form.Code)
+	})
+	if err != nil {
+		return response.ErrOrFallback(http.StatusInternalServerError, "Failed to disable two-factor authentication", err)
+	}
+	return response.Success("Two-factor authentication disabled")
+}
+
+func (s *Service) resetHandler(c *contextmodel.ReqContext) response.Response {
+	userID, err := strconv.ParseInt(web.Params(c.Req)[":id"], 10, 64)
+	if err != nil {
+		return response.Error(http.StatusBadRequest, "id is invalid", err)
+	}
+	if err := s.Reset(c.Req.Context(), userID); err != nil {
+		return response.Error(http.StatusInternalServerError, "Failed to reset two-factor authentication", err)
+	}
+	s.log.FromContext(c.Req.Context()).Info("Two-factor authentication reset", "userId", userID, "by", c.SignedInUser.GetID())
+	return response.Success("Two-factor authentication reset")
+}
+
+func (s *Service) getOrgPolicyHandler(c *contextmodel.ReqContext) response.Response {
+	policy, err := s.GetOrgPolicy(c.Req.Context(), c.SignedInUser.GetOrgID())
+	if err != nil {
+		return response.Error(http.StatusInternalServerError, "Failed to get two-factor policy", err)
+	}
+	return response.JSON(http.StatusOK, policy)
+}
+
+func (s *Service) setOrgPolicyHandler(c *contextmodel.ReqContext) response.Response {
+	policy := twofactor.OrgPolicy{}
+	if err := web.Bind(c.Req, &policy); err != nil {
+		return response.Error(http.StatusBadRequest, "bad request data", err)
+	}
+	if err := s.SetOrgPolicy(c.Req.Context(), c.SignedInUser.GetOrgID(), policy); err != nil {
+		return response.Error(http.StatusInternalServerError, "Failed to update two-factor policy", err)
+	}
+	return response.Success("Two-factor policy updated")
+}
@@ -0,0 +1,69 @@
+package twofactorimpl
+
+import (
+	"errors"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
+	"github.com/grafana/grafana/pkg/services/loginattempt/loginattempttest"
+	"github.com/grafana/grafana/pkg/services/twofactor"
+	"github.com/grafana/grafana/pkg/services/user"
+	"github.com/grafana/grafana/pkg/web"
+)
+
+func TestService_LimitAttempts(t *testing.T) {
+	setup := func(valid bool) (*Service, *loginattempttest.MockLoginAttemptService, *contextmodel.ReqContext) {
+		attempts := &loginattempttest.MockLoginAttemptService{ExpectedValid: valid}
+		s := &Service{loginAttempts: attempts, log: log.NewNopLogger()}
+		c := &contextmodel.ReqContext{
+			Context:      &web.Context{Req: httptest.NewRequest("POST", "/api/user/two-factor/disable", nil)},
+			SignedInUser: &user.SignedInUser{UserID: 1, Login: "user"},
+		}
+		return s, attempts, c
+	}
+
+	t.Run("should not verify the code when attempts are exhausted", func(t *testing.T) {
+		s, attempts, c := setup(false)
+		called := false
+		err := s.limitAttempts(c, func() error {
+			called = true
+			return nil
+		})
+		require.ErrorIs(t, err, twofactor.ErrTooManyAttempts)
+		assert.False(t, called)
+		assert.True(t, attempts.ValidateCalled)
+	})
+
+	t.Run("should count invalid codes", func(t *testing.T) {
+		s, attempts, c := setup(true)
+		err := s.limitAttempts(c, func() error {
+			return twofactor.ErrInvalidCode.Errorf("invalid code")
+		})
+		require.ErrorIs(t, err, twofactor.ErrInvalidCode)
+		assert.True(t, attempts.AddCalled)
+		assert.False(t, attempts.ResetCalled)
+	})
+
+	t.Run("should not count other errors", func(t *testing.T) {
+		s, attempts, c := setup(true)
+		err := s.limitAttempts(c, func() error {
+			return twofactor.ErrNotEnrolled.Errorf("not enrolled")
+		})
+		require.Error(t, err)
+		assert.False(t, errors.Is(err, twofactor.ErrInvalidCode))
+		assert.False(t, attempts.AddCalled)
+		assert.False(t, attempts.ResetCalled)
+	})
+
+	t.Run("should reset attempts on a valid code", func(t *testing.T) {
+		s, attempts, c := setup(true)
+		require.NoError(t, s.limitAttempts(c, func() error { return nil }))
+		assert.False(t, attempts.AddCalled)
+		assert.True(t, attempts.ResetCalled)
+	})
+}
@@ -0,0 +1,142 @@
+package twofactorimpl
+
+import (
+	"context"
+	"time"
+
+	"github.com/grafana/grafana/pkg/infra/db"
+)
+
+type userTwoFactor struct {
+	ID     int64 `xorm:"pk autoincr 'id'"`
+	UserID int64 `xorm:"user_id"`
+	// Secret is the TOTP secret encrypted with the secrets service and base64 encoded.
+	Secret string `xorm:"secret"`
+	// RecoveryCodes is a JSON list of hashed unused recovery codes.
+	RecoveryCodes string `xorm:"recovery_codes"`
+	Confirmed     bool   `xorm:"confirmed"`
+	// LastCounter is the time step of the last accepted code.
+	LastCounter int64     `xorm:"last_counter"`
+	Created     time.Time `xorm:"created"`
+	Updated     time.Time `xorm:"updated"`
+}
+
+func (userTwoFactor) TableName() string {
+	return "user_two_factor"
+}
+
+type orgTwoFactor struct {
+	ID       int64     `xorm:"pk autoincr 'id'"`
+	OrgID    int64     `xorm:"org_id"`
+	Required bool      `xorm:"required"`
+	Updated  time.Time `xorm:"updated"`
+}
+
+func (orgTwoFactor) TableName() string {
+	return "org_two_factor"
+}
+
+type store interface {
+	Get(ctx context.Context, userID int64) (*userTwoFactor, error)
+	// Upsert replaces the two-factor settings of a user.
+	Upsert(ctx context.Context, tf *userTwoFactor) error
+	// UpdateIfUnchanged updates the two-factor settings of a user if the last
+	// counter and recovery codes are still those of prev, so a code can only
+	// be used once even with concurrent requests.
+	UpdateIfUnchanged(ctx context.Context, tf *userTwoFactor, prev *userTwoFactor) (bool, error)
+	Delete(ctx context.Context, userID int64) error
+	IsRequiredForUser(ctx context.Context, userID int64) (bool, error)
+	GetOrgPolicy(ctx context.Context, orgID int64) (*orgTwoFactor, error)
+	SetOrgPolicy(ctx context.Context, orgID int64, required bool) error
+}
+
+type xormStore struct {
+	db  db.DB
+	now func() time.Time
+}
+
+func (s *xormStore) Get(ctx context.Context, userID int64) (*userTwoFactor, error) {
+	var tf *userTwoFactor
+	err := s.db.WithDbSession(ctx, func(sess *db.Session) error {
+		row := userTwoFactor{}
+		has, err := sess.Where("user_id = ?", userID).Get(&row)
+		if err != nil || !has {
+			return err
+		}
+		tf = &row
+		return nil
+	})
+	return tf, err
+}
+
+func (s *xormStore) Upsert(ctx context.Context, tf *userTwoFactor) error {
+	return s.db.WithTransactionalDbSession(ctx, func(sess *db.Session) error {
+		if _, err := sess.Exec("DELETE FROM user_two_factor WHERE user_id = ?", tf.UserID); err != nil {
+			return err
+		}
+		now := s.now()
+		tf.ID = 0
+		tf.Created = now
+		tf.Updated = now
+		_, err := sess.Insert(tf)
+		return err
+	})
+}
+
+func (s *xormStore) UpdateIfUnchanged(ctx context.Context, tf *userTwoFactor, prev *userTwoFactor) (bool, error) {
+	var updated bool
+	err := s.db.WithDbSession(ctx, func(sess *db.Session) error {
+		tf.Updated = s.now()
+		affected, err := sess.Where("user_id = ? AND last_counter = ? AND recovery_codes = ?", prev.UserID, prev.LastCounter, prev.RecoveryCodes).
+			Cols("secret", "recovery_codes", "confirmed", "last_counter", "updated").
+			Update(tf)
+		updated = affected > 0
+		return err
+	})
+	return updated, err
+}
+
+func (s *xormStore) Delete(ctx context.Context, userID int64) error {
+	return s.db.WithDbSession(ctx, func(sess *db.Session) error {
+		_, err := sess.Exec("DELETE FROM user_two_factor WHERE user_id = ?", userID)
+		return err
+	})
+}
+
+func (s *xormStore) IsRequiredForUser(ctx context.Context, userID int64) (bool, error) {
+	var required bool
+	err := s.db.WithDbSession(ctx, func(sess *db.Session) error {
+		count, err := sess.Table("org_two_factor").
+			Join("INNER", "org_user", "org_user.org_id = org_two_factor.org_id").
+			Where("org_user.user_id = ? AND org_two_factor.required = ?", userID, true).
+			Count()
+		required = count > 0
+		return err
+	})
+	return required, err
+}
+
+func (s *xormStore) GetOrgPolicy(ctx context.Context, orgID int64) (*orgTwoFactor, error) {
+	policy := &orgTwoFactor{OrgID: orgID}
+	err := s.db.WithDbSession(ctx, func(sess *db.Session) error {
+		_, err := sess.Where("org_id = ?", orgID).Get(policy)
+		return err
+	})
+	return policy, err
+}
+
+func (s *xormStore) SetOrgPolicy(ctx context.Context, orgID int64, required bool) error {
+	return s.db.WithTransactionalDbSession(ctx, func(sess *db.Session) error {
+		policy := orgTwoFactor{OrgID: orgID, Required: required, Updated: s.now()}
+		exists, err := sess.Table("org_two_factor").Where("org_id = ?", orgID).Exist()
+		if err != nil {
+			return err
+		}
+		if exists {
+			_, err = sess.Where("org_id = ?", orgID).Cols("required", "updated").Update(&policy)
+			return err
+		}
+		_, err = sess.Insert(&policy)
+		return err
+	})
+}
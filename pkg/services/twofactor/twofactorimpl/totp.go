@@ -0,0 +1,113 @@
+package twofactorimpl
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1" //nolint:gosec // RFC 6238 uses HMAC-SHA1 and authenticator apps default to it
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	totpPeriod = 30 * time.Second
+	totpDigits = 6
+	// totpSkew is the number of periods before and after the current one
+	// that are accepted, to allow for clock drift.
+	totpSkew = 1
+	// recoveryCodeLength is the number of characters in a recovery code.
+	recoveryCodeLength = 10
+)
+
+var secretEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+func generateSecret() (string, error) {
+	b := make([]byte, 20)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return secretEncoding.EncodeToString(b), nil
+}
+
+// totpCode returns the code for a time step as defined in RFC 4226 and RFC 6238.
+func totpCode(secret []byte, counter uint64) string {
+	msg := make([]byte, 8)
+	binary.BigEndian.PutUint64(msg, counter)
+	mac := hmac.New(sha1.New, secret)
+	mac.Write(msg)
+	sum := mac.Sum(nil)
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	return fmt.Sprintf("%0*d", totpDigits, value%1_000_000)
+}
+
+func totpCounter(t time.Time) uint64 {
+	return uint64(t.Unix() / int64(totpPeriod/time.Second))
+}
+
+// validateTOTP returns the time step of the code if it is valid at t and
+// newer than lastCounter, so that a code cannot be used twice.
+func validateTOTP(secret string, code string, t time.Time, lastCounter uint64) (uint64, bool) {
+	key, err := secretEncoding.DecodeString(strings.ToUpper(secret))
+	if err != nil || len(code) != totpDigits {
+		return 0, false
+	}
+	current := totpCounter(t)
+	for i := -totpSkew; i <= totpSkew; i++ {
+		counter := current + uint64(i)
+		if counter <= lastCounter {
+			continue
+		}
+		if subtle.ConstantTimeCompare([]byte(totpCode(key, counter)), []byte(code)) == 1 {
+			return counter, true
+		}
+	}
+	return 0, false
+}
+
+// totpURL returns the otpauth:// URL authenticator apps read from QR codes.
+func totpURL(issuer, account, secret string) string {
+	label := url.PathEscape(issuer + ":" + account)
+	values := url.Values{}
+	values.Set("secret", secret)
+	values.Set("issuer", issuer)
+	values.Set("algorithm", "SHA1")
+	values.Set("digits", fmt.Sprint(totpDigits))
+	values.Set("period", fmt.Sprint(int(totpPeriod.Seconds())))
+	return "otpauth://totp/" + label + "?" + values.Encode()
+}
+
+var recoveryCodeAlphabet = []byte("23456789ABCDEFGHJKLMNPQRSTUVWXYZ")
+
+func generateRecoveryCodes(n int) ([]string, error) {
+	codes := make([]string, 0, n)
+	for i := 0; i < n; i++ {
+		b := make([]byte, recoveryCodeLength)
+		if _, err := rand.Read(b); err != nil {
+			return nil, err
+		}
+		for j := range b {
+			b[j] = recoveryCodeAlphabet[int(b[j])%len(recoveryCodeAlphabet)]
+		}
+		codes = append(codes, string(b[:recoveryCodeLength/2])+"-"+string(b[recoveryCodeLength/2:]))
+	}
+	return codes, nil
+}
+
+// normalizeRecoveryCode makes recovery codes case and separator insensitive.
+func normalizeRecoveryCode(code string) string {
+	return strings.ToUpper(strings.NewReplacer("-", "", " ", "").Replace(code))
+}
+
+// hashRecoveryCode hashes a recovery code for storage. Recovery codes are
+// random, so a salted slow hash is not needed.
+func hashRecoveryCode(code string) string {
+	sum := sha256.Sum256([]byte(normalizeRecoveryCode(code)))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,80 @@
+package twofactorimpl
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTOTPCode(t *testing.T) {
+	// Test values from RFC 4226 appendix D.
+	secret := []byte("12345678901234567890")
+	expected := []string{"755224", "287082", "359152", "969429", "338314", "254676", "287922", "162583", "399871", "520489"}
+	for counter, code := range expected {
+		assert.Equal(t, code, totpCode(secret, uint64(counter)))
+	}
+}
+
+func TestValidateTOTP(t *testing.T) {
+	secret := secretEncoding.EncodeToString([]byte("12345678901234567890"))
+	// 59s is in time step 1.
+	now := time.Unix(59, 0)
+
+	t.Run("should accept code for current time step", func(t *testing.T) {
+		counter, ok := validateTOTP(secret, "287082", now, 0)
+		require.True(t, ok)
+		assert.Equal(t, uint64(1), counter)
+	})
+
+	t.Run("should accept code for adjacent time step", func(t *testing.T) {
+		counter, ok := validateTOTP(secret, "359152", now, 0)
+		require.True(t, ok)
+		assert.Equal(t, uint64(2), counter)
+	})
+
+	t.Run("should accept lower case secret", func(t *testing.T) {
+		_, ok := validateTOTP(strings.ToLower(secret), "287082", now, 0)
+		assert.True(t, ok)
+	})
+
+	t.Run("should reject code outside of skew", func(t *testing.T) {
+		_, ok := validateTOTP(secret, "969429", now, 0)
+		assert.False(t, ok)
+	})
+
+	t.Run("should reject code already used", func(t *testing.T) {
+		_, ok := validateTOTP(secret, "287082", now, 1)
+		assert.False(t, ok)
+	})
+
+	t.Run("should reject invalid code", func(t *testing.T) {
+		_, ok := validateTOTP(secret, "000000", now, 0)
+		assert.False(t, ok)
+		_, ok = validateTOTP(secret, "28708", now, 0)
+		assert.False(t, ok)
+	})
+}
+
+func TestTOTPURL(t *testing.T) {
+	u, err := url.Parse(totpURL("Grafana", "admin@example.com", "SECRET"))
+	require.NoError(t, err)
+	assert.Equal(t, "otpauth", u.Scheme)
+	assert.Equal(t, "totp", u.Host)
+	assert.Equal(t, "/Grafana:admin@example.com", u.Path)
+	assert.Equal(t, "SECRET", u.Query().Get("secret"))
+	assert.Equal(t, "Grafana", u.Query().Get("issuer"))
+}
+
+func TestRecoveryCodes(t *testing.T) {
+	codes, err := generateRecoveryCodes(10)
+	require.NoError(t, err)
+	require.Len(t, codes, 10)
+	for _, code := range codes {
+		assert.Len(t, code, recoveryCodeLength+1)
+		assert.Equal(t, hashRecoveryCode(code), hashRecoveryCode(strings.ToLower(strings.ReplaceAll(code, "-", " "))))
+	}
+}
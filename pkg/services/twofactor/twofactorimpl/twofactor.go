@@ -0,0 +1,289 @@
+package twofactorimpl
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"slices"
+	"time"
+
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/infra/log"
+	ac "github.com/grafana/grafana/pkg/services/accesscontrol"
+	"github.com/grafana/grafana/pkg/services/loginattempt"
+	"github.com/grafana/grafana/pkg/services/secrets"
+	"github.com/grafana/grafana/pkg/services/twofactor"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+var _ twofactor.Service = new(Service)
+
+func ProvideService(cfg *setting.Cfg, db db.DB, secretsService secrets.Service, routeRegister routing.RouteRegister, accessControl ac.AccessControl, loginAttempts loginattempt.Service) *Service {
+	s := &Service{
+		cfg:            cfg.TwoFactorAuth,
+		store:          &xormStore{db: db, now: time.Now},
+		secretsService: secretsService,
+		accessControl:  accessControl,
+		loginAttempts:  loginAttempts,
+		now:            time.Now,
+		log:            log.New("two-factor"),
+	}
+
+	if s.cfg.Enabled {
+		s.registerAPIEndpoints(routeRegister)
+	}
+
+	return s
+}
+
+type Service struct {
+	cfg            setting.AuthTwoFactorSettings
+	store          store
+	secretsService secrets.Service
+	accessControl  ac.AccessControl
+	loginAttempts  loginattempt.Service
+	now            func() time.Time
+	log            log.Logger
+}
+
+func (s *Service) IsEnabled() bool {
+	return s.cfg.Enabled
+}
+
+func (s *Service) GetStatus(ctx context.Context, userID int64) (*twofactor.Status, error) {
+	required, err := s.store.IsRequiredForUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	status := &twofactor.Status{Required: required}
+
+	tf, err := s.store.Get(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if tf != nil && tf.Confirmed {
+		codes, err := decodeRecoveryCodes(tf.RecoveryCodes)
+		if err != nil {
+			return nil, err
+		}
+		status.Enabled = true
+		status.RecoveryCodesRemaining = len(codes)
+	}
+	return status, nil
+}
+
+func (s *Service) StartEnrollment(ctx context.Context, userID int64, login string) (*twofactor.Enrollment, error) {
+	tf, err := s.store.Get(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if tf != nil && tf.Confirmed {
+		return nil, twofactor.ErrAlreadyEnrolled.Errorf("user %d already has two-factor authentication enabled", userID)
+	}
+
+	secret, err := generateSecret()
+	if err != nil {
+		return nil, err
+	}
+	encrypted, err := s.secretsService.Encrypt(ctx, []byte(secret), secrets.WithoutScope())
+	if err != nil {
+		return nil, err
+	}
+	codes, hashed, err := s.newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.store.Upsert(ctx, &userTwoFactor{
+		UserID:        userID,
+		Secret:        base64.StdEncoding.EncodeToString(encrypted),
+		RecoveryCodes: hashed,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &twofactor.Enrollment{
+		Secret:        secret,
+		URL:           totpURL(s.cfg.Issuer, login, secret),
+		RecoveryCodes: codes,
+	}, nil
+}
+
+func (s *Service) ConfirmEnrollment(ctx context.Context, userID int64, code string) error {
+	tf, err := s.store.Get(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if tf == nil {
+		return twofactor.ErrNoPendingEnroll.Errorf("user %d has no pending enrollment", userID)
+	}
+	if tf.Confirmed {
+		return twofactor.ErrAlreadyEnrolled.Errorf("user %d already has two-factor authentication enabled", userID)
+	}
+
+	// Recovery codes can not be used to confirm an enrollment, as they do not
+	// prove that the authenticator was set up.
+	updated, err := s.verifyTOTP(ctx, tf, code)
+	if err != nil {
+		return err
+	}
+	updated.Confirmed = true
+	return s.update(ctx, updated, tf)
+}
+
+func (s *Service) Verify(ctx context.Context, userID int64, code string) error {
+	tf, err := s.getEnrolled(ctx, userID)
+	if err != nil {
+		return err
+	}
+	updated, err := s.verifyCode(ctx, tf, code)
+	if err != nil {
+		return err
+	}
+	return s.update(ctx, updated, tf)
+}
+
+func (s *Service) RegenerateRecoveryCodes(ctx context.Context, userID int64, code string) ([]string, error) {
+	tf, err := s.getEnrolled(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	updated, err := s.verifyTOTP(ctx, tf, code)
+	if err != nil {
+		return nil, err
+	}
+	codes, hashed, err := s.newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	updated.RecoveryCodes = hashed
+	if err := s.update(ctx, updated, tf); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+func (s *Service) Disable(ctx context.Context, userID int64, code string) error {
+	required, err := s.store.IsRequiredForUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if required {
+		return twofactor.ErrRequiredByPolicy.Errorf("two-factor authentication is required for user %d", userID)
+	}
+	if err := s.Verify(ctx, userID, code); err != nil {
+		return err
+	}
+	return s.store.Delete(ctx, userID)
+}
+
+func (s *Service) Reset(ctx context.Context, userID int64) error {
+	return s.store.Delete(ctx, userID)
+}
+
+func (s *Service) GetOrgPolicy(ctx context.Context, orgID int64) (*twofactor.OrgPolicy, error) {
+	policy, err := s.store.GetOrgPolicy(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+	return &twofactor.OrgPolicy{Required: policy.Required}, nil
+}
+
+func (s *Service) SetOrgPolicy(ctx context.Context, orgID int64, policy twofactor.OrgPolicy) error {
+	return s.store.SetOrgPolicy(ctx, orgID, policy.Required)
+}
+
+func (s *Service) getEnrolled(ctx context.Context, userID int64) (*userTwoFactor, error) {
+	tf, err := s.store.Get(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if tf == nil || !tf.Confirmed {
+		return nil, twofactor.ErrNotEnrolled.Errorf("user %d has no two-factor authentication enabled", userID)
+	}
+	return tf, nil
+}
+
+// verifyCode checks a TOTP code, and falls back to recovery codes. It
+// returns the settings to store so that the code cannot be used again.
+func (s *Service) verifyCode(ctx context.Context, tf *userTwoFactor, code string) (*userTwoFactor, error) {
+	if len(code) == totpDigits {
+		return s.verifyTOTP(ctx, tf, code)
+	}
+
+	hashes, err := decodeRecoveryCodes(tf.RecoveryCodes)
+	if err != nil {
+		return nil, err
+	}
+	idx := slices.Index(hashes, hashRecoveryCode(code))
+	if idx < 0 {
+		return nil, twofactor.ErrInvalidCode.Errorf("invalid recovery code")
+	}
+	remaining, err := json.Marshal(slices.Delete(hashes, idx, idx+1))
+	if err != nil {
+		return nil, err
+	}
+	s.log.FromContext(ctx).Info("Recovery code used", "userId", tf.UserID, "remaining", len(hashes)-1)
+
+	updated := *tf
+	updated.RecoveryCodes = string(remaining)
+	return &updated, nil
+}
+
+func (s *Service) verifyTOTP(ctx context.Context, tf *userTwoFactor, code string) (*userTwoFactor, error) {
+	encrypted, err := base64.StdEncoding.DecodeString(tf.Secret)
+	if err != nil {
+		return nil, err
+	}
+	secret, err := s.secretsService.Decrypt(ctx, encrypted)
+	if err != nil {
+		return nil, err
+	}
+	counter, ok := validateTOTP(string(secret), code, s.now(), uint64(tf.LastCounter))
+	if !ok {
+		return nil, twofactor.ErrInvalidCode.Errorf("invalid code")
+	}
+
+	updated := *tf
+	updated.LastCounter = int64(counter)
+	return &updated, nil
+}
+
+func (s *Service) update(ctx context.Context, tf *userTwoFactor, prev *userTwoFactor) error {
+	ok, err := s.store.UpdateIfUnchanged(ctx, tf, prev)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		// Another request used a code at the same time.
+		return twofactor.ErrInvalidCode.Errorf("code already used")
+	}
+	return nil
+}
+
+func (s *Service) newRecoveryCodes() ([]string, string, error) {
+	codes, err := generateRecoveryCodes(s.cfg.RecoveryCodes)
+	if err != nil {
+		return nil, "", err
+	}
+	hashes := make([]string, 0, len(codes))
+	for _, code := range codes {
+		hashes = append(hashes, hashRecoveryCode(code))
+	}
+	hashed, err := json.Marshal(hashes)
+	if err != nil {
+		return nil, "", err
+	}
+	return codes, string(hashed), nil
+}
+
+func decodeRecoveryCodes(raw string) ([]string, error) {
+	hashes := []string{}
+	if raw == "" {
+		return hashes, nil
+	}
+	err := json.Unmarshal([]byte(raw), &hashes)
+	return hashes, err
+}
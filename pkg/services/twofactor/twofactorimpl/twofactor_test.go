@@ -0,0 +1,157 @@
+package twofactorimpl
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/services/accesscontrol/actest"
+	"github.com/grafana/grafana/pkg/services/loginattempt/loginattempttest"
+	"github.com/grafana/grafana/pkg/services/org"
+	"github.com/grafana/grafana/pkg/services/secrets/fakes"
+	"github.com/grafana/grafana/pkg/services/twofactor"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/tests/testsuite"
+	"github.com/grafana/grafana/pkg/util/testutil"
+)
+
+func TestMain(m *testing.M) {
+	testsuite.Run(m)
+}
+
+func TestIntegrationTwoFactorService(t *testing.T) {
+	testutil.SkipIntegrationTestInShortMode(t)
+
+	ctx := context.Background()
+	now := time.Unix(1_700_000_000, 0)
+
+	setup := func(t *testing.T) (*Service, db.DB) {
+		t.Helper()
+		sqlStore := db.InitTestDB(t)
+		cfg := setting.NewCfg()
+		cfg.TwoFactorAuth = setting.AuthTwoFactorSettings{Enabled: false, Issuer: "Grafana", RecoveryCodes: 3}
+		s := ProvideService(cfg, sqlStore, fakes.NewFakeSecretsService(), routing.NewRouteRegister(), &actest.FakeAccessControl{}, loginattempttest.FakeLoginAttemptService{ExpectedValid: true})
+		s.now = func() time.Time { return now }
+		return s, sqlStore
+	}
+
+	codeAt := func(t *testing.T, secret string, at time.Time) string {
+		t.Helper()
+		key, err := secretEncoding.DecodeString(secret)
+		require.NoError(t, err)
+		return totpCode(key, totpCounter(at))
+	}
+
+	enroll := func(t *testing.T, s *Service, userID int64) *twofactor.Enrollment {
+		t.Helper()
+		enrollment, err := s.StartEnrollment(ctx, userID, "user")
+		require.NoError(t, err)
+		require.NoError(t, s.ConfirmEnrollment(ctx, userID, codeAt(t, enrollment.Secret, now)))
+		// Move to the next time step so that a new code can be used.
+		now = now.Add(totpPeriod)
+		return enrollment
+	}
+
+	t.Run("should enroll and verify codes", func(t *testing.T) {
+		s, _ := setup(t)
+
+		status, err := s.GetStatus(ctx, 1)
+		require.NoError(t, err)
+		assert.False(t, status.Enabled)
+
+		enrollment, err := s.StartEnrollment(ctx, 1, "user")
+		require.NoError(t, err)
+		assert.Len(t, enrollment.RecoveryCodes, 3)
+		assert.Contains(t, enrollment.URL, "otpauth://totp/Grafana:user")
+
+		err = s.Verify(ctx, 1, codeAt(t, enrollment.Secret, now))
+		require.ErrorIs(t, err, twofactor.ErrNotEnrolled, "pending enrollment should not be usable")
+
+		err = s.ConfirmEnrollment(ctx, 1, enrollment.RecoveryCodes[0])
+		require.ErrorIs(t, err, twofactor.ErrInvalidCode, "recovery codes should not confirm an enrollment")
+
+		code := codeAt(t, enrollment.Secret, now)
+		require.NoError(t, s.ConfirmEnrollment(ctx, 1, code))
+
+		status, err = s.GetStatus(ctx, 1)
+		require.NoError(t, err)
+		assert.True(t, status.Enabled)
+		assert.Equal(t, 3, status.RecoveryCodesRemaining)
+
+		err = s.Verify(ctx, 1, code)
+		require.ErrorIs(t, err, twofactor.ErrInvalidCode, "code should only be usable once")
+
+		now = now.Add(totpPeriod)
+		require.NoError(t, s.Verify(ctx, 1, codeAt(t, enrollment.Secret, now)))
+
+		_, err = s.StartEnrollment(ctx, 1, "user")
+		require.ErrorIs(t, err, twofactor.ErrAlreadyEnrolled)
+	})
+
+	t.Run("should use recovery codes once", func(t *testing.T) {
+		s, _ := setup(t)
+		enrollment := enroll(t, s, 1)
+
+		require.NoError(t, s.Verify(ctx, 1, enrollment.RecoveryCodes[0]))
+		err := s.Verify(ctx, 1, enrollment.RecoveryCodes[0])
+		require.ErrorIs(t, err, twofactor.ErrInvalidCode)
+
+		status, err := s.GetStatus(ctx, 1)
+		require.NoError(t, err)
+		assert.Equal(t, 2, status.RecoveryCodesRemaining)
+
+		codes, err := s.RegenerateRecoveryCodes(ctx, 1, codeAt(t, enrollment.Secret, now))
+		require.NoError(t, err)
+		assert.Len(t, codes, 3)
+
+		err = s.Verify(ctx, 1, enrollment.RecoveryCodes[1])
+		require.ErrorIs(t, err, twofactor.ErrInvalidCode, "old recovery codes should be replaced")
+		require.NoError(t, s.Verify(ctx, 1, codes[1]))
+	})
+
+	t.Run("should not disable when required by org", func(t *testing.T) {
+		s, sqlStore := setup(t)
+		enrollment := enroll(t, s, 1)
+
+		err := sqlStore.WithDbSession(ctx, func(sess *db.Session) error {
+			_, err := sess.Insert(&org.OrgUser{OrgID: 2, UserID: 1, Role: org.RoleViewer, Created: now, Updated: now})
+			return err
+		})
+		require.NoError(t, err)
+
+		require.NoError(t, s.SetOrgPolicy(ctx, 2, twofactor.OrgPolicy{Required: true}))
+		policy, err := s.GetOrgPolicy(ctx, 2)
+		require.NoError(t, err)
+		assert.True(t, policy.Required)
+
+		status, err := s.GetStatus(ctx, 1)
+		require.NoError(t, err)
+		assert.True(t, status.Required)
+
+		err = s.Disable(ctx, 1, codeAt(t, enrollment.Secret, now))
+		require.ErrorIs(t, err, twofactor.ErrRequiredByPolicy)
+
+		// Setting the same policy again should not fail.
+		require.NoError(t, s.SetOrgPolicy(ctx, 2, twofactor.OrgPolicy{Required: false}))
+		require.NoError(t, s.SetOrgPolicy(ctx, 2, twofactor.OrgPolicy{Required: false}))
+
+		require.NoError(t, s.Disable(ctx, 1, codeAt(t, enrollment.Secret, now)))
+		status, err = s.GetStatus(ctx, 1)
+		require.NoError(t, err)
+		assert.False(t, status.Enabled)
+	})
+
+	t.Run("should reset without code", func(t *testing.T) {
+		s, _ := setup(t)
+		enroll(t, s, 1)
+
+		require.NoError(t, s.Reset(ctx, 1))
+		err := s.Verify(ctx, 1, "123456")
+		require.ErrorIs(t, err, twofactor.ErrNotEnrolled)
+	})
+}
@@ -0,0 +1,70 @@
+package twofactortest
+
+import (
+	"context"
+
+	"github.com/grafana/grafana/pkg/services/twofactor"
+)
+
+var _ twofactor.Service = new(FakeService)
+
+type FakeService struct {
+	ExpectedEnabled    bool
+	ExpectedStatus     *twofactor.Status
+	ExpectedEnrollment *twofactor.Enrollment
+	ExpectedPolicy     *twofactor.OrgPolicy
+	ExpectedCodes      []string
+	ExpectedErr        error
+
+	// VerifiedCodes records the codes passed to Verify and ConfirmEnrollment.
+	VerifiedCodes []string
+	Confirmed     bool
+}
+
+func (f *FakeService) IsEnabled() bool {
+	return f.ExpectedEnabled
+}
+
+func (f *FakeService) GetStatus(ctx context.Context, userID int64) (*twofactor.Status, error) {
+	if f.ExpectedStatus == nil {
+		return &twofactor.Status{}, f.ExpectedErr
+	}
+	return f.ExpectedStatus, f.ExpectedErr
+}
+
+func (f *FakeService) StartEnrollment(ctx context.Context, userID int64, login string) (*twofactor.Enrollment, error) {
+	return f.ExpectedEnrollment, f.ExpectedErr
+}
+
+func (f *FakeService) ConfirmEnrollment(ctx context.Context, userID int64, code string) error {
+	f.VerifiedCodes = append(f.VerifiedCodes, code)
+	if f.ExpectedErr == nil {
+		f.Confirmed = true
+	}
+	return f.ExpectedErr
+}
+
+func (f *FakeService) Verify(ctx context.Context, userID int64, code string) error {
+	f.VerifiedCodes = append(f.VerifiedCodes, code)
+	return f.ExpectedErr
+}
+
+func (f *FakeService) RegenerateRecoveryCodes(ctx context.Context, userID int64, code string) ([]string, error) {
+	return f.ExpectedCodes, f.ExpectedErr
+}
+
+func (f *FakeService) Disable(ctx context.Context, userID int64, code string) error {
+	return f.ExpectedErr
+}
+
+func (f *FakeService) Reset(ctx context.Context, userID int64) error {
+	return f.ExpectedErr
+}
+
+func (f *FakeService) GetOrgPolicy(ctx context.Context, orgID int64) (*twofactor.OrgPolicy, error) {
+	return f.ExpectedPolicy, f.ExpectedErr
+}
+
+func (f *FakeService) SetOrgPolicy(ctx context.Context, orgID int64, policy twofactor.OrgPolicy) error {
+	return f.ExpectedErr
+}
@@ -0,0 +1,32 @@
+package webauthn
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+)
+
+// URLEncodedBase64 is a byte slice encoded as unpadded base64url in JSON.
+type URLEncodedBase64 []byte
+
+func (b URLEncodedBase64) String() string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func (b URLEncodedBase64) MarshalJSON() ([]byte, error) {
+	return json.Marshal(b.String())
+}
+
+func (b *URLEncodedBase64) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	// Some clients pad the value, which is allowed by the spec.
+	decoded, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(s, "="))
+	if err != nil {
+		return err
+	}
+	*b = decoded
+	return nil
+}
@@ -0,0 +1,144 @@
+package webauthn
+
+import (
+	"context"
+	"time"
+
+	"github.com/grafana/grafana/pkg/apimachinery/errutil"
+)
+
+var (
+	ErrInvalidCredential  = errutil.Unauthorized("webauthn.invalid-credential", errutil.WithPublicMessage("Invalid passkey"))
+	ErrInvalidChallenge   = errutil.Unauthorized("webauthn.invalid-challenge", errutil.WithPublicMessage("Passkey request expired, please try again"))
+	ErrCredentialExists   = errutil.BadRequest("webauthn.credential-exists", errutil.WithPublicMessage("Passkey is already registered"))
+	ErrCredentialNotFound = errutil.NotFound("webauthn.credential-not-found", errutil.WithPublicMessage("Passkey not found"))
+	ErrUnsupportedKey     = errutil.BadRequest("webauthn.unsupported-key", errutil.WithPublicMessage("Passkey algorithm is not supported"))
+	ErrLastSecondFactor   = errutil.Forbidden("webauthn.last-second-factor", errutil.WithPublicMessage("Two-factor authentication is required, the last passkey cannot be deleted"))
+)
+
+// Service registers and verifies WebAuthn credentials (passkeys) of users.
+type Service interface {
+	// IsEnabled returns true if WebAuthn is enabled in the configuration.
+	IsEnabled() bool
+	// BeginRegistration returns the options to create a new credential for a user.
+	BeginRegistration(ctx context.Context, userID int64, login string) (*CredentialCreation, error)
+	// FinishRegistration verifies and stores a credential created with the options of BeginRegistration.
+	FinishRegistration(ctx context.Context, userID int64, name string, resp *RegistrationResponse) (*Credential, error)
+	// BeginLogin returns the options to get an assertion. If userID is 0 any
+	// discoverable credential can be used, and user verification is required.
+	BeginLogin(ctx context.Context, userID int64) (*CredentialAssertion, error)
+	// FinishLogin verifies an assertion for the options of BeginLogin and returns the user it belongs to.
+	FinishLogin(ctx context.Context, resp *AssertionResponse) (*LoginResult, error)
+	// HasCredentials returns true if a user has at least one credential.
+	HasCredentials(ctx context.Context, userID int64) (bool, error)
+	ListCredentials(ctx context.Context, userID int64) ([]*Credential, error)
+	RenameCredential(ctx context.Context, userID, id int64, name string) error
+	// DeleteCredential deletes a credential of a user. resp must be an
+	// assertion of one of the user's credentials for the options of
+	// BeginLogin, so that a stolen session can't remove a second factor.
+	DeleteCredential(ctx context.Context, userID, id int64, resp *AssertionResponse) error
+}
+
+// Credential is a registered credential, without its key material.
+type Credential struct {
+	ID           int64      `json:"id"`
+	Name         string     `json:"name"`
+	CredentialID string     `json:"credentialId"`
+	Transports   []string   `json:"transports"`
+	Created      time.Time  `json:"created"`
+	LastUsed     *time.Time `json:"lastUsed,omitempty"`
+}
+
+type LoginResult struct {
+	UserID       int64
+	CredentialID int64
+	// UserVerified is true if the authenticator verified the user with a PIN or biometrics.
+	UserVerified bool
+}
+
+// The types below follow the JSON serialization of the WebAuthn browser API,
+// with binary values encoded as base64url.
+// See https://www.w3.org/TR/webauthn-3/#sctn-parseCreationOptionsFromJSON.
+
+type CredentialCreation struct {
+	PublicKey CreationOptions `json:"publicKey"`
+}
+
+type CreationOptions struct {
+	RP                     RelyingParty           `json:"rp"`
+	User                   UserEntity             `json:"user"`
+	Challenge              URLEncodedBase64       `json:"challenge"`
+	PubKeyCredParams       []CredentialParameter  `json:"pubKeyCredParams"`
+	Timeout                int64                  `json:"timeout"`
+	ExcludeCredentials     []CredentialDescriptor `json:"excludeCredentials"`
+	AuthenticatorSelection AuthenticatorSelection `json:"authenticatorSelection"`
+	Attestation            string                 `json:"attestation"`
+}
+
+type CredentialAssertion struct {
+	PublicKey RequestOptions `json:"publicKey"`
+}
+
+type RequestOptions struct {
+	Challenge        URLEncodedBase64       `json:"challenge"`
+	Timeout          int64                  `json:"timeout"`
+	RPID             string                 `json:"rpId"`
+	AllowCredentials []CredentialDescriptor `json:"allowCredentials"`
+	UserVerification string                 `json:"userVerification"`
+}
+
+type RelyingParty struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type UserEntity struct {
+	ID          URLEncodedBase64 `json:"id"`
+	Name        string           `json:"name"`
+	DisplayName string           `json:"displayName"`
+}
+
+type CredentialParameter struct {
+	Type string `json:"type"`
+	Alg  int64  `json:"alg"`
+}
+
+type CredentialDescriptor struct {
+	Type       string           `json:"type"`
+	ID         URLEncodedBase64 `json:"id"`
+	Transports []string         `json:"transports,omitempty"`
+}
+
+type AuthenticatorSelection struct {
+	ResidentKey      string `json:"residentKey"`
+	UserVerification string `json:"userVerification"`
+}
+
+// RegistrationResponse is the result of navigator.credentials.create().
+type RegistrationResponse struct {
+	ID       string              `json:"id"`
+	RawID    URLEncodedBase64    `json:"rawId"`
+	Type     string              `json:"type"`
+	Response AttestationResponse `json:"response"`
+}
+
+type AttestationResponse struct {
+	ClientDataJSON    URLEncodedBase64 `json:"clientDataJSON"`
+	AttestationObject URLEncodedBase64 `json:"attestationObject"`
+	Transports        []string         `json:"transports"`
+}
+
+// AssertionResponse is the result of navigator.credentials.get().
+type AssertionResponse struct {
+	ID       string                         `json:"id"`
+	RawID    URLEncodedBase64               `json:"rawId"`
+	Type     string                         `json:"type"`
+	Response AuthenticatorAssertionResponse `json:"response"`
+}
+
+type AuthenticatorAssertionResponse struct {
+	ClientDataJSON    URLEncodedBase64 `json:"clientDataJSON"`
+	AuthenticatorData URLEncodedBase64 `json:"authenticatorData"`
+	Signature         URLEncodedBase64 `json:"signature"`
+	UserHandle        URLEncodedBase64 `json:"userHandle"`
+}
@@ -0,0 +1,151 @@
+package webauthnimpl
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/grafana/authlib/types"
+
+	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/middleware"
+	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
+	"github.com/grafana/grafana/pkg/services/webauthn"
+	"github.com/grafana/grafana/pkg/web"
+)
+
+type finishRegistrationForm struct {
+	Name       string                        `json:"name"`
+	Credential webauthn.RegistrationResponse `json:"credential"`
+}
+
+type deleteCredentialForm struct {
+	// Credential is an assertion for the options of /api/user/webauthn/verify/begin.
+	Credential webauthn.AssertionResponse `json:"credential"`
+}
+
+type renameCredentialForm struct {
+	Name string `json:"name" binding:"Required"`
+}
+
+// maxNameLength is the length of the name column.
+const maxNameLength = 190
+
+func (s *Service) registerAPIEndpoints(routeRegister routing.RouteRegister) {
+	routeRegister.Group("/api/user/webauthn", func(userRoute routing.RouteRegister) {
+		userRoute.Get("/credentials", routing.Wrap(s.listCredentialsHandler))
+		userRoute.Post("/register/begin", routing.Wrap(s.beginRegistrationHandler))
+		userRoute.Post("/register/finish", routing.Wrap(s.finishRegistrationHandler))
+		userRoute.Post("/verify/begin", routing.Wrap(s.beginVerificationHandler))
+		userRoute.Patch("/credentials/:id", routing.Wrap(s.renameCredentialHandler))
+		userRoute.Delete("/credentials/:id", routing.Wrap(s.deleteCredentialHandler))
+	}, middleware.ReqSignedInNoAnonymous)
+}
+
+// signedInUserID returns the id of the signed in user. Passkeys are only
+// available to users, not service accounts.
+func signedInUserID(c *contextmodel.ReqContext) (int64, error) {
+	if !c.SignedInUser.IsIdentityType(types.TypeUser) {
+		return 0, errors.New("passkeys are only available to users")
+	}
+	return c.SignedInUser.GetInternalID()
+}
+
+func (s *Service) listCredentialsHandler(c *contextmodel.ReqContext) response.Response {
+	userID, err := signedInUserID(c)
+	if err != nil {
+		return response.Error(http.StatusForbidden, "Passkeys are only available to users", err)
+	}
+	creds, err := s.ListCredentials(c.Req.Context(), userID)
+	if err != nil {
+		return response.Error(http.StatusInternalServerError, "Failed to list passkeys", err)
+	}
+	return response.JSON(http.StatusOK, creds)
+}
+
+func (s *Service) beginRegistrationHandler(c *contextmodel.ReqContext) response.Response {
+	userID, err := signedInUserID(c)
+	if err != nil {
+		return response.Error(http.StatusForbidden, "Passkeys are only available to users", err)
+	}
+	options, err := s.BeginRegistration(c.Req.Context(), userID, c.SignedInUser.GetLogin())
+	if err != nil {
+		return response.ErrOrFallback(http.StatusInternalServerError, "Failed to start passkey registration", err)
+	}
+	return response.JSON(http.StatusOK, options)
+}
+
+func (s *Service) finishRegistrationHandler(c *contextmodel.ReqContext) response.Response {
+	userID, err := signedInUserID(c)
+	if err != nil {
+		return response.Error(http.StatusForbidden, "Passkeys are only available to users", err)
+	}
+	form := finishRegistrationForm{}
+	if err := web.Bind(c.Req, &form); err != nil {
+		return response.Error(http.StatusBadRequest, "bad request data", err)
+	}
+	if len(form.Name) > maxNameLength {
+		return response.Error(http.StatusBadRequest, "Name is too long", nil)
+	}
+	cred, err := s.FinishRegistration(c.Req.Context(), userID, form.Name, &form.Credential)
+	if err != nil {
+		return response.ErrOrFallback(http.StatusInternalServerError, "Failed to register passkey", err)
+	}
+	return response.JSON(http.StatusOK, cred)
+}
+
+// beginVerificationHandler returns the options to prove possession of a
+// passkey of the signed in user, which is required to delete a passkey.
+func (s *Service) beginVerificationHandler(c *contextmodel.ReqContext) response.Response {
+	userID, err := signedInUserID(c)
+	if err != nil {
+		return response.Error(http.StatusForbidden, "Passkeys are only available to users", err)
+	}
+	options, err := s.BeginLogin(c.Req.Context(), userID)
+	if err != nil {
+		return response.ErrOrFallback(http.StatusInternalServerError, "Failed to start passkey verification", err)
+	}
+	return response.JSON(http.StatusOK, options)
+}
+
+func (s *Service) renameCredentialHandler(c *contextmodel.ReqContext) response.Response {
+	userID, err := signedInUserID(c)
+	if err != nil {
+		return response.Error(http.StatusForbidden, "Passkeys are only available to users", err)
+	}
+	id, err := strconv.ParseInt(web.Params(c.Req)[":id"], 10, 64)
+	if err != nil {
+		return response.Error(http.StatusBadRequest, "id is invalid", err)
+	}
+	form := renameCredentialForm{}
+	if err := web.Bind(c.Req, &form); err != nil {
+		return response.Error(http.StatusBadRequest, "bad request data", err)
+	}
+	if len(form.Name) > maxNameLength {
+		return response.Error(http.StatusBadRequest, "Name is too long", nil)
+	}
+	if err := s.RenameCredential(c.Req.Context(), userID, id, form.Name); err != nil {
+		return response.ErrOrFallback(http.StatusInternalServerError, "Failed to rename passkey", err)
+	}
+	return response.Success("Passkey renamed")
+}
+
+func (s *Service) deleteCredentialHandler(c *contextmodel.ReqContext) response.Response {
+	userID, err := signedInUserID(c)
+	if err != nil {
+		return response.Error(http.StatusForbidden, "Passkeys are only available to users", err)
+	}
+	id, err := strconv.ParseInt(web.Params(c.Req)[":id"], 10, 64)
+	if err != nil {
+		return response.Error(http.StatusBadRequest, "id is invalid", err)
+	}
+	form := deleteCredentialForm{}
+	if err := web.Bind(c.Req, &form); err != nil {
+		return response.Error(http.StatusBadRequest, "bad request data", err)
+	}
+	if err := s.DeleteCredential(c.Req.Context(), userID, id, &form.Credential); err != nil {
+		return response.ErrOrFallback(http.StatusInternalServerError, "Failed to delete passkey", err)
+	}
+	return response.Success("Passkey deleted")
+}
@@ -0,0 +1,92 @@
+package webauthnimpl
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"slices"
+
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/go-webauthn/webauthn/protocol/webauthncose"
+
+	"github.com/grafana/grafana/pkg/services/webauthn"
+)
+
+// The ceremonies are verified with github.com/go-webauthn/webauthn/protocol.
+// The types of the webauthn package follow the same JSON serialization as the
+// library, so responses are converted through their JSON encoding.
+
+// supportedAlgorithms are offered to authenticators in order of preference.
+var supportedAlgorithms = []webauthncose.COSEAlgorithmIdentifier{webauthncose.AlgES256, webauthncose.AlgEdDSA, webauthncose.AlgRS256}
+
+// minRSAKeyBits is the smallest RSA modulus accepted for a credential.
+const minRSAKeyBits = 2048
+
+func credentialParameters() []protocol.CredentialParameter {
+	params := make([]protocol.CredentialParameter, 0, len(supportedAlgorithms))
+	for _, alg := range supportedAlgorithms {
+		params = append(params, protocol.CredentialParameter{Type: protocol.PublicKeyCredentialType, Algorithm: alg})
+	}
+	return params
+}
+
+func parseRegistration(resp *webauthn.RegistrationResponse) (*protocol.ParsedCredentialCreationData, error) {
+	data, err := json.Marshal(resp)
+	if err != nil {
+		return nil, err
+	}
+	parsed, err := protocol.ParseCredentialCreationResponseBytes(data)
+	if err != nil {
+		return nil, webauthn.ErrInvalidCredential.Errorf("%w", protocolError(err))
+	}
+	return parsed, nil
+}
+
+func parseAssertion(resp *webauthn.AssertionResponse) (*protocol.ParsedCredentialAssertionData, error) {
+	data, err := json.Marshal(resp)
+	if err != nil {
+		return nil, err
+	}
+	parsed, err := protocol.ParseCredentialRequestResponseBytes(data)
+	if err != nil {
+		return nil, webauthn.ErrInvalidCredential.Errorf("%w", protocolError(err))
+	}
+	return parsed, nil
+}
+
+// checkPublicKey returns an error if a COSE encoded public key uses an
+// algorithm that is not offered, or is an RSA key that is too small.
+func checkPublicKey(raw []byte) error {
+	key, err := webauthncose.ParsePublicKey(raw)
+	if err != nil {
+		return fmt.Errorf("failed to parse public key: %w", err)
+	}
+
+	var alg int64
+	switch k := key.(type) {
+	case webauthncose.EC2PublicKeyData:
+		alg = k.Algorithm
+	case webauthncose.OKPPublicKeyData:
+		alg = k.Algorithm
+	case webauthncose.RSAPublicKeyData:
+		alg = k.Algorithm
+		if new(big.Int).SetBytes(k.Modulus).BitLen() < minRSAKeyBits {
+			return errors.New("RSA key too small")
+		}
+	}
+	if !slices.Contains(supportedAlgorithms, webauthncose.COSEAlgorithmIdentifier(alg)) {
+		return fmt.Errorf("unsupported algorithm %d", alg)
+	}
+	return nil
+}
+
+// protocolError includes the details of library errors, whose message alone
+// only names the kind of error.
+func protocolError(err error) error {
+	var perr *protocol.Error
+	if errors.As(err, &perr) && perr.DevInfo != "" {
+		return fmt.Errorf("%s: %s", perr.Details, perr.DevInfo)
+	}
+	return err
+}
@@ -0,0 +1,155 @@
+package webauthnimpl
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+
+	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/services/webauthn"
+)
+
+type credential struct {
+	ID     int64  `xorm:"pk autoincr 'id'"`
+	UserID int64  `xorm:"user_id"`
+	Name   string `xorm:"name"`
+	// CredentialID is the base64url encoded credential id.
+	CredentialID     string `xorm:"credential_id"`
+	CredentialIDHash string `xorm:"credential_id_hash"`
+	// UserHandle is the base64url encoded user handle shared by the credentials of a user.
+	UserHandle string `xorm:"user_handle"`
+	// PublicKey is the base64url encoded COSE public key.
+	PublicKey string `xorm:"public_key"`
+	SignCount int64  `xorm:"sign_count"`
+	AAGUID    string `xorm:"aaguid"`
+	// Transports is a comma separated list of transport hints.
+	Transports string     `xorm:"transports"`
+	Created    time.Time  `xorm:"created"`
+	Updated    time.Time  `xorm:"updated"`
+	LastUsed   *time.Time `xorm:"last_used"`
+}
+
+func (credential) TableName() string {
+	return "webauthn_credential"
+}
+
+func hashCredentialID(credentialID string) string {
+	sum := sha256.Sum256([]byte(credentialID))
+	return hex.EncodeToString(sum[:])
+}
+
+type store interface {
+	Get(ctx context.Context, userID, id int64) (*credential, error)
+	GetByCredentialID(ctx context.Context, credentialID string) (*credential, error)
+	List(ctx context.Context, userID int64) ([]*credential, error)
+	Insert(ctx context.Context, cred *credential) error
+	Rename(ctx context.Context, userID, id int64, name string) error
+	// UpdateUsage stores the sign count and last use of a credential if the
+	// sign count was not changed by a concurrent login.
+	UpdateUsage(ctx context.Context, cred *credential, prevSignCount int64) (bool, error)
+	Delete(ctx context.Context, userID, id int64) error
+}
+
+type xormStore struct {
+	db  db.DB
+	now func() time.Time
+}
+
+func (s *xormStore) Get(ctx context.Context, userID, id int64) (*credential, error) {
+	cred := &credential{}
+	err := s.db.WithDbSession(ctx, func(sess *db.Session) error {
+		has, err := sess.Where("id = ? AND user_id = ?", id, userID).Get(cred)
+		if err != nil {
+			return err
+		}
+		if !has {
+			return webauthn.ErrCredentialNotFound.Errorf("credential %d not found for user %d", id, userID)
+		}
+		return nil
+	})
+	return cred, err
+}
+
+func (s *xormStore) GetByCredentialID(ctx context.Context, credentialID string) (*credential, error) {
+	var cred *credential
+	err := s.db.WithDbSession(ctx, func(sess *db.Session) error {
+		row := credential{}
+		has, err := sess.Where("credential_id_hash = ?", hashCredentialID(credentialID)).Get(&row)
+		if err != nil || !has || row.CredentialID != credentialID {
+			return err
+		}
+		cred = &row
+		return nil
+	})
+	return cred, err
+}
+
+func (s *xormStore) List(ctx context.Context, userID int64) ([]*credential, error) {
+	creds := make([]*credential, 0)
+	err := s.db.WithDbSession(ctx, func(sess *db.Session) error {
+		return sess.Where("user_id = ?", userID).Asc("id").Find(&creds)
+	})
+	return creds, err
+}
+
+func (s *xormStore) Insert(ctx context.Context, cred *credential) error {
+	return s.db.WithTransactionalDbSession(ctx, func(sess *db.Session) error {
+		cred.CredentialIDHash = hashCredentialID(cred.CredentialID)
+		exists, err := sess.Table("webauthn_credential").Where("credential_id_hash = ?", cred.CredentialIDHash).Exist()
+		if err != nil {
+			return err
+		}
+		if exists {
+			return webauthn.ErrCredentialExists.Errorf("credential already registered")
+		}
+
+		now := s.now()
+		cred.Created = now
+		cred.Updated = now
+		_, err = sess.Insert(cred)
+		return err
+	})
+}
+
+func (s *xormStore) Rename(ctx context.Context, userID, id int64, name string) error {
+	return s.db.WithTransactionalDbSession(ctx, func(sess *db.Session) error {
+		exists, err := sess.Table("webauthn_credential").Where("id = ? AND user_id = ?", id, userID).Exist()
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return webauthn.ErrCredentialNotFound.Errorf("credential %d not found for user %d", id, userID)
+		}
+		_, err = sess.Where("id = ? AND user_id = ?", id, userID).Cols("name", "updated").Update(&credential{Name: name, Updated: s.now()})
+		return err
+	})
+}
+
+func (s *xormStore) UpdateUsage(ctx context.Context, cred *credential, prevSignCount int64) (bool, error) {
+	var updated bool
+	err := s.db.WithDbSession(ctx, func(sess *db.Session) error {
+		now := s.now()
+		cred.LastUsed = &now
+		cred.Updated = now
+		affected, err := sess.Where("id = ? AND sign_count = ?", cred.ID, prevSignCount).
+			Cols("sign_count", "last_used", "updated").
+			Update(cred)
+		updated = affected > 0
+		return err
+	})
+	return updated, err
+}
+
+func (s *xormStore) Delete(ctx context.Context, userID, id int64) error {
+	return s.db.WithDbSession(ctx, func(sess *db.Session) error {
+		affected, err := sess.Where("id = ? AND user_id = ?", id, userID).Delete(&credential{})
+		if err != nil {
+			return err
+		}
+		if affected == 0 {
+			return webauthn.ErrCredentialNotFound.Errorf("credential %d not found for user %d", id, userID)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,400 @@
+package webauthnimpl
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-webauthn/webauthn/protocol"
+
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/infra/remotecache"
+	"github.com/grafana/grafana/pkg/services/twofactor"
+	"github.com/grafana/grafana/pkg/services/webauthn"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+const (
+	challengeKeyPrefix = "webauthn-challenge-%s"
+
+	challengeTypeRegistration = "registration"
+	challengeTypeLogin        = "login"
+)
+
+var _ webauthn.Service = new(Service)
+
+func ProvideService(cfg *setting.Cfg, db db.DB, cache remotecache.CacheStorage, routeRegister routing.RouteRegister, twoFactor twofactor.Service) *Service {
+	s := &Service{
+		cfg:       cfg.WebAuthn,
+		store:     &xormStore{db: db, now: time.Now},
+		cache:     cache,
+		twoFactor: twoFactor,
+		log:       log.New("webauthn"),
+	}
+
+	if s.cfg.Enabled {
+		s.registerAPIEndpoints(routeRegister)
+	}
+
+	return s
+}
+
+type Service struct {
+	cfg       setting.AuthWebAuthnSettings
+	store     store
+	cache     remotecache.CacheStorage
+	twoFactor twofactor.Service
+	log       log.Logger
+}
+
+// challengeEntry is stored for each challenge until it is used or expires.
+type challengeEntry struct {
+	Type string `json:"type"`
+	// UserID is the user the challenge was created for, 0 for logins with
+	// discoverable credentials.
+	UserID     int64  `json:"user_id"`
+	UserHandle string `json:"user_handle,omitempty"`
+}
+
+func (s *Service) IsEnabled() bool {
+	return s.cfg.Enabled
+}
+
+func (s *Service) BeginRegistration(ctx context.Context, userID int64, login string) (*webauthn.CredentialCreation, error) {
+	creds, err := s.store.List(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	// All credentials of a user share a user handle, so that authenticators
+	// replace an existing passkey for the account instead of adding one.
+	var userHandle []byte
+	exclude := make([]webauthn.CredentialDescriptor, 0, len(creds))
+	for _, cred := range creds {
+		desc, err := descriptor(cred)
+		if err != nil {
+			return nil, err
+		}
+		exclude = append(exclude, desc)
+		if userHandle == nil {
+			if userHandle, err = base64.RawURLEncoding.DecodeString(cred.UserHandle); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if userHandle == nil {
+		if userHandle, err = randomBytes(32); err != nil {
+			return nil, err
+		}
+	}
+
+	challenge, err := s.newChallenge(ctx, challengeEntry{
+		Type:       challengeTypeRegistration,
+		UserID:     userID,
+		UserHandle: base64.RawURLEncoding.EncodeToString(userHandle),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	params := make([]webauthn.CredentialParameter, 0, len(supportedAlgorithms))
+	for _, alg := range supportedAlgorithms {
+		params = append(params, webauthn.CredentialParameter{Type: "public-key", Alg: int64(alg)})
+	}
+
+	return &webauthn.CredentialCreation{PublicKey: webauthn.CreationOptions{
+		RP:                 webauthn.RelyingParty{ID: s.cfg.RPID, Name: s.cfg.RPDisplayName},
+		User:               webauthn.UserEntity{ID: userHandle, Name: login, DisplayName: login},
+		Challenge:          challenge,
+		PubKeyCredParams:   params,
+		Timeout:            s.cfg.ChallengeTimeout.Milliseconds(),
+		ExcludeCredentials: exclude,
+		AuthenticatorSelection: webauthn.AuthenticatorSelection{
+			ResidentKey:      "preferred",
+			UserVerification: s.cfg.UserVerification,
+		},
+		Attestation: "none",
+	}}, nil
+}
+
+func (s *Service) FinishRegistration(ctx context.Context, userID int64, name string, resp *webauthn.RegistrationResponse) (*webauthn.Credential, error) {
+	parsed, err := parseRegistration(resp)
+	if err != nil {
+		return nil, err
+	}
+	challenge := parsed.Response.CollectedClientData.Challenge
+	entry, err := s.consumeChallenge(ctx, challenge, challengeTypeRegistration)
+	if err != nil {
+		return nil, err
+	}
+	if entry.UserID != userID {
+		return nil, webauthn.ErrInvalidChallenge.Errorf("challenge was created for another user")
+	}
+
+	authData := parsed.Response.AttestationObject.AuthData
+	if err := checkPublicKey(authData.AttData.CredentialPublicKey); err != nil {
+		return nil, webauthn.ErrUnsupportedKey.Errorf("%w", err)
+	}
+	// Attestation is not requested, so no metadata is given and the
+	// authenticator model is not trusted.
+	_, err = parsed.Verify(challenge, s.cfg.UserVerification == "required", true, s.cfg.RPID, s.cfg.Origins, nil, protocol.TopOriginImplicitVerificationMode, nil, credentialParameters())
+	if err != nil {
+		return nil, webauthn.ErrInvalidCredential.Errorf("%w", protocolError(err))
+	}
+
+	if name == "" {
+		name = "Passkey"
+	}
+	cred := &credential{
+		UserID:       userID,
+		Name:         name,
+		CredentialID: base64.RawURLEncoding.EncodeToString(authData.AttData.CredentialID),
+		UserHandle:   entry.UserHandle,
+		PublicKey:    base64.RawURLEncoding.EncodeToString(authData.AttData.CredentialPublicKey),
+		SignCount:    int64(authData.Counter),
+		AAGUID:       hex.EncodeToString(authData.AttData.AAGUID),
+		Transports:   strings.Join(resp.Response.Transports, ","),
+	}
+	if err := s.store.Insert(ctx, cred); err != nil {
+		return nil, err
+	}
+
+	s.log.FromContext(ctx).Info("Registered WebAuthn credential", "userId", userID, "credentialId", cred.ID)
+	return toCredential(cred), nil
+}
+
+func (s *Service) BeginLogin(ctx context.Context, userID int64) (*webauthn.CredentialAssertion, error) {
+	allow := []webauthn.CredentialDescriptor{}
+	userVerification := "required"
+	if userID != 0 {
+		creds, err := s.store.List(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		if len(creds) == 0 {
+			return nil, webauthn.ErrCredentialNotFound.Errorf("user %d has no credentials", userID)
+		}
+		for _, cred := range creds {
+			desc, err := descriptor(cred)
+			if err != nil {
+				return nil, err
+			}
+			allow = append(allow, desc)
+		}
+		userVerification = s.cfg.UserVerification
+	}
+
+	challenge, err := s.newChallenge(ctx, challengeEntry{Type: challengeTypeLogin, UserID: userID})
+	if err != nil {
+		return nil, err
+	}
+
+	return &webauthn.CredentialAssertion{PublicKey: webauthn.RequestOptions{
+		Challenge:        challenge,
+		Timeout:          s.cfg.ChallengeTimeout.Milliseconds(),
+		RPID:             s.cfg.RPID,
+		AllowCredentials: allow,
+		UserVerification: userVerification,
+	}}, nil
+}
+
+func (s *Service) FinishLogin(ctx context.Context, resp *webauthn.AssertionResponse) (*webauthn.LoginResult, error) {
+	parsed, err := parseAssertion(resp)
+	if err != nil {
+		return nil, err
+	}
+	challenge := parsed.Response.CollectedClientData.Challenge
+	entry, err := s.consumeChallenge(ctx, challenge, challengeTypeLogin)
+	if err != nil {
+		return nil, err
+	}
+
+	cred, err := s.store.GetByCredentialID(ctx, base64.RawURLEncoding.EncodeToString(resp.RawID))
+	if err != nil {
+		return nil, err
+	}
+	if cred == nil {
+		return nil, webauthn.ErrInvalidCredential.Errorf("unknown credential")
+	}
+	if entry.UserID != 0 && cred.UserID != entry.UserID {
+		return nil, webauthn.ErrInvalidCredential.Errorf("credential does not belong to user %d", entry.UserID)
+	}
+	// The user handle identifies the account for discoverable credentials, so
+	// it is required when no user was given when the login started.
+	if len(resp.Response.UserHandle) > 0 || entry.UserID == 0 {
+		if resp.Response.UserHandle.String() != cred.UserHandle {
+			return nil, webauthn.ErrInvalidCredential.Errorf("user handle does not match credential")
+		}
+	}
+
+	publicKey, err := base64.RawURLEncoding.DecodeString(cred.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+	// Without a password the passkey is the only factor, so the authenticator
+	// has to verify the user.
+	verifyUser := entry.UserID == 0 || s.cfg.UserVerification == "required"
+	err = parsed.Verify(challenge, s.cfg.RPID, s.cfg.Origins, nil, protocol.TopOriginImplicitVerificationMode, "", verifyUser, true, publicKey)
+	if err != nil {
+		return nil, webauthn.ErrInvalidCredential.Errorf("%w", protocolError(err))
+	}
+	authData := parsed.Response.AuthenticatorData
+
+	// Authenticators that count signatures must always increase the counter,
+	// otherwise the credential may have been cloned.
+	prevSignCount := cred.SignCount
+	if (authData.Counter != 0 || prevSignCount != 0) && int64(authData.Counter) <= prevSignCount {
+		s.log.FromContext(ctx).Warn("WebAuthn sign count did not increase, the credential may be cloned", "userId", cred.UserID, "credentialId", cred.ID)
+		return nil, webauthn.ErrInvalidCredential.Errorf("sign count did not increase")
+	}
+	cred.SignCount = int64(authData.Counter)
+	ok, err := s.store.UpdateUsage(ctx, cred, prevSignCount)
+	if err != nil {
+		return nil, err
+	}
+	if !ok && authData.Counter != 0 {
+		return nil, webauthn.ErrInvalidCredential.Errorf("sign count was used by a concurrent login")
+	}
+
+	return &webauthn.LoginResult{UserID: cred.UserID, CredentialID: cred.ID, UserVerified: authData.Flags.UserVerified()}, nil
+}
+
+func (s *Service) HasCredentials(ctx context.Context, userID int64) (bool, error) {
+	creds, err := s.store.List(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+	return len(creds) > 0, nil
+}
+
+func (s *Service) ListCredentials(ctx context.Context, userID int64) ([]*webauthn.Credential, error) {
+	creds, err := s.store.List(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]*webauthn.Credential, 0, len(creds))
+	for _, cred := range creds {
+		result = append(result, toCredential(cred))
+	}
+	return result, nil
+}
+
+func (s *Service) RenameCredential(ctx context.Context, userID, id int64, name string) error {
+	return s.store.Rename(ctx, userID, id, name)
+}
+
+func (s *Service) DeleteCredential(ctx context.Context, userID, id int64, resp *webauthn.AssertionResponse) error {
+	result, err := s.FinishLogin(ctx, resp)
+	if err != nil {
+		return err
+	}
+	if result.UserID != userID {
+		return webauthn.ErrInvalidCredential.Errorf("credential does not belong to user %d", userID)
+	}
+
+	creds, err := s.store.List(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if len(creds) == 1 && creds[0].ID == id && s.twoFactor.IsEnabled() {
+		// Passkeys count as a second factor, so the last one can only be
+		// deleted if the user has another one or doesn't need one.
+		status, err := s.twoFactor.GetStatus(ctx, userID)
+		if err != nil {
+			return err
+		}
+		if status.Required && !status.Enabled {
+			return webauthn.ErrLastSecondFactor.Errorf("user %d requires two-factor authentication", userID)
+		}
+	}
+
+	if err := s.store.Delete(ctx, userID, id); err != nil {
+		return err
+	}
+	s.log.FromContext(ctx).Info("Deleted WebAuthn credential", "userId", userID, "credentialId", id)
+	return nil
+}
+
+func (s *Service) newChallenge(ctx context.Context, entry challengeEntry) ([]byte, error) {
+	challenge, err := randomBytes(32)
+	if err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return nil, err
+	}
+	key := fmt.Sprintf(challengeKeyPrefix, base64.RawURLEncoding.EncodeToString(challenge))
+	if err := s.cache.Set(ctx, key, data, s.cfg.ChallengeTimeout); err != nil {
+		return nil, err
+	}
+	return challenge, nil
+}
+
+// consumeChallenge returns the entry of a challenge and deletes it, so that
+// each challenge can only be used once. Finding the challenge is what
+// verifies it, the client data is verified by the library.
+func (s *Service) consumeChallenge(ctx context.Context, challenge, challengeType string) (*challengeEntry, error) {
+	key := fmt.Sprintf(challengeKeyPrefix, strings.TrimRight(challenge, "="))
+	data, err := s.cache.Get(ctx, key)
+	if err != nil {
+		if errors.Is(err, remotecache.ErrCacheItemNotFound) {
+			return nil, webauthn.ErrInvalidChallenge.Errorf("unknown or expired challenge")
+		}
+		return nil, err
+	}
+	if err := s.cache.Delete(ctx, key); err != nil {
+		return nil, err
+	}
+
+	entry := &challengeEntry{}
+	if err := json.Unmarshal(data, entry); err != nil {
+		return nil, err
+	}
+	if entry.Type != challengeType {
+		return nil, webauthn.ErrInvalidChallenge.Errorf("challenge was created for a %s", entry.Type)
+	}
+	return entry, nil
+}
+
+func descriptor(cred *credential) (webauthn.CredentialDescriptor, error) {
+	id, err := base64.RawURLEncoding.DecodeString(cred.CredentialID)
+	if err != nil {
+		return webauthn.CredentialDescriptor{}, err
+	}
+	return webauthn.CredentialDescriptor{Type: "public-key", ID: id, Transports: splitTransports(cred.Transports)}, nil
+}
+
+func toCredential(cred *credential) *webauthn.Credential {
+	return &webauthn.Credential{
+		ID:           cred.ID,
+		Name:         cred.Name,
+		CredentialID: cred.CredentialID,
+		Transports:   splitTransports(cred.Transports),
+		Created:      cred.Created,
+		LastUsed:     cred.LastUsed,
+	}
+}
+
+func splitTransports(transports string) []string {
+	if transports == "" {
+		return []string{}
+	}
+	return strings.Split(transports, ",")
+}
+
+func randomBytes(n int) ([]byte, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
@@ -0,0 +1,244 @@
+package webauthnimpl
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/infra/remotecache"
+	"github.com/grafana/grafana/pkg/services/twofactor"
+	"github.com/grafana/grafana/pkg/services/twofactor/twofactortest"
+	"github.com/grafana/grafana/pkg/services/webauthn"
+	"github.com/grafana/grafana/pkg/services/webauthn/webauthntest"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/tests/testsuite"
+	"github.com/grafana/grafana/pkg/util/testutil"
+)
+
+func TestMain(m *testing.M) {
+	testsuite.Run(m)
+}
+
+const testOrigin = "https://grafana.example.com"
+
+func setupTestService(t *testing.T) *Service {
+	t.Helper()
+	return setupTestServiceWithTwoFactor(t, &twofactortest.FakeService{})
+}
+
+func setupTestServiceWithTwoFactor(t *testing.T, twoFactor twofactor.Service) *Service {
+	t.Helper()
+	cfg := setting.NewCfg()
+	cfg.WebAuthn = setting.AuthWebAuthnSettings{
+		RPID:             "grafana.example.com",
+		RPDisplayName:    "Grafana",
+		Origins:          []string{testOrigin},
+		ChallengeTimeout: time.Minute,
+		UserVerification: "preferred",
+	}
+	return ProvideService(cfg, db.InitTestDB(t), remotecache.NewFakeCacheStorage(), routing.NewRouteRegister(), twoFactor)
+}
+
+func register(t *testing.T, s *Service, authenticator *webauthntest.Authenticator, userID int64) *webauthn.Credential {
+	t.Helper()
+	options, err := s.BeginRegistration(context.Background(), userID, "user")
+	require.NoError(t, err)
+	resp, err := authenticator.Create(options)
+	require.NoError(t, err)
+	cred, err := s.FinishRegistration(context.Background(), userID, "laptop", resp)
+	require.NoError(t, err)
+	return cred
+}
+
+func verify(t *testing.T, s *Service, authenticator *webauthntest.Authenticator, userID int64) *webauthn.AssertionResponse {
+	t.Helper()
+	options, err := s.BeginLogin(context.Background(), userID)
+	require.NoError(t, err)
+	resp, err := authenticator.Get(options)
+	require.NoError(t, err)
+	return resp
+}
+
+func TestIntegrationWebAuthnService(t *testing.T) {
+	testutil.SkipIntegrationTestInShortMode(t)
+	ctx := context.Background()
+
+	t.Run("should register and log in with discoverable credential", func(t *testing.T) {
+		s := setupTestService(t)
+		authenticator := webauthntest.NewAuthenticator(testOrigin)
+		cred := register(t, s, authenticator, 1)
+		assert.Equal(t, "laptop", cred.Name)
+		assert.Equal(t, []string{"internal"}, cred.Transports)
+
+		options, err := s.BeginLogin(ctx, 0)
+		require.NoError(t, err)
+		assert.Empty(t, options.PublicKey.AllowCredentials)
+		assert.Equal(t, "required", options.PublicKey.UserVerification)
+
+		resp, err := authenticator.Get(options)
+		require.NoError(t, err)
+		result, err := s.FinishLogin(ctx, resp)
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), result.UserID)
+		assert.Equal(t, cred.ID, result.CredentialID)
+
+		_, err = s.FinishLogin(ctx, resp)
+		require.ErrorIs(t, err, webauthn.ErrInvalidChallenge, "challenge should only be usable once")
+
+		creds, err := s.ListCredentials(ctx, 1)
+		require.NoError(t, err)
+		require.Len(t, creds, 1)
+		assert.NotNil(t, creds[0].LastUsed)
+	})
+
+	t.Run("should share user handle and exclude existing credentials", func(t *testing.T) {
+		s := setupTestService(t)
+		first := webauthntest.NewAuthenticator(testOrigin)
+		register(t, s, first, 1)
+
+		options, err := s.BeginRegistration(ctx, 1, "user")
+		require.NoError(t, err)
+		assert.Equal(t, []byte(first.UserHandle), []byte(options.PublicKey.User.ID))
+		require.Len(t, options.PublicKey.ExcludeCredentials, 1)
+		assert.Equal(t, []byte(first.CredentialID), []byte(options.PublicKey.ExcludeCredentials[0].ID))
+	})
+
+	t.Run("should require user verification without a password", func(t *testing.T) {
+		s := setupTestService(t)
+		authenticator := webauthntest.NewAuthenticator(testOrigin)
+		register(t, s, authenticator, 1)
+		authenticator.UserVerified = false
+
+		options, err := s.BeginLogin(ctx, 0)
+		require.NoError(t, err)
+		resp, err := authenticator.Get(options)
+		require.NoError(t, err)
+		_, err = s.FinishLogin(ctx, resp)
+		require.ErrorIs(t, err, webauthn.ErrInvalidCredential)
+
+		// As a second factor the configured user verification applies.
+		options, err = s.BeginLogin(ctx, 1)
+		require.NoError(t, err)
+		require.Len(t, options.PublicKey.AllowCredentials, 1)
+		resp, err = authenticator.Get(options)
+		require.NoError(t, err)
+		result, err := s.FinishLogin(ctx, resp)
+		require.NoError(t, err)
+		assert.False(t, result.UserVerified)
+	})
+
+	t.Run("should reject credential of another user", func(t *testing.T) {
+		s := setupTestService(t)
+		authenticator := webauthntest.NewAuthenticator(testOrigin)
+		register(t, s, authenticator, 1)
+		register(t, s, webauthntest.NewAuthenticator(testOrigin), 2)
+
+		options, err := s.BeginLogin(ctx, 2)
+		require.NoError(t, err)
+		resp, err := authenticator.Get(options)
+		require.NoError(t, err)
+		_, err = s.FinishLogin(ctx, resp)
+		require.ErrorIs(t, err, webauthn.ErrInvalidCredential)
+	})
+
+	t.Run("should reject wrong origin and tampered signature", func(t *testing.T) {
+		s := setupTestService(t)
+		authenticator := webauthntest.NewAuthenticator(testOrigin)
+		register(t, s, authenticator, 1)
+
+		authenticator.Origin = "https://evil.example.com"
+		options, err := s.BeginLogin(ctx, 0)
+		require.NoError(t, err)
+		resp, err := authenticator.Get(options)
+		require.NoError(t, err)
+		_, err = s.FinishLogin(ctx, resp)
+		require.ErrorIs(t, err, webauthn.ErrInvalidCredential)
+
+		authenticator.Origin = testOrigin
+		options, err = s.BeginLogin(ctx, 0)
+		require.NoError(t, err)
+		resp, err = authenticator.Get(options)
+		require.NoError(t, err)
+		resp.Response.Signature[len(resp.Response.Signature)-1] ^= 0xff
+		_, err = s.FinishLogin(ctx, resp)
+		require.ErrorIs(t, err, webauthn.ErrInvalidCredential)
+	})
+
+	t.Run("should reject sign count that does not increase", func(t *testing.T) {
+		s := setupTestService(t)
+		authenticator := webauthntest.NewAuthenticator(testOrigin)
+		authenticator.CountSignatures = true
+		register(t, s, authenticator, 1)
+
+		login := func() error {
+			options, err := s.BeginLogin(ctx, 0)
+			require.NoError(t, err)
+			resp, err := authenticator.Get(options)
+			require.NoError(t, err)
+			_, err = s.FinishLogin(ctx, resp)
+			return err
+		}
+
+		require.NoError(t, login())
+		require.NoError(t, login())
+
+		// A cloned authenticator reuses an old counter.
+		authenticator.SignCount = 0
+		require.ErrorIs(t, login(), webauthn.ErrInvalidCredential)
+	})
+
+	t.Run("should rename and delete credentials", func(t *testing.T) {
+		s := setupTestService(t)
+		authenticator := webauthntest.NewAuthenticator(testOrigin)
+		cred := register(t, s, authenticator, 1)
+
+		require.ErrorIs(t, s.RenameCredential(ctx, 2, cred.ID, "other"), webauthn.ErrCredentialNotFound)
+		require.NoError(t, s.RenameCredential(ctx, 1, cred.ID, "phone"))
+		creds, err := s.ListCredentials(ctx, 1)
+		require.NoError(t, err)
+		assert.Equal(t, "phone", creds[0].Name)
+
+		other := webauthntest.NewAuthenticator(testOrigin)
+		register(t, s, other, 2)
+		require.ErrorIs(t, s.DeleteCredential(ctx, 2, cred.ID, verify(t, s, other, 2)), webauthn.ErrCredentialNotFound)
+		require.NoError(t, s.DeleteCredential(ctx, 1, cred.ID, verify(t, s, authenticator, 1)))
+		has, err := s.HasCredentials(ctx, 1)
+		require.NoError(t, err)
+		assert.False(t, has)
+	})
+	t.Run("should require an assertion of the user to delete credentials", func(t *testing.T) {
+		s := setupTestService(t)
+		authenticator := webauthntest.NewAuthenticator(testOrigin)
+		cred := register(t, s, authenticator, 1)
+		other := webauthntest.NewAuthenticator(testOrigin)
+		register(t, s, other, 2)
+
+		require.ErrorIs(t, s.DeleteCredential(ctx, 1, cred.ID, &webauthn.AssertionResponse{}), webauthn.ErrInvalidCredential)
+		require.ErrorIs(t, s.DeleteCredential(ctx, 1, cred.ID, verify(t, s, other, 2)), webauthn.ErrInvalidCredential)
+
+		resp := verify(t, s, authenticator, 1)
+		require.NoError(t, s.DeleteCredential(ctx, 1, cred.ID, resp))
+		require.ErrorIs(t, s.DeleteCredential(ctx, 1, cred.ID, resp), webauthn.ErrInvalidChallenge, "assertion should only be usable once")
+	})
+
+	t.Run("should not delete the last second factor when two-factor authentication is required", func(t *testing.T) {
+		twoFactor := &twofactortest.FakeService{ExpectedEnabled: true, ExpectedStatus: &twofactor.Status{Required: true}}
+		s := setupTestServiceWithTwoFactor(t, twoFactor)
+		first := webauthntest.NewAuthenticator(testOrigin)
+		firstCred := register(t, s, first, 1)
+		second := webauthntest.NewAuthenticator(testOrigin)
+		secondCred := register(t, s, second, 1)
+
+		require.NoError(t, s.DeleteCredential(ctx, 1, firstCred.ID, verify(t, s, first, 1)))
+		require.ErrorIs(t, s.DeleteCredential(ctx, 1, secondCred.ID, verify(t, s, second, 1)), webauthn.ErrLastSecondFactor)
+
+		// With TOTP enabled the passkey is not the last second factor.
+		twoFactor.ExpectedStatus.Enabled = true
+		require.NoError(t, s.DeleteCredential(ctx, 1, secondCred.ID, verify(t, s, second, 1)))
+	})
+}
@@ -0,0 +1,139 @@
+package webauthntest
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+
+	"github.com/fxamacker/cbor/v2"
+
+	"github.com/grafana/grafana/pkg/services/webauthn"
+)
+
+// Authenticator is a software authenticator holding a single ES256 credential.
+type Authenticator struct {
+	Origin string
+	// UserVerified sets the user verified flag in responses.
+	UserVerified bool
+	// SignCount is incremented for each assertion when CountSignatures is true.
+	SignCount       uint32
+	CountSignatures bool
+
+	CredentialID []byte
+	UserHandle   []byte
+	key          *ecdsa.PrivateKey
+	rpID         string
+}
+
+func NewAuthenticator(origin string) *Authenticator {
+	return &Authenticator{Origin: origin, UserVerified: true}
+}
+
+// Create creates a credential for the options returned by BeginRegistration.
+func (a *Authenticator) Create(options *webauthn.CredentialCreation) (*webauthn.RegistrationResponse, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	a.key = key
+	a.rpID = options.PublicKey.RP.ID
+	a.UserHandle = options.PublicKey.User.ID
+	a.CredentialID = make([]byte, 16)
+	if _, err := rand.Read(a.CredentialID); err != nil {
+		return nil, err
+	}
+
+	x, y := make([]byte, 32), make([]byte, 32)
+	key.X.FillBytes(x)
+	key.Y.FillBytes(y)
+	coseKey, err := cbor.Marshal(map[int]any{1: 2, 3: -7, -1: 1, -2: x, -3: y})
+	if err != nil {
+		return nil, err
+	}
+
+	attested := make([]byte, 18, 18+len(a.CredentialID)+len(coseKey))
+	binary.BigEndian.PutUint16(attested[16:], uint16(len(a.CredentialID)))
+	attested = append(attested, a.CredentialID...)
+	attested = append(attested, coseKey...)
+
+	attestationObject, err := cbor.Marshal(map[string]any{
+		"fmt":      "none",
+		"attStmt":  map[string]any{},
+		"authData": a.authData(0x40, attested),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	clientData, err := a.clientData("webauthn.create", options.PublicKey.Challenge)
+	if err != nil {
+		return nil, err
+	}
+
+	return &webauthn.RegistrationResponse{
+		ID:    base64.RawURLEncoding.EncodeToString(a.CredentialID),
+		RawID: a.CredentialID,
+		Type:  "public-key",
+		Response: webauthn.AttestationResponse{
+			ClientDataJSON:    clientData,
+			AttestationObject: attestationObject,
+			Transports:        []string{"internal"},
+		},
+	}, nil
+}
+
+// Get signs the challenge of the options returned by BeginLogin.
+func (a *Authenticator) Get(options *webauthn.CredentialAssertion) (*webauthn.AssertionResponse, error) {
+	if a.CountSignatures {
+		a.SignCount++
+	}
+	authData := a.authData(0, nil)
+	clientData, err := a.clientData("webauthn.get", options.PublicKey.Challenge)
+	if err != nil {
+		return nil, err
+	}
+
+	clientDataHash := sha256.Sum256(clientData)
+	digest := sha256.Sum256(append(append([]byte{}, authData...), clientDataHash[:]...))
+	signature, err := ecdsa.SignASN1(rand.Reader, a.key, digest[:])
+	if err != nil {
+		return nil, err
+	}
+
+	return &webauthn.AssertionResponse{
+		ID:    base64.RawURLEncoding.EncodeToString(a.CredentialID),
+		RawID: a.CredentialID,
+		Type:  "public-key",
+		Response: webauthn.AuthenticatorAssertionResponse{
+			ClientDataJSON:    clientData,
+			AuthenticatorData: authData,
+			Signature:         signature,
+			UserHandle:        a.UserHandle,
+		},
+	}, nil
+}
+
+func (a *Authenticator) authData(flags byte, attested []byte) []byte {
+	rpIDHash := sha256.Sum256([]byte(a.rpID))
+	flags |= 0x01
+	if a.UserVerified {
+		flags |= 0x04
+	}
+	data := make([]byte, 0, 37+len(attested))
+	data = append(data, rpIDHash[:]...)
+	data = append(data, flags)
+	data = binary.BigEndian.AppendUint32(data, a.SignCount)
+	return append(data, attested...)
+}
+
+func (a *Authenticator) clientData(typ string, challenge []byte) ([]byte, error) {
+	return json.Marshal(map[string]string{
+		"type":      typ,
+		"challenge": base64.RawURLEncoding.EncodeToString(challenge),
+		"origin":    a.Origin,
+	})
+}
@@ -0,0 +1,59 @@
+package webauthntest
+
+import (
+	"context"
+
+	"github.com/grafana/grafana/pkg/services/webauthn"
+)
+
+var _ webauthn.Service = new(FakeService)
+
+type FakeService struct {
+	ExpectedEnabled     bool
+	ExpectedCreation    *webauthn.CredentialCreation
+	ExpectedAssertion   *webauthn.CredentialAssertion
+	ExpectedResult      *webauthn.LoginResult
+	ExpectedCredential  *webauthn.Credential
+	ExpectedCredentials []*webauthn.Credential
+	ExpectedErr         error
+
+	// BeginLoginUserID records the user id passed to BeginLogin.
+	BeginLoginUserID int64
+}
+
+func (f *FakeService) IsEnabled() bool {
+	return f.ExpectedEnabled
+}
+
+func (f *FakeService) BeginRegistration(ctx context.Context, userID int64, login string) (*webauthn.CredentialCreation, error) {
+	return f.ExpectedCreation, f.ExpectedErr
+}
+
+func (f *FakeService) FinishRegistration(ctx context.Context, userID int64, name string, resp *webauthn.RegistrationResponse) (*webauthn.Credential, error) {
+	return f.ExpectedCredential, f.ExpectedErr
+}
+
+func (f *FakeService) BeginLogin(ctx context.Context, userID int64) (*webauthn.CredentialAssertion, error) {
+	f.BeginLoginUserID = userID
+	return f.ExpectedAssertion, f.ExpectedErr
+}
+
+func (f *FakeService) FinishLogin(ctx context.Context, resp *webauthn.AssertionResponse) (*webauthn.LoginResult, error) {
+	return f.ExpectedResult, f.ExpectedErr
+}
+
+func (f *FakeService) HasCredentials(ctx context.Context, userID int64) (bool, error) {
+	return len(f.ExpectedCredentials) > 0, f.ExpectedErr
+}
+
+func (f *FakeService) ListCredentials(ctx context.Context, userID int64) ([]*webauthn.Credential, error) {
+	return f.ExpectedCredentials, f.ExpectedErr
+}
+
+func (f *FakeService) RenameCredential(ctx context.Context, userID, id int64, name string) error {
+	return f.ExpectedErr
+}
+
+func (f *FakeService) DeleteCredential(ctx context.Context, userID, id int64, resp *webauthn.AssertionResponse) error {
+	return f.ExpectedErr
+}
@@ -50,6 +50,12 @@ const (
 	ApplicationName  = "Grafana"
 )
 
+// Policies applied when a user logs in with login_maximum_concurrent_sessions sessions already active.
+const (
+	ConcurrentSessionsRevokeOldest = "revoke_oldest"
+	ConcurrentSessionsDenyNew      = "deny_new"
+)
+
 // zoneInfo names environment variable for setting the path to look for the timezone database in go
 const zoneInfo = "ZONEINFO"
 
@@ -215,6 +221,13 @@ type Cfg struct {
 	PluginsCDNURLTemplate    string
 	PluginLogBackendRequests bool
 
+	// Limits of concurrent requests to backend plugins, 0 means unlimited.
+	PluginMaxConcurrentRequestsPerDatasource int
+	PluginMaxConcurrentRequestsPerPlugin     int
+	PluginConcurrentRequestsQueueTimeout     time.Duration
+	PluginCircuitBreakerFailureThreshold     int
+	PluginCircuitBreakerOpenDuration         time.Duration
+
 	PluginUpdateStrategy string
 
 	// Plugin API restrictions - maps API name to list of plugin IDs/patterns
@@ -248,6 +261,8 @@ type Cfg struct {
 	LoginMaxInactiveLifetime      time.Duration
 	LoginMaxLifetime              time.Duration
 	TokenRotationIntervalMinutes  int
+	LoginMaxConcurrentSessions    int
+	ConcurrentSessionsPolicy      string
 	SigV4AuthEnabled              bool
 	SigV4VerboseLogging           bool
 	AzureAuthEnabled              bool
@@ -279,6 +294,9 @@ type Cfg struct {
 	// Auth proxy settings
 	AuthProxy AuthProxySettings
 
+	// Client certificate settings
+	ClientCertAuth AuthClientCertSettings
+
 	// OAuth
 	OAuthAutoLogin                       bool
 	OAuthLoginErrorMessage               string
@@ -291,6 +309,10 @@ type Cfg struct {
 
 	PasswordlessMagicLinkAuth AuthPasswordlessMagicLinkSettings
 
+	TwoFactorAuth AuthTwoFactorSettings
+
+	WebAuthn AuthWebAuthnSettings
+
 	// SSO Settings Auth
 	SSOSettingsReloadInterval        time.Duration
 	SSOSettingsConfigurableProviders map[string]bool
@@ -336,6 +358,13 @@ type Cfg struct {
 
 	// Service Accounts
 	SATokenExpirationDayLimit int
+	// Default token policy of organizations that did not set their own
+	SATokenMaxTTLDays          int
+	SATokenRequireExpiry       bool
+	SATokenIdleRevocationDays  int
+	SATokenExpiryWarningDays   int
+	SATokenRotationOverlap     time.Duration
+	SATokenPolicyCheckInterval time.Duration
 
 	// Annotations
 	AnnotationCleanupJobBatchSize      int64
@@ -471,6 +500,12 @@ type Cfg struct {
 	// LiveAllowedOrigins is a set of origins accepted by Live. If not provided
 	// then Live uses AppURL as the only allowed origin.
 	LiveAllowedOrigins []string
+	// LiveHistoryMaxRows is the number of rows kept per managed stream channel
+	// and sent to new subscribers. 0 keeps only the last frame.
+	LiveHistoryMaxRows int
+	// LiveHistoryMaxAge is the age of the rows kept per managed stream channel
+	// relative to the latest row. 0 does not limit the age.
+	LiveHistoryMaxAge time.Duration
 	// LiveMessageSizeLimit is the maximum size in bytes of Websocket messages
 	// from clients. Defaults to 64KB.
 	LiveMessageSizeLimit int
@@ -1344,8 +1379,11 @@ func (cfg *Cfg) parseINIFile(iniFile *ini.File) error {
 	cfg.readAuthJWTSettings()
 	cfg.readAuthExtJWTSettings()
 	cfg.readAuthProxySettings()
+	cfg.readClientCertSettings()
 	cfg.readSessionConfig()
 	cfg.readPasswordlessMagicLinkSettings()
+	cfg.readTwoFactorSettings()
+	cfg.readWebAuthnSettings()
 	if err := cfg.readSmtpSettings(); err != nil {
 		return err
 	}
@@ -1718,6 +1756,12 @@ func readAuthSettings(iniFile *ini.File, cfg *Cfg) (err error) {
 		cfg.TokenRotationIntervalMinutes = 2
 	}
 
+	cfg.LoginMaxConcurrentSessions = auth.Key("login_maximum_concurrent_sessions").MustInt(0)
+	cfg.ConcurrentSessionsPolicy = valueAsString(auth, "concurrent_sessions_policy", ConcurrentSessionsRevokeOldest)
+	if cfg.ConcurrentSessionsPolicy != ConcurrentSessionsRevokeOldest && cfg.ConcurrentSessionsPolicy != ConcurrentSessionsDenyNew {
+		return fmt.Errorf("invalid concurrent_sessions_policy %q, must be %q or %q", cfg.ConcurrentSessionsPolicy, ConcurrentSessionsRevokeOldest, ConcurrentSessionsDenyNew)
+	}
+
 	cfg.DisableLoginForm = auth.Key("disable_login_form").MustBool(false)
 	cfg.DisableSignoutMenu = auth.Key("disable_signout_menu").MustBool(false)
 
@@ -1870,6 +1914,12 @@ func readUserSettings(iniFile *ini.File, cfg *Cfg) error {
 func readServiceAccountSettings(iniFile *ini.File, cfg *Cfg) error {
 	serviceAccount := iniFile.Section("service_accounts")
 	cfg.SATokenExpirationDayLimit = serviceAccount.Key("token_expiration_day_limit").MustInt(-1)
+	cfg.SATokenMaxTTLDays = serviceAccount.Key("token_max_ttl_days").MustInt(0)
+	cfg.SATokenRequireExpiry = serviceAccount.Key("token_require_expiry").MustBool(false)
+	cfg.SATokenIdleRevocationDays = serviceAccount.Key("token_idle_revocation_days").MustInt(0)
+	cfg.SATokenExpiryWarningDays = serviceAccount.Key("token_expiry_warning_days").MustInt(7)
+	cfg.SATokenRotationOverlap = serviceAccount.Key("token_rotation_overlap").MustDuration(24 * time.Hour)
+	cfg.SATokenPolicyCheckInterval = serviceAccount.Key("token_policy_check_interval").MustDuration(time.Hour)
 	return nil
 }
 
@@ -2060,6 +2110,11 @@ func (cfg *Cfg) readLiveSettings(iniFile *ini.File) error {
 	if cfg.LiveMessageSizeLimit < -1 {
 		return fmt.Errorf("unexpected value %d for [live] message_size_limit", cfg.LiveMaxConnections)
 	}
+	cfg.LiveHistoryMaxRows = section.Key("history_max_rows").MustInt(0)
+	if cfg.LiveHistoryMaxRows < 0 {
+		return fmt.Errorf("unexpected value %d for [live] history_max_rows", cfg.LiveHistoryMaxRows)
+	}
+	cfg.LiveHistoryMaxAge = section.Key("history_max_age").MustDuration(0)
 	cfg.LiveHAEngine = section.Key("ha_engine").MustString("")
 	switch cfg.LiveHAEngine {
 	case "", "redis":
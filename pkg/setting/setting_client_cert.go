@@ -0,0 +1,34 @@
+package setting
+
+import (
+	"strings"
+
+	"github.com/grafana/grafana/pkg/util"
+)
+
+type AuthClientCertSettings struct {
+	// Client certificate (mTLS) authentication
+	Enabled bool
+	// ClientCAFile is the CA bundle the HTTP server verifies client certificates against
+	ClientCAFile string
+	// Rules map certificate fields to users or service accounts, the first matching rule is used
+	Rules      []string
+	AutoSignUp bool
+	// DenyList contains serial numbers or sha256 fingerprints of revoked certificates
+	DenyList     []string
+	DenyListFile string
+}
+
+func (cfg *Cfg) readClientCertSettings() {
+	section := cfg.SectionWithEnvOverrides("auth.client_cert")
+
+	cfg.ClientCertAuth = AuthClientCertSettings{
+		Enabled:      section.Key("enabled").MustBool(false),
+		ClientCAFile: section.Key("client_ca_file").MustString(""),
+		// rules contain regular expressions, so they are only separated by whitespace
+		Rules:        strings.Fields(section.Key("rules").MustString("cn:.+:user")),
+		AutoSignUp:   section.Key("auto_sign_up").MustBool(false),
+		DenyList:     util.SplitString(section.Key("deny_list").MustString("")),
+		DenyListFile: section.Key("deny_list_file").MustString(""),
+	}
+}
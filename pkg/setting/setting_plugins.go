@@ -4,6 +4,7 @@ import (
 	"os"
 	"regexp"
 	"strings"
+	"time"
 
 	"gopkg.in/ini.v1"
 
@@ -196,6 +197,12 @@ func (cfg *Cfg) readPluginSettings(iniFile *ini.File) error {
 	cfg.PluginsCDNURLTemplate = strings.TrimRight(pluginsSection.Key("cdn_base_url").MustString(""), "/")
 	cfg.PluginLogBackendRequests = pluginsSection.Key("log_backend_requests").MustBool(false)
 
+	cfg.PluginMaxConcurrentRequestsPerDatasource = pluginsSection.Key("max_concurrent_requests_per_datasource").MustInt(0)
+	cfg.PluginMaxConcurrentRequestsPerPlugin = pluginsSection.Key("max_concurrent_requests_per_plugin").MustInt(0)
+	cfg.PluginConcurrentRequestsQueueTimeout = pluginsSection.Key("concurrent_requests_queue_timeout").MustDuration(10 * time.Second)
+	cfg.PluginCircuitBreakerFailureThreshold = pluginsSection.Key("circuit_breaker_failure_threshold").MustInt(0)
+	cfg.PluginCircuitBreakerOpenDuration = pluginsSection.Key("circuit_breaker_open_duration").MustDuration(30 * time.Second)
+
 	cfg.PluginUpdateStrategy = pluginsSection.Key("update_strategy").In(PluginUpdateStrategyLatest, []string{PluginUpdateStrategyLatest, PluginUpdateStrategyMinor})
 
 	// Plugin API restrictions - read from sections
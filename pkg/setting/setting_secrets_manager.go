@@ -3,6 +3,8 @@ package setting
 import (
 	"strings"
 	"time"
+
+	"github.com/grafana/grafana/pkg/util"
 )
 
 const (
@@ -36,6 +38,11 @@ type SecretsManagerSettings struct {
 	GCWorkerPerSecureValueCleanupTimeout time.Duration
 	// Whether the secrets management is running in developer mode.
 	IsDeveloperMode bool
+
+	// Environment variables keepers can read their credentials from. Empty means none.
+	KeeperCredentialEnvAllowlist []string
+	// Configuration keys, as <section>.<key>, keepers can read their credentials from. Empty means none.
+	KeeperCredentialConfigAllowlist []string
 }
 
 func (cfg *Cfg) readSecretsManagerSettings() {
@@ -57,6 +64,9 @@ func (cfg *Cfg) readSecretsManagerSettings() {
 
 	cfg.SecretsManagement.IsDeveloperMode = secretsMgmt.Key("developer_mode").MustBool(false)
 
+	cfg.SecretsManagement.KeeperCredentialEnvAllowlist = util.SplitString(secretsMgmt.Key("keeper_credential_env_allowlist").MustString(""))
+	cfg.SecretsManagement.KeeperCredentialConfigAllowlist = util.SplitString(secretsMgmt.Key("keeper_credential_config_allowlist").MustString(""))
+
 	// Extract available KMS providers from configuration sections
 	providers := make(map[string]map[string]string)
 	for _, section := range cfg.Raw.Sections() {
@@ -0,0 +1,23 @@
+package setting
+
+import "time"
+
+type AuthTwoFactorSettings struct {
+	// TOTP two-factor authentication for users logging in with a Grafana password
+	Enabled                 bool
+	Issuer                  string
+	LoginTimeout            time.Duration
+	RecoveryCodes           int
+	RequireForGrafanaAdmins bool
+}
+
+func (cfg *Cfg) readTwoFactorSettings() {
+	section := cfg.SectionWithEnvOverrides("auth.two_factor")
+	cfg.TwoFactorAuth = AuthTwoFactorSettings{
+		Enabled:                 section.Key("enabled").MustBool(false),
+		Issuer:                  section.Key("issuer").MustString("Grafana"),
+		LoginTimeout:            section.Key("login_timeout").MustDuration(5 * time.Minute),
+		RecoveryCodes:           section.Key("recovery_codes").MustInt(10),
+		RequireForGrafanaAdmins: section.Key("require_for_grafana_admins").MustBool(false),
+	}
+}
@@ -0,0 +1,52 @@
+package setting
+
+import (
+	"net/url"
+	"time"
+
+	"github.com/grafana/grafana/pkg/util"
+)
+
+type AuthWebAuthnSettings struct {
+	// WebAuthn (passkey) authentication for users with a Grafana account
+	Enabled bool
+	// RPID is the relying party id credentials are scoped to, defaults to the host of root_url
+	RPID          string
+	RPDisplayName string
+	// Origins are the origins allowed to use credentials, defaults to the origin of root_url
+	Origins          []string
+	ChallengeTimeout time.Duration
+	// UserVerification is one of required, preferred or discouraged
+	UserVerification string
+}
+
+func (cfg *Cfg) readWebAuthnSettings() {
+	section := cfg.SectionWithEnvOverrides("auth.webauthn")
+
+	settings := AuthWebAuthnSettings{
+		Enabled:          section.Key("enabled").MustBool(false),
+		RPID:             section.Key("rp_id").MustString(""),
+		RPDisplayName:    section.Key("rp_display_name").MustString("Grafana"),
+		Origins:          util.SplitString(section.Key("origins").MustString("")),
+		ChallengeTimeout: section.Key("challenge_timeout").MustDuration(5 * time.Minute),
+		UserVerification: section.Key("user_verification").MustString("preferred"),
+	}
+
+	if appURL, err := url.Parse(cfg.AppURL); err == nil && appURL.Host != "" {
+		if settings.RPID == "" {
+			settings.RPID = appURL.Hostname()
+		}
+		if len(settings.Origins) == 0 {
+			settings.Origins = []string{appURL.Scheme + "://" + appURL.Host}
+		}
+	}
+
+	switch settings.UserVerification {
+	case "required", "preferred", "discouraged":
+	default:
+		cfg.Logger.Warn("Invalid user_verification for WebAuthn, using preferred", "value", settings.UserVerification)
+		settings.UserVerification = "preferred"
+	}
+
+	cfg.WebAuthn = settings
+}
@@ -27,6 +27,9 @@ type ZanzanaClientSettings struct {
 	// URL called to perform exchange request.
 	// Only used when mode is set to client.
 	TokenExchangeURL string
+	// Comma separated IP addresses or CIDR networks of the proxies trusted to forward the
+	// client address in the X-Real-IP and X-Forwarded-For headers.
+	TrustedProxies string
 }
 
 type ZanzanaServerSettings struct {
@@ -112,6 +115,7 @@ func (cfg *Cfg) readZanzanaSettings() {
 	zc.TokenExchangeURL = clientSec.Key("token_exchange_url").MustString("")
 	zc.Addr = clientSec.Key("address").MustString("")
 	zc.ServerCertFile = clientSec.Key("tls_cert").MustString("")
+	zc.TrustedProxies = clientSec.Key("trusted_proxies").MustString("")
 
 	cfg.ZanzanaClient = zc
 
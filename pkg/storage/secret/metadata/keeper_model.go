@@ -273,9 +273,13 @@ func extractSecureValues(kp *secretv1beta1.Keeper) map[string]struct{} {
 		return nil
 
 	case kp.Spec.HashiCorpVault != nil:
-		if kp.Spec.HashiCorpVault.Token.SecureValueName != "" {
+		if kp.Spec.HashiCorpVault.Token != nil && kp.Spec.HashiCorpVault.Token.SecureValueName != "" {
 			return map[string]struct{}{kp.Spec.HashiCorpVault.Token.SecureValueName: {}}
 		}
+
+		if kp.Spec.HashiCorpVault.AppRole != nil && kp.Spec.HashiCorpVault.AppRole.SecretID.SecureValueName != "" {
+			return map[string]struct{}{kp.Spec.HashiCorpVault.AppRole.SecretID.SecureValueName: {}}
+		}
 	}
 
 	return nil
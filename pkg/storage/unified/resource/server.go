@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"iter"
 	"log/slog"
+	"maps"
 	"net/http"
 	"sync"
 	"sync/atomic"
@@ -25,6 +26,7 @@ import (
 
 	"github.com/grafana/grafana/pkg/apimachinery/utils"
 	secrets "github.com/grafana/grafana/pkg/registry/apis/secret/contracts"
+	"github.com/grafana/grafana/pkg/services/authz/zanzana/attributes"
 	"github.com/grafana/grafana/pkg/storage/unified/resourcepb"
 	"github.com/grafana/grafana/pkg/util/scheduler"
 )
@@ -529,7 +531,7 @@ func (s *server) newEvent(ctx context.Context, user claims.AuthInfo, key *resour
 
 	// For folder moves, we need to check permissions on both folders
 	if s.isFolderMove(event) {
-		if err := s.checkFolderMovePermissions(ctx, user, key, event.ObjectOld.GetFolder(), obj.GetFolder()); err != nil {
+		if err := s.checkFolderMovePermissions(ctx, user, key, event.ObjectOld, obj); err != nil {
 			return nil, err
 		}
 	} else {
@@ -541,19 +543,26 @@ func (s *server) newEvent(ctx context.Context, user claims.AuthInfo, key *resour
 			Namespace: key.Namespace,
 		}
 
+		// Grants restricted by labels must apply to the resource both before and after an update
+		labels := []map[string]string{obj.GetLabels()}
 		if event.Type == resourcepb.WatchEvent_MODIFIED {
 			check.Verb = utils.VerbUpdate
 			check.Name = key.Name
+			if event.ObjectOld != nil && !maps.Equal(event.ObjectOld.GetLabels(), obj.GetLabels()) {
+				labels = append(labels, event.ObjectOld.GetLabels())
+			}
 		}
 
 		check.Folder = obj.GetFolder()
-		a, err := s.access.Check(ctx, user, check)
-		if err != nil {
-			return nil, AsErrorResult(err)
-		}
-		if !a.Allowed {
-			return nil, &resourcepb.ErrorResult{
-				Code: http.StatusForbidden,
+		for _, l := range labels {
+			a, err := s.access.Check(attributes.WithResourceLabels(ctx, l), user, check)
+			if err != nil {
+				return nil, AsErrorResult(err)
+			}
+			if !a.Allowed {
+				return nil, &resourcepb.ErrorResult{
+					Code: http.StatusForbidden,
+				}
 			}
 		}
 	}
@@ -576,7 +585,7 @@ func (s *server) isFolderMove(event *WriteEvent) bool {
 }
 
 // checkFolderMovePermissions handles permission checks when a resource is being moved between folders
-func (s *server) checkFolderMovePermissions(ctx context.Context, user claims.AuthInfo, key *resourcepb.ResourceKey, oldFolder, newFolder string) *resourcepb.ErrorResult {
+func (s *server) checkFolderMovePermissions(ctx context.Context, user claims.AuthInfo, key *resourcepb.ResourceKey, oldObj, newObj utils.GrafanaMetaAccessor) *resourcepb.ErrorResult {
 	// First check if user can update the resource in the original folder
 	updateCheck := claims.CheckRequest{
 		Verb:      utils.VerbUpdate,
@@ -584,10 +593,10 @@ func (s *server) checkFolderMovePermissions(ctx context.Context, user claims.Aut
 		Resource:  key.Resource,
 		Namespace: key.Namespace,
 		Name:      key.Name,
-		Folder:    oldFolder,
+		Folder:    oldObj.GetFolder(),
 	}
 
-	a, err := s.access.Check(ctx, user, updateCheck)
+	a, err := s.access.Check(attributes.WithResourceLabels(ctx, oldObj.GetLabels()), user, updateCheck)
 	if err != nil {
 		return AsErrorResult(err)
 	}
@@ -604,10 +613,10 @@ func (s *server) checkFolderMovePermissions(ctx context.Context, user claims.Aut
 		Group:     key.Group,
 		Resource:  key.Resource,
 		Namespace: key.Namespace,
-		Folder:    newFolder,
+		Folder:    newObj.GetFolder(),
 	}
 
-	a, err = s.access.Check(ctx, user, createCheck)
+	a, err = s.access.Check(attributes.WithResourceLabels(ctx, newObj.GetLabels()), user, createCheck)
 	if err != nil {
 		return AsErrorResult(err)
 	}
@@ -787,7 +796,7 @@ func (s *server) delete(ctx context.Context, user claims.AuthInfo, req *resource
 		return rsp, nil
 	}
 
-	access, err := s.access.Check(ctx, user, claims.CheckRequest{
+	access, err := s.access.Check(withStoredResourceLabels(ctx, latest.Value), user, claims.CheckRequest{
 		Verb:      "delete",
 		Group:     req.Key.Group,
 		Resource:  req.Key.Resource,
@@ -890,7 +899,7 @@ func (s *server) read(ctx context.Context, user claims.AuthInfo, req *resourcepb
 		return &resourcepb.ReadResponse{Error: rsp.Error}, nil
 	}
 
-	a, err := s.access.Check(ctx, user, claims.CheckRequest{
+	a, err := s.access.Check(withStoredResourceLabels(ctx, rsp.Value), user, claims.CheckRequest{
 		Verb:      "get",
 		Group:     req.Key.Group,
 		Resource:  req.Key.Resource,
@@ -914,6 +923,20 @@ func (s *server) read(ctx context.Context, user claims.AuthInfo, req *resourcepb
 	}, nil
 }
 
+// withStoredResourceLabels adds the labels of a stored resource to the context of an access check,
+// so grants restricted by labels can be evaluated. The labels stay unknown if the value can't be parsed.
+func withStoredResourceLabels(ctx context.Context, value []byte) context.Context {
+	var obj struct {
+		Metadata struct {
+			Labels map[string]string `json:"labels"`
+		} `json:"metadata"`
+	}
+	if len(value) == 0 || json.Unmarshal(value, &obj) != nil {
+		return ctx
+	}
+	return attributes.WithResourceLabels(ctx, obj.Metadata.Labels)
+}
+
 func (s *server) List(ctx context.Context, req *resourcepb.ListRequest) (*resourcepb.ListResponse, error) {
 	ctx, span := s.tracer.Start(ctx, "storage_server.List")
 	defer span.End()
@@ -23,6 +23,7 @@ import (
 	"github.com/grafana/grafana/pkg/apimachinery/identity"
 	"github.com/grafana/grafana/pkg/apimachinery/utils"
 	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/authz/zanzana/attributes"
 	"github.com/grafana/grafana/pkg/storage/unified/resourcepb"
 	"github.com/grafana/grafana/pkg/util/scheduler"
 )
@@ -283,6 +284,100 @@ func TestSimpleServer(t *testing.T) {
 	})
 }
 
+// labelAccessClient denies access to resources with the env=prod label and records the labels of the checks.
+type labelAccessClient struct {
+	checked []map[string]string
+}
+
+func (c *labelAccessClient) Check(ctx context.Context, _ authlib.AuthInfo, _ authlib.CheckRequest) (authlib.CheckResponse, error) {
+	attrs, _ := attributes.FromContext(ctx)
+	c.checked = append(c.checked, attrs.ResourceLabels)
+	return authlib.CheckResponse{Allowed: attrs.ResourceLabels != nil && attrs.ResourceLabels["env"] != "prod"}, nil
+}
+
+func (c *labelAccessClient) Compile(context.Context, authlib.AuthInfo, authlib.ListRequest) (authlib.ItemChecker, authlib.Zookie, error) {
+	return func(string, string) bool { return true }, authlib.NoopZookie{}, nil
+}
+
+func TestServerChecksWithResourceLabels(t *testing.T) {
+	ctx := authlib.WithAuthInfo(context.Background(), &identity.StaticRequester{
+		Type:    authlib.TypeUser,
+		UserID:  123,
+		UserUID: "u123",
+		OrgRole: identity.RoleViewer,
+	})
+
+	store, err := NewCDKBackend(ctx, CDKBackendOptions{Bucket: memblob.OpenBucket(nil)})
+	require.NoError(t, err)
+
+	access := &labelAccessClient{}
+	server, err := NewResourceServer(ResourceServerOptions{Backend: store, AccessClient: access})
+	require.NoError(t, err)
+
+	playlist := func(name, labels string) []byte {
+		return []byte(`{
+			"apiVersion": "playlist.grafana.app/v0alpha1",
+			"kind": "Playlist",
+			"metadata": {"name": "` + name + `", "uid": "` + name + `", "namespace": "default"` + labels + `},
+			"spec": {"title": "hello", "interval": "5m", "items": []}
+		}`)
+	}
+	key := func(name string) *resourcepb.ResourceKey {
+		return &resourcepb.ResourceKey{Group: "playlist.grafana.app", Resource: "playlists", Namespace: "default", Name: name}
+	}
+
+	t.Run("create checks the labels of the new resource", func(t *testing.T) {
+		rsp, err := server.Create(ctx, &resourcepb.CreateRequest{Key: key("prod"), Value: playlist("prod", `, "labels": {"env": "prod"}`)})
+		require.NoError(t, err)
+		require.NotNil(t, rsp.Error)
+		require.Equal(t, int32(http.StatusForbidden), rsp.Error.Code)
+
+		rsp, err = server.Create(ctx, &resourcepb.CreateRequest{Key: key("dev"), Value: playlist("dev", "")})
+		require.NoError(t, err)
+		require.Nil(t, rsp.Error)
+		require.Equal(t, map[string]string{}, access.checked[len(access.checked)-1])
+	})
+
+	t.Run("read and delete check the labels of the stored resource", func(t *testing.T) {
+		found, err := server.Read(ctx, &resourcepb.ReadRequest{Key: key("dev")})
+		require.NoError(t, err)
+		require.Nil(t, found.Error)
+		require.Equal(t, map[string]string{}, access.checked[len(access.checked)-1])
+
+		deleted, err := server.Delete(ctx, &resourcepb.DeleteRequest{Key: key("dev"), ResourceVersion: found.ResourceVersion})
+		require.NoError(t, err)
+		require.Nil(t, deleted.Error)
+		require.Equal(t, map[string]string{}, access.checked[len(access.checked)-1])
+	})
+
+	t.Run("update checks the labels before and after the update", func(t *testing.T) {
+		created, err := server.Create(ctx, &resourcepb.CreateRequest{Key: key("relabel"), Value: playlist("relabel", `, "labels": {"env": "dev"}`)})
+		require.NoError(t, err)
+		require.Nil(t, created.Error)
+
+		access.checked = nil
+		updated, err := server.Update(ctx, &resourcepb.UpdateRequest{
+			Key:             key("relabel"),
+			Value:           playlist("relabel", `, "labels": {"env": "prod"}`),
+			ResourceVersion: created.ResourceVersion,
+		})
+		require.NoError(t, err)
+		require.NotNil(t, updated.Error)
+		require.Equal(t, int32(http.StatusForbidden), updated.Error.Code)
+		require.Equal(t, []map[string]string{{"env": "prod"}}, access.checked)
+
+		access.checked = nil
+		updated, err = server.Update(ctx, &resourcepb.UpdateRequest{
+			Key:             key("relabel"),
+			Value:           playlist("relabel", `, "labels": {"env": "staging"}`),
+			ResourceVersion: created.ResourceVersion,
+		})
+		require.NoError(t, err)
+		require.Nil(t, updated.Error)
+		require.Equal(t, []map[string]string{{"env": "staging"}, {"env": "dev"}}, access.checked)
+	})
+}
+
 func TestRunInQueue(t *testing.T) {
 	const testTenantID = "test-tenant"
 	t.Run("should execute successfully when queue has capacity", func(t *testing.T) {
@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"encoding/json"
 	"fmt"
+	"net/http"
 	"reflect"
 	"strconv"
 	"strings"
@@ -382,6 +383,23 @@ func (t *postgresQueryResultTransformer) TransformQueryError(_ log.Logger, err e
 	return err
 }
 
+// CallResource handles the query cost resource used by Grafana to check
+// queries before they run.
+func (s *Service) CallResource(ctx context.Context, req *backend.CallResourceRequest, sender backend.CallResourceResponseSender) error {
+	if !strings.EqualFold(req.Path, sqleng.QueryCostPath) {
+		return sender.Send(&backend.CallResourceResponse{Status: http.StatusNotFound})
+	}
+	dsHandler, err := s.getDSInfo(ctx, req.PluginContext)
+	if err != nil {
+		return err
+	}
+	resp, err := dsHandler.EstimateQueryCost(ctx, req, s.features)
+	if err != nil {
+		return err
+	}
+	return sender.Send(resp)
+}
+
 // CheckHealth pings the connected SQL database
 func (s *Service) CheckHealth(ctx context.Context, req *backend.CheckHealthRequest) (*backend.CheckHealthResult, error) {
 	dsHandler, err := s.getDSInfo(ctx, req.PluginContext)
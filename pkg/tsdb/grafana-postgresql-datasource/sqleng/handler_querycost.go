@@ -0,0 +1,150 @@
+package sqleng
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana/pkg/services/featuremgmt"
+	"github.com/jackc/pgx/v5"
+)
+
+// QueryCostPath is the resource path Grafana calls to estimate the cost of
+// queries before they run.
+const QueryCostPath = "query-cost"
+
+// QueryCostRequest is the request body of the query cost resource.
+type QueryCostRequest struct {
+	Queries []QueryCostQuery `json:"queries"`
+}
+
+type QueryCostQuery struct {
+	RefID         string          `json:"refId"`
+	From          time.Time       `json:"from"`
+	To            time.Time       `json:"to"`
+	IntervalMs    int64           `json:"intervalMs"`
+	MaxDataPoints int64           `json:"maxDataPoints"`
+	Model         json.RawMessage `json:"model"`
+}
+
+// QueryCostResponse is the response body of the query cost resource.
+type QueryCostResponse struct {
+	Estimates []QueryCostEstimate `json:"estimates"`
+}
+
+type QueryCostEstimate struct {
+	RefID string `json:"refId"`
+	Rows  int64  `json:"rows,omitempty"`
+}
+
+// EstimateQueryCost estimates the number of rows each query returns using the
+// planner's estimate from EXPLAIN. Queries that cannot be explained are left
+// out of the response.
+func (e *DataSourceHandler) EstimateQueryCost(ctx context.Context, req *backend.CallResourceRequest, features featuremgmt.FeatureToggles) (*backend.CallResourceResponse, error) {
+	costReq := QueryCostRequest{}
+	if err := json.Unmarshal(req.Body, &costReq); err != nil {
+		return &backend.CallResourceResponse{Status: http.StatusBadRequest, Body: []byte(err.Error())}, nil
+	}
+
+	logger := e.log.FromContext(ctx)
+	isPGX := features.IsEnabled(ctx, featuremgmt.FlagPostgresDSUsePGX)
+	costResp := QueryCostResponse{Estimates: []QueryCostEstimate{}}
+	for _, q := range costReq.Queries {
+		query := backend.DataQuery{
+			RefID:         q.RefID,
+			JSON:          q.Model,
+			Interval:      time.Duration(q.IntervalMs) * time.Millisecond,
+			MaxDataPoints: q.MaxDataPoints,
+			TimeRange:     backend.TimeRange{From: q.From, To: q.To},
+		}
+		rows, err := e.estimateRows(ctx, query, isPGX)
+		if err != nil {
+			logger.Debug("Failed to estimate query rows", "refId", q.RefID, "error", err)
+			continue
+		}
+		costResp.Estimates = append(costResp.Estimates, QueryCostEstimate{RefID: q.RefID, Rows: rows})
+	}
+
+	body, err := json.Marshal(costResp)
+	if err != nil {
+		return nil, err
+	}
+	return &backend.CallResourceResponse{
+		Status:  http.StatusOK,
+		Headers: map[string][]string{"Content-Type": {"application/json"}},
+		Body:    body,
+	}, nil
+}
+
+func (e *DataSourceHandler) estimateRows(ctx context.Context, query backend.DataQuery, isPGX bool) (int64, error) {
+	queryJSON := QueryJson{}
+	if err := json.Unmarshal(query.JSON, &queryJSON); err != nil {
+		return 0, err
+	}
+	if queryJSON.RawSql == "" {
+		return 0, errors.New("query has no rawSql")
+	}
+
+	interpolatedQuery := Interpolate(query, query.TimeRange, e.dsInfo.JsonData.TimeInterval, queryJSON.RawSql)
+	interpolatedQuery, err := e.macroEngine.Interpolate(&query, query.TimeRange, interpolatedQuery)
+	if err != nil {
+		return 0, err
+	}
+	explainQuery := "EXPLAIN (FORMAT JSON) " + strings.TrimSuffix(strings.TrimSpace(interpolatedQuery), ";")
+
+	// EXPLAIN without ANALYZE plans the statement without running it. The query
+	// is sent with the extended protocol, which rejects a string with more than
+	// one statement. With the simple protocol a second statement would run
+	// outside of EXPLAIN and could commit the read only transaction.
+	var plan []byte
+	if isPGX {
+		tx, err := e.pool.BeginTx(ctx, pgx.TxOptions{AccessMode: pgx.ReadOnly})
+		if err != nil {
+			return 0, err
+		}
+		defer tx.Rollback(ctx) //nolint:errcheck
+		if err := tx.QueryRow(ctx, explainQuery, pgx.QueryExecModeDescribeExec).Scan(&plan); err != nil {
+			return 0, err
+		}
+	} else {
+		tx, err := e.db.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+		if err != nil {
+			return 0, err
+		}
+		defer tx.Rollback() //nolint:errcheck
+		// lib/pq only uses the extended protocol for prepared statements or
+		// queries with arguments.
+		stmt, err := tx.PrepareContext(ctx, explainQuery)
+		if err != nil {
+			return 0, err
+		}
+		defer stmt.Close() //nolint:errcheck
+		if err := stmt.QueryRowContext(ctx).Scan(&plan); err != nil {
+			return 0, err
+		}
+	}
+	return parsePlanRows(plan)
+}
+
+// parsePlanRows returns the estimated row count of the top plan node of
+// EXPLAIN (FORMAT JSON) output.
+func parsePlanRows(plan []byte) (int64, error) {
+	explain := []struct {
+		Plan struct {
+			PlanRows float64 `json:"Plan Rows"`
+		} `json:"Plan"`
+	}{}
+	if err := json.Unmarshal(plan, &explain); err != nil {
+		return 0, err
+	}
+	if len(explain) == 0 {
+		return 0, fmt.Errorf("empty query plan")
+	}
+	return int64(explain[0].Plan.PlanRows), nil
+}
@@ -0,0 +1,56 @@
+package sqleng
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana/pkg/services/featuremgmt"
+	"github.com/stretchr/testify/require"
+)
+
+type testMacroEngine struct{}
+
+func (testMacroEngine) Interpolate(query *backend.DataQuery, timeRange backend.TimeRange, sql string) (string, error) {
+	return strings.ReplaceAll(sql, "$__timeFilter(time)", "time BETWEEN '2024-01-01' AND '2024-01-02'"), nil
+}
+
+func TestEstimateQueryCost(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer func() { _ = db.Close() }()
+
+	handler := DataSourceHandler{
+		db:          db,
+		macroEngine: testMacroEngine{},
+		log:         backend.NewLoggerWith("logger", "test"),
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectPrepare(regexp.QuoteMeta("EXPLAIN (FORMAT JSON) SELECT * FROM logs WHERE time BETWEEN '2024-01-01' AND '2024-01-02'")).
+		ExpectQuery().
+		WillReturnRows(sqlmock.NewRows([]string{"QUERY PLAN"}).AddRow([]byte(`[{"Plan": {"Node Type": "Seq Scan", "Plan Rows": 125000}}]`)))
+	mock.ExpectRollback()
+
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	body, err := json.Marshal(QueryCostRequest{Queries: []QueryCostQuery{
+		{RefID: "A", From: from, To: from.Add(24 * time.Hour), Model: json.RawMessage(`{"rawSql":"SELECT * FROM logs WHERE $__timeFilter(time);"}`)},
+		{RefID: "B", From: from, To: from.Add(24 * time.Hour), Model: json.RawMessage(`{"rawQuery":true}`)},
+	}})
+	require.NoError(t, err)
+
+	resp, err := handler.EstimateQueryCost(context.Background(), &backend.CallResourceRequest{Body: body}, featuremgmt.WithFeatures())
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.Status)
+
+	costResp := QueryCostResponse{}
+	require.NoError(t, json.Unmarshal(resp.Body, &costResp))
+	require.Equal(t, []QueryCostEstimate{{RefID: "A", Rows: 125000}}, costResp.Estimates)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
@@ -48,6 +48,16 @@ const (
 	ErrorSourceDownstream ErrorSource = "downstream"
 )
 
+// ReplayFormat defines model for ReplayQuery.Format.
+// +enum
+type ReplayFormat string
+
+// Defines values for ReplayFormat.
+const (
+	ReplayFormatNDJSON ReplayFormat = "ndjson"
+	ReplayFormatArrow  ReplayFormat = "arrow"
+)
+
 // TestDataQueryType defines model for TestDataQueryType.
 // +enum
 type TestDataQueryType string
@@ -76,6 +86,7 @@ const (
 	TestDataQueryTypeRandomWalkTable              TestDataQueryType = "random_walk_table"
 	TestDataQueryTypeRandomWalkWithError          TestDataQueryType = "random_walk_with_error"
 	TestDataQueryTypeRawFrame                     TestDataQueryType = "raw_frame"
+	TestDataQueryTypeReplay                       TestDataQueryType = "replay"
 	TestDataQueryTypeServerError500               TestDataQueryType = "server_error_500"
 	TestDataQueryTypeSteps                        TestDataQueryType = "steps"
 	TestDataQueryTypeSimulation                   TestDataQueryType = "simulation"
@@ -123,6 +134,7 @@ type TestDataQuery struct {
 
 	Nodes     *NodesQuery      `json:"nodes,omitempty"`
 	PulseWave *PulseWaveQuery  `json:"pulseWave,omitempty"`
+	Replay    *ReplayQuery     `json:"replay,omitempty"`
 	Sim       *SimulationQuery `json:"sim,omitempty"`
 	Stream    *StreamingQuery  `json:"stream,omitempty"`
 	Usa       *USAQuery        `json:"usa,omitempty"`
@@ -152,6 +164,21 @@ type PulseWaveQuery struct {
 	TimeStep int64   `json:"timeStep,omitempty"`
 }
 
+// ReplayQuery defines model for ReplayQuery.
+type ReplayQuery struct {
+	// The recording format, defaults to ndjson
+	Format ReplayFormat `json:"format,omitempty"`
+	// The recorded frames. For ndjson each line is a JSON encoded data frame,
+	// for arrow each line is a base64 encoded arrow frame
+	Content string `json:"content,omitempty"`
+	// Playback speed multiplier, defaults to 1
+	Speed float64 `json:"speed,omitempty"`
+	// Restart the recording once it reaches the end
+	Loop bool `json:"loop,omitempty"`
+	// Continue playing the recording over a live channel
+	Stream bool `json:"stream,omitempty"`
+}
+
 // SimulationQuery defines model for SimulationQuery.
 type SimulationQuery struct {
 	Config map[string]any `json:"config,omitempty"`
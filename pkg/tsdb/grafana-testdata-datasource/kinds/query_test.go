@@ -22,6 +22,7 @@ func TestQueryTypeDefinitions(t *testing.T) {
 				reflect.TypeOf(StreamingQueryTypeFetch),      // pick an example value (not the root)
 				reflect.TypeOf(ErrorTypeServerPanic),         // pick an example value (not the root)
 				reflect.TypeOf(ErrorSourcePlugin),            // pick an example value (not the root)
+				reflect.TypeOf(ReplayFormatNDJSON),           // pick an example value (not the root)
 				reflect.TypeOf(TestDataQueryTypeAnnotations), // pick an example value (not the root)
 			},
 		})
@@ -0,0 +1,406 @@
+package testdatasource
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+
+	"github.com/grafana/grafana/pkg/tsdb/grafana-testdata-datasource/kinds"
+)
+
+const (
+	// maxReplayRecordings limits how many recordings are kept around for streaming
+	maxReplayRecordings = 50
+	// maxReplayLineSize is the largest single frame accepted in a recording
+	maxReplayLineSize = 32 * 1024 * 1024
+	// maxReplayRows limits how many rows a replay query returns across all frames
+	maxReplayRows = 1_000_000
+)
+
+// replayRecording is a parsed recording along with its playback settings.
+type replayRecording struct {
+	frames  []*data.Frame
+	timeIdx []int
+	start   time.Time
+	end     time.Time
+	// interval is the average distance between two rows of the densest frame
+	interval time.Duration
+	speed    float64
+	loop     bool
+}
+
+// replayStore keeps recordings that were queried with streaming enabled, so
+// RunStream can find them again from the channel path.
+type replayStore struct {
+	mu         sync.RWMutex
+	keys       []string
+	recordings map[string]*replayRecording
+}
+
+func newReplayStore() *replayStore {
+	return &replayStore{
+		recordings: map[string]*replayRecording{},
+	}
+}
+
+func (s *replayStore) add(key string, rec *replayRecording) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.recordings[key]; ok {
+		return
+	}
+	if len(s.keys) >= maxReplayRecordings {
+		delete(s.recordings, s.keys[0])
+		s.keys = s.keys[1:]
+	}
+	s.keys = append(s.keys, key)
+	s.recordings[key] = rec
+}
+
+func (s *replayStore) get(key string) (*replayRecording, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	rec, ok := s.recordings[key]
+	return rec, ok
+}
+
+func (s *Service) handleReplayScenario(ctx context.Context, req *backend.QueryDataRequest) (*backend.QueryDataResponse, error) {
+	resp := backend.NewQueryDataResponse()
+
+	for _, q := range req.Queries {
+		model, err := GetJSONModel(q.JSON)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse query json: %v", err)
+		}
+		if model.Replay == nil || strings.TrimSpace(model.Replay.Content) == "" {
+			continue
+		}
+
+		rec, err := parseReplayRecording(model.Replay)
+		if err != nil {
+			resp.Responses[q.RefID] = backend.ErrDataResponseWithSource(backend.StatusBadRequest, backend.ErrorSourceDownstream, err.Error())
+			continue
+		}
+
+		frames, err := rec.mapToTimeRange(q.TimeRange)
+		if err != nil {
+			resp.Responses[q.RefID] = backend.ErrDataResponseWithSource(backend.StatusBadRequest, backend.ErrorSourceDownstream, err.Error())
+			continue
+		}
+		if model.Replay.Stream && req.PluginContext.DataSourceInstanceSettings != nil {
+			key := replayKey(model.Replay)
+			s.replays.add(key, rec)
+
+			uid := req.PluginContext.DataSourceInstanceSettings.UID
+			for i, frame := range frames {
+				if frame.Meta == nil {
+					frame.Meta = &data.FrameMeta{}
+				}
+				frame.Meta.Channel = fmt.Sprintf("ds/%s/replay/%s/%d", uid, key, i)
+			}
+		}
+
+		respD := resp.Responses[q.RefID]
+		respD.Frames = append(respD.Frames, frames...)
+		resp.Responses[q.RefID] = respD
+	}
+
+	return resp, nil
+}
+
+// replayKey identifies a recording and its playback settings in channel paths.
+func replayKey(q *kinds.ReplayQuery) string {
+	h := sha256.New()
+	_, _ = fmt.Fprintf(h, "%s\n%g\n%t\n", q.Format, q.Speed, q.Loop)
+	_, _ = h.Write([]byte(q.Content))
+	return hex.EncodeToString(h.Sum(nil))[:16]
+}
+
+func parseReplayRecording(q *kinds.ReplayQuery) (*replayRecording, error) {
+	rec := &replayRecording{
+		speed: q.Speed,
+		loop:  q.Loop,
+	}
+	if rec.speed <= 0 {
+		rec.speed = 1
+	}
+
+	scanner := bufio.NewScanner(strings.NewReader(q.Content))
+	scanner.Buffer(make([]byte, 0, 64*1024), maxReplayLineSize)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		frame, err := decodeReplayFrame(q.Format, line)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read frame %d of recording: %w", len(rec.frames), err)
+		}
+		rec.frames = append(rec.frames, frame)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read recording: %w", err)
+	}
+	if len(rec.frames) == 0 {
+		return nil, fmt.Errorf("recording does not contain any frames")
+	}
+
+	maxRows := 0
+	for i, frame := range rec.frames {
+		idx := -1
+		for fidx, f := range frame.Fields {
+			if f.Type() == data.FieldTypeTime || f.Type() == data.FieldTypeNullableTime {
+				idx = fidx
+				break
+			}
+		}
+		if idx < 0 {
+			return nil, fmt.Errorf("frame %d of recording has no time field", i)
+		}
+		rec.timeIdx = append(rec.timeIdx, idx)
+
+		rows := frame.Fields[idx].Len()
+		for row := 0; row < rows; row++ {
+			t, ok := replayTimeAt(frame.Fields[idx], row)
+			if !ok {
+				continue
+			}
+			if rec.start.IsZero() || t.Before(rec.start) {
+				rec.start = t
+			}
+			if t.After(rec.end) {
+				rec.end = t
+			}
+		}
+		maxRows = max(maxRows, rows)
+	}
+	if rec.start.IsZero() {
+		return nil, fmt.Errorf("recording does not contain any timestamps")
+	}
+	if maxRows > 1 {
+		rec.interval = rec.end.Sub(rec.start) / time.Duration(maxRows-1)
+	}
+
+	return rec, nil
+}
+
+func decodeReplayFrame(format kinds.ReplayFormat, line string) (*data.Frame, error) {
+	switch format {
+	case "", kinds.ReplayFormatNDJSON:
+		frame := &data.Frame{}
+		if err := json.Unmarshal([]byte(line), frame); err != nil {
+			return nil, err
+		}
+		return frame, nil
+	case kinds.ReplayFormatArrow:
+		raw, err := base64.StdEncoding.DecodeString(line)
+		if err != nil {
+			return nil, err
+		}
+		return data.UnmarshalArrowFrame(raw)
+	default:
+		return nil, fmt.Errorf("unsupported recording format: %q", format)
+	}
+}
+
+func replayTimeAt(f *data.Field, idx int) (time.Time, bool) {
+	switch v := f.At(idx).(type) {
+	case time.Time:
+		return v, true
+	case *time.Time:
+		if v != nil {
+			return *v, true
+		}
+	}
+	return time.Time{}, false
+}
+
+func setReplayTime(f *data.Field, row []any, idx int, t time.Time) {
+	if f.Type() == data.FieldTypeNullableTime {
+		row[idx] = &t
+		return
+	}
+	row[idx] = t
+}
+
+// scaled converts a duration within the recording to a playback duration.
+func (r *replayRecording) scaled(d time.Duration) time.Duration {
+	return time.Duration(float64(d) / r.speed)
+}
+
+// period is the playback duration of a full pass through the recording.
+func (r *replayRecording) period() time.Duration {
+	return r.scaled(r.end.Sub(r.start) + r.interval)
+}
+
+// passes is the number of times the recording is played within the time range.
+func (r *replayRecording) passes(tr backend.TimeRange) int64 {
+	period := r.period()
+	if !r.loop || period <= 0 || !tr.To.After(tr.From) {
+		return 1
+	}
+	return int64(tr.To.Sub(tr.From)/period) + 1
+}
+
+// mapToTimeRange shifts the recording so it starts at the beginning of the
+// time range, repeating it when looping until the end of the range is reached.
+// It returns an error rather than building the frames when they would hold more than maxReplayRows rows.
+func (r *replayRecording) mapToTimeRange(tr backend.TimeRange) ([]*data.Frame, error) {
+	passes := r.passes(tr)
+	var rows int64
+	for i, frame := range r.frames {
+		rows += int64(frame.Fields[r.timeIdx[i]].Len())
+	}
+	if passes > maxReplayRows || rows*passes > maxReplayRows {
+		return nil, fmt.Errorf("replay would return more than %d rows, shorten the time range, lower the speed or disable looping", maxReplayRows)
+	}
+
+	period := r.period()
+	frames := make([]*data.Frame, 0, len(r.frames))
+
+	for i, frame := range r.frames {
+		timeField := frame.Fields[r.timeIdx[i]]
+		out := frame.EmptyCopy()
+
+		for pass := 0; ; pass++ {
+			offset := tr.From.Add(time.Duration(pass) * period)
+			if offset.After(tr.To) {
+				break
+			}
+			for row := 0; row < timeField.Len(); row++ {
+				t, ok := replayTimeAt(timeField, row)
+				if !ok {
+					continue
+				}
+				mapped := offset.Add(r.scaled(t.Sub(r.start)))
+				if mapped.After(tr.To) {
+					continue
+				}
+				vals := frame.RowCopy(row)
+				setReplayTime(timeField, vals, r.timeIdx[i], mapped)
+				out.AppendRow(vals...)
+			}
+			if !r.loop || period <= 0 {
+				break
+			}
+		}
+
+		frames = append(frames, out)
+	}
+
+	return frames, nil
+}
+
+// getReplayFromPath finds the recording and frame index for a path like replay/<key>/<frame>.
+func (s *Service) getReplayFromPath(path string) (*replayRecording, int, error) {
+	parts := strings.Split(strings.TrimPrefix(path, "replay/"), "/")
+	if len(parts) != 2 {
+		return nil, 0, fmt.Errorf("invalid replay path: %s", path)
+	}
+
+	rec, ok := s.replays.get(parts[0])
+	if !ok {
+		return nil, 0, fmt.Errorf("replay recording not found, run the query again: %s", path)
+	}
+
+	idx, err := strconv.Atoi(parts[1])
+	if err != nil || idx < 0 || idx >= len(rec.frames) {
+		return nil, 0, fmt.Errorf("invalid replay frame in path: %s", path)
+	}
+	return rec, idx, nil
+}
+
+func (s *Service) subscribeReplayStream(req *backend.SubscribeStreamRequest) (*backend.SubscribeStreamResponse, error) {
+	rec, idx, err := s.getReplayFromPath(req.Path)
+	if err != nil {
+		return &backend.SubscribeStreamResponse{
+			Status: backend.SubscribeStreamStatusNotFound,
+		}, nil
+	}
+
+	initialData, err := backend.NewInitialFrame(rec.frames[idx], data.IncludeSchemaOnly)
+	if err != nil {
+		return nil, err
+	}
+
+	return &backend.SubscribeStreamResponse{
+		Status:      backend.SubscribeStreamStatusOK,
+		InitialData: initialData,
+	}, nil
+}
+
+// runReplayStream sends the recorded rows one at a time, keeping the original
+// spacing between rows divided by the playback speed.
+func (s *Service) runReplayStream(ctx context.Context, path string, sender *backend.StreamSender) error {
+	ctxLogger := s.logger.FromContext(ctx)
+	rec, idx, err := s.getReplayFromPath(path)
+	if err != nil {
+		return err
+	}
+
+	frame := rec.frames[idx]
+	timeField := frame.Fields[rec.timeIdx[idx]]
+
+	type replayRow struct {
+		idx int
+		t   time.Time
+	}
+	rows := make([]replayRow, 0, timeField.Len())
+	for i := 0; i < timeField.Len(); i++ {
+		if t, ok := replayTimeAt(timeField, i); ok {
+			rows = append(rows, replayRow{idx: i, t: t})
+		}
+	}
+	slices.SortStableFunc(rows, func(a, b replayRow) int {
+		return a.t.Compare(b.t)
+	})
+
+	out := frame.EmptyCopy()
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+
+	started := time.Now()
+	period := rec.period()
+	for pass := 0; ; pass++ {
+		offset := started.Add(time.Duration(pass) * period)
+		for _, row := range rows {
+			due := offset.Add(rec.scaled(row.t.Sub(rec.start)))
+			timer.Reset(time.Until(due))
+
+			select {
+			case <-ctx.Done():
+				ctxLogger.Debug("Stop replaying data for path", "path", path)
+				return ctx.Err()
+			case <-timer.C:
+			}
+
+			vals := frame.RowCopy(row.idx)
+			setReplayTime(timeField, vals, rec.timeIdx[idx], due)
+			if out.Rows() == 0 {
+				out.AppendRow(vals...)
+			} else {
+				out.SetRow(0, vals...)
+			}
+			if err := sender.SendFrame(out, data.IncludeDataOnly); err != nil {
+				return err
+			}
+		}
+		if !rec.loop || period <= 0 {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package testdatasource
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/tsdb/grafana-testdata-datasource/kinds"
+)
+
+func TestReplayScenario(t *testing.T) {
+	recordedAt := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	recorded := data.NewFrame("cpu",
+		data.NewField("time", nil, []time.Time{
+			recordedAt,
+			recordedAt.Add(10 * time.Second),
+			recordedAt.Add(20 * time.Second),
+		}),
+		data.NewField("value", data.Labels{"host": "a"}, []float64{1, 2, 3}),
+	)
+
+	ndjson, err := json.Marshal(recorded)
+	require.NoError(t, err)
+
+	from := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	runQuery := func(t *testing.T, s *Service, replay kinds.ReplayQuery, to time.Time, pCtx backend.PluginContext) backend.DataResponse {
+		t.Helper()
+		model, err := json.Marshal(kinds.TestDataQuery{
+			ScenarioId: kinds.TestDataQueryTypeReplay,
+			Replay:     &replay,
+		})
+		require.NoError(t, err)
+
+		resp, err := s.handleReplayScenario(context.Background(), &backend.QueryDataRequest{
+			PluginContext: pCtx,
+			Queries: []backend.DataQuery{{
+				RefID:     "A",
+				TimeRange: backend.TimeRange{From: from, To: to},
+				JSON:      model,
+			}},
+		})
+		require.NoError(t, err)
+		return resp.Responses["A"]
+	}
+
+	t.Run("should shift ndjson recording onto the time range", func(t *testing.T) {
+		s := ProvideService()
+		dr := runQuery(t, s, kinds.ReplayQuery{Content: string(ndjson)}, from.Add(time.Hour), backend.PluginContext{})
+		require.NoError(t, dr.Error)
+		require.Len(t, dr.Frames, 1)
+
+		frame := dr.Frames[0]
+		require.Equal(t, 3, frame.Rows())
+		require.Equal(t, from, frame.Fields[0].At(0))
+		require.Equal(t, from.Add(20*time.Second), frame.Fields[0].At(2))
+		require.Equal(t, 3.0, frame.Fields[1].At(2))
+		require.Equal(t, data.Labels{"host": "a"}, frame.Fields[1].Labels)
+	})
+
+	t.Run("should apply speed and loop until the end of the range", func(t *testing.T) {
+		s := ProvideService()
+		dr := runQuery(t, s, kinds.ReplayQuery{Content: string(ndjson), Speed: 2, Loop: true}, from.Add(35*time.Second), backend.PluginContext{})
+		require.NoError(t, dr.Error)
+
+		frame := dr.Frames[0]
+		// one pass takes 15s at double speed, so three passes fit with the last one cut short
+		require.Equal(t, 8, frame.Rows())
+		require.Equal(t, from.Add(10*time.Second), frame.Fields[0].At(2))
+		require.Equal(t, from.Add(15*time.Second), frame.Fields[0].At(3))
+		require.Equal(t, 1.0, frame.Fields[1].At(3))
+	})
+
+	t.Run("should return an error when the replay would return too many rows", func(t *testing.T) {
+		s := ProvideService()
+		// one pass takes 30s, so looping over a year would return about 3M rows
+		dr := runQuery(t, s, kinds.ReplayQuery{Content: string(ndjson), Loop: true}, from.AddDate(1, 0, 0), backend.PluginContext{})
+		require.ErrorContains(t, dr.Error, "more than 1000000 rows")
+		require.Equal(t, backend.ErrorSourceDownstream, dr.ErrorSource)
+		require.Empty(t, dr.Frames)
+	})
+
+	t.Run("should read base64 encoded arrow recordings", func(t *testing.T) {
+		s := ProvideService()
+		raw, err := recorded.MarshalArrow()
+		require.NoError(t, err)
+		content := base64.StdEncoding.EncodeToString(raw) + "\n" + base64.StdEncoding.EncodeToString(raw)
+
+		dr := runQuery(t, s, kinds.ReplayQuery{Format: kinds.ReplayFormatArrow, Content: content}, from.Add(time.Hour), backend.PluginContext{})
+		require.NoError(t, dr.Error)
+		require.Len(t, dr.Frames, 2)
+		require.Equal(t, from.Add(10*time.Second), dr.Frames[1].Fields[0].At(1))
+	})
+
+	t.Run("should return an error for frames without time", func(t *testing.T) {
+		s := ProvideService()
+		frame, err := json.Marshal(data.NewFrame("", data.NewField("value", nil, []float64{1})))
+		require.NoError(t, err)
+
+		dr := runQuery(t, s, kinds.ReplayQuery{Content: string(frame)}, from.Add(time.Hour), backend.PluginContext{})
+		require.ErrorContains(t, dr.Error, "no time field")
+		require.Equal(t, backend.ErrorSourceDownstream, dr.ErrorSource)
+	})
+
+	t.Run("should stream recording over a live channel", func(t *testing.T) {
+		s := ProvideService()
+		pCtx := backend.PluginContext{
+			DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{UID: "testdata"},
+		}
+		dr := runQuery(t, s, kinds.ReplayQuery{Content: string(ndjson), Speed: 1000, Stream: true}, from.Add(time.Hour), pCtx)
+		require.NoError(t, dr.Error)
+
+		channel := dr.Frames[0].Meta.Channel
+		require.True(t, strings.HasPrefix(channel, "ds/testdata/replay/"))
+		path := strings.TrimPrefix(channel, "ds/testdata/")
+
+		sub, err := s.SubscribeStream(context.Background(), &backend.SubscribeStreamRequest{Path: path})
+		require.NoError(t, err)
+		require.Equal(t, backend.SubscribeStreamStatusOK, sub.Status)
+
+		sender := &replayTestSender{}
+		err = s.RunStream(context.Background(), &backend.RunStreamRequest{Path: path}, backend.NewStreamSender(sender))
+		require.NoError(t, err)
+		require.Len(t, sender.frames, 3)
+
+		sub, err = s.SubscribeStream(context.Background(), &backend.SubscribeStreamRequest{Path: "replay/unknown/0"})
+		require.NoError(t, err)
+		require.Equal(t, backend.SubscribeStreamStatusNotFound, sub.Status)
+	})
+}
+
+type replayTestSender struct {
+	frames []json.RawMessage
+}
+
+func (s *replayTestSender) Send(packet *backend.StreamPacket) error {
+	s.frames = append(s.frames, packet.Data)
+	return nil
+}
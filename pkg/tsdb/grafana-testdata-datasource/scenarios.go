@@ -205,6 +205,14 @@ Timestamps will line up evenly on timeStepSeconds (For example, 60 seconds means
 		Name: "Trace",
 	})
 
+	s.registerScenario(&Scenario{
+		ID:      kinds.TestDataQueryTypeReplay,
+		Name:    "Replay recording",
+		handler: s.handleReplayScenario,
+		Description: `Replays a recording of data frames (NDJSON or base64 encoded Arrow, one frame per line) shifted onto the query time range.
+Enable streaming to keep playing the recording over a live channel at the configured speed.`,
+	})
+
 	s.registerScenario(&Scenario{
 		ID:      kinds.TestDataQueryTypeErrorWithSource,
 		Name:    "Error with source",
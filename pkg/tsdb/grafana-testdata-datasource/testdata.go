@@ -32,7 +32,8 @@ func ProvideService() *Service {
 			data.NewField("Time", nil, make([]time.Time, 1)),
 			data.NewField("Value", nil, make([]float64, 1)),
 		),
-		logger: backend.NewLoggerWith("logger", "tsdb.testdata"),
+		replays: newReplayStore(),
+		logger:  backend.NewLoggerWith("logger", "tsdb.testdata"),
 	}
 
 	var err error
@@ -62,6 +63,7 @@ type Service struct {
 	queryMux        *datasource.QueryTypeMux
 	resourceHandler backend.CallResourceHandler
 	sims            *sims.SimulationEngine
+	replays         *replayStore
 }
 
 func (s *Service) QueryData(ctx context.Context, req *backend.QueryDataRequest) (*backend.QueryDataResponse, error) {
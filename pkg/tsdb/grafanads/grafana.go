@@ -6,12 +6,14 @@ import (
 	"encoding/json"
 	"fmt"
 	"path/filepath"
+	"strings"
 
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
 
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
 	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/grafana/grafana-plugin-sdk-go/live"
 	"github.com/grafana/grafana/pkg/components/simplejson"
 	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/services/datasources"
@@ -69,10 +71,22 @@ func newService(search searchV2.SearchService, store store.StorageService, featu
 
 // Service exists regardless of user settings
 type Service struct {
-	search   searchV2.SearchService
-	store    store.StorageService
-	log      log.Logger
-	features featuremgmt.FeatureToggles
+	search      searchV2.SearchService
+	store       store.StorageService
+	log         log.Logger
+	features    featuremgmt.FeatureToggles
+	liveHistory LiveHistory
+}
+
+// LiveHistory reads the history kept for Grafana Live managed streams.
+type LiveHistory interface {
+	GetHistory(ctx context.Context, orgID int64, channel string, tr backend.TimeRange) (*data.Frame, bool, error)
+}
+
+// SetLiveHistory is called by Grafana Live once the managed streams are set up,
+// since Live itself depends on the query service that uses this data source.
+func (s *Service) SetLiveHistory(h LiveHistory) {
+	s.liveHistory = h
 }
 
 func DataSourceModel(orgId int64) *datasources.DataSource {
@@ -100,6 +114,8 @@ func (s *Service) QueryData(ctx context.Context, req *backend.QueryDataRequest)
 			response.Responses[q.RefID] = s.doReadQuery(ctx, q)
 		case queryTypeSearch, queryTypeSearchNext:
 			response.Responses[q.RefID] = s.doSearchQuery(ctx, req, q)
+		case queryTypeLiveHistory:
+			response.Responses[q.RefID] = s.doLiveHistoryQuery(ctx, req, q)
 		default:
 			response.Responses[q.RefID] = backend.DataResponse{
 				Error: fmt.Errorf("unknown query type"),
@@ -206,6 +222,42 @@ func (s *Service) doSearchQuery(ctx context.Context, req *backend.QueryDataReque
 	return *s.search.DoDashboardQuery(ctx, req.PluginContext.User, req.PluginContext.OrgID, m.Search)
 }
 
+func (s *Service) doLiveHistoryQuery(ctx context.Context, req *backend.QueryDataRequest, query backend.DataQuery) backend.DataResponse {
+	q := &liveHistoryQueryModel{}
+	if err := json.Unmarshal(query.JSON, q); err != nil {
+		return backend.DataResponse{
+			Error: err,
+		}
+	}
+
+	if s.liveHistory == nil {
+		return backend.DataResponse{
+			Error: fmt.Errorf("live is not available"),
+		}
+	}
+
+	// Only stream channels are readable by every user in the org, the other
+	// scopes are checked by their data source or plugin on subscribe.
+	if !strings.HasPrefix(q.Channel, live.ScopeStream+"/") {
+		return backend.DataResponse{
+			Error: fmt.Errorf("history is only available for %s channels", live.ScopeStream),
+		}
+	}
+
+	frame, ok, err := s.liveHistory.GetHistory(ctx, req.PluginContext.OrgID, q.Channel, query.TimeRange)
+	if err != nil {
+		return backend.DataResponse{
+			Error: err,
+		}
+	}
+	if !ok {
+		return backend.DataResponse{}
+	}
+	return backend.DataResponse{
+		Frames: data.Frames{frame},
+	}
+}
+
 type requestModel struct {
 	QueryType string                  `json:"queryType"`
 	Search    searchV2.DashboardQuery `json:"search,omitempty"`
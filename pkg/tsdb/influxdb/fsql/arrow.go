@@ -18,11 +18,6 @@ import (
 	"google.golang.org/grpc/metadata"
 )
 
-// TODO: Make this configurable. This is an arbitrary value right
-// now. Grafana used to have a 1M row rowLimit established in open-source. I'll
-// let users hit that for now until we decide how to proceed.
-const rowLimit = 1_000_000
-
 type recordReader interface {
 	Next() bool
 	Schema() *arrow.Schema
@@ -31,14 +26,21 @@ type recordReader interface {
 }
 
 // newQueryDataResponse builds a [backend.DataResponse] from a stream of
-// [arrow.Record]s.
+// [arrow.Record]s, reading at most rowLimit rows.
 //
-// The backend.DataResponse contains a single [data.Frame].
-func newQueryDataResponse(reader recordReader, query sqlutil.Query, headers metadata.MD) backend.DataResponse {
+// The backend.DataResponse contains a single [data.Frame]. If the stream fails
+// part way through, the rows read so far are returned along with the error.
+func newQueryDataResponse(reader recordReader, query sqlutil.Query, headers metadata.MD, rowLimit int64) backend.DataResponse {
 	var resp backend.DataResponse
-	frame, err := frameForRecords(reader)
+	frame, err := frameForRecords(reader, rowLimit)
 	if err != nil {
 		resp.Error = err
+		if frame.Rows() > 0 {
+			frame.AppendNotices(data.Notice{
+				Severity: data.NoticeSeverityWarning,
+				Text:     fmt.Sprintf("Partial results: reading the query results failed after %d rows", frame.Rows()),
+			})
+		}
 	}
 	if frame.Rows() == 0 {
 		resp.Frames = data.Frames{}
@@ -66,6 +68,15 @@ func newQueryDataResponse(reader recordReader, query sqlutil.Query, headers meta
 				return resp
 			}
 		}
+
+		if query.FillMissing != nil {
+			var err error
+			frame, err = sqlutil.ResampleWideFrame(frame, query.FillMissing, query.TimeRange, query.Interval)
+			if err != nil {
+				resp.Error = err
+				return resp
+			}
+		}
 	case sqlutil.FormatOptionTable:
 		// No changes to the output. Send it as is.
 	case sqlutil.FormatOptionLogs:
@@ -80,21 +91,32 @@ func newQueryDataResponse(reader recordReader, query sqlutil.Query, headers meta
 }
 
 // frameForRecords creates a [data.Frame] from a stream of [arrow.Record]s.
-func frameForRecords(reader recordReader) (*data.Frame, error) {
+//
+// Records are converted one at a time as they arrive so only the resulting
+// frame is kept in memory. Once rowLimit rows have been read the remaining
+// records are not requested.
+func frameForRecords(reader recordReader, rowLimit int64) (*data.Frame, error) {
 	var (
 		frame = newFrame(reader.Schema())
 		rows  int64
 	)
 	for reader.Next() {
 		record := reader.Record()
-		for i, col := range record.Columns() {
-			if err := copyData(frame.Fields[i], col); err != nil {
-				return frame, err
-			}
+		limited := rowLimit > 0 && rows+record.NumRows() > rowLimit
+		if limited {
+			record = record.NewSlice(0, rowLimit-rows)
 		}
 
+		err := copyRecord(frame, record)
 		rows += record.NumRows()
-		if rows > rowLimit {
+		if limited {
+			record.Release()
+		}
+		if err != nil {
+			return frame, err
+		}
+
+		if limited {
 			frame.AppendNotices(data.Notice{
 				Severity: data.NoticeSeverityWarning,
 				Text:     fmt.Sprintf("Results have been limited to %v because the SQL row limit was reached", rowLimit),
@@ -106,9 +128,21 @@ func frameForRecords(reader recordReader) (*data.Frame, error) {
 			return frame, err
 		}
 	}
+	if err := reader.Err(); err != nil && !errors.Is(err, io.EOF) {
+		return frame, err
+	}
 	return frame, nil
 }
 
+func copyRecord(frame *data.Frame, record arrow.Record) error {
+	for i, col := range record.Columns() {
+		if err := copyData(frame.Fields[i], col); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // newFrame builds a new Data Frame from an Arrow Schema.
 func newFrame(schema *arrow.Schema) *data.Frame {
 	fields := schema.Fields()
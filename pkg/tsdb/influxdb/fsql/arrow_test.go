@@ -11,6 +11,7 @@ import (
 	"github.com/apache/arrow-go/v18/arrow/array"
 	"github.com/apache/arrow-go/v18/arrow/memory"
 	"github.com/google/go-cmp/cmp"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
 	"github.com/grafana/grafana-plugin-sdk-go/data"
 	"github.com/grafana/grafana-plugin-sdk-go/data/sqlutil"
 	"github.com/stretchr/testify/assert"
@@ -83,7 +84,7 @@ func TestNewQueryDataResponse(t *testing.T) {
 	assert.NoError(t, err)
 
 	query := sqlutil.Query{Format: sqlutil.FormatOptionTable}
-	resp := newQueryDataResponse(errReader{RecordReader: reader}, query, metadata.MD{})
+	resp := newQueryDataResponse(errReader{RecordReader: reader}, query, metadata.MD{}, 1_000_000)
 	assert.NoError(t, resp.Error)
 	assert.Len(t, resp.Frames, 1)
 	assert.Len(t, resp.Frames[0].Fields, 14)
@@ -212,11 +213,93 @@ func TestNewQueryDataResponse_Error(t *testing.T) {
 		err:          fmt.Errorf("explosion!"),
 	}
 	query := sqlutil.Query{Format: sqlutil.FormatOptionTable}
-	resp := newQueryDataResponse(wrappedReader, query, metadata.MD{})
+	resp := newQueryDataResponse(wrappedReader, query, metadata.MD{}, 1_000_000)
 	assert.Error(t, resp.Error)
 	assert.Equal(t, fmt.Errorf("explosion!"), resp.Error)
 }
 
+func TestNewQueryDataResponse_RowLimit(t *testing.T) {
+	alloc := memory.DefaultAllocator
+	schema := arrow.NewSchema([]arrow.Field{{Name: "i64", Type: arrow.PrimitiveTypes.Int64}}, nil)
+
+	first, _, err := array.FromJSON(alloc, arrow.PrimitiveTypes.Int64, strings.NewReader(`[1, 2, 3]`))
+	assert.NoError(t, err)
+	second, _, err := array.FromJSON(alloc, arrow.PrimitiveTypes.Int64, strings.NewReader(`[4, 5, 6]`))
+	assert.NoError(t, err)
+
+	records := []arrow.Record{
+		array.NewRecord(schema, []arrow.Array{first}, -1),
+		array.NewRecord(schema, []arrow.Array{second}, -1),
+	}
+	reader, err := array.NewRecordReader(schema, records)
+	assert.NoError(t, err)
+
+	query := sqlutil.Query{Format: sqlutil.FormatOptionTable}
+	resp := newQueryDataResponse(errReader{RecordReader: reader}, query, metadata.MD{}, 4)
+	assert.NoError(t, resp.Error)
+	assert.Len(t, resp.Frames, 1)
+	assert.Equal(t, 4, resp.Frames[0].Rows())
+	assert.Equal(t, int64(4), resp.Frames[0].Fields[0].At(3))
+	assert.Len(t, resp.Frames[0].Meta.Notices, 1)
+	assert.Equal(t, data.NoticeSeverityWarning, resp.Frames[0].Meta.Notices[0].Severity)
+}
+
+func TestNewQueryDataResponse_PartialResults(t *testing.T) {
+	alloc := memory.DefaultAllocator
+	schema := arrow.NewSchema([]arrow.Field{{Name: "i64", Type: arrow.PrimitiveTypes.Int64}}, nil)
+
+	i64s, _, err := array.FromJSON(alloc, arrow.PrimitiveTypes.Int64, strings.NewReader(`[1, 2, 3]`))
+	assert.NoError(t, err)
+	reader, err := array.NewRecordReader(schema, []arrow.Record{array.NewRecord(schema, []arrow.Array{i64s}, -1)})
+	assert.NoError(t, err)
+
+	// the stream fails after the first batch, the rows read so far are still returned
+	wrappedReader := &failingReader{RecordReader: reader, err: fmt.Errorf("stream reset")}
+	query := sqlutil.Query{Format: sqlutil.FormatOptionTable}
+	resp := newQueryDataResponse(wrappedReader, query, metadata.MD{}, 1_000_000)
+	assert.Equal(t, fmt.Errorf("stream reset"), resp.Error)
+	assert.Len(t, resp.Frames, 1)
+	assert.Equal(t, 3, resp.Frames[0].Rows())
+	assert.Len(t, resp.Frames[0].Meta.Notices, 1)
+}
+
+func TestNewQueryDataResponse_FillMissing(t *testing.T) {
+	alloc := memory.DefaultAllocator
+	schema := arrow.NewSchema(
+		[]arrow.Field{
+			{Name: "time", Type: &arrow.TimestampType{Unit: arrow.Nanosecond}},
+			{Name: "value", Type: arrow.PrimitiveTypes.Float64},
+		},
+		nil,
+	)
+
+	times, _, err := array.FromJSON(
+		alloc,
+		&arrow.TimestampType{Unit: arrow.Nanosecond},
+		strings.NewReader(`["2023-01-01T00:00:00Z", "2023-01-01T00:00:30Z"]`),
+	)
+	assert.NoError(t, err)
+	values, _, err := array.FromJSON(alloc, arrow.PrimitiveTypes.Float64, strings.NewReader(`[1, 2]`))
+	assert.NoError(t, err)
+
+	reader, err := array.NewRecordReader(schema, []arrow.Record{array.NewRecord(schema, []arrow.Array{times, values}, -1)})
+	assert.NoError(t, err)
+
+	from := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	query := sqlutil.Query{
+		Format:      sqlutil.FormatOptionTimeSeries,
+		TimeRange:   backend.TimeRange{From: from, To: from.Add(30 * time.Second)},
+		Interval:    10 * time.Second,
+		FillMissing: &data.FillMissing{Mode: data.FillModeValue, Value: 0},
+	}
+	resp := newQueryDataResponse(errReader{RecordReader: reader}, query, metadata.MD{}, 1_000_000)
+	assert.NoError(t, resp.Error)
+	assert.Len(t, resp.Frames, 1)
+	assert.Equal(t, 4, resp.Frames[0].Rows())
+	assert.Equal(t, 0.0, resp.Frames[0].Fields[1].At(1))
+	assert.Equal(t, 2.0, resp.Frames[0].Fields[1].At(3))
+}
+
 func TestNewQueryDataResponse_WideTable(t *testing.T) {
 	alloc := memory.DefaultAllocator
 	schema := arrow.NewSchema(
@@ -252,7 +335,7 @@ func TestNewQueryDataResponse_WideTable(t *testing.T) {
 	reader, err := array.NewRecordReader(schema, records)
 	assert.NoError(t, err)
 
-	resp := newQueryDataResponse(errReader{RecordReader: reader}, sqlutil.Query{}, metadata.MD{})
+	resp := newQueryDataResponse(errReader{RecordReader: reader}, sqlutil.Query{}, metadata.MD{}, 1_000_000)
 	assert.NoError(t, resp.Error)
 	assert.Len(t, resp.Frames, 1)
 	assert.Equal(t, 3, resp.Frames[0].Rows())
@@ -296,6 +379,28 @@ func (r errReader) Err() error {
 	return r.err
 }
 
+// failingReader reports err once all of its records have been read.
+type failingReader struct {
+	array.RecordReader
+	err  error
+	done bool
+}
+
+func (r *failingReader) Next() bool {
+	if !r.RecordReader.Next() {
+		r.done = true
+		return false
+	}
+	return true
+}
+
+func (r *failingReader) Err() error {
+	if r.done {
+		return r.err
+	}
+	return nil
+}
+
 func TestNewFrame(t *testing.T) {
 	schema := arrow.NewSchema([]arrow.Field{
 		{
@@ -533,7 +638,7 @@ func TestCustomMetadata(t *testing.T) {
 	query := sqlutil.Query{
 		Format: sqlutil.FormatOptionTable,
 	}
-	resp := newQueryDataResponse(errReader{RecordReader: reader}, query, md)
+	resp := newQueryDataResponse(errReader{RecordReader: reader}, query, md, 1_000_000)
 	assert.NoError(t, resp.Error)
 
 	assert.Equal(t, map[string]any{
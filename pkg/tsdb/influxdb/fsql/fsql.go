@@ -90,7 +90,7 @@ func Query(ctx context.Context, dsInfo *models.DatasourceInfo, req backend.Query
 			logger.Error(fmt.Sprintf("Failed to extract headers: %s", err))
 		}
 
-		tRes.Responses[q.RefID] = newQueryDataResponse(reader, *qm.Query, headers)
+		tRes.Responses[q.RefID] = newQueryDataResponse(reader, *qm.Query, headers, dsInfo.RowLimit)
 	}
 
 	return tRes, nil
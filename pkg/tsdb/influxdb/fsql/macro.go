@@ -2,34 +2,116 @@ package fsql
 
 import (
 	"fmt"
+	"strconv"
 	"time"
 
+	"github.com/grafana/grafana-plugin-sdk-go/backend/gtime"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
 	"github.com/grafana/grafana-plugin-sdk-go/data/sqlutil"
 )
 
-var macros = sqlutil.Macros{
-	"dateBin":        macroDateBin(""),
-	"dateBinAlias":   macroDateBin("_binned"),
-	"interval":       macroInterval,
-	"timeGroup":      macroTimeGroup,
-	"timeGroupAlias": macroTimeGroupAlias,
-
-	// The behaviors of timeFrom and timeTo as defined in the SDK are different
-	// from all other Grafana SQL plugins. Instead we'll take the implementations,
-	// rename them and define timeFrom and timeTo ourselves.
-	"timeTo":   macroTo,
-	"timeFrom": macroFrom,
+// macroOptions collects options set by macros that change how the results of
+// a query are processed, such as the fill mode of $__timeGroup.
+type macroOptions struct {
+	fillMissing  *data.FillMissing
+	fillInterval time.Duration
 }
 
-func macroTimeGroup(query *sqlutil.Query, args []string) (string, error) {
-	if len(args) != 2 {
-		return "", fmt.Errorf("%w: expected 1 argument, received %d", sqlutil.ErrorBadArgumentCount, len(args))
+// newMacros returns the macros for a single query, writing any result options to opts.
+func newMacros(opts *macroOptions) sqlutil.Macros {
+	return sqlutil.Macros{
+		"dateBin":        macroDateBin(""),
+		"dateBinAlias":   macroDateBin("_binned"),
+		"interval":       macroInterval,
+		"timeGroup":      macroTimeGroup(opts, ""),
+		"timeGroupAlias": macroTimeGroup(opts, "time"),
+
+		// The behaviors of timeFrom and timeTo as defined in the SDK are different
+		// from all other Grafana SQL plugins. Instead we'll take the implementations,
+		// rename them and define timeFrom and timeTo ourselves.
+		"timeTo":   macroTo,
+		"timeFrom": macroFrom,
+
+		"unixEpochFilter":     macroUnixEpochFilter(time.Second),
+		"unixEpochNanoFilter": macroUnixEpochFilter(time.Nanosecond),
 	}
+}
+
+// macroTimeGroup groups by time. The period is either an interval such as 5m or $__interval,
+// optionally followed by a fill mode (NULL, previous or a value), or one of minute, hour, day,
+// month and year to group by date parts.
+func macroTimeGroup(opts *macroOptions, alias string) sqlutil.MacroFunc {
+	return func(query *sqlutil.Query, args []string) (string, error) {
+		if len(args) < 2 || len(args) > 3 {
+			return "", fmt.Errorf("%w: expected 2 or 3 arguments, received %d", sqlutil.ErrorBadArgumentCount, len(args))
+		}
+
+		column := args[0]
+		switch args[1] {
+		case "minute", "hour", "day", "month", "year":
+			if len(args) == 3 {
+				return "", fmt.Errorf("fill is not supported when grouping by %s", args[1])
+			}
+			if alias != "" {
+				return timeGroupDatePartAlias(column, args[1]), nil
+			}
+			return timeGroupDatePart(column, args[1]), nil
+		}
+
+		interval, err := parseTimeGroupInterval(query, args[1])
+		if err != nil {
+			return "", err
+		}
 
-	column := args[0]
+		if len(args) == 3 {
+			fill, err := parseFillMode(args[2])
+			if err != nil {
+				return "", err
+			}
+			opts.fillMissing = fill
+			opts.fillInterval = interval
+		}
+
+		res := fmt.Sprintf("date_bin(%s, %s, timestamp '1970-01-01T00:00:00Z')", sqlInterval(interval), column)
+		if alias != "" {
+			res += " as " + alias
+		}
+		return res, nil
+	}
+}
 
+func parseTimeGroupInterval(query *sqlutil.Query, arg string) (time.Duration, error) {
+	if arg == "$__interval" {
+		return query.Interval, nil
+	}
+	interval, err := gtime.ParseInterval(arg)
+	if err != nil {
+		return 0, fmt.Errorf("error parsing interval %q: %w", arg, err)
+	}
+	if interval <= 0 {
+		return 0, fmt.Errorf("interval must be positive, received %q", arg)
+	}
+	return interval, nil
+}
+
+func parseFillMode(arg string) (*data.FillMissing, error) {
+	switch arg {
+	case "NULL":
+		return &data.FillMissing{Mode: data.FillModeNull}, nil
+	case "previous":
+		return &data.FillMissing{Mode: data.FillModePrevious}, nil
+	default:
+		v, err := strconv.ParseFloat(arg, 64)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing fill value %v", arg)
+		}
+		return &data.FillMissing{Mode: data.FillModeValue, Value: v}, nil
+	}
+}
+
+func timeGroupDatePart(column, period string) string {
 	res := ""
-	switch args[1] {
+	switch period {
 	case "minute":
 		res += fmt.Sprintf("datepart('minute', %s),", column)
 		fallthrough
@@ -46,18 +128,12 @@ func macroTimeGroup(query *sqlutil.Query, args []string) (string, error) {
 		res += fmt.Sprintf("datepart('year', %s)", column)
 	}
 
-	return res, nil
+	return res
 }
 
-func macroTimeGroupAlias(query *sqlutil.Query, args []string) (string, error) {
-	if len(args) != 2 {
-		return "", fmt.Errorf("%w: expected 1 argument, received %d", sqlutil.ErrorBadArgumentCount, len(args))
-	}
-
-	column := args[0]
-
+func timeGroupDatePartAlias(column, period string) string {
 	res := ""
-	switch args[1] {
+	switch period {
 	case "minute":
 		res += fmt.Sprintf("datepart('minute', %s) as %s_minute,", column, column)
 		fallthrough
@@ -74,13 +150,33 @@ func macroTimeGroupAlias(query *sqlutil.Query, args []string) (string, error) {
 		res += fmt.Sprintf("datepart('year', %s) as %s_year", column, column)
 	}
 
-	return res, nil
+	return res
+}
+
+// sqlInterval formats a duration as an SQL interval, using milliseconds for sub-second precision.
+func sqlInterval(d time.Duration) string {
+	if d%time.Second != 0 {
+		return fmt.Sprintf("interval '%d millisecond'", d.Milliseconds())
+	}
+	return fmt.Sprintf("interval '%d second'", int64(d.Seconds()))
 }
 
 func macroInterval(query *sqlutil.Query, _ []string) (string, error) {
 	return fmt.Sprintf("interval '%d second'", int64(query.Interval.Seconds())), nil
 }
 
+// macroUnixEpochFilter filters a column holding unix timestamps in the given unit.
+func macroUnixEpochFilter(unit time.Duration) sqlutil.MacroFunc {
+	return func(query *sqlutil.Query, args []string) (string, error) {
+		if len(args) != 1 {
+			return "", fmt.Errorf("%w: expected 1 argument, received %d", sqlutil.ErrorBadArgumentCount, len(args))
+		}
+		from := query.TimeRange.From.UnixNano() / int64(unit)
+		to := query.TimeRange.To.UnixNano() / int64(unit)
+		return fmt.Sprintf("%s >= %d AND %s <= %d", args[0], from, args[0], to), nil
+	}
+}
+
 // https://docs.influxdata.com/influxdb/cloud-serverless/query-data/sql/cast-types/?t=CAST%28%29#cast-to-a-timestamp-type
 func macroFrom(query *sqlutil.Query, _ []string) (string, error) {
 	return fmt.Sprintf("cast('%s' as timestamp)", query.TimeRange.From.Format(time.RFC3339)), nil
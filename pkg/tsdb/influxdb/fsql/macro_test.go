@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
 	"github.com/grafana/grafana-plugin-sdk-go/data/sqlutil"
 	"github.com/stretchr/testify/require"
 )
@@ -52,12 +53,94 @@ func TestMacros(t *testing.T) {
 			in:  `select * from x where time < $__timeTo`,
 			out: `select * from x where time < cast('2023-01-01T00:10:00Z' as timestamp)`,
 		},
+		{
+			in:  `select $__timeGroup(time, hour)`,
+			out: `select datepart('hour', time),datepart('day', time),datepart('month', time),datepart('year', time)`,
+		},
+		{
+			in:  `select $__timeGroupAlias(time, day)`,
+			out: `select datepart('day', time) as time_day,datepart('month', time) as time_month,datepart('year', time) as time_year`,
+		},
+		{
+			in:  `select $__timeGroup(time, $__interval)`,
+			out: `select date_bin(interval '10 second', time, timestamp '1970-01-01T00:00:00Z')`,
+		},
+		{
+			in:  `select $__timeGroupAlias(time, 5m)`,
+			out: `select date_bin(interval '300 second', time, timestamp '1970-01-01T00:00:00Z') as time`,
+		},
+		{
+			in:  `select $__timeGroup(time, 500ms)`,
+			out: `select date_bin(interval '500 millisecond', time, timestamp '1970-01-01T00:00:00Z')`,
+		},
+		{
+			in:  `select $__interval_ms`,
+			out: `select 10000`,
+		},
+		{
+			in:  `select * from x where $__unixEpochFilter(ts)`,
+			out: `select * from x where ts >= 1672531200 AND ts <= 1672531800`,
+		},
+		{
+			in:  `select * from x where $__unixEpochNanoFilter(ts)`,
+			out: `select * from x where ts >= 1672531200000000000 AND ts <= 1672531800000000000`,
+		},
 	}
 	for _, c := range cs {
 		t.Run(c.in, func(t *testing.T) {
-			sql, err := sqlutil.Interpolate(query.WithSQL(c.in), macros)
+			var opts macroOptions
+			sql, err := sqlutil.Interpolate(query.WithSQL(c.in), newMacros(&opts))
 			require.NoError(t, err)
 			require.Equal(t, c.out, sql)
+			require.Nil(t, opts.fillMissing)
 		})
 	}
 }
+
+func TestMacroTimeGroupFill(t *testing.T) {
+	query := sqlutil.Query{Interval: 10 * time.Second}
+
+	cs := []struct {
+		in       string
+		fill     *data.FillMissing
+		interval time.Duration
+	}{
+		{
+			in:       `select $__timeGroup(time, $__interval, NULL)`,
+			fill:     &data.FillMissing{Mode: data.FillModeNull},
+			interval: 10 * time.Second,
+		},
+		{
+			in:       `select $__timeGroupAlias(time, 1m, previous)`,
+			fill:     &data.FillMissing{Mode: data.FillModePrevious},
+			interval: time.Minute,
+		},
+		{
+			in:       `select $__timeGroup(time, 5m, 1.5)`,
+			fill:     &data.FillMissing{Mode: data.FillModeValue, Value: 1.5},
+			interval: 5 * time.Minute,
+		},
+	}
+	for _, c := range cs {
+		t.Run(c.in, func(t *testing.T) {
+			var opts macroOptions
+			_, err := sqlutil.Interpolate(query.WithSQL(c.in), newMacros(&opts))
+			require.NoError(t, err)
+			require.Equal(t, c.fill, opts.fillMissing)
+			require.Equal(t, c.interval, opts.fillInterval)
+		})
+	}
+
+	t.Run("invalid arguments", func(t *testing.T) {
+		for _, in := range []string{
+			`select $__timeGroup(time)`,
+			`select $__timeGroup(time, 5m, foo)`,
+			`select $__timeGroup(time, hour, NULL)`,
+			`select $__timeGroup(time, nope)`,
+		} {
+			var opts macroOptions
+			_, err := sqlutil.Interpolate(query.WithSQL(in), newMacros(&opts))
+			require.Error(t, err, in)
+		}
+	})
+}
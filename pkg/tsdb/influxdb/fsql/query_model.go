@@ -50,11 +50,18 @@ func getQueryModel(dataQuery backend.DataQuery) (*queryModel, error) {
 
 	// Process macros and generate raw fsql to be sent to
 	// influxdb backend for execution.
-	sql, err := sqlutil.Interpolate(query, macros)
+	var opts macroOptions
+	sql, err := sqlutil.Interpolate(query, newMacros(&opts))
 	if err != nil {
 		return nil, fmt.Errorf("macro interpolation: %w", err)
 	}
 	query.RawSQL = sql
 
+	// $__timeGroup with a fill mode resamples the results to the grouping interval.
+	if opts.fillMissing != nil {
+		query.FillMissing = opts.fillMissing
+		query.Interval = opts.fillInterval
+	}
+
 	return &queryModel{query}, nil
 }
@@ -59,6 +59,11 @@ func newInstanceSettings(httpClientProvider httpclient.Provider) datasource.Inst
 			maxSeries = 1000
 		}
 
+		rowLimit := jsonData.RowLimit
+		if rowLimit <= 0 {
+			rowLimit = 1_000_000
+		}
+
 		version := jsonData.Version
 		if version == "" {
 			version = influxVersionInfluxQL
@@ -86,6 +91,7 @@ func newInstanceSettings(httpClientProvider httpclient.Provider) datasource.Inst
 			Organization:  jsonData.Organization,
 			MaxSeries:     maxSeries,
 			InsecureGrpc:  jsonData.InsecureGrpc,
+			RowLimit:      rowLimit,
 			Token:         settings.DecryptedSecureJSONData["token"],
 			Timeout:       opts.Timeouts.Timeout,
 			ProxyClient:   proxyClient,
@@ -25,6 +25,8 @@ type DatasourceInfo struct {
 
 	// FlightSQL grpc connection
 	InsecureGrpc bool `json:"insecureGrpc"`
+	// RowLimit is the maximum number of rows read from a FlightSQL query
+	RowLimit int64 `json:"rowLimit"`
 
 	TLSConfig *httpclient.TLSOptions
 
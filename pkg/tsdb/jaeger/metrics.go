@@ -0,0 +1,224 @@
+package jaeger
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+const (
+	metricSpanRate         = "spanRate"
+	metricErrorRate        = "errorRate"
+	metricDurationQuantile = "durationQuantile"
+
+	groupByService   = "service"
+	groupByOperation = "operation"
+
+	// defaultMetricsLimit is the number of traces searched for a metrics query
+	// when the query does not set a limit. Jaeger only returns 20 by default.
+	defaultMetricsLimit = 1000
+	defaultQuantile     = 0.95
+	// maxMetricsPoints bounds the number of buckets of each series
+	maxMetricsPoints = 100
+)
+
+// metricsSearchQuery returns the search to run for a metrics query.
+func metricsSearchQuery(query JaegerQuery) JaegerQuery {
+	if query.Limit <= 0 {
+		query.Limit = defaultMetricsLimit
+	}
+	return query
+}
+
+func validateMetricsQuery(query JaegerQuery) error {
+	switch query.Metric {
+	case metricSpanRate, metricErrorRate, metricDurationQuantile:
+	default:
+		return fmt.Errorf("unsupported metric %q, expected one of %s, %s or %s", query.Metric, metricSpanRate, metricErrorRate, metricDurationQuantile)
+	}
+	if query.Quantile < 0 || query.Quantile > 1 {
+		return fmt.Errorf("quantile must be between 0 and 1, received %v", query.Quantile)
+	}
+	for _, g := range query.GroupBy {
+		if g != groupByService && g != groupByOperation {
+			return fmt.Errorf("unsupported group by %q, expected %s or %s", g, groupByService, groupByOperation)
+		}
+	}
+	return nil
+}
+
+// metricsStep returns the bucket size used to aggregate the spans. It is the
+// query interval, raised when needed so that the time range fits in maxMetricsPoints buckets.
+func metricsStep(q backend.DataQuery) time.Duration {
+	// aligning the start of the range on the step adds up to two buckets
+	minStep := q.TimeRange.Duration() / (maxMetricsPoints - 2)
+	if minStep%time.Second != 0 {
+		minStep = minStep.Truncate(time.Second) + time.Second
+	}
+	return max(q.Interval, minStep, time.Second)
+}
+
+type metricsSeries struct {
+	labels data.Labels
+	// values holds the span durations in microseconds for each bucket
+	values [][]int64
+	errors []int
+}
+
+// transformMetricsResponse aggregates the spans of a trace search into time series.
+//
+// This is best effort: only the traces returned by the search are counted, so
+// the results are incomplete when the search limit is reached.
+func transformMetricsResponse(traces []TraceResponse, query JaegerQuery, q backend.DataQuery) []*data.Frame {
+	step := metricsStep(q)
+	start := q.TimeRange.From.Truncate(step)
+	buckets := int(q.TimeRange.To.Sub(start)/step) + 1
+
+	series := map[string]*metricsSeries{}
+	for _, trace := range traces {
+		for _, span := range trace.Spans {
+			serviceName := ""
+			if process, ok := trace.Processes[span.ProcessID]; ok {
+				serviceName = process.ServiceName
+			}
+			if query.Service != "" && serviceName != query.Service {
+				continue
+			}
+			if query.Operation != "" && span.OperationName != query.Operation {
+				continue
+			}
+
+			ts := time.UnixMicro(span.StartTime)
+			if ts.Before(q.TimeRange.From) || ts.After(q.TimeRange.To) {
+				continue
+			}
+			idx := int(ts.Sub(start) / step)
+
+			labels := data.Labels{}
+			for _, g := range query.GroupBy {
+				switch g {
+				case groupByService:
+					labels["service"] = serviceName
+				case groupByOperation:
+					labels["operation"] = span.OperationName
+				}
+			}
+
+			key := labels.String()
+			s, ok := series[key]
+			if !ok {
+				s = &metricsSeries{
+					labels: labels,
+					values: make([][]int64, buckets),
+					errors: make([]int, buckets),
+				}
+				series[key] = s
+			}
+			s.values[idx] = append(s.values[idx], span.Duration)
+			if isErrorSpan(span) {
+				s.errors[idx]++
+			}
+		}
+	}
+
+	keys := make([]string, 0, len(series))
+	for k := range series {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	quantile := query.Quantile
+	if quantile == 0 {
+		quantile = defaultQuantile
+	}
+
+	frames := make([]*data.Frame, 0, len(keys))
+	for _, key := range keys {
+		s := series[key]
+		times := make([]time.Time, buckets)
+		values := make([]*float64, buckets)
+		for i := range buckets {
+			times[i] = start.Add(time.Duration(i) * step)
+
+			var v float64
+			switch query.Metric {
+			case metricSpanRate:
+				v = float64(len(s.values[i])) / step.Seconds()
+			case metricErrorRate:
+				v = float64(s.errors[i]) / step.Seconds()
+			case metricDurationQuantile:
+				if len(s.values[i]) == 0 {
+					continue
+				}
+				// durations are reported in seconds like the TraceQL metrics functions
+				v = durationQuantile(quantile, s.values[i]) / float64(time.Second/time.Microsecond)
+			}
+			values[i] = &v
+		}
+
+		valueField := data.NewField(data.TimeSeriesValueFieldName, s.labels, values)
+		if query.Metric == metricDurationQuantile {
+			valueField.Config = &data.FieldConfig{Unit: "s"}
+		}
+
+		frame := data.NewFrame(query.Metric,
+			data.NewField(data.TimeSeriesTimeFieldName, nil, times),
+			valueField,
+		)
+		frame.Meta = &data.FrameMeta{
+			Type:        data.FrameTypeTimeSeriesMulti,
+			TypeVersion: data.FrameTypeVersion{0, 1},
+		}
+		frames = append(frames, frame)
+	}
+
+	if len(traces) >= query.Limit && query.Limit > 0 && len(frames) > 0 {
+		frames[0].AppendNotices(data.Notice{
+			Severity: data.NoticeSeverityWarning,
+			Text:     fmt.Sprintf("The search returned the limit of %d traces, the metrics only include the spans of these traces", query.Limit),
+		})
+	}
+
+	return frames
+}
+
+// isErrorSpan follows the Jaeger UI, which marks spans with an error tag or an OpenTelemetry error status.
+func isErrorSpan(span Span) bool {
+	for _, tag := range span.Tags {
+		switch tag.Key {
+		case "error":
+			if v, ok := tag.Value.(bool); ok && v {
+				return true
+			}
+			if v, ok := tag.Value.(string); ok && strings.EqualFold(v, "true") {
+				return true
+			}
+		case "otel.status_code":
+			if v, ok := tag.Value.(string); ok && v == "ERROR" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// durationQuantile returns the q-quantile of the durations, interpolating
+// linearly between the closest ranks.
+func durationQuantile(q float64, durations []int64) float64 {
+	sorted := make([]float64, len(durations))
+	for i, d := range durations {
+		sorted[i] = float64(d)
+	}
+	sort.Float64s(sorted)
+
+	rank := q * float64(len(sorted)-1)
+	lower := math.Floor(rank)
+	upper := math.Ceil(rank)
+	weight := rank - lower
+	return sorted[int(lower)]*(1-weight) + sorted[int(upper)]*weight
+}
@@ -0,0 +1,105 @@
+package jaeger
+
+import (
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransformMetricsResponse(t *testing.T) {
+	from := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	q := backend.DataQuery{
+		TimeRange: backend.TimeRange{From: from, To: from.Add(2 * time.Minute)},
+		Interval:  time.Minute,
+	}
+	span := func(process, operation string, offset time.Duration, duration int64, tags ...TraceKeyValuePair) Span {
+		return Span{
+			ProcessID:     process,
+			OperationName: operation,
+			StartTime:     from.Add(offset).UnixMicro(),
+			Duration:      duration,
+			Tags:          tags,
+		}
+	}
+	traces := []TraceResponse{
+		{
+			Processes: map[string]TraceProcess{"p1": {ServiceName: "api"}, "p2": {ServiceName: "db"}},
+			Spans: []Span{
+				span("p1", "GET /", 10*time.Second, 1000),
+				span("p1", "GET /", 20*time.Second, 3000, TraceKeyValuePair{Key: "error", Value: true}),
+				span("p2", "select", 30*time.Second, 500),
+				span("p1", "POST /", 70*time.Second, 2000, TraceKeyValuePair{Key: "otel.status_code", Value: "ERROR"}),
+			},
+		},
+	}
+
+	t.Run("span rate grouped by service", func(t *testing.T) {
+		query := JaegerQuery{Metric: metricSpanRate, GroupBy: []string{groupByService}}
+		frames := transformMetricsResponse(traces, query, q)
+		require.Len(t, frames, 2)
+
+		assert.Equal(t, data.Labels{"service": "api"}, frames[0].Fields[1].Labels)
+		assert.Equal(t, data.FrameTypeTimeSeriesMulti, frames[0].Meta.Type)
+		assert.Equal(t, 3, frames[0].Rows())
+		assert.Equal(t, from.Add(time.Minute), frames[0].Fields[0].At(1))
+		assert.InDelta(t, 2.0/60, *frames[0].Fields[1].At(0).(*float64), 1e-9)
+		assert.InDelta(t, 1.0/60, *frames[0].Fields[1].At(1).(*float64), 1e-9)
+		assert.Equal(t, 0.0, *frames[0].Fields[1].At(2).(*float64))
+		assert.Equal(t, data.Labels{"service": "db"}, frames[1].Fields[1].Labels)
+	})
+
+	t.Run("error rate filtered by service", func(t *testing.T) {
+		query := JaegerQuery{Metric: metricErrorRate, Service: "api"}
+		frames := transformMetricsResponse(traces, query, q)
+		require.Len(t, frames, 1)
+		assert.InDelta(t, 1.0/60, *frames[0].Fields[1].At(0).(*float64), 1e-9)
+		assert.InDelta(t, 1.0/60, *frames[0].Fields[1].At(1).(*float64), 1e-9)
+	})
+
+	t.Run("duration quantile grouped by operation", func(t *testing.T) {
+		query := JaegerQuery{Metric: metricDurationQuantile, Quantile: 0.5, Service: "api", GroupBy: []string{groupByOperation}}
+		frames := transformMetricsResponse(traces, query, q)
+		require.Len(t, frames, 2)
+		assert.Equal(t, data.Labels{"operation": "GET /"}, frames[0].Fields[1].Labels)
+		assert.InDelta(t, 0.002, *frames[0].Fields[1].At(0).(*float64), 1e-9)
+		assert.Nil(t, frames[0].Fields[1].At(1))
+		assert.Equal(t, "s", frames[0].Fields[1].Config.Unit)
+	})
+
+	t.Run("raises the interval so a long range fits in max points", func(t *testing.T) {
+		long := backend.DataQuery{
+			TimeRange: backend.TimeRange{From: from, To: from.Add(30 * 24 * time.Hour)},
+			Interval:  time.Second,
+		}
+		longTraces := []TraceResponse{{
+			Processes: map[string]TraceProcess{"p1": {ServiceName: "api"}},
+			Spans: []Span{
+				span("p1", "GET /", 0, 1000),
+				span("p1", "GET /", 30*24*time.Hour, 1000),
+			},
+		}}
+		frames := transformMetricsResponse(longTraces, JaegerQuery{Metric: metricSpanRate}, long)
+		require.Len(t, frames, 1)
+		assert.LessOrEqual(t, frames[0].Rows(), maxMetricsPoints)
+		assert.Greater(t, frames[0].Rows(), maxMetricsPoints/2)
+	})
+
+	t.Run("adds a notice when the search limit is reached", func(t *testing.T) {
+		query := JaegerQuery{Metric: metricSpanRate, Limit: 1}
+		frames := transformMetricsResponse(traces, query, q)
+		require.Len(t, frames, 1)
+		require.Len(t, frames[0].Meta.Notices, 1)
+	})
+}
+
+func TestValidateMetricsQuery(t *testing.T) {
+	assert.NoError(t, validateMetricsQuery(JaegerQuery{Metric: metricSpanRate, GroupBy: []string{groupByService}}))
+	assert.Error(t, validateMetricsQuery(JaegerQuery{Metric: "latency"}))
+	assert.Error(t, validateMetricsQuery(JaegerQuery{Metric: metricDurationQuantile, Quantile: 2}))
+	assert.Error(t, validateMetricsQuery(JaegerQuery{Metric: metricSpanRate, GroupBy: []string{"host"}}))
+	assert.Equal(t, defaultMetricsLimit, metricsSearchQuery(JaegerQuery{}).Limit)
+}
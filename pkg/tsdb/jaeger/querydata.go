@@ -20,6 +20,11 @@ type JaegerQuery struct {
 	MinDuration string `json:"minDuration"`
 	MaxDuration string `json:"maxDuration"`
 	Limit       int    `json:"limit"`
+
+	// Metrics queries aggregate the spans of a search into time series
+	Metric   string   `json:"metric"`
+	Quantile float64  `json:"quantile"`
+	GroupBy  []string `json:"groupBy"`
 }
 
 func queryData(ctx context.Context, dsInfo *datasourceInfo, req *backend.QueryDataRequest) (*backend.QueryDataResponse, error) {
@@ -59,6 +64,22 @@ func queryData(ctx context.Context, dsInfo *datasourceInfo, req *backend.QueryDa
 			}
 		}
 
+		if query.QueryType == "metrics" {
+			if err := validateMetricsQuery(query); err != nil {
+				response.Responses[q.RefID] = backend.ErrorResponseWithErrorSource(backend.DownstreamError(err))
+				continue
+			}
+			searchQuery := metricsSearchQuery(query)
+			traces, err := dsInfo.JaegerClient.Search(&searchQuery, q.TimeRange.From.UnixMicro(), q.TimeRange.To.UnixMicro())
+			if err != nil {
+				response.Responses[q.RefID] = backend.ErrorResponseWithErrorSource(err)
+				continue
+			}
+			response.Responses[q.RefID] = backend.DataResponse{
+				Frames: transformMetricsResponse(traces, searchQuery, q),
+			}
+		}
+
 		// No query type means traceID query
 		if query.QueryType == "" {
 			traces, err := dsInfo.JaegerClient.Trace(ctx, query.Query, q.TimeRange.From.UnixMilli(), q.TimeRange.To.UnixMilli())
@@ -300,6 +321,9 @@ func transformDependenciesResponse(dependencies DependenciesResponse, refID stri
 	nodesFrame := data.NewFrame(refID+"_nodes",
 		data.NewField("id", nil, []string{}),
 		data.NewField("title", nil, []string{}),
+		data.NewField("mainstat", nil, []int64{}).SetConfig(&data.FieldConfig{
+			DisplayName: "Calls received",
+		}),
 	)
 	nodesFrame.Meta = &data.FrameMeta{
 		PreferredVisualization: "nodeGraph",
@@ -326,14 +350,17 @@ func transformDependenciesResponse(dependencies DependenciesResponse, refID stri
 		return []*data.Frame{nodesFrame, edgesFrame}
 	}
 
-	// Create a map to store unique service nodes
-	servicesByName := make(map[string]bool)
+	// Create a map to store unique service nodes with the number of calls they received.
+	// Jaeger does not report errors for dependency links, so only call counts are available.
+	callsByService := make(map[string]int64)
 
 	// Process each dependency
 	for _, dependency := range dependencies.Data {
 		// Add services to the map to track unique services
-		servicesByName[dependency.Parent] = true
-		servicesByName[dependency.Child] = true
+		if _, ok := callsByService[dependency.Parent]; !ok {
+			callsByService[dependency.Parent] = 0
+		}
+		callsByService[dependency.Child] += int64(dependency.CallCount)
 
 		// Add edge data
 		edgesFrame.AppendRow(
@@ -345,8 +372,8 @@ func transformDependenciesResponse(dependencies DependenciesResponse, refID stri
 	}
 
 	// Convert map keys to slice and sort them - this is to ensure the returned nodes are in a consistent order
-	services := make([]string, 0, len(servicesByName))
-	for service := range servicesByName {
+	services := make([]string, 0, len(callsByService))
+	for service := range callsByService {
 		services = append(services, service)
 	}
 	sort.Strings(services)
@@ -356,6 +383,7 @@ func transformDependenciesResponse(dependencies DependenciesResponse, refID stri
 		nodesFrame.AppendRow(
 			service,
 			service,
+			callsByService[service],
 		)
 	}
 
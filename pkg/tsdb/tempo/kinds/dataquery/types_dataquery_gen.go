@@ -127,13 +127,14 @@ const (
 type TempoQueryType string
 
 const (
-	TempoQueryTypeTraceql       TempoQueryType = "traceql"
-	TempoQueryTypeTraceqlSearch TempoQueryType = "traceqlSearch"
-	TempoQueryTypeServiceMap    TempoQueryType = "serviceMap"
-	TempoQueryTypeUpload        TempoQueryType = "upload"
-	TempoQueryTypeNativeSearch  TempoQueryType = "nativeSearch"
-	TempoQueryTypeTraceId       TempoQueryType = "traceId"
-	TempoQueryTypeClear         TempoQueryType = "clear"
+	TempoQueryTypeTraceql        TempoQueryType = "traceql"
+	TempoQueryTypeTraceqlSearch  TempoQueryType = "traceqlSearch"
+	TempoQueryTypeTraceqlMetrics TempoQueryType = "traceqlMetrics"
+	TempoQueryTypeServiceMap     TempoQueryType = "serviceMap"
+	TempoQueryTypeUpload         TempoQueryType = "upload"
+	TempoQueryTypeNativeSearch   TempoQueryType = "nativeSearch"
+	TempoQueryTypeTraceId        TempoQueryType = "traceId"
+	TempoQueryTypeClear          TempoQueryType = "clear"
 )
 
 // The state of the TraceQL streaming search query
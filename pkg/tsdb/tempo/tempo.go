@@ -122,6 +122,13 @@ func (s *Service) QueryData(ctx context.Context, req *backend.QueryDataRequest)
 				return response, err
 			}
 
+		case string(dataquery.TempoQueryTypeTraceqlMetrics):
+			res, err = s.runTraceQlMetricsQuery(ctx, req.PluginContext, q)
+			if err != nil {
+				ctxLogger.Error("Error processing TraceQL metrics query", "error", err)
+				return response, err
+			}
+
 		default:
 			return nil, fmt.Errorf("unsupported query type: '%s' for query with refID '%s'", q.QueryType, q.RefID)
 		}
@@ -42,6 +42,36 @@ func (s *Service) runTraceQlQuery(ctx context.Context, pCtx backend.PluginContex
 	return s.runTraceQlQuerySearch(ctx, pCtx, backendQuery)
 }
 
+// runTraceQlMetricsQuery runs a TraceQL metrics query without streaming, so the
+// resulting time series can be used by alert rules and server side expressions.
+func (s *Service) runTraceQlMetricsQuery(ctx context.Context, pCtx backend.PluginContext, backendQuery backend.DataQuery) (*backend.DataResponse, error) {
+	ctxLogger := s.logger.FromContext(ctx)
+	ctxLogger.Debug("Running TraceQL metrics query", "function", logEntrypoint())
+
+	tempoQuery := &dataquery.TempoQuery{}
+	err := json.Unmarshal(backendQuery.JSON, tempoQuery)
+	if err != nil {
+		ctxLogger.Error("Failed to unmarshall Tempo query model", "error", err, "function", logEntrypoint())
+		return nil, err
+	}
+
+	if tempoQuery.Query == nil || !isMetricsQuery(*tempoQuery.Query) {
+		return &backend.DataResponse{
+			Error:       fmt.Errorf("query is not a TraceQL metrics query, use a metrics function such as rate() or quantile_over_time()"),
+			ErrorSource: backend.ErrorSourceDownstream,
+		}, nil
+	}
+
+	// Default the step to the query interval so the series line up with the
+	// evaluation interval of alert rules.
+	if tempoQuery.Step == nil && backendQuery.Interval > 0 {
+		step := backendQuery.Interval.String()
+		tempoQuery.Step = &step
+	}
+
+	return s.runTraceQlQueryMetrics(ctx, pCtx, backendQuery, tempoQuery)
+}
+
 func (s *Service) runTraceQlQuerySearch(ctx context.Context, pCtx backend.PluginContext, query backend.DataQuery) (*backend.DataResponse, error) {
 	return s.Search(ctx, pCtx, query)
 }
@@ -2,11 +2,19 @@ package tempo
 
 import (
 	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
 	"testing"
+	"time"
 
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/datasource"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/instancemgmt"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
 	"github.com/grafana/grafana/pkg/tsdb/tempo/kinds/dataquery"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestCreateMetricsQuery_Success(t *testing.T) {
@@ -139,3 +147,46 @@ func TestQueryWithInvalidFunction_ReturnsFalse(t *testing.T) {
 	result := isMetricsQuery("{} | invalid_function(foo)")
 	assert.False(t, result)
 }
+
+func TestRunTraceQlMetricsQuery(t *testing.T) {
+	var requestedURL *url.URL
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestedURL = r.URL
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"series":[{"labels":[{"key":"resource.service.name","value":{"stringValue":"api"}}],"samples":[{"timestampMs":"1700000000000","value":2.5}]}]}`))
+	}))
+	defer srv.Close()
+
+	service := &Service{
+		logger: backend.NewLoggerWith("logger", "tsdb.tempo.test"),
+		im: datasource.NewInstanceManager(func(_ context.Context, _ backend.DataSourceInstanceSettings) (instancemgmt.Instance, error) {
+			return &DatasourceInfo{HTTPClient: srv.Client(), URL: srv.URL}, nil
+		}),
+	}
+
+	pCtx := backend.PluginContext{DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{UID: "tempo"}}
+
+	t.Run("returns time series using the query interval as step", func(t *testing.T) {
+		res, err := service.runTraceQlMetricsQuery(context.Background(), pCtx, backend.DataQuery{
+			QueryType: string(dataquery.TempoQueryTypeTraceqlMetrics),
+			Interval:  30 * time.Second,
+			TimeRange: backend.TimeRange{From: time.Unix(1700000000, 0), To: time.Unix(1700003600, 0)},
+			JSON:      []byte(`{"query":"{} | rate() by (resource.service.name)"}`),
+		})
+		require.NoError(t, err)
+		require.NoError(t, res.Error)
+		require.Len(t, res.Frames, 1)
+		assert.Equal(t, data.FrameTypeTimeSeriesMulti, res.Frames[0].Meta.Type)
+		assert.Equal(t, "/api/metrics/query_range", requestedURL.Path)
+		assert.Equal(t, "30s", requestedURL.Query().Get("step"))
+	})
+
+	t.Run("rejects queries without a metrics function", func(t *testing.T) {
+		res, err := service.runTraceQlMetricsQuery(context.Background(), pCtx, backend.DataQuery{
+			JSON: []byte(`{"query":"{ status = error }"}`),
+		})
+		require.NoError(t, err)
+		assert.ErrorContains(t, res.Error, "not a TraceQL metrics query")
+		assert.Equal(t, backend.ErrorSourceDownstream, res.ErrorSource)
+	})
+}
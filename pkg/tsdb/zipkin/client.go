@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"net/http"
 	"net/url"
+	"strconv"
 
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
 	"github.com/grafana/grafana-plugin-sdk-go/backend/log"
@@ -156,6 +157,56 @@ func (z *ZipkinClient) Trace(traceId string) ([]model.SpanModel, error) {
 	return trace, err
 }
 
+// DependencyLink is a call relationship between two services
+// https://zipkin.io/zipkin-api/#/default/get_dependencies
+type DependencyLink struct {
+	Parent     string `json:"parent"`
+	Child      string `json:"child"`
+	CallCount  int64  `json:"callCount"`
+	ErrorCount int64  `json:"errorCount"`
+}
+
+// Dependencies returns the service dependency links for the lookback period (in milliseconds) ending at endTs
+// https://zipkin.io/zipkin-api/#/default/get_dependencies
+func (z *ZipkinClient) Dependencies(endTs int64, lookback int64) ([]DependencyLink, error) {
+	dependencies := []DependencyLink{}
+	params := map[string]string{"endTs": strconv.FormatInt(endTs, 10)}
+	if lookback > 0 {
+		params["lookback"] = strconv.FormatInt(lookback, 10)
+	}
+	dependenciesUrl, err := createZipkinURL(z.url, "/api/v2/dependencies", params)
+	if err != nil {
+		return dependencies, backend.DownstreamError(fmt.Errorf("failed to compose url: %w", err))
+	}
+
+	res, err := z.httpClient.Get(dependenciesUrl)
+	if err != nil {
+		if backend.IsDownstreamHTTPError(err) {
+			return dependencies, backend.DownstreamError(err)
+		}
+		return dependencies, err
+	}
+
+	defer func() {
+		if err = res.Body.Close(); err != nil {
+			z.logger.Error("Failed to close response body", "error", err)
+		}
+	}()
+
+	if res.StatusCode/100 != 2 {
+		err := backend.DownstreamError(fmt.Errorf("request failed: %s", res.Status))
+		if backend.ErrorSourceFromHTTPStatus(res.StatusCode) == backend.ErrorSourceDownstream {
+			return dependencies, backend.DownstreamError(err)
+		}
+		return dependencies, err
+	}
+
+	if err := json.NewDecoder(res.Body).Decode(&dependencies); err != nil {
+		return dependencies, err
+	}
+	return dependencies, nil
+}
+
 func createZipkinURL(baseURL string, path string, params map[string]string) (string, error) {
 	// Parse the base URL
 	finalUrl, err := url.Parse(baseURL)
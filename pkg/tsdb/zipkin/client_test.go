@@ -319,6 +319,53 @@ func TestZipkinClient_Trace(t *testing.T) {
 	}
 }
 
+func TestZipkinClient_Dependencies(t *testing.T) {
+	tests := []struct {
+		name           string
+		mockResponse   string
+		mockStatusCode int
+		expectedResult []DependencyLink
+		expectError    bool
+	}{
+		{
+			name:           "Successful response",
+			mockResponse:   `[{"parent":"frontend","child":"backend","callCount":10,"errorCount":2}]`,
+			mockStatusCode: http.StatusOK,
+			expectedResult: []DependencyLink{{Parent: "frontend", Child: "backend", CallCount: 10, ErrorCount: 2}},
+		},
+		{
+			name:           "Server error",
+			mockResponse:   "",
+			mockStatusCode: http.StatusInternalServerError,
+			expectedResult: []DependencyLink{},
+			expectError:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, "/api/v2/dependencies", r.URL.Path)
+				assert.Equal(t, "1700000000000", r.URL.Query().Get("endTs"))
+				assert.Equal(t, "3600000", r.URL.Query().Get("lookback"))
+				w.WriteHeader(tt.mockStatusCode)
+				_, _ = w.Write([]byte(tt.mockResponse))
+			}))
+			defer server.Close()
+			client, _ := New(server.URL, server.Client(), log.New())
+
+			dependencies, err := client.Dependencies(1700000000000, 3600000)
+			if tt.expectError {
+				assert.Error(t, err)
+				assert.True(t, backend.IsDownstreamError(err))
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.expectedResult, dependencies)
+		})
+	}
+}
+
 func TestCreateZipkinURL(t *testing.T) {
 	tests := []struct {
 		name      string
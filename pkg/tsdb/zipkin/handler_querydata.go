@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"sort"
 
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
 	"github.com/grafana/grafana-plugin-sdk-go/data"
@@ -35,6 +36,23 @@ func queryData(ctx context.Context, dsInfo *datasourceInfo, req *backend.QueryDa
 				Error:       fmt.Errorf("unsupported query type %s. only available in frontend mode", query.QueryType),
 				ErrorSource: backend.ErrorSourcePlugin,
 			}
+		case zipkinQueryTypeDependencyGraph:
+			dependencies, err := dsInfo.ZipkinClient.Dependencies(q.TimeRange.To.UnixMilli(), q.TimeRange.Duration().Milliseconds())
+			if err != nil {
+				es := backend.ErrorSourcePlugin
+				if backend.IsDownstreamError(err) {
+					es = backend.ErrorSourceDownstream
+				}
+				response.Responses[q.RefID] = backend.DataResponse{
+					Error:       err,
+					ErrorSource: es,
+				}
+				continue
+			}
+
+			response.Responses[q.RefID] = backend.DataResponse{
+				Frames: transformDependenciesResponse(dependencies, q.RefID),
+			}
 		default:
 			traces, err := dsInfo.ZipkinClient.Trace(query.Query)
 			if err != nil {
@@ -61,8 +79,9 @@ func queryData(ctx context.Context, dsInfo *datasourceInfo, req *backend.QueryDa
 type zipkinQueryType string
 
 const (
-	zipkinQueryTypeTraceId zipkinQueryType = "traceID"
-	zipkinQueryTypeUpload  zipkinQueryType = "upload"
+	zipkinQueryTypeTraceId         zipkinQueryType = "traceID"
+	zipkinQueryTypeUpload          zipkinQueryType = "upload"
+	zipkinQueryTypeDependencyGraph zipkinQueryType = "dependencyGraph"
 )
 
 type zipkinQuery struct {
@@ -258,3 +277,74 @@ func transformTags(span model.SpanModel) []TraceKeyValuePair {
 
 	return tags
 }
+
+// transformDependenciesResponse returns the nodes and edges frames of the node graph for the dependency links.
+func transformDependenciesResponse(dependencies []DependencyLink, refID string) []*data.Frame {
+	nodesFrame := data.NewFrame(refID+"_nodes",
+		data.NewField("id", nil, []string{}),
+		data.NewField("title", nil, []string{}),
+		data.NewField("mainstat", nil, []int64{}).SetConfig(&data.FieldConfig{
+			DisplayName: "Calls received",
+		}),
+		data.NewField("secondarystat", nil, []int64{}).SetConfig(&data.FieldConfig{
+			DisplayName: "Errors",
+		}),
+	)
+	nodesFrame.Meta = &data.FrameMeta{
+		PreferredVisualization: "nodeGraph",
+	}
+
+	edgesFrame := data.NewFrame(refID+"_edges",
+		data.NewField("id", nil, []string{}),
+		data.NewField("source", nil, []string{}),
+		data.NewField("target", nil, []string{}),
+		data.NewField("mainstat", nil, []int64{}).SetConfig(&data.FieldConfig{
+			DisplayName: "Call count",
+		}),
+		data.NewField("secondarystat", nil, []int64{}).SetConfig(&data.FieldConfig{
+			DisplayName: "Error count",
+		}),
+	)
+	edgesFrame.Meta = &data.FrameMeta{
+		PreferredVisualization: "nodeGraph",
+	}
+
+	type nodeStats struct {
+		calls  int64
+		errors int64
+	}
+	nodes := map[string]*nodeStats{}
+	for _, dependency := range dependencies {
+		if _, ok := nodes[dependency.Parent]; !ok {
+			nodes[dependency.Parent] = &nodeStats{}
+		}
+		child, ok := nodes[dependency.Child]
+		if !ok {
+			child = &nodeStats{}
+			nodes[dependency.Child] = child
+		}
+		child.calls += dependency.CallCount
+		child.errors += dependency.ErrorCount
+
+		edgesFrame.AppendRow(
+			dependency.Parent+"--"+dependency.Child,
+			dependency.Parent,
+			dependency.Child,
+			dependency.CallCount,
+			dependency.ErrorCount,
+		)
+	}
+
+	// Sort the services so the nodes are returned in a consistent order
+	services := make([]string, 0, len(nodes))
+	for service := range nodes {
+		services = append(services, service)
+	}
+	sort.Strings(services)
+
+	for _, service := range services {
+		nodesFrame.AppendRow(service, service, nodes[service].calls, nodes[service].errors)
+	}
+
+	return []*data.Frame{nodesFrame, edgesFrame}
+}
@@ -8,6 +8,7 @@ import (
 	"github.com/openzipkin/zipkin-go/model"
 
 	"github.com/grafana/grafana-plugin-sdk-go/experimental"
+	"github.com/stretchr/testify/require"
 )
 
 func TestTransformResponse(t *testing.T) {
@@ -81,3 +82,24 @@ func TestTransformResponse(t *testing.T) {
 		experimental.CheckGoldenJSONFrame(t, "./testdata", "simple_trace.golden", frames, false)
 	})
 }
+
+func TestTransformDependenciesResponse(t *testing.T) {
+	t.Run("simple_dependencies", func(t *testing.T) {
+		dependencies := []DependencyLink{
+			{Parent: "frontend", Child: "backend", CallCount: 10, ErrorCount: 2},
+			{Parent: "frontend", Child: "auth", CallCount: 5},
+			{Parent: "backend", Child: "auth", CallCount: 3, ErrorCount: 1},
+		}
+
+		frames := transformDependenciesResponse(dependencies, "test")
+		experimental.CheckGoldenJSONFrame(t, "./testdata", "simple_dependencies_nodes.golden", frames[0], false)
+		experimental.CheckGoldenJSONFrame(t, "./testdata", "simple_dependencies_edges.golden", frames[1], false)
+	})
+
+	t.Run("empty_dependencies", func(t *testing.T) {
+		frames := transformDependenciesResponse([]DependencyLink{}, "test")
+		require.Len(t, frames, 2)
+		require.Equal(t, 0, frames[0].Rows())
+		require.Equal(t, 0, frames[1].Rows())
+	})
+}
@@ -4,7 +4,7 @@ import { t } from '@grafana/i18n';
 import { FetchError, getBackendSrv, isFetchError, locationService } from '@grafana/runtime';
 import config from 'app/core/config';
 
-import { LoginDTO, AuthNRedirectDTO } from './types';
+import { LoginDTO, AuthNRedirectDTO, TwoFactorEnrollmentDTO } from './types';
 
 const isOauthEnabled = () => {
   return !!config.oauth && Object.keys(config.oauth).length > 0;
@@ -32,6 +32,21 @@ export interface PasswordlessConfirmationFormModel {
   name?: string;
 }
 
+export interface TwoFactorFormModel {
+  code: string;
+}
+
+export interface TwoFactorChallenge {
+  token: string;
+  enrollmentRequired: boolean;
+  methods: string[];
+  enrollment?: {
+    secret: string;
+    url: string;
+    recoveryCodes: string[];
+  };
+}
+
 interface Props {
   resetCode?: string;
 
@@ -43,6 +58,8 @@ interface Props {
     login: (data: FormModel) => void;
     passwordlessStart: (data: PasswordlessFormModel) => void;
     passwordlessConfirm: (data: PasswordlessConfirmationFormModel) => void;
+    twoFactorLogin: (data: TwoFactorFormModel) => void;
+    twoFactorChallenge: TwoFactorChallenge | undefined;
     showPasswordlessConfirmation: boolean;
     disableLoginForm: boolean;
     disableUserSignUp: boolean;
@@ -59,6 +76,7 @@ interface State {
   isChangingPassword: boolean;
   showDefaultPasswordWarning: boolean;
   loginErrorMessage?: string;
+  twoFactorChallenge?: TwoFactorChallenge;
 }
 
 export class LoginCtrl extends PureComponent<Props, State> {
@@ -121,6 +139,41 @@ export class LoginCtrl extends PureComponent<Props, State> {
           this.changeView(formModel.password === 'admin');
         }
       })
+      .catch((err) => {
+        const challenge = isFetchError(err) ? getTwoFactorChallenge(err) : undefined;
+        if (challenge) {
+          this.startTwoFactor(challenge);
+          return;
+        }
+        const fetchErrorMessage = isFetchError(err) ? getErrorMessage(err) : undefined;
+        this.setState({
+          isLoggingIn: false,
+          loginErrorMessage: fetchErrorMessage || t('login.error.unknown', 'Unknown error occurred'),
+        });
+      });
+  };
+
+  startTwoFactor = (challenge: TwoFactorChallenge) => {
+    if (!challenge.enrollmentRequired) {
+      this.setState({ isLoggingIn: false, twoFactorChallenge: challenge });
+      return;
+    }
+
+    getBackendSrv()
+      .post<TwoFactorEnrollmentDTO>('/api/login/two-factor/enroll', { token: challenge.token }, { showErrorAlert: false })
+      .then((result) => {
+        this.setState({
+          isLoggingIn: false,
+          twoFactorChallenge: {
+            ...challenge,
+            enrollment: {
+              secret: result.Extra.secret,
+              url: result.Extra.url,
+              recoveryCodes: result.Extra.recoveryCodes ? result.Extra.recoveryCodes.split(',') : [],
+            },
+          },
+        });
+      })
       .catch((err) => {
         const fetchErrorMessage = isFetchError(err) ? getErrorMessage(err) : undefined;
         this.setState({
@@ -130,6 +183,34 @@ export class LoginCtrl extends PureComponent<Props, State> {
       });
   };
 
+  twoFactorLogin = (formModel: TwoFactorFormModel) => {
+    const challenge = this.state.twoFactorChallenge;
+    if (!challenge) {
+      return;
+    }
+    this.setState({
+      loginErrorMessage: undefined,
+      isLoggingIn: true,
+    });
+
+    getBackendSrv()
+      .post<LoginDTO>('/api/login/two-factor', { token: challenge.token, code: formModel.code }, { showErrorAlert: false })
+      .then((result) => {
+        this.result = result;
+        this.toGrafana();
+      })
+      .catch((err) => {
+        const fetchErrorMessage = isFetchError(err) ? getErrorMessage(err) : undefined;
+        this.setState({
+          isLoggingIn: false,
+          // The pending login is gone once it expired, so the user has to start again.
+          twoFactorChallenge:
+            isFetchError(err) && err.data?.messageId === 'two-factor.invalid-token' ? undefined : challenge,
+          loginErrorMessage: fetchErrorMessage || t('login.error.unknown', 'Unknown error occurred'),
+        });
+      });
+  };
+
   passwordlessStart = (formModel: PasswordlessFormModel) => {
     this.setState({
       loginErrorMessage: undefined,
@@ -199,8 +280,9 @@ export class LoginCtrl extends PureComponent<Props, State> {
 
   render() {
     const { children } = this.props;
-    const { isLoggingIn, isChangingPassword, showDefaultPasswordWarning, loginErrorMessage } = this.state;
-    const { login, toGrafana, changePassword, passwordlessStart, passwordlessConfirm } = this;
+    const { isLoggingIn, isChangingPassword, showDefaultPasswordWarning, loginErrorMessage, twoFactorChallenge } =
+      this.state;
+    const { login, toGrafana, changePassword, passwordlessStart, passwordlessConfirm, twoFactorLogin } = this;
     const { loginHint, passwordHint, disableLoginForm, disableUserSignUp } = config;
 
     return (
@@ -214,6 +296,8 @@ export class LoginCtrl extends PureComponent<Props, State> {
           login,
           passwordlessStart,
           passwordlessConfirm,
+          twoFactorLogin,
+          twoFactorChallenge,
           showPasswordlessConfirmation: showPasswordlessConfirmation(),
           isLoggingIn,
           changePassword,
@@ -245,6 +329,20 @@ function getErrorMessage(err: FetchError<undefined | { messageId?: string; messa
   }
 }
 
+function getTwoFactorChallenge(
+  err: FetchError<undefined | { messageId?: string; extra?: Partial<TwoFactorChallenge> }>
+): TwoFactorChallenge | undefined {
+  const extra = err.data?.extra;
+  if (err.data?.messageId !== 'two-factor.required' || !extra?.token) {
+    return undefined;
+  }
+  return {
+    token: extra.token,
+    enrollmentRequired: !!extra.enrollmentRequired,
+    methods: extra.methods ?? [],
+  };
+}
+
 function getBootDataErrMessage(str?: string) {
   switch (str) {
     case 'oauth.login.error':
@@ -164,4 +164,41 @@ describe('Login Page', () => {
       'You have exceeded the number of login attempts for this user. Please try again later.'
     );
   });
+
+  it('asks for a two-factor code after the password', async () => {
+    Object.defineProperty(window, 'location', {
+      value: {
+        assign: jest.fn(),
+      },
+    });
+    postMock.mockRejectedValueOnce({
+      data: {
+        message: 'Two-factor authentication required',
+        messageId: 'two-factor.required',
+        statusCode: 401,
+        extra: { token: 'pending', enrollmentRequired: false, methods: ['totp'] },
+      },
+      status: 401,
+      statusText: 'Unauthorized',
+    });
+    postMock.mockResolvedValueOnce({ message: 'Logged in' });
+
+    render(<LoginPage />);
+
+    await userEvent.type(screen.getByLabelText('Email or username'), 'admin');
+    await userEvent.type(screen.getByLabelText('Password'), 'test');
+    await userEvent.click(screen.getByRole('button', { name: 'Log in' }));
+
+    await userEvent.type(await screen.findByLabelText(/Authentication code/), '123456');
+    await userEvent.click(screen.getByRole('button', { name: 'Verify' }));
+
+    await waitFor(() =>
+      expect(postMock).toHaveBeenCalledWith(
+        '/api/login/two-factor',
+        { token: 'pending', code: '123456' },
+        { showErrorAlert: false }
+      )
+    );
+    expect(window.location.assign).toHaveBeenCalledWith('/');
+  });
 });
@@ -17,6 +17,7 @@ import { LoginLayout, InnerBox } from './LoginLayout';
 import { LoginServiceButtons } from './LoginServiceButtons';
 import { PasswordlessConfirmation } from './PasswordlessConfirmationForm';
 import { PasswordlessLoginForm } from './PasswordlessLoginForm';
+import { TwoFactorForm } from './TwoFactorForm';
 import { UserSignup } from './UserSignup';
 
 const LoginPage = () => {
@@ -35,6 +36,8 @@ const LoginPage = () => {
           login,
           passwordlessStart,
           passwordlessConfirm,
+          twoFactorLogin,
+          twoFactorChallenge,
           showPasswordlessConfirmation,
           isLoggingIn,
           changePassword,
@@ -44,7 +47,18 @@ const LoginPage = () => {
           loginErrorMessage,
         }) => (
           <LoginLayout isChangingPassword={isChangingPassword}>
-            {!isChangingPassword && !showPasswordlessConfirmation && (
+            {!isChangingPassword && !showPasswordlessConfirmation && twoFactorChallenge && (
+              <InnerBox>
+                {loginErrorMessage && (
+                  <Alert className={styles.alert} severity="error" title={t('login.error.title', 'Login failed')}>
+                    {loginErrorMessage}
+                  </Alert>
+                )}
+                <TwoFactorForm challenge={twoFactorChallenge} onSubmit={twoFactorLogin} isLoggingIn={isLoggingIn} />
+              </InnerBox>
+            )}
+
+            {!isChangingPassword && !showPasswordlessConfirmation && !twoFactorChallenge && (
               <InnerBox>
                 {loginErrorMessage && (
                   <Alert className={styles.alert} severity="error" title={t('login.error.title', 'Login failed')}>
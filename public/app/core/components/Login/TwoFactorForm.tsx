@@ -0,0 +1,127 @@
+import { css } from '@emotion/css';
+import { useId } from 'react';
+import { useForm } from 'react-hook-form';
+
+import { GrafanaTheme2 } from '@grafana/data';
+import { selectors } from '@grafana/e2e-selectors';
+import { Trans, t } from '@grafana/i18n';
+import { Button, ClipboardButton, Field, Input, Stack, Text, useStyles2 } from '@grafana/ui';
+
+import { TwoFactorChallenge, TwoFactorFormModel } from './LoginCtrl';
+
+interface Props {
+  challenge: TwoFactorChallenge;
+  onSubmit: (data: TwoFactorFormModel) => void;
+  isLoggingIn: boolean;
+}
+
+export const TwoFactorForm = ({ challenge, onSubmit, isLoggingIn }: Props) => {
+  const styles = useStyles2(getStyles);
+  const codeId = useId();
+  const { enrollment } = challenge;
+
+  const {
+    handleSubmit,
+    register,
+    formState: { errors },
+  } = useForm<TwoFactorFormModel>({ mode: 'onChange' });
+
+  const canUseCode = challenge.enrollmentRequired ? !!enrollment : challenge.methods.includes('totp');
+
+  return (
+    <div className={styles.wrapper}>
+      {challenge.enrollmentRequired && enrollment && (
+        <Stack direction="column" gap={1}>
+          <Text element="p">
+            <Trans i18nKey="login.two-factor.enroll-description">
+              Two-factor authentication is required for your account. Add the key below to your authenticator app,
+              then enter the code it shows.
+            </Trans>
+          </Text>
+          <Field label={t('login.two-factor.secret-label', 'Setup key')}>
+            <Input
+              readOnly
+              value={enrollment.secret}
+              addonAfter={
+                <ClipboardButton icon="copy" variant="primary" getText={() => enrollment.url}>
+                  <Trans i18nKey="login.two-factor.copy-url">Copy link</Trans>
+                </ClipboardButton>
+              }
+            />
+          </Field>
+          <Field
+            label={t('login.two-factor.recovery-codes-label', 'Recovery codes')}
+            description={t(
+              'login.two-factor.recovery-codes-description',
+              'Store these codes in a safe place. Each code can be used once instead of an authentication code.'
+            )}
+          >
+            <pre className={styles.recoveryCodes}>{enrollment.recoveryCodes.join('\n')}</pre>
+          </Field>
+        </Stack>
+      )}
+      {!canUseCode && (
+        <Text element="p">
+          <Trans i18nKey="login.two-factor.passkey-only">
+            Your account uses a passkey as its second factor. Sign in with your passkey to continue.
+          </Trans>
+        </Text>
+      )}
+      {canUseCode && (
+        <form onSubmit={handleSubmit(onSubmit)}>
+          <Field
+            label={t('login.two-factor.code-label', 'Authentication code')}
+            description={
+              challenge.enrollmentRequired
+                ? undefined
+                : t(
+                    'login.two-factor.code-description',
+                    'Enter the code from your authenticator app or one of your recovery codes.'
+                  )
+            }
+            invalid={!!errors.code}
+            error={errors.code?.message}
+          >
+            <Input
+              {...register('code', {
+                required: t('login.two-factor.code-required', 'Authentication code is required'),
+              })}
+              id={codeId}
+              autoFocus
+              autoComplete="one-time-code"
+              autoCapitalize="none"
+            />
+          </Field>
+          <Button
+            type="submit"
+            data-testid={selectors.pages.Login.submit}
+            className={styles.submitButton}
+            disabled={isLoggingIn}
+          >
+            {isLoggingIn
+              ? t('login.form.submit-loading-label', 'Logging in...')
+              : t('login.two-factor.submit-label', 'Verify')}
+          </Button>
+        </form>
+      )}
+    </div>
+  );
+};
+
+const getStyles = (theme: GrafanaTheme2) => {
+  return {
+    wrapper: css({
+      width: '100%',
+      paddingBottom: theme.spacing(2),
+    }),
+
+    recoveryCodes: css({
+      margin: 0,
+    }),
+
+    submitButton: css({
+      justifyContent: 'center',
+      width: '100%',
+    }),
+  };
+};
@@ -6,3 +6,12 @@ export interface LoginDTO {
 export interface AuthNRedirectDTO {
   URL: string;
 }
+
+export interface TwoFactorEnrollmentDTO {
+  URL: string;
+  Extra: {
+    secret: string;
+    url: string;
+    recoveryCodes: string;
+  };
+}
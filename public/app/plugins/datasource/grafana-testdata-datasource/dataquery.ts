@@ -25,6 +25,7 @@ export enum TestDataQueryType {
   RandomWalkTable = 'random_walk_table',
   RandomWalkWithError = 'random_walk_with_error',
   RawFrame = 'raw_frame',
+  Replay = 'replay',
   ServerError500 = 'server_error_500',
   Simulation = 'simulation',
   Steps = 'steps',
@@ -54,6 +55,14 @@ export interface PulseWaveQuery {
   timeStep?: number;
 }
 
+export interface ReplayQuery {
+  content?: string;
+  format?: 'ndjson' | 'arrow';
+  loop?: boolean;
+  speed?: number;
+  stream?: boolean;
+}
+
 export interface SimulationQuery {
   config?: Record<string, unknown>;
   key: {
@@ -120,6 +129,7 @@ export interface TestDataDataQuery extends common.DataQuery {
   points?: Array<Array<string | number>>;
   pulseWave?: PulseWaveQuery;
   rawFrameContent?: string;
+  replay?: ReplayQuery;
   scenarioId?: TestDataQueryType;
   seriesCount?: number;
   sim?: SimulationQuery;
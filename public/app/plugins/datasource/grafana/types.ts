@@ -20,6 +20,7 @@ export enum GrafanaQueryType {
   Read = 'read',
   Search = 'search',
   SearchNext = 'searchNext',
+  LiveHistory = 'liveHistory',
 }
 
 export interface GrafanaQuery extends DataQuery {
@@ -28,6 +28,7 @@ export interface InfluxOptions extends DataSourceJsonData {
   // With SQL
   metadata?: Array<Record<string, string>>;
   insecureGrpc?: boolean;
+  rowLimit?: number;
 }
 
 /**
@@ -46,9 +46,13 @@ export type JaegerQuery = {
   minDuration?: string;
   maxDuration?: string;
   limit?: number;
+  // metrics query
+  metric?: 'spanRate' | 'errorRate' | 'durationQuantile';
+  quantile?: number;
+  groupBy?: Array<'service' | 'operation'>;
 } & DataQuery;
 
-export type JaegerQueryType = 'search' | 'upload' | 'dependencyGraph';
+export type JaegerQueryType = 'search' | 'upload' | 'dependencyGraph' | 'metrics';
 
 export type JaegerResponse = {
   data: TraceResponse[];
@@ -83,7 +83,7 @@ export const defaultTempoQuery: Partial<TempoQuery> = {
   groupBy: [],
 };
 
-export type TempoQueryType = ('traceql' | 'traceqlSearch' | 'serviceMap' | 'upload' | 'nativeSearch' | 'traceId' | 'clear');
+export type TempoQueryType = ('traceql' | 'traceqlSearch' | 'traceqlMetrics' | 'serviceMap' | 'upload' | 'nativeSearch' | 'traceId' | 'clear');
 
 export enum MetricsQueryType {
   Instant = 'instant',
@@ -26,7 +26,7 @@ export type ZipkinAnnotation = {
   timestamp: number;
   value: string;
 };
-export type ZipkinQueryType = 'traceID' | 'upload';
+export type ZipkinQueryType = 'traceID' | 'upload' | 'dependencyGraph';
 
 export interface ZipkinQuery extends DataQuery {
   query: string;